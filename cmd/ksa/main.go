@@ -20,8 +20,10 @@ import (
 	// Register built-in plugins
 	_ "github.com/kubestack-ai/kubestack-ai/plugins/elasticsearch"
 	_ "github.com/kubestack-ai/kubestack-ai/plugins/kafka"
+	_ "github.com/kubestack-ai/kubestack-ai/plugins/mongodb"
 	_ "github.com/kubestack-ai/kubestack-ai/plugins/mysql"
 	_ "github.com/kubestack-ai/kubestack-ai/plugins/postgresql"
+	_ "github.com/kubestack-ai/kubestack-ai/plugins/rabbitmq"
 	_ "github.com/kubestack-ai/kubestack-ai/plugins/redis"
 )
 
@@ -3,13 +3,20 @@ package agent
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/kubestack-ai/kubestack-ai/internal/core/execution"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/knowledge"
 	"github.com/kubestack-ai/kubestack-ai/internal/memory"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/alert"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/storage"
 	"github.com/kubestack-ai/kubestack-ai/internal/nlp"
 	ncontext "github.com/kubestack-ai/kubestack-ai/internal/nlp/context"
 	"github.com/kubestack-ai/kubestack-ai/internal/nlp/entity"
 	"github.com/kubestack-ai/kubestack-ai/internal/nlp/intent"
 	"github.com/kubestack-ai/kubestack-ai/internal/planning"
+	"github.com/kubestack-ai/kubestack-ai/internal/tools"
 )
 
 // UserInput represents a user's input.
@@ -23,6 +30,21 @@ type UserInput struct {
 type AgentResponse struct {
 	Text   string
 	Result interface{}
+	// Task is the structured outcome of the handled intent
+	Task *TaskResult
+}
+
+// Services are the subsystems the intent handlers act on. Handlers whose
+// service is nil answer with TaskStatusUnavailable.
+type Services struct {
+	Diagnosis  interfaces.DiagnosisManager
+	Metrics    storage.TimeseriesStore
+	AutoFix    *execution.AutoFixManager
+	FixOptions *execution.AutoFixOptions // Defaults to an approval-gated dry run
+	Approvals  *execution.ApprovalGate   // Holds plans requiring approval; without it they are only reported
+	Silences   *alert.SilenceManager
+	AlertRules *alert.RuleEngine
+	Knowledge  *knowledge.KnowledgeBase
 }
 
 // Agent is the AI agent that processes user input.
@@ -31,6 +53,8 @@ type Agent struct {
 	memoryManager *memory.MemoryManager
 	planEngine    *planning.PlanEngine
 	llmClient     planning.LLMClient
+	toolRegistry  tools.Registry
+	services      *Services
 }
 
 // NewAgent creates a new Agent.
@@ -51,6 +75,16 @@ func NewAgentWithPlanning(nlpProcessor *nlp.NLPProcessor, memoryManager *memory.
 	}
 }
 
+// SetToolRegistry sets the tools generated plans may call.
+func (a *Agent) SetToolRegistry(registry tools.Registry) {
+	a.toolRegistry = registry
+}
+
+// SetServices connects the intent handlers to the given subsystems.
+func (a *Agent) SetServices(services *Services) {
+	a.services = services
+}
+
 // ProcessUserInput processes the user's input and returns a response.
 func (a *Agent) ProcessUserInput(ctx context.Context, input *UserInput) (*AgentResponse, error) {
 	// === 0. Memory Management ===
@@ -78,17 +112,27 @@ func (a *Agent) ProcessUserInput(ctx context.Context, input *UserInput) (*AgentR
 		return nil, fmt.Errorf("NLP processing failed: %w", err)
 	}
 
-	// === 2. Intent Routing ===
-	handler, err := a.routeByIntent(nlpResult.Intent)
+	// === 2. Clarification ===
+	// An answer to a pending question resumes the intent that asked it
+	currentIntent := nlpResult.Intent
+	convCtx := nlpResult.Context
+	if convCtx != nil {
+		if pending := convCtx.TakePending(); pending != nil {
+			currentIntent = resumeIntent(pending, nlpResult, input.Text)
+		}
+	}
+
+	// === 3. Intent Routing ===
+	handler, err := a.routeByIntent(currentIntent)
 	if err != nil {
 		return a.handleUnknownIntent(ctx, nlpResult)
 	}
 
-	// === 3. Task Execution ===
+	// === 4. Task Execution ===
 	taskReq := &TaskRequest{
-		Intent:   nlpResult.Intent,
+		Intent:   currentIntent,
 		Entities: nlpResult.Entities,
-		Context:  nlpResult.Context,
+		Context:  convCtx,
 		RawText:  input.Text,
 	}
 
@@ -97,7 +141,19 @@ func (a *Agent) ProcessUserInput(ctx context.Context, input *UserInput) (*AgentR
 		return nil, err
 	}
 
-	// === 4. Record Assistant Response ===
+	if convCtx != nil {
+		if taskResult.Clarification != nil {
+			convCtx.Ask(taskResult.Clarification)
+		}
+		if n := len(convCtx.Turns); n > 0 {
+			convCtx.Turns[n-1].Response = taskResult.Message
+		}
+		if err := a.nlpProcessor.SaveContext(ctx, input.SessionID, convCtx); err != nil {
+			return nil, fmt.Errorf("failed to save conversation context: %w", err)
+		}
+	}
+
+	// === 5. Record Assistant Response ===
 	if a.memoryManager != nil {
 		assistantEntry := memory.MemoryEntry{
 			Role:    "assistant",
@@ -108,28 +164,61 @@ func (a *Agent) ProcessUserInput(ctx context.Context, input *UserInput) (*AgentR
 		}
 	}
 
-	// === 5. Response Generation ===
+	// === 6. Response Generation ===
 	return &AgentResponse{
 		Text:   taskResult.Message,
 		Result: taskResult.Data,
+		Task:   taskResult,
 	}, nil
 }
 
+// resumeIntent decides whether a turn answers the pending clarification. It
+// does unless the user clearly moved on to another request. A bare value
+// answering a single missing slot is taken as that slot.
+func resumeIntent(pending *ncontext.Clarification, res *nlp.ProcessResult, text string) *intent.Intent {
+	answered := false
+	for _, e := range res.Entities {
+		for _, missing := range pending.Missing {
+			if e.Type == missing {
+				answered = true
+			}
+		}
+	}
+
+	switch {
+	case answered:
+	case res.Intent.Type == pending.Intent.Type:
+	case res.Intent.Type != intent.IntentUnknown:
+		return res.Intent
+	case len(pending.Missing) == 1:
+		value := strings.TrimSpace(text)
+		if value != "" && len(strings.Fields(value)) == 1 {
+			res.Context.SetActiveEntity(entity.Entity{
+				Type:       pending.Missing[0],
+				Value:      value,
+				NormValue:  value,
+				Confidence: 1,
+			})
+		}
+	}
+	return pending.Intent
+}
+
 func (a *Agent) routeByIntent(i *intent.Intent) (TaskHandler, error) {
 	// Basic routing implementation
 	switch i.Type {
 	case intent.IntentDiagnose:
-		return &DiagnoseHandler{}, nil
+		return &DiagnoseHandler{services: a.services}, nil
 	case intent.IntentQuery:
-		return &QueryHandler{}, nil
+		return &QueryHandler{services: a.services}, nil
 	case intent.IntentFix:
-		return &FixHandler{}, nil
+		return &FixHandler{services: a.services}, nil
 	case intent.IntentAlert:
-		return &AlertHandler{}, nil
+		return &AlertHandler{services: a.services}, nil
 	case intent.IntentConfig:
-		return &ConfigHandler{}, nil
+		return &ConfigHandler{services: a.services}, nil
 	case intent.IntentExplain:
-		return &ExplainHandler{}, nil
+		return &ExplainHandler{services: a.services, llmClient: a.llmClient}, nil
 	case intent.IntentHelp:
 		return &HelpHandler{}, nil
 	default:
@@ -174,10 +263,27 @@ type TaskRequest struct {
 	RawText  string
 }
 
+// TaskStatus is the outcome of a task.
+type TaskStatus string
+
+const (
+	TaskStatusCompleted       TaskStatus = "completed"
+	TaskStatusNeedsInput      TaskStatus = "needs_input"
+	TaskStatusPendingApproval TaskStatus = "pending_approval"
+	TaskStatusFailed          TaskStatus = "failed"
+	TaskStatusUnavailable     TaskStatus = "unavailable"
+)
+
 // TaskResult represents the result of a task execution.
 type TaskResult struct {
-	Message string
-	Data    interface{}
+	Intent  intent.IntentType `json:"intent"`
+	Status  TaskStatus        `json:"status"`
+	Message string            `json:"message"`
+	// Params are the slots the task ran with, keyed by entity type
+	Params map[string]string `json:"params,omitempty"`
+	Data   interface{}       `json:"data,omitempty"`
+	// Clarification is set when Status is TaskStatusNeedsInput
+	Clarification *ncontext.Clarification `json:"clarification,omitempty"`
 }
 
 // TaskHandler is the interface for handling tasks.
@@ -185,50 +291,6 @@ type TaskHandler interface {
 	Handle(ctx context.Context, req *TaskRequest) (*TaskResult, error)
 }
 
-// -- Placeholder Handlers --
-
-type DiagnoseHandler struct{}
-
-func (h *DiagnoseHandler) Handle(ctx context.Context, req *TaskRequest) (*TaskResult, error) {
-	return &TaskResult{Message: "Starting diagnosis... (Mock)"}, nil
-}
-
-type QueryHandler struct{}
-
-func (h *QueryHandler) Handle(ctx context.Context, req *TaskRequest) (*TaskResult, error) {
-	return &TaskResult{Message: "Querying metrics... (Mock)"}, nil
-}
-
-type FixHandler struct{}
-
-func (h *FixHandler) Handle(ctx context.Context, req *TaskRequest) (*TaskResult, error) {
-	return &TaskResult{Message: "Executing fix... (Mock)"}, nil
-}
-
-type HelpHandler struct{}
-
-func (h *HelpHandler) Handle(ctx context.Context, req *TaskRequest) (*TaskResult, error) {
-	return &TaskResult{Message: "I can help you diagnose, query, and fix infrastructure issues."}, nil
-}
-
-type AlertHandler struct{}
-
-func (h *AlertHandler) Handle(ctx context.Context, req *TaskRequest) (*TaskResult, error) {
-	return &TaskResult{Message: "Configuring alert... (Mock)"}, nil
-}
-
-type ConfigHandler struct{}
-
-func (h *ConfigHandler) Handle(ctx context.Context, req *TaskRequest) (*TaskResult, error) {
-	return &TaskResult{Message: "Updating config... (Mock)"}, nil
-}
-
-type ExplainHandler struct{}
-
-func (h *ExplainHandler) Handle(ctx context.Context, req *TaskRequest) (*TaskResult, error) {
-	return &TaskResult{Message: "Explaining... (Mock)"}, nil
-}
-
 // ExecutePlan executes a plan and records it in memory
 func (a *Agent) ExecutePlan(ctx context.Context, plan *planning.Plan) (*planning.ExecutionState, error) {
 	if a.planEngine == nil {
@@ -250,48 +312,13 @@ func (a *Agent) ExecutePlan(ctx context.Context, plan *planning.Plan) (*planning
 	return state, err
 }
 
-// CreatePlanFromGoal converts a natural language goal into a structured plan
+// CreatePlanFromGoal converts a natural language goal into a structured plan.
+// The plan has been validated, and its tool calls only use registered tools.
 func (a *Agent) CreatePlanFromGoal(ctx context.Context, goal string) (*planning.Plan, error) {
 	if a.llmClient == nil {
 		return nil, fmt.Errorf("LLM client not initialized")
 	}
-
-	prompt := fmt.Sprintf(`Convert the following goal into a structured execution plan.
-Goal: %s
-
-Create a plan with specific steps. Each step should have:
-- A unique ID
-- A descriptive name
-- A type (ToolCall, LLMQuery, Condition)
-- Any dependencies on other steps
-- The action to perform
-
-Respond with a simple list of steps that can be executed in order.`, goal)
-
-	response, err := a.llmClient.Complete(ctx, prompt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate plan: %w", err)
-	}
-
-	// For now, create a simple mock plan
-	// In a real implementation, this would parse the LLM response
-	plan := planning.NewPlan(
-		fmt.Sprintf("plan-%d", len(response)),
-		"Generated Plan",
-		[]planning.Step{
-			{
-				ID:   "step1",
-				Name: "Initial Step",
-				Type: planning.StepTypeLLMQuery,
-				Action: planning.ActionSpec{
-					Prompt: goal,
-				},
-			},
-		},
-	)
-	plan.Description = goal
-
-	return plan, nil
+	return planning.NewPlanGenerator(a.llmClient, a.toolRegistry).Generate(ctx, goal)
 }
 
 // GetPlanState retrieves the execution state of a plan
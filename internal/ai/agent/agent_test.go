@@ -0,0 +1,289 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/execution"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/alert"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/model"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/storage"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/types"
+	"github.com/kubestack-ai/kubestack-ai/internal/nlp"
+	"github.com/kubestack-ai/kubestack-ai/internal/nlp/entity"
+	redisplugin "github.com/kubestack-ai/kubestack-ai/internal/plugins/builtin/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDiagnosisManager struct {
+	interfaces.DiagnosisManager
+	requests []*models.DiagnosisRequest
+	issues   []*models.Issue
+}
+
+func (m *fakeDiagnosisManager) RunDiagnosis(ctx context.Context, req *models.DiagnosisRequest, progress chan<- interfaces.DiagnosisProgress) (*models.DiagnosisResult, error) {
+	defer close(progress)
+	m.requests = append(m.requests, req)
+	return &models.DiagnosisResult{ID: "diag-1", Status: enum.StatusWarning, Issues: m.issues}, nil
+}
+
+type fakeTimeseriesStore struct {
+	storage.TimeseriesStore
+	query  *storage.Query
+	points []*model.MetricPoint
+}
+
+func (s *fakeTimeseriesStore) Query(ctx context.Context, q *storage.Query) ([]*model.MetricPoint, error) {
+	s.query = q
+	return s.points, nil
+}
+
+func newTestAgent(services *Services) *Agent {
+	a := NewAgent(nlp.NewNLPProcessor(nlp.DefaultConfig(), nil), nil)
+	a.SetServices(services)
+	return a
+}
+
+func ask(t *testing.T, a *Agent, text string) *TaskResult {
+	t.Helper()
+	resp, err := a.ProcessUserInput(context.Background(), &UserInput{Text: text, SessionID: "s1", UserID: "alice"})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Task, "no task result for %q", text)
+	return resp.Task
+}
+
+func TestAgent_ClarifiesThenResumesDiagnosis(t *testing.T) {
+	diag := &fakeDiagnosisManager{}
+	a := newTestAgent(&Services{Diagnosis: diag})
+
+	res := ask(t, a, "帮我诊断一下")
+	assert.Equal(t, TaskStatusNeedsInput, res.Status)
+	require.NotNil(t, res.Clarification)
+	assert.Equal(t, []entity.EntityType{entity.EntityMiddlewareType, entity.EntityInstanceID}, res.Clarification.Missing)
+	assert.Empty(t, diag.requests)
+
+	// The instance name answers both questions
+	res = ask(t, a, "redis-cluster-01 namespace cache")
+	assert.Equal(t, TaskStatusCompleted, res.Status, res.Message)
+	require.Len(t, diag.requests, 1)
+	assert.Equal(t, enum.Redis, diag.requests[0].TargetMiddleware)
+	assert.Equal(t, "redis-cluster-01", diag.requests[0].Instance)
+	assert.Equal(t, "cache", diag.requests[0].Namespace)
+}
+
+func TestAgent_BareAnswerFillsMissingSlot(t *testing.T) {
+	diag := &fakeDiagnosisManager{}
+	a := newTestAgent(&Services{Diagnosis: diag})
+
+	res := ask(t, a, "诊断 mysql 实例")
+	require.Equal(t, TaskStatusNeedsInput, res.Status)
+	assert.Equal(t, []entity.EntityType{entity.EntityInstanceID}, res.Clarification.Missing)
+
+	res = ask(t, a, "orders")
+	assert.Equal(t, TaskStatusCompleted, res.Status, res.Message)
+	require.Len(t, diag.requests, 1)
+	assert.Equal(t, enum.MySQL, diag.requests[0].TargetMiddleware)
+	assert.Equal(t, "orders", diag.requests[0].Instance)
+}
+
+func TestAgent_QueryMetrics(t *testing.T) {
+	now := time.Now()
+	store := &fakeTimeseriesStore{points: []*model.MetricPoint{
+		{Name: "redis_used_memory", Value: 10, Timestamp: now.Add(-2 * time.Minute), Labels: map[string]string{"instance": "redis-0"}},
+		{Name: "redis_used_memory", Value: 30, Timestamp: now.Add(-time.Minute), Labels: map[string]string{"instance": "redis-0"}},
+		{Name: "redis_used_memory", Value: 20, Timestamp: now, Labels: map[string]string{"instance": "redis-0"}},
+	}}
+	a := newTestAgent(&Services{Metrics: store})
+
+	res := ask(t, a, "查看redis-0最近2小时的内存")
+	require.Equal(t, TaskStatusCompleted, res.Status, res.Message)
+	assert.Equal(t, "redis_*memory*", store.query.Metric)
+	assert.Equal(t, map[string]string{"instance": "redis-0"}, store.query.Labels)
+	assert.WithinDuration(t, now.Add(-2*time.Hour), store.query.Start, time.Minute)
+
+	series, ok := res.Data.([]*MetricSeries)
+	require.True(t, ok)
+	require.Len(t, series, 1)
+	assert.Equal(t, 20.0, series[0].Latest)
+	assert.Equal(t, 10.0, series[0].Min)
+	assert.Equal(t, 30.0, series[0].Max)
+	assert.Equal(t, 20.0, series[0].Avg)
+	assert.Equal(t, 3, series[0].Samples)
+}
+
+func TestAgent_SilenceAlerts(t *testing.T) {
+	silences := alert.NewSilenceManager(nil)
+	a := newTestAgent(&Services{Silences: silences})
+
+	res := ask(t, a, "静默redis-0的告警2小时")
+	require.Equal(t, TaskStatusCompleted, res.Status, res.Message)
+	silence, ok := res.Data.(*types.Silence)
+	require.True(t, ok)
+	assert.Equal(t, 2*time.Hour, silence.EndTime.Sub(silence.StartTime))
+	assert.Equal(t, "alice", silence.CreatedBy)
+	assert.True(t, silences.IsSilenced("RedisMemoryHigh", map[string]string{"instance": "redis-0"}))
+	assert.False(t, silences.IsSilenced("RedisMemoryHigh", map[string]string{"instance": "redis-1"}))
+}
+
+func TestAgent_FixRequiresApproval(t *testing.T) {
+	diag := &fakeDiagnosisManager{issues: []*models.Issue{{
+		ID:    "issue-1",
+		Title: "Too many idle connections",
+		Recommendations: []*models.Recommendation{{
+			CanAutoFix: true,
+			Fix:        models.FixAction{ID: "fix-1", Description: "Kill idle clients", Command: "redis-cli CLIENT KILL TYPE normal"},
+		}},
+	}}}
+	a := newTestAgent(&Services{
+		Diagnosis: diag,
+		AutoFix:   execution.NewAutoFixManager(nil, execution.NewInMemoryRecordStore(), nil),
+	})
+
+	res := ask(t, a, "帮我修复redis-0的问题")
+	require.Equal(t, TaskStatusPendingApproval, res.Status, res.Message)
+	plan, ok := res.Data.(*execution.FixPlan)
+	require.True(t, ok)
+	assert.Equal(t, "diag-1", plan.DiagnosisID)
+	assert.Len(t, plan.Actions, 1)
+}
+
+func TestAgent_FixSubmittedForApproval(t *testing.T) {
+	diag := &fakeDiagnosisManager{issues: []*models.Issue{{
+		ID:    "issue-1",
+		Title: "Too many idle connections",
+		Recommendations: []*models.Recommendation{{
+			CanAutoFix: true,
+			Fix:        models.FixAction{ID: "fix-1", Description: "Kill idle clients", Command: "redis-cli CLIENT KILL TYPE normal"},
+		}},
+	}}}
+	autofix := execution.NewAutoFixManager(nil, execution.NewInMemoryRecordStore(), nil)
+	gate := execution.NewApprovalGate(execution.NewInMemoryApprovalStore(), autofix, execution.DefaultApprovalPolicy())
+	a := newTestAgent(&Services{Diagnosis: diag, AutoFix: autofix, Approvals: gate})
+
+	res := ask(t, a, "帮我修复redis-0的问题")
+	require.Equal(t, TaskStatusPendingApproval, res.Status, res.Message)
+	approval, ok := res.Data.(*execution.ApprovalRequest)
+	require.True(t, ok)
+	assert.Equal(t, "alice", approval.RequestedBy)
+	assert.Contains(t, res.Message, "ksa fix approve "+approval.ID)
+
+	pending, err := gate.List(context.Background(), execution.ApprovalStatusPending)
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+}
+
+type fakePluginManager struct {
+	interfaces.PluginManager
+	plugins map[string]interfaces.DiagnosticPlugin
+}
+
+func (m *fakePluginManager) LoadPlugin(name string) (interfaces.DiagnosticPlugin, error) {
+	if p, ok := m.plugins[name]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("plugin %s not found", name)
+}
+
+// runConfigServer serves CONFIG GET/SET, which miniredis does not implement.
+// Values set to ignored parameters do not take.
+func runConfigServer(t *testing.T, config map[string]string, ignored ...string) *miniredis.Miniredis {
+	m := miniredis.RunT(t)
+	var mu sync.Mutex
+	require.NoError(t, m.Server().Register("CONFIG", func(c *server.Peer, cmd string, args []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case len(args) == 2 && strings.EqualFold(args[0], "GET"):
+			value, ok := config[args[1]]
+			if !ok {
+				c.WriteLen(0)
+				return
+			}
+			c.WriteLen(2)
+			c.WriteBulk(args[1])
+			c.WriteBulk(value)
+		case len(args) == 3 && strings.EqualFold(args[0], "SET"):
+			if _, ok := config[args[1]]; !ok {
+				c.WriteError("ERR Unknown option or number of arguments for CONFIG SET - '" + args[1] + "'")
+				return
+			}
+			for _, key := range ignored {
+				if key == args[1] {
+					c.WriteOK()
+					return
+				}
+			}
+			config[args[1]] = args[2]
+			c.WriteOK()
+		default:
+			c.WriteError("ERR unsupported CONFIG subcommand")
+		}
+	}))
+	return m
+}
+
+func TestAgent_ConfigChangeRunsThroughPlugin(t *testing.T) {
+	config := map[string]string{"maxmemory": "1073741824", "maxclients": "10000"}
+	m := runConfigServer(t, config, "maxclients")
+	plugin, err := redisplugin.NewFromAddr(m.Addr())
+	require.NoError(t, err)
+
+	opts := &execution.AutoFixOptions{Enabled: true, MaxRiskLevel: execution.RiskLevelMedium, EnableRollback: true}
+	autofix := execution.NewAutoFixManager(nil, execution.NewInMemoryRecordStore(), opts)
+	autofix.SetFixRunner(execution.NewPluginFixRunner(&fakePluginManager{
+		plugins: map[string]interfaces.DiagnosticPlugin{"redis": plugin},
+	}))
+	a := newTestAgent(&Services{AutoFix: autofix, FixOptions: opts})
+
+	res := ask(t, a, "把redis-0的maxmemory改成4gb")
+	require.Equal(t, TaskStatusCompleted, res.Status, res.Message)
+	result, ok := res.Data.(*execution.FixResult)
+	require.True(t, ok)
+	require.Equal(t, execution.FixExecutionStatusSuccess, result.Status, res.Message)
+	require.Len(t, result.ActionResults, 1)
+	action := result.ActionResults[0]
+	assert.Equal(t, "1073741824", action.Before)
+	assert.Equal(t, "4gb", action.After)
+	assert.Equal(t, "CONFIG SET maxmemory 1073741824", action.Compensation)
+	assert.Equal(t, "4gb", config["maxmemory"])
+
+	// A value the server does not take is set back to the previous one
+	res = ask(t, a, "把redis-0的maxclients改成20000")
+	require.Equal(t, TaskStatusFailed, res.Status, res.Message)
+	assert.Contains(t, res.Message, "verification")
+	assert.Equal(t, "10000", config["maxclients"])
+}
+
+func TestAgent_UnavailableService(t *testing.T) {
+	a := newTestAgent(nil)
+
+	res := ask(t, a, "诊断 redis-0")
+	assert.Equal(t, TaskStatusUnavailable, res.Status)
+	assert.Equal(t, "redis-0", res.Params[string(entity.EntityInstanceID)])
+}
+
+func TestParseThreshold(t *testing.T) {
+	for input, want := range map[string][2]string{
+		"80%":    {">", "80"},
+		"> 1000": {">", "1000"},
+		"<=5":    {"<=", "5"},
+		"低于 10":  {"<", "10"},
+		"超过 0.5": {">", "0.5"},
+	} {
+		op, value, ok := parseThreshold(input)
+		require.True(t, ok, input)
+		assert.Equal(t, want, [2]string{op, value}, input)
+	}
+	_, _, ok := parseThreshold("high")
+	assert.False(t, ok)
+}
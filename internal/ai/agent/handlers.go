@@ -0,0 +1,688 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/execution"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/knowledge"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/model"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/storage"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/types"
+	"github.com/kubestack-ai/kubestack-ai/internal/nlp/entity"
+	"github.com/kubestack-ai/kubestack-ai/internal/planning"
+)
+
+const (
+	defaultQueryWindow     = "1h"
+	defaultSilenceDuration = "1h"
+	maxExplainRules        = 5
+)
+
+// DiagnoseHandler runs a diagnosis of one middleware instance.
+type DiagnoseHandler struct {
+	services *Services
+}
+
+func (h *DiagnoseHandler) Handle(ctx context.Context, req *TaskRequest) (*TaskResult, error) {
+	if res := req.clarify(entity.EntityMiddlewareType, entity.EntityInstanceID); res != nil {
+		return res, nil
+	}
+	params := req.Params(entity.EntityMiddlewareType, entity.EntityInstanceID, entity.EntityNamespace)
+	if h.services == nil || h.services.Diagnosis == nil {
+		return unavailable(req, params, "diagnosis"), nil
+	}
+
+	result, err := runDiagnosis(ctx, h.services.Diagnosis, req)
+	if err != nil {
+		return failed(req, params, "Diagnosis of %s failed: %v", params[string(entity.EntityInstanceID)], err), nil
+	}
+	return &TaskResult{
+		Intent:  req.Intent.Type,
+		Status:  TaskStatusCompleted,
+		Message: summarizeDiagnosis(params[string(entity.EntityInstanceID)], result),
+		Params:  params,
+		Data:    result,
+	}, nil
+}
+
+// QueryHandler reads recent metrics of a middleware from the timeseries store.
+type QueryHandler struct {
+	services *Services
+}
+
+// MetricSeries summarizes the samples of one series in the queried window.
+type MetricSeries struct {
+	Name     string            `json:"name"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Latest   float64           `json:"latest"`
+	Min      float64           `json:"min"`
+	Max      float64           `json:"max"`
+	Avg      float64           `json:"avg"`
+	Samples  int               `json:"samples"`
+	latestAt time.Time
+}
+
+func (h *QueryHandler) Handle(ctx context.Context, req *TaskRequest) (*TaskResult, error) {
+	if res := req.clarify(entity.EntityMiddlewareType); res != nil {
+		return res, nil
+	}
+	params := req.Params(entity.EntityMiddlewareType, entity.EntityInstanceID, entity.EntityMetricName, entity.EntityTimeRange)
+	if h.services == nil || h.services.Metrics == nil {
+		return unavailable(req, params, "metrics store"), nil
+	}
+
+	window, ok := params[string(entity.EntityTimeRange)]
+	if !ok {
+		window = defaultQueryWindow
+		params[string(entity.EntityTimeRange)] = window
+	}
+	start, end, err := parseTimeRange(window, time.Now())
+	if err != nil {
+		return failed(req, params, "Cannot understand time range %q", window), nil
+	}
+
+	mt, _ := req.Middleware()
+	query := &storage.Query{
+		Metric: metricPrefix(mt) + "_*",
+		Start:  start,
+		End:    end,
+	}
+	if metric, ok := params[string(entity.EntityMetricName)]; ok {
+		query.Metric = metricPrefix(mt) + "_*" + metricKeyword(metric) + "*"
+	}
+	if instance, ok := params[string(entity.EntityInstanceID)]; ok {
+		query.Labels = map[string]string{"instance": instance}
+	}
+
+	points, err := h.services.Metrics.Query(ctx, query)
+	if err != nil {
+		return failed(req, params, "Querying metrics failed: %v", err), nil
+	}
+	series := summarizeSeries(points)
+	if len(series) == 0 {
+		return &TaskResult{
+			Intent:  req.Intent.Type,
+			Status:  TaskStatusCompleted,
+			Message: fmt.Sprintf("No %s metrics matching %s in the last %s.", mt, query.Metric, window),
+			Params:  params,
+			Data:    series,
+		}, nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s metrics over %s:", mt, window)
+	for _, s := range series {
+		fmt.Fprintf(&b, "\n- %s: %g (min %g, max %g, avg %.2f over %d samples)", s.Name, s.Latest, s.Min, s.Max, s.Avg, s.Samples)
+	}
+	return &TaskResult{
+		Intent:  req.Intent.Type,
+		Status:  TaskStatusCompleted,
+		Message: b.String(),
+		Params:  params,
+		Data:    series,
+	}, nil
+}
+
+// FixHandler diagnoses an instance and applies the auto-fixable recommendations.
+type FixHandler struct {
+	services *Services
+}
+
+func (h *FixHandler) Handle(ctx context.Context, req *TaskRequest) (*TaskResult, error) {
+	if res := req.clarify(entity.EntityMiddlewareType, entity.EntityInstanceID); res != nil {
+		return res, nil
+	}
+	params := req.Params(entity.EntityMiddlewareType, entity.EntityInstanceID, entity.EntityNamespace)
+	if h.services == nil || h.services.Diagnosis == nil {
+		return unavailable(req, params, "diagnosis"), nil
+	}
+	if h.services.AutoFix == nil {
+		return unavailable(req, params, "auto-fix"), nil
+	}
+
+	result, err := runDiagnosis(ctx, h.services.Diagnosis, req)
+	if err != nil {
+		return failed(req, params, "Diagnosis of %s failed: %v", params[string(entity.EntityInstanceID)], err), nil
+	}
+	if len(result.Issues) == 0 {
+		return &TaskResult{
+			Intent:  req.Intent.Type,
+			Status:  TaskStatusCompleted,
+			Message: fmt.Sprintf("No issues found on %s, nothing to fix.", params[string(entity.EntityInstanceID)]),
+			Params:  params,
+			Data:    result,
+		}, nil
+	}
+	return h.services.applyFix(ctx, req, params, result.ID, result.Issues), nil
+}
+
+// ConfigHandler changes one configuration parameter through the auto-fix
+// lifecycle, so that approval and audit rules apply as for any other fix.
+type ConfigHandler struct {
+	services *Services
+}
+
+var safeConfigValue = regexp.MustCompile(`^[\w.%-]+$`)
+
+func (h *ConfigHandler) Handle(ctx context.Context, req *TaskRequest) (*TaskResult, error) {
+	if res := req.clarify(entity.EntityMiddlewareType, entity.EntityInstanceID, entity.EntityConfigKey, entity.EntityConfigValue); res != nil {
+		return res, nil
+	}
+	params := req.Params(entity.EntityMiddlewareType, entity.EntityInstanceID, entity.EntityNamespace, entity.EntityConfigKey, entity.EntityConfigValue)
+	if h.services == nil || h.services.AutoFix == nil {
+		return unavailable(req, params, "auto-fix"), nil
+	}
+
+	mt, _ := req.Middleware()
+	instance := params[string(entity.EntityInstanceID)]
+	key := params[string(entity.EntityConfigKey)]
+	value := params[string(entity.EntityConfigValue)]
+	if !safeConfigValue.MatchString(key) || !safeConfigValue.MatchString(value) {
+		return failed(req, params, "Refusing to set %q to %q: unexpected characters", key, value), nil
+	}
+	fix, ok := configFix(mt, key, value)
+	if !ok {
+		return failed(req, params, "Changing %s configuration is not supported yet", mt), nil
+	}
+
+	description := fmt.Sprintf("Set %s to %s on %s", key, value, instance)
+	fix.ID = uuid.New().String()
+	fix.Description = description
+	fix.Parameters = map[string]string{
+		"instance":  instance,
+		"namespace": params[string(entity.EntityNamespace)],
+		"key":       key,
+		"value":     value,
+	}
+	issue := &models.Issue{
+		ID:          uuid.New().String(),
+		Source:      "Manual",
+		Title:       description,
+		Severity:    enum.SeverityInfo,
+		Description: req.RawText,
+		Recommendations: []*models.Recommendation{{
+			ID:          uuid.New().String(),
+			Description: description,
+			CanAutoFix:  true,
+			Fix:         fix,
+		}},
+	}
+	return h.services.applyFix(ctx, req, params, "config-"+instance, []*models.Issue{issue}), nil
+}
+
+// configFix returns the action that sets key to value at runtime through the
+// middleware's plugin. The current value is recorded before the change and
+// restored if the new one does not take. Servers normalise some values (4gb
+// reads back as bytes), so any change of the value verifies the fix.
+func configFix(mt enum.MiddlewareType, key, value string) (models.FixAction, bool) {
+	var command, rollback, probe string
+	switch mt {
+	case enum.Redis:
+		command = fmt.Sprintf("CONFIG SET %s %s", key, value)
+		rollback = fmt.Sprintf("CONFIG SET %s {{before}}", key)
+		probe = "CONFIG GET " + key
+	case enum.MySQL:
+		command = fmt.Sprintf("SET GLOBAL %s = %s", key, value)
+		rollback = fmt.Sprintf("SET GLOBAL %s = {{before}}", key)
+		probe = "SELECT @@GLOBAL." + key
+	case enum.PostgreSQL:
+		// ALTER SYSTEM only writes postgresql.auto.conf; the reload applies it
+		command = fmt.Sprintf("ALTER SYSTEM SET %s = '%s'; SELECT pg_reload_conf()", key, value)
+		rollback = fmt.Sprintf("ALTER SYSTEM SET %s = '{{before}}'; SELECT pg_reload_conf()", key)
+		probe = "SHOW " + key
+	default:
+		return models.FixAction{}, false
+	}
+	return models.FixAction{
+		Command:         command,
+		RollbackCommand: rollback,
+		Category:        "ConfigChange",
+		Plugin:          strings.ToLower(mt.String()),
+		Precondition:    &models.FixProbe{Command: probe},
+		Verification: &models.FixProbe{
+			Command: probe,
+			Expect:  fmt.Sprintf("value == %q || value != before", value),
+			Settle:  time.Second,
+		},
+	}, true
+}
+
+// AlertHandler silences alerts or adds threshold alert rules.
+type AlertHandler struct {
+	services *Services
+}
+
+var silenceKeywords = regexp.MustCompile(`(?i)(静默|屏蔽|silence|mute)`)
+
+func (h *AlertHandler) Handle(ctx context.Context, req *TaskRequest) (*TaskResult, error) {
+	if silenceKeywords.MatchString(req.RawText) {
+		return h.silence(ctx, req), nil
+	}
+	return h.addRule(req), nil
+}
+
+func (h *AlertHandler) silence(ctx context.Context, req *TaskRequest) *TaskResult {
+	if res := req.clarify(entity.EntityMiddlewareType); res != nil {
+		return res
+	}
+	params := req.Params(entity.EntityMiddlewareType, entity.EntityInstanceID, entity.EntityTimeRange)
+	if h.services == nil || h.services.Silences == nil {
+		return unavailable(req, params, "alert silencing")
+	}
+
+	// Only the current turn sets the duration; an earlier query window is not meant
+	duration := defaultSilenceDuration
+	for _, e := range req.Entities {
+		if e.Type == entity.EntityTimeRange {
+			duration = slotValue(e)
+		}
+	}
+	params[string(entity.EntityTimeRange)] = duration
+	d, err := parseDuration(duration)
+	if err != nil || d <= 0 {
+		return failed(req, params, "Cannot understand silence duration %q", duration)
+	}
+
+	mt, _ := req.Middleware()
+	labels := map[string]string{"type": metricPrefix(mt)}
+	target := mt.String()
+	if instance, ok := params[string(entity.EntityInstanceID)]; ok {
+		labels = map[string]string{"instance": instance}
+		target = instance
+	}
+	now := time.Now()
+	silence := &types.Silence{
+		ID:        uuid.New().String(),
+		Labels:    labels,
+		StartTime: now,
+		EndTime:   now.Add(d),
+		Comment:   req.RawText,
+	}
+	if req.Context != nil {
+		silence.CreatedBy = req.Context.UserID
+	}
+	if err := h.services.Silences.Add(ctx, silence); err != nil {
+		return failed(req, params, "Silencing alerts failed: %v", err)
+	}
+	return &TaskResult{
+		Intent:  req.Intent.Type,
+		Status:  TaskStatusCompleted,
+		Message: fmt.Sprintf("Silenced alerts for %s until %s.", target, silence.EndTime.Format(time.RFC3339)),
+		Params:  params,
+		Data:    silence,
+	}
+}
+
+func (h *AlertHandler) addRule(req *TaskRequest) *TaskResult {
+	if res := req.clarify(entity.EntityMiddlewareType, entity.EntityMetricName, entity.EntityThreshold); res != nil {
+		return res
+	}
+	params := req.Params(entity.EntityMiddlewareType, entity.EntityInstanceID, entity.EntityMetricName, entity.EntityThreshold)
+	if h.services == nil || h.services.AlertRules == nil {
+		return unavailable(req, params, "alert rules")
+	}
+
+	threshold := params[string(entity.EntityThreshold)]
+	op, value, ok := parseThreshold(threshold)
+	if !ok {
+		return failed(req, params, "Cannot understand threshold %q", threshold)
+	}
+
+	mt, _ := req.Middleware()
+	metric := metricPrefix(mt) + "_" + params[string(entity.EntityMetricName)]
+	name := metric
+	selector := ""
+	if instance, ok := params[string(entity.EntityInstanceID)]; ok {
+		selector = fmt.Sprintf(`{instance=%q}`, instance)
+		name += "_" + strings.ReplaceAll(instance, "-", "_")
+	}
+	rule, err := h.services.AlertRules.AddRule(config.AlertRuleConfig{
+		Name:        name,
+		Expr:        fmt.Sprintf("%s%s %s %s", metric, selector, op, value),
+		Severity:    "warning",
+		Labels:      map[string]string{"source": "agent"},
+		Annotations: map[string]string{"summary": req.RawText},
+	})
+	if err != nil {
+		return failed(req, params, "Adding alert rule failed: %v", err)
+	}
+	return &TaskResult{
+		Intent:  req.Intent.Type,
+		Status:  TaskStatusCompleted,
+		Message: fmt.Sprintf("Added alert rule %s: %s", rule.Name, rule.Expr),
+		Params:  params,
+		Data:    rule,
+	}
+}
+
+// ExplainHandler answers questions from the knowledge base, phrased by the
+// LLM when one is configured.
+type ExplainHandler struct {
+	services  *Services
+	llmClient planning.LLMClient
+}
+
+func (h *ExplainHandler) Handle(ctx context.Context, req *TaskRequest) (*TaskResult, error) {
+	params := req.Params(entity.EntityMiddlewareType, entity.EntityMetricName)
+	var kb *knowledge.KnowledgeBase
+	if h.services != nil {
+		kb = h.services.Knowledge
+	}
+	if kb == nil && h.llmClient == nil {
+		return unavailable(req, params, "knowledge base"), nil
+	}
+
+	var rules []*knowledge.Rule
+	if kb != nil {
+		opts := knowledge.QueryOptions{}
+		if mt, ok := req.Middleware(); ok {
+			opts.MiddlewareType = metricPrefix(mt)
+		}
+		found, err := kb.QueryRules(opts)
+		if err != nil {
+			return failed(req, params, "Querying the knowledge base failed: %v", err), nil
+		}
+		rules = relevantRules(found, params[string(entity.EntityMetricName)])
+	}
+
+	if h.llmClient != nil {
+		answer, err := h.llmClient.Complete(ctx, explainPrompt(req.RawText, rules))
+		if err == nil && strings.TrimSpace(answer) != "" {
+			return &TaskResult{
+				Intent:  req.Intent.Type,
+				Status:  TaskStatusCompleted,
+				Message: strings.TrimSpace(answer),
+				Params:  params,
+				Data:    rules,
+			}, nil
+		}
+	}
+
+	if len(rules) == 0 {
+		return &TaskResult{
+			Intent:  req.Intent.Type,
+			Status:  TaskStatusCompleted,
+			Message: "I found nothing about that in the knowledge base.",
+			Params:  params,
+		}, nil
+	}
+	var b strings.Builder
+	b.WriteString("From the knowledge base:")
+	for _, r := range rules {
+		fmt.Fprintf(&b, "\n- %s: when %s, %s", r.Name, r.Condition, r.Recommendation)
+	}
+	return &TaskResult{
+		Intent:  req.Intent.Type,
+		Status:  TaskStatusCompleted,
+		Message: b.String(),
+		Params:  params,
+		Data:    rules,
+	}, nil
+}
+
+// HelpHandler describes what the agent can do.
+type HelpHandler struct{}
+
+func (h *HelpHandler) Handle(ctx context.Context, req *TaskRequest) (*TaskResult, error) {
+	return &TaskResult{
+		Intent:  req.Intent.Type,
+		Status:  TaskStatusCompleted,
+		Message: "I can help you diagnose, query, and fix infrastructure issues.",
+	}, nil
+}
+
+// applyFix builds a fix plan for the issues and executes it unless it needs
+// approval first.
+func (s *Services) applyFix(ctx context.Context, req *TaskRequest, params map[string]string, diagnosisID string, issues []*models.Issue) *TaskResult {
+	plan, err := s.AutoFix.BuildFixPlan(ctx, diagnosisID, issues, s.fixOptions())
+	if err != nil {
+		return failed(req, params, "Cannot build a fix plan: %v", err)
+	}
+	if plan.RequiresApproval && s.Approvals != nil {
+		requestedBy := ""
+		if req.Context != nil {
+			requestedBy = req.Context.UserID
+		}
+		approval, err := s.Approvals.Submit(ctx, plan, requestedBy)
+		if err != nil {
+			return failed(req, params, "Cannot submit fix plan %s for approval: %v", plan.ID, err)
+		}
+		return &TaskResult{
+			Intent: req.Intent.Type,
+			Status: TaskStatusPendingApproval,
+			Message: fmt.Sprintf("Fix plan %s with %d action(s) (risk: %s) needs %d approval(s) before %s. Approve with `ksa fix approve %s`.",
+				plan.ID, len(plan.Actions), plan.RiskAssessment.Level, approval.RequiredApprovals,
+				approval.ExpiresAt.Format(time.RFC3339), plan.ID),
+			Params: params,
+			Data:   approval,
+		}
+	}
+	if plan.RequiresApproval {
+		return &TaskResult{
+			Intent: req.Intent.Type,
+			Status: TaskStatusPendingApproval,
+			Message: fmt.Sprintf("Fix plan %s with %d action(s) (risk: %s) is waiting for approval.",
+				plan.ID, len(plan.Actions), plan.RiskAssessment.Level),
+			Params: params,
+			Data:   plan,
+		}
+	}
+
+	result, err := s.AutoFix.ExecuteFixPlan(ctx, plan)
+	if err != nil {
+		return failed(req, params, "Fix plan %s failed: %v", plan.ID, err)
+	}
+	message := fmt.Sprintf("Fix plan %s finished with status %s.", plan.ID, result.Status)
+	if plan.DryRun {
+		message = fmt.Sprintf("Fix plan %s simulated (dry run) with status %s.", plan.ID, result.Status)
+	}
+	if _, err := s.AutoFix.RecordExecution(ctx, plan, result, ""); err != nil {
+		message += fmt.Sprintf(" The audit record was not stored: %v", err)
+	}
+	return &TaskResult{
+		Intent:  req.Intent.Type,
+		Status:  TaskStatusCompleted,
+		Message: message,
+		Params:  params,
+		Data:    result,
+	}
+}
+
+func (s *Services) fixOptions() *execution.AutoFixOptions {
+	if s.FixOptions != nil {
+		return s.FixOptions
+	}
+	return &execution.AutoFixOptions{
+		Enabled:          true,
+		DryRun:           true,
+		RequireApproval:  true,
+		MaxRiskLevel:     execution.RiskLevelMedium,
+		TimeoutPerAction: 5 * time.Minute,
+		EnableRollback:   true,
+	}
+}
+
+// runDiagnosis runs a diagnosis for the request's middleware instance.
+func runDiagnosis(ctx context.Context, manager interfaces.DiagnosisManager, req *TaskRequest) (*models.DiagnosisResult, error) {
+	mt, _ := req.Middleware()
+	diagReq := &models.DiagnosisRequest{TargetMiddleware: mt}
+	diagReq.Instance, _ = req.Slot(entity.EntityInstanceID)
+	diagReq.Namespace, _ = req.Slot(entity.EntityNamespace)
+
+	// Progress is not reported to the chat; drain it so the manager never blocks
+	progress := make(chan interfaces.DiagnosisProgress, 16)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case _, ok := <-progress:
+				if !ok {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return manager.RunDiagnosis(ctx, diagReq, progress)
+}
+
+func summarizeDiagnosis(instance string, result *models.DiagnosisResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Diagnosis of %s finished: %s.", instance, result.Status)
+	if result.Summary != "" {
+		fmt.Fprintf(&b, " %s", result.Summary)
+	}
+	if len(result.Issues) == 0 {
+		b.WriteString(" No issues found.")
+		return b.String()
+	}
+	fmt.Fprintf(&b, " Found %d issue(s):", len(result.Issues))
+	for _, issue := range result.Issues {
+		fmt.Fprintf(&b, "\n- [%s] %s", issue.Severity, issue.Title)
+	}
+	return b.String()
+}
+
+// metricKeywords maps normalized metric entities to the part of the collected
+// metric names that identifies them.
+var metricKeywords = map[string]string{
+	"memory_usage": "memory",
+	"disk_usage":   "disk",
+	"slow_query":   "slow",
+	"consumer_lag": "lag",
+}
+
+func metricKeyword(metric string) string {
+	if keyword, ok := metricKeywords[metric]; ok {
+		return keyword
+	}
+	return metric
+}
+
+// metricPrefix is the prefix of the metrics collected for a middleware.
+func metricPrefix(mt enum.MiddlewareType) string {
+	return strings.ToLower(mt.String())
+}
+
+func summarizeSeries(points []*model.MetricPoint) []*MetricSeries {
+	byKey := make(map[string]*MetricSeries)
+	for _, p := range points {
+		key := p.Name + "|" + p.Labels["instance"]
+		s, ok := byKey[key]
+		if !ok {
+			s = &MetricSeries{Name: p.Name, Labels: p.Labels, Min: p.Value, Max: p.Value}
+			byKey[key] = s
+		}
+		if p.Value < s.Min {
+			s.Min = p.Value
+		}
+		if p.Value > s.Max {
+			s.Max = p.Value
+		}
+		if !p.Timestamp.Before(s.latestAt) {
+			s.Latest = p.Value
+			s.latestAt = p.Timestamp
+		}
+		s.Avg += p.Value
+		s.Samples++
+	}
+
+	series := make([]*MetricSeries, 0, len(byKey))
+	for _, s := range byKey {
+		s.Avg /= float64(s.Samples)
+		series = append(series, s)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Name != series[j].Name {
+			return series[i].Name < series[j].Name
+		}
+		return series[i].Labels["instance"] < series[j].Labels["instance"]
+	})
+	return series
+}
+
+var thresholdNumber = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// parseThreshold turns a threshold entity such as "80%", "> 1000" or
+// "低于 10" into a PromQL comparison. Thresholds without an operator are upper
+// bounds.
+func parseThreshold(v string) (string, string, bool) {
+	number := thresholdNumber.FindString(v)
+	if _, err := strconv.ParseFloat(number, 64); err != nil {
+		return "", "", false
+	}
+	op := ">"
+	switch {
+	case strings.Contains(v, ">="):
+		op = ">="
+	case strings.Contains(v, "<="):
+		op = "<="
+	case strings.Contains(v, "<"), strings.Contains(v, "低于"), strings.Contains(v, "小于"):
+		op = "<"
+	}
+	return op, number, true
+}
+
+// relevantRules returns the highest priority rules mentioning the metric.
+func relevantRules(rules []*knowledge.Rule, metric string) []*knowledge.Rule {
+	keyword := strings.ToLower(metricKeyword(metric))
+	var matched []*knowledge.Rule
+	for _, r := range rules {
+		text := strings.ToLower(r.Name + " " + r.Condition + " " + r.Recommendation + " " + strings.Join(r.Tags, " "))
+		if keyword == "" || strings.Contains(text, keyword) {
+			matched = append(matched, r)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].Priority > matched[j].Priority
+	})
+	if len(matched) > maxExplainRules {
+		matched = matched[:maxExplainRules]
+	}
+	return matched
+}
+
+func explainPrompt(question string, rules []*knowledge.Rule) string {
+	var b strings.Builder
+	b.WriteString("You are a middleware operations expert. Answer the question concisely.\n")
+	if len(rules) > 0 {
+		b.WriteString("Relevant knowledge base rules:\n")
+		for _, r := range rules {
+			fmt.Fprintf(&b, "- %s: when %s, %s\n", r.Name, r.Condition, r.Recommendation)
+		}
+	}
+	fmt.Fprintf(&b, "Question: %s", question)
+	return b.String()
+}
+
+func failed(req *TaskRequest, params map[string]string, format string, args ...interface{}) *TaskResult {
+	return &TaskResult{
+		Intent:  req.Intent.Type,
+		Status:  TaskStatusFailed,
+		Message: fmt.Sprintf(format, args...),
+		Params:  params,
+	}
+}
+
+func unavailable(req *TaskRequest, params map[string]string, service string) *TaskResult {
+	return &TaskResult{
+		Intent:  req.Intent.Type,
+		Status:  TaskStatusUnavailable,
+		Message: fmt.Sprintf("The %s service is not available.", service),
+		Params:  params,
+	}
+}
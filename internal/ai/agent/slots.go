@@ -0,0 +1,175 @@
+package agent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	ncontext "github.com/kubestack-ai/kubestack-ai/internal/nlp/context"
+	"github.com/kubestack-ai/kubestack-ai/internal/nlp/entity"
+)
+
+// slotQuestions are asked when a required slot cannot be filled.
+var slotQuestions = map[entity.EntityType]string{
+	entity.EntityMiddlewareType: "Which middleware is it (e.g. redis, mysql, kafka)?",
+	entity.EntityInstanceID:     "Which instance do you mean (e.g. redis-cluster-01)?",
+	entity.EntityNamespace:      "Which namespace is it in?",
+	entity.EntityMetricName:     "Which metric are you interested in (e.g. memory, connections, qps)?",
+	entity.EntityTimeRange:      "For how long (e.g. 30m, 最近2小时)?",
+	entity.EntityThreshold:      "At which threshold (e.g. 80%, > 1000)?",
+	entity.EntityConfigKey:      "Which parameter should be changed (e.g. maxmemory)?",
+	entity.EntityConfigValue:    "Which value should it be set to?",
+}
+
+// middlewareAliases maps short names used in instance names to middleware types.
+var middlewareAliases = map[string]string{
+	"pg":       "postgresql",
+	"postgres": "postgresql",
+	"es":       "elasticsearch",
+	"mongo":    "mongodb",
+	"rabbit":   "rabbitmq",
+}
+
+// Slot returns the value of an entity for the task: from the current turn
+// first, then from entities still active in the conversation.
+func (r *TaskRequest) Slot(entityType entity.EntityType) (string, bool) {
+	for _, e := range r.Entities {
+		if e.Type == entityType {
+			return slotValue(e), true
+		}
+	}
+	if r.Context != nil {
+		if e, ok := r.Context.GetActiveEntity(entityType); ok {
+			return slotValue(e), true
+		}
+	}
+	if entityType == entity.EntityMiddlewareType {
+		if instance, ok := r.Slot(entity.EntityInstanceID); ok {
+			return middlewareFromInstance(instance)
+		}
+	}
+	return "", false
+}
+
+// Middleware returns the middleware type slot.
+func (r *TaskRequest) Middleware() (enum.MiddlewareType, bool) {
+	v, ok := r.Slot(entity.EntityMiddlewareType)
+	if !ok {
+		return -1, false
+	}
+	mt, err := enum.ParseMiddlewareType(v)
+	return mt, err == nil
+}
+
+// Params returns the filled slots among types, keyed by entity type.
+func (r *TaskRequest) Params(types ...entity.EntityType) map[string]string {
+	params := make(map[string]string)
+	for _, t := range types {
+		if v, ok := r.Slot(t); ok {
+			params[string(t)] = v
+		}
+	}
+	return params
+}
+
+// clarify returns a TaskResult asking for the missing slots among required,
+// or nil when all of them are filled.
+func (r *TaskRequest) clarify(required ...entity.EntityType) *TaskResult {
+	var missing []entity.EntityType
+	for _, t := range required {
+		if t == entity.EntityMiddlewareType {
+			if _, ok := r.Middleware(); ok {
+				continue
+			}
+		} else if _, ok := r.Slot(t); ok {
+			continue
+		}
+		missing = append(missing, t)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	questions := make([]string, 0, len(missing))
+	for _, t := range missing {
+		questions = append(questions, slotQuestions[t])
+	}
+	question := strings.Join(questions, " ")
+	return &TaskResult{
+		Intent:  r.Intent.Type,
+		Status:  TaskStatusNeedsInput,
+		Message: question,
+		Params:  r.Params(required...),
+		Clarification: &ncontext.Clarification{
+			Intent:   r.Intent,
+			Missing:  missing,
+			Question: question,
+		},
+	}
+}
+
+func slotValue(e entity.Entity) string {
+	if e.NormValue != "" {
+		return e.NormValue
+	}
+	return e.Value
+}
+
+// middlewareFromInstance infers the middleware from an instance name such as
+// redis-cluster-01 or pg-main.
+func middlewareFromInstance(instance string) (string, bool) {
+	for _, part := range strings.FieldsFunc(strings.ToLower(instance), func(r rune) bool {
+		return r == '-' || r == '_' || r == '.'
+	}) {
+		if alias, ok := middlewareAliases[part]; ok {
+			part = alias
+		}
+		if _, err := enum.ParseMiddlewareType(part); err == nil {
+			return part, true
+		}
+	}
+	return "", false
+}
+
+// parseDuration parses a normalized time range like 30m, 2h, 1d or 1w.
+func parseDuration(v string) (time.Duration, error) {
+	v = strings.TrimSpace(strings.ToLower(v))
+	if n := len(v); n > 1 && (v[n-1] == 'd' || v[n-1] == 'w') {
+		count, err := strconv.Atoi(v[:n-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", v)
+		}
+		day := 24 * time.Hour
+		if v[n-1] == 'w' {
+			day *= 7
+		}
+		return time.Duration(count) * day, nil
+	}
+	return time.ParseDuration(v)
+}
+
+// parseTimeRange resolves a time range slot to absolute bounds.
+func parseTimeRange(v string, now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	// Weeks start on Monday
+	weekStart := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+
+	switch strings.ToLower(v) {
+	case "今天", "today":
+		return today, now, nil
+	case "昨天", "yesterday":
+		return today.AddDate(0, 0, -1), today, nil
+	case "本周":
+		return weekStart, now, nil
+	case "上周":
+		return weekStart.AddDate(0, 0, -7), weekStart, nil
+	}
+
+	d, err := parseDuration(v)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return now.Add(-d), now, nil
+}
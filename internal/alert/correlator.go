@@ -1,15 +1,27 @@
 package alert
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	kgraph "github.com/kubestack-ai/kubestack-ai/internal/knowledge/graph"
+	"github.com/kubestack-ai/kubestack-ai/internal/storage/graph"
 )
 
+// DefaultTopologyHops is how far apart in the dependency graph two alerting
+// instances may be for their alerts to be merged into one incident.
+const DefaultTopologyHops = 2
+
+// topologyTimeout bounds the graph queries made for a single alert.
+const topologyTimeout = 5 * time.Second
+
 // CorrelatedAlert represents a grouped set of alerts.
 type CorrelatedAlert struct {
 	Instance   string
@@ -17,6 +29,15 @@ type CorrelatedAlert struct {
 	Alerts     []*models.AlertEvent
 	Severity   enum.SeverityLevel
 	Summary    string
+
+	// The fields below are set when alerts were correlated over the
+	// dependency graph; Instance and Middleware then describe RootNode.
+	Namespace  string
+	RootNode   string               // graph node ID of the probable root cause
+	Instances  []string             // every alerting instance
+	Symptoms   []*models.AlertEvent // alerts raised on other nodes than the root
+	Confidence float64
+	Evidence   []string
 }
 
 // AlertBucket holds alerts for a specific instance within a time window.
@@ -26,14 +47,23 @@ type AlertBucket struct {
 	FirstSeen   time.Time
 	LastUpdated time.Time
 	Middleware  enum.MiddlewareType
+	// Nodes are the graph nodes the alerting instances resolved to, keyed
+	// by ID. Empty when the alerts could not be placed in the graph.
+	Nodes map[string]*graph.Node
+	// instanceNodes maps each alerting instance to its node ID.
+	instanceNodes map[string]string
 }
 
 // Correlator aggregates alerts.
 type Correlator struct {
 	windowSize  time.Duration
-	buckets     map[string]*AlertBucket // instance -> bucket
+	buckets     map[string]*AlertBucket // instance or root node -> bucket
 	bucketMu    sync.RWMutex
 	onFlush     func(*CorrelatedAlert)
+
+	topology *kgraph.QueryEngine
+	maxHops  int
+	logger   logger.Logger
 }
 
 // NewCorrelator creates a new Correlator.
@@ -42,38 +72,65 @@ func NewCorrelator(windowSize time.Duration, onFlush func(*CorrelatedAlert)) *Co
 		windowSize: windowSize,
 		buckets:    make(map[string]*AlertBucket),
 		onFlush:    onFlush,
+		maxHops:    DefaultTopologyHops,
+		logger:     logger.NewLogger("alert-correlator"),
 	}
 	go c.flushLoop()
 	return c
 }
 
-// AddAlert adds an alert to the correlator.
-func (c *Correlator) AddAlert(event *models.AlertEvent) (bool, *CorrelatedAlert) {
+// SetTopology makes the correlator merge alerts of instances that are
+// connected within maxHops dependency edges into one incident, and pick
+// the probable root cause of each incident from the graph.
+func (c *Correlator) SetTopology(q *kgraph.QueryEngine, maxHops int) {
+	if maxHops <= 0 {
+		maxHops = DefaultTopologyHops
+	}
 	c.bucketMu.Lock()
 	defer c.bucketMu.Unlock()
+	c.topology = q
+	c.maxHops = maxHops
+}
+
+// AddAlert adds an alert to the correlator.
+func (c *Correlator) AddAlert(event *models.AlertEvent) (bool, *CorrelatedAlert) {
+	// Graph lookups may be remote, so they are made before taking the lock
+	node, reach := c.locate(event)
 
+	c.bucketMu.Lock()
 	key := event.Instance
-	bucket, exists := c.buckets[key]
-	if !exists {
+	var bucket *AlertBucket
+	if node != nil {
+		key, bucket = c.mergeConnected(node, reach)
+	}
+	if bucket == nil {
+		bucket = c.buckets[key]
+	}
+	if bucket == nil {
 		mwType := c.inferMiddlewareType(event)
 		bucket = &AlertBucket{
-			Instance:    event.Instance,
-			Alerts:      []*models.AlertEvent{},
-			FirstSeen:   time.Now(),
-			Middleware:  mwType,
+			Instance:      event.Instance,
+			Alerts:        []*models.AlertEvent{},
+			FirstSeen:     time.Now(),
+			Middleware:    mwType,
+			Nodes:         make(map[string]*graph.Node),
+			instanceNodes: make(map[string]string),
 		}
 		c.buckets[key] = bucket
 	}
 
 	bucket.Alerts = append(bucket.Alerts, event)
 	bucket.LastUpdated = time.Now()
+	if node != nil {
+		bucket.Nodes[node.ID] = node
+		bucket.instanceNodes[event.Instance] = node.ID
+	}
 
 	// If critical, trigger immediately but keep bucket for context?
 	// Or just return immediately.
 	if event.Severity == enum.SeverityCritical {
 		// Flush immediately for this alert? Or return it as correlated single.
 		// To keep simple: treat as correlated immediately.
-		correlated := c.buildCorrelatedAlert(bucket)
 		// We might want to remove bucket or keep it?
 		// If we trigger now, we should probably clear the bucket to avoid double trigger later?
 		// Or maybe we just return it and let the bucket accumulate more?
@@ -81,12 +138,89 @@ func (c *Correlator) AddAlert(event *models.AlertEvent) (bool, *CorrelatedAlert)
 		// For simplicity, critical alerts bypass aggregation window wait, but include currently aggregated context.
 		// And we clear bucket to restart aggregation.
 		delete(c.buckets, key)
-		return true, correlated
+		c.bucketMu.Unlock()
+		return true, c.buildCorrelatedAlert(bucket)
 	}
 
+	c.bucketMu.Unlock()
 	return false, nil
 }
 
+// locate resolves the instance of an alert to its graph node and returns
+// the IDs of the nodes within reach of it, the node itself included.
+func (c *Correlator) locate(event *models.AlertEvent) (*graph.Node, map[string]bool) {
+	c.bucketMu.RLock()
+	q, hops := c.topology, c.maxHops
+	c.bucketMu.RUnlock()
+	if q == nil {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), topologyTimeout)
+	defer cancel()
+	node, err := q.ResolveNode(ctx, event.Labels["namespace"], event.Instance)
+	if err != nil {
+		if err != graph.ErrNodeNotFound {
+			c.logger.Warnf("Failed to resolve %s in the dependency graph: %v", event.Instance, err)
+		}
+		return nil, nil
+	}
+	reach := map[string]bool{node.ID: true}
+	connected, err := q.ConnectedWithin(ctx, node.ID, hops)
+	if err != nil {
+		c.logger.Warnf("Failed to query neighbours of %s: %v", node.ID, err)
+	}
+	for _, n := range connected {
+		reach[n.ID] = true
+	}
+	return node, reach
+}
+
+// mergeConnected merges every bucket holding a node in reach into the
+// oldest of them and returns it, or nil if no bucket is in reach. The
+// caller must hold bucketMu.
+func (c *Correlator) mergeConnected(node *graph.Node, reach map[string]bool) (string, *AlertBucket) {
+	var keys []string
+	for key, b := range c.buckets {
+		for id := range b.Nodes {
+			if reach[id] {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return "node:" + node.ID, nil
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		bi, bj := c.buckets[keys[i]], c.buckets[keys[j]]
+		if !bi.FirstSeen.Equal(bj.FirstSeen) {
+			return bi.FirstSeen.Before(bj.FirstSeen)
+		}
+		return keys[i] < keys[j]
+	})
+
+	target := c.buckets[keys[0]]
+	for _, key := range keys[1:] {
+		b := c.buckets[key]
+		target.Alerts = append(target.Alerts, b.Alerts...)
+		for id, n := range b.Nodes {
+			target.Nodes[id] = n
+		}
+		for inst, id := range b.instanceNodes {
+			target.instanceNodes[inst] = id
+		}
+		if b.LastUpdated.After(target.LastUpdated) {
+			target.LastUpdated = b.LastUpdated
+		}
+		delete(c.buckets, key)
+	}
+	sort.SliceStable(target.Alerts, func(i, j int) bool {
+		return target.Alerts[i].StartsAt.Before(target.Alerts[j].StartsAt)
+	})
+	return keys[0], target
+}
+
 func (c *Correlator) flushLoop() {
 	ticker := time.NewTicker(time.Second * 10)
 	for range ticker.C {
@@ -96,18 +230,24 @@ func (c *Correlator) flushLoop() {
 
 func (c *Correlator) flushExpiredBuckets() {
 	c.bucketMu.Lock()
-	defer c.bucketMu.Unlock()
-
 	now := time.Now()
+	var expired []*AlertBucket
 	for key, bucket := range c.buckets {
 		if now.Sub(bucket.LastUpdated) > c.windowSize {
-			correlated := c.buildCorrelatedAlert(bucket)
-			if c.onFlush != nil {
-				c.onFlush(correlated)
-			}
+			expired = append(expired, bucket)
 			delete(c.buckets, key)
 		}
 	}
+	c.bucketMu.Unlock()
+
+	// Root cause analysis and the flush callback run without the lock so
+	// that incoming alerts are not blocked on them
+	for _, bucket := range expired {
+		correlated := c.buildCorrelatedAlert(bucket)
+		if c.onFlush != nil {
+			c.onFlush(correlated)
+		}
+	}
 }
 
 func (c *Correlator) buildCorrelatedAlert(bucket *AlertBucket) *CorrelatedAlert {
@@ -130,13 +270,135 @@ func (c *Correlator) buildCorrelatedAlert(bucket *AlertBucket) *CorrelatedAlert
 		summaries = append(summaries, a.Summary)
 	}
 
-	return &CorrelatedAlert{
+	correlated := &CorrelatedAlert{
 		Instance:   bucket.Instance,
 		Middleware: bucket.Middleware,
 		Alerts:     bucket.Alerts,
 		Severity:   maxSeverity,
 		Summary:    fmt.Sprintf("%d alerts on %s: %s", len(bucket.Alerts), bucket.Instance, strings.Join(uniqueStrings(summaries), "; ")),
 	}
+	if len(bucket.Nodes) > 0 {
+		c.attachRootCause(correlated, bucket, summaries)
+	}
+	return correlated
+}
+
+// attachRootCause picks the probable root of an incident spanning graph
+// nodes: the node upstream of the most alerting nodes, preferring nodes
+// that alert themselves, then refined by TraceRootCause which follows
+// unhealthy dependencies. Alerts raised elsewhere become symptoms.
+func (c *Correlator) attachRootCause(correlated *CorrelatedAlert, bucket *AlertBucket, summaries []string) {
+	c.bucketMu.RLock()
+	q, hops := c.topology, c.maxHops
+	c.bucketMu.RUnlock()
+	if q == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), topologyTimeout)
+	defer cancel()
+
+	// Severity of the alerts on each node
+	alerting := make(map[string]enum.SeverityLevel)
+	for _, a := range bucket.Alerts {
+		if id, ok := bucket.instanceNodes[a.Instance]; ok {
+			if sev, seen := alerting[id]; !seen || isMoreSevere(a.Severity, sev) {
+				alerting[id] = a.Severity
+			}
+		}
+	}
+
+	// A dependency of the alerting nodes may be the root without alerting
+	candidates := make(map[string]*graph.Node)
+	for id, n := range bucket.Nodes {
+		candidates[id] = n
+		deps, err := q.Dependencies(ctx, id, hops)
+		if err != nil {
+			c.logger.Warnf("Failed to query dependencies of %s: %v", id, err)
+			continue
+		}
+		for _, d := range deps {
+			candidates[d.ID] = d
+		}
+	}
+
+	type scored struct {
+		node  *graph.Node
+		score int
+	}
+	var ranked []scored
+	for id, n := range candidates {
+		score := 0
+		if _, ok := alerting[id]; ok {
+			score++
+		}
+		impact, err := q.FindImpactedServices(ctx, id, hops)
+		if err != nil {
+			c.logger.Warnf("Failed to analyse impact of %s: %v", id, err)
+		} else {
+			for _, m := range impact.ImpactedNodes {
+				if _, ok := alerting[m.ID]; ok {
+					score++
+				}
+			}
+		}
+		ranked = append(ranked, scored{node: n, score: score})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		sa, alertA := alerting[a.node.ID]
+		sb, alertB := alerting[b.node.ID]
+		if alertA != alertB {
+			return alertA
+		}
+		if isMoreSevere(sa, sb) || isMoreSevere(sb, sa) {
+			return isMoreSevere(sa, sb)
+		}
+		if a.node.Type != b.node.Type {
+			return a.node.Type == graph.NodeTypeMiddleware
+		}
+		return a.node.ID < b.node.ID
+	})
+
+	root := ranked[0].node
+	correlated.Confidence = float64(ranked[0].score) / float64(len(alerting))
+	correlated.Evidence = []string{fmt.Sprintf("%s is upstream of %d of %d alerting instances",
+		root.ID, ranked[0].score, len(alerting))}
+	if rc, err := q.TraceRootCause(ctx, root.ID); err == nil && rc.RootCauseNode != nil && rc.RootCauseNode.ID != root.ID {
+		root = rc.RootCauseNode
+		correlated.Confidence = rc.Confidence
+		correlated.Evidence = append(correlated.Evidence, rc.Evidence...)
+	}
+
+	correlated.RootNode = root.ID
+	correlated.Namespace = root.Namespace
+	correlated.Instance = root.Name
+	correlated.Symptoms = nil
+	for _, a := range bucket.Alerts {
+		if bucket.instanceNodes[a.Instance] == root.ID {
+			// Diagnose the instance the alerts name, e.g. a pod or endpoint
+			correlated.Instance = a.Instance
+			if mw := c.inferMiddlewareType(a); mw >= 0 {
+				correlated.Middleware = mw
+			}
+		} else {
+			correlated.Symptoms = append(correlated.Symptoms, a)
+		}
+	}
+	for inst := range bucket.instanceNodes {
+		correlated.Instances = append(correlated.Instances, inst)
+	}
+	sort.Strings(correlated.Instances)
+
+	if mw, ok := root.Properties["middleware_type"].(string); ok {
+		if t, err := enum.ParseMiddlewareType(mw); err == nil {
+			correlated.Middleware = t
+		}
+	}
+	correlated.Summary = fmt.Sprintf("%d alerts on %d instances, probable root %s: %s",
+		len(bucket.Alerts), len(correlated.Instances), correlated.Instance, strings.Join(uniqueStrings(summaries), "; "))
 }
 
 func (c *Correlator) inferMiddlewareType(event *models.AlertEvent) enum.MiddlewareType {
@@ -0,0 +1,140 @@
+package alert
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	kgraph "github.com/kubestack-ai/kubestack-ai/internal/knowledge/graph"
+	"github.com/kubestack-ai/kubestack-ai/internal/storage/graph"
+	"github.com/kubestack-ai/kubestack-ai/internal/storage/graph/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	cacheID = kgraph.GenerateID(graph.NodeTypeMiddleware, "prod", "cache")
+	dbID    = kgraph.GenerateID(graph.NodeTypeMiddleware, "prod", "db")
+	svcAID  = kgraph.GenerateID(graph.NodeTypeService, "prod", "svc-a")
+	svcBID  = kgraph.GenerateID(graph.NodeTypeService, "prod", "svc-b")
+)
+
+// newTopologyCorrelator returns a correlator over a graph where svc-a
+// depends on the Redis "cache", svc-c on the MySQL "db", and svc-b on both.
+// With one hop, cache and db are only connected through svc-b.
+func newTopologyCorrelator(t *testing.T) *Correlator {
+	ctx := context.Background()
+	store := memory.NewMemoryGraphStore()
+	b := kgraph.NewBuilder(store)
+	require.NoError(t, b.AddMiddleware(ctx, "prod", "cache", "redis"))
+	require.NoError(t, b.AddMiddleware(ctx, "prod", "db", "mysql"))
+	for svc, deps := range map[string][]string{"svc-a": {cacheID}, "svc-b": {cacheID, dbID}, "svc-c": {dbID}} {
+		require.NoError(t, b.AddService(ctx, "prod", svc))
+		for _, dep := range deps {
+			require.NoError(t, b.AddDependency(ctx, kgraph.GenerateID(graph.NodeTypeService, "prod", svc), dep))
+		}
+	}
+
+	c := NewCorrelator(time.Minute, nil)
+	c.SetTopology(kgraph.NewQueryEngine(store), 1)
+	return c
+}
+
+func prodAlert(name, instance string, severity enum.SeverityLevel, startsAt time.Time) *models.AlertEvent {
+	return &models.AlertEvent{
+		Name:     name,
+		Instance: instance,
+		Severity: severity,
+		Summary:  name + " on " + instance,
+		Labels:   map[string]string{"namespace": "prod"},
+		StartsAt: startsAt,
+	}
+}
+
+func TestMergeConnected_BridgingAlertMergesBuckets(t *testing.T) {
+	c := newTopologyCorrelator(t)
+	now := time.Now()
+
+	c.AddAlert(prodAlert("RedisSlow", "cache", enum.SeverityWarning, now))
+	c.AddAlert(prodAlert("MySQLSlow", "db", enum.SeverityWarning, now.Add(-time.Minute)))
+	require.Len(t, c.buckets, 2, "cache and db are two hops apart")
+
+	// svc-b is one hop from both, so its alert joins the two incidents
+	triggered, _ := c.AddAlert(prodAlert("Errors", "svc-b", enum.SeverityWarning, now.Add(time.Second)))
+	assert.False(t, triggered)
+	require.Len(t, c.buckets, 1)
+	bucket, ok := c.buckets["node:"+cacheID]
+	require.True(t, ok, "the merged incident keeps the key of the oldest bucket")
+	assert.Len(t, bucket.Nodes, 3)
+	assert.Equal(t, map[string]string{"cache": cacheID, "db": dbID, "svc-b": svcBID}, bucket.instanceNodes)
+	require.Len(t, bucket.Alerts, 3)
+	assert.Equal(t, "MySQLSlow", bucket.Alerts[0].Name, "alerts are ordered by start time")
+	assert.Equal(t, "RedisSlow", bucket.Alerts[1].Name)
+	assert.Equal(t, "Errors", bucket.Alerts[2].Name)
+}
+
+func TestAddAlert_CriticalFlushKeepsGraphContext(t *testing.T) {
+	c := newTopologyCorrelator(t)
+	now := time.Now()
+
+	c.AddAlert(prodAlert("HighLatency", "svc-a-7d9f8b6c5-xk2lp", enum.SeverityWarning, now))
+	triggered, correlated := c.AddAlert(prodAlert("RedisDown", "cache", enum.SeverityCritical, now.Add(time.Second)))
+
+	// The critical alert is not held for the window, yet the incident it
+	// flushes still names the root and the symptoms found over the graph
+	require.True(t, triggered)
+	require.NotNil(t, correlated)
+	assert.Empty(t, c.buckets)
+	assert.Equal(t, cacheID, correlated.RootNode)
+	assert.Equal(t, "cache", correlated.Instance)
+	assert.Equal(t, "prod", correlated.Namespace)
+	assert.Equal(t, enum.Redis, correlated.Middleware)
+	assert.Equal(t, enum.SeverityCritical, correlated.Severity)
+	assert.Equal(t, []string{"cache", "svc-a-7d9f8b6c5-xk2lp"}, correlated.Instances)
+	require.Len(t, correlated.Symptoms, 1)
+	assert.Equal(t, "HighLatency", correlated.Symptoms[0].Name)
+	assert.InDelta(t, 1.0, correlated.Confidence, 0.001)
+
+	// Later alerts start a new incident
+	c.AddAlert(prodAlert("HighLatency", "svc-a-7d9f8b6c5-xk2lp", enum.SeverityWarning, now.Add(2*time.Second)))
+	require.Len(t, c.buckets, 1)
+	for _, bucket := range c.buckets {
+		assert.Len(t, bucket.Alerts, 1)
+	}
+}
+
+func TestAttachRootCause_AlertingNodeWinsTie(t *testing.T) {
+	c := newTopologyCorrelator(t)
+	svcA, err := c.topology.ResolveNode(context.Background(), "prod", "svc-a")
+	require.NoError(t, err)
+
+	// svc-a alerts and cache does not; both explain the one alerting node
+	bucket := &AlertBucket{
+		Alerts:        []*models.AlertEvent{prodAlert("Errors", "svc-a", enum.SeverityWarning, time.Now())},
+		Nodes:         map[string]*graph.Node{svcAID: svcA},
+		instanceNodes: map[string]string{"svc-a": svcAID},
+	}
+	correlated := &CorrelatedAlert{Instance: "svc-a", Middleware: enum.MiddlewareType(-1)}
+	c.attachRootCause(correlated, bucket, []string{"Errors on svc-a"})
+
+	assert.Equal(t, svcAID, correlated.RootNode)
+	assert.Equal(t, "svc-a", correlated.Instance)
+	assert.Empty(t, correlated.Symptoms)
+	assert.Equal(t, []string{"svc-a"}, correlated.Instances)
+
+	// Once the cache explains a second alerting node it outranks them
+	svcB, err := c.topology.ResolveNode(context.Background(), "prod", "svc-b")
+	require.NoError(t, err)
+	bucket.Alerts = append(bucket.Alerts, prodAlert("Errors", "svc-b", enum.SeverityCritical, time.Now()))
+	bucket.Nodes[svcBID] = svcB
+	bucket.instanceNodes["svc-b"] = svcBID
+	correlated = &CorrelatedAlert{}
+	c.attachRootCause(correlated, bucket, []string{"Errors on svc-a", "Errors on svc-b"})
+
+	assert.Equal(t, cacheID, correlated.RootNode)
+	assert.Equal(t, "cache", correlated.Instance)
+	assert.Equal(t, enum.Redis, correlated.Middleware)
+	assert.Len(t, correlated.Symptoms, 2)
+}
@@ -17,6 +17,9 @@ import (
 type DispatcherConfig struct {
 	DedupWindow  time.Duration
 	CorrelationWindow time.Duration
+	// TopologyHops is how many dependency edges apart alerting instances
+	// may be to be correlated into one incident.
+	TopologyHops int
 }
 
 // Dispatcher routes alerts to the diagnosis engine.
@@ -101,12 +104,19 @@ func (d *Dispatcher) cleanupExpiredCache() {
 
 // TriggerDiagnosis is public so Correlator can call it (via closure or direct call).
 func (d *Dispatcher) TriggerDiagnosis(ctx context.Context, alert *CorrelatedAlert) error {
-	d.logger.Infof("Triggering diagnosis for correlated alert on %s", alert.Instance)
+	if alert.RootNode != "" {
+		d.logger.Infof("Triggering diagnosis for correlated alert on %s (root %s, %d symptom alerts on %d instances)",
+			alert.Instance, alert.RootNode, len(alert.Symptoms), len(alert.Instances))
+	} else {
+		d.logger.Infof("Triggering diagnosis for correlated alert on %s", alert.Instance)
+	}
 
 	// Create DiagnosisRequest
 	req := &models.DiagnosisRequest{
 		TargetMiddleware: alert.Middleware,
+		Namespace:        alert.Namespace,
 		Instance:         alert.Instance,
+		Symptoms:         alert.Symptoms,
 	}
 
 	// We run diagnosis asynchronously
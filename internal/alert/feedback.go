@@ -50,6 +50,12 @@ func (f *FeedbackProcessor) buildNotificationMessage(alert *CorrelatedAlert, res
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("**Instance**: %s\n", alert.Instance))
 	sb.WriteString(fmt.Sprintf("**Alerts**: %s\n", alert.Summary))
+	if alert.RootNode != "" {
+		sb.WriteString(fmt.Sprintf("**Probable Root Cause**: %s (confidence %.0f%%)\n", alert.RootNode, alert.Confidence*100))
+		if len(alert.Symptoms) > 0 {
+			sb.WriteString(fmt.Sprintf("**Symptoms**: %d alerts on %s\n", len(alert.Symptoms), strings.Join(symptomInstances(alert), ", ")))
+		}
+	}
 	sb.WriteString(fmt.Sprintf("**Diagnosis**: %s\n", result.Summary))
 
 	if len(result.Issues) > 0 {
@@ -74,3 +80,12 @@ func (f *FeedbackProcessor) buildNotificationMessage(alert *CorrelatedAlert, res
 		Link:     fmt.Sprintf("http://dashboard-url/diagnosis/%s", result.ID), // TODO: Configurable URL
 	}
 }
+
+// symptomInstances lists the instances the symptom alerts were raised on.
+func symptomInstances(alert *CorrelatedAlert) []string {
+	var instances []string
+	for _, a := range alert.Symptoms {
+		instances = append(instances, a.Instance)
+	}
+	return uniqueStrings(instances)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/kubestack-ai/kubestack-ai/internal/alert/notifier"
 	"github.com/kubestack-ai/kubestack-ai/internal/alert/webhook"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/diagnosis"
+	kgraph "github.com/kubestack-ai/kubestack-ai/internal/knowledge/graph"
 )
 
 // Manager coordinates the alerting system.
@@ -21,6 +22,9 @@ type ManagerConfig struct {
 	Dispatcher *DispatcherConfig
 	Feedback   *FeedbackConfig
 	Notifiers  []notifier.Notifier
+	// Topology, when set, correlates alerts across instances connected in
+	// the dependency graph.
+	Topology *kgraph.QueryEngine
 }
 
 // NewManager creates a new alert Manager.
@@ -35,6 +39,9 @@ func NewManager(diagManager *diagnosis.Manager, config *ManagerConfig) *Manager
 	feedback := NewFeedbackProcessor(config.Notifiers, config.Feedback)
 
 	correlator := NewCorrelator(config.Dispatcher.CorrelationWindow, nil)
+	if config.Topology != nil {
+		correlator.SetTopology(config.Topology, config.Dispatcher.TopologyHops)
+	}
 
 	dispatcher := NewDispatcher(diagManager, correlator, feedback, config.Dispatcher)
 
@@ -90,6 +90,9 @@ func (d *DingTalkNotifier) generateSignature() (string, string) {
 }
 
 func (d *DingTalkNotifier) buildMarkdownBody(msg *NotificationMessage) ([]byte, error) {
+	if len(msg.Actions) > 0 {
+		return d.buildActionCardBody(msg)
+	}
 	payload := map[string]interface{}{
 		"msgtype": "markdown",
 		"markdown": map[string]string{
@@ -102,3 +105,24 @@ func (d *DingTalkNotifier) buildMarkdownBody(msg *NotificationMessage) ([]byte,
 	}
 	return json.Marshal(payload)
 }
+
+// buildActionCardBody renders the message as an actionCard with one button per action
+func (d *DingTalkNotifier) buildActionCardBody(msg *NotificationMessage) ([]byte, error) {
+	btns := make([]map[string]string, 0, len(msg.Actions))
+	for _, a := range msg.Actions {
+		btns = append(btns, map[string]string{
+			"title":     a.Label,
+			"actionURL": a.URL,
+		})
+	}
+	payload := map[string]interface{}{
+		"msgtype": "actionCard",
+		"actionCard": map[string]interface{}{
+			"title":          msg.Title,
+			"text":           msg.Content,
+			"btnOrientation": "1",
+			"btns":           btns,
+		},
+	}
+	return json.Marshal(payload)
+}
@@ -10,8 +10,16 @@ import (
 type NotificationMessage struct {
 	Title    string
 	Content  string
-	Severity string // "critical", "warning", "info"
-	Link     string // Optional link to dashboard/report
+	Severity string   // "critical", "warning", "info"
+	Link     string   // Optional link to dashboard/report
+	Actions  []Action // Optional buttons, e.g. approve/reject links
+}
+
+// Action is a button rendered with a notification that opens URL.
+type Action struct {
+	Label string
+	URL   string
+	Style string // "primary", "danger" or empty
 }
 
 // Notifier defines the interface for sending notifications.
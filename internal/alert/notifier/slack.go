@@ -90,6 +90,28 @@ func (s *SlackNotifier) buildSlackPayload(msg *NotificationMessage) ([]byte, err
 		})
 	}
 
+	if len(msg.Actions) > 0 {
+		elements := make([]map[string]interface{}, 0, len(msg.Actions))
+		for _, a := range msg.Actions {
+			button := map[string]interface{}{
+				"type": "button",
+				"text": map[string]string{
+					"type": "plain_text",
+					"text": a.Label,
+				},
+				"url": a.URL,
+			}
+			if a.Style != "" {
+				button["style"] = a.Style
+			}
+			elements = append(elements, button)
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "actions",
+			"elements": elements,
+		})
+	}
+
 	payload := map[string]interface{}{
 		"blocks": blocks,
 	}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/execution"
+)
+
+// ApprovalHandler lets approvers approve or reject fix plans awaiting approval
+type ApprovalHandler struct {
+	gate   *execution.ApprovalGate
+	linker *execution.ApprovalLinker
+	log    logger.Logger
+}
+
+func NewApprovalHandler(gate *execution.ApprovalGate, linker *execution.ApprovalLinker) *ApprovalHandler {
+	return &ApprovalHandler{gate: gate, linker: linker, log: logger.NewLogger("approval-handler")}
+}
+
+type decisionRequest struct {
+	Comment string `json:"comment"`
+}
+
+// ListApprovals lists approval requests, optionally filtered by status
+func (h *ApprovalHandler) ListApprovals(c *gin.Context) {
+	// GET /api/v1/approvals?status=pending_approval
+	if !h.available(c) {
+		return
+	}
+	reqs, err := h.gate.List(c.Request.Context(), execution.ApprovalStatus(c.Query("status")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if reqs == nil {
+		reqs = []*execution.ApprovalRequest{}
+	}
+	c.JSON(http.StatusOK, gin.H{"approvals": reqs})
+}
+
+// GetApproval returns one approval request with its plan and decisions
+func (h *ApprovalHandler) GetApproval(c *gin.Context) {
+	// GET /api/v1/approvals/:id
+	if !h.available(c) {
+		return
+	}
+	req, err := h.gate.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+// SubmitPlan puts a fix plan in PendingApproval
+func (h *ApprovalHandler) SubmitPlan(c *gin.Context) {
+	// POST /api/v1/approvals
+	if !h.available(c) {
+		return
+	}
+	var plan execution.FixPlan
+	if err := c.ShouldBindJSON(&plan); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req, err := h.gate.Submit(c.Request.Context(), &plan, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, req)
+}
+
+// Approve records the caller's approval of a plan
+func (h *ApprovalHandler) Approve(c *gin.Context) {
+	// POST /api/v1/approvals/:id/approve
+	h.decide(c, true)
+}
+
+// Reject records the caller's rejection of a plan; a comment is required
+func (h *ApprovalHandler) Reject(c *gin.Context) {
+	// POST /api/v1/approvals/:id/reject
+	h.decide(c, false)
+}
+
+func (h *ApprovalHandler) decide(c *gin.Context, approve bool) {
+	if !h.available(c) {
+		return
+	}
+	approver := c.GetString("user_id")
+	if approver == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "approver identity required"})
+		return
+	}
+	var body decisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if !approve && body.Comment == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a comment is required to reject a plan"})
+		return
+	}
+
+	id := c.Param("id")
+	var req *execution.ApprovalRequest
+	var err error
+	if approve {
+		req, err = h.gate.Approve(c.Request.Context(), id, approver, body.Comment, "api")
+	} else {
+		req, err = h.gate.Reject(c.Request.Context(), id, approver, body.Comment, "api")
+	}
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.executeIfApproved(req)
+	c.JSON(http.StatusOK, req)
+}
+
+// linkDecision is the decision posted from a confirmation page
+type linkDecision struct {
+	Action  string `json:"action" binding:"required"`
+	Expires string `json:"expires" binding:"required"`
+	Sig     string `json:"sig" binding:"required"`
+	Comment string `json:"comment"`
+}
+
+// ConfirmDecision serves the page a signed chat link opens. It changes
+// nothing: the page posts the decision to Decide with the approver's
+// dashboard login.
+func (h *ApprovalHandler) ConfirmDecision(c *gin.Context) {
+	// GET /api/v1/approvals/:id/decide?action=approve&expires=...&sig=...
+	if !h.available(c) {
+		return
+	}
+	id, action, expires, sig := c.Param("id"), c.Query("action"), c.Query("expires"), c.Query("sig")
+	if h.linker == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "approval links are not enabled"})
+		return
+	}
+	if err := h.linker.Verify(id, action, expires, sig); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	req, err := h.gate.Get(c.Request.Context(), id)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	var page bytes.Buffer
+	if err := confirmPage.Execute(&page, gin.H{
+		"Request":  req,
+		"Action":   action,
+		"Expires":  expires,
+		"Sig":      sig,
+		"Endpoint": c.Request.URL.Path,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// Decide records the decision confirmed on the page of a signed chat link,
+// under the identity of the logged-in caller
+func (h *ApprovalHandler) Decide(c *gin.Context) {
+	// POST /api/v1/approvals/:id/decide
+	if !h.available(c) {
+		return
+	}
+	approver := c.GetString("user_id")
+	if approver == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "approver identity required"})
+		return
+	}
+	if h.linker == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "approval links are not enabled"})
+		return
+	}
+	var body linkDecision
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	id := c.Param("id")
+	if err := h.linker.Verify(id, body.Action, body.Expires, body.Sig); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req *execution.ApprovalRequest
+	var err error
+	switch body.Action {
+	case "approve":
+		req, err = h.gate.Approve(c.Request.Context(), id, approver, body.Comment, "link")
+	case "reject":
+		if body.Comment == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "a comment is required to reject a plan"})
+			return
+		}
+		req, err = h.gate.Reject(c.Request.Context(), id, approver, body.Comment, "link")
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown action"})
+		return
+	}
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	h.executeIfApproved(req)
+	c.JSON(http.StatusOK, gin.H{"plan_id": id, "status": req.Status,
+		"approvals": req.Approvals(), "required": req.RequiredApprovals})
+}
+
+// confirmPage shows the plan and posts the decision with the dashboard's
+// login token
+var confirmPage = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{if eq .Action "approve"}}Approve{{else}}Reject{{end}} fix plan {{.Request.ID}}</title></head>
+<body>
+<h1>{{if eq .Action "approve"}}Approve{{else}}Reject{{end}} fix plan {{.Request.ID}}</h1>
+<p>Status: {{.Request.Status}}. Approvals: {{.Request.Approvals}} of {{.Request.RequiredApprovals}}.{{if .Request.Plan.RiskAssessment}} Risk: {{.Request.Plan.RiskAssessment.Level}}.{{end}}</p>
+<ul>{{range .Request.Plan.Actions}}{{if .Action}}<li>{{.Action.Description}}: <code>{{.Action.Command}}</code></li>{{end}}{{end}}</ul>
+<p><label>Comment{{if ne .Action "approve"}} (required){{end}}<br><textarea id="comment" rows="3" cols="60"></textarea></label></p>
+<p><button id="confirm">Confirm</button></p>
+<p id="result"></p>
+<script>
+document.getElementById("confirm").onclick = async function () {
+  const out = document.getElementById("result");
+  const token = localStorage.getItem("token");
+  if (!token) {
+    out.textContent = "Sign in to the dashboard in this browser, then reload this page.";
+    return;
+  }
+  const resp = await fetch({{.Endpoint}}, {
+    method: "POST",
+    headers: {"Content-Type": "application/json", "Authorization": "Bearer " + token},
+    body: JSON.stringify({action: {{.Action}}, expires: {{.Expires}}, sig: {{.Sig}},
+      comment: document.getElementById("comment").value}),
+  });
+  const body = await resp.json();
+  out.textContent = resp.ok
+    ? "Recorded. The plan is " + body.status + " (" + body.approvals + "/" + body.required + " approvals)."
+    : "Failed: " + body.error;
+};
+</script>
+</body>
+</html>
+`))
+
+// executeIfApproved runs a plan in the background once it is approved
+func (h *ApprovalHandler) executeIfApproved(req *execution.ApprovalRequest) {
+	if req.Status == execution.ApprovalStatusApproved {
+		go executeApproved(h.gate, h.log, req.ID)
+	}
+}
+
+func (h *ApprovalHandler) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, execution.ErrApprovalNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, execution.ErrApproverNotAllowed):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, execution.ErrApprovalClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *ApprovalHandler) available(c *gin.Context) bool {
+	if h.gate == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "fix plan approval is not configured"})
+		return false
+	}
+	return true
+}
+
+// executeApproved executes an approved fix plan and logs the outcome
+func executeApproved(gate *execution.ApprovalGate, log logger.Logger, id string) {
+	result, _, err := gate.Execute(context.Background(), id)
+	if err != nil {
+		log.Warnf("Approved fix plan %s failed: %v", id, err)
+		return
+	}
+	log.Infof("Approved fix plan %s executed: %s", id, result.Status)
+}
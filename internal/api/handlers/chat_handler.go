@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestack-ai/kubestack-ai/internal/ai/agent"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+)
+
+// ChatHandler answers chat messages of the web console through the agent,
+// which routes each recognized intent to the diagnosis, monitoring and fix
+// subsystems
+type ChatHandler struct {
+	agent *agent.Agent
+	log   logger.Logger
+}
+
+func NewChatHandler(a *agent.Agent) *ChatHandler {
+	return &ChatHandler{agent: a, log: logger.NewLogger("chat-handler")}
+}
+
+type chatRequest struct {
+	Text      string `json:"text" binding:"required"`
+	SessionID string `json:"session_id"`
+}
+
+// Chat handles one message and returns the agent's answer with the
+// structured result of the task it ran
+func (h *ChatHandler) Chat(c *gin.Context) {
+	// POST /api/v1/chat
+	if h.agent == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "chat is not available"})
+		return
+	}
+	var req chatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Sessions are scoped to the user, so nobody answers another user's question
+	userID := c.GetString("user_id")
+	resp, err := h.agent.ProcessUserInput(c.Request.Context(), &agent.UserInput{
+		Text:      req.Text,
+		SessionID: userID + "/" + req.SessionID,
+		UserID:    userID,
+	})
+	if err != nil {
+		h.log.Errorf("Chat message failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"text": resp.Text, "task": resp.Task})
+}
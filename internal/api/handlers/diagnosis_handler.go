@@ -2,20 +2,26 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/kubestack-ai/kubestack-ai/internal/api/websocket"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/diagnosis"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	llminterfaces "github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/report"
 )
 
 type DiagnosisHandler struct {
 	engine    interfaces.DiagnosisManager
 	wsHandler *websocket.Handler
+	history   diagnosis.HistoryStore
 }
 
 func NewDiagnosisHandler(engine interfaces.DiagnosisManager, wsHandler *websocket.Handler) *DiagnosisHandler {
@@ -25,6 +31,11 @@ func NewDiagnosisHandler(engine interfaces.DiagnosisManager, wsHandler *websocke
 	}
 }
 
+// SetHistory enables the history and trend endpoints
+func (h *DiagnosisHandler) SetHistory(store diagnosis.HistoryStore) {
+	h.history = store
+}
+
 type TriggerRequest struct {
 	Target     string            `json:"target" binding:"required"`
 	Middleware string            `json:"middleware" binding:"required"` // e.g., "redis", "mysql"
@@ -54,6 +65,7 @@ func (h *DiagnosisHandler) TriggerDiagnosis(c *gin.Context) {
 	taskID := uuid.New().String()
 
 	// Launch diagnosis in background
+	tenant := llminterfaces.TenantFromContext(c.Request.Context())
 	go func() {
 		// Create a channel for progress
 		progressChan := make(chan interfaces.DiagnosisProgress)
@@ -66,8 +78,9 @@ func (h *DiagnosisHandler) TriggerDiagnosis(c *gin.Context) {
 		}()
 
 		// Run Diagnosis
-		// Use a background context as the request context will be cancelled when the handler returns
-		ctx := context.Background()
+		// Use a background context as the request context will be cancelled
+		// when the handler returns, keeping the LLM tenant of the request
+		ctx := llminterfaces.WithTenant(context.Background(), tenant)
 		result, err := h.engine.RunDiagnosis(ctx, diagReq, progressChan)
 
 		// Final message
@@ -98,14 +111,94 @@ func (h *DiagnosisHandler) GetDiagnosisResult(c *gin.Context) {
 	id := c.Param("id")
 
 	result, err := h.engine.GetDiagnosisResult(id)
-	if err != nil {
+	if errors.Is(err, diagnosis.ErrDiagnosisNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, result)
 }
 
+// ListDiagnoses lists recorded diagnoses, newest first
+func (h *DiagnosisHandler) ListDiagnoses(c *gin.Context) {
+	// GET /api/v1/diagnosis?instance=redis-0&middleware=redis&status=warning&since=24h&limit=50
+	q, ok := h.historyQuery(c)
+	if !ok {
+		return
+	}
+	q.Middleware = c.Query("middleware")
+	q.Status = c.Query("status")
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+			return
+		}
+		q.Limit = n
+	}
+
+	entries, err := h.history.List(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if entries == nil {
+		entries = []*diagnosis.HistoryEntry{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"diagnoses": entries,
+		"total":     len(entries),
+	})
+}
+
+// GetHealthTrend returns the health scores of an instance over time, oldest first
+func (h *DiagnosisHandler) GetHealthTrend(c *gin.Context) {
+	// GET /api/v1/diagnosis/trend?instance=redis-0&since=7d
+	q, ok := h.historyQuery(c)
+	if !ok {
+		return
+	}
+	if q.Instance == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "instance parameter is required"})
+		return
+	}
+
+	points, err := diagnosis.HealthTrend(c.Request.Context(), h.history, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"instance": q.Instance,
+		"points":   points,
+	})
+}
+
+// historyQuery parses the instance, since and until parameters, writing
+// the error response when it fails
+func (h *DiagnosisHandler) historyQuery(c *gin.Context) (diagnosis.HistoryQuery, bool) {
+	if h.history == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "diagnosis history is not configured"})
+		return diagnosis.HistoryQuery{}, false
+	}
+	now := time.Now()
+	since, err := diagnosis.ParseSince(c.Query("since"), now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since parameter: " + err.Error()})
+		return diagnosis.HistoryQuery{}, false
+	}
+	until, err := diagnosis.ParseSince(c.Query("until"), now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid until parameter: " + err.Error()})
+		return diagnosis.HistoryQuery{}, false
+	}
+	return diagnosis.HistoryQuery{Instance: c.Query("instance"), Since: since, Until: until}, true
+}
+
 // RunDiagnosisSync executes diagnosis synchronously and returns a standardized DiagnosisReport
 func (h *DiagnosisHandler) RunDiagnosisSync(c *gin.Context) {
 	var req TriggerRequest
@@ -1,26 +1,148 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/execution"
+	"github.com/kubestack-ai/kubestack-ai/internal/planning"
 )
 
+// ExecutionHandler runs plans on the plan engine and exposes their history.
+// Fix plans held by the approval gate only execute once approved.
 type ExecutionHandler struct {
-    // manager interfaces.ExecutionManager
+	engine    *planning.PlanEngine
+	approvals *execution.ApprovalGate
+	log       logger.Logger
 }
 
-func NewExecutionHandler() *ExecutionHandler {
-	return &ExecutionHandler{}
+func NewExecutionHandler(engine *planning.PlanEngine, approvals *execution.ApprovalGate) *ExecutionHandler {
+	return &ExecutionHandler{engine: engine, approvals: approvals, log: logger.NewLogger("execution-handler")}
 }
 
+// SubmitPlan validates a plan and starts executing it
+func (h *ExecutionHandler) SubmitPlan(c *gin.Context) {
+	// POST /api/v1/execution/plans
+	if !h.available(c) {
+		return
+	}
+	var plan planning.Plan
+	if err := c.ShouldBindJSON(&plan); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := plan.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := h.engine.GetState(plan.ID); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "an execution of this plan already exists"})
+		return
+	}
+
+	go func() {
+		if _, err := h.engine.ExecutePlan(context.Background(), &plan); err != nil {
+			h.log.Warnf("Plan %s failed: %v", plan.ID, err)
+		}
+	}()
+	c.JSON(http.StatusAccepted, gin.H{"status": "executing", "plan_id": plan.ID})
+}
+
+// ExecutePlan executes an approved fix plan, or resumes a stored execution
+// from its first unfinished step
 func (h *ExecutionHandler) ExecutePlan(c *gin.Context) {
+	// POST /api/v1/execution/plan/:id/execute
 	id := c.Param("id")
-	// TODO: Call ExecutionManager.Execute(id)
-	c.JSON(http.StatusOK, gin.H{"status": "executing", "plan_id": id})
+	if h.approvals != nil {
+		if req, err := h.approvals.Get(c.Request.Context(), id); err == nil {
+			if req.Status != execution.ApprovalStatusApproved {
+				c.JSON(http.StatusConflict, gin.H{"error": "fix plan is not approved", "status": req.Status,
+					"approvals": req.Approvals(), "required": req.RequiredApprovals})
+				return
+			}
+			go executeApproved(h.approvals, h.log, id)
+			c.JSON(http.StatusAccepted, gin.H{"status": "executing", "plan_id": id})
+			return
+		}
+	}
+
+	if !h.available(c) {
+		return
+	}
+	state, err := h.engine.GetState(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "execution not found"})
+		return
+	}
+	if state.Status == planning.PlanStatusCompleted {
+		c.JSON(http.StatusConflict, gin.H{"error": "plan already completed"})
+		return
+	}
+
+	go func() {
+		if _, err := h.engine.ResumePlan(context.Background(), id); err != nil {
+			h.log.Warnf("Resumed plan %s failed: %v", id, err)
+		}
+	}()
+	c.JSON(http.StatusAccepted, gin.H{"status": "executing", "plan_id": id})
 }
 
+// GetHistory lists plan executions, most recently started first
 func (h *ExecutionHandler) GetHistory(c *gin.Context) {
-	// TODO: Call ExecutionManager.GetHistory()
-	c.JSON(http.StatusOK, gin.H{"history": []string{}})
+	// GET /api/v1/execution/history?status=Failed
+	if !h.available(c) {
+		return
+	}
+	states, err := h.engine.ListExecutions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := c.Query("status")
+	history := make([]*planning.ExecutionState, 0, len(states))
+	for _, s := range states {
+		if status == "" || strings.EqualFold(string(s.Status), status) {
+			history = append(history, s)
+		}
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].StartedAt.After(history[j].StartedAt) })
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
+
+// GetExecution returns the plan, state and event log of one execution
+func (h *ExecutionHandler) GetExecution(c *gin.Context) {
+	// GET /api/v1/execution/history/:id
+	if !h.available(c) {
+		return
+	}
+	id := c.Param("id")
+	state, err := h.engine.GetState(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "execution not found"})
+		return
+	}
+	plan, err := h.engine.GetPlan(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	events, err := h.engine.GetEvents(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"plan": plan, "state": state, "events": events})
+}
+
+func (h *ExecutionHandler) available(c *gin.Context) bool {
+	if h.engine == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "plan execution is not configured"})
+		return false
+	}
+	return true
 }
@@ -3,9 +3,11 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/detection/baseline"
 	"github.com/kubestack-ai/kubestack-ai/internal/monitor/alert"
 	"github.com/kubestack-ai/kubestack-ai/internal/monitor/collector"
 	"github.com/kubestack-ai/kubestack-ai/internal/monitor/storage"
@@ -17,7 +19,9 @@ type MonitorHandler struct {
 	store      storage.TimeseriesStore
 	alertStore storage.AlertStore
 	ruleEngine *alert.RuleEngine
+	evaluator  *alert.AlertEvaluator
 	silence    *alert.SilenceManager
+	baselines  *baseline.Learner
 }
 
 func NewMonitorHandler(
@@ -25,6 +29,7 @@ func NewMonitorHandler(
 	store storage.TimeseriesStore,
 	alertStore storage.AlertStore,
 	ruleEngine *alert.RuleEngine,
+	evaluator *alert.AlertEvaluator,
 	silence *alert.SilenceManager,
 ) *MonitorHandler {
 	return &MonitorHandler{
@@ -32,10 +37,16 @@ func NewMonitorHandler(
 		store:      store,
 		alertStore: alertStore,
 		ruleEngine: ruleEngine,
+		evaluator:  evaluator,
 		silence:    silence,
 	}
 }
 
+// SetBaselines enables the learned baseline endpoints.
+func (h *MonitorHandler) SetBaselines(learner *baseline.Learner) {
+	h.baselines = learner
+}
+
 // GetMetrics queries metrics
 func (h *MonitorHandler) GetMetrics(c *gin.Context) {
 	// GET /api/v1/metrics?type=redis&instance=redis-0&range=1h
@@ -97,6 +108,28 @@ func (h *MonitorHandler) GetAlertHistory(c *gin.Context) {
 	})
 }
 
+// GetActiveAlerts lists pending, firing and recently resolved alert instances
+func (h *MonitorHandler) GetActiveAlerts(c *gin.Context) {
+	// GET /api/v1/alerts/active?state=firing
+
+	state := c.Query("state")
+
+	instances := make([]*types.AlertInstance, 0)
+	if h.evaluator != nil {
+		for _, inst := range h.evaluator.ActiveAlerts() {
+			if state != "" && string(inst.State) != state {
+				continue
+			}
+			instances = append(instances, inst)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"alerts": instances,
+		"total":  len(instances),
+	})
+}
+
 // CreateSilence creates a silence rule
 func (h *MonitorHandler) CreateSilence(c *gin.Context) {
 	// POST /api/v1/alerts/silence
@@ -137,3 +170,69 @@ func (h *MonitorHandler) CreateSilence(c *gin.Context) {
 		"expires_at": silence.EndTime,
 	})
 }
+
+// Bounds of the baseline forecast returned by GetBaselines
+const (
+	minForecastStep   = time.Minute
+	maxForecastPoints = 10000
+)
+
+// GetBaselines lists learned baselines, or explains one series' baseline
+// with its components and expected range over the coming hours.
+func (h *MonitorHandler) GetBaselines(c *gin.Context) {
+	// GET /api/v1/baselines?metric=redis_connected_clients&instance=redis-0&hours=24&step=1h
+
+	if h.baselines == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "baseline detection is not enabled"})
+		return
+	}
+
+	metric := c.Query("metric")
+	instance, explain := c.GetQuery("instance")
+	if metric == "" || !explain {
+		models, err := h.baselines.List(c.Request.Context(), metric)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"baselines": models, "count": len(models)})
+		return
+	}
+
+	hours, err := strconv.Atoi(c.DefaultQuery("hours", "24"))
+	if err != nil || hours <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid hours parameter"})
+		return
+	}
+	step, err := time.ParseDuration(c.DefaultQuery("step", "1h"))
+	if err != nil || step <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid step parameter"})
+		return
+	}
+	if step < minForecastStep {
+		step = minForecastStep
+	}
+	// Compare in float64 so that huge hours cannot overflow the duration
+	if float64(hours)*float64(time.Hour)/float64(step) > maxForecastPoints {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("hours/step must not exceed %d forecast points", maxForecastPoints)})
+		return
+	}
+
+	model, err := h.baselines.Model(c.Request.Context(), metric, instance)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if model.Samples == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no history for " + metric})
+		return
+	}
+
+	from := time.Now().Truncate(step)
+	c.JSON(http.StatusOK, gin.H{
+		"baseline": model,
+		"forecast": model.Band(from, int(time.Duration(hours)*time.Hour/step), step, h.baselines.Params()),
+		"from":     from,
+		"step":     step.String(),
+	})
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestack-ai/kubestack-ai/internal/task"
+)
+
+// TaskHandler exposes the dead-letter queue of the task system.
+type TaskHandler struct {
+	queue task.TaskQueue
+}
+
+func NewTaskHandler(queue task.TaskQueue) *TaskHandler {
+	return &TaskHandler{queue: queue}
+}
+
+// ListDeadLetters lists tasks that exhausted their attempts
+func (h *TaskHandler) ListDeadLetters(c *gin.Context) {
+	// GET /api/v1/tasks/dlq
+	tasks, err := h.queue.DeadLetters(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+}
+
+// ReplayDeadLetter moves a dead-lettered task back to the queue
+func (h *TaskHandler) ReplayDeadLetter(c *gin.Context) {
+	// POST /api/v1/tasks/dlq/:id/replay
+	id := c.Param("id")
+	if err := h.queue.Replay(c.Request.Context(), id); err != nil {
+		if errors.Is(err, task.ErrTaskNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not in dead-letter queue"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "replayed", "task_id": id})
+}
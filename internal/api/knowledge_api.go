@@ -1,24 +1,30 @@
 package api
 
 import (
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kubestack-ai/kubestack-ai/internal/knowledge"
+	"github.com/kubestack-ai/kubestack-ai/internal/knowledge/bundle"
 	"gopkg.in/yaml.v2"
 )
 
 // KnowledgeAPI handles knowledge base related requests.
 type KnowledgeAPI struct {
-	kb     *knowledge.KnowledgeBase
-	loader *knowledge.RuleLoader
+	kb      *knowledge.KnowledgeBase
+	loader  *knowledge.RuleLoader
+	bundles *bundle.Manager
 }
 
-// NewKnowledgeAPI creates a new KnowledgeAPI instance.
-func NewKnowledgeAPI(kb *knowledge.KnowledgeBase, loader *knowledge.RuleLoader) *KnowledgeAPI {
+// NewKnowledgeAPI creates a new KnowledgeAPI instance. bundles may be nil
+// when rule bundles are not configured.
+func NewKnowledgeAPI(kb *knowledge.KnowledgeBase, loader *knowledge.RuleLoader, bundles *bundle.Manager) *KnowledgeAPI {
 	return &KnowledgeAPI{
-		kb:     kb,
-		loader: loader,
+		kb:      kb,
+		loader:  loader,
+		bundles: bundles,
 	}
 }
 
@@ -34,6 +40,14 @@ func (api *KnowledgeAPI) RegisterRoutes(router *gin.RouterGroup) {
 		rules.GET("/export", api.ExportRules)
 		rules.POST("/import", api.ImportRules)
 	}
+
+	bundles := router.Group("/bundles")
+	{
+		bundles.GET("", api.ListBundles)
+		bundles.POST("", api.InstallBundle)
+		bundles.POST("/diff", api.DiffBundle)
+		bundles.POST("/:name/rollback", api.RollbackBundle)
+	}
 }
 
 // CreateRule creates a new rule.
@@ -197,3 +211,114 @@ func (api *KnowledgeAPI) ImportRules(c *gin.Context) {
 		"total":    len(rules),
 	})
 }
+
+// ListBundles lists installed rule bundles and their version history.
+func (api *KnowledgeAPI) ListBundles(c *gin.Context) {
+	if !api.bundlesAvailable(c) {
+		return
+	}
+	installed, err := api.bundles.Installed()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"bundles": installed})
+}
+
+// DiffBundle previews installing an uploaded bundle.
+func (api *KnowledgeAPI) DiffBundle(c *gin.Context) {
+	api.withBundle(c, func(b *bundle.Bundle, strategy bundle.Strategy) {
+		plan, err := api.bundles.Plan(b, strategy)
+		if err != nil {
+			api.bundleError(c, plan, err)
+			return
+		}
+		c.JSON(http.StatusOK, plan)
+	})
+}
+
+// InstallBundle installs an uploaded bundle. Conflicts with local edits
+// fail the install unless ?strategy=ours or ?strategy=theirs is given.
+func (api *KnowledgeAPI) InstallBundle(c *gin.Context) {
+	api.withBundle(c, func(b *bundle.Bundle, strategy bundle.Strategy) {
+		plan, err := api.bundles.Install(b, strategy)
+		if err != nil {
+			api.bundleError(c, plan, err)
+			return
+		}
+		c.JSON(http.StatusOK, plan)
+	})
+}
+
+// RollbackBundle reinstalls the previously installed version of a bundle.
+func (api *KnowledgeAPI) RollbackBundle(c *gin.Context) {
+	if !api.bundlesAvailable(c) {
+		return
+	}
+	strategy, err := bundle.ParseStrategy(c.Query("strategy"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	plan, err := api.bundles.Rollback(c.Param("name"), strategy)
+	if err != nil {
+		api.bundleError(c, plan, err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// withBundle reads a bundle from a multipart "file" field or the request body
+func (api *KnowledgeAPI) withBundle(c *gin.Context, fn func(*bundle.Bundle, bundle.Strategy)) {
+	if !api.bundlesAvailable(c) {
+		return
+	}
+	strategy, err := bundle.ParseStrategy(c.Query("strategy"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var r io.Reader = c.Request.Body
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+			return
+		}
+		defer f.Close()
+		r = f
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read bundle"})
+		return
+	}
+	b, err := bundle.Parse(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fn(b, strategy)
+}
+
+func (api *KnowledgeAPI) bundleError(c *gin.Context, plan *bundle.Plan, err error) {
+	switch {
+	case errors.Is(err, bundle.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "plan": plan})
+	case errors.Is(err, bundle.ErrNotNewer), errors.Is(err, bundle.ErrNoPrevious):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, bundle.ErrUntrusted), errors.Is(err, bundle.ErrChecksumMismatch):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (api *KnowledgeAPI) bundlesAvailable(c *gin.Context) bool {
+	if api.bundles == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "rule bundles are not configured"})
+		return false
+	}
+	return true
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
 )
 
 type Claims struct {
@@ -65,6 +66,9 @@ func (svc *AuthService) JWTAuth() gin.HandlerFunc {
 
 		c.Set("user_id", claims.UserID)
 		c.Set("role", claims.Role)
+		// LLM calls made while serving the request count against the
+		// user's token budget
+		c.Request = c.Request.WithContext(interfaces.WithTenant(c.Request.Context(), claims.UserID))
 		c.Next()
 	}
 }
@@ -8,6 +8,8 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/kubestack-ai/kubestack-ai/internal/ai/agent"
 	"github.com/kubestack-ai/kubestack-ai/internal/api/handlers"
 	"github.com/kubestack-ai/kubestack-ai/internal/api/middleware"
 	"github.com/kubestack-ai/kubestack-ai/internal/api/websocket"
@@ -15,17 +17,29 @@ import (
 	"github.com/kubestack-ai/kubestack-ai/internal/alert/notifier"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/detection"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/detection/baseline"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/diagnosis"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/execution"
+	"github.com/kubestack-ai/kubestack-ai/internal/context/k8s"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
 	"github.com/kubestack-ai/kubestack-ai/internal/knowledge"
+	"github.com/kubestack-ai/kubestack-ai/internal/knowledge/bundle"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp"
+	kgraph "github.com/kubestack-ai/kubestack-ai/internal/knowledge/graph"
 	"github.com/kubestack-ai/kubestack-ai/internal/monitor/alert"
 	"github.com/kubestack-ai/kubestack-ai/internal/monitor/alert/channels"
 	"github.com/kubestack-ai/kubestack-ai/internal/monitor/collector"
 	"github.com/kubestack-ai/kubestack-ai/internal/monitor/storage"
+	"github.com/kubestack-ai/kubestack-ai/internal/nlp"
 	"github.com/kubestack-ai/kubestack-ai/internal/notification"
+	"github.com/kubestack-ai/kubestack-ai/internal/planning"
 	storage_pkg "github.com/kubestack-ai/kubestack-ai/internal/storage"
+	graph_store "github.com/kubestack-ai/kubestack-ai/internal/storage/graph"
 	"github.com/kubestack-ai/kubestack-ai/internal/task"
+	"github.com/kubestack-ai/kubestack-ai/internal/tools"
 	"github.com/kubestack-ai/kubestack-ai/internal/web"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type Server struct {
@@ -40,6 +54,7 @@ type Server struct {
 	wsHandler      *websocket.Handler
 	taskScheduler  *task.Scheduler
 	taskWorker     *task.Worker
+	taskQueue      task.TaskQueue
 	taskStore      storage_pkg.TaskStore
 
 	// Knowledge Base API
@@ -56,6 +71,26 @@ type Server struct {
 
 	// Alert Integration (P7)
 	alertManager *pkg_alert.Manager
+
+	// Knowledge graph discovery
+	graphDiscoverer *kgraph.Discoverer
+
+	// Plan execution
+	planEngine *planning.PlanEngine
+	planStore  *planning.SQLiteStateStore
+	mcpBridge  *mcp.MCPBridge
+
+	// Fix plan approval
+	approvalGate   *execution.ApprovalGate
+	approvalLinker *execution.ApprovalLinker
+	approvalDB     *execution.SQLiteApprovalStore
+	recordDB       *execution.SQLiteRecordStore
+
+	// Diagnosis history
+	diagnosisHistory diagnosis.HistoryStore
+
+	// Chat
+	chatAgent *agent.Agent
 }
 
 // NewServer creates a new API server.
@@ -69,12 +104,19 @@ func NewServer(cfg *config.Config, diagnosisEngine interfaces.DiagnosisManager,
 	var queue task.TaskQueue
 	var store storage_pkg.TaskStore
 
+	queueOpts := task.QueueOptions{
+		VisibilityTimeout: cfg.TaskQueue.VisibilityTimeout,
+		MaxAttempts:       cfg.TaskQueue.MaxAttempts,
+	}
 	if cfg.TaskQueue.Type == "redis" {
-		queue = task.NewRedisQueue(
-			cfg.TaskQueue.Redis.Addr,
-			cfg.TaskQueue.Redis.Password,
-			cfg.TaskQueue.Redis.DB,
+		queue = task.NewRedisQueueWithOptions(
+			redis.NewClient(&redis.Options{
+				Addr:     cfg.TaskQueue.Redis.Addr,
+				Password: cfg.TaskQueue.Redis.Password,
+				DB:       cfg.TaskQueue.Redis.DB,
+			}),
 			cfg.TaskQueue.Redis.QueueName,
+			queueOpts,
 		)
 		store = storage_pkg.NewRedisTaskStore(
 			cfg.TaskQueue.Redis.Addr,
@@ -83,6 +125,8 @@ func NewServer(cfg *config.Config, diagnosisEngine interfaces.DiagnosisManager,
 			24*time.Hour,
 		)
 	} else {
+		// Single node: tasks are queued in process
+		queue = task.NewMemoryQueue(queueOpts)
 		store = storage_pkg.NewInMemoryTaskStore()
 	}
 
@@ -91,7 +135,14 @@ func NewServer(cfg *config.Config, diagnosisEngine interfaces.DiagnosisManager,
 	// Composite Notifier for Tasks
 	compositeNotifier := notification.NewCompositeNotifier(cfg.Notification)
 
-	worker := task.NewWorker(queue, diagnosisEngine, store, compositeNotifier, cfg.Notification)
+	retry := task.DefaultRetryPolicy
+	if cfg.TaskQueue.InitialBackoff > 0 {
+		retry = task.RetryPolicy{InitialBackoff: cfg.TaskQueue.InitialBackoff, MaxBackoff: cfg.TaskQueue.MaxBackoff}
+	}
+	worker := task.NewWorker(queue, diagnosisEngine, store, compositeNotifier, cfg.Notification,
+		task.WithRetryPolicy(retry),
+		task.WithVisibilityTimeout(cfg.TaskQueue.VisibilityTimeout),
+	)
 
 	// KB Init
 	if kb == nil {
@@ -105,7 +156,11 @@ func NewServer(cfg *config.Config, diagnosisEngine interfaces.DiagnosisManager,
 		}
 	}
 	loader := knowledge.NewRuleLoader(kb)
-	knowledgeAPI := NewKnowledgeAPI(kb, loader)
+	bundles, err := bundle.NewManagerFromConfig(cfg.Knowledge.Bundles, kb, loader)
+	if err != nil {
+		log.Warnf("Failed to init knowledge bundles, bundle install disabled: %v", err)
+	}
+	knowledgeAPI := NewKnowledgeAPI(kb, loader, bundles)
 
 	// --- Monitoring Subsystem Init ---
 	tsStore, err := storage.NewSQLiteTimeseriesStore(cfg.Monitor.Storage.Path)
@@ -127,6 +182,7 @@ func NewServer(cfg *config.Config, diagnosisEngine interfaces.DiagnosisManager,
 	var alEvaluator *alert.AlertEvaluator
 	var monHandler *handlers.MonitorHandler
 	var silenceMgr *alert.SilenceManager
+	var ruleEngine *alert.RuleEngine
 
 	if tsStore != nil && alertStore != nil {
 		colScheduler = collector.NewCollectorScheduler(tsStore, log)
@@ -152,10 +208,13 @@ func NewServer(cfg *config.Config, diagnosisEngine interfaces.DiagnosisManager,
 					colScheduler.Register(mc)
 				}
 			}
+			if src.Type == "kafka" && len(src.Brokers) > 0 {
+				colScheduler.Register(collector.NewKafkaLagCollector(src.Brokers, log))
+			}
 		}
 
 		// Alerting
-		ruleEngine := alert.NewRuleEngine(log)
+		ruleEngine = alert.NewRuleEngine(log)
 		if err := ruleEngine.LoadRules(cfg.Monitor.Alerting.Rules); err != nil {
 			log.Errorf("Failed to load alert rules: %v", err)
 		}
@@ -176,50 +235,201 @@ func NewServer(cfg *config.Config, diagnosisEngine interfaces.DiagnosisManager,
 
 		silenceMgr = alert.NewSilenceManager(silenceStore)
 		alEvaluator = alert.NewAlertEvaluator(ruleEngine, tsStore, alertStore, notifier, silenceMgr, log)
-		monHandler = handlers.NewMonitorHandler(colScheduler, tsStore, alertStore, ruleEngine, silenceMgr)
+		monHandler = handlers.NewMonitorHandler(colScheduler, tsStore, alertStore, ruleEngine, alEvaluator, silenceMgr)
+
+		if cfg.Detection.Baseline.Enabled {
+			learner, err := baseline.NewLearnerFromConfig(cfg.Detection.Baseline, tsStore)
+			if err != nil {
+				log.Errorf("Failed to init baseline models: %v", err)
+			} else {
+				monHandler.SetBaselines(learner)
+				colScheduler.SetDetector(detection.NewAnomalyDetector(cfg, detection.WithBaseline(learner)))
+			}
+		}
 	}
 	// -----------------------------
 
 	// --- Alert Integration (P7) ---
 	// Initialize Alert Manager
-	var am *pkg_alert.Manager
-	if dm, ok := diagnosisEngine.(*diagnosis.Manager); ok {
-		// Create notifiers
-		var notifiers []notifier.Notifier
-		for _, ch := range cfg.Notification.Channels {
-			if !ch.Enabled {
-				continue
-			}
-			if ch.Type == "dingtalk" {
-				notifiers = append(notifiers, notifier.NewDingTalkNotifier(ch.WebhookURL, ch.Secret))
-			} else if ch.Type == "slack" {
-				// Assumes channel name is mapped to webhook_url in logic or handled inside
-				// We need to check SlackConfig or ChannelConfig.
-				// For P7-T7, we implemented SlackNotifier which takes webhook, channel, username.
-				// ChannelConfig struct has channel.
-				notifiers = append(notifiers, notifier.NewSlackNotifier(ch.WebhookURL, ch.Channel, "KSA-Bot"))
-			}
+	// Create notifiers
+	var notifiers []notifier.Notifier
+	for _, ch := range cfg.Notification.Channels {
+		if !ch.Enabled {
+			continue
+		}
+		if ch.Type == "dingtalk" {
+			notifiers = append(notifiers, notifier.NewDingTalkNotifier(ch.WebhookURL, ch.Secret))
+		} else if ch.Type == "slack" {
+			// Assumes channel name is mapped to webhook_url in logic or handled inside
+			// We need to check SlackConfig or ChannelConfig.
+			// For P7-T7, we implemented SlackNotifier which takes webhook, channel, username.
+			// ChannelConfig struct has channel.
+			notifiers = append(notifiers, notifier.NewSlackNotifier(ch.WebhookURL, ch.Channel, "KSA-Bot"))
 		}
+	}
 
-		// Also check existing configs for Notification
-		if cfg.Notification.Slack.Enabled && cfg.Notification.Slack.WebhookURL != "" {
-			notifiers = append(notifiers, notifier.NewSlackNotifier(cfg.Notification.Slack.WebhookURL, "", "KSA-Bot"))
+	// Also check existing configs for Notification
+	if cfg.Notification.Slack.Enabled && cfg.Notification.Slack.WebhookURL != "" {
+		notifiers = append(notifiers, notifier.NewSlackNotifier(cfg.Notification.Slack.WebhookURL, "", "KSA-Bot"))
+	}
+
+	// Knowledge graph, shared by alert correlation and discovery. An
+	// in-memory graph is only populated by discovery.
+	var graphStore graph_store.GraphStore
+	if cfg.Knowledge.Graph.Discovery.Enabled || cfg.Knowledge.Graph.Store == "neo4j" {
+		if graphStore, err = kgraph.NewStore(cfg.Knowledge.Graph); err != nil {
+			log.Errorf("Failed to open graph store: %v", err)
+			graphStore = nil
 		}
+	}
 
+	var am *pkg_alert.Manager
+	if dm, ok := diagnosisEngine.(*diagnosis.Manager); ok {
 		amConfig := &pkg_alert.ManagerConfig{
 			Dispatcher: &pkg_alert.DispatcherConfig{
 				DedupWindow:       cfg.AlertDispatcher.DedupWindow,
 				CorrelationWindow: cfg.AlertDispatcher.CorrelationWindow,
+				TopologyHops:      cfg.AlertDispatcher.TopologyHops,
 			},
 			Feedback: &pkg_alert.FeedbackConfig{
 				EnabledChannels: []string{}, // All in notifiers are enabled
 			},
 			Notifiers: notifiers,
 		}
+		if graphStore != nil {
+			amConfig.Topology = kgraph.NewQueryEngine(graphStore)
+		}
 		am = pkg_alert.NewManager(dm, amConfig)
 	}
 	// -----------------------------
 
+	// Knowledge graph discovery
+	var discoverer *kgraph.Discoverer
+	if cfg.Knowledge.Graph.Discovery.Enabled && graphStore != nil {
+		if k8sClient, err := k8s.NewClient(); err != nil {
+			log.Errorf("Failed to create k8s client for graph discovery: %v", err)
+		} else {
+			discoverer = kgraph.NewDiscoverer(k8sClient, graphStore, cfg.Knowledge.Graph.Discovery.Namespace)
+		}
+	}
+
+	// --- Plan execution ---
+	var planEngine *planning.PlanEngine
+	var planStore *planning.SQLiteStateStore
+	var mcpBridge *mcp.MCPBridge
+	if cfg.Planning.StatePath != "" {
+		planStore, err = planning.NewSQLiteStateStore(cfg.Planning.StatePath)
+		if err != nil {
+			log.Warnf("Failed to init plan state store, plan execution disabled: %v", err)
+		} else {
+			planTools := tools.NewRegistry()
+			_ = planTools.Register(tools.NewDiagnoseTool(diagnosisEngine))
+			// Tools of external MCP servers are registered as they are discovered
+			if len(cfg.MCP.Servers) > 0 {
+				if bridgeCfg, err := mcp.BridgeConfigFromConfig(cfg.MCP); err != nil {
+					log.Warnf("Invalid MCP server configuration, external tools disabled: %v", err)
+				} else if mcpBridge, err = mcp.NewMCPBridge(planTools, bridgeCfg); err == nil {
+					go func() {
+						if err := mcpBridge.Initialize(context.Background()); err != nil {
+							log.Warnf("Failed to discover MCP tools: %v", err)
+						}
+					}()
+				}
+			}
+			engineCfg := planning.DefaultPlanEngineConfig()
+			if cfg.Planning.RecoveryPolicy != "" {
+				engineCfg.RecoveryPolicy = planning.RecoveryPolicy(cfg.Planning.RecoveryPolicy)
+			}
+			planEngine = planning.NewPlanEngine(planning.NewDefaultStepExecutor(planTools, nil), planStore, engineCfg)
+		}
+	}
+	// -----------------------------
+
+	// --- Fix plan approval ---
+	var approvalStore execution.ApprovalStore = execution.NewInMemoryApprovalStore()
+	var approvalDB *execution.SQLiteApprovalStore
+	if cfg.Approval.StorePath != "" {
+		if approvalDB, err = execution.NewSQLiteApprovalStore(cfg.Approval.StorePath); err != nil {
+			log.Warnf("Failed to init approval store, pending approvals will not survive restarts: %v", err)
+		} else {
+			approvalStore = approvalDB
+		}
+	}
+	approvalPolicy := execution.DefaultApprovalPolicy()
+	approvalPolicy.Approvers = cfg.Approval.Approvers
+	if cfg.Approval.TTL > 0 {
+		approvalPolicy.TTL = cfg.Approval.TTL
+	}
+	if cfg.Approval.RequiredApprovals > 0 {
+		approvalPolicy.RequiredApprovals = cfg.Approval.RequiredApprovals
+	}
+	if cfg.Approval.HighRiskApprovals > 0 {
+		approvalPolicy.HighRiskApprovals = cfg.Approval.HighRiskApprovals
+	}
+	var recordStore execution.ExecutionRecordStore = execution.NewInMemoryRecordStore()
+	var recordDB *execution.SQLiteRecordStore
+	if cfg.Approval.RecordPath != "" {
+		if recordDB, err = execution.NewSQLiteRecordStore(cfg.Approval.RecordPath); err != nil {
+			log.Warnf("Failed to init execution record store, fix executions will not survive restarts: %v", err)
+		} else {
+			recordStore = recordDB
+		}
+	}
+	// Approved plans are applied to the middleware through its plugin
+	autofix := execution.NewAutoFixManager(nil, recordStore, &execution.AutoFixOptions{
+		Enabled:          true,
+		RequireApproval:  true,
+		MaxRiskLevel:     execution.RiskLevelMedium,
+		TimeoutPerAction: 5 * time.Minute,
+		EnableRollback:   true,
+	})
+	autofix.SetFixRunner(execution.NewPluginFixRunner(pluginManager))
+	approvalGate := execution.NewApprovalGate(approvalStore, autofix, approvalPolicy)
+	approvalLinker := &execution.ApprovalLinker{BaseURL: cfg.Notification.DashboardURL, Secret: cfg.Approval.LinkSecret}
+	if len(notifiers) > 0 {
+		approvalGate.SetNotifier(execution.NewChannelApprovalNotifier(notifiers, approvalLinker))
+	}
+	// -----------------------------
+
+	// --- Diagnosis history ---
+	var diagnosisHistory diagnosis.HistoryStore
+	if dm, ok := diagnosisEngine.(*diagnosis.Manager); ok && dm.History() != nil {
+		diagnosisHistory = dm.History()
+	} else if cfg.History.StorePath != "" {
+		if diagnosisHistory, err = diagnosis.NewSQLiteHistoryStore(cfg.History.StorePath); err != nil {
+			log.Warnf("Failed to init diagnosis history, results will not be kept: %v", err)
+			diagnosisHistory = nil
+		} else if ok {
+			dm.SetHistory(diagnosisHistory)
+		}
+	}
+	// -----------------------------
+
+	// --- Chat ---
+	// Fix plans asked for in chat go to the approval gate, which applies
+	// them once approved
+	chatServices := &agent.Services{
+		Diagnosis: diagnosisEngine,
+		AutoFix:   autofix,
+		FixOptions: &execution.AutoFixOptions{
+			Enabled:          true,
+			RequireApproval:  true,
+			MaxRiskLevel:     execution.RiskLevelMedium,
+			TimeoutPerAction: 5 * time.Minute,
+			EnableRollback:   true,
+		},
+		Approvals:  approvalGate,
+		Silences:   silenceMgr,
+		AlertRules: ruleEngine,
+		Knowledge:  kb,
+	}
+	if tsStore != nil {
+		chatServices.Metrics = tsStore
+	}
+	chatAgent := agent.NewAgent(nlp.NewNLPProcessor(nlp.DefaultConfig(), nil), nil)
+	chatAgent.SetServices(chatServices)
+	// -----------------------------
+
 	s := &Server{
 		router:             gin.Default(),
 		config:             cfg,
@@ -233,6 +443,7 @@ func NewServer(cfg *config.Config, diagnosisEngine interfaces.DiagnosisManager,
 		taskScheduler:      scheduler,
 		taskWorker:         worker,
 		taskStore:          store,
+		taskQueue:          queue,
 		knowledgeAPI:       knowledgeAPI,
 		monitorHandler:     monHandler,
 		collectorScheduler: colScheduler,
@@ -241,6 +452,16 @@ func NewServer(cfg *config.Config, diagnosisEngine interfaces.DiagnosisManager,
 		timeseriesStore:    tsStore,
 		alertStore:         alertStore,
 		silenceStore:       silenceStore,
+		graphDiscoverer:    discoverer,
+		planEngine:         planEngine,
+		planStore:          planStore,
+		mcpBridge:          mcpBridge,
+		approvalGate:       approvalGate,
+		approvalLinker:     approvalLinker,
+		approvalDB:         approvalDB,
+		recordDB:           recordDB,
+		diagnosisHistory:   diagnosisHistory,
+		chatAgent:          chatAgent,
 	}
 
 	s.setupRoutes()
@@ -268,6 +489,9 @@ func (s *Server) setupRoutes() {
 		c.HTML(http.StatusOK, "dashboard.html", nil)
 	})
 
+	// Prometheus scrape endpoint (LLM router usage, latency and cost)
+	s.router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	// WebSocket route - ensure it handles the 'id' parameter query correctly
 	s.router.GET("/api/v1/ws/diagnose", s.wsHandler.ServeHTTP)
 
@@ -288,22 +512,49 @@ func (s *Server) setupRoutes() {
 	// Diagnosis Trigger (mapped to /api/v1/diagnose to match stream.js)
 	// IMPORTANT: stream.js calls /api/v1/diagnose, so we map it there.
 	diagnosisHandler := handlers.NewDiagnosisHandler(s.diagnosisEngine, s.wsHandler)
+	diagnosisHandler.SetHistory(s.diagnosisHistory)
 	v1.POST("/diagnose", diagnosisHandler.TriggerDiagnosis)
 
 	// Original path support
 	diagnosis := v1.Group("/diagnosis")
 	// Protected if needed: diagnosis.Use(s.rbacMiddleware.CheckPermission("diagnosis:write"))
 	diagnosis.POST("", diagnosisHandler.TriggerDiagnosis)
+	diagnosis.GET("", diagnosisHandler.ListDiagnoses)
+	diagnosis.GET("/trend", diagnosisHandler.GetHealthTrend)
 	diagnosis.GET("/:id", diagnosisHandler.GetDiagnosisResult)
 
 	// Knowledge Base Routes (NEW)
 	s.knowledgeAPI.RegisterRoutes(v1.Group("/knowledge"))
 
-	// Execution (Placeholder for now)
+	// Plan execution
 	execution := v1.Group("/execution")
-	executionHandler := handlers.NewExecutionHandler() // Placeholder
+	executionHandler := handlers.NewExecutionHandler(s.planEngine, s.approvalGate)
+	execution.POST("/plans", s.rbacMiddleware.CheckPermission("execution:write"), executionHandler.SubmitPlan)
 	execution.POST("/plan/:id/execute", s.rbacMiddleware.CheckPermission("execution:write"), executionHandler.ExecutePlan)
 	execution.GET("/history", s.rbacMiddleware.CheckPermission("execution:read"), executionHandler.GetHistory)
+	execution.GET("/history/:id", s.rbacMiddleware.CheckPermission("execution:read"), executionHandler.GetExecution)
+
+	// Fix plan approval; deciding needs an authenticated approver
+	approvalHandler := handlers.NewApprovalHandler(s.approvalGate, s.approvalLinker)
+	approvals := v1.Group("/approvals")
+	approvals.GET("/:id/decide", approvalHandler.ConfirmDecision) // confirmation page of signed chat links
+	authed := approvals.Group("", s.authService.JWTAuth())
+	authed.GET("", s.rbacMiddleware.CheckPermission("execution:read"), approvalHandler.ListApprovals)
+	authed.GET("/:id", s.rbacMiddleware.CheckPermission("execution:read"), approvalHandler.GetApproval)
+	authed.POST("", s.rbacMiddleware.CheckPermission("execution:write"), approvalHandler.SubmitPlan)
+	authed.POST("/:id/approve", s.rbacMiddleware.CheckPermission("execution:approve"), approvalHandler.Approve)
+	authed.POST("/:id/reject", s.rbacMiddleware.CheckPermission("execution:approve"), approvalHandler.Reject)
+	authed.POST("/:id/decide", s.rbacMiddleware.CheckPermission("execution:approve"), approvalHandler.Decide)
+
+	// Chat; the agent submits fix plans in the caller's name
+	chatHandler := handlers.NewChatHandler(s.chatAgent)
+	v1.POST("/chat", s.authService.JWTAuth(), s.rbacMiddleware.CheckPermission("diagnosis:write"), chatHandler.Chat)
+
+	// Task dead-letter queue
+	taskHandler := handlers.NewTaskHandler(s.taskQueue)
+	tasks := v1.Group("/tasks")
+	tasks.GET("/dlq", s.rbacMiddleware.CheckPermission("task:read"), taskHandler.ListDeadLetters)
+	tasks.POST("/dlq/:id/replay", s.rbacMiddleware.CheckPermission("task:write"), taskHandler.ReplayDeadLetter)
 
 	// Config
 	configHandler := handlers.NewConfigHandler(s.config)
@@ -319,7 +570,10 @@ func (s *Server) setupRoutes() {
 
 		alerts := v1.Group("/alerts")
 		alerts.GET("/history", s.rbacMiddleware.CheckPermission("monitor:read"), s.monitorHandler.GetAlertHistory)
+		alerts.GET("/active", s.rbacMiddleware.CheckPermission("monitor:read"), s.monitorHandler.GetActiveAlerts)
 		alerts.POST("/silence", s.rbacMiddleware.CheckPermission("monitor:write"), s.monitorHandler.CreateSilence)
+
+		v1.GET("/baselines", s.rbacMiddleware.CheckPermission("monitor:read"), s.monitorHandler.GetBaselines)
 	}
 
 	// Webhooks (P7)
@@ -350,6 +604,21 @@ func (s *Server) Start(ctx context.Context) error {
 	if s.silenceManager != nil {
 		go s.silenceManager.GC(ctx)
 	}
+	if s.graphDiscoverer != nil {
+		go s.graphDiscoverer.Run(ctx, s.config.Knowledge.Graph.Discovery.Interval)
+	}
+	if s.planEngine != nil {
+		// Executions a previous process left running are resumed or rolled back
+		go func() {
+			recovered, err := s.planEngine.RecoverInterrupted(ctx)
+			if err != nil {
+				s.log.Errorf("Failed to recover interrupted plans: %v", err)
+			}
+			for _, state := range recovered {
+				s.log.Infof("Recovered interrupted plan %s: %s", state.PlanID, state.Status)
+			}
+		}()
+	}
 
 	addr := fmt.Sprintf(":%d", s.config.Server.Port)
 	srv := &http.Server{
@@ -389,6 +658,21 @@ func (s *Server) Start(ctx context.Context) error {
 	if s.silenceStore != nil {
 		s.silenceStore.Close()
 	}
+	if s.planStore != nil {
+		s.planStore.Close()
+	}
+	if s.mcpBridge != nil {
+		s.mcpBridge.Close()
+	}
+	if s.approvalDB != nil {
+		s.approvalDB.Close()
+	}
+	if s.recordDB != nil {
+		s.recordDB.Close()
+	}
+	if s.diagnosisHistory != nil {
+		s.diagnosisHistory.Close()
+	}
 
 	s.log.Info("Server exiting")
 	return nil
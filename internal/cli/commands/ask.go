@@ -18,14 +18,17 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/kubestack-ai/kubestack-ai/internal/ai/agent"
+	"github.com/kubestack-ai/kubestack-ai/internal/nlp/intent"
 	"github.com/spf13/cobra"
 )
 
 // newAskCmd creates and configures the `ask` command.
 // This command allows users to ask questions in natural language to the KubeStack-AI assistant.
 // It sets up the command's usage, short and long descriptions, examples, and the execution logic (`RunE`).
-// The execution logic captures the user's question, sends it to the orchestrator,
-// and streams the response back to the console.
+// The execution logic captures the user's question. A question naming a task, such as
+// "诊断 redis-0", is run by the agent; any other is sent to the orchestrator, and the
+// response is streamed back to the console.
 //
 // Returns:
 //   *cobra.Command: A pointer to the configured cobra.Command object for the `ask` command.
@@ -52,6 +55,18 @@ The response will be streamed to the console in real-time.`,
 
 			fmt.Print("🤖 KubeStack-AI: ")
 
+			// Questions that name a task, such as a diagnosis, run it
+			if chatAgent != nil {
+				resp, err := chatAgent.ProcessUserInput(cmd.Context(), &agent.UserInput{Text: question, SessionID: "cli"})
+				if err != nil {
+					return err
+				}
+				if ranTask(resp.Task) {
+					fmt.Println(resp.Text)
+					return nil
+				}
+			}
+
 			// Get the streaming channel from the orchestrator.
 			responseChan, err := orchestrator.ProcessNaturalLanguageStream(cmd.Context(), question)
 			if err != nil {
@@ -77,4 +92,10 @@ The response will be streamed to the console in real-time.`,
 	return cmd
 }
 
+// ranTask reports whether the agent answered with the outcome of a task.
+// Explanations and questions it cannot route are left to the LLM.
+func ranTask(task *agent.TaskResult) bool {
+	return task != nil && task.Intent != intent.IntentExplain && task.Status != agent.TaskStatusUnavailable
+}
+
 //Personal.AI order the ending
@@ -0,0 +1,146 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/core/diagnosis"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/spf13/cobra"
+)
+
+// newDiagnoseHistoryCmd creates the diagnose history subcommand
+func newDiagnoseHistoryCmd() *cobra.Command {
+	var q diagnosis.HistoryQuery
+	var since string
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List past diagnoses",
+		Long: `Lists the diagnoses recorded in the diagnosis history (history.store_path),
+newest first, with the health score of each run.`,
+		Example: `  # Diagnoses of one instance during the last week
+  ksa diagnose history --instance redis-0 --since 7d
+
+  # Critical diagnoses of any MySQL instance
+  ksa diagnose history --middleware mysql --status critical`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if diagHistory == nil {
+				return fmt.Errorf("diagnosis history is not configured (history.store_path)")
+			}
+			var err error
+			if q.Since, err = diagnosis.ParseSince(since, time.Now()); err != nil {
+				return err
+			}
+			entries, err := diagHistory.List(cmd.Context(), q)
+			if err != nil {
+				return err
+			}
+
+			outputFormat, _ := cmd.Flags().GetString("output")
+			if outputFormat == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(entries)
+			}
+
+			if len(entries) == 0 {
+				fmt.Println("No diagnoses found.")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tTIME\tMIDDLEWARE\tINSTANCE\tSTATUS\tSCORE\tISSUES")
+			for _, e := range entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\n", e.ID, e.Timestamp.Local().Format(time.RFC3339),
+					e.Middleware, e.Instance, e.Status, e.HealthScore, e.IssueCount)
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().StringVarP(&q.Instance, "instance", "i", "", "Only list diagnoses of this instance")
+	cmd.Flags().StringVar(&q.Middleware, "middleware", "", "Only list diagnoses of this middleware type")
+	cmd.Flags().StringVar(&q.Status, "status", "", "Only list diagnoses with this status (healthy, warning, critical)")
+	cmd.Flags().StringVar(&since, "since", "", "Only list diagnoses since this time (RFC 3339) or age (e.g. 24h, 7d)")
+	cmd.Flags().IntVar(&q.Limit, "limit", 20, "Maximum number of diagnoses to list (at most 1000)")
+	return cmd
+}
+
+// newDiagnoseDiffCmd creates the diagnose diff subcommand
+func newDiagnoseDiffCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff [diagnosis-id] [diagnosis-id]",
+		Short: "Compare the issues of two diagnoses",
+		Long: `Compares two recorded diagnoses, usually of the same instance, and shows the
+issues that appeared, were resolved or changed severity from the first to the
+second.`,
+		Example: `  ksa diagnose history --instance redis-0 --limit 2
+  ksa diagnose diff 0b6f3c1e-... 7d2a9e40-...`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if diagHistory == nil {
+				return fmt.Errorf("diagnosis history is not configured (history.store_path)")
+			}
+			from, err := diagHistory.Get(cmd.Context(), args[0])
+			if err != nil {
+				return fmt.Errorf("failed to load diagnosis %s: %w", args[0], err)
+			}
+			to, err := diagHistory.Get(cmd.Context(), args[1])
+			if err != nil {
+				return fmt.Errorf("failed to load diagnosis %s: %w", args[1], err)
+			}
+			diff := diagnosis.DiffResults(from.Result, to.Result)
+
+			outputFormat, _ := cmd.Flags().GetString("output")
+			if outputFormat == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(diff)
+			}
+
+			fmt.Printf("From: %s (%s, %s, score %d)\n", from.ID, from.Instance, from.Timestamp.Local().Format(time.RFC3339), from.HealthScore)
+			fmt.Printf("To:   %s (%s, %s, score %d)\n", to.ID, to.Instance, to.Timestamp.Local().Format(time.RFC3339), to.HealthScore)
+			if from.Instance != to.Instance || from.Middleware != to.Middleware {
+				fmt.Println("Note: the diagnoses are of different targets.")
+			}
+
+			fmt.Printf("\nAppeared (%d):\n", len(diff.Appeared))
+			for _, issue := range diff.Appeared {
+				fmt.Printf("  + [%s] %s\n", issue.Severity, describeIssue(issue))
+			}
+			fmt.Printf("\nResolved (%d):\n", len(diff.Resolved))
+			for _, issue := range diff.Resolved {
+				fmt.Printf("  - [%s] %s\n", issue.Severity, describeIssue(issue))
+			}
+			fmt.Printf("\nSeverity changed (%d):\n", len(diff.Changed))
+			for _, c := range diff.Changed {
+				fmt.Printf("  ~ %s: %s -> %s\n", describeIssue(c.Issue), c.From, c.To)
+			}
+			fmt.Printf("\nUnchanged: %d\n", diff.Unchanged)
+			return nil
+		},
+	}
+}
+
+func describeIssue(issue *models.Issue) string {
+	if subject := diagnosis.IssueSubject(issue); subject != "" {
+		return fmt.Sprintf("%s (%s)", issue.Title, subject)
+	}
+	return issue.Title
+}
@@ -16,74 +16,114 @@ package commands
 
 import (
 	"fmt"
+	"time"
 
-	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/execution"
 	"github.com/spf13/cobra"
 )
 
+// fixOptions are the AutoFix options of the fix command. Running `ksa fix`
+// is the opt-in, and the plan is confirmed interactively before it runs.
+func fixOptions(dryRun bool) *execution.AutoFixOptions {
+	return &execution.AutoFixOptions{
+		Enabled:          true,
+		DryRun:           dryRun,
+		MaxRiskLevel:     execution.RiskLevelMedium,
+		TimeoutPerAction: 5 * time.Minute,
+		EnableRollback:   true,
+	}
+}
+
 // newFixCmd creates and configures the `fix` command.
 // This command is designed to apply automated fixes based on the results of a previous diagnosis.
 // It sets up the command's usage, descriptions, examples, and the core execution logic (`RunE`).
-// The execution flow includes fetching recommendations, generating a safe execution plan,
-// requiring user confirmation, executing the plan, and validating the outcome.
+// The execution flow includes fetching recommendations, building a fix plan with a risk
+// assessment, requiring user confirmation, and applying the plan through the plugins of the
+// diagnosed middleware, which verify each fix and undo the ones that do not take effect.
 //
 // Returns:
 //   *cobra.Command: A pointer to the configured cobra.Command object for the `fix` command.
 func newFixCmd() *cobra.Command {
+	var dryRun bool
+	var token string
+
 	cmd := &cobra.Command{
 		Use:   "fix [diagnosis-id]",
 		Short: "Apply automated fixes for a given diagnosis",
 		Long: `Applies automated fixes based on the recommendations from a previous diagnosis.
 This command follows a safe, multi-step process:
-1. Generate an execution plan based on the diagnosis report.
+1. Build a fix plan from the auto-fixable recommendations of the diagnosis report.
 2. Display the plan, including all commands and a risk assessment, for your review.
-3. Upon your confirmation, execute the plan step-by-step.`,
+3. Upon your confirmation, apply the plan step-by-step. Each fix is checked before
+   and after it runs, and fixes that do not take effect are rolled back.
+Plans that need approval (high and critical risk) are not applied here; they are
+submitted to the server's approval gate and run there once approved.`,
 		Example: `  # Generate and apply a fix for a diagnosis with a specific ID
-  ksa fix <diagnosis-id-from-report>`,
+  ksa fix <diagnosis-id-from-report>
+
+  # Show what the fix would do without changing anything
+  ksa fix <diagnosis-id-from-report> --dry-run
+
+  # Review and approve fix plans held for approval on the server
+  ksa fix approvals --status pending_approval
+  ksa fix approve <plan-id> --comment "looks safe"`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			diagnosisID := args[0]
 
-			// The orchestrator is now initialized in root.go's PersistentPreRunE.
-			if orchestrator == nil {
-				return fmt.Errorf("orchestrator not initialized")
+			// The AutoFix manager is initialized in root.go's PersistentPreRunE.
+			if autoFix == nil {
+				return fmt.Errorf("autofix manager not initialized")
 			}
 
 			// 1. Fetch recommendations from the diagnosis report.
-			// This requires a persistence layer for diagnosis results, which is not yet implemented.
-			// We will use placeholder recommendations to demonstrate the flow.
-			fmt.Printf("Fetching recommendations for diagnosis ID: %s (using placeholder data)\n", diagnosisID)
-			issues := []models.Issue{
-				{
-					Recommendations: []*models.Recommendation{
-						{
-							ID: "rec-001", Description: "Increase the max_connections parameter.", CanAutoFix: true,
-							Fix: models.FixAction{Command: "mysql -e 'SET GLOBAL max_connections = 500;'"},
-						},
-						{
-							ID: "rec-002", Description: "Restart the database server to apply changes.", CanAutoFix: true,
-							Fix: models.FixAction{Command: "systemctl restart mysqld"},
-						},
-					},
-				},
+			if diagManager == nil {
+				return fmt.Errorf("diagnosis manager not initialized")
+			}
+			fmt.Printf("Fetching recommendations for diagnosis ID: %s\n", diagnosisID)
+			result, err := diagManager.GetDiagnosisResult(diagnosisID)
+			if err != nil {
+				return fmt.Errorf("failed to load diagnosis %s: %w", diagnosisID, err)
 			}
 
-			// 2. Generate the execution plan.
-			fmt.Println("Generating execution plan...")
-			plan, err := orchestrator.PlanExecution(cmd.Context(), issues)
+			// 2. Build the fix plan.
+			fmt.Println("Building fix plan...")
+			plan, err := autoFix.BuildFixPlan(cmd.Context(), diagnosisID, result.Issues, fixOptions(dryRun))
 			if err != nil {
-				return fmt.Errorf("failed to generate execution plan: %w", err)
+				return fmt.Errorf("failed to build fix plan: %w", err)
 			}
 
 			// 3. Display the plan and ask for user confirmation. This is a critical safety step.
-			// TODO: Use a proper UI component from `internal/cli/ui` to render the plan nicely.
-			fmt.Println("\n--- [Execution Plan Review] ---")
-			fmt.Printf(" Risk Level: %s\n", plan.Risk.MaxSeverity)
-			fmt.Printf(" Description: %s\n", plan.Risk.Description)
-			fmt.Println(" Steps to be executed:")
-			for i, step := range plan.Steps {
-				fmt.Printf("  %d. %s\n", i+1, step.Name)
-				fmt.Printf("     └─ Command: `%s`\n", step.Action.Command)
+			fmt.Println("\n--- [Fix Plan Review] ---")
+			fmt.Printf(" Risk Level: %s (score %d)\n", plan.RiskAssessment.Level, plan.RiskAssessment.Score)
+			for _, factor := range plan.RiskAssessment.Factors {
+				fmt.Printf("  - %s\n", factor.Description)
+			}
+			fmt.Println(" Actions to be executed:")
+			for i, item := range plan.Actions {
+				fmt.Printf("  %d. %s\n", i+1, item.Action.Description)
+				fmt.Printf("     └─ Command: `%s` (plugin %s)\n", item.Action.Command, item.Action.Plugin)
+				if item.Action.RollbackCommand != "" {
+					fmt.Printf("     └─ Rollback: `%s`\n", item.Action.RollbackCommand)
+				}
+			}
+
+			if plan.RequiresApproval && !dryRun {
+				fmt.Print("\nThis plan needs approval. Submit it to the approval gate? [y/N]: ")
+				var response string
+				fmt.Scanln(&response)
+				if response != "y" && response != "Y" {
+					fmt.Println("Submission cancelled by user.")
+					return nil
+				}
+				req, err := submitApproval(plan, token)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Plan %s is %s: it needs %d approval(s) before %s.\n",
+					req.ID, req.Status, req.RequiredApprovals, req.ExpiresAt.Format(time.RFC3339))
+				fmt.Printf("Approvers can run `ksa fix approve %s`.\n", req.ID)
+				return nil
 			}
 
 			fmt.Print("\nDo you want to execute this plan? [y/N]: ")
@@ -94,37 +134,39 @@ This command follows a safe, multi-step process:
 				return nil
 			}
 
-			// 4. Execute the plan.
+			// 4. Execute the plan and record the outcome.
 			fmt.Println("\nExecuting plan...")
-
-			execResult, err := orchestrator.ManageExecution(cmd.Context(), plan)
-			if err != nil {
-				fmt.Printf("\n--- [Execution Failed] ---\n")
-				fmt.Printf("Error: %v\n", err)
-				// Print the partial result if available
-				if execResult != nil {
-					// TODO: Print formatted result of partial execution.
-				}
-				return err
+			fixResult, execErr := autoFix.ExecuteFixPlan(cmd.Context(), plan)
+			if fixResult == nil {
+				return fmt.Errorf("fix plan failed: %w", execErr)
+			}
+			if _, err := autoFix.RecordExecution(cmd.Context(), plan, fixResult, ""); err != nil {
+				fmt.Printf("Warning: failed to record execution: %v\n", err)
 			}
 
-			// 5. Display final result.
+			// 5. Display the result of every action.
 			fmt.Println("\n--- [Execution Result] ---")
-			fmt.Printf("Final Status: %s\n", execResult.Status)
-			// TODO: Print detailed step results and logs from execResult.
-
-			// 6. Validate the fix.
-			if execResult.Status == "Success" {
-				fmt.Println("\nValidating that the fix was successful...")
-				if err := orchestrator.ValidateExecution(cmd.Context(), execResult); err != nil {
-					return fmt.Errorf("fix validation failed: %w", err)
+			fmt.Printf("Final Status: %s\n", fixResult.Status)
+			for _, action := range fixResult.ActionResults {
+				fmt.Printf("  %s: %s\n", action.ActionID, action.Status)
+				if action.Before != nil || action.After != nil {
+					fmt.Printf("     └─ Before: %v, After: %v\n", action.Before, action.After)
+				}
+				if action.ErrorOutput != "" {
+					fmt.Printf("     └─ Error: %s\n", action.ErrorOutput)
 				}
-				fmt.Println("Fix validated successfully.")
 			}
-
-			return nil
+			if fixResult.RollbackPerformed {
+				fmt.Printf("Completed actions were rolled back (success: %v).\n", *fixResult.RollbackSuccess)
+			}
+			return execErr
 		},
 	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Simulate the fix plan without changing anything")
+	addTokenFlag(cmd, &token)
+	cmd.AddCommand(newFixApproveCmd())
+	cmd.AddCommand(newFixRejectCmd())
+	cmd.AddCommand(newFixApprovalsCmd())
 	return cmd
 }
 
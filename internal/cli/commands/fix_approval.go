@@ -0,0 +1,236 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/core/execution"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// newFixApproveCmd creates the fix approve subcommand
+func newFixApproveCmd() *cobra.Command {
+	var comment, token string
+	cmd := &cobra.Command{
+		Use:   "approve [plan-id]",
+		Short: "Approve a fix plan awaiting approval",
+		Long: `Approves a fix plan held in PendingApproval on a running KubeStack-AI server.
+The plan executes once it has the required number of approvals; high-risk
+plans need several distinct approvers, and nobody can approve their own plan.`,
+		Example: `  ksa fix approve 3f2a9c1e-... --comment "checked with the DBA"`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req, err := decideApproval(args[0], "approve", comment, token)
+			if err != nil {
+				return err
+			}
+			printApprovalDecision(req)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&comment, "comment", "", "Comment recorded with the approval")
+	addTokenFlag(cmd, &token)
+	return cmd
+}
+
+// newFixRejectCmd creates the fix reject subcommand
+func newFixRejectCmd() *cobra.Command {
+	var comment, token string
+	cmd := &cobra.Command{
+		Use:     "reject [plan-id]",
+		Short:   "Reject a fix plan awaiting approval",
+		Example: `  ksa fix reject 3f2a9c1e-... --comment "restarts during business hours"`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if comment == "" {
+				return fmt.Errorf("--comment is required to reject a plan")
+			}
+			req, err := decideApproval(args[0], "reject", comment, token)
+			if err != nil {
+				return err
+			}
+			printApprovalDecision(req)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&comment, "comment", "", "Reason for the rejection")
+	addTokenFlag(cmd, &token)
+	return cmd
+}
+
+// newFixApprovalsCmd creates the fix approvals subcommand
+func newFixApprovalsCmd() *cobra.Command {
+	var status, token string
+	cmd := &cobra.Command{
+		Use:   "approvals",
+		Short: "List fix plans and their approval status",
+		Example: `  # Plans waiting for approval
+  ksa fix approvals --status pending_approval`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reqs, err := fetchApprovals(status, token)
+			if err != nil {
+				return err
+			}
+
+			outputFormat, _ := cmd.Flags().GetString("output")
+			if outputFormat == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(reqs)
+			}
+
+			if len(reqs) == 0 {
+				fmt.Println("No fix plans found.")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PLAN\tSTATUS\tRISK\tAPPROVALS\tREQUESTED BY\tEXPIRES")
+			for _, r := range reqs {
+				risk := ""
+				if r.Plan != nil && r.Plan.RiskAssessment != nil {
+					risk = string(r.Plan.RiskAssessment.Level)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d/%d\t%s\t%s\n", r.ID, r.Status, risk,
+					r.Approvals(), r.RequiredApprovals, r.RequestedBy, r.ExpiresAt.Format(time.RFC3339))
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().StringVar(&status, "status", "", "Only list plans with this status (pending_approval, approved, rejected, expired, executed)")
+	addTokenFlag(cmd, &token)
+	return cmd
+}
+
+// addTokenFlag adds the flag for the API token identifying the caller
+func addTokenFlag(cmd *cobra.Command, token *string) {
+	cmd.Flags().StringVar(token, "token", "", "API token identifying the approver (default api.token or $KSA_API_TOKEN)")
+}
+
+func approvalAPIURL(path string) string {
+	port := viper.GetInt("server.port")
+	if port == 0 {
+		port = 8080 // Default
+	}
+	return fmt.Sprintf("http://localhost:%d/api/v1/approvals%s", port, path)
+}
+
+func approvalRequest(method, path, token string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, approvalAPIURL(path), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token == "" {
+		token = viper.GetString("api.token")
+	}
+	if token == "" {
+		token = os.Getenv("KSA_API_TOKEN")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+func fetchApprovals(status, token string) ([]*execution.ApprovalRequest, error) {
+	path := ""
+	if status != "" {
+		path = "?status=" + url.QueryEscape(status)
+	}
+	resp, err := approvalRequest(http.MethodGet, path, token, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error querying approvals: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server returned %s: %s", resp.Status, body)
+	}
+
+	var out struct {
+		Approvals []*execution.ApprovalRequest `json:"approvals"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return out.Approvals, nil
+}
+
+func decideApproval(id, action, comment, token string) (*execution.ApprovalRequest, error) {
+	body, _ := json.Marshal(map[string]string{"comment": comment})
+	resp, err := approvalRequest(http.MethodPost, "/"+url.PathEscape(id)+"/"+action, token, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error sending %s for plan %s: %w", action, id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to %s plan %s: %s: %s", action, id, resp.Status, body)
+	}
+
+	var req execution.ApprovalRequest
+	if err := json.NewDecoder(resp.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &req, nil
+}
+
+func printApprovalDecision(req *execution.ApprovalRequest) {
+	switch req.Status {
+	case execution.ApprovalStatusApproved:
+		fmt.Printf("Plan %s approved (%d/%d); it is being executed.\n", req.ID, req.Approvals(), req.RequiredApprovals)
+	case execution.ApprovalStatusRejected:
+		fmt.Printf("Plan %s rejected.\n", req.ID)
+	default:
+		fmt.Printf("Plan %s has %d of %d required approvals.\n", req.ID, req.Approvals(), req.RequiredApprovals)
+	}
+}
+
+// submitApproval hands a fix plan to the server's approval gate
+func submitApproval(plan *execution.FixPlan, token string) (*execution.ApprovalRequest, error) {
+	body, err := json.Marshal(plan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode plan %s: %w", plan.ID, err)
+	}
+	resp, err := approvalRequest(http.MethodPost, "", token, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error submitting plan %s for approval: %w", plan.ID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to submit plan %s for approval: %s: %s", plan.ID, resp.Status, body)
+	}
+
+	var req execution.ApprovalRequest
+	if err := json.NewDecoder(resp.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &req, nil
+}
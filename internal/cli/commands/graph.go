@@ -0,0 +1,180 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/context/k8s"
+	kgraph "github.com/kubestack-ai/kubestack-ai/internal/knowledge/graph"
+	"github.com/kubestack-ai/kubestack-ai/internal/storage/graph"
+	"github.com/spf13/cobra"
+)
+
+// newGraphCmd creates the graph command for knowledge graph operations
+func newGraphCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Knowledge graph operations",
+		Long: `Inspect and maintain the dependency knowledge graph used for
+impact analysis and root cause tracing.`,
+	}
+
+	cmd.AddCommand(newGraphDiscoverCmd())
+	return cmd
+}
+
+// newGraphDiscoverCmd creates the graph discover subcommand
+func newGraphDiscoverCmd() *cobra.Command {
+	var (
+		namespace string
+		apply     bool
+		yes       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "discover",
+		Short: "Discover service-to-middleware dependencies from Kubernetes",
+		Long: `Walk Deployments, StatefulSets, Services, Endpoints and the ConfigMaps and
+Secrets referenced from container environments to infer which services depend
+on which middleware. The changes against the configured graph store are shown
+as a diff; use --apply to write them.`,
+		Example: `  # Show what discovery would change
+  ksa graph discover
+
+  # Limit to one namespace and apply after confirmation
+  ksa graph discover --namespace shop --apply
+
+  # Machine-readable diff
+  ksa graph discover -o json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig(cfgFile)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if namespace == "" {
+				namespace = cfg.Knowledge.Graph.Discovery.Namespace
+			}
+
+			store, err := kgraph.NewStore(cfg.Knowledge.Graph)
+			if err != nil {
+				return fmt.Errorf("failed to open graph store: %w", err)
+			}
+			defer store.Close()
+
+			client, err := k8s.NewClient()
+			if err != nil {
+				return fmt.Errorf("failed to create kubernetes client: %w", err)
+			}
+
+			discoverer := kgraph.NewDiscoverer(client, store, namespace)
+			result, err := discoverer.Discover(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("discovery failed: %w", err)
+			}
+			diff, err := discoverer.Diff(cmd.Context(), result)
+			if err != nil {
+				return fmt.Errorf("failed to compare with graph store: %w", err)
+			}
+
+			outputFormat, _ := cmd.Flags().GetString("output")
+			switch outputFormat {
+			case "json":
+				if err := kbOutputJSON(diff); err != nil {
+					return err
+				}
+			case "yaml":
+				if err := kbOutputYAML(diff); err != nil {
+					return err
+				}
+			default:
+				printGraphDiff(diff)
+			}
+
+			if !apply || diff.Empty() {
+				return nil
+			}
+			if cfg.Knowledge.Graph.Store == "" || cfg.Knowledge.Graph.Store == "memory" {
+				fmt.Println("\nThe configured graph store is in-memory; changes would not persist. Configure knowledge.graph.store to apply.")
+				return nil
+			}
+			if !yes {
+				fmt.Print("\nApply these changes to the knowledge graph? [y/N]: ")
+				var response string
+				fmt.Scanln(&response)
+				if response != "y" && response != "Y" {
+					fmt.Println("Discovery changes discarded.")
+					return nil
+				}
+			}
+			if err := discoverer.Apply(cmd.Context(), diff); err != nil {
+				return fmt.Errorf("failed to apply changes: %w", err)
+			}
+			fmt.Printf("Applied %s\n", diff.Summary())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Namespace to discover (default: all namespaces)")
+	cmd.Flags().BoolVar(&apply, "apply", false, "Write the discovered changes to the graph store")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Apply without asking for confirmation")
+
+	return cmd
+}
+
+// printGraphDiff renders a discovery diff in a git-like format
+func printGraphDiff(diff *kgraph.GraphDiff) {
+	if diff.Empty() {
+		fmt.Println("Knowledge graph is up to date.")
+		return
+	}
+
+	fmt.Printf("Discovery changes (%s):\n\n", diff.Summary())
+	for _, n := range diff.AddedNodes {
+		fmt.Printf("  + node %s%s\n", n.ID, describeGraphNode(n))
+	}
+	for _, n := range diff.UpdatedNodes {
+		fmt.Printf("  ~ node %s%s\n", n.ID, describeGraphNode(n))
+	}
+	for _, n := range diff.RemovedNodes {
+		fmt.Printf("  - node %s\n", n.ID)
+	}
+	for _, e := range diff.AddedEdges {
+		fmt.Printf("  + %s -> %s%s\n", e.FromID, e.ToID, describeGraphEdge(e))
+	}
+	for _, e := range diff.UpdatedEdges {
+		fmt.Printf("  ~ %s -> %s%s\n", e.FromID, e.ToID, describeGraphEdge(e))
+	}
+	for _, e := range diff.RemovedEdges {
+		fmt.Printf("  - %s -> %s\n", e.FromID, e.ToID)
+	}
+}
+
+func describeGraphNode(n *graph.Node) string {
+	if mw, ok := n.Properties["middleware_type"].(string); ok {
+		return fmt.Sprintf(" (%s)", mw)
+	}
+	return ""
+}
+
+func describeGraphEdge(e *graph.Edge) string {
+	via, ok := e.Properties["via"].([]string)
+	if !ok || len(via) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (via %s)", strings.Join(via, ", "))
+}
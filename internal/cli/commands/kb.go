@@ -56,12 +56,22 @@ troubleshooting guides, and solutions for various middleware issues.`,
   ksa kb get kb-redis-001
 
   # Update knowledge base
-  ksa kb update`,
+  ksa kb update
+
+  # Preview and install a shared rule bundle
+  ksa kb diff redis-core-1.1.0.yaml
+  ksa kb install redis-core-1.1.0.yaml`,
 	}
 
 	cmd.AddCommand(newKBSearchCmd())
 	cmd.AddCommand(newKBGetCmd())
 	cmd.AddCommand(newKBUpdateCmd())
+	cmd.AddCommand(newKBPackCmd())
+	cmd.AddCommand(newKBDiffCmd())
+	cmd.AddCommand(newKBInstallCmd())
+	cmd.AddCommand(newKBRollbackCmd())
+	cmd.AddCommand(newKBBundlesCmd())
+	cmd.AddCommand(newKBKeygenCmd())
 
 	return cmd
 }
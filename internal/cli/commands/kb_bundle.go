@@ -0,0 +1,393 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/knowledge"
+	"github.com/kubestack-ai/kubestack-ai/internal/knowledge/bundle"
+	"github.com/spf13/cobra"
+)
+
+// newKBPackCmd creates the kb pack subcommand
+func newKBPackCmd() *cobra.Command {
+	var (
+		manifest   bundle.Manifest
+		middleware string
+		keyFile    string
+		outFile    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "pack [rule files or directories...]",
+		Short: "Package rules into a versioned bundle",
+		Long: `Package knowledge base rules into a bundle that other teams can install.
+The manifest records the bundle name, semantic version, author and a checksum
+of the rules. With --key the manifest is also signed with an ed25519 key
+created by "ksa kb keygen".`,
+		Example: `  # Package the shipped Redis rules
+  ksa kb pack --name redis-core --version 1.0.0 --author sre-team --middleware redis -f redis-core.yaml
+
+  # Package and sign
+  ksa kb pack rules/ --name mysql-core --version 1.1.0 --author dba --key team.key -f mysql-core.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig(cfgFile)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if len(args) == 0 {
+				args = []string{kbRulesDir(cfg)}
+			}
+
+			kb := knowledge.NewKnowledgeBase()
+			loader := knowledge.NewRuleLoader(kb)
+			for _, path := range args {
+				if err := loadKBRules(loader, path); err != nil {
+					return err
+				}
+			}
+			all, err := kb.GetAllRules()
+			if err != nil {
+				return err
+			}
+			var rules []knowledge.Rule
+			for _, r := range all {
+				if middleware == "" || strings.EqualFold(r.MiddlewareType, middleware) {
+					rules = append(rules, *r)
+				}
+			}
+			if len(rules) == 0 {
+				return fmt.Errorf("no rules to package")
+			}
+
+			b, err := bundle.New(manifest, rules)
+			if err != nil {
+				return err
+			}
+			if keyFile != "" {
+				key, err := readBundleKey(keyFile)
+				if err != nil {
+					return err
+				}
+				b.Sign(key)
+			}
+			if outFile == "" {
+				outFile = fmt.Sprintf("%s-%s.yaml", manifest.Name, manifest.Version)
+			}
+			if err := b.Save(outFile); err != nil {
+				return err
+			}
+
+			fmt.Printf("Packaged %d rules into %s (%s %s", len(rules), outFile, manifest.Name, manifest.Version)
+			if b.Signed() {
+				fmt.Print(", signed")
+			}
+			fmt.Println(")")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&manifest.Name, "name", "", "Bundle name (required)")
+	cmd.Flags().StringVar(&manifest.Version, "version", "", "Bundle semantic version (required)")
+	cmd.Flags().StringVar(&manifest.Author, "author", "", "Bundle author")
+	cmd.Flags().StringVar(&manifest.Description, "description", "", "Bundle description")
+	cmd.Flags().StringVar(&middleware, "middleware", "", "Only package rules for this middleware type")
+	cmd.Flags().StringVar(&keyFile, "key", "", "Private key file to sign the bundle with")
+	cmd.Flags().StringVarP(&outFile, "file", "f", "", "Output file (default <name>-<version>.yaml)")
+	_ = cmd.MarkFlagRequired("name")
+	_ = cmd.MarkFlagRequired("version")
+
+	return cmd
+}
+
+// newKBDiffCmd creates the kb diff subcommand
+func newKBDiffCmd() *cobra.Command {
+	var strategy string
+
+	cmd := &cobra.Command{
+		Use:   "diff <bundle>",
+		Short: "Preview what installing a bundle would change",
+		Long: `Verify a bundle and show the rules installing it would add, update or
+delete. Rules edited locally since the previous version of the bundle was
+installed are merged three ways; edits that clash with upstream changes are
+listed as conflicts. Nothing is changed.`,
+		Example: `  # Preview an update
+  ksa kb diff redis-core-1.1.0.yaml
+
+  # Preview resolving conflicts in favour of the bundle
+  ksa kb diff redis-core-1.1.0.yaml --strategy theirs`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := bundle.ParseStrategy(strategy)
+			if err != nil {
+				return err
+			}
+			mgr, err := newKBBundleManager()
+			if err != nil {
+				return err
+			}
+			b, err := bundle.Load(args[0])
+			if err != nil {
+				return err
+			}
+			plan, err := mgr.Plan(b, s)
+			if err != nil {
+				return err
+			}
+			return outputBundlePlan(cmd, plan, false)
+		},
+	}
+
+	cmd.Flags().StringVar(&strategy, "strategy", "fail", "Conflict resolution: fail, ours or theirs")
+	return cmd
+}
+
+// newKBInstallCmd creates the kb install subcommand
+func newKBInstallCmd() *cobra.Command {
+	var strategy string
+
+	cmd := &cobra.Command{
+		Use:   "install <bundle>",
+		Short: "Install or upgrade a rule bundle",
+		Long: `Verify and install a rule bundle. Only a newer version of an installed
+bundle can be installed. Local edits are kept where they do not clash with
+the bundle; conflicting edits stop the install unless --strategy ours or
+--strategy theirs says which side wins.`,
+		Example: `  # Install a bundle
+  ksa kb install redis-core-1.1.0.yaml
+
+  # Keep local edits where they conflict
+  ksa kb install redis-core-1.1.0.yaml --strategy ours`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := bundle.ParseStrategy(strategy)
+			if err != nil {
+				return err
+			}
+			mgr, err := newKBBundleManager()
+			if err != nil {
+				return err
+			}
+			b, err := bundle.Load(args[0])
+			if err != nil {
+				return err
+			}
+			plan, err := mgr.Install(b, s)
+			if errors.Is(err, bundle.ErrConflict) {
+				_ = outputBundlePlan(cmd, plan, false)
+				return fmt.Errorf("%w; rerun with --strategy ours or --strategy theirs", err)
+			}
+			if err != nil {
+				return err
+			}
+			return outputBundlePlan(cmd, plan, true)
+		},
+	}
+
+	cmd.Flags().StringVar(&strategy, "strategy", "fail", "Conflict resolution: fail, ours or theirs")
+	return cmd
+}
+
+// newKBRollbackCmd creates the kb rollback subcommand
+func newKBRollbackCmd() *cobra.Command {
+	var strategy string
+
+	cmd := &cobra.Command{
+		Use:   "rollback <bundle-name>",
+		Short: "Roll a bundle back to its previous version",
+		Long: `Reinstall the version of a bundle that was installed before the current
+one. Local edits made since are kept where they do not conflict.`,
+		Example: `  ksa kb rollback redis-core`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := bundle.ParseStrategy(strategy)
+			if err != nil {
+				return err
+			}
+			mgr, err := newKBBundleManager()
+			if err != nil {
+				return err
+			}
+			plan, err := mgr.Rollback(args[0], s)
+			if errors.Is(err, bundle.ErrConflict) {
+				_ = outputBundlePlan(cmd, plan, false)
+				return fmt.Errorf("%w; rerun with --strategy ours or --strategy theirs", err)
+			}
+			if err != nil {
+				return err
+			}
+			return outputBundlePlan(cmd, plan, true)
+		},
+	}
+
+	cmd.Flags().StringVar(&strategy, "strategy", "fail", "Conflict resolution: fail, ours or theirs")
+	return cmd
+}
+
+// newKBBundlesCmd creates the kb bundles subcommand
+func newKBBundlesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "bundles",
+		Short: "List installed rule bundles",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := newKBBundleManager()
+			if err != nil {
+				return err
+			}
+			installed, err := mgr.Installed()
+			if err != nil {
+				return err
+			}
+
+			switch outputFormat, _ := cmd.Flags().GetString("output"); outputFormat {
+			case "json":
+				return kbOutputJSON(installed)
+			case "yaml":
+				return kbOutputYAML(installed)
+			}
+			if len(installed) == 0 {
+				fmt.Println("No bundles installed")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tVERSION\tAUTHOR\tSIGNED\tHISTORY")
+			for _, inst := range installed {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", inst.Name, inst.Current.Version, inst.Current.Author,
+					inst.Current.Signature != "", strings.Join(inst.Versions, " -> "))
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// newKBKeygenCmd creates the kb keygen subcommand
+func newKBKeygenCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "keygen <name>",
+		Short: "Create a key pair for signing bundles",
+		Long: `Create an ed25519 key pair for signing bundles. The private key is written
+to <name>.key and the public key to <name>.pub. Add the public key to
+knowledge.bundles.trusted_keys to only accept bundles signed with it.`,
+		Example: `  ksa kb keygen sre-team`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pub, priv, err := bundle.GenerateKey()
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(args[0]+".key", []byte(priv+"\n"), 0600); err != nil {
+				return err
+			}
+			if err := os.WriteFile(args[0]+".pub", []byte(pub+"\n"), 0644); err != nil {
+				return err
+			}
+			fmt.Printf("Private key: %s.key\nPublic key:  %s.pub\n%s\n", args[0], args[0], pub)
+			return nil
+		},
+	}
+}
+
+// newKBBundleManager loads the local knowledge base rules and bundle store
+func newKBBundleManager() (*bundle.Manager, error) {
+	cfg, err := config.LoadConfig(cfgFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	kb := knowledge.NewKnowledgeBase()
+	loader := knowledge.NewRuleLoader(kb)
+	if dir := kbRulesDir(cfg); dirExists(dir) {
+		if err := loader.LoadFromDirectory(dir); err != nil {
+			return nil, err
+		}
+	}
+	return bundle.NewManagerFromConfig(cfg.Knowledge.Bundles, kb, loader)
+}
+
+func kbRulesDir(cfg *config.Config) string {
+	if cfg.Knowledge.Bundles.RulesDir != "" {
+		return cfg.Knowledge.Bundles.RulesDir
+	}
+	return knowledge.DefaultRepoDir
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func loadKBRules(loader *knowledge.RuleLoader, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read rules: %w", err)
+	}
+	if info.IsDir() {
+		return loader.LoadFromDirectory(path)
+	}
+	return loader.LoadFromFile(path)
+}
+
+func readBundleKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	return bundle.ParsePrivateKey(strings.TrimSpace(string(data)))
+}
+
+func outputBundlePlan(cmd *cobra.Command, plan *bundle.Plan, applied bool) error {
+	switch outputFormat, _ := cmd.Flags().GetString("output"); outputFormat {
+	case "json":
+		return kbOutputJSON(plan)
+	case "yaml":
+		return kbOutputYAML(plan)
+	}
+
+	from := "not installed"
+	if plan.From != "" {
+		from = plan.From
+	}
+	fmt.Printf("Bundle %s %s by %s (installed: %s)\n", plan.Bundle.Name, plan.Bundle.Version, plan.Bundle.Author, from)
+	if len(plan.Changes) == 0 && len(plan.Conflicts) == 0 {
+		fmt.Println("No changes")
+	}
+	for _, c := range plan.Changes {
+		line := fmt.Sprintf("  %-7s %s", c.Action, c.RuleID)
+		if len(c.Fields) > 0 {
+			line += " (" + strings.Join(c.Fields, ", ") + ")"
+		}
+		fmt.Println(line)
+	}
+	if len(plan.Conflicts) > 0 {
+		fmt.Println("Conflicts:")
+		for _, c := range plan.Conflicts {
+			line := fmt.Sprintf("  %s: %s", c.RuleID, c.Reason)
+			if c.Resolution != "" {
+				line += fmt.Sprintf(" -> %s (%s)", c.Resolution, plan.Strategy)
+			}
+			fmt.Println(line)
+		}
+	}
+	if applied {
+		fmt.Printf("Installed %s %s\n", plan.Bundle.Name, plan.Bundle.Version)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/diagnosis"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/knowledge"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/client"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/provider"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/server"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/storage"
+	"github.com/kubestack-ai/kubestack-ai/internal/plugins/manager"
+	"github.com/kubestack-ai/kubestack-ai/internal/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// newMCPCmd creates the mcp command
+func newMCPCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Model Context Protocol integration",
+		// Stdout may carry the protocol, so the root initialization that
+		// logs to it is replaced by a stderr logger
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if cfgFile == "" {
+				cfgFile = "configs/config.yaml"
+			}
+			cfg, err := config.LoadConfig(cfgFile)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			logCfg := cfg.Logger
+			logCfg.Level = viper.GetString("logger.level")
+			if logCfg.Output != "file" {
+				logCfg.Output = "stderr"
+			}
+			logger.InitGlobalLogger(&logCfg)
+			return nil
+		},
+	}
+	cmd.AddCommand(newMCPServeCmd())
+	return cmd
+}
+
+func newMCPServeCmd() *cobra.Command {
+	var transport, addr, path, token string
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve diagnosis tools, resources and prompts to MCP clients",
+		Long: `Serve KubeStack-AI as an MCP server.
+
+Tools:     diagnose
+Resources: ksa://diagnosis/reports/{id}   reports of diagnoses run by clients
+           ksa://knowledge/rules/{id}     knowledge base rules
+           ksa://alerts/history           alert history, ksa://alerts/active
+           ksa://metrics/{type}/{name}    metric snapshot of an instance
+Prompts:   diagnosis_analysis, solution_generation, question_clarification
+
+The stdio transport serves one client on stdin/stdout. The http transport
+serves Streamable HTTP clients at --addr and --path.`,
+		Example: `  # Let a local MCP client spawn ksa
+  ksa mcp serve
+
+  # Serve remote clients with a bearer token
+  ksa mcp serve --transport http --addr 0.0.0.0:8765 --token s3cret`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig(cfgFile)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			serverCfg := cfg.MCP.Server
+			flags := cmd.Flags()
+			if flags.Changed("transport") || serverCfg.Transport == "" {
+				serverCfg.Transport = transport
+			}
+			if flags.Changed("addr") || serverCfg.Addr == "" {
+				serverCfg.Addr = addr
+			}
+			if flags.Changed("path") || serverCfg.Path == "" {
+				serverCfg.Path = path
+			}
+			if flags.Changed("token") {
+				serverCfg.AuthToken = token
+			}
+
+			mcpServer, closeFn, err := newMCPServer(cfg, serverCfg)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			log := logger.NewLogger("mcp")
+			switch serverCfg.Transport {
+			case "stdio":
+				log.Info("Serving MCP over stdio")
+				err = mcpServer.ServeStdio(ctx)
+			case "http":
+				log.Infof("Serving MCP over Streamable HTTP at http://%s%s", serverCfg.Addr, serverCfg.Path)
+				err = mcpServer.ListenAndServeHTTP(ctx, serverCfg.Addr, serverCfg.Path, server.HTTPOptions{
+					AuthToken:      serverCfg.AuthToken,
+					AllowedOrigins: serverCfg.AllowedOrigins,
+					SessionTimeout: serverCfg.SessionTimeout,
+				})
+			default:
+				return fmt.Errorf("unknown transport %q, want stdio or http", serverCfg.Transport)
+			}
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&transport, "transport", "stdio", "Transport: stdio or http")
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8765", "Listen address of the http transport")
+	cmd.Flags().StringVar(&path, "path", "/mcp", "Endpoint path of the http transport")
+	cmd.Flags().StringVar(&token, "token", "", "Bearer token required by the http transport")
+	return cmd
+}
+
+// newMCPServer wires the diagnosis components into an MCP server. The
+// returned function releases the stores it opened.
+func newMCPServer(cfg *config.Config, serverCfg config.MCPServerConfig) (*server.Server, func(), error) {
+	llmClient, err := client.NewClientFromConfig(&cfg.LLM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create LLM client: %w", err)
+	}
+
+	pluginRegistry, err := manager.NewRegistry([]string{cfg.Plugins.Directory})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create plugin registry: %w", err)
+	}
+	pluginManager := manager.NewManager(pluginRegistry, manager.NewLoader())
+
+	kb := knowledge.NewKnowledgeBase()
+	if dir := kbRulesDir(cfg); dirExists(dir) {
+		if err := knowledge.NewRuleLoader(kb).LoadFromDirectory(dir); err != nil {
+			return nil, nil, fmt.Errorf("failed to load knowledge rules: %w", err)
+		}
+	}
+
+	aiAnalyzer, err := diagnosis.NewAIAnalyzer(llmClient)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create AI analyzer: %w", err)
+	}
+	analyzers := []interfaces.DiagnosisAnalyzer{diagnosis.NewRuleBasedAnalyzer(nil, nil), aiAnalyzer}
+
+	// Diagnoses run through the diagnose tool become report resources
+	reports := provider.NewReportLog(provider.DefaultReportLogSize)
+	dm := diagnosis.NewManager(pluginManager, analyzers, nil, "reports", kb)
+	if diagHistory != nil {
+		dm.SetHistory(diagHistory)
+	}
+	diagManager := provider.RecordReports(dm, reports)
+
+	registry := tools.NewRegistry()
+	if err := registry.Register(tools.NewDiagnoseTool(diagManager)); err != nil {
+		return nil, nil, fmt.Errorf("failed to register diagnose tool: %w", err)
+	}
+
+	resources := []server.ResourceProvider{
+		provider.NewReports(reports, diagManager),
+		provider.NewKnowledge(kb),
+		provider.NewMetrics(pluginManager),
+	}
+	closeFn := func() {}
+	if alertStore, err := storage.NewSQLiteAlertStore(cfg.Monitor.Storage.Path); err == nil {
+		resources = append(resources, provider.NewAlerts(alertStore))
+		closeFn = func() { alertStore.Close() }
+	} else {
+		logger.NewLogger("mcp").Warnf("Alert history unavailable: %v", err)
+	}
+
+	mcpServer := server.NewServer(server.ServerConfig{
+		Name:           "kubestack-ai",
+		Version:        "0.1.0",
+		Capabilities:   protocol.ServerCapabilities{Tools: &protocol.ToolsCapability{}},
+		Resources:      resources,
+		Prompts:        []server.PromptProvider{provider.NewPrompts()},
+		MaxConcurrency: serverCfg.MaxConcurrency,
+	}, registry)
+	return mcpServer, closeFn, nil
+}
@@ -73,7 +73,7 @@ var alertListCmd = &cobra.Command{
 	Short: "List active alerts",
 	Run: func(cmd *cobra.Command, args []string) {
 		port := viper.GetInt("server.port")
-		url := fmt.Sprintf("http://localhost:%d/api/v1/alerts/history?status=firing", port)
+		url := fmt.Sprintf("http://localhost:%d/api/v1/alerts/active?state=firing", port)
 
 		resp, err := http.Get(url)
 		if err != nil {
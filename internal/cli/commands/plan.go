@@ -0,0 +1,178 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/planning"
+	"github.com/kubestack-ai/kubestack-ai/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+// newPlanCmd creates the plan command, which turns a goal into an execution plan
+func newPlanCmd() *cobra.Command {
+	var (
+		dryRun     bool
+		maxRepairs int
+		inputs     map[string]string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "plan <goal>",
+		Short: "Generate and run an execution plan for a goal",
+		Long: `Ask the LLM to break a goal down into a plan of steps. The plan is validated
+(known tools, valid arguments, no dependency cycles) and sent back for repair
+if needed. Steps without dependencies between them form parallel stages.
+Condition steps choose between branches using the outputs of earlier steps
+and the plan inputs given with --input.
+The stages are printed before anything runs; --dry-run stops there.`,
+		Example: `  # Show the plan without running it
+  ksa plan "find out why redis-cluster-01 is slow" --dry-run
+
+  # Generate and run the plan
+  ksa plan "check all kafka consumer groups for lag"
+
+  # Pass inputs that conditions can check
+  ksa plan "free memory on redis-0 if it is above 90%" --input env=prod`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if llmClient == nil {
+				return fmt.Errorf("LLM client not initialized")
+			}
+			goal := strings.Join(args, " ")
+			ctx := cmd.Context()
+
+			llm := planning.NewLLMClient(llmClient)
+			registry := newPlanToolRegistry()
+			generator := planning.NewPlanGenerator(llm, registry)
+			generator.SetMaxRepairs(maxRepairs)
+			if len(inputs) > 0 {
+				planInputs := make(map[string]any, len(inputs))
+				for k, v := range inputs {
+					planInputs[k] = v
+				}
+				generator.SetInputs(planInputs)
+			}
+
+			plan, err := generator.Generate(ctx, goal)
+			if err != nil {
+				return err
+			}
+			groups := planStages(plan)
+
+			outputFormat, _ := cmd.Flags().GetString("output")
+			if outputFormat == "json" && dryRun {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(struct {
+					Plan           *planning.Plan `json:"plan"`
+					ParallelGroups [][]string     `json:"parallel_groups"`
+				}{plan, groups})
+			}
+
+			printPlan(plan, groups)
+			if dryRun {
+				fmt.Println("\nDry run: nothing was executed.")
+				return nil
+			}
+
+			engine := planning.NewPlanEngine(
+				planning.NewDefaultStepExecutor(registry, llm),
+				planning.NewMemoryStateStore(),
+				planning.DefaultPlanEngineConfig(),
+			)
+			state, err := engine.ExecutePlan(ctx, plan)
+			if state != nil {
+				printPlanState(plan, state)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the plan without executing it")
+	cmd.Flags().StringToStringVar(&inputs, "input", nil, "Plan input as key=value, available to conditions as input.<key>")
+	cmd.Flags().IntVar(&maxRepairs, "max-repairs", planning.DefaultMaxRepairs, "How often an invalid plan is sent back to the LLM")
+	return cmd
+}
+
+// planStages returns the parallel groups of the plan with stable ordering
+func planStages(plan *planning.Plan) [][]string {
+	groups := planning.NewDAG(plan.Steps).GetParallelGroups()
+	for _, g := range groups {
+		sort.Strings(g)
+	}
+	return groups
+}
+
+func printPlan(plan *planning.Plan, groups [][]string) {
+	fmt.Printf("Plan: %s (%s)\n", plan.Name, plan.ID)
+	fmt.Printf("Goal: %s\n", plan.Description)
+	for i, group := range groups {
+		parallel := ""
+		if len(group) > 1 {
+			parallel = " (parallel)"
+		}
+		fmt.Printf("\nStage %d%s:\n", i+1, parallel)
+		for _, step := range plan.GetStepsByIDs(group) {
+			fmt.Printf("  - %s [%s] %s\n", step.ID, step.Type, step.Name)
+			switch step.Type {
+			case planning.StepTypeToolCall:
+				toolArgs, _ := json.Marshal(step.Action.ToolArgs)
+				fmt.Printf("      tool: %s %s\n", step.Action.ToolName, toolArgs)
+			case planning.StepTypeLLMQuery:
+				fmt.Printf("      prompt: %s\n", step.Action.Prompt)
+			case planning.StepTypeCondition:
+				fmt.Printf("      condition: %s\n", step.Action.Condition)
+				if len(step.Action.Then) > 0 {
+					fmt.Printf("      then: %s\n", strings.Join(step.Action.Then, ", "))
+				}
+				if len(step.Action.Else) > 0 {
+					fmt.Printf("      else: %s\n", strings.Join(step.Action.Else, ", "))
+				}
+			}
+			if len(step.DependsOn) > 0 {
+				fmt.Printf("      after: %s\n", strings.Join(step.DependsOn, ", "))
+			}
+		}
+	}
+}
+
+func printPlanState(plan *planning.Plan, state *planning.ExecutionState) {
+	fmt.Printf("\nExecution %s\n", state.Status)
+	for _, step := range plan.Steps {
+		s, ok := state.StepStates[step.ID]
+		if !ok {
+			fmt.Printf("  - %s: %s\n", step.ID, planning.StepStatusPending)
+			continue
+		}
+		if s.Error != "" {
+			fmt.Printf("  - %s: %s (%s)\n", step.ID, s.Status, s.Error)
+		} else {
+			fmt.Printf("  - %s: %s\n", step.ID, s.Status)
+		}
+	}
+}
+
+// newPlanToolRegistry returns the tools generated plans may call from the CLI
+func newPlanToolRegistry() tools.Registry {
+	registry := tools.NewRegistry()
+	_ = registry.Register(tools.NewDiagnoseTool(&lazyDiagManager{}))
+	return registry
+}
@@ -18,9 +18,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 	"text/tabwriter"
 
+	"github.com/kubestack-ai/kubestack-ai/internal/plugin"
+	kafkaplugin "github.com/kubestack-ai/kubestack-ai/internal/plugin/kafka"
+	mysqlplugin "github.com/kubestack-ai/kubestack-ai/internal/plugin/mysql"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
@@ -57,6 +62,7 @@ View available plugins, enable/disable plugins, and query plugin information.`,
 	cmd.AddCommand(newPluginInfoCmd())
 	cmd.AddCommand(newPluginEnableCmd())
 	cmd.AddCommand(newPluginDisableCmd())
+	cmd.AddCommand(newPluginServeCmd())
 
 	return cmd
 }
@@ -215,6 +221,42 @@ func newPluginDisableCmd() *cobra.Command {
 	return cmd
 }
 
+// newPluginServeCmd creates the hidden plugin serve subcommand. It is the
+// child side of an out-of-process plugin: the host starts
+// "ksa plugin serve <type>" and talks to it over stdin/stdout.
+func newPluginServeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "serve <type>",
+		Short:  "Serve a middleware plugin over stdio for an out-of-process host",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		// The child must not load the full configuration or write to stdout
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+		RunE: func(cmd *cobra.Command, args []string) error {
+			factories := map[plugin.MiddlewareType]plugin.PluginFactory{
+				plugin.MiddlewareMySQL: mysqlplugin.NewMySQLPlugin,
+				plugin.MiddlewareKafka: kafkaplugin.NewKafkaPlugin,
+			}
+			mwType := plugin.MiddlewareType(args[0])
+			factory, ok := factories[mwType]
+			if !ok {
+				return fmt.Errorf("plugin type %s cannot be served out of process", mwType)
+			}
+
+			p, err := factory(&plugin.PluginConfig{Type: mwType})
+			if err != nil {
+				return fmt.Errorf("failed to create plugin: %w", err)
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return plugin.ServeStdio(ctx, p)
+		},
+	}
+
+	return cmd
+}
+
 // getPluginDir returns the plugin directory path
 func getPluginDir() string {
 	// Try to get from config, fallback to default
@@ -256,6 +298,18 @@ func getAvailablePlugins() []PluginInfo {
 			Version:     "1.0.0",
 			Description: "PostgreSQL performance diagnostics",
 		},
+		{
+			Name:        "mongodb-diagnostics",
+			Type:        "diagnostics",
+			Version:     "1.0.0",
+			Description: "MongoDB replica set and query diagnostics",
+		},
+		{
+			Name:        "rabbitmq-diagnostics",
+			Type:        "diagnostics",
+			Version:     "1.0.0",
+			Description: "RabbitMQ queue, alarm and connection diagnostics",
+		},
 	}
 }
 
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/kubestack-ai/kubestack-ai/internal/ai/agent"
 	"github.com/kubestack-ai/kubestack-ai/internal/cli"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
@@ -29,6 +30,8 @@ import (
 	orch "github.com/kubestack-ai/kubestack-ai/internal/core/orchestrator"
 	"github.com/kubestack-ai/kubestack-ai/internal/knowledge"
 	"github.com/kubestack-ai/kubestack-ai/internal/llm/client"
+	llminterfaces "github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/nlp"
 	"github.com/kubestack-ai/kubestack-ai/internal/plugins/manager"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -41,6 +44,14 @@ var (
 	orchestrator interfaces.Orchestrator
 	// diagManager is needed for the CLI diagnose command
 	diagManager interfaces.DiagnosisManager
+	// diagHistory keeps completed diagnoses for the diagnose history/diff and fix commands
+	diagHistory diagnosis.HistoryStore
+	// llmClient is needed for the CLI plan command
+	llmClient llminterfaces.LLMClient
+	// autoFix builds, runs and records fix plans for the fix command
+	autoFix *execution.AutoFixManager
+	// chatAgent runs the tasks `ask` questions name, such as a diagnosis
+	chatAgent *agent.Agent
 )
 
 var rootCmd = &cobra.Command{
@@ -73,7 +84,7 @@ running on Kubernetes or bare metal servers.`,
 		}
 
 		// 4. Initialize all core components (Dependency Injection)
-		llmClient, err := client.NewClientFromConfig(&cfg.LLM)
+		llmClient, err = client.NewClientFromConfig(&cfg.LLM)
 		if err != nil {
 			return fmt.Errorf("failed to create LLM client: %w", err)
 		}
@@ -98,11 +109,40 @@ running on Kubernetes or bare metal servers.`,
 		analyzers := []interfaces.DiagnosisAnalyzer{ruleAnalyzer, aiAnalyzer}
 
 		// P7: Use the unified plugin manager
-		diagManager = diagnosis.NewManager(pluginManager, analyzers, nil, "reports", kb)
+		dm := diagnosis.NewManager(pluginManager, analyzers, nil, "reports", kb)
+		if cfg.History.StorePath != "" {
+			store, err := diagnosis.NewSQLiteHistoryStore(cfg.History.StorePath)
+			if err != nil {
+				log.Warnf("Failed to open diagnosis history, results will not be kept: %v", err)
+			} else {
+				diagHistory = store
+				dm.SetHistory(store)
+			}
+		}
+		diagManager = dm
 
 		// Execution components
 		execPlanner := execution.NewPlanner()
 		execManager := execution.NewManager(execPlanner)
+		var fixRecords execution.ExecutionRecordStore = execution.NewInMemoryRecordStore()
+		if cfg.Approval.RecordPath != "" {
+			store, err := execution.NewSQLiteRecordStore(cfg.Approval.RecordPath)
+			if err != nil {
+				log.Warnf("Failed to open fix execution records, executions will not be kept: %v", err)
+			} else {
+				fixRecords = store
+			}
+		}
+		autoFix = execution.NewAutoFixManager(execManager, fixRecords, fixOptions(false))
+		autoFix.SetFixRunner(execution.NewPluginFixRunner(pluginManager))
+
+		// Fixes asked for are only previewed; `ksa fix` applies them
+		chatAgent = agent.NewAgent(nlp.NewNLPProcessor(nlp.DefaultConfig(), nil), nil)
+		chatAgent.SetServices(&agent.Services{
+			Diagnosis: diagManager,
+			AutoFix:   autoFix,
+			Knowledge: kb,
+		})
 
 		// --- Orchestrator ---
 		// Warning: Missing KnowledgeManager and other components for RAG.
@@ -159,13 +199,21 @@ func init() {
 	viper.BindPFlag("output.format", rootCmd.PersistentFlags().Lookup("output"))
 
 	// Use the lazy wrapper
-	rootCmd.AddCommand(cli.NewDiagnoseCommand(&lazyDiagManager{}))
+	diagnoseCmd := cli.NewDiagnoseCommand(&lazyDiagManager{})
+	diagnoseCmd.AddCommand(newDiagnoseHistoryCmd())
+	diagnoseCmd.AddCommand(newDiagnoseDiffCmd())
+	rootCmd.AddCommand(diagnoseCmd)
 
 	rootCmd.AddCommand(newAskCmd())
 	rootCmd.AddCommand(newFixCmd())
 	rootCmd.AddCommand(newServerCmd())
 	rootCmd.AddCommand(newPluginCmd())
 	rootCmd.AddCommand(newKBCmd())
+	rootCmd.AddCommand(newGraphCmd())
+	rootCmd.AddCommand(newTaskCmd())
+	rootCmd.AddCommand(newPlanCmd())
+	rootCmd.AddCommand(newRulesCmd())
+	rootCmd.AddCommand(newMCPCmd())
 
 	rootCmd.AddCommand(&cobra.Command{
 		Use:   "version",
@@ -0,0 +1,159 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/diagnosis/rules"
+	"github.com/spf13/cobra"
+)
+
+// defaultRulePaths are the rule files shipped for the diagnosis engine,
+// the knowledge base and root cause analysis
+var defaultRulePaths = []string{
+	"data/diagnosis",
+	"internal/knowledge/repository",
+	"configs/rca/rules.yaml",
+}
+
+// newRulesCmd creates the rules command
+func newRulesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Diagnosis rule tooling",
+		Long: `Work with diagnosis rules. Diagnosis, knowledge base and root cause analysis
+rules share one schema and expression language, so the same tooling checks
+all of them.`,
+	}
+
+	cmd.AddCommand(newRulesTestCmd())
+	return cmd
+}
+
+// newRulesTestCmd creates the rules test subcommand
+func newRulesTestCmd() *cobra.Command {
+	var (
+		rulePaths    []string
+		fixturePaths []string
+		catalogPath  string
+		strict       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Run rules against fixture snapshots",
+		Long: `Evaluate every rule against recorded fixture snapshots (metrics, config,
+slow log, connections, replication and anomalies) without connecting to any
+middleware. Each fixture lists the rules expected to match it; a missing or
+unexpected match fails the run.
+
+The report also lists rules no fixture exercises and lints conditions for
+metrics the rule's plugin does not collect. With --strict those fail the
+run as well.`,
+		Example: `  # Run the shipped rules against the shipped fixtures
+  ksa rules test
+
+  # Test a rule file under development
+  ksa rules test --rules my_rules.yaml --fixtures my_fixtures/ --strict`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ruleSets, err := rules.LoadPaths(rulePaths...)
+			if err != nil {
+				return fmt.Errorf("failed to load rules: %w", err)
+			}
+			var all []rules.Rule
+			for _, rs := range ruleSets {
+				all = append(all, rs.Rules...)
+			}
+
+			fixtures, err := rules.LoadFixtures(fixturePaths...)
+			if err != nil {
+				return fmt.Errorf("failed to load fixtures: %w", err)
+			}
+
+			var catalog rules.MetricCatalog
+			if catalogPath != "" {
+				catalog, err = rules.LoadMetricCatalog(catalogPath)
+				if err != nil {
+					return fmt.Errorf("failed to load metric catalog: %w", err)
+				}
+			}
+
+			report := rules.NewHarness(all, fixtures, catalog).Run()
+
+			outputFormat, _ := cmd.Flags().GetString("output")
+			if outputFormat == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				printRulesReport(report)
+			}
+
+			if !report.Passed(strict) {
+				return fmt.Errorf("rule tests failed")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&rulePaths, "rules", defaultRulePaths, "Rule files or directories")
+	cmd.Flags().StringSliceVar(&fixturePaths, "fixtures", []string{"data/rules/fixtures"}, "Fixture files or directories")
+	cmd.Flags().StringVar(&catalogPath, "catalog", "data/rules/metrics.yaml", "Metric catalog to lint against (empty to skip)")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Fail on lint issues and rules no fixture exercises")
+	return cmd
+}
+
+func printRulesReport(report *rules.Report) {
+	failed := 0
+	for _, f := range report.Fixtures {
+		if f.Passed() {
+			fmt.Printf("PASS  %s\n", f.Fixture)
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL  %s (%s)\n", f.Fixture, f.File)
+		if len(f.Missing) > 0 {
+			fmt.Printf("      expected but not matched: %s\n", strings.Join(f.Missing, ", "))
+		}
+		if len(f.Unexpected) > 0 {
+			fmt.Printf("      matched but not expected: %s\n", strings.Join(f.Unexpected, ", "))
+		}
+		for id, msg := range f.Errors {
+			fmt.Printf("      %s: %s\n", id, msg)
+		}
+	}
+
+	fmt.Printf("\n%d/%d fixtures passed\n", len(report.Fixtures)-failed, len(report.Fixtures))
+	fmt.Printf("Coverage: %.0f%% of %d rules exercised\n", report.Coverage()*100, report.Rules)
+	if len(report.Unexercised) > 0 {
+		fmt.Println("\nRules not exercised by any fixture:")
+		for _, id := range report.Unexercised {
+			fmt.Printf("  - %s\n", id)
+		}
+	}
+	if len(report.Lint) > 0 {
+		fmt.Println("\nLint:")
+		for _, l := range report.Lint {
+			fmt.Printf("  - %s: %s\n", l.RuleID, l.Message)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/task"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// newTaskCmd creates the task command for the asynchronous task queue
+func newTaskCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "task",
+		Short: "Inspect the asynchronous task queue",
+		Long: `Inspect the task queue of a running KubeStack-AI server.
+Tasks that fail on every attempt are moved to a dead-letter queue,
+where they can be inspected and replayed once the cause is fixed.`,
+	}
+
+	cmd.AddCommand(newTaskDLQCmd())
+	return cmd
+}
+
+// newTaskDLQCmd creates the task dlq subcommand
+func newTaskDLQCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dlq",
+		Short: "Manage dead-lettered tasks",
+		Example: `  # List dead-lettered tasks
+  ksa task dlq list
+
+  # Replay one task, or all of them
+  ksa task dlq replay 3f2a9c1e-...
+  ksa task dlq replay --all`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List dead-lettered tasks",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tasks, err := fetchDeadLetters()
+			if err != nil {
+				return err
+			}
+
+			outputFormat, _ := cmd.Flags().GetString("output")
+			if outputFormat == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(tasks)
+			}
+
+			if len(tasks) == 0 {
+				fmt.Println("Dead-letter queue is empty.")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tTYPE\tPRIORITY\tATTEMPTS\tDEAD SINCE\tLAST ERROR")
+			for _, t := range tasks {
+				since := ""
+				if t.DeadLetteredAt != nil {
+					since = t.DeadLetteredAt.Format(time.RFC3339)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", t.ID, t.Type, t.Priority, t.Attempts, since, t.LastError)
+			}
+			return w.Flush()
+		},
+	})
+
+	var all bool
+	replayCmd := &cobra.Command{
+		Use:   "replay [task-id...]",
+		Short: "Move dead-lettered tasks back to the queue",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids := args
+			if all {
+				tasks, err := fetchDeadLetters()
+				if err != nil {
+					return err
+				}
+				ids = ids[:0]
+				for _, t := range tasks {
+					ids = append(ids, t.ID)
+				}
+			}
+			if len(ids) == 0 {
+				return fmt.Errorf("specify task IDs or --all")
+			}
+
+			for _, id := range ids {
+				if err := replayDeadLetter(id); err != nil {
+					return err
+				}
+				fmt.Printf("Replayed task %s\n", id)
+			}
+			return nil
+		},
+	}
+	replayCmd.Flags().BoolVar(&all, "all", false, "Replay every dead-lettered task")
+	cmd.AddCommand(replayCmd)
+
+	return cmd
+}
+
+func taskAPIURL(path string) string {
+	port := viper.GetInt("server.port")
+	if port == 0 {
+		port = 8080 // Default
+	}
+	return fmt.Sprintf("http://localhost:%d/api/v1/tasks%s", port, path)
+}
+
+func fetchDeadLetters() ([]*task.Task, error) {
+	resp, err := http.Get(taskAPIURL("/dlq"))
+	if err != nil {
+		return nil, fmt.Errorf("error querying dead-letter queue: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server returned %s: %s", resp.Status, body)
+	}
+
+	var out struct {
+		Tasks []*task.Task `json:"tasks"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return out.Tasks, nil
+}
+
+func replayDeadLetter(id string) error {
+	resp, err := http.Post(taskAPIURL("/dlq/"+url.PathEscape(id)+"/replay"), "application/json", nil)
+	if err != nil {
+		return fmt.Errorf("error replaying task %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to replay task %s: %s: %s", id, resp.Status, body)
+	}
+	return nil
+}
@@ -43,15 +43,92 @@ type Config struct {
 	Crawler             CrawlerConfig      `mapstructure:"crawler"`
 	Cron                CronConfig         `mapstructure:"cron"`
 	NLP                 NLPConfig          `mapstructure:"nlp"`
+	Planning            PlanningConfig     `mapstructure:"planning"`
+	Approval            ApprovalConfig     `mapstructure:"approval"`
+	History             HistoryConfig      `mapstructure:"history"`
 
 	// Phase 7
 	AlertDispatcher     AlertDispatcherConfig `mapstructure:"alert_dispatcher"`
 	AlertRules          []AlertRule           `mapstructure:"alert_rules"`
+
+	MCP MCPConfig `mapstructure:"mcp"`
+}
+
+// MCPConfig configures the Model Context Protocol integration.
+type MCPConfig struct {
+	Server MCPServerConfig `mapstructure:"server"`
+	// AutoDiscover connects to Servers at startup and registers their
+	// tools for planning.
+	AutoDiscover bool              `mapstructure:"auto_discover"`
+	Pool         MCPPoolConfig     `mapstructure:"pool"`
+	Servers      []MCPRemoteConfig `mapstructure:"servers"`
+}
+
+// MCPPoolConfig configures the connections to external MCP servers.
+type MCPPoolConfig struct {
+	MaxIdleTime time.Duration `mapstructure:"max_idle_time"`
+}
+
+// MCPRemoteConfig describes an external MCP server whose tools are used
+// during diagnosis. Exactly one of Command (stdio) or URL (Streamable
+// HTTP) is set.
+type MCPRemoteConfig struct {
+	ID      string            `mapstructure:"id"`
+	Command string            `mapstructure:"command"`
+	Args    []string          `mapstructure:"args"`
+	Env     []string          `mapstructure:"env"`
+	URL     string            `mapstructure:"url"`
+	Headers map[string]string `mapstructure:"headers"`
+	Auth    MCPAuthConfig     `mapstructure:"auth"`
+	Tools   MCPToolsConfig    `mapstructure:"tools"`
+}
+
+// MCPAuthConfig authenticates to a remote MCP server with a static bearer
+// token or OAuth client credentials.
+type MCPAuthConfig struct {
+	BearerToken string         `mapstructure:"bearer_token"`
+	OAuth       MCPOAuthConfig `mapstructure:"oauth"`
+}
+
+// MCPOAuthConfig configures the OAuth 2.0 client credentials flow.
+type MCPOAuthConfig struct {
+	TokenURL     string   `mapstructure:"token_url"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	Scopes       []string `mapstructure:"scopes"`
+}
+
+// MCPToolsConfig limits the tools of a server that are registered. Allow
+// and Deny hold glob patterns; Deny wins and an empty Allow allows all.
+type MCPToolsConfig struct {
+	Allow []string `mapstructure:"allow"`
+	Deny  []string `mapstructure:"deny"`
+	// AllowDestructive exposes tools annotated as destructive, and for
+	// url servers, tools not annotated read-only.
+	AllowDestructive bool `mapstructure:"allow_destructive"`
+}
+
+// MCPServerConfig configures `ksa mcp serve`, which exposes diagnosis
+// tools, resources and prompts to MCP clients.
+type MCPServerConfig struct {
+	// Transport is "stdio" or "http" (Streamable HTTP).
+	Transport string `mapstructure:"transport"`
+	Addr      string `mapstructure:"addr"`
+	Path      string `mapstructure:"path"`
+	// AuthToken, when set, is required as a bearer token over HTTP.
+	AuthToken      string   `mapstructure:"auth_token"`
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+	// MaxConcurrency bounds the requests a client runs at once.
+	MaxConcurrency int           `mapstructure:"max_concurrency"`
+	SessionTimeout time.Duration `mapstructure:"session_timeout"`
 }
 
 type AlertDispatcherConfig struct {
 	DedupWindow       time.Duration `mapstructure:"dedup_window"`
 	CorrelationWindow time.Duration `mapstructure:"correlation_window"`
+	// TopologyHops is how many dependency graph edges apart two alerting
+	// instances may be to be merged into one incident.
+	TopologyHops int `mapstructure:"topology_hops"`
 }
 
 type AlertRule struct {
@@ -159,6 +236,7 @@ type SourceConfig struct {
 	KubeConfig  string   `mapstructure:"kubeconfig"`
 	URL         string   `mapstructure:"url"`
 	Middlewares []string `mapstructure:"middlewares"`
+	Brokers     []string `mapstructure:"brokers"`
 }
 
 type AlertingConfig struct {
@@ -220,6 +298,44 @@ type KnowledgeConfig struct {
 	Language     string          `mapstructure:"language"`
 	Retrieval    RetrievalConfig `mapstructure:"retrieval"`
 	RAG          RAGConfig       `mapstructure:"rag"`
+
+	// Knowledge graph
+	Graph GraphConfig `mapstructure:"graph"`
+
+	// Rule bundles
+	Bundles BundleConfig `mapstructure:"bundles"`
+}
+
+// BundleConfig configures versioned knowledge base rule bundles.
+type BundleConfig struct {
+	// Dir keeps every installed bundle version for merges and rollback
+	Dir string `mapstructure:"dir"`
+	// RulesDir is where the knowledge base rules are kept
+	RulesDir string `mapstructure:"rules_dir"`
+	// TrustedKeys are base64 ed25519 public keys; when set, only bundles
+	// signed by one of them can be installed
+	TrustedKeys []string `mapstructure:"trusted_keys"`
+}
+
+// GraphConfig selects the knowledge graph store and configures discovery.
+type GraphConfig struct {
+	Store     string               `mapstructure:"store"` // memory/neo4j
+	Neo4j     Neo4jConfig          `mapstructure:"neo4j"`
+	Discovery GraphDiscoveryConfig `mapstructure:"discovery"`
+}
+
+type Neo4jConfig struct {
+	URI      string `mapstructure:"uri"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	Database string `mapstructure:"database"`
+}
+
+// GraphDiscoveryConfig controls the scheduled Kubernetes dependency discovery.
+type GraphDiscoveryConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	Interval  time.Duration `mapstructure:"interval"`
+	Namespace string        `mapstructure:"namespace"` // empty means all namespaces
 }
 
 // RetrievalConfig holds settings for the retrieval process.
@@ -289,14 +405,49 @@ type RAGEngineConfig struct {
 
 type DetectionConfig struct {
 	Thresholds map[string]map[string]float64 `mapstructure:"thresholds"`
+	Baseline   BaselineConfig                `mapstructure:"baseline"`
+}
+
+// BaselineConfig configures learned per-metric, per-instance baselines.
+// Zero values fall back to the detector defaults.
+type BaselineConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// ModelPath is the SQLite database learned models are persisted in
+	ModelPath string `mapstructure:"model_path"`
+	// TrainingWindow is how much monitor history a new model learns from
+	TrainingWindow time.Duration `mapstructure:"training_window"`
+	// MinSamples is how many points a model needs before it flags anything
+	MinSamples int `mapstructure:"min_samples"`
+
+	// Holt-Winters smoothing for level, trend and the hour-of-day and
+	// day-of-week seasonal profiles
+	Alpha     float64 `mapstructure:"alpha"`
+	Beta      float64 `mapstructure:"beta"`
+	GammaHour float64 `mapstructure:"gamma_hour"`
+	GammaDay  float64 `mapstructure:"gamma_day"`
+	// Phi damps the trend per hour
+	Phi float64 `mapstructure:"phi"`
+
+	// Threshold is the robust z-score beyond which a point is anomalous
+	Threshold float64 `mapstructure:"threshold"`
+	// ResidualWindow is how many recent residuals the MAD is taken over
+	ResidualWindow int `mapstructure:"residual_window"`
+	// CUSUMDrift and CUSUMThreshold tune level shift detection, in units
+	// of the robust z-score
+	CUSUMDrift     float64 `mapstructure:"cusum_drift"`
+	CUSUMThreshold float64 `mapstructure:"cusum_threshold"`
 }
 
 type RCAConfig struct {
 	Rules []RuleConfig `mapstructure:"rules"`
 }
 
+// RuleConfig is an RCA rule in the shared rule schema. Conditions is the
+// legacy list of anomaly matches, used when Condition is empty.
 type RuleConfig struct {
+	ID         string            `mapstructure:"id"`
 	Name       string            `mapstructure:"name"`
+	Condition  string            `mapstructure:"condition"`
 	Conditions []ConditionConfig `mapstructure:"conditions"`
 	RootCause  string            `mapstructure:"root_cause"`
 	Priority   int               `mapstructure:"priority"`
@@ -309,8 +460,34 @@ type ConditionConfig struct {
 }
 
 type TaskQueueConfig struct {
-	Type  string      `mapstructure:"type"`
-	Redis RedisConfig `mapstructure:"redis"`
+	Type              string        `mapstructure:"type"` // "redis" or "memory"
+	Redis             RedisConfig   `mapstructure:"redis"`
+	VisibilityTimeout time.Duration `mapstructure:"visibility_timeout"`
+	MaxAttempts       int           `mapstructure:"max_attempts"`
+	InitialBackoff    time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff        time.Duration `mapstructure:"max_backoff"`
+}
+
+// PlanningConfig configures how the server executes plans
+type PlanningConfig struct {
+	StatePath      string `mapstructure:"state_path"`      // SQLite database of plan executions and their event logs
+	RecoveryPolicy string `mapstructure:"recovery_policy"` // "rollback", "resume" or "fail" for executions interrupted by a restart
+}
+
+// ApprovalConfig configures the human approval gate for fix plans
+type ApprovalConfig struct {
+	StorePath         string        `mapstructure:"store_path"`          // SQLite database of approval requests; empty keeps them in memory
+	TTL               time.Duration `mapstructure:"ttl"`                 // How long a plan waits for approval before it expires
+	Approvers         []string      `mapstructure:"approvers"`           // Users allowed to approve; empty allows anyone with execution:approve
+	RequiredApprovals int           `mapstructure:"required_approvals"`  // Approvals needed for low and medium risk plans
+	HighRiskApprovals int           `mapstructure:"high_risk_approvals"` // Approvals needed for high and critical risk plans
+	LinkSecret        string        `mapstructure:"link_secret"`         // HMAC key of the approve/reject links in chat notifications; empty disables the links
+	RecordPath        string        `mapstructure:"record_path"`         // SQLite database of executed fix plans, shared by the CLI and the server; empty keeps them in memory
+}
+
+// HistoryConfig configures the diagnosis history
+type HistoryConfig struct {
+	StorePath string `mapstructure:"store_path"` // SQLite database of diagnosis results, shared by the CLI and the server; empty disables the history
 }
 
 type RedisConfig struct {
@@ -361,14 +538,21 @@ type PluginConfig struct {
 }
 
 type LLMConfig struct {
-	Provider string       `mapstructure:"provider"`
-	OpenAI   OpenAIConfig `mapstructure:"openai"`
-	Gemini   GeminiConfig `mapstructure:"gemini"`
+	Provider string          `mapstructure:"provider"`
+	OpenAI   OpenAIConfig    `mapstructure:"openai"`
+	Gemini   GeminiConfig    `mapstructure:"gemini"`
+	Ollama   OllamaConfig    `mapstructure:"ollama"`
+	Replay   ReplayConfig    `mapstructure:"replay"`
+	Router   LLMRouterConfig `mapstructure:"router"`
 }
 
 type OpenAIConfig struct {
 	APIKey string `mapstructure:"api_key"`
 	Model  string `mapstructure:"model"`
+	// BaseURL points the client at an OpenAI-compatible server (vLLM, Ollama,
+	// llama.cpp), e.g. http://localhost:8000/v1
+	BaseURL        string `mapstructure:"base_url"`
+	EmbeddingModel string `mapstructure:"embedding_model"`
 }
 
 type GeminiConfig struct {
@@ -376,6 +560,54 @@ type GeminiConfig struct {
 	Model  string `mapstructure:"model"`
 }
 
+// LLMRouterConfig configures the "router" provider, which tries Providers in
+// order with retries, a circuit breaker per provider and token budgets.
+type LLMRouterConfig struct {
+	Providers      []string      `mapstructure:"providers"`
+	MaxRetries     int           `mapstructure:"max_retries"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+	// BreakerFailures consecutive failures open a provider's breaker for
+	// BreakerTimeout, after which a trial request is let through
+	BreakerFailures uint32                        `mapstructure:"breaker_failures"`
+	BreakerTimeout  time.Duration                 `mapstructure:"breaker_timeout"`
+	Budgets         []TokenBudgetConfig           `mapstructure:"budgets"`
+	Pricing         map[string]ModelPricingConfig `mapstructure:"pricing"`
+}
+
+// TokenBudgetConfig caps the tokens used by a tenant and/or feature in each
+// Window. An empty Tenant or Feature matches all; a zero Window never resets.
+type TokenBudgetConfig struct {
+	Tenant  string        `mapstructure:"tenant"`
+	Feature string        `mapstructure:"feature"`
+	Tokens  int           `mapstructure:"tokens"`
+	Window  time.Duration `mapstructure:"window"`
+}
+
+// ModelPricingConfig is the price in USD per 1000 tokens of a model
+type ModelPricingConfig struct {
+	PromptPer1K     float64 `mapstructure:"prompt_per_1k"`
+	CompletionPer1K float64 `mapstructure:"completion_per_1k"`
+}
+
+// OllamaConfig configures a local Ollama server reached over plain HTTP
+type OllamaConfig struct {
+	BaseURL        string        `mapstructure:"base_url"`
+	Model          string        `mapstructure:"model"`
+	EmbeddingModel string        `mapstructure:"embedding_model"`
+	Timeout        time.Duration `mapstructure:"timeout"`
+}
+
+// ReplayConfig configures the cassette provider. In "record" mode requests go
+// to the Provider and every exchange is written to the cassette; in "replay"
+// mode only the cassette is served; "auto" replays known requests and records
+// the rest.
+type ReplayConfig struct {
+	Cassette string `mapstructure:"cassette"`
+	Mode     string `mapstructure:"mode"`
+	Provider string `mapstructure:"provider"`
+}
+
 type ServerConfig struct {
 	Port int        `mapstructure:"port"`
 	TLS  TLSConfig  `mapstructure:"tls"`
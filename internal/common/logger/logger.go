@@ -66,7 +66,7 @@ type Config struct {
 	Level string `mapstructure:"level"`
 	// Format specifies the log format, either "json" for structured logs or "text" for human-readable logs.
 	Format string `mapstructure:"format"`
-	// Output defines where logs should be sent: "console", "file", or "both".
+	// Output defines where logs should be sent: "console", "stderr", "file", or "both".
 	Output string `mapstructure:"output"`
 	// File is the path to the log file, used when Output is "file" or "both".
 	File string `mapstructure:"file"`
@@ -114,6 +114,10 @@ func InitGlobalLogger(cfg *Config) {
 			Compress:   cfg.Compress,
 		})
 	}
+	if cfg.Output == "stderr" {
+		// Keeps stdout free for protocols spoken over it, such as MCP stdio
+		writers = append(writers, os.Stderr)
+	}
 	if cfg.Output == "console" || cfg.Output == "both" || len(writers) == 0 {
 		writers = append(writers, os.Stdout)
 	}
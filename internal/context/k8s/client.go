@@ -146,7 +146,50 @@ func (c *Client) GetService(ctx context.Context, namespace, name string) (*corev
 	return c.clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
 }
 
-// TODO: Implement methods for other common resource types (StatefulSets, ConfigMaps, etc.).
+// NewClientFromClientset wraps an existing clientset, e.g. a fake clientset in tests.
+//
+// Parameters:
+//   clientset (kubernetes.Interface): The clientset used for all API calls.
+//
+// Returns:
+//   *Client: A client backed by the given clientset.
+func NewClientFromClientset(clientset kubernetes.Interface) *Client {
+	return &Client{
+		log:       logger.NewLogger("k8s-client"),
+		clientset: clientset,
+	}
+}
+
+// ListDeployments lists all Deployments in a namespace, or in all namespaces if namespace is empty.
+func (c *Client) ListDeployments(ctx context.Context, namespace string) (*appsv1.DeploymentList, error) {
+	return c.clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+}
+
+// ListStatefulSets lists all StatefulSets in a namespace, or in all namespaces if namespace is empty.
+func (c *Client) ListStatefulSets(ctx context.Context, namespace string) (*appsv1.StatefulSetList, error) {
+	return c.clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+}
+
+// ListServices lists all Services in a namespace, or in all namespaces if namespace is empty.
+func (c *Client) ListServices(ctx context.Context, namespace string) (*corev1.ServiceList, error) {
+	return c.clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+}
+
+// ListEndpoints lists all Endpoints in a namespace, or in all namespaces if namespace is empty.
+func (c *Client) ListEndpoints(ctx context.Context, namespace string) (*corev1.EndpointsList, error) {
+	return c.clientset.CoreV1().Endpoints(namespace).List(ctx, metav1.ListOptions{})
+}
+
+// GetConfigMap fetches a specific ConfigMap resource by name and namespace.
+func (c *Client) GetConfigMap(ctx context.Context, namespace, name string) (*corev1.ConfigMap, error) {
+	return c.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+// GetSecret fetches a specific Secret resource by name and namespace.
+func (c *Client) GetSecret(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+	return c.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
 // TODO: Implement methods for Custom Resources (CRDs) using a dynamic client (`dynamic.NewForConfig`).
 // TODO: Implement RBAC checks using `authorizationv1.SelfSubjectAccessReview`.
 // TODO: Implement event listening by creating and using an informer from `tools/cache`.
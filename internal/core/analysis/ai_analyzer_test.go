@@ -22,6 +22,7 @@ import (
 	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/llm"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/client"
 )
 
 // TestAIAnalyzer_ParseValidJSON tests that the analyzer correctly parses valid JSON responses.
@@ -416,3 +417,44 @@ func TestBuildAIInput(t *testing.T) {
 		t.Errorf("Expected 2 config entries, got %d", len(input.Data.Config))
 	}
 }
+
+// TestAIAnalyzer_ReplayCassette replays a recorded LLM exchange, so a change
+// to the prompt or the response handling shows up as a cassette miss or a
+// different result. Re-record with the "replay" provider in "record" mode.
+func TestAIAnalyzer_ReplayCassette(t *testing.T) {
+	replay, err := client.NewReplayClient("testdata/cassettes/ai_analyzer_redis_memory.json", client.ReplayModeReplay, nil)
+	if err != nil {
+		t.Fatalf("Failed to load cassette: %v", err)
+	}
+
+	analyzer := NewAIAnalyzer(replay, AIAnalyzerConfig{
+		Middleware: "redis",
+		Namespace:  "cache",
+		Instance:   "redis-0",
+	})
+
+	testData := &models.CollectedData{
+		Metrics: &models.MetricsData{Data: map[string]interface{}{
+			"used_memory":  972 << 20,
+			"maxmemory":    1 << 30,
+			"evicted_keys": 18234,
+		}},
+		Logs:   &models.LogData{Entries: []string{"WARNING: maxmemory reached, evicting keys"}},
+		Config: &models.ConfigData{Data: map[string]string{"maxmemory-policy": "allkeys-lru"}},
+	}
+
+	result, err := analyzer.Analyze(context.Background(), testData)
+	if err != nil {
+		t.Fatalf("Analyze() failed: %v", err)
+	}
+
+	if len(result.Issues) != 1 || result.Issues[0].ID != "redis-mem-001" {
+		t.Fatalf("Unexpected issues: %+v", result.Issues)
+	}
+	if result.Issues[0].Severity != enum.SeverityHigh {
+		t.Errorf("Expected severity 'High', got '%s'", result.Issues[0].Severity)
+	}
+	if result.Metadata["llm_tokens_used"] != 150 {
+		t.Errorf("Expected recorded token usage, got %v", result.Metadata["llm_tokens_used"])
+	}
+}
@@ -2,10 +2,11 @@ package detection
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/detection/baseline"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/detection/detectors"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/detection/models"
 )
@@ -13,11 +14,32 @@ import (
 // AnomalyDetector orchestrates multiple detectors.
 type AnomalyDetector struct {
 	detectors []Detector
+	log       logger.Logger
+}
+
+// Option configures an AnomalyDetector.
+type Option func(*options)
+
+type options struct {
+	learner *baseline.Learner
+}
+
+// WithBaseline scores time series against baselines learned by learner
+// instead of a single mean and standard deviation over the series.
+func WithBaseline(learner *baseline.Learner) Option {
+	return func(o *options) {
+		o.learner = learner
+	}
 }
 
 // NewAnomalyDetector creates a new AnomalyDetector with sub-detectors initialized from config.
 // If cfg is nil, it falls back to default hardcoded thresholds.
-func NewAnomalyDetector(cfg *config.Config) *AnomalyDetector {
+func NewAnomalyDetector(cfg *config.Config, opts ...Option) *AnomalyDetector {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	var thresholds map[string]float64
 
 	if cfg != nil && len(cfg.Detection.Thresholds) > 0 {
@@ -42,12 +64,18 @@ func NewAnomalyDetector(cfg *config.Config) *AnomalyDetector {
 		}
 	}
 
+	var seriesDetector Detector = detectors.NewTimeSeriesDetector(3.0)
+	if o.learner != nil {
+		seriesDetector = detectors.NewBaselineDetector(o.learner)
+	}
+
 	return &AnomalyDetector{
 		detectors: []Detector{
 			detectors.NewThresholdDetector(thresholds),
-			detectors.NewTimeSeriesDetector(3.0),
+			seriesDetector,
 			detectors.NewLogPatternDetector(10), // 10 errors threshold
 		},
+		log: logger.NewLogger("anomaly-detector"),
 	}
 }
 
@@ -59,9 +87,8 @@ func (ad *AnomalyDetector) Detect(ctx context.Context, input *models.DetectionIn
 	for _, d := range ad.detectors {
 		res, err := d.Detect(ctx, input)
 		if err != nil {
-			// Log error but continue with other detectors?
-			// For now, let's just print/log and continue
-			fmt.Printf("Detector %s failed: %v\n", d.Name(), err)
+			// Log the error and continue with the other detectors
+			ad.log.Warnf("Detector %s failed: %v", d.Name(), err)
 			continue
 		}
 		if res != nil {
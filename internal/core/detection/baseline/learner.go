@@ -0,0 +1,205 @@
+package baseline
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/detection/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/storage"
+)
+
+// InstanceLabel is the metric label that identifies an instance.
+const InstanceLabel = "instance"
+
+// Learner trains models from the monitor time series store, keeps them up
+// to date as new points are observed and persists them.
+type Learner struct {
+	ts     storage.TimeseriesStore
+	store  Store
+	params Params
+	log    logger.Logger
+
+	mu     sync.Mutex
+	models map[string]*Model
+}
+
+// NewLearner creates a Learner. ts may be nil, in which case models only
+// learn from observed points; store may be nil to keep models in memory.
+func NewLearner(ts storage.TimeseriesStore, store Store, params Params) *Learner {
+	return &Learner{
+		ts:     ts,
+		store:  store,
+		params: params,
+		log:    logger.NewLogger("baseline"),
+		models: make(map[string]*Model),
+	}
+}
+
+// NewLearnerFromConfig creates a Learner over ts that persists models in
+// the SQLite database at cfg.ModelPath, or keeps them in memory when no
+// path is set.
+func NewLearnerFromConfig(cfg config.BaselineConfig, ts storage.TimeseriesStore) (*Learner, error) {
+	var store Store
+	if cfg.ModelPath != "" {
+		s, err := NewSQLiteStore(cfg.ModelPath)
+		if err != nil {
+			return nil, err
+		}
+		store = s
+	}
+	return NewLearner(ts, store, ParamsFromConfig(cfg)), nil
+}
+
+// Params returns the learner's parameters.
+func (l *Learner) Params() Params {
+	return l.params
+}
+
+// Model returns the model of a series, loading it from the store or
+// training it from history the first time it is needed.
+func (l *Learner) Model(ctx context.Context, metric, instance string) (*Model, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	m, err := l.model(ctx, metric, instance, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return m.clone(), nil
+}
+
+// Train relearns the model of a series from the training window of history
+// up to now, replacing any existing model.
+func (l *Learner) Train(ctx context.Context, metric, instance string) (*Model, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	m, err := l.train(ctx, metric, instance, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return m.clone(), nil
+}
+
+// Observe scores points against the model of a series and learns from
+// those newer than the model has seen. Without points, the points recorded
+// in the time series store since the model was last updated are used.
+func (l *Learner) Observe(ctx context.Context, metric, instance string, points []models.DataPoint) ([]Observation, *Model, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	m, err := l.model(ctx, metric, instance, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if points == nil && l.ts != nil {
+		points, err = l.history(ctx, metric, instance, m.LastTime.Add(time.Nanosecond), now)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if m.Samples == 0 && len(points) > 0 {
+		// A series without history learns its seasonality from the
+		// points themselves, like it would from the store
+		fitted := Fit(metric, instance, points, l.params)
+		*m = *fitted
+	}
+
+	learned := false
+	observations := make([]Observation, 0, len(points))
+	for _, pt := range sortedPoints(points) {
+		if m.LastTime.IsZero() || pt.Time.After(m.LastTime) {
+			learned = true
+		}
+		observations = append(observations, m.Update(pt.Time, pt.Value, l.params))
+	}
+	if learned {
+		if err := l.save(ctx, m); err != nil {
+			l.log.Warnf("Failed to persist baseline %s: %v", m.Key(), err)
+		}
+	}
+	return observations, m.clone(), nil
+}
+
+// List returns the persisted models of a metric, or all if metric is empty.
+func (l *Learner) List(ctx context.Context, metric string) ([]*Model, error) {
+	if l.store != nil {
+		return l.store.List(ctx, metric)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []*Model
+	for _, m := range l.models {
+		if metric == "" || m.Metric == metric {
+			out = append(out, m.clone())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key() < out[j].Key() })
+	return out, nil
+}
+
+func (l *Learner) model(ctx context.Context, metric, instance string, now time.Time) (*Model, error) {
+	if m, ok := l.models[Key(metric, instance)]; ok {
+		return m, nil
+	}
+	if l.store != nil {
+		m, err := l.store.Load(ctx, metric, instance)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			l.models[m.Key()] = m
+			return m, nil
+		}
+	}
+	return l.train(ctx, metric, instance, now)
+}
+
+func (l *Learner) train(ctx context.Context, metric, instance string, now time.Time) (*Model, error) {
+	var points []models.DataPoint
+	if l.ts != nil {
+		var err error
+		points, err = l.history(ctx, metric, instance, now.Add(-l.params.TrainingWindow), now)
+		if err != nil {
+			return nil, err
+		}
+	}
+	m := Fit(metric, instance, points, l.params)
+	if len(points) > 0 {
+		l.log.Infof("Trained baseline %s on %d points from %s", m.Key(), len(points), m.FirstTime.Format(time.RFC3339))
+		if err := l.save(ctx, m); err != nil {
+			l.log.Warnf("Failed to persist baseline %s: %v", m.Key(), err)
+		}
+	}
+	l.models[m.Key()] = m
+	return m, nil
+}
+
+func (l *Learner) history(ctx context.Context, metric, instance string, start, end time.Time) ([]models.DataPoint, error) {
+	q := &storage.Query{Metric: metric, Labels: map[string]string{}, Start: start, End: end}
+	if instance != "" {
+		q.Labels[InstanceLabel] = instance
+	}
+	raw, err := l.ts.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history of %s: %w", Key(metric, instance), err)
+	}
+	points := make([]models.DataPoint, 0, len(raw))
+	for _, p := range raw {
+		points = append(points, models.DataPoint{Time: p.Timestamp, Value: p.Value})
+	}
+	return points, nil
+}
+
+func (l *Learner) save(ctx context.Context, m *Model) error {
+	m.UpdatedAt = time.Now()
+	if l.store == nil {
+		return nil
+	}
+	return l.store.Save(ctx, m)
+}
@@ -0,0 +1,447 @@
+// Package baseline learns per-metric, per-instance baselines from monitor
+// history so that anomalies are judged against what is normal for the time
+// of day and week rather than against a single mean or a static threshold.
+//
+// Each series gets an additive Holt-Winters model (level, trend, an
+// hour-of-day and a day-of-week profile). Forecast errors are scored with a
+// robust z-score over the median absolute deviation of recent residuals,
+// and a two-sided CUSUM over the same scores detects level shifts.
+package baseline
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/detection/models"
+)
+
+// madScale makes the MAD a consistent estimator of the standard deviation
+// for normally distributed residuals
+const madScale = 1.4826
+
+// Params tunes how models learn and score.
+type Params struct {
+	Alpha     float64
+	Beta      float64
+	GammaHour float64
+	GammaDay  float64
+	// Phi damps the trend per hour, so that forecasts across gaps in the
+	// data do not extrapolate a short-lived trend indefinitely
+	Phi float64
+
+	Threshold      float64
+	MinSamples     int
+	ResidualWindow int
+	CUSUMDrift     float64
+	CUSUMThreshold float64
+
+	TrainingWindow time.Duration
+}
+
+// DefaultParams returns the parameters used for unset config values.
+func DefaultParams() Params {
+	return Params{
+		Alpha:          0.1,
+		Beta:           0.01,
+		GammaHour:      0.1,
+		GammaDay:       0.05,
+		Phi:            0.9,
+		Threshold:      3.5,
+		MinSamples:     48,
+		ResidualWindow: 288,
+		CUSUMDrift:     0.5,
+		CUSUMThreshold: 8,
+		TrainingWindow: 14 * 24 * time.Hour,
+	}
+}
+
+// ParamsFromConfig fills unset config values with defaults.
+func ParamsFromConfig(cfg config.BaselineConfig) Params {
+	p := DefaultParams()
+	setFloat := func(dst *float64, v float64) {
+		if v > 0 {
+			*dst = v
+		}
+	}
+	setFloat(&p.Alpha, cfg.Alpha)
+	setFloat(&p.Beta, cfg.Beta)
+	setFloat(&p.GammaHour, cfg.GammaHour)
+	setFloat(&p.GammaDay, cfg.GammaDay)
+	setFloat(&p.Phi, cfg.Phi)
+	setFloat(&p.Threshold, cfg.Threshold)
+	setFloat(&p.CUSUMDrift, cfg.CUSUMDrift)
+	setFloat(&p.CUSUMThreshold, cfg.CUSUMThreshold)
+	if cfg.MinSamples > 0 {
+		p.MinSamples = cfg.MinSamples
+	}
+	if cfg.ResidualWindow > 0 {
+		p.ResidualWindow = cfg.ResidualWindow
+	}
+	if cfg.TrainingWindow > 0 {
+		p.TrainingWindow = cfg.TrainingWindow
+	}
+	return p
+}
+
+// Shift is the direction of a detected level shift.
+type Shift string
+
+const (
+	ShiftNone Shift = ""
+	ShiftUp   Shift = "up"
+	ShiftDown Shift = "down"
+)
+
+// Observation is one point scored against the model.
+type Observation struct {
+	Time     time.Time            `json:"time"`
+	Value    float64              `json:"value"`
+	Expected models.ExpectedRange `json:"expected"`
+	// Anomaly is set when the score exceeds the threshold on a model that
+	// has seen enough samples
+	Anomaly bool `json:"anomaly"`
+	// Shift is set on the point where CUSUM detects a level shift
+	Shift Shift `json:"shift,omitempty"`
+	// ShiftSize is the estimated size of the level shift
+	ShiftSize float64 `json:"shift_size,omitempty"`
+}
+
+// Model is the learned baseline of one series. It is plain data so that it
+// can be persisted as JSON and inspected.
+type Model struct {
+	Metric   string `json:"metric"`
+	Instance string `json:"instance"`
+
+	Level     float64     `json:"level"`
+	Trend     float64     `json:"trend"` // per hour
+	HourOfDay [24]float64 `json:"hour_of_day"`
+	DayOfWeek [7]float64  `json:"day_of_week"`
+
+	// Residuals holds recent (clipped) forecast errors for MAD scoring
+	Residuals []float64 `json:"residuals"`
+
+	CUSUMPos float64 `json:"cusum_pos"`
+	CUSUMNeg float64 `json:"cusum_neg"`
+	// Running sums of raw residuals while each CUSUM side is active, used
+	// to estimate the size of a shift
+	PosSum float64 `json:"pos_sum"`
+	PosN   int     `json:"pos_n"`
+	NegSum float64 `json:"neg_sum"`
+	NegN   int     `json:"neg_n"`
+
+	Samples   int       `json:"samples"`
+	FirstTime time.Time `json:"first_time"`
+	LastTime  time.Time `json:"last_time"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Key identifies the series a model belongs to.
+func (m *Model) Key() string {
+	return Key(m.Metric, m.Instance)
+}
+
+// Key builds the series key for a metric and instance.
+func Key(metric, instance string) string {
+	return metric + "|" + instance
+}
+
+func (m *Model) clone() *Model {
+	c := *m
+	c.Residuals = append([]float64(nil), m.Residuals...)
+	return &c
+}
+
+// Fit learns a new model from historical points. The seasonal profiles are
+// initialised from hour-of-day and day-of-week averages, which need at
+// least two days and two weeks of history respectively, and the model is
+// then warmed up by replaying the points in order.
+func Fit(metric, instance string, points []models.DataPoint, p Params) *Model {
+	m := &Model{Metric: metric, Instance: instance}
+	points = sortedPoints(points)
+	if len(points) == 0 {
+		return m
+	}
+
+	var sum float64
+	for _, pt := range points {
+		sum += pt.Value
+	}
+	mean := sum / float64(len(points))
+	m.Level = mean
+
+	span := points[len(points)-1].Time.Sub(points[0].Time)
+	if span >= 48*time.Hour {
+		copy(m.HourOfDay[:], profile(points, 24, mean, func(t time.Time) (int, float64) {
+			return t.UTC().Hour(), 0
+		}))
+	}
+	if span >= 14*24*time.Hour {
+		copy(m.DayOfWeek[:], profile(points, 7, mean, func(t time.Time) (int, float64) {
+			return int(t.UTC().Weekday()), seasonal(m.HourOfDay[:], hourPos(t))
+		}))
+	}
+
+	for _, pt := range points {
+		m.Update(pt.Time, pt.Value, p)
+	}
+	return m
+}
+
+// profile averages the points per bucket, less the mean and any offset the
+// bucket function returns, giving a zero-mean seasonal profile
+func profile(points []models.DataPoint, n int, mean float64, bucket func(time.Time) (int, float64)) []float64 {
+	sums := make([]float64, n)
+	counts := make([]int, n)
+	for _, pt := range points {
+		i, offset := bucket(pt.Time)
+		sums[i] += pt.Value - mean - offset
+		counts[i]++
+	}
+	out := make([]float64, n)
+	for i := range out {
+		if counts[i] > 0 {
+			out[i] = sums[i] / float64(counts[i])
+		}
+	}
+	centre(out)
+	return out
+}
+
+// Forecast returns the expected value at t and its components.
+func (m *Model) Forecast(t time.Time, p Params) models.ExpectedRange {
+	var dt float64
+	if !m.LastTime.IsZero() {
+		dt = t.Sub(m.LastTime).Hours()
+	}
+	r := models.ExpectedRange{
+		Level:     m.Level,
+		Trend:     m.Trend * damped(dt, p.Phi),
+		HourOfDay: seasonal(m.HourOfDay[:], hourPos(t)),
+		DayOfWeek: seasonal(m.DayOfWeek[:], dayPos(t)),
+	}
+	r.Value = r.Level + r.Trend + r.HourOfDay + r.DayOfWeek
+	return r
+}
+
+// Expected returns the forecast at t with the range of values the model
+// considers normal.
+func (m *Model) Expected(t time.Time, p Params) models.ExpectedRange {
+	exp, _, _ := m.expected(t, p)
+	return exp
+}
+
+func (m *Model) expected(t time.Time, p Params) (models.ExpectedRange, float64, float64) {
+	exp := m.Forecast(t, p)
+	med, scale := m.residualStats(exp.Value)
+	exp.Lower = exp.Value + med - p.Threshold*scale
+	exp.Upper = exp.Value + med + p.Threshold*scale
+	return exp, med, scale
+}
+
+// Score scores a value at t without learning from it.
+func (m *Model) Score(t time.Time, value float64, p Params) Observation {
+	exp, med, scale := m.expected(t, p)
+	exp.Score = (value - exp.Value - med) / scale
+
+	return Observation{
+		Time:     t,
+		Value:    value,
+		Expected: exp,
+		Anomaly:  m.Samples >= p.MinSamples && math.Abs(exp.Score) > p.Threshold,
+	}
+}
+
+// Update scores a value at t and then learns from it. Points at or before
+// the last learned point are only scored.
+func (m *Model) Update(t time.Time, value float64, p Params) Observation {
+	obs := m.Score(t, value, p)
+	if !m.LastTime.IsZero() && !t.After(m.LastTime) {
+		return obs
+	}
+	if m.Samples == 0 {
+		m.Level = value - seasonal(m.HourOfDay[:], hourPos(t)) - seasonal(m.DayOfWeek[:], dayPos(t))
+		m.FirstTime = t
+	}
+
+	// Learn from a clipped value so that anomalies do not drag the
+	// baseline towards themselves
+	forecast := obs.Expected.Value
+	residual := value - forecast
+	limit := (obs.Expected.Upper - obs.Expected.Lower) / 2
+	clipped := residual
+	if m.Samples >= p.MinSamples {
+		clipped = math.Max(-limit, math.Min(limit, residual))
+	}
+	y := forecast + clipped
+
+	m.detectShift(&obs, residual, p)
+
+	var dt float64
+	if !m.LastTime.IsZero() {
+		dt = t.Sub(m.LastTime).Hours()
+	}
+	hp, dp := hourPos(t), dayPos(t)
+
+	if m.Samples > 0 {
+		sh, sd := seasonal(m.HourOfDay[:], hp), seasonal(m.DayOfWeek[:], dp)
+		level := p.Alpha*(y-sh-sd) + (1-p.Alpha)*(m.Level+m.Trend*damped(dt, p.Phi))
+		if dt > 0 {
+			m.Trend = p.Beta*(level-m.Level)/dt + (1-p.Beta)*math.Pow(p.Phi, dt)*m.Trend
+		}
+		m.Level = level
+		adjust(m.HourOfDay[:], hp, p.GammaHour*(y-m.Level-sd-sh))
+		sh = seasonal(m.HourOfDay[:], hp)
+		adjust(m.DayOfWeek[:], dp, p.GammaDay*(y-m.Level-sh-sd))
+		m.Level += centre(m.HourOfDay[:]) + centre(m.DayOfWeek[:])
+	}
+
+	m.Residuals = append(m.Residuals, clipped)
+	if len(m.Residuals) > p.ResidualWindow {
+		m.Residuals = m.Residuals[len(m.Residuals)-p.ResidualWindow:]
+	}
+	m.Samples++
+	m.LastTime = t
+	return obs
+}
+
+// detectShift runs the CUSUM over the point's score and, on a shift,
+// moves the level by the mean residual since the shift began
+func (m *Model) detectShift(obs *Observation, residual float64, p Params) {
+	if m.Samples < p.MinSamples {
+		return
+	}
+	// Clamp the score so that one outlier cannot trip the CUSUM alone
+	z := math.Max(-p.Threshold, math.Min(p.Threshold, obs.Expected.Score))
+
+	m.CUSUMPos = math.Max(0, m.CUSUMPos+z-p.CUSUMDrift)
+	m.CUSUMNeg = math.Max(0, m.CUSUMNeg-z-p.CUSUMDrift)
+	if m.CUSUMPos > 0 {
+		m.PosSum += residual
+		m.PosN++
+	} else {
+		m.PosSum, m.PosN = 0, 0
+	}
+	if m.CUSUMNeg > 0 {
+		m.NegSum += residual
+		m.NegN++
+	} else {
+		m.NegSum, m.NegN = 0, 0
+	}
+
+	switch {
+	case m.CUSUMPos > p.CUSUMThreshold:
+		obs.Shift = ShiftUp
+		obs.ShiftSize = m.PosSum / float64(m.PosN)
+	case m.CUSUMNeg > p.CUSUMThreshold:
+		obs.Shift = ShiftDown
+		obs.ShiftSize = m.NegSum / float64(m.NegN)
+	default:
+		return
+	}
+	m.Level += obs.ShiftSize
+	m.CUSUMPos, m.CUSUMNeg = 0, 0
+	m.PosSum, m.PosN, m.NegSum, m.NegN = 0, 0, 0, 0
+}
+
+// residualStats returns the median and MAD-based scale of recent residuals.
+// The scale is floored at 1% of the forecast so that a perfectly flat
+// series does not turn every tiny change into an anomaly.
+func (m *Model) residualStats(forecast float64) (float64, float64) {
+	floor := math.Max(0.01*math.Abs(forecast), 1e-9)
+	if len(m.Residuals) == 0 {
+		return 0, floor
+	}
+	med := median(m.Residuals)
+	dev := make([]float64, len(m.Residuals))
+	for i, r := range m.Residuals {
+		dev[i] = math.Abs(r - med)
+	}
+	return med, math.Max(madScale*median(dev), floor)
+}
+
+// Band forecasts the expected range at each step from from, for
+// explaining a model.
+func (m *Model) Band(from time.Time, steps int, step time.Duration, p Params) []models.ExpectedRange {
+	out := make([]models.ExpectedRange, 0, steps)
+	for i := 0; i < steps; i++ {
+		out = append(out, m.Expected(from.Add(time.Duration(i)*step), p))
+	}
+	return out
+}
+
+// hourPos is t's position in the hour-of-day profile. Each bucket holds
+// the value at half past the hour; positions in between interpolate, so
+// that steep daily ramps are not flattened into hourly steps.
+func hourPos(t time.Time) float64 {
+	utc := t.UTC()
+	return float64(utc.Hour()) + float64(utc.Minute())/60 + float64(utc.Second())/3600 - 0.5
+}
+
+// dayPos is t's position in the day-of-week profile, whose buckets hold
+// the value at noon
+func dayPos(t time.Time) float64 {
+	return float64(t.UTC().Weekday()) + (hourPos(t)+0.5)/24 - 0.5
+}
+
+// seasonal interpolates a cyclic profile at pos
+func seasonal(profile []float64, pos float64) float64 {
+	i0, i1, w := neighbours(len(profile), pos)
+	return (1-w)*profile[i0] + w*profile[i1]
+}
+
+// adjust moves a cyclic profile at pos by delta, split between the two
+// neighbouring buckets
+func adjust(profile []float64, pos, delta float64) {
+	i0, i1, w := neighbours(len(profile), pos)
+	profile[i0] += (1 - w) * delta
+	profile[i1] += w * delta
+}
+
+func neighbours(n int, pos float64) (int, int, float64) {
+	x := math.Mod(pos, float64(n))
+	if x < 0 {
+		x += float64(n)
+	}
+	floor := math.Floor(x)
+	i0 := int(floor) % n
+	return i0, (i0 + 1) % n, x - floor
+}
+
+// damped returns how many hours of trend a forecast dt hours ahead adds
+func damped(dt, phi float64) float64 {
+	if phi >= 1 || dt <= 0 {
+		return dt
+	}
+	return phi * (1 - math.Pow(phi, dt)) / (1 - phi)
+}
+
+func median(values []float64) float64 {
+	s := append([]float64(nil), values...)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
+
+// centre subtracts the mean from values and returns it
+func centre(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	for i := range values {
+		values[i] -= mean
+	}
+	return mean
+}
+
+func sortedPoints(points []models.DataPoint) []models.DataPoint {
+	out := append([]models.DataPoint(nil), points...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out
+}
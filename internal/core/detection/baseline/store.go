@@ -0,0 +1,133 @@
+package baseline
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Store persists learned models.
+type Store interface {
+	// Load returns the model for a series, or nil if none was saved
+	Load(ctx context.Context, metric, instance string) (*Model, error)
+	Save(ctx context.Context, m *Model) error
+	// List returns every saved model of a metric, or all models if metric
+	// is empty
+	List(ctx context.Context, metric string) ([]*Model, error)
+	Close() error
+}
+
+// SQLiteStore keeps models as JSON rows in SQLite.
+type SQLiteStore struct {
+	db *sql.DB
+	mu sync.RWMutex
+}
+
+// NewSQLiteStore opens or creates a model store at path.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create baseline store directory: %w", err)
+		}
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+    CREATE TABLE IF NOT EXISTS baselines (
+        metric TEXT NOT NULL,
+        instance TEXT NOT NULL,
+        model TEXT NOT NULL, -- JSON encoded Model
+        updated_at DATETIME NOT NULL,
+        PRIMARY KEY (metric, instance)
+    );
+    `
+	if _, err := db.Exec(query); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to init baseline db: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Load returns the saved model of a series.
+func (s *SQLiteStore) Load(ctx context.Context, metric, instance string) (*Model, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var data string
+	err := s.db.QueryRowContext(ctx, "SELECT model FROM baselines WHERE metric = ? AND instance = ?", metric, instance).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m Model
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		return nil, fmt.Errorf("failed to decode baseline %s: %w", Key(metric, instance), err)
+	}
+	return &m, nil
+}
+
+// Save upserts a model.
+func (s *SQLiteStore) Save(ctx context.Context, m *Model) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+        INSERT INTO baselines (metric, instance, model, updated_at) VALUES (?, ?, ?, ?)
+        ON CONFLICT(metric, instance) DO UPDATE SET model = excluded.model, updated_at = excluded.updated_at`,
+		m.Metric, m.Instance, string(data), m.UpdatedAt)
+	return err
+}
+
+// List returns the saved models of a metric.
+func (s *SQLiteStore) List(ctx context.Context, metric string) ([]*Model, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := "SELECT model FROM baselines"
+	var args []interface{}
+	if metric != "" {
+		query += " WHERE metric = ?"
+		args = append(args, metric)
+	}
+	query += " ORDER BY metric, instance"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Model
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var m Model
+		if err := json.Unmarshal([]byte(data), &m); err != nil {
+			continue // Skip models saved by an incompatible version
+		}
+		out = append(out, &m)
+	}
+	return out, rows.Err()
+}
+
+// Close closes the database.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
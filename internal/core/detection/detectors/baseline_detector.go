@@ -0,0 +1,132 @@
+package detectors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/core/detection/baseline"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/detection/models"
+)
+
+// Context keys BaselineDetector reads from DetectionInput.Context.
+const (
+	ContextMetric   = "metric"
+	ContextInstance = "instance"
+)
+
+// BaselineDetector scores time series against learned per-metric,
+// per-instance baselines with hour-of-day and day-of-week seasonality, so
+// that regular daily cycles are not reported as anomalies.
+type BaselineDetector struct {
+	learner *baseline.Learner
+}
+
+// NewBaselineDetector creates a new BaselineDetector.
+func NewBaselineDetector(learner *baseline.Learner) *BaselineDetector {
+	return &BaselineDetector{learner: learner}
+}
+
+// Name returns the name of the detector.
+func (d *BaselineDetector) Name() string {
+	return "BaselineDetector"
+}
+
+// Detect scores input.TimeSeries against the baseline of the metric and
+// instance named in input.Context. Without a time series, the points
+// recorded in the monitor store since the last run are scored.
+func (d *BaselineDetector) Detect(ctx context.Context, input *models.DetectionInput) (*models.DetectionResult, error) {
+	metric := input.Context[ContextMetric]
+	if metric == "" {
+		return &models.DetectionResult{DetectedAt: time.Now()}, nil
+	}
+	instance := input.Context[ContextInstance]
+
+	observations, model, err := d.learner.Observe(ctx, metric, instance, input.TimeSeries)
+	if err != nil {
+		return nil, err
+	}
+
+	params := d.learner.Params()
+	var anomalies []models.Anomaly
+	for _, obs := range observations {
+		if obs.Shift != baseline.ShiftNone {
+			anomalies = append(anomalies, d.shiftAnomaly(metric, instance, obs))
+		}
+		if !obs.Anomaly {
+			continue
+		}
+		anomalyType := models.AnomalyTypeTrafficSpike
+		if obs.Value < obs.Expected.Value {
+			anomalyType = models.AnomalyTypeTrafficDrop
+		}
+		expected := obs.Expected
+		anomalies = append(anomalies, models.Anomaly{
+			Type:     anomalyType,
+			Severity: d.calculateSeverity(math.Abs(expected.Score), params.Threshold),
+			Description: fmt.Sprintf("%s on %s is %.2f, expected %.2f (%.2f-%.2f) at %s (score=%.2f)",
+				metric, instanceName(instance), obs.Value, expected.Value, expected.Lower, expected.Upper,
+				obs.Time.Format(time.RFC3339), expected.Score),
+			StartTime: obs.Time,
+			EndTime:   obs.Time,
+			Metadata:  baselineMetadata(metric, instance, expected),
+			Expected:  &expected,
+		})
+	}
+
+	// Confidence grows with the history the model has learned from
+	confidence := math.Min(1, float64(model.Samples)/float64(4*params.MinSamples))
+	return &models.DetectionResult{
+		Anomalies:  anomalies,
+		Confidence: confidence,
+		DetectedAt: time.Now(),
+	}, nil
+}
+
+func (d *BaselineDetector) shiftAnomaly(metric, instance string, obs baseline.Observation) models.Anomaly {
+	expected := obs.Expected
+	metadata := baselineMetadata(metric, instance, expected)
+	metadata["shift"] = string(obs.Shift)
+	metadata["shift_size"] = fmt.Sprintf("%.2f", obs.ShiftSize)
+	return models.Anomaly{
+		Type:     models.AnomalyTypeLevelShift,
+		Severity: models.SeverityMedium,
+		Description: fmt.Sprintf("%s on %s shifted %s by %.2f at %s",
+			metric, instanceName(instance), obs.Shift, math.Abs(obs.ShiftSize), obs.Time.Format(time.RFC3339)),
+		StartTime: obs.Time,
+		EndTime:   obs.Time,
+		Metadata:  metadata,
+		Expected:  &expected,
+	}
+}
+
+func (d *BaselineDetector) calculateSeverity(score, threshold float64) string {
+	ratio := score / threshold
+	if ratio >= 2.0 {
+		return models.SeverityCritical
+	} else if ratio >= 1.5 {
+		return models.SeverityHigh
+	} else if ratio >= 1.2 {
+		return models.SeverityMedium
+	}
+	return models.SeverityLow
+}
+
+func baselineMetadata(metric, instance string, expected models.ExpectedRange) map[string]string {
+	return map[string]string{
+		"metric":   metric,
+		"instance": instance,
+		"expected": fmt.Sprintf("%.2f", expected.Value),
+		"lower":    fmt.Sprintf("%.2f", expected.Lower),
+		"upper":    fmt.Sprintf("%.2f", expected.Upper),
+		"score":    fmt.Sprintf("%.2f", expected.Score),
+	}
+}
+
+func instanceName(instance string) string {
+	if instance == "" {
+		return "all instances"
+	}
+	return instance
+}
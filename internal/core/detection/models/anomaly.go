@@ -13,6 +13,7 @@ const (
 	AnomalyTypeTrafficDrop   = "TrafficDrop"
 	AnomalyTypeSlowQuery     = "SlowQuery"
 	AnomalyTypeLogPattern    = "LogPattern"
+	AnomalyTypeLevelShift    = "LevelShift"
 )
 
 // Severity levels
@@ -31,6 +32,23 @@ type Anomaly struct {
 	StartTime   time.Time         `json:"start_time"`
 	EndTime     time.Time         `json:"end_time"`
 	Metadata    map[string]string `json:"metadata,omitempty"`
+	// Expected is the range a learned baseline predicted, if any
+	Expected *ExpectedRange `json:"expected,omitempty"`
+}
+
+// ExpectedRange is what a learned baseline expected at a point in time,
+// broken down into the components that explain it.
+type ExpectedRange struct {
+	Value float64 `json:"value"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	// Score is the robust (MAD-based) z-score of the observed value
+	Score float64 `json:"score"`
+
+	Level     float64 `json:"level"`
+	Trend     float64 `json:"trend"`
+	HourOfDay float64 `json:"hour_of_day"`
+	DayOfWeek float64 `json:"day_of_week"`
 }
 
 // DetectionResult holds the output of a detection run.
@@ -0,0 +1,105 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnosis
+
+import (
+	"strings"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+)
+
+// identityKeys are the issue metadata entries naming the object an issue
+// is about. Other metadata, such as counters, changes between runs.
+var identityKeys = []string{"node", "member", "replica", "vhost", "queue", "database", "schema", "table", "ns", "slot"}
+
+// SeverityChange is an issue found by both diagnoses at different severities.
+type SeverityChange struct {
+	Issue *models.Issue      `json:"issue"`
+	From  enum.SeverityLevel `json:"from"`
+	To    enum.SeverityLevel `json:"to"`
+}
+
+// DiagnosisDiff compares two diagnoses of the same target.
+type DiagnosisDiff struct {
+	FromID string `json:"from_id"`
+	ToID   string `json:"to_id"`
+	// Appeared holds the issues only the later diagnosis found
+	Appeared []*models.Issue `json:"appeared"`
+	// Resolved holds the issues only the earlier diagnosis found
+	Resolved []*models.Issue  `json:"resolved"`
+	Changed  []SeverityChange `json:"changed"`
+	// Unchanged counts the issues found by both at the same severity
+	Unchanged int `json:"unchanged"`
+}
+
+// DiffResults compares the issues of two diagnoses. Issues are matched by
+// title and by the metadata naming the object they concern, so that, for
+// example, the same slow queue reported with a different depth is one issue.
+func DiffResults(from, to *models.DiagnosisResult) *DiagnosisDiff {
+	diff := &DiagnosisDiff{
+		FromID:   from.ID,
+		ToID:     to.ID,
+		Appeared: []*models.Issue{},
+		Resolved: []*models.Issue{},
+		Changed:  []SeverityChange{},
+	}
+
+	before := make(map[string][]*models.Issue)
+	for _, issue := range from.Issues {
+		k := issueKey(issue)
+		before[k] = append(before[k], issue)
+	}
+
+	matched := make(map[*models.Issue]bool)
+	for _, issue := range to.Issues {
+		k := issueKey(issue)
+		if len(before[k]) == 0 {
+			diff.Appeared = append(diff.Appeared, issue)
+			continue
+		}
+		prev := before[k][0]
+		before[k] = before[k][1:]
+		matched[prev] = true
+		if prev.Severity != issue.Severity {
+			diff.Changed = append(diff.Changed, SeverityChange{Issue: issue, From: prev.Severity, To: issue.Severity})
+		} else {
+			diff.Unchanged++
+		}
+	}
+
+	for _, issue := range from.Issues {
+		if !matched[issue] {
+			diff.Resolved = append(diff.Resolved, issue)
+		}
+	}
+	return diff
+}
+
+func issueKey(issue *models.Issue) string {
+	return issue.Title + "\x00" + IssueSubject(issue)
+}
+
+// IssueSubject names the object an issue concerns from its metadata, such
+// as "vhost=/ queue=orders", or returns "" for issues about the whole target.
+func IssueSubject(issue *models.Issue) string {
+	var parts []string
+	for _, k := range identityKeys {
+		if v, ok := issue.Metadata[k]; ok {
+			parts = append(parts, k+"="+v)
+		}
+	}
+	return strings.Join(parts, " ")
+}
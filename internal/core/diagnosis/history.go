@@ -0,0 +1,405 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnosis
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrDiagnosisNotFound is returned for diagnosis IDs the history does not know.
+var ErrDiagnosisNotFound = errors.New("diagnosis not found")
+
+// ErrDiagnosisExists is returned when saving a diagnosis ID that is already recorded.
+var ErrDiagnosisExists = errors.New("diagnosis already recorded")
+
+const (
+	// DefaultHistoryLimit is the number of entries listed when a query sets no limit
+	DefaultHistoryLimit = 100
+	// MaxHistoryLimit is the largest number of entries a query may list
+	MaxHistoryLimit = 1000
+)
+
+// HistoryEntry is a diagnosis result recorded with the target it diagnosed.
+type HistoryEntry struct {
+	ID          string    `json:"id"`
+	Middleware  string    `json:"middleware"`
+	Instance    string    `json:"instance"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Status      string    `json:"status"`
+	HealthScore int       `json:"health_score"`
+	IssueCount  int       `json:"issue_count"`
+	Timestamp   time.Time `json:"timestamp"`
+	// Result is the full result. List leaves it empty.
+	Result *models.DiagnosisResult `json:"result,omitempty"`
+}
+
+// NewHistoryEntry records result as the outcome of req.
+func NewHistoryEntry(req *models.DiagnosisRequest, result *models.DiagnosisResult) *HistoryEntry {
+	entry := &HistoryEntry{
+		ID:          result.ID,
+		Status:      result.Status.String(),
+		HealthScore: HealthScore(result.Issues),
+		IssueCount:  len(result.Issues),
+		Timestamp:   result.Timestamp,
+		Result:      result,
+	}
+	if req != nil {
+		entry.Middleware = strings.ToLower(req.TargetMiddleware.String())
+		entry.Instance = req.Instance
+		entry.Namespace = req.Namespace
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	return entry
+}
+
+// HealthScore rates a diagnosis from 100 (no issues) down to 0, taking
+// points off for each issue by severity.
+func HealthScore(issues []*models.Issue) int {
+	score := 100
+	for _, issue := range issues {
+		switch issue.Severity {
+		case enum.SeverityCritical:
+			score -= 30
+		case enum.SeverityHigh:
+			score -= 20
+		case enum.SeverityWarning:
+			score -= 10
+		case enum.SeverityMedium:
+			score -= 5
+		case enum.SeverityLow:
+			score -= 2
+		}
+	}
+	if score < 0 {
+		score = 0
+	}
+	return score
+}
+
+// HistoryQuery selects history entries. Empty fields match everything;
+// a Limit of 0 lists DefaultHistoryLimit entries and larger limits are
+// capped at MaxHistoryLimit.
+type HistoryQuery struct {
+	Instance   string
+	Middleware string
+	Status     string
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+}
+
+func (q HistoryQuery) limit() int {
+	switch {
+	case q.Limit <= 0:
+		return DefaultHistoryLimit
+	case q.Limit > MaxHistoryLimit:
+		return MaxHistoryLimit
+	}
+	return q.Limit
+}
+
+func (q HistoryQuery) matches(e *HistoryEntry) bool {
+	return (q.Instance == "" || e.Instance == q.Instance) &&
+		(q.Middleware == "" || strings.EqualFold(e.Middleware, q.Middleware)) &&
+		(q.Status == "" || strings.EqualFold(e.Status, q.Status)) &&
+		(q.Since.IsZero() || !e.Timestamp.Before(q.Since)) &&
+		(q.Until.IsZero() || e.Timestamp.Before(q.Until))
+}
+
+// HistoryStore persists diagnosis results.
+type HistoryStore interface {
+	// Save records an entry, or returns ErrDiagnosisExists for a known ID
+	Save(ctx context.Context, entry *HistoryEntry) error
+
+	// Get returns an entry with its result, or ErrDiagnosisNotFound
+	Get(ctx context.Context, id string) (*HistoryEntry, error)
+
+	// List returns the matching entries without their results, newest first
+	List(ctx context.Context, q HistoryQuery) ([]*HistoryEntry, error)
+
+	Close() error
+}
+
+// TrendPoint is the health of an instance at one diagnosis.
+type TrendPoint struct {
+	DiagnosisID string    `json:"diagnosis_id"`
+	Timestamp   time.Time `json:"timestamp"`
+	HealthScore int       `json:"health_score"`
+	Status      string    `json:"status"`
+	IssueCount  int       `json:"issue_count"`
+}
+
+// HealthTrend returns the health scores of the diagnoses matching q,
+// oldest first.
+func HealthTrend(ctx context.Context, store HistoryStore, q HistoryQuery) ([]TrendPoint, error) {
+	entries, err := store.List(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]TrendPoint, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		points = append(points, TrendPoint{
+			DiagnosisID: e.ID,
+			Timestamp:   e.Timestamp,
+			HealthScore: e.HealthScore,
+			Status:      e.Status,
+			IssueCount:  e.IssueCount,
+		})
+	}
+	return points, nil
+}
+
+// ParseSince parses a point in time given as an RFC 3339 timestamp or as
+// an age relative to now, such as "90m", "24h" or "7d".
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: want an RFC 3339 timestamp or an age such as 24h or 7d", s)
+}
+
+// InMemoryHistoryStore is a HistoryStore for testing and single-process use.
+type InMemoryHistoryStore struct {
+	entries map[string]*HistoryEntry
+	mu      sync.RWMutex
+}
+
+// NewInMemoryHistoryStore creates an empty in-memory history.
+func NewInMemoryHistoryStore() *InMemoryHistoryStore {
+	return &InMemoryHistoryStore{entries: make(map[string]*HistoryEntry)}
+}
+
+// Save implements HistoryStore.
+func (s *InMemoryHistoryStore) Save(ctx context.Context, entry *HistoryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[entry.ID]; ok {
+		return fmt.Errorf("failed to save diagnosis %s: %w", entry.ID, ErrDiagnosisExists)
+	}
+	e := *entry
+	s.entries[entry.ID] = &e
+	return nil
+}
+
+// Get implements HistoryStore.
+func (s *InMemoryHistoryStore) Get(ctx context.Context, id string) (*HistoryEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.entries[id]
+	if !ok {
+		return nil, ErrDiagnosisNotFound
+	}
+	c := *e
+	return &c, nil
+}
+
+// List implements HistoryStore.
+func (s *InMemoryHistoryStore) List(ctx context.Context, q HistoryQuery) ([]*HistoryEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*HistoryEntry
+	for _, e := range s.entries {
+		if q.matches(e) {
+			c := *e
+			c.Result = nil
+			out = append(out, &c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Timestamp.After(out[j].Timestamp) })
+	if len(out) > q.limit() {
+		out = out[:q.limit()]
+	}
+	return out, nil
+}
+
+// Close implements HistoryStore.
+func (s *InMemoryHistoryStore) Close() error {
+	return nil
+}
+
+// SQLiteHistoryStore is a HistoryStore backed by SQLite. The CLI and the
+// server can share one database, so that `ksa fix` finds diagnoses the
+// server ran and the other way round.
+type SQLiteHistoryStore struct {
+	db *sql.DB
+	mu sync.RWMutex
+}
+
+// NewSQLiteHistoryStore opens or creates the history database at path.
+// The database runs in WAL mode and waits for locks held by other
+// processes sharing it instead of failing right away.
+func NewSQLiteHistoryStore(path string) (*SQLiteHistoryStore, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create diagnosis history directory: %w", err)
+		}
+	}
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+	CREATE TABLE IF NOT EXISTS diagnosis_history (
+		id TEXT PRIMARY KEY,
+		middleware TEXT NOT NULL,
+		instance TEXT NOT NULL,
+		namespace TEXT NOT NULL,
+		status TEXT NOT NULL,
+		health_score INTEGER NOT NULL,
+		issue_count INTEGER NOT NULL,
+		result TEXT NOT NULL, -- JSON encoded models.DiagnosisResult
+		created_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_diagnosis_history_instance ON diagnosis_history(instance, created_at);
+	CREATE INDEX IF NOT EXISTS idx_diagnosis_history_middleware ON diagnosis_history(middleware, created_at);
+	CREATE INDEX IF NOT EXISTS idx_diagnosis_history_status ON diagnosis_history(status, created_at);
+	CREATE INDEX IF NOT EXISTS idx_diagnosis_history_created_at ON diagnosis_history(created_at);
+	`
+	if _, err := db.Exec(query); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to init diagnosis history db: %w", err)
+	}
+
+	return &SQLiteHistoryStore{db: db}, nil
+}
+
+// Save implements HistoryStore.
+func (s *SQLiteHistoryStore) Save(ctx context.Context, entry *HistoryEntry) error {
+	data, err := json.Marshal(entry.Result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal diagnosis result: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO diagnosis_history
+		(id, middleware, instance, namespace, status, health_score, issue_count, result, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.ID, entry.Middleware, entry.Instance, entry.Namespace, entry.Status,
+		entry.HealthScore, entry.IssueCount, string(data), entry.Timestamp.UTC())
+	if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey {
+		return fmt.Errorf("failed to save diagnosis %s: %w", entry.ID, ErrDiagnosisExists)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save diagnosis %s: %w", entry.ID, err)
+	}
+	return nil
+}
+
+const historyColumns = `id, middleware, instance, namespace, status, health_score, issue_count, created_at`
+
+// Get implements HistoryStore.
+func (s *SQLiteHistoryStore) Get(ctx context.Context, id string) (*HistoryEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var e HistoryEntry
+	var data string
+	err := s.db.QueryRowContext(ctx, `SELECT `+historyColumns+`, result FROM diagnosis_history WHERE id = ?`, id).
+		Scan(&e.ID, &e.Middleware, &e.Instance, &e.Namespace, &e.Status, &e.HealthScore, &e.IssueCount, &e.Timestamp, &data)
+	if err == sql.ErrNoRows {
+		return nil, ErrDiagnosisNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load diagnosis %s: %w", id, err)
+	}
+	if err := json.Unmarshal([]byte(data), &e.Result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal diagnosis %s: %w", id, err)
+	}
+	return &e, nil
+}
+
+// List implements HistoryStore.
+func (s *SQLiteHistoryStore) List(ctx context.Context, q HistoryQuery) ([]*HistoryEntry, error) {
+	query := `SELECT ` + historyColumns + ` FROM diagnosis_history WHERE 1=1`
+	var args []interface{}
+	if q.Instance != "" {
+		query += " AND instance = ?"
+		args = append(args, q.Instance)
+	}
+	if q.Middleware != "" {
+		query += " AND middleware = ?"
+		args = append(args, strings.ToLower(q.Middleware))
+	}
+	if q.Status != "" {
+		query += " AND status = ? COLLATE NOCASE"
+		args = append(args, q.Status)
+	}
+	if !q.Since.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		query += " AND created_at < ?"
+		args = append(args, q.Until.UTC())
+	}
+	query += " ORDER BY created_at DESC LIMIT ?"
+	args = append(args, q.limit())
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query diagnosis history: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*HistoryEntry
+	for rows.Next() {
+		var e HistoryEntry
+		if err := rows.Scan(&e.ID, &e.Middleware, &e.Instance, &e.Namespace, &e.Status, &e.HealthScore, &e.IssueCount, &e.Timestamp); err != nil {
+			return nil, err
+		}
+		out = append(out, &e)
+	}
+	return out, rows.Err()
+}
+
+// Close closes the database.
+func (s *SQLiteHistoryStore) Close() error {
+	return s.db.Close()
+}
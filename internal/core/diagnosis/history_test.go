@@ -0,0 +1,232 @@
+package diagnosis
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type stubAnalyzer struct {
+	issues []*models.Issue
+}
+
+func (a *stubAnalyzer) Name() string { return "stub" }
+func (a *stubAnalyzer) AnalyzeMetrics(ctx context.Context, data *models.MetricsData) ([]*models.Issue, error) {
+	return a.issues, nil
+}
+func (a *stubAnalyzer) AnalyzeLogs(ctx context.Context, data *models.LogData) ([]*models.Issue, error) {
+	return nil, nil
+}
+func (a *stubAnalyzer) CorrelateSystems(ctx context.Context, data *models.SystemCorrelationData) ([]*models.Issue, error) {
+	return nil, nil
+}
+
+func historyEntry(id, instance string, at time.Time, issues ...*models.Issue) *HistoryEntry {
+	req := &models.DiagnosisRequest{TargetMiddleware: enum.Redis, Instance: instance, Namespace: "prod"}
+	return NewHistoryEntry(req, &models.DiagnosisResult{
+		ID:        id,
+		Timestamp: at,
+		Status:    calculateOverallStatus(issues),
+		Issues:    issues,
+	})
+}
+
+func TestSQLiteHistoryStore_QueryAndReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "history", "diagnosis.db")
+	store, err := NewSQLiteHistoryStore(path)
+	require.NoError(t, err)
+
+	now := time.Now()
+	critical := &models.Issue{Title: "Replication Broken", Severity: enum.SeverityCritical}
+	require.NoError(t, store.Save(ctx, historyEntry("redis-0-1", "redis-0", now.Add(-48*time.Hour))))
+	require.NoError(t, store.Save(ctx, historyEntry("redis-0-2", "redis-0", now.Add(-time.Hour), critical)))
+	require.NoError(t, store.Save(ctx, historyEntry("redis-1-1", "redis-1", now.Add(-time.Minute))))
+	require.NoError(t, store.Close())
+
+	// Results survive a restart
+	store, err = NewSQLiteHistoryStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	entry, err := store.Get(ctx, "redis-0-2")
+	require.NoError(t, err)
+	assert.Equal(t, "redis", entry.Middleware)
+	assert.Equal(t, "prod", entry.Namespace)
+	assert.Equal(t, "Warning", entry.Status)
+	assert.Equal(t, 70, entry.HealthScore)
+	require.Len(t, entry.Result.Issues, 1)
+	assert.Equal(t, enum.SeverityCritical, entry.Result.Issues[0].Severity)
+
+	_, err = store.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrDiagnosisNotFound)
+
+	all, err := store.List(ctx, HistoryQuery{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, []string{"redis-1-1", "redis-0-2", "redis-0-1"}, []string{all[0].ID, all[1].ID, all[2].ID})
+	assert.Nil(t, all[0].Result, "List leaves results out")
+
+	recent, err := store.List(ctx, HistoryQuery{Instance: "redis-0", Since: now.Add(-24 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, recent, 1)
+	assert.Equal(t, "redis-0-2", recent[0].ID)
+
+	warning, err := store.List(ctx, HistoryQuery{Middleware: "Redis", Status: "warning"})
+	require.NoError(t, err)
+	require.Len(t, warning, 1)
+	assert.Equal(t, "redis-0-2", warning[0].ID)
+
+	// IDs are never overwritten
+	err = store.Save(ctx, historyEntry("redis-0-2", "redis-0", now))
+	assert.ErrorIs(t, err, ErrDiagnosisExists)
+	entry, err = store.Get(ctx, "redis-0-2")
+	require.NoError(t, err)
+	assert.Equal(t, 70, entry.HealthScore)
+
+	limited, err := store.List(ctx, HistoryQuery{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, limited, 1)
+	assert.Equal(t, "redis-1-1", limited[0].ID)
+}
+
+func TestHistoryQuery_Limit(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryHistoryStore()
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < MaxHistoryLimit+5; i++ {
+		require.NoError(t, store.Save(ctx, historyEntry(fmt.Sprintf("d-%d", i), "redis-0", start.Add(time.Duration(i)*time.Second))))
+	}
+
+	entries, err := store.List(ctx, HistoryQuery{})
+	require.NoError(t, err)
+	assert.Len(t, entries, DefaultHistoryLimit)
+	assert.Equal(t, fmt.Sprintf("d-%d", MaxHistoryLimit+4), entries[0].ID)
+
+	entries, err = store.List(ctx, HistoryQuery{Limit: 10 * MaxHistoryLimit})
+	require.NoError(t, err)
+	assert.Len(t, entries, MaxHistoryLimit)
+}
+
+func TestHealthTrend(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryHistoryStore()
+	start := time.Now().Add(-3 * time.Hour)
+	high := &models.Issue{Title: "Memory Pressure", Severity: enum.SeverityHigh}
+	warning := &models.Issue{Title: "Slow Log", Severity: enum.SeverityWarning}
+
+	require.NoError(t, store.Save(ctx, historyEntry("a", "redis-0", start)))
+	require.NoError(t, store.Save(ctx, historyEntry("b", "redis-0", start.Add(time.Hour), warning)))
+	require.NoError(t, store.Save(ctx, historyEntry("c", "redis-0", start.Add(2*time.Hour), high, warning)))
+	require.NoError(t, store.Save(ctx, historyEntry("d", "redis-1", start.Add(2*time.Hour), high)))
+
+	points, err := HealthTrend(ctx, store, HistoryQuery{Instance: "redis-0"})
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+	assert.Equal(t, []string{"a", "b", "c"}, []string{points[0].DiagnosisID, points[1].DiagnosisID, points[2].DiagnosisID})
+	assert.Equal(t, []int{100, 90, 70}, []int{points[0].HealthScore, points[1].HealthScore, points[2].HealthScore})
+	assert.Equal(t, 2, points[2].IssueCount)
+}
+
+func TestManager_RecordsDiagnosisHistory(t *testing.T) {
+	pm := new(MockPluginManager)
+	pm.On("CollectData", mock.Anything, mock.Anything).Return(&models.CollectedData{Metrics: &models.MetricsData{}}, nil)
+	issue := &models.Issue{Title: "High CPU", Severity: enum.SeverityWarning}
+	m := NewManager(pm, []interfaces.DiagnosisAnalyzer{&stubAnalyzer{issues: []*models.Issue{issue}}}, nil, "", nil)
+
+	_, err := m.GetDiagnosisResult("any")
+	assert.Error(t, err, "no history configured")
+
+	store := NewInMemoryHistoryStore()
+	m.SetHistory(store)
+	req := &models.DiagnosisRequest{TargetMiddleware: enum.MySQL, Instance: "db-01"}
+	result, err := m.RunDiagnosis(context.Background(), req, drainProgress())
+	require.NoError(t, err)
+
+	got, err := m.GetDiagnosisResult(result.ID)
+	require.NoError(t, err)
+	assert.Equal(t, result.ID, got.ID)
+	require.Len(t, got.Issues, 1)
+
+	entries, err := store.List(context.Background(), HistoryQuery{Instance: "db-01"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "mysql", entries[0].Middleware)
+	assert.Equal(t, 90, entries[0].HealthScore)
+
+	// A second run in the same second is recorded separately
+	again, err := m.RunDiagnosis(context.Background(), req, drainProgress())
+	require.NoError(t, err)
+	assert.NotEqual(t, result.ID, again.ID)
+	entries, err = store.List(context.Background(), HistoryQuery{Instance: "db-01"})
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	_, err = m.GetDiagnosisResult("missing")
+	assert.ErrorIs(t, err, ErrDiagnosisNotFound)
+}
+
+func TestDiffResults(t *testing.T) {
+	from := &models.DiagnosisResult{ID: "old", Issues: []*models.Issue{
+		{Title: "Queue Without Consumers", Severity: enum.SeverityWarning, Metadata: map[string]string{"vhost": "/", "queue": "orders", "messages": "10"}},
+		{Title: "Queue Without Consumers", Severity: enum.SeverityWarning, Metadata: map[string]string{"vhost": "/", "queue": "emails"}},
+		{Title: "High Memory Usage", Severity: enum.SeverityWarning, Metadata: map[string]string{"node": "rabbit@a"}},
+	}}
+	to := &models.DiagnosisResult{ID: "new", Issues: []*models.Issue{
+		// Same queue with a different depth is the same issue
+		{Title: "Queue Without Consumers", Severity: enum.SeverityWarning, Metadata: map[string]string{"vhost": "/", "queue": "orders", "messages": "5000"}},
+		{Title: "High Memory Usage", Severity: enum.SeverityCritical, Metadata: map[string]string{"node": "rabbit@a"}},
+		{Title: "Disk Alarm", Severity: enum.SeverityCritical, Metadata: map[string]string{"node": "rabbit@b"}},
+	}}
+
+	diff := DiffResults(from, to)
+	assert.Equal(t, "old", diff.FromID)
+	assert.Equal(t, "new", diff.ToID)
+	require.Len(t, diff.Appeared, 1)
+	assert.Equal(t, "Disk Alarm", diff.Appeared[0].Title)
+	require.Len(t, diff.Resolved, 1)
+	assert.Equal(t, "emails", diff.Resolved[0].Metadata["queue"])
+	require.Len(t, diff.Changed, 1)
+	assert.Equal(t, "High Memory Usage", diff.Changed[0].Issue.Title)
+	assert.Equal(t, enum.SeverityWarning, diff.Changed[0].From)
+	assert.Equal(t, enum.SeverityCritical, diff.Changed[0].To)
+	assert.Equal(t, 1, diff.Unchanged)
+	assert.Equal(t, "vhost=/ queue=orders", IssueSubject(to.Issues[0]))
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"":                     {},
+		"90m":                  now.Add(-90 * time.Minute),
+		"7d":                   now.AddDate(0, 0, -7),
+		"2024-05-01T00:00:00Z": time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for in, want := range cases {
+		got, err := ParseSince(in, now)
+		require.NoError(t, err, in)
+		assert.True(t, want.Equal(got), "%s: got %s, want %s", in, got, want)
+	}
+	for _, in := range []string{"yesterday", "-1h", "xd"} {
+		_, err := ParseSince(in, now)
+		assert.Error(t, err, in)
+	}
+}
+
+func drainProgress() chan interfaces.DiagnosisProgress {
+	ch := make(chan interfaces.DiagnosisProgress)
+	go func() {
+		for range ch {
+		}
+	}()
+	return ch
+}
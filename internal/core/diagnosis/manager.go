@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
@@ -20,6 +22,7 @@ type Manager struct {
 	execManager   interfaces.ExecutionManager
 	reportDir     string
 	knowledgeBase *knowledge.KnowledgeBase
+	history       HistoryStore
 	logger        logger.Logger
 }
 
@@ -45,6 +48,17 @@ func NewManager(pm interfaces.PluginManager, analyzers []interfaces.DiagnosisAna
 	}
 }
 
+// SetHistory records every completed diagnosis in store, from where
+// GetDiagnosisResult reads them back.
+func (m *Manager) SetHistory(store HistoryStore) {
+	m.history = store
+}
+
+// History returns the store diagnoses are recorded in, or nil.
+func (m *Manager) History() HistoryStore {
+	return m.history
+}
+
 func (m *Manager) RunDiagnosis(ctx context.Context, req *models.DiagnosisRequest, progress chan<- interfaces.DiagnosisProgress) (*models.DiagnosisResult, error) {
 	defer close(progress)
 
@@ -68,18 +82,25 @@ func (m *Manager) RunDiagnosis(ctx context.Context, req *models.DiagnosisRequest
 		m.logger.Errorf("Analysis failed: %v", err)
 		return nil, fmt.Errorf("analysis failed: %w", err)
 	}
+	assignFixPlugin(req, issues)
 
 	// 3. Result Compilation
 	progress <- interfaces.DiagnosisProgress{Step: "Reporting", Status: "InProgress", Message: "Generating final report..."}
 
 	result := &models.DiagnosisResult{
-		ID:        fmt.Sprintf("%s-%d", req.Instance, time.Now().Unix()),
+		ID:        uuid.New().String(),
 		Timestamp: time.Now(),
 		Status:    calculateOverallStatus(issues),
 		Summary:   fmt.Sprintf("Diagnosis completed for %s. Found %d issues.", req.TargetMiddleware, len(issues)),
 		Issues:    issues,
 	}
 
+	if m.history != nil {
+		if err := m.history.Save(ctx, NewHistoryEntry(req, result)); err != nil {
+			m.logger.Errorf("Failed to record diagnosis %s: %v", result.ID, err)
+		}
+	}
+
 	m.logger.Infof("Diagnosis completed for %s. Found %d issues. Report ID: %s", req.TargetMiddleware, len(issues), result.ID)
 	return result, nil
 }
@@ -114,7 +135,14 @@ func (m *Manager) GenerateReport(result *models.DiagnosisResult) (string, error)
 }
 
 func (m *Manager) GetDiagnosisResult(id string) (*models.DiagnosisResult, error) {
-	return nil, fmt.Errorf("not implemented")
+	if m.history == nil {
+		return nil, fmt.Errorf("diagnosis history is not configured")
+	}
+	entry, err := m.history.Get(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	return entry.Result, nil
 }
 
 func (m *Manager) GetKnowledgeBase() *knowledge.KnowledgeBase {
@@ -123,6 +151,24 @@ func (m *Manager) GetKnowledgeBase() *knowledge.KnowledgeBase {
 
 // Helpers
 
+// assignFixPlugin names the plugin of the diagnosed middleware on the
+// auto-fixable recommendations that do not name one, so a fix runner applies
+// them through that plugin.
+func assignFixPlugin(req *models.DiagnosisRequest, issues []*models.Issue) {
+	name := req.TargetMiddleware.String()
+	if name == "Unknown" {
+		return
+	}
+	plugin := strings.ToLower(name)
+	for _, issue := range issues {
+		for _, rec := range issue.Recommendations {
+			if rec.CanAutoFix && rec.Fix.Plugin == "" {
+				rec.Fix.Plugin = plugin
+			}
+		}
+	}
+}
+
 func calculateOverallStatus(issues []*models.Issue) enum.DiagnosisStatus {
 	if len(issues) == 0 {
 		return enum.StatusHealthy
@@ -0,0 +1,422 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+)
+
+// ApprovalStatus is the state of a fix plan in the approval workflow.
+type ApprovalStatus string
+
+const (
+	// ApprovalStatusPending indicates the plan is waiting for approvers
+	ApprovalStatusPending ApprovalStatus = "pending_approval"
+
+	// ApprovalStatusApproved indicates enough approvers agreed; the plan may run
+	ApprovalStatusApproved ApprovalStatus = "approved"
+
+	// ApprovalStatusRejected indicates an approver rejected the plan
+	ApprovalStatusRejected ApprovalStatus = "rejected"
+
+	// ApprovalStatusExpired indicates the plan was not approved in time
+	ApprovalStatusExpired ApprovalStatus = "expired"
+
+	// ApprovalStatusExecuted indicates the approved plan has been executed
+	ApprovalStatusExecuted ApprovalStatus = "executed"
+)
+
+var (
+	// ErrApprovalNotFound is returned for unknown approval requests
+	ErrApprovalNotFound = errors.New("approval request not found")
+
+	// ErrApprovalClosed is returned when deciding on a request that is no longer pending
+	ErrApprovalClosed = errors.New("approval request is no longer pending")
+
+	// ErrApproverNotAllowed is returned when the approver may not decide on the request
+	ErrApproverNotAllowed = errors.New("approver is not allowed to decide on this plan")
+)
+
+// ApprovalDecision records one approver's verdict on a fix plan.
+type ApprovalDecision struct {
+	// Approver is the user who decided
+	Approver string `json:"approver" yaml:"approver"`
+
+	// Approved is true for an approval and false for a rejection
+	Approved bool `json:"approved" yaml:"approved"`
+
+	// Comment explains the decision
+	Comment string `json:"comment,omitempty" yaml:"comment,omitempty"`
+
+	// Channel is how the decision was made (api, cli, link)
+	Channel string `json:"channel,omitempty" yaml:"channel,omitempty"`
+
+	// DecidedAt is when the decision was made
+	DecidedAt time.Time `json:"decidedAt" yaml:"decidedAt"`
+}
+
+// ApprovalRequest holds a fix plan until enough approvers have agreed.
+type ApprovalRequest struct {
+	// ID is the ID of the plan awaiting approval
+	ID string `json:"id" yaml:"id"`
+
+	// Plan is the fix plan to execute once approved
+	Plan *FixPlan `json:"plan" yaml:"plan"`
+
+	// Status is the current state of the request
+	Status ApprovalStatus `json:"status" yaml:"status"`
+
+	// RequiredApprovals is how many distinct approvers must approve (N of M)
+	RequiredApprovals int `json:"requiredApprovals" yaml:"requiredApprovals"`
+
+	// Approvers lists who may decide (M); empty allows anyone with the approve permission
+	Approvers []string `json:"approvers,omitempty" yaml:"approvers,omitempty"`
+
+	// Decisions lists the verdicts so far, in order
+	Decisions []ApprovalDecision `json:"decisions,omitempty" yaml:"decisions,omitempty"`
+
+	// RequestedBy is who submitted the plan; they cannot approve it themselves
+	RequestedBy string `json:"requestedBy,omitempty" yaml:"requestedBy,omitempty"`
+
+	// CreatedAt is when the plan was submitted
+	CreatedAt time.Time `json:"createdAt" yaml:"createdAt"`
+
+	// ExpiresAt is when a pending request expires
+	ExpiresAt time.Time `json:"expiresAt" yaml:"expiresAt"`
+
+	// ExecutionID links to the execution once the plan has run
+	ExecutionID string `json:"executionId,omitempty" yaml:"executionId,omitempty"`
+}
+
+// Approvals returns the number of approving decisions.
+func (r *ApprovalRequest) Approvals() int {
+	n := 0
+	for _, d := range r.Decisions {
+		if d.Approved {
+			n++
+		}
+	}
+	return n
+}
+
+// ApprovedBy returns the approvers who approved the plan, in order.
+func (r *ApprovalRequest) ApprovedBy() []string {
+	var approvers []string
+	for _, d := range r.Decisions {
+		if d.Approved {
+			approvers = append(approvers, d.Approver)
+		}
+	}
+	return approvers
+}
+
+// CanDecide reports whether approver may approve or reject the request.
+func (r *ApprovalRequest) CanDecide(approver string) bool {
+	if approver == "" || approver == r.RequestedBy {
+		return false
+	}
+	for _, d := range r.Decisions {
+		if d.Approver == approver {
+			return false
+		}
+	}
+	if len(r.Approvers) == 0 {
+		return true
+	}
+	for _, a := range r.Approvers {
+		if a == approver {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovalPolicy decides how many approvals a plan needs and for how long
+// it may wait for them.
+type ApprovalPolicy struct {
+	// Approvers lists who may approve plans; empty allows anyone with the approve permission
+	Approvers []string
+
+	// RequiredApprovals is the number of approvals for plans below high risk
+	RequiredApprovals int
+
+	// HighRiskApprovals is the number of approvals for high and critical risk plans
+	HighRiskApprovals int
+
+	// TTL is how long a plan waits for approval before it expires
+	TTL time.Duration
+}
+
+// DefaultApprovalPolicy returns a policy requiring one approval, two for
+// high-risk plans, within 24 hours.
+func DefaultApprovalPolicy() ApprovalPolicy {
+	return ApprovalPolicy{
+		RequiredApprovals: 1,
+		HighRiskApprovals: 2,
+		TTL:               24 * time.Hour,
+	}
+}
+
+// required returns the number of approvals plan needs
+func (p ApprovalPolicy) required(plan *FixPlan) int {
+	n := p.RequiredApprovals
+	if plan.RiskAssessment != nil {
+		switch plan.RiskAssessment.Level {
+		case RiskLevelHigh, RiskLevelCritical:
+			if p.HighRiskApprovals > n {
+				n = p.HighRiskApprovals
+			}
+		}
+	}
+	if n < 1 {
+		n = 1
+	}
+	if len(p.Approvers) > 0 && n > len(p.Approvers) {
+		n = len(p.Approvers)
+	}
+	return n
+}
+
+// ApprovalNotifier tells approvers that a plan is waiting for them.
+type ApprovalNotifier interface {
+	NotifyApproval(ctx context.Context, req *ApprovalRequest) error
+}
+
+// ApprovalGate holds fix plans in PendingApproval until enough approvers
+// agree, then lets them execute through the AutoFixManager. Requests are
+// persisted in an ApprovalStore so pending approvals survive restarts.
+type ApprovalGate struct {
+	store    ApprovalStore
+	autofix  *AutoFixManager
+	policy   ApprovalPolicy
+	notifier ApprovalNotifier
+	log      logger.Logger
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+// NewApprovalGate creates an approval gate executing approved plans with autofix.
+func NewApprovalGate(store ApprovalStore, autofix *AutoFixManager, policy ApprovalPolicy) *ApprovalGate {
+	if policy.TTL <= 0 {
+		policy.TTL = DefaultApprovalPolicy().TTL
+	}
+	return &ApprovalGate{
+		store:   store,
+		autofix: autofix,
+		policy:  policy,
+		log:     logger.NewLogger("approval-gate"),
+		now:     time.Now,
+	}
+}
+
+// SetNotifier sets who is told about plans waiting for approval.
+func (g *ApprovalGate) SetNotifier(n ApprovalNotifier) {
+	g.notifier = n
+}
+
+// Submit puts a fix plan in PendingApproval. The plan's risk, which decides
+// how many approvals it needs, is assessed from its actions rather than
+// taken from the submitter.
+func (g *ApprovalGate) Submit(ctx context.Context, plan *FixPlan, requestedBy string) (*ApprovalRequest, error) {
+	if plan == nil || plan.ID == "" {
+		return nil, fmt.Errorf("fix plan must have an ID")
+	}
+	if err := reassessPlan(plan); err != nil {
+		return nil, err
+	}
+	plan.RequiresApproval = true
+
+	g.mu.Lock()
+	if _, err := g.store.Get(ctx, plan.ID); err == nil {
+		g.mu.Unlock()
+		return nil, fmt.Errorf("plan %s was already submitted for approval", plan.ID)
+	}
+
+	now := g.now().UTC()
+	req := &ApprovalRequest{
+		ID:                plan.ID,
+		Plan:              plan,
+		Status:            ApprovalStatusPending,
+		RequiredApprovals: g.policy.required(plan),
+		Approvers:         g.policy.Approvers,
+		RequestedBy:       requestedBy,
+		CreatedAt:         now,
+		ExpiresAt:         now.Add(g.policy.TTL),
+	}
+	err := g.store.Save(ctx, req)
+	g.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to store approval request: %w", err)
+	}
+
+	g.log.Infof("Fix plan %s is pending approval (%d required, expires %s)",
+		plan.ID, req.RequiredApprovals, req.ExpiresAt.Format(time.RFC3339))
+	if g.notifier != nil {
+		if err := g.notifier.NotifyApproval(ctx, req); err != nil {
+			g.log.Warnf("Failed to notify approvers of plan %s: %v", plan.ID, err)
+		}
+	}
+	return req, nil
+}
+
+// Get returns an approval request, expiring it if its time is up.
+func (g *ApprovalGate) Get(ctx context.Context, id string) (*ApprovalRequest, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.load(ctx, id)
+}
+
+// List returns the approval requests with the given status, or all of them
+// when status is empty.
+func (g *ApprovalGate) List(ctx context.Context, status ApprovalStatus) ([]*ApprovalRequest, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	all, err := g.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []*ApprovalRequest
+	for _, req := range all {
+		if err := g.expire(ctx, req); err != nil {
+			return nil, err
+		}
+		if status == "" || req.Status == status {
+			out = append(out, req)
+		}
+	}
+	return out, nil
+}
+
+// Approve records an approval. The request becomes approved once it has
+// the required number of approvals.
+func (g *ApprovalGate) Approve(ctx context.Context, id, approver, comment, channel string) (*ApprovalRequest, error) {
+	return g.decide(ctx, id, ApprovalDecision{Approver: approver, Approved: true, Comment: comment, Channel: channel})
+}
+
+// Reject rejects the plan; a single rejection is final.
+func (g *ApprovalGate) Reject(ctx context.Context, id, approver, comment, channel string) (*ApprovalRequest, error) {
+	return g.decide(ctx, id, ApprovalDecision{Approver: approver, Approved: false, Comment: comment, Channel: channel})
+}
+
+func (g *ApprovalGate) decide(ctx context.Context, id string, d ApprovalDecision) (*ApprovalRequest, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	req, err := g.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != ApprovalStatusPending {
+		return req, fmt.Errorf("%w: %s", ErrApprovalClosed, req.Status)
+	}
+	if !req.CanDecide(d.Approver) {
+		return req, ErrApproverNotAllowed
+	}
+
+	d.DecidedAt = g.now().UTC()
+	req.Decisions = append(req.Decisions, d)
+	if !d.Approved {
+		req.Status = ApprovalStatusRejected
+	} else if req.Approvals() >= req.RequiredApprovals {
+		req.Status = ApprovalStatusApproved
+	}
+	if err := g.store.Save(ctx, req); err != nil {
+		return nil, fmt.Errorf("failed to store approval decision: %w", err)
+	}
+
+	g.log.Infof("Plan %s %s by %s (%d/%d approvals)", id, verdict(d.Approved), d.Approver,
+		req.Approvals(), req.RequiredApprovals)
+	return req, nil
+}
+
+// Execute runs an approved plan and records who approved it. The request is
+// marked executed whatever the outcome, so a plan runs at most once.
+func (g *ApprovalGate) Execute(ctx context.Context, id string) (*FixResult, *ExecutionRecord, error) {
+	g.mu.Lock()
+	req, err := g.load(ctx, id)
+	if err == nil && req.Status != ApprovalStatusApproved {
+		err = fmt.Errorf("plan %s is %s, not approved", id, req.Status)
+	}
+	if err == nil {
+		req.Status = ApprovalStatusExecuted
+		err = g.store.Save(ctx, req)
+	}
+	g.mu.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if g.autofix == nil {
+		return nil, nil, fmt.Errorf("no AutoFix manager configured")
+	}
+	result, execErr := g.autofix.ExecuteApproved(ctx, req.Plan)
+	if result == nil {
+		return nil, nil, execErr
+	}
+	record, err := g.autofix.RecordApprovedExecution(ctx, req, result)
+	if err != nil {
+		g.log.Errorf("Failed to record execution of plan %s: %v", id, err)
+	}
+
+	g.mu.Lock()
+	req.ExecutionID = result.ExecutionID
+	if err := g.store.Save(ctx, req); err != nil {
+		g.log.Errorf("Failed to link execution to approval %s: %v", id, err)
+	}
+	g.mu.Unlock()
+	return result, record, execErr
+}
+
+// load reads a request and expires it if needed; callers hold g.mu
+func (g *ApprovalGate) load(ctx context.Context, id string) (*ApprovalRequest, error) {
+	req, err := g.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.expire(ctx, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// expire marks a pending request expired once its deadline has passed
+func (g *ApprovalGate) expire(ctx context.Context, req *ApprovalRequest) error {
+	if req.Status != ApprovalStatusPending || g.now().Before(req.ExpiresAt) {
+		return nil
+	}
+	req.Status = ApprovalStatusExpired
+	g.log.Infof("Approval of plan %s expired with %d/%d approvals", req.ID, req.Approvals(), req.RequiredApprovals)
+	return g.store.Save(ctx, req)
+}
+
+func verdict(approved bool) string {
+	if approved {
+		return "approved"
+	}
+	return "rejected"
+}
+
+// approversList formats approvers for ExecutionRecord.ApprovedBy
+func approversList(approvers []string) string {
+	return strings.Join(approvers, ",")
+}
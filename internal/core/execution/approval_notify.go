@@ -0,0 +1,150 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/alert/notifier"
+)
+
+// ApprovalLinker signs the approve and reject links of chat messages. A
+// link opens a confirmation page; the decision is posted from there under
+// the approver's own login, so a forwarded or leaked link cannot decide on
+// anyone's behalf. A link is bound to one request and action, and stops
+// working when the request expires.
+type ApprovalLinker struct {
+	// BaseURL is the externally reachable address of the API server
+	BaseURL string
+
+	// Secret is the HMAC key used to sign links. It is dedicated to approval
+	// links so that it cannot be used to mint login tokens.
+	Secret string
+}
+
+// Link returns the signed URL of the page confirming action on req;
+// action is "approve" or "reject".
+func (l *ApprovalLinker) Link(req *ApprovalRequest, action string) string {
+	expires := strconv.FormatInt(req.ExpiresAt.Unix(), 10)
+	q := url.Values{}
+	q.Set("action", action)
+	q.Set("expires", expires)
+	q.Set("sig", l.sign(req.ID, action, expires))
+	return fmt.Sprintf("%s/api/v1/approvals/%s/decide?%s",
+		strings.TrimRight(l.BaseURL, "/"), url.PathEscape(req.ID), q.Encode())
+}
+
+// Verify checks the signature and expiry of a link's parameters.
+func (l *ApprovalLinker) Verify(id, action, expires, sig string) error {
+	if l.Secret == "" {
+		return errors.New("approval links are not enabled")
+	}
+	want := l.sign(id, action, expires)
+	if !hmac.Equal([]byte(want), []byte(sig)) {
+		return errors.New("invalid approval link signature")
+	}
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid approval link expiry: %w", err)
+	}
+	if time.Now().Unix() >= exp {
+		return errors.New("approval link has expired")
+	}
+	return nil
+}
+
+func (l *ApprovalLinker) sign(id, action, expires string) string {
+	h := hmac.New(sha256.New, []byte(l.Secret))
+	h.Write([]byte(strings.Join([]string{id, action, expires}, "\n")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ChannelApprovalNotifier posts plans awaiting approval to chat channels.
+// When a linker is set, the message carries approve and reject buttons
+// leading to confirmation pages; otherwise it points to the CLI.
+type ChannelApprovalNotifier struct {
+	notifiers []notifier.Notifier
+	linker    *ApprovalLinker
+}
+
+// NewChannelApprovalNotifier creates an ApprovalNotifier sending through notifiers.
+func NewChannelApprovalNotifier(notifiers []notifier.Notifier, linker *ApprovalLinker) *ChannelApprovalNotifier {
+	return &ChannelApprovalNotifier{notifiers: notifiers, linker: linker}
+}
+
+// NotifyApproval implements ApprovalNotifier.
+func (n *ChannelApprovalNotifier) NotifyApproval(ctx context.Context, req *ApprovalRequest) error {
+	msg := approvalMessage(req)
+	if n.linker != nil && n.linker.Secret != "" && n.linker.BaseURL != "" {
+		msg.Content += "\n\nThe buttons ask you to confirm while signed in to the dashboard; the decision is recorded under your account."
+		msg.Actions = append(msg.Actions,
+			notifier.Action{Label: "Approve", URL: n.linker.Link(req, "approve"), Style: "primary"},
+			notifier.Action{Label: "Reject", URL: n.linker.Link(req, "reject"), Style: "danger"},
+		)
+	}
+
+	var errs []error
+	for _, nt := range n.notifiers {
+		if err := nt.Send(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", nt.Type(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// approvalMessage describes a pending plan for approvers
+func approvalMessage(req *ApprovalRequest) *notifier.NotificationMessage {
+	risk := RiskLevelLow
+	if req.Plan.RiskAssessment != nil {
+		risk = req.Plan.RiskAssessment.Level
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Fix plan:** %s\n\n", req.ID)
+	if req.Plan.DiagnosisID != "" {
+		fmt.Fprintf(&b, "**Diagnosis:** %s\n\n", req.Plan.DiagnosisID)
+	}
+	fmt.Fprintf(&b, "**Risk:** %s\n\n", risk)
+	for _, a := range req.Plan.Actions {
+		if a.Action != nil {
+			fmt.Fprintf(&b, "- %s\n", a.Action.Description)
+		}
+	}
+	fmt.Fprintf(&b, "\n**Approvals required:** %d", req.RequiredApprovals)
+	if len(req.Approvers) > 0 {
+		fmt.Fprintf(&b, " of %s", strings.Join(req.Approvers, ", "))
+	}
+	fmt.Fprintf(&b, "\n\n**Expires:** %s\n\n", req.ExpiresAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Approve with `ksa fix approve %s` or reject with `ksa fix reject %s --comment <reason>`.", req.ID, req.ID)
+
+	severity := "warning"
+	if risk == RiskLevelHigh || risk == RiskLevelCritical {
+		severity = "critical"
+	}
+	return &notifier.NotificationMessage{
+		Title:    "Fix plan awaiting approval",
+		Content:  b.String(),
+		Severity: severity,
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ApprovalStore persists approval requests.
+type ApprovalStore interface {
+	// Save creates or replaces an approval request
+	Save(ctx context.Context, req *ApprovalRequest) error
+
+	// Get returns an approval request, or ErrApprovalNotFound
+	Get(ctx context.Context, id string) (*ApprovalRequest, error)
+
+	// List returns every approval request, newest first
+	List(ctx context.Context) ([]*ApprovalRequest, error)
+}
+
+// InMemoryApprovalStore is an ApprovalStore for testing and single-process use.
+type InMemoryApprovalStore struct {
+	requests map[string]*ApprovalRequest
+	mu       sync.RWMutex
+}
+
+// NewInMemoryApprovalStore creates an empty in-memory approval store.
+func NewInMemoryApprovalStore() *InMemoryApprovalStore {
+	return &InMemoryApprovalStore{requests: make(map[string]*ApprovalRequest)}
+}
+
+// Save implements ApprovalStore.
+func (s *InMemoryApprovalStore) Save(ctx context.Context, req *ApprovalRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[req.ID] = copyApproval(req)
+	return nil
+}
+
+// Get implements ApprovalStore.
+func (s *InMemoryApprovalStore) Get(ctx context.Context, id string) (*ApprovalRequest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	req, ok := s.requests[id]
+	if !ok {
+		return nil, ErrApprovalNotFound
+	}
+	return copyApproval(req), nil
+}
+
+// List implements ApprovalStore.
+func (s *InMemoryApprovalStore) List(ctx context.Context) ([]*ApprovalRequest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*ApprovalRequest, 0, len(s.requests))
+	for _, req := range s.requests {
+		out = append(out, copyApproval(req))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// copyApproval copies a request so callers cannot change stored decisions
+func copyApproval(req *ApprovalRequest) *ApprovalRequest {
+	c := *req
+	c.Decisions = append([]ApprovalDecision(nil), req.Decisions...)
+	return &c
+}
+
+// SQLiteApprovalStore is an ApprovalStore backed by SQLite, so that plans
+// pending approval survive server restarts.
+type SQLiteApprovalStore struct {
+	db *sql.DB
+	mu sync.RWMutex
+}
+
+// NewSQLiteApprovalStore opens or creates the approval database at path.
+func NewSQLiteApprovalStore(path string) (*SQLiteApprovalStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+	CREATE TABLE IF NOT EXISTS fix_approvals (
+		id TEXT PRIMARY KEY,
+		status TEXT NOT NULL,
+		data TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_fix_approvals_status ON fix_approvals(status);
+	`
+	if _, err := db.Exec(query); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to init approval db: %w", err)
+	}
+
+	return &SQLiteApprovalStore{db: db}, nil
+}
+
+// Save implements ApprovalStore.
+func (s *SQLiteApprovalStore) Save(ctx context.Context, req *ApprovalRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal approval: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO fix_approvals (id, status, data, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET status = excluded.status, data = excluded.data,
+			expires_at = excluded.expires_at`,
+		req.ID, string(req.Status), string(data), req.CreatedAt, req.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to save approval: %w", err)
+	}
+	return nil
+}
+
+// Get implements ApprovalStore.
+func (s *SQLiteApprovalStore) Get(ctx context.Context, id string) (*ApprovalRequest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var data string
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM fix_approvals WHERE id = ?`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrApprovalNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load approval: %w", err)
+	}
+
+	var req ApprovalRequest
+	if err := json.Unmarshal([]byte(data), &req); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal approval: %w", err)
+	}
+	return &req, nil
+}
+
+// List implements ApprovalStore.
+func (s *SQLiteApprovalStore) List(ctx context.Context) ([]*ApprovalRequest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM fix_approvals ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approvals: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*ApprovalRequest
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var req ApprovalRequest
+		if err := json.Unmarshal([]byte(data), &req); err != nil {
+			continue
+		}
+		out = append(out, &req)
+	}
+	return out, rows.Err()
+}
+
+// Close closes the database.
+func (s *SQLiteApprovalStore) Close() error {
+	return s.db.Close()
+}
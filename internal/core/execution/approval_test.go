@@ -0,0 +1,235 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newApprovalTestPlan returns a plan restarting the given number of
+// replicas; each restart adds 20 to the risk score, so 2 are medium, 3 high
+// and 4 critical risk. The plan claims to be low risk.
+func newApprovalTestPlan(id string, restarts int) *FixPlan {
+	plan := &FixPlan{
+		ID:             id,
+		DiagnosisID:    "diag-1",
+		CreatedAt:      time.Now().UTC(),
+		RiskAssessment: &RiskAssessment{Level: RiskLevelLow},
+		DryRun:         true,
+	}
+	for i := 0; i < restarts; i++ {
+		plan.Actions = append(plan.Actions, &FixActionItem{
+			Sequence: i,
+			Action: &models.FixAction{
+				ID:          fmt.Sprintf("fix-%d", i),
+				Description: fmt.Sprintf("Restart replica redis-%d", i),
+				Command:     fmt.Sprintf("kubectl rollout restart statefulset/redis-%d", i),
+			},
+		})
+	}
+	return plan
+}
+
+func newTestApprovalGate(store ApprovalStore, policy ApprovalPolicy) (*ApprovalGate, *InMemoryRecordStore) {
+	records := NewInMemoryRecordStore()
+	autofix := NewAutoFixManager(nil, records, nil)
+	return NewApprovalGate(store, autofix, policy), records
+}
+
+func TestApprovalGate_NOfM(t *testing.T) {
+	ctx := context.Background()
+	policy := ApprovalPolicy{Approvers: []string{"alice", "bob", "carol"}, RequiredApprovals: 1, HighRiskApprovals: 2, TTL: time.Hour}
+	gate, records := newTestApprovalGate(NewInMemoryApprovalStore(), policy)
+
+	req, err := gate.Submit(ctx, newApprovalTestPlan("plan-1", 4), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalStatusPending, req.Status)
+	assert.Equal(t, RiskLevelCritical, req.Plan.RiskAssessment.Level, "the risk is assessed from the actions, not taken from the submitter")
+	assert.True(t, req.Plan.RequiresApproval)
+	assert.Equal(t, 2, req.RequiredApprovals, "critical plans need the high-risk quorum")
+
+	_, err = gate.Approve(ctx, "plan-1", "alice", "", "api")
+	assert.ErrorIs(t, err, ErrApproverNotAllowed, "requesters cannot approve their own plan")
+	_, err = gate.Approve(ctx, "plan-1", "mallory", "", "api")
+	assert.ErrorIs(t, err, ErrApproverNotAllowed, "only listed approvers may approve")
+
+	req, err = gate.Approve(ctx, "plan-1", "bob", "looks safe", "cli")
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalStatusPending, req.Status)
+	_, err = gate.Approve(ctx, "plan-1", "bob", "", "cli")
+	assert.ErrorIs(t, err, ErrApproverNotAllowed, "an approver counts once")
+
+	_, _, err = gate.Execute(ctx, "plan-1")
+	assert.Error(t, err, "a plan cannot run before it is approved")
+
+	req, err = gate.Approve(ctx, "plan-1", "carol", "", "link")
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalStatusApproved, req.Status)
+
+	// Approved critical plans pass validation and record who approved them
+	result, record, err := gate.Execute(ctx, "plan-1")
+	require.NoError(t, err)
+	assert.Equal(t, FixExecutionStatusSuccess, result.Status)
+	require.NotNil(t, record)
+	assert.Equal(t, "bob,carol", record.ApprovedBy)
+	require.Len(t, record.Approvals, 2)
+	assert.Equal(t, "looks safe", record.Approvals[0].Comment)
+	assert.NotNil(t, record.ApprovedAt)
+
+	stored, err := records.Get(ctx, record.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "plan-1", stored.PlanID)
+
+	req, err = gate.Get(ctx, "plan-1")
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalStatusExecuted, req.Status)
+	assert.Equal(t, result.ExecutionID, req.ExecutionID)
+
+	_, _, err = gate.Execute(ctx, "plan-1")
+	assert.Error(t, err, "a plan runs at most once")
+}
+
+func TestApprovalGate_RejectionIsFinal(t *testing.T) {
+	ctx := context.Background()
+	gate, _ := newTestApprovalGate(NewInMemoryApprovalStore(), ApprovalPolicy{RequiredApprovals: 2, TTL: time.Hour})
+
+	_, err := gate.Submit(ctx, newApprovalTestPlan("plan-2", 2), "alice")
+	require.NoError(t, err)
+	_, err = gate.Approve(ctx, "plan-2", "bob", "", "api")
+	require.NoError(t, err)
+
+	req, err := gate.Reject(ctx, "plan-2", "carol", "not during peak hours", "api")
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalStatusRejected, req.Status)
+
+	_, err = gate.Approve(ctx, "plan-2", "dave", "", "api")
+	assert.ErrorIs(t, err, ErrApprovalClosed)
+}
+
+func TestApprovalGate_Expiry(t *testing.T) {
+	ctx := context.Background()
+	gate, _ := newTestApprovalGate(NewInMemoryApprovalStore(), ApprovalPolicy{TTL: time.Hour})
+	now := time.Now()
+	gate.now = func() time.Time { return now }
+
+	_, err := gate.Submit(ctx, newApprovalTestPlan("plan-3", 3), "alice")
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Hour)
+	_, err = gate.Approve(ctx, "plan-3", "bob", "", "api")
+	assert.ErrorIs(t, err, ErrApprovalClosed)
+
+	expired, err := gate.List(ctx, ApprovalStatusExpired)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, "plan-3", expired[0].ID)
+}
+
+func TestApprovalLinker_Verify(t *testing.T) {
+	linker := &ApprovalLinker{BaseURL: "https://ksa.example.com/", Secret: "s3cret"}
+	req := &ApprovalRequest{ID: "plan-4", ExpiresAt: time.Now().Add(time.Hour)}
+
+	link := linker.Link(req, "approve")
+	assert.Contains(t, link, "https://ksa.example.com/api/v1/approvals/plan-4/decide?")
+
+	q := mustQuery(t, link)
+	assert.Empty(t, q.Get("approver"), "the approver is whoever confirms while logged in")
+	assert.NoError(t, linker.Verify("plan-4", q.Get("action"), q.Get("expires"), q.Get("sig")))
+	assert.Error(t, linker.Verify("plan-4", "reject", q.Get("expires"), q.Get("sig")), "the action is signed")
+	assert.Error(t, linker.Verify("plan-5", "approve", q.Get("expires"), q.Get("sig")), "the plan is signed")
+	assert.Error(t, (&ApprovalLinker{Secret: "other"}).Verify("plan-4", "approve", q.Get("expires"), q.Get("sig")), "links are signed with the link secret")
+
+	req.ExpiresAt = time.Now().Add(-time.Minute)
+	q = mustQuery(t, linker.Link(req, "approve"))
+	assert.Error(t, linker.Verify("plan-4", "approve", q.Get("expires"), q.Get("sig")), "expired links are refused")
+}
+
+func mustQuery(t *testing.T, link string) url.Values {
+	t.Helper()
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	return u.Query()
+}
+
+func TestSQLiteApprovalStore_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "approvals.db")
+
+	store, err := NewSQLiteApprovalStore(path)
+	require.NoError(t, err)
+	gate, _ := newTestApprovalGate(store, ApprovalPolicy{RequiredApprovals: 2, TTL: time.Hour})
+	_, err = gate.Submit(ctx, newApprovalTestPlan("plan-6", 2), "alice")
+	require.NoError(t, err)
+	_, err = gate.Approve(ctx, "plan-6", "bob", "ok", "api")
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	store, err = NewSQLiteApprovalStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	gate, _ = newTestApprovalGate(store, ApprovalPolicy{RequiredApprovals: 2, TTL: time.Hour})
+
+	req, err := gate.Approve(ctx, "plan-6", "carol", "", "api")
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalStatusApproved, req.Status)
+	assert.Equal(t, []string{"bob", "carol"}, req.ApprovedBy())
+	assert.Equal(t, "Restart replica redis-0", req.Plan.Actions[0].Action.Description)
+
+	_, err = store.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrApprovalNotFound)
+}
+
+func TestSQLiteRecordStore_KeepsApprovedExecutions(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "executions.db")
+
+	records, err := NewSQLiteRecordStore(path)
+	require.NoError(t, err)
+	gate := NewApprovalGate(NewInMemoryApprovalStore(), NewAutoFixManager(nil, records, nil), ApprovalPolicy{TTL: time.Hour})
+	_, err = gate.Submit(ctx, newApprovalTestPlan("plan-7", 1), "alice")
+	require.NoError(t, err)
+	_, err = gate.Approve(ctx, "plan-7", "bob", "", "api")
+	require.NoError(t, err)
+	_, record, err := gate.Execute(ctx, "plan-7")
+	require.NoError(t, err)
+	require.NoError(t, records.Close())
+
+	records, err = NewSQLiteRecordStore(path)
+	require.NoError(t, err)
+	defer records.Close()
+
+	stored, err := records.Get(ctx, record.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", stored.ApprovedBy)
+	assert.Equal(t, FixExecutionStatusSuccess, stored.ExecutionResult.Status)
+
+	list, err := records.List(ctx, map[string]interface{}{"plan_id": "plan-7"})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	list, err = records.List(ctx, map[string]interface{}{"diagnosis_id": "other"})
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	_, err = records.List(ctx, map[string]interface{}{"approved_by": "bob"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,48 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+)
+
+// PluginFixRunner is the FixRunner that applies actions through the plugin
+// named by their Plugin field, so the plugin's probes and compensating
+// commands guard every action.
+type PluginFixRunner struct {
+	plugins interfaces.PluginManager
+}
+
+// NewPluginFixRunner creates a FixRunner backed by the plugin manager.
+func NewPluginFixRunner(plugins interfaces.PluginManager) *PluginFixRunner {
+	return &PluginFixRunner{plugins: plugins}
+}
+
+// ExecuteFix loads the action's plugin, if it is not loaded yet, and runs the
+// action through it.
+func (r *PluginFixRunner) ExecuteFix(ctx context.Context, fix *models.FixAction) (*models.FixResult, error) {
+	if fix.Plugin == "" {
+		return nil, fmt.Errorf("fix action %s does not name the plugin that applies it", fix.ID)
+	}
+	plugin, err := r.plugins.LoadPlugin(fix.Plugin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plugin %s for fix action %s: %w", fix.Plugin, fix.ID, err)
+	}
+	return plugin.ExecuteFix(ctx, fix)
+}
@@ -16,6 +16,7 @@ package execution
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -37,8 +38,21 @@ type AutoFixManager struct {
 	log               logger.Logger
 	executionManager  interfaces.ExecutionManager
 	recordStore       ExecutionRecordStore
+	fixRunner         FixRunner
 	validationEnabled bool
 	dryRunMode        bool
+	requireApproval   bool
+}
+
+// ErrApprovalRequired is returned when a plan that needs approval is
+// executed without passing an ApprovalGate.
+var ErrApprovalRequired = errors.New("fix plan requires approval")
+
+// FixRunner applies fix actions to the target middleware. Builtin plugins
+// implement it through ExecuteFix, which probes the action's pre- and
+// post-conditions and compensates fixes that do not verify.
+type FixRunner interface {
+	ExecuteFix(ctx context.Context, fix *models.FixAction) (*models.FixResult, error)
 }
 
 // ExecutionRecordStore defines the interface for persisting execution records.
@@ -98,9 +112,16 @@ func NewAutoFixManager(
 		recordStore:       recordStore,
 		validationEnabled: true,
 		dryRunMode:        opts.DryRun,
+		requireApproval:   opts.RequireApproval,
 	}
 }
 
+// SetFixRunner sets the runner that applies actions. Without one, actions
+// are only simulated.
+func (m *AutoFixManager) SetFixRunner(runner FixRunner) {
+	m.fixRunner = runner
+}
+
 // BuildFixPlan creates a FixPlan from diagnosis recommendations.
 // This separates planning from execution, allowing human review.
 func (m *AutoFixManager) BuildFixPlan(
@@ -147,7 +168,7 @@ func (m *AutoFixManager) BuildFixPlan(
 	}
 
 	// Perform risk assessment
-	riskAssessment := m.assessRisk(actions, opts)
+	riskAssessment := assessRisk(actions)
 
 	plan := &FixPlan{
 		ID:                uuid.New().String(),
@@ -163,6 +184,7 @@ func (m *AutoFixManager) BuildFixPlan(
 
 	plan.Metadata["action_count"] = len(actions)
 	plan.Metadata["created_by"] = "autofix-manager"
+	plan.Metadata["enable_rollback"] = opts.EnableRollback
 
 	m.log.Infof("Fix plan %s created with %d actions, risk level: %s",
 		plan.ID, len(actions), riskAssessment.Level)
@@ -173,6 +195,12 @@ func (m *AutoFixManager) BuildFixPlan(
 // ValidatePlan performs pre-execution validation checks.
 // This is the first phase of the Validate → Execute → Record lifecycle.
 func (m *AutoFixManager) ValidatePlan(ctx context.Context, plan *FixPlan) (*ValidationReport, error) {
+	return m.validatePlan(ctx, plan, false)
+}
+
+// validatePlan runs the checks of ValidatePlan. A critical plan passes the
+// critical risk check only once approvers have signed it off.
+func (m *AutoFixManager) validatePlan(ctx context.Context, plan *FixPlan, approved bool) (*ValidationReport, error) {
 	m.log.Infof("Validating fix plan %s", plan.ID)
 
 	if !m.validationEnabled {
@@ -202,7 +230,7 @@ func (m *AutoFixManager) ValidatePlan(ctx context.Context, plan *FixPlan) (*Vali
 	}
 
 	// Additional plan-level validations
-	if plan.RiskAssessment.Level == RiskLevelCritical {
+	if plan.RiskAssessment.Level == RiskLevelCritical && !approved {
 		report.ValidationResults = append(report.ValidationResults, ValidationResult{
 			RuleName: "critical_risk_check",
 			Passed:   false,
@@ -224,10 +252,39 @@ func (m *AutoFixManager) ValidatePlan(ctx context.Context, plan *FixPlan) (*Vali
 // ExecuteFixPlan executes a validated fix plan.
 // This is the second phase of the Validate → Execute → Record lifecycle.
 func (m *AutoFixManager) ExecuteFixPlan(ctx context.Context, plan *FixPlan) (*FixResult, error) {
+	return m.executePlan(ctx, plan, false)
+}
+
+// ExecuteApproved executes a fix plan that passed an ApprovalGate.
+// Critical plans, which ExecuteFixPlan always refuses, may run once approved.
+func (m *AutoFixManager) ExecuteApproved(ctx context.Context, plan *FixPlan) (*FixResult, error) {
+	return m.executePlan(ctx, plan, true)
+}
+
+func (m *AutoFixManager) executePlan(ctx context.Context, plan *FixPlan, approved bool) (*FixResult, error) {
 	m.log.Infof("Executing fix plan %s (DryRun: %v)", plan.ID, plan.DryRun)
 
+	// The plan may have been built or edited elsewhere, so its risk is
+	// assessed again rather than trusted
+	if err := reassessPlan(plan); err != nil {
+		return nil, err
+	}
+	dryRun := plan.DryRun || m.dryRunMode
+	if !approved && !dryRun && (plan.RequiresApproval || m.requireApproval) {
+		m.log.Warnf("Refusing to execute plan %s without approval (risk: %s)", plan.ID, plan.RiskAssessment.Level)
+		now := time.Now().UTC()
+		return &FixResult{
+			PlanID:       plan.ID,
+			ExecutionID:  uuid.New().String(),
+			Status:       FixExecutionStatusValidationFailed,
+			StartedAt:    now,
+			CompletedAt:  now,
+			ErrorMessage: fmt.Sprintf("Plan has %s risk and must be approved before it runs", plan.RiskAssessment.Level),
+		}, fmt.Errorf("%w: submit plan %s to the approval gate", ErrApprovalRequired, plan.ID)
+	}
+
 	// Validate first (enforced lifecycle)
-	validationReport, err := m.ValidatePlan(ctx, plan)
+	validationReport, err := m.validatePlan(ctx, plan, approved)
 	if err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
@@ -258,7 +315,7 @@ func (m *AutoFixManager) ExecuteFixPlan(ctx context.Context, plan *FixPlan) (*Fi
 	m.addLog(result, LogLevelInfo, fmt.Sprintf("Starting execution of plan %s", plan.ID), "")
 
 	// Dry run mode: simulate without executing
-	if plan.DryRun || m.dryRunMode {
+	if dryRun {
 		m.log.Info("Dry-run mode: simulating execution")
 		result = m.simulateExecution(ctx, plan, result)
 		result.Status = FixExecutionStatusSuccess
@@ -273,6 +330,10 @@ func (m *AutoFixManager) ExecuteFixPlan(ctx context.Context, plan *FixPlan) (*Fi
 	for _, action := range plan.Actions {
 		actionResult := m.executeAction(ctx, action)
 		result.ActionResults = append(result.ActionResults, actionResult)
+		if actionResult.Before != nil || actionResult.After != nil {
+			m.addLog(result, LogLevelInfo, fmt.Sprintf("State of %s: before %v, after %v",
+				action.Action.ID, actionResult.Before, actionResult.After), action.Action.ID)
+		}
 
 		if actionResult.Status == ActionExecutionStatusSuccess {
 			completedActions = append(completedActions, actionResult)
@@ -286,7 +347,7 @@ func (m *AutoFixManager) ExecuteFixPlan(ctx context.Context, plan *FixPlan) (*Fi
 			if plan.Metadata != nil {
 				if enableRollback, ok := plan.Metadata["enable_rollback"].(bool); ok && enableRollback {
 					m.log.Info("Triggering rollback for failed execution")
-					rollbackSuccess := m.performRollback(ctx, completedActions)
+					rollbackSuccess := m.performRollback(ctx, plan, result, completedActions)
 					result.RollbackPerformed = true
 					result.RollbackSuccess = &rollbackSuccess
 
@@ -336,6 +397,39 @@ func (m *AutoFixManager) RecordExecution(
 		record.ApprovedAt = &now
 	}
 
+	return m.storeRecord(ctx, record)
+}
+
+// RecordApprovedExecution records an execution together with every
+// decision taken on its approval request.
+func (m *AutoFixManager) RecordApprovedExecution(
+	ctx context.Context,
+	req *ApprovalRequest,
+	result *FixResult,
+) (*ExecutionRecord, error) {
+	m.log.Infof("Recording approved execution %s for plan %s", result.ExecutionID, req.Plan.ID)
+
+	record := &ExecutionRecord{
+		ID:              uuid.New().String(),
+		Timestamp:       time.Now().UTC(),
+		PlanID:          req.Plan.ID,
+		DiagnosisID:     req.Plan.DiagnosisID,
+		ExecutionResult: result,
+		ApprovedBy:      approversList(req.ApprovedBy()),
+		Approvals:       req.Decisions,
+		SystemState:     make(map[string]interface{}),
+		Tags:            []string{"autofix", "approved", string(result.Status)},
+	}
+	if n := len(req.Decisions); n > 0 {
+		approvedAt := req.Decisions[n-1].DecidedAt
+		record.ApprovedAt = &approvedAt
+	}
+
+	return m.storeRecord(ctx, record)
+}
+
+// storeRecord persists an execution record if a record store is configured.
+func (m *AutoFixManager) storeRecord(ctx context.Context, record *ExecutionRecord) (*ExecutionRecord, error) {
 	// Store the record
 	if m.recordStore != nil {
 		if err := m.recordStore.Store(ctx, record); err != nil {
@@ -361,13 +455,50 @@ func (m *AutoFixManager) executeAction(ctx context.Context, actionItem *FixActio
 		ValidationsPassed: true,
 	}
 
-	// Simulate execution (placeholder for actual implementation)
-	// In production, this would delegate to the actual executor
-	result.Output = fmt.Sprintf("Simulated execution of: %s", actionItem.Action.Command)
-	result.Status = ActionExecutionStatusSuccess
-	result.CompletedAt = time.Now().UTC()
+	if m.fixRunner == nil {
+		// Simulate execution (placeholder for actual implementation)
+		// In production, this would delegate to the actual executor
+		result.Output = fmt.Sprintf("Simulated execution of: %s", actionItem.Action.Command)
+		result.Status = ActionExecutionStatusSuccess
+		result.CompletedAt = time.Now().UTC()
+
+		m.log.Infof("Action %s completed successfully", actionItem.Action.ID)
+		return result
+	}
 
-	m.log.Infof("Action %s completed successfully", actionItem.Action.ID)
+	if actionItem.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, actionItem.Timeout)
+		defer cancel()
+	}
+
+	fixResult, err := m.fixRunner.ExecuteFix(ctx, actionItem.Action)
+	result.CompletedAt = time.Now().UTC()
+	if fixResult != nil {
+		result.Output = fixResult.Message
+		result.Before = fixResult.Before
+		result.After = fixResult.After
+		result.Compensation = fixResult.Compensation
+	}
+
+	switch {
+	case err != nil || fixResult == nil || !fixResult.Success:
+		result.Status = ActionExecutionStatusFailed
+		if err != nil {
+			result.ErrorOutput = err.Error()
+		} else if fixResult != nil {
+			result.ErrorOutput = fixResult.Message
+		}
+		if fixResult != nil && fixResult.RolledBack {
+			// The runner already undid this action
+			result.Changes = append(result.Changes, "compensated after failed verification")
+		}
+		m.log.Warnf("Action %s failed: %s", actionItem.Action.ID, result.ErrorOutput)
+	default:
+		result.Status = ActionExecutionStatusSuccess
+		result.Changes = append(result.Changes, actionItem.Action.Command)
+		m.log.Infof("Action %s completed successfully", actionItem.Action.ID)
+	}
 	return result
 }
 
@@ -388,17 +519,49 @@ func (m *AutoFixManager) simulateExecution(ctx context.Context, plan *FixPlan, r
 	return result
 }
 
-// performRollback attempts to revert completed actions.
-func (m *AutoFixManager) performRollback(ctx context.Context, completedActions []*ActionResult) bool {
+// performRollback reverts completed actions in reverse order by running
+// their compensating commands through the plugin that applied them.
+func (m *AutoFixManager) performRollback(ctx context.Context, plan *FixPlan, result *FixResult, completedActions []*ActionResult) bool {
 	m.log.Info("Starting rollback process")
 
+	applied := make(map[string]*models.FixAction, len(plan.Actions))
+	for _, item := range plan.Actions {
+		applied[item.Action.ID] = item.Action
+	}
+
 	allSuccess := true
 	for i := len(completedActions) - 1; i >= 0; i-- {
 		action := completedActions[i]
 		m.log.Infof("Rolling back action: %s", action.ActionID)
 
-		// Placeholder for actual rollback logic
-		// In production, this would execute rollback commands
+		if m.fixRunner == nil {
+			// Simulated actions changed nothing
+			action.Status = ActionExecutionStatusRolledBack
+			continue
+		}
+		if action.Compensation == "" {
+			m.addLog(result, LogLevelWarn, fmt.Sprintf("Action %s has no compensating command and was left in place", action.ActionID), action.ActionID)
+			continue
+		}
+
+		compensation := &models.FixAction{
+			ID:          action.ActionID + "-rollback",
+			Description: fmt.Sprintf("Undo %s", action.ActionID),
+			Command:     action.Compensation,
+		}
+		if original := applied[action.ActionID]; original != nil {
+			compensation.Plugin = original.Plugin
+			compensation.Parameters = original.Parameters
+		}
+		res, err := m.fixRunner.ExecuteFix(ctx, compensation)
+		if err != nil || res == nil || !res.Success {
+			allSuccess = false
+			m.log.Errorf("Rollback of action %s failed: %v", action.ActionID, err)
+			m.addLog(result, LogLevelError, fmt.Sprintf("Rollback of %s failed: %v", action.ActionID, err), action.ActionID)
+			continue
+		}
+		action.Status = ActionExecutionStatusRolledBack
+		m.addLog(result, LogLevelInfo, fmt.Sprintf("Rolled back %s with %s", action.ActionID, action.Compensation), action.ActionID)
 		m.log.Infof("Action %s rolled back successfully", action.ActionID)
 	}
 
@@ -411,8 +574,34 @@ func (m *AutoFixManager) performRollback(ctx context.Context, completedActions [
 	return allSuccess
 }
 
+// reassessPlan recategorizes the plan's actions and replaces its risk
+// assessment with one computed from them. A plan that needs approval keeps
+// needing it.
+func reassessPlan(plan *FixPlan) error {
+	if len(plan.Actions) == 0 {
+		return fmt.Errorf("fix plan %s has no actions", plan.ID)
+	}
+	for _, item := range plan.Actions {
+		if item == nil || item.Action == nil {
+			return fmt.Errorf("fix plan %s has an empty action", plan.ID)
+		}
+		item.Category = categorizeAction(item.Action)
+	}
+	plan.RiskAssessment = assessRisk(plan.Actions)
+	plan.RequiresApproval = plan.RequiresApproval || plan.RiskAssessment.RequiresApproval
+	return nil
+}
+
+// riskRank orders risk levels from low to critical
+var riskRank = map[RiskLevel]int{
+	RiskLevelLow:      0,
+	RiskLevelMedium:   1,
+	RiskLevelHigh:     2,
+	RiskLevelCritical: 3,
+}
+
 // assessRisk evaluates the risk level of a fix plan.
-func (m *AutoFixManager) assessRisk(actions []*FixActionItem, opts *AutoFixOptions) *RiskAssessment {
+func assessRisk(actions []*FixActionItem) *RiskAssessment {
 	assessment := &RiskAssessment{
 		Level:            RiskLevelLow,
 		Score:            0,
@@ -432,6 +621,15 @@ func (m *AutoFixManager) assessRisk(actions []*FixActionItem, opts *AutoFixOptio
 			})
 		}
 
+		if action.Category == ActionCategoryConfiguration {
+			assessment.Score += 25
+			assessment.Factors = append(assessment.Factors, RiskFactor{
+				Name:        "config_change",
+				Description: "Action changes the configuration of a running service",
+				Severity:    RiskLevelMedium,
+			})
+		}
+
 		if action.Action.Command != "" && len(action.Action.Command) > 100 {
 			assessment.Score += 10
 			assessment.Factors = append(assessment.Factors, RiskFactor{
@@ -454,7 +652,7 @@ func (m *AutoFixManager) assessRisk(actions []*FixActionItem, opts *AutoFixOptio
 	}
 
 	// Require approval for high-risk operations
-	if assessment.Level >= RiskLevelHigh {
+	if riskRank[assessment.Level] >= riskRank[RiskLevelHigh] {
 		assessment.RequiresApproval = true
 		assessment.Mitigations = append(assessment.Mitigations,
 			"Manual approval required",
@@ -525,13 +723,13 @@ func categorizeAction(action *models.FixAction) ActionCategory {
 	if action.Category != "" {
 		// Map string category to typed category
 		switch action.Category {
-		case "validation":
+		case "validation", "Validation":
 			return ActionCategoryValidation
-		case "configuration", "config":
+		case "configuration", "config", "ConfigChange":
 			return ActionCategoryConfiguration
-		case "restart":
+		case "restart", "Restart":
 			return ActionCategoryRestart
-		case "scale":
+		case "scale", "Scale":
 			return ActionCategoryScale
 		case "cleanup":
 			return ActionCategoryCleanup
@@ -16,6 +16,7 @@ package execution
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -292,6 +293,75 @@ func TestExecutionManager_RiskAssessment(t *testing.T) {
 
 // Helper functions and mocks
 
+// TestExecutionManager_CompensatesOnFailure
+// Verifies that when an action fails, completed actions are undone with the
+// compensating commands their runner recorded
+func TestExecutionManager_CompensatesOnFailure(t *testing.T) {
+	ctx := context.Background()
+
+	runner := &mockFixRunner{failing: "fix-2"}
+	manager := NewAutoFixManager(nil, NewInMemoryRecordStore(), &AutoFixOptions{Enabled: true})
+	manager.SetFixRunner(runner)
+
+	plan := &FixPlan{
+		ID: "plan-compensate",
+		Actions: []*FixActionItem{
+			{Sequence: 0, Action: &models.FixAction{ID: "fix-1", Command: "CONFIG SET maxmemory-policy allkeys-lru", Plugin: "redis"}},
+			{Sequence: 1, Action: &models.FixAction{ID: "fix-2", Command: "CONFIG SET maxmemory 1gb", Plugin: "redis"}},
+		},
+		RiskAssessment: &RiskAssessment{Level: RiskLevelLow},
+		Metadata:       map[string]interface{}{"enable_rollback": true},
+	}
+
+	result, err := manager.ExecuteFixPlan(ctx, plan)
+	require.Error(t, err)
+	assert.Equal(t, FixExecutionStatusRolledBack, result.Status)
+	require.Len(t, result.ActionResults, 2)
+	assert.Equal(t, ActionExecutionStatusRolledBack, result.ActionResults[0].Status)
+	assert.Equal(t, "noeviction", result.ActionResults[0].Before)
+	assert.Equal(t, "allkeys-lru", result.ActionResults[0].After)
+	assert.Equal(t, []string{
+		"CONFIG SET maxmemory-policy allkeys-lru",
+		"CONFIG SET maxmemory 1gb",
+		"CONFIG SET maxmemory-policy noeviction",
+	}, runner.commands)
+	// The compensation goes to the plugin that applied the action
+	assert.Equal(t, []string{"redis", "redis", "redis"}, runner.plugins)
+}
+
+// TestExecutionManager_RequiresApproval
+// Verifies that plans needing approval only run through the approval gate,
+// whatever risk they claim
+func TestExecutionManager_RequiresApproval(t *testing.T) {
+	ctx := context.Background()
+
+	runner := &mockFixRunner{}
+	manager := NewAutoFixManager(nil, NewInMemoryRecordStore(), &AutoFixOptions{Enabled: true})
+	manager.SetFixRunner(runner)
+
+	plan := &FixPlan{
+		ID:             "plan-understated",
+		RiskAssessment: &RiskAssessment{Level: RiskLevelLow},
+		Metadata:       map[string]interface{}{},
+	}
+	for _, svc := range []string{"redis", "mysql", "kafka"} {
+		plan.Actions = append(plan.Actions, &FixActionItem{
+			Action: &models.FixAction{ID: "restart-" + svc, Command: "systemctl restart " + svc},
+		})
+	}
+
+	result, err := manager.ExecuteFixPlan(ctx, plan)
+	require.ErrorIs(t, err, ErrApprovalRequired)
+	assert.Equal(t, FixExecutionStatusValidationFailed, result.Status)
+	assert.Equal(t, RiskLevelHigh, plan.RiskAssessment.Level)
+	assert.Empty(t, runner.commands, "nothing runs without approval")
+
+	result, err = manager.ExecuteApproved(ctx, plan)
+	require.NoError(t, err)
+	assert.Equal(t, FixExecutionStatusSuccess, result.Status)
+	assert.Len(t, runner.commands, 3)
+}
+
 func createTestIssues() []*models.Issue {
 	return []*models.Issue{
 		{
@@ -443,3 +513,24 @@ func (m *mockExecutionManager) ValidateExecution(ctx context.Context, result *mo
 	m.called = true
 	return nil
 }
+
+// mockFixRunner is a test double for FixRunner that fails one action
+type mockFixRunner struct {
+	failing  string
+	commands []string
+	plugins  []string
+}
+
+func (r *mockFixRunner) ExecuteFix(ctx context.Context, fix *models.FixAction) (*models.FixResult, error) {
+	r.commands = append(r.commands, fix.Command)
+	r.plugins = append(r.plugins, fix.Plugin)
+	if fix.ID == r.failing {
+		return &models.FixResult{Success: false, Message: "boom"}, fmt.Errorf("boom")
+	}
+	return &models.FixResult{
+		Success:      true,
+		Before:       "noeviction",
+		After:        "allkeys-lru",
+		Compensation: "CONFIG SET maxmemory-policy noeviction",
+	}, nil
+}
@@ -0,0 +1,160 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package execution
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteRecordStore is an ExecutionRecordStore backed by SQLite, so the
+// audit trail of executed fix plans survives restarts and is shared by the
+// CLI and the server.
+type SQLiteRecordStore struct {
+	db *sql.DB
+	mu sync.RWMutex
+}
+
+// NewSQLiteRecordStore opens or creates the execution record database at path.
+func NewSQLiteRecordStore(path string) (*SQLiteRecordStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+	CREATE TABLE IF NOT EXISTS fix_executions (
+		id TEXT PRIMARY KEY,
+		plan_id TEXT NOT NULL,
+		diagnosis_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		data TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_fix_executions_plan ON fix_executions(plan_id);
+	CREATE INDEX IF NOT EXISTS idx_fix_executions_diagnosis ON fix_executions(diagnosis_id);
+	`
+	if _, err := db.Exec(query); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to init execution record db: %w", err)
+	}
+
+	return &SQLiteRecordStore{db: db}, nil
+}
+
+// Store implements ExecutionRecordStore.
+func (s *SQLiteRecordStore) Store(ctx context.Context, record *ExecutionRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal execution record: %w", err)
+	}
+	status := ""
+	if record.ExecutionResult != nil {
+		status = string(record.ExecutionResult.Status)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.db.ExecContext(ctx, `INSERT OR REPLACE INTO fix_executions
+		(id, plan_id, diagnosis_id, status, data, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		record.ID, record.PlanID, record.DiagnosisID, status, string(data), record.Timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to save execution record: %w", err)
+	}
+	return nil
+}
+
+// Get implements ExecutionRecordStore.
+func (s *SQLiteRecordStore) Get(ctx context.Context, id string) (*ExecutionRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var data string
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM fix_executions WHERE id = ?`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("record not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load execution record: %w", err)
+	}
+
+	var record ExecutionRecord
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal execution record: %w", err)
+	}
+	return &record, nil
+}
+
+// recordFilterColumns maps the supported List filters to their columns
+var recordFilterColumns = map[string]string{
+	"plan_id":      "plan_id",
+	"diagnosis_id": "diagnosis_id",
+	"status":       "status",
+}
+
+// List implements ExecutionRecordStore, newest first. The filters plan_id,
+// diagnosis_id and status match exactly; other filters are rejected.
+func (s *SQLiteRecordStore) List(ctx context.Context, filters map[string]interface{}) ([]*ExecutionRecord, error) {
+	var where []string
+	var args []interface{}
+	for key, value := range filters {
+		column, ok := recordFilterColumns[key]
+		if !ok {
+			return nil, fmt.Errorf("unsupported execution record filter %q", key)
+		}
+		where = append(where, column+" = ?")
+		args = append(args, fmt.Sprint(value))
+	}
+	query := `SELECT data FROM fix_executions`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at DESC"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list execution records: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*ExecutionRecord
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var record ExecutionRecord
+		if err := json.Unmarshal([]byte(data), &record); err != nil {
+			continue
+		}
+		out = append(out, &record)
+	}
+	return out, rows.Err()
+}
+
+// Close closes the database.
+func (s *SQLiteRecordStore) Close() error {
+	return s.db.Close()
+}
@@ -145,6 +145,9 @@ type ExecutionRecord struct {
 	// ApprovedAt records when approval was granted
 	ApprovedAt *time.Time `json:"approvedAt,omitempty" yaml:"approvedAt,omitempty"`
 
+	// Approvals lists every approval decision taken on the plan
+	Approvals []ApprovalDecision `json:"approvals,omitempty" yaml:"approvals,omitempty"`
+
 	// SystemState captures relevant system state before execution
 	SystemState map[string]interface{} `json:"systemState,omitempty" yaml:"systemState,omitempty"`
 
@@ -177,6 +180,15 @@ type ActionResult struct {
 
 	// Changes records what was actually modified
 	Changes []string `json:"changes,omitempty" yaml:"changes,omitempty"`
+
+	// Before is the state probed before the action ran
+	Before interface{} `json:"before,omitempty" yaml:"before,omitempty"`
+
+	// After is the state probed to verify the action
+	After interface{} `json:"after,omitempty" yaml:"after,omitempty"`
+
+	// Compensation is the command that undoes the action
+	Compensation string `json:"compensation,omitempty" yaml:"compensation,omitempty"`
 }
 
 // ValidationReport contains the results of pre-execution validation checks.
@@ -34,6 +34,9 @@ type DiagnosisRequest struct {
 	// OutputFormat specifies the desired format of the result (e.g., "json", "text").
 	// Defaults to "text" if not specified.
 	OutputFormat string `json:"outputFormat,omitempty" yaml:"outputFormat,omitempty"`
+	// Symptoms are alerts raised on instances that depend on the target and
+	// were attributed to it during alert correlation.
+	Symptoms []*AlertEvent `json:"symptoms,omitempty" yaml:"symptoms,omitempty"`
 }
 
 // DiagnosisResult is the comprehensive, structured output of a completed diagnosis run.
@@ -69,6 +72,9 @@ type Issue struct {
 	Evidence string `json:"evidence" yaml:"evidence"`
 	// Recommendations is a list of suggested actions to resolve the issue.
 	Recommendations []*Recommendation `json:"recommendations" yaml:"recommendations"`
+	// Metadata holds structured identifiers of the affected objects (e.g., consumer group, topic, PID)
+	// so that fixes can target them precisely.
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
 }
 
 // Recommendation provides a suggested action or set of actions to resolve a specific issue.
@@ -195,12 +201,33 @@ type FixAction struct {
 	Description string `json:"description" yaml:"description"`
 	// Command is the shell command to be executed.
 	Command string `json:"command" yaml:"command"`
-	// RollbackCommand is the shell command to be executed to undo the action.
+	// RollbackCommand is the compensating command that undoes the action. It may
+	// reference the value recorded by the precondition probe as {{before}}.
 	RollbackCommand string `json:"rollbackCommand,omitempty" yaml:"rollbackCommand,omitempty"`
+	// Precondition is probed before the fix; the fix is skipped unless it holds.
+	// Its value is recorded as the "before" state.
+	Precondition *FixProbe `json:"precondition,omitempty" yaml:"precondition,omitempty"`
+	// Verification is probed after the fix; the fix is rolled back unless it holds.
+	Verification *FixProbe `json:"verification,omitempty" yaml:"verification,omitempty"`
 	// Parameters provides any additional parameters needed to execute the fix.
 	Parameters map[string]string `json:"parameters" yaml:"parameters"`
 	// Category helps classify the action type for dependency analysis (e.g., "ConfigChange", "Restart", "Validation").
 	Category string `json:"category,omitempty" yaml:"category,omitempty"`
+	// Plugin names the plugin that applies the action (e.g., "redis"). Fix runners route the action to it.
+	Plugin string `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+}
+
+// FixProbe is a read-only command run around a fix to check the state of the
+// middleware, e.g. `CONFIG GET maxmemory-policy`.
+type FixProbe struct {
+	// Command is the read-only command whose reply is the probed value.
+	Command string `json:"command" yaml:"command"`
+	// Expect is a boolean expression over the reply `value` and, after the fix,
+	// the precondition's `before` value, e.g. `value == "allkeys-lru"`. An empty
+	// expression only requires the probe to succeed.
+	Expect string `json:"expect,omitempty" yaml:"expect,omitempty"`
+	// Settle is how long to wait before probing, giving the fix time to take effect.
+	Settle time.Duration `json:"settle,omitempty" yaml:"settle,omitempty"`
 }
 
 // FixResult represents the outcome of a single executed fix action.
@@ -209,4 +236,12 @@ type FixResult struct {
 	Success bool `json:"success" yaml:"success"`
 	// Message provides details about the outcome, such as stdout or an error message.
 	Message string `json:"message" yaml:"message"`
+	// Before is the value of the precondition probe, if any.
+	Before interface{} `json:"before,omitempty" yaml:"before,omitempty"`
+	// After is the value of the verification probe, if any.
+	After interface{} `json:"after,omitempty" yaml:"after,omitempty"`
+	// RolledBack is true if the compensating command ran because the fix failed or did not verify.
+	RolledBack bool `json:"rolledBack,omitempty" yaml:"rolledBack,omitempty"`
+	// Compensation is the rendered compensating command that undoes the applied fix.
+	Compensation string `json:"compensation,omitempty" yaml:"compensation,omitempty"`
 }
@@ -2,26 +2,20 @@ package rca
 
 import (
 	"context"
+	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/detection/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/diagnosis/rules"
 )
 
-// Condition represents a condition for a rule match.
-type Condition struct {
-	AnomalyType string
-	Severity    string // Optional, if empty matches any severity
-}
-
-// Rule represents a mapping from symptoms (conditions) to a root cause.
-type Rule struct {
-	Name       string
-	Conditions []Condition
-	RootCause  string
-	Priority   int
-	Actions    []string
-}
+// Rule maps symptoms to a root cause. RCA rules use the shared rule schema;
+// their conditions test the detected anomalies, e.g.
+// `anomaly("HighCPU") && anomaly("HighConnections", "high")`.
+type Rule = rules.Rule
 
 // RCAResult represents the outcome of Root Cause Analysis.
 type RCAResult struct {
@@ -33,68 +27,52 @@ type RCAResult struct {
 
 // Engine is the RCA Rules Engine.
 type RulesEngine struct {
-	rules []Rule
+	rules     []Rule
+	evaluator *rules.Evaluator
+	log       logger.Logger
 }
 
 // NewRulesEngine creates a new RulesEngine.
 func NewRulesEngine(cfg *config.Config) *RulesEngine {
-	var rules []Rule
+	var ruleList []Rule
 
 	if cfg != nil && len(cfg.RCA.Rules) > 0 {
 		// Convert config rules to internal rules
 		for _, rc := range cfg.RCA.Rules {
-			var conditions []Condition
-			for _, cc := range rc.Conditions {
-				conditions = append(conditions, Condition{
-					AnomalyType: cc.AnomalyType,
-					Severity:    cc.Severity,
-				})
-			}
-			rules = append(rules, Rule{
-				Name:       rc.Name,
-				Conditions: conditions,
-				RootCause:  rc.RootCause,
-				Priority:   rc.Priority,
-				Actions:    rc.Actions,
-			})
+			ruleList = append(ruleList, ruleFromConfig(rc))
 		}
 	} else {
 		// Initialize with some default rules for now.
-		rules = []Rule{
+		ruleList = []Rule{
 			{
-				Name: "High CPU from Connections",
-				Conditions: []Condition{
-					{AnomalyType: models.AnomalyTypeHighCPU},
-					{AnomalyType: models.AnomalyTypeHighConnections},
-				},
+				ID:        "rca-connection-storm",
+				Name:      "High CPU from Connections",
+				Condition: rules.Condition{Expression: fmt.Sprintf("anomaly(%q) && anomaly(%q)", models.AnomalyTypeHighCPU, models.AnomalyTypeHighConnections)},
 				RootCause: "Connection Storm",
 				Priority:  100,
 				Actions:   []string{"Check for client connection leaks", "Increase connection limit if capacity allows", "Implement connection pooling"},
 			},
 			{
-				Name: "High Memory",
-				Conditions: []Condition{
-					{AnomalyType: models.AnomalyTypeHighMemory},
-				},
+				ID:        "rca-high-memory",
+				Name:      "High Memory",
+				Condition: rules.Condition{Expression: fmt.Sprintf("anomaly(%q)", models.AnomalyTypeHighMemory)},
 				RootCause: "Memory Leak or OOM Risk",
 				Priority:  50,
 				Actions:   []string{"Analyze memory dump", "Check for large keys (Redis)"},
 			},
 			{
-				Name: "Slow Query",
-				Conditions: []Condition{
-					{AnomalyType: models.AnomalyTypeSlowQuery},
-				},
+				ID:        "rca-slow-query",
+				Name:      "Slow Query",
+				Condition: rules.Condition{Expression: fmt.Sprintf("anomaly(%q)", models.AnomalyTypeSlowQuery)},
 				RootCause: "Unoptimized Query",
 				Priority:  80,
 				Actions:   []string{"Explain query plan", "Add missing index"},
 			},
 			// Fallback generic rules
 			{
-				Name: "High CPU Generic",
-				Conditions: []Condition{
-					{AnomalyType: models.AnomalyTypeHighCPU},
-				},
+				ID:        "rca-high-cpu",
+				Name:      "High CPU Generic",
+				Condition: rules.Condition{Expression: fmt.Sprintf("anomaly(%q)", models.AnomalyTypeHighCPU)},
 				RootCause: "High CPU Usage",
 				Priority:  10,
 				Actions:   []string{"Check top consumers", "Scale up CPU"},
@@ -103,7 +81,41 @@ func NewRulesEngine(cfg *config.Config) *RulesEngine {
 	}
 
 	return &RulesEngine{
-		rules: rules,
+		rules:     ruleList,
+		evaluator: rules.NewEvaluator(),
+		log:       logger.NewLogger("rca-rules"),
+	}
+}
+
+// ruleFromConfig converts a configured rule. The legacy conditions list is
+// compiled into an anomaly() conjunction when no condition is given.
+func ruleFromConfig(rc config.RuleConfig) Rule {
+	expression := rc.Condition
+	if expression == "" {
+		var terms []string
+		for _, cc := range rc.Conditions {
+			if cc.Severity != "" {
+				terms = append(terms, fmt.Sprintf("anomaly(%q, %q)", cc.AnomalyType, cc.Severity))
+			} else {
+				terms = append(terms, fmt.Sprintf("anomaly(%q)", cc.AnomalyType))
+			}
+		}
+		expression = strings.Join(terms, " && ")
+	}
+	if expression == "" {
+		expression = "true"
+	}
+	id := rc.ID
+	if id == "" {
+		id = rc.Name
+	}
+	return Rule{
+		ID:        id,
+		Name:      rc.Name,
+		Condition: rules.Condition{Expression: expression},
+		RootCause: rc.RootCause,
+		Priority:  rc.Priority,
+		Actions:   rc.Actions,
 	}
 }
 
@@ -117,7 +129,7 @@ func (e *RulesEngine) Analyze(ctx context.Context, anomalies []models.Anomaly) (
 	var matchedRules []Rule
 
 	for _, rule := range e.rules {
-		if e.matchRule(rule, anomalies) {
+		if rule.IsEnabled() && e.matchRule(rule, anomalies) {
 			matchedRules = append(matchedRules, rule)
 		}
 	}
@@ -144,22 +156,12 @@ func (e *RulesEngine) Analyze(ctx context.Context, anomalies []models.Anomaly) (
 }
 
 func (e *RulesEngine) matchRule(rule Rule, anomalies []models.Anomaly) bool {
-	// All conditions in the rule must be met (AND logic)
-	for _, condition := range rule.Conditions {
-		conditionMet := false
-		for _, anomaly := range anomalies {
-			if anomaly.Type == condition.AnomalyType {
-				if condition.Severity == "" || anomaly.Severity == condition.Severity {
-					conditionMet = true
-					break
-				}
-			}
-		}
-		if !conditionMet {
-			return false
-		}
+	matched, err := e.evaluator.Evaluate(rule.Condition.Expression, rules.AnomalyEnv(anomalies))
+	if err != nil {
+		e.log.Warnf("RCA rule %s evaluation failed: %v", rule.Name, err)
+		return false
 	}
-	return true
+	return matched
 }
 
 func (e *RulesEngine) calculateConfidence(rule Rule, anomalies []models.Anomaly) float64 {
@@ -173,7 +175,7 @@ func (e *RulesEngine) calculateConfidence(rule Rule, anomalies []models.Anomaly)
 	}
 
 	// Add a little boost if we matched multiple specific conditions
-	if len(rule.Conditions) > 1 {
+	if strings.Count(rule.Condition.Expression, "anomaly(") > 1 {
 		baseConfidence += 0.05
 	}
 
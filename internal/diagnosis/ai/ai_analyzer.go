@@ -35,7 +35,7 @@ func (a *Analyzer) Analyze(ctx context.Context, data *models.CollectedData) ([]*
 	}
 
 	// 2. Call LLM
-	response, err := a.llmClient.SendMessage(ctx, req)
+	response, err := a.llmClient.SendMessage(interfaces.WithFeature(ctx, "diagnosis"), req)
 	if err != nil {
 		return nil, err
 	}
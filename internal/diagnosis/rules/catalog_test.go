@@ -0,0 +1,137 @@
+package rules
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// repoRoot is the repository root relative to this package
+const repoRoot = "../../.."
+
+// collectorDirs maps the plugin packages whose collector.go reports
+// metrics under literal names to the middleware they report for. MySQL's
+// legacy collector passes SHOW GLOBAL STATUS through, and the legacy Redis
+// collector is behind a build tag.
+var collectorDirs = map[string]string{
+	"internal/plugin/kafka":                  "kafka",
+	"internal/plugins/builtin/elasticsearch": "elasticsearch",
+	"internal/plugins/builtin/kafka":         "kafka",
+	"internal/plugins/builtin/mongodb":       "mongodb",
+	"internal/plugins/builtin/mysql":         "mysql",
+	"internal/plugins/builtin/postgresql":    "postgresql",
+	"internal/plugins/builtin/rabbitmq":      "rabbitmq",
+	"internal/plugins/builtin/redis":         "redis",
+}
+
+func loadShippedCatalog(t *testing.T) MetricCatalog {
+	t.Helper()
+	catalog, err := LoadMetricCatalog(filepath.Join(repoRoot, "data/rules/metrics.yaml"))
+	require.NoError(t, err)
+	return catalog
+}
+
+// TestMetricCatalog_CoversCollectors checks that every metric a collector
+// sets under a literal name is in the catalog, so the catalog does not
+// drift from the collectors it describes.
+func TestMetricCatalog_CoversCollectors(t *testing.T) {
+	catalog := loadShippedCatalog(t)
+	for dir, middleware := range collectorDirs {
+		entry, ok := catalog[middleware]
+		if !assert.True(t, ok, "no catalog entry for %s", middleware) {
+			continue
+		}
+		file := filepath.Join(repoRoot, dir, "collector.go")
+		names := collectedMetricNames(t, file)
+		assert.NotEmpty(t, names, "no metrics found in %s", file)
+		for _, name := range names {
+			assert.True(t, entry.Has(name), "%s sets %q, which the %s catalog does not list", file, name, middleware)
+		}
+	}
+}
+
+// TestShippedRules_Lint checks that the shipped rules only read metrics
+// their plugin collects and that the shipped fixtures pass.
+func TestShippedRules_Lint(t *testing.T) {
+	ruleSets, err := LoadPaths(
+		filepath.Join(repoRoot, "data/diagnosis"),
+		filepath.Join(repoRoot, "internal/knowledge/repository"),
+		filepath.Join(repoRoot, "configs/rca/rules.yaml"),
+	)
+	require.NoError(t, err)
+	var all []Rule
+	for _, rs := range ruleSets {
+		all = append(all, rs.Rules...)
+	}
+	fixtures, err := LoadFixtures(filepath.Join(repoRoot, "data/rules/fixtures"))
+	require.NoError(t, err)
+
+	report := NewHarness(all, fixtures, loadShippedCatalog(t)).Run()
+	assert.Empty(t, report.Lint)
+	for _, f := range report.Fixtures {
+		assert.True(t, f.Passed(), "fixture %s: missing %v, unexpected %v", f.Fixture, f.Missing, f.Unexpected)
+	}
+}
+
+// collectedMetricNames returns the literal metric names file assigns, as
+// in metrics["name"] = v, snapshot.Metrics["name"] = v or a metrics map
+// literal, or passes to a set("name", path...) helper.
+func collectedMetricNames(t *testing.T, file string) []string {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	require.NoError(t, err)
+
+	seen := make(map[string]bool)
+	var names []string
+	add := func(expr ast.Expr) {
+		lit, ok := expr.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return
+		}
+		if name, err := strconv.Unquote(lit.Value); err == nil && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				if idx, ok := lhs.(*ast.IndexExpr); ok && isMetricsMap(idx.X) {
+					add(idx.Index)
+				}
+				if isMetricsMap(lhs) && i < len(n.Rhs) {
+					if lit, ok := n.Rhs[i].(*ast.CompositeLit); ok {
+						for _, elt := range lit.Elts {
+							if kv, ok := elt.(*ast.KeyValueExpr); ok {
+								add(kv.Key)
+							}
+						}
+					}
+				}
+			}
+		case *ast.CallExpr:
+			if fn, ok := n.Fun.(*ast.Ident); ok && fn.Name == "set" && len(n.Args) > 0 {
+				add(n.Args[0])
+			}
+		}
+		return true
+	})
+	return names
+}
+
+func isMetricsMap(expr ast.Expr) bool {
+	switch x := expr.(type) {
+	case *ast.Ident:
+		return x.Name == "metrics"
+	case *ast.SelectorExpr:
+		return x.Sel.Name == "Metrics"
+	}
+	return false
+}
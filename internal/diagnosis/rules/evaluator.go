@@ -0,0 +1,267 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/parser"
+	"github.com/expr-lang/expr/vm"
+	detmodels "github.com/kubestack-ai/kubestack-ai/internal/core/detection/models"
+)
+
+// Reserved environment names. Everything else at the top level of the
+// environment is a metric value.
+const (
+	EnvMetrics     = "metrics"
+	EnvConfig      = "config"
+	EnvSlowLogs    = "slowlogs"
+	EnvConnections = "connections"
+	EnvReplication = "replication"
+	EnvExtra       = "extra"
+	EnvAnomalies   = "anomalies"
+	EnvAnomaly     = "anomaly"
+)
+
+var reservedNames = map[string]bool{
+	EnvMetrics: true, EnvConfig: true, EnvSlowLogs: true, EnvConnections: true,
+	EnvReplication: true, EnvExtra: true, EnvAnomalies: true, EnvAnomaly: true,
+}
+
+// References are the names a condition reads from its environment
+type References struct {
+	// Names are top-level identifiers, e.g. memory_usage_ratio or config
+	Names []string
+	// Metrics are names read through metrics.<name> or metrics["<name>"]
+	Metrics []string
+	// Config are keys read through config.<key> or config["<key>"]
+	Config []string
+}
+
+// MetricNames returns every metric the condition reads, flat or through
+// the metrics map.
+func (r References) MetricNames() []string {
+	var out []string
+	for _, n := range r.Names {
+		if !reservedNames[n] {
+			out = append(out, n)
+		}
+	}
+	return append(out, r.Metrics...)
+}
+
+type compiled struct {
+	program *vm.Program
+	refs    References
+}
+
+// Evaluator compiles rule conditions once and evaluates them against an
+// environment. A condition that reads a metric or data missing from the
+// environment does not match, so rules for data a collector did not report
+// stay quiet; Lint catches conditions that can never see their data.
+type Evaluator struct {
+	mu       sync.RWMutex
+	programs map[string]*compiled
+}
+
+// NewEvaluator creates an Evaluator with an empty program cache
+func NewEvaluator() *Evaluator {
+	return &Evaluator{programs: make(map[string]*compiled)}
+}
+
+// Compile parses and compiles a condition, returning what it references
+func (e *Evaluator) Compile(condition string) (References, error) {
+	c, err := e.compile(condition)
+	if err != nil {
+		return References{}, err
+	}
+	return c.refs, nil
+}
+
+// Evaluate reports whether condition holds in env
+func (e *Evaluator) Evaluate(condition string, env map[string]interface{}) (bool, error) {
+	c, err := e.compile(condition)
+	if err != nil {
+		return false, err
+	}
+	if missingReference(c.refs, env) {
+		return false, nil
+	}
+
+	result, err := expr.Run(c.program, env)
+	if err != nil {
+		return false, fmt.Errorf("evaluate condition failed: %w", err)
+	}
+	matched, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("condition result is not bool: %T", result)
+	}
+	return matched, nil
+}
+
+func (e *Evaluator) compile(condition string) (*compiled, error) {
+	e.mu.RLock()
+	c, ok := e.programs[condition]
+	e.mu.RUnlock()
+	if ok {
+		return c, nil
+	}
+
+	if strings.TrimSpace(condition) == "" {
+		return nil, fmt.Errorf("compile condition failed: empty condition")
+	}
+	refs, err := ParseReferences(condition)
+	if err != nil {
+		return nil, fmt.Errorf("compile condition failed: %w", err)
+	}
+	program, err := expr.Compile(condition)
+	if err != nil {
+		return nil, fmt.Errorf("compile condition failed: %w", err)
+	}
+
+	c = &compiled{program: program, refs: refs}
+	e.mu.Lock()
+	e.programs[condition] = c
+	e.mu.Unlock()
+	return c, nil
+}
+
+// missingReference reports whether env lacks a metric or data the
+// condition reads
+func missingReference(refs References, env map[string]interface{}) bool {
+	for _, n := range refs.Names {
+		if _, ok := env[n]; !ok {
+			return true
+		}
+	}
+	if len(refs.Metrics) > 0 {
+		metrics, _ := env[EnvMetrics].(map[string]float64)
+		for _, m := range refs.Metrics {
+			if _, ok := metrics[m]; !ok {
+				return true
+			}
+		}
+	}
+	if len(refs.Config) > 0 {
+		config, _ := env[EnvConfig].(map[string]interface{})
+		for _, k := range refs.Config {
+			if _, ok := config[k]; !ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseReferences lists the environment names a condition reads
+func ParseReferences(condition string) (References, error) {
+	tree, err := parser.Parse(condition)
+	if err != nil {
+		return References{}, err
+	}
+	// Walk visits children first, so collect let-declared names up front
+	decls := &declVisitor{declared: make(map[string]bool)}
+	ast.Walk(&tree.Node, decls)
+	v := &refVisitor{declared: decls.declared, seen: make(map[string]bool)}
+	ast.Walk(&tree.Node, v)
+	return v.refs, nil
+}
+
+type declVisitor struct {
+	declared map[string]bool
+}
+
+func (v *declVisitor) Visit(node *ast.Node) {
+	if n, ok := (*node).(*ast.VariableDeclaratorNode); ok {
+		v.declared[n.Name] = true
+	}
+}
+
+type refVisitor struct {
+	refs     References
+	declared map[string]bool
+	seen     map[string]bool
+}
+
+func (v *refVisitor) Visit(node *ast.Node) {
+	switch n := (*node).(type) {
+	case *ast.IdentifierNode:
+		if !v.declared[n.Value] && !v.seen[n.Value] {
+			v.seen[n.Value] = true
+			v.refs.Names = append(v.refs.Names, n.Value)
+		}
+	case *ast.MemberNode:
+		root, ok := n.Node.(*ast.IdentifierNode)
+		if !ok || v.declared[root.Value] {
+			return
+		}
+		prop, ok := n.Property.(*ast.StringNode)
+		if !ok || v.seen[root.Value+"."+prop.Value] {
+			return
+		}
+		switch root.Value {
+		case EnvMetrics:
+			v.refs.Metrics = append(v.refs.Metrics, prop.Value)
+		case EnvConfig:
+			v.refs.Config = append(v.refs.Config, prop.Value)
+		default:
+			return
+		}
+		v.seen[root.Value+"."+prop.Value] = true
+	}
+}
+
+// BuildEnv builds the evaluation environment for a snapshot. Besides the
+// reserved names, every metric is also exposed at the top level so that
+// `memory_usage_ratio > 0.9` and `metrics.memory_usage_ratio > 0.9` are
+// equivalent. anomaly(type[, severity]) reports whether a matching anomaly
+// was detected.
+func BuildEnv(evalCtx *EvalContext) map[string]interface{} {
+	if evalCtx == nil {
+		evalCtx = &EvalContext{}
+	}
+	env := make(map[string]interface{})
+
+	metrics := make(map[string]float64)
+	if evalCtx.Metrics != nil {
+		for name, metric := range evalCtx.Metrics.Metrics {
+			metrics[name] = metric.Value
+			if !reservedNames[name] {
+				env[name] = metric.Value
+			}
+		}
+	}
+	env[EnvMetrics] = metrics
+	env[EnvConfig] = evalCtx.Config
+	env[EnvSlowLogs] = evalCtx.SlowLogs
+	env[EnvConnections] = evalCtx.Connections
+	if evalCtx.Replication != nil {
+		// expr can access struct fields directly since they are exported
+		env[EnvReplication] = evalCtx.Replication
+	}
+	env[EnvExtra] = evalCtx.Extra
+	env[EnvAnomalies] = evalCtx.Anomalies
+	env[EnvAnomaly] = anomalyFunc(evalCtx.Anomalies)
+	return env
+}
+
+// AnomalyEnv builds the environment for rules that only look at anomalies
+func AnomalyEnv(anomalies []detmodels.Anomaly) map[string]interface{} {
+	return BuildEnv(&EvalContext{Anomalies: anomalies})
+}
+
+func anomalyFunc(anomalies []detmodels.Anomaly) func(string, ...string) bool {
+	return func(anomalyType string, severity ...string) bool {
+		for _, a := range anomalies {
+			if a.Type != anomalyType {
+				continue
+			}
+			if len(severity) == 0 || severity[0] == "" || a.Severity == severity[0] {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,72 @@
+package rules
+
+import (
+	"testing"
+
+	detmodels "github.com/kubestack-ai/kubestack-ai/internal/core/detection/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_SharedEnvironment(t *testing.T) {
+	env := BuildEnv(&EvalContext{
+		Metrics: &plugin.MetricsSnapshot{Metrics: map[string]plugin.MetricValue{
+			"used_memory": {Value: 900}, "maxmemory": {Value: 1000},
+		}},
+		Config:    map[string]interface{}{"maxmemory-policy": "noeviction"},
+		SlowLogs:  []plugin.SlowLogEntry{{Command: "KEYS *"}},
+		Anomalies: []detmodels.Anomaly{{Type: "HighMemory", Severity: "high"}},
+	})
+	e := NewEvaluator()
+
+	tests := []struct {
+		name      string
+		condition string
+		expected  bool
+	}{
+		{"metrics map", "metrics.used_memory / metrics.maxmemory > 0.8", true},
+		{"flat metric names", "used_memory / maxmemory > 0.8", true},
+		{"config", `config["maxmemory-policy"] == "noeviction"`, true},
+		{"slow log", `any(slowlogs, .Command == "KEYS *")`, true},
+		{"anomaly", `anomaly("HighMemory")`, true},
+		{"anomaly severity", `anomaly("HighMemory", "critical")`, false},
+		{"missing flat metric", "connected_clients > 100", false},
+		{"missing map metric", "metrics.connected_clients > 100", false},
+		{"missing config key", "config.maxclients < 100", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, err := e.Evaluate(tt.condition, env)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, matched)
+		})
+	}
+
+	_, err := e.Evaluate("used_memory >", env)
+	assert.Error(t, err)
+	_, err = e.Evaluate("used_memory + 1", env)
+	assert.Error(t, err, "conditions must be boolean")
+}
+
+func TestParseReferences(t *testing.T) {
+	refs, err := ParseReferences(`let total = hits + misses; total > 0 && metrics.evicted_keys > 0 && config.maxmemory > 0 && anomaly("HighMemory")`)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"hits", "misses", "evicted_keys"}, refs.MetricNames())
+	assert.Equal(t, []string{"maxmemory"}, refs.Config)
+}
+
+func TestCondition_Unmarshal(t *testing.T) {
+	rs, err := (&JSONRuleLoader{}).LoadFromJSON([]byte(`{"middleware": "redis", "rules": [
+		{"id": "a", "condition": "hits > 1"},
+		{"id": "b", "condition": {"expression": "misses > 1"}, "middleware_type": "mysql", "enabled": false}
+	]}`))
+	require.NoError(t, err)
+	require.Len(t, rs.Rules, 2)
+	assert.Equal(t, "hits > 1", rs.Rules[0].Condition.Expression)
+	assert.Equal(t, "redis", rs.Rules[0].MiddlewareType)
+	assert.True(t, rs.Rules[0].IsEnabled())
+	assert.Equal(t, "misses > 1", rs.Rules[1].Condition.Expression)
+	assert.Equal(t, "mysql", rs.Rules[1].MiddlewareType)
+	assert.False(t, rs.Rules[1].IsEnabled())
+}
@@ -0,0 +1,357 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	detmodels "github.com/kubestack-ai/kubestack-ai/internal/core/detection/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/plugin"
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture is a recorded snapshot together with the IDs of the rules that
+// must match it. Every other in-scope rule must not match.
+type Fixture struct {
+	Name string `yaml:"name"`
+	// Middleware limits the fixture to rules of that middleware and
+	// rules without one
+	Middleware  string                 `yaml:"middleware"`
+	Metrics     map[string]float64     `yaml:"metrics"`
+	Config      map[string]interface{} `yaml:"config"`
+	SlowLogs    []FixtureSlowLog       `yaml:"slowlogs"`
+	Connections []FixtureConnection    `yaml:"connections"`
+	Replication *FixtureReplication    `yaml:"replication"`
+	Anomalies   []FixtureAnomaly       `yaml:"anomalies"`
+	Extra       map[string]interface{} `yaml:"extra"`
+	Expect      []string               `yaml:"expect"`
+
+	// File is the file the fixture was loaded from
+	File string `yaml:"-"`
+}
+
+// FixtureSlowLog is a slow log entry in a fixture
+type FixtureSlowLog struct {
+	Command      string        `yaml:"command"`
+	Query        string        `yaml:"query"`
+	Duration     time.Duration `yaml:"duration"`
+	ClientIP     string        `yaml:"client_ip"`
+	User         string        `yaml:"user"`
+	Database     string        `yaml:"database"`
+	RowsExamined int64         `yaml:"rows_examined"`
+}
+
+// FixtureConnection is a client connection in a fixture
+type FixtureConnection struct {
+	User     string `yaml:"user"`
+	ClientIP string `yaml:"client_ip"`
+	Database string `yaml:"database"`
+	Command  string `yaml:"command"`
+	Time     int64  `yaml:"time"`
+	State    string `yaml:"state"`
+}
+
+// FixtureReplication is the replication status in a fixture
+type FixtureReplication struct {
+	Role             string        `yaml:"role"`
+	MasterLinkStatus string        `yaml:"master_link_status"`
+	SlaveLag         time.Duration `yaml:"slave_lag"`
+	ConnectedSlaves  int           `yaml:"connected_slaves"`
+}
+
+// FixtureAnomaly is a detected anomaly in a fixture
+type FixtureAnomaly struct {
+	Type     string `yaml:"type"`
+	Severity string `yaml:"severity"`
+}
+
+// EvalContext converts the fixture into the context rules are evaluated in
+func (f *Fixture) EvalContext() *EvalContext {
+	ctx := &EvalContext{
+		Metrics: &plugin.MetricsSnapshot{Metrics: make(map[string]plugin.MetricValue)},
+		Config:  f.Config,
+		Extra:   f.Extra,
+	}
+	for name, v := range f.Metrics {
+		ctx.Metrics.Metrics[name] = plugin.MetricValue{Name: name, Value: v}
+	}
+	for _, s := range f.SlowLogs {
+		ctx.SlowLogs = append(ctx.SlowLogs, plugin.SlowLogEntry{
+			Command: s.Command, Query: s.Query, Duration: s.Duration, ClientIP: s.ClientIP,
+			User: s.User, Database: s.Database, RowsExam: s.RowsExamined,
+		})
+	}
+	for _, c := range f.Connections {
+		ctx.Connections = append(ctx.Connections, plugin.ConnectionInfo{
+			User: c.User, ClientIP: c.ClientIP, Database: c.Database,
+			Command: c.Command, Time: c.Time, State: c.State,
+		})
+	}
+	if r := f.Replication; r != nil {
+		ctx.Replication = &plugin.ReplicationInfo{
+			Role: r.Role, MasterLinkStatus: r.MasterLinkStatus,
+			SlaveLag: r.SlaveLag, ConnectedSlaves: r.ConnectedSlaves,
+		}
+	}
+	for _, a := range f.Anomalies {
+		ctx.Anomalies = append(ctx.Anomalies, detmodels.Anomaly{Type: a.Type, Severity: a.Severity})
+	}
+	return ctx
+}
+
+// LoadFixtures loads fixtures from YAML files or directories; each file
+// holds a list of fixtures.
+func LoadFixtures(paths ...string) ([]Fixture, error) {
+	var fixtures []Fixture
+	for _, p := range paths {
+		files, err := ruleFiles(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			var fs []Fixture
+			if err := yaml.Unmarshal(data, &fs); err != nil {
+				return nil, fmt.Errorf("failed to parse fixtures in %s: %w", file, err)
+			}
+			for i := range fs {
+				fs[i].File = file
+				if fs[i].Name == "" {
+					fs[i].Name = fmt.Sprintf("%s#%d", file, i+1)
+				}
+			}
+			fixtures = append(fixtures, fs...)
+		}
+	}
+	return fixtures, nil
+}
+
+// MetricCatalog lists, per middleware, the metric names its collectors
+// report. Patterns are path.Match globs for collectors that pass through
+// whole status tables, such as MySQL's SHOW GLOBAL STATUS.
+type MetricCatalog map[string]CatalogEntry
+
+// CatalogEntry is the set of metrics one middleware reports
+type CatalogEntry struct {
+	Metrics  []string `yaml:"metrics"`
+	Patterns []string `yaml:"patterns"`
+}
+
+// LoadMetricCatalog loads a metric catalog from a YAML file
+func LoadMetricCatalog(file string) (MetricCatalog, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var catalog MetricCatalog
+	if err := yaml.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse metric catalog %s: %w", file, err)
+	}
+	return catalog, nil
+}
+
+// Has reports whether the middleware's collectors report metric
+func (e CatalogEntry) Has(metric string) bool {
+	for _, m := range e.Metrics {
+		if m == metric {
+			return true
+		}
+	}
+	for _, p := range e.Patterns {
+		if ok, _ := path.Match(p, metric); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// LintIssue is a problem found in a rule without evaluating it
+type LintIssue struct {
+	RuleID  string `json:"rule_id"`
+	Message string `json:"message"`
+}
+
+// Lint checks that rule IDs are unique, conditions compile, and every
+// metric a condition reads is reported by the rule's middleware. Rules
+// without a middleware may read a metric reported by any middleware.
+func Lint(rules []Rule, catalog MetricCatalog) []LintIssue {
+	var issues []LintIssue
+	evaluator := NewEvaluator()
+	seen := make(map[string]bool)
+
+	for _, r := range rules {
+		if seen[r.ID] {
+			issues = append(issues, LintIssue{RuleID: r.ID, Message: "duplicate rule ID"})
+		}
+		seen[r.ID] = true
+
+		refs, err := evaluator.Compile(r.Condition.Expression)
+		if err != nil {
+			issues = append(issues, LintIssue{RuleID: r.ID, Message: err.Error()})
+			continue
+		}
+		metrics := refs.MetricNames()
+		if len(metrics) == 0 || catalog == nil {
+			continue
+		}
+
+		if r.MiddlewareType != "" {
+			entry, ok := catalog[r.MiddlewareType]
+			if !ok {
+				issues = append(issues, LintIssue{RuleID: r.ID,
+					Message: fmt.Sprintf("no metric catalog for middleware %q", r.MiddlewareType)})
+				continue
+			}
+			for _, m := range metrics {
+				if !entry.Has(m) {
+					issues = append(issues, LintIssue{RuleID: r.ID,
+						Message: fmt.Sprintf("unknown metric %q: not collected by the %s plugin", m, r.MiddlewareType)})
+				}
+			}
+			continue
+		}
+		for _, m := range metrics {
+			if !catalog.anyHas(m) {
+				issues = append(issues, LintIssue{RuleID: r.ID,
+					Message: fmt.Sprintf("unknown metric %q: not collected by any plugin", m)})
+			}
+		}
+	}
+	return issues
+}
+
+func (c MetricCatalog) anyHas(metric string) bool {
+	for _, e := range c {
+		if e.Has(metric) {
+			return true
+		}
+	}
+	return false
+}
+
+// FixtureResult is the outcome of evaluating every in-scope rule against
+// one fixture
+type FixtureResult struct {
+	Fixture string   `json:"fixture"`
+	File    string   `json:"file,omitempty"`
+	Matched []string `json:"matched"`
+	// Missing rules were expected to match but did not
+	Missing []string `json:"missing,omitempty"`
+	// Unexpected rules matched but were not expected
+	Unexpected []string `json:"unexpected,omitempty"`
+	// Errors maps rule IDs to evaluation errors
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Passed reports whether the fixture matched exactly its expected rules
+func (r *FixtureResult) Passed() bool {
+	return len(r.Missing) == 0 && len(r.Unexpected) == 0 && len(r.Errors) == 0
+}
+
+// Report is the outcome of a harness run
+type Report struct {
+	Fixtures []*FixtureResult `json:"fixtures"`
+	// Unexercised lists enabled rules that no fixture matched
+	Unexercised []string    `json:"unexercised,omitempty"`
+	Lint        []LintIssue `json:"lint,omitempty"`
+	Rules       int         `json:"rules"`
+}
+
+// Coverage is the fraction of enabled rules matched by at least one fixture
+func (r *Report) Coverage() float64 {
+	if r.Rules == 0 {
+		return 1
+	}
+	return float64(r.Rules-len(r.Unexercised)) / float64(r.Rules)
+}
+
+// Passed reports whether every fixture passed. In strict mode lint issues
+// and unexercised rules fail the run as well.
+func (r *Report) Passed(strict bool) bool {
+	for _, f := range r.Fixtures {
+		if !f.Passed() {
+			return false
+		}
+	}
+	return !strict || (len(r.Lint) == 0 && len(r.Unexercised) == 0)
+}
+
+// Harness runs rules against fixtures offline
+type Harness struct {
+	rules     []Rule
+	fixtures  []Fixture
+	catalog   MetricCatalog
+	evaluator *Evaluator
+}
+
+// NewHarness creates a harness; catalog may be nil to skip metric linting
+func NewHarness(rules []Rule, fixtures []Fixture, catalog MetricCatalog) *Harness {
+	return &Harness{rules: rules, fixtures: fixtures, catalog: catalog, evaluator: NewEvaluator()}
+}
+
+// Run evaluates every enabled rule against every fixture in its scope
+func (h *Harness) Run() *Report {
+	report := &Report{Lint: Lint(h.rules, h.catalog)}
+	exercised := make(map[string]bool)
+
+	for i := range h.fixtures {
+		f := &h.fixtures[i]
+		env := BuildEnv(f.EvalContext())
+		expected := make(map[string]bool)
+		for _, id := range f.Expect {
+			expected[id] = true
+		}
+
+		res := &FixtureResult{Fixture: f.Name, File: f.File, Matched: []string{}}
+		matched := make(map[string]bool)
+		for _, r := range h.rules {
+			if !r.IsEnabled() || !inScope(r, f) {
+				continue
+			}
+			ok, err := h.evaluator.Evaluate(r.Condition.Expression, env)
+			if err != nil {
+				if res.Errors == nil {
+					res.Errors = make(map[string]string)
+				}
+				res.Errors[r.ID] = err.Error()
+				continue
+			}
+			if !ok {
+				continue
+			}
+			matched[r.ID] = true
+			exercised[r.ID] = true
+			res.Matched = append(res.Matched, r.ID)
+			if !expected[r.ID] {
+				res.Unexpected = append(res.Unexpected, r.ID)
+			}
+		}
+		for _, id := range f.Expect {
+			if !matched[id] {
+				res.Missing = append(res.Missing, id)
+			}
+		}
+		report.Fixtures = append(report.Fixtures, res)
+	}
+
+	for _, r := range h.rules {
+		if !r.IsEnabled() {
+			continue
+		}
+		report.Rules++
+		if !exercised[r.ID] {
+			report.Unexercised = append(report.Unexercised, r.ID)
+		}
+	}
+	sort.Strings(report.Unexercised)
+	return report
+}
+
+func inScope(r Rule, f *Fixture) bool {
+	return r.MiddlewareType == "" || f.Middleware == "" || r.MiddlewareType == f.Middleware
+}
@@ -0,0 +1,80 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHarness_Run(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rules.yaml"), []byte(`
+- id: mem-high
+  middleware_type: redis
+  condition: memory_usage_ratio > 0.8
+- id: clients-high
+  middleware_type: redis
+  condition: connected_clients > 1000
+- id: typo
+  middleware_type: redis
+  condition: conected_clients > 1000
+- id: lag-high
+  middleware_type: kafka
+  condition: consumer_group_total_lag > 1000
+- id: rca-oom
+  condition: anomaly("HighMemory")
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fixtures.yaml"), []byte(`
+- name: memory
+  middleware: redis
+  metrics: {memory_usage_ratio: 0.9, connected_clients: 10}
+  anomalies: [{type: HighMemory}]
+  expect: [mem-high, rca-oom]
+- name: clients
+  middleware: redis
+  metrics: {memory_usage_ratio: 0.1, connected_clients: 5000}
+  expect: [clients-high, mem-high]
+`), 0o644))
+
+	sets, err := LoadPaths(filepath.Join(dir, "rules.yaml"))
+	require.NoError(t, err)
+	fixtures, err := LoadFixtures(filepath.Join(dir, "fixtures.yaml"))
+	require.NoError(t, err)
+	catalog := MetricCatalog{
+		"redis": {Metrics: []string{"memory_usage_ratio", "connected_clients"}},
+		"kafka": {Patterns: []string{"consumer_group_*"}},
+	}
+
+	report := NewHarness(sets[0].Rules, fixtures, catalog).Run()
+	require.Len(t, report.Fixtures, 2)
+	assert.True(t, report.Fixtures[0].Passed())
+	assert.Equal(t, []string{"mem-high", "rca-oom"}, report.Fixtures[0].Matched)
+
+	assert.False(t, report.Fixtures[1].Passed())
+	assert.Equal(t, []string{"mem-high"}, report.Fixtures[1].Missing)
+	assert.Empty(t, report.Fixtures[1].Unexpected)
+	assert.False(t, report.Passed(false))
+
+	assert.Equal(t, []string{"lag-high", "typo"}, report.Unexercised)
+	assert.Equal(t, 5, report.Rules)
+	assert.InDelta(t, 0.6, report.Coverage(), 1e-9)
+
+	require.Len(t, report.Lint, 1)
+	assert.Equal(t, "typo", report.Lint[0].RuleID)
+	assert.Contains(t, report.Lint[0].Message, `"conected_clients"`)
+}
+
+func TestLint_InvalidAndDuplicate(t *testing.T) {
+	issues := Lint([]Rule{
+		{ID: "a", Condition: Condition{Expression: "x >"}},
+		{ID: "b", MiddlewareType: "mongodb", Condition: Condition{Expression: "opcounters > 1"}},
+		{ID: "b", Condition: Condition{Expression: "true"}},
+	}, MetricCatalog{})
+	require.Len(t, issues, 3)
+	assert.Equal(t, "a", issues[0].RuleID)
+	assert.Contains(t, issues[1].Message, "no metric catalog")
+	assert.Equal(t, "duplicate rule ID", issues[2].Message)
+}
@@ -2,37 +2,100 @@ package rules
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 // RuleSet defines a collection of rules
 type RuleSet struct {
-	Name        string
-	Version     string
-	Description string
-	Rules       []Rule
+	Name        string `json:"name" yaml:"name"`
+	Version     string `json:"version" yaml:"version"`
+	Description string `json:"description" yaml:"description"`
+	// Middleware applies to every rule in the set that does not name its own
+	Middleware string `json:"middleware,omitempty" yaml:"middleware,omitempty"`
+	Rules      []Rule `json:"rules" yaml:"rules"`
 }
 
-// Rule defines a single rule structure (internal representation)
-// Note: This maps to DiagnosisRule in interface.go, but for loading from file we might have slightly different structure or use the same.
-// Let's reuse basic structure but keep it decoupled if needed.
+// Rule is the rule schema shared by the diagnosis engine, the knowledge base
+// and root cause analysis. Every rule is a boolean expr condition evaluated
+// against the environment built by BuildEnv; the remaining fields describe
+// what a match means to each consumer.
 type Rule struct {
-	ID          string   `json:"id" yaml:"id"`
-	Name        string   `json:"name" yaml:"name"`
-	Description string   `json:"description" yaml:"description"`
-	Severity    string   `json:"severity" yaml:"severity"`
-	Condition   Condition `json:"condition" yaml:"condition"`
-	Message     string   `json:"message" yaml:"message"`
-	Suggestion  string   `json:"suggestion" yaml:"suggestion"`
-	Tags        []string `json:"tags" yaml:"tags"`
-	Enabled     bool     `json:"enabled" yaml:"enabled"`
-}
-
-// Condition defines the logic condition
+	ID          string `json:"id" yaml:"id"`
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// MiddlewareType scopes the rule to one middleware; empty applies to all
+	MiddlewareType string    `json:"middleware_type,omitempty" yaml:"middleware_type,omitempty"`
+	Category       string    `json:"category,omitempty" yaml:"category,omitempty"`
+	Severity       string    `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Condition      Condition `json:"condition" yaml:"condition"`
+	Message        string    `json:"message,omitempty" yaml:"message,omitempty"`
+	Recommendation string    `json:"recommendation,omitempty" yaml:"recommendation,omitempty"`
+	// RootCause and Actions are reported by root cause analysis
+	RootCause string   `json:"root_cause,omitempty" yaml:"root_cause,omitempty"`
+	Actions   []string `json:"actions,omitempty" yaml:"actions,omitempty"`
+	Priority  int      `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Tags      []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Version   string   `json:"version,omitempty" yaml:"version,omitempty"`
+	// Enabled defaults to true when omitted
+	Enabled *bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+}
+
+// IsEnabled reports whether the rule should be evaluated
+func (r *Rule) IsEnabled() bool {
+	return r.Enabled == nil || *r.Enabled
+}
+
+// Condition defines the logic condition. In files it is written either as a
+// plain expression string or as an object with an expression field.
 type Condition struct {
 	Expression string `json:"expression" yaml:"expression"`
 }
 
+// UnmarshalJSON accepts a string or {"expression": "..."}
+func (c *Condition) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		c.Expression = s
+		return nil
+	}
+	var obj struct {
+		Expression string `json:"expression"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("condition must be a string or an object with an expression: %w", err)
+	}
+	c.Expression = obj.Expression
+	return nil
+}
+
+// UnmarshalYAML accepts a string or {expression: ...}. It uses the
+// function form so that both yaml.v2 and yaml.v3 decode conditions.
+func (c *Condition) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		c.Expression = s
+		return nil
+	}
+	var obj struct {
+		Expression string `yaml:"expression"`
+	}
+	if err := unmarshal(&obj); err != nil {
+		return fmt.Errorf("condition must be a string or a mapping with an expression: %w", err)
+	}
+	c.Expression = obj.Expression
+	return nil
+}
+
+// MarshalYAML writes the condition as a plain expression string
+func (c Condition) MarshalYAML() (interface{}, error) {
+	return c.Expression, nil
+}
+
 // RuleLoader interface
 type RuleLoader interface {
 	Load(path string) (*RuleSet, error)
@@ -55,5 +118,92 @@ func (l *JSONRuleLoader) LoadFromJSON(data []byte) (*RuleSet, error) {
 	if err := json.Unmarshal(data, &ruleSet); err != nil {
 		return nil, err
 	}
+	ruleSet.applyDefaults()
 	return &ruleSet, nil
 }
+
+// LoadFile loads rules from a JSON or YAML file. The file holds either a
+// rule set ({name, rules: [...]}) or a bare list of rules.
+func LoadFile(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ruleSet RuleSet
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := unmarshalRuleSet(data, json.Unmarshal, &ruleSet); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case ".yaml", ".yml":
+		if err := unmarshalRuleSet(data, yaml.Unmarshal, &ruleSet); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported rule file %s", path)
+	}
+	if ruleSet.Name == "" {
+		ruleSet.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	ruleSet.applyDefaults()
+	return &ruleSet, nil
+}
+
+// LoadPaths loads every rule file under the given files or directories
+func LoadPaths(paths ...string) ([]*RuleSet, error) {
+	var sets []*RuleSet
+	for _, p := range paths {
+		files, err := ruleFiles(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			rs, err := LoadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			sets = append(sets, rs)
+		}
+	}
+	return sets, nil
+}
+
+func unmarshalRuleSet(data []byte, unmarshal func([]byte, interface{}) error, rs *RuleSet) error {
+	if err := unmarshal(data, rs); err == nil {
+		return nil
+	}
+	return unmarshal(data, &rs.Rules)
+}
+
+func (rs *RuleSet) applyDefaults() {
+	for i := range rs.Rules {
+		if rs.Rules[i].MiddlewareType == "" {
+			rs.Rules[i].MiddlewareType = rs.Middleware
+		}
+	}
+}
+
+func ruleFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".json", ".yaml", ".yml":
+			if !e.IsDir() {
+				files = append(files, filepath.Join(path, e.Name()))
+			}
+		}
+	}
+	return files, nil
+}
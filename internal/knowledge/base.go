@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/kubestack-ai/kubestack-ai/internal/diagnosis/rules"
 )
 
 // Rule represents a diagnostic rule in the knowledge base.
@@ -109,6 +110,38 @@ func (kb *KnowledgeBase) DeleteRule(id string) error {
 	return nil
 }
 
+// ApplyRules stores the upserted rules as given, replacing rules with the
+// same ID, and removes the deleted IDs as a single change: when a rule is
+// invalid or an ID is unknown the knowledge base is left untouched.
+func (kb *KnowledgeBase) ApplyRules(upserts []*Rule, deletes []string) error {
+	kb.mu.Lock()
+	defer kb.mu.Unlock()
+
+	staged := make(map[string]*Rule, len(kb.rules)+len(upserts))
+	for id, rule := range kb.rules {
+		staged[id] = rule
+	}
+	for _, rule := range upserts {
+		if rule.ID == "" {
+			return fmt.Errorf("rule %q has no ID", rule.Name)
+		}
+		if err := kb.validateRule(rule); err != nil {
+			return fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+		staged[rule.ID] = rule
+	}
+	for _, id := range deletes {
+		if _, exists := staged[id]; !exists {
+			return fmt.Errorf("rule with ID %s not found", id)
+		}
+		delete(staged, id)
+	}
+
+	kb.rules = staged
+	kb.rebuildIndexes()
+	return nil
+}
+
 // GetRule retrieves a rule by ID.
 func (kb *KnowledgeBase) GetRule(id string) (*Rule, error) {
 	kb.mu.RLock()
@@ -183,6 +216,9 @@ func (kb *KnowledgeBase) validateRule(rule *Rule) error {
 	if rule.Condition == "" {
 		return fmt.Errorf("condition is required")
 	}
+	if _, err := rules.ParseReferences(rule.Condition); err != nil {
+		return fmt.Errorf("invalid condition: %w", err)
+	}
 	return nil
 }
 
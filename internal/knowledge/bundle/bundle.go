@@ -0,0 +1,257 @@
+// Package bundle packages knowledge base rules into versioned, optionally
+// signed bundles that teams can share, and installs them with a three-way
+// merge against local edits.
+package bundle
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/kubestack-ai/kubestack-ai/internal/knowledge"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// ErrChecksumMismatch means the rules do not match the manifest checksum
+	ErrChecksumMismatch = errors.New("bundle checksum mismatch")
+
+	// ErrUntrusted means the bundle is unsigned or not signed by a trusted key
+	ErrUntrusted = errors.New("bundle is not signed by a trusted key")
+)
+
+// Manifest describes a bundle and its provenance.
+type Manifest struct {
+	// Name identifies the rule pack, e.g. "redis-core"
+	Name string `json:"name" yaml:"name"`
+
+	// Version is a semantic version; installs move forward only
+	Version string `json:"version" yaml:"version"`
+
+	Author      string    `json:"author" yaml:"author"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at" yaml:"created_at"`
+
+	// Checksum is the hex SHA-256 of the canonical rules
+	Checksum string `json:"checksum" yaml:"checksum"`
+
+	// Signature is the base64 ed25519 signature of the manifest digest
+	Signature string `json:"signature,omitempty" yaml:"signature,omitempty"`
+
+	// PublicKey is the base64 key that made the signature
+	PublicKey string `json:"public_key,omitempty" yaml:"public_key,omitempty"`
+}
+
+// Bundle is a manifest and the rules it ships.
+type Bundle struct {
+	Manifest Manifest         `json:"manifest" yaml:"manifest"`
+	Rules    []knowledge.Rule `json:"rules" yaml:"rules"`
+}
+
+// New creates a bundle for rules and computes its checksum.
+func New(m Manifest, rules []knowledge.Rule) (*Bundle, error) {
+	if m.Name == "" {
+		return nil, fmt.Errorf("bundle name is required")
+	}
+	if _, err := semver.StrictNewVersion(m.Version); err != nil {
+		return nil, fmt.Errorf("bundle version %q is not a semantic version: %w", m.Version, err)
+	}
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now().UTC()
+	}
+	m.CreatedAt = m.CreatedAt.UTC().Truncate(time.Second)
+
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		if r.ID == "" {
+			return nil, fmt.Errorf("every rule in a bundle needs an ID")
+		}
+		if seen[r.ID] {
+			return nil, fmt.Errorf("duplicate rule ID %s", r.ID)
+		}
+		seen[r.ID] = true
+	}
+
+	b := &Bundle{Manifest: m, Rules: canonicalRules(rules)}
+	b.Manifest.Checksum = checksum(b.Rules)
+	b.Manifest.Signature = ""
+	b.Manifest.PublicKey = ""
+	return b, nil
+}
+
+// Load reads a bundle from a YAML file.
+func Load(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes a bundle from YAML (JSON is valid YAML).
+func Parse(data []byte) (*Bundle, error) {
+	var b Bundle
+	if err := yaml.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	if b.Manifest.Name == "" || b.Manifest.Version == "" {
+		return nil, fmt.Errorf("bundle manifest needs a name and version")
+	}
+	return &b, nil
+}
+
+// Save writes the bundle as YAML.
+func (b *Bundle) Save(path string) error {
+	data, err := yaml.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Sign signs the manifest with key.
+func (b *Bundle) Sign(key ed25519.PrivateKey) {
+	b.Manifest.PublicKey = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	b.Manifest.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, b.Manifest.digest()))
+}
+
+// Verify checks the checksum and, when trusted keys are given, that one of
+// them signed the manifest. Without trusted keys a signature is still
+// checked against the embedded key, which proves integrity but not origin.
+func (b *Bundle) Verify(trusted []ed25519.PublicKey) error {
+	if checksum(canonicalRules(b.Rules)) != b.Manifest.Checksum {
+		return ErrChecksumMismatch
+	}
+	if _, err := semver.StrictNewVersion(b.Manifest.Version); err != nil {
+		return fmt.Errorf("bundle version %q is not a semantic version: %w", b.Manifest.Version, err)
+	}
+
+	if b.Manifest.Signature == "" {
+		if len(trusted) > 0 {
+			return ErrUntrusted
+		}
+		return nil
+	}
+	sig, err := base64.StdEncoding.DecodeString(b.Manifest.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid bundle signature: %w", err)
+	}
+
+	if len(trusted) == 0 {
+		key, err := ParsePublicKey(b.Manifest.PublicKey)
+		if err != nil {
+			return err
+		}
+		if !ed25519.Verify(key, b.Manifest.digest(), sig) {
+			return fmt.Errorf("invalid bundle signature")
+		}
+		return nil
+	}
+	for _, key := range trusted {
+		if ed25519.Verify(key, b.Manifest.digest(), sig) {
+			return nil
+		}
+	}
+	return ErrUntrusted
+}
+
+// Signed reports whether the bundle carries a signature.
+func (b *Bundle) Signed() bool {
+	return b.Manifest.Signature != ""
+}
+
+// digest is what the signature covers: every manifest field but the
+// signature itself, including the rules checksum.
+func (m Manifest) digest() []byte {
+	c := m
+	c.Signature = ""
+	c.PublicKey = ""
+	c.CreatedAt = c.CreatedAt.UTC()
+	data, _ := json.Marshal(c)
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+// canonicalRules sorts rules by ID and clears the timestamps the knowledge
+// base sets on load, so that the checksum only covers rule content.
+func canonicalRules(rules []knowledge.Rule) []knowledge.Rule {
+	out := make([]knowledge.Rule, len(rules))
+	for i, r := range rules {
+		r.CreatedAt = time.Time{}
+		r.UpdatedAt = time.Time{}
+		if len(r.Tags) == 0 {
+			r.Tags = nil
+		}
+		out[i] = r
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func checksum(rules []knowledge.Rule) string {
+	data, _ := json.Marshal(rules)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateKey creates an ed25519 key pair encoded as base64.
+func GenerateKey() (publicKey, privateKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(priv), nil
+}
+
+// ParsePublicKey decodes a base64 ed25519 public key.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key")
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// ParsePrivateKey decodes a base64 ed25519 private key.
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(raw) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid ed25519 private key")
+	}
+	return ed25519.PrivateKey(raw), nil
+}
+
+// ParsePublicKeys decodes a list of base64 ed25519 public keys.
+func ParsePublicKeys(keys []string) ([]ed25519.PublicKey, error) {
+	var out []ed25519.PublicKey
+	for _, k := range keys {
+		key, err := ParsePublicKey(k)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, key)
+	}
+	return out, nil
+}
+
+// newer reports whether version a is greater than b
+func newer(a, b string) (bool, error) {
+	va, err := semver.NewVersion(a)
+	if err != nil {
+		return false, err
+	}
+	vb, err := semver.NewVersion(b)
+	if err != nil {
+		return false, err
+	}
+	return va.GreaterThan(vb), nil
+}
@@ -0,0 +1,76 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/knowledge"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRules() []knowledge.Rule {
+	return []knowledge.Rule{
+		{ID: "redis-mem-001", Name: "High Memory", MiddlewareType: "redis", Severity: "high",
+			Condition: "memory_usage_ratio > 0.8", Recommendation: "Enable eviction", Priority: 10},
+		{ID: "redis-conn-001", Name: "Too Many Clients", MiddlewareType: "redis", Severity: "medium",
+			Condition: "connected_clients > 900", Recommendation: "Use a connection pool", Priority: 5},
+	}
+}
+
+func TestBundle_SaveLoadVerify(t *testing.T) {
+	b, err := New(Manifest{Name: "redis-core", Version: "1.0.0", Author: "sre"}, testRules())
+	require.NoError(t, err)
+	assert.Equal(t, "redis-conn-001", b.Rules[0].ID, "rules are stored sorted by ID")
+
+	path := filepath.Join(t.TempDir(), "redis-core.yaml")
+	require.NoError(t, b.Save(path))
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, b.Manifest.Checksum, loaded.Manifest.Checksum)
+	assert.NoError(t, loaded.Verify(nil))
+
+	loaded.Rules[0].Condition = "connected_clients > 1"
+	assert.ErrorIs(t, loaded.Verify(nil), ErrChecksumMismatch)
+}
+
+func TestBundle_New_Validation(t *testing.T) {
+	_, err := New(Manifest{Name: "redis-core", Version: "v1"}, testRules())
+	assert.Error(t, err)
+
+	dup := append(testRules(), testRules()[0])
+	_, err = New(Manifest{Name: "redis-core", Version: "1.0.0"}, dup)
+	assert.Error(t, err)
+}
+
+func TestBundle_Signature(t *testing.T) {
+	pubStr, privStr, err := GenerateKey()
+	require.NoError(t, err)
+	pub, err := ParsePublicKey(pubStr)
+	require.NoError(t, err)
+	priv, err := ParsePrivateKey(privStr)
+	require.NoError(t, err)
+	other, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	b, err := New(Manifest{Name: "redis-core", Version: "1.0.0"}, testRules())
+	require.NoError(t, err)
+	assert.ErrorIs(t, b.Verify([]ed25519.PublicKey{pub}), ErrUntrusted, "unsigned bundle with trusted keys set")
+
+	b.Sign(priv)
+	path := filepath.Join(t.TempDir(), "signed.yaml")
+	require.NoError(t, b.Save(path))
+	b, err = Load(path)
+	require.NoError(t, err)
+
+	assert.True(t, b.Signed())
+	assert.NoError(t, b.Verify(nil))
+	assert.NoError(t, b.Verify([]ed25519.PublicKey{other, pub}))
+	assert.ErrorIs(t, b.Verify([]ed25519.PublicKey{other}), ErrUntrusted)
+
+	// The signature covers the manifest, so a bumped version is rejected
+	b.Manifest.Version = "9.9.9"
+	assert.ErrorIs(t, b.Verify([]ed25519.PublicKey{pub}), ErrUntrusted)
+	assert.Error(t, b.Verify(nil))
+}
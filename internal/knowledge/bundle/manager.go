@@ -0,0 +1,266 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/knowledge"
+)
+
+var (
+	// ErrConflict means local edits conflict with the bundle
+	ErrConflict = errors.New("bundle conflicts with local rule edits")
+
+	// ErrNotNewer means the bundle does not advance the installed version
+	ErrNotNewer = errors.New("bundle is not newer than the installed version")
+
+	// ErrNoPrevious means there is no earlier version to roll back to
+	ErrNoPrevious = errors.New("no previous bundle version to roll back to")
+)
+
+// Manager installs bundles into a knowledge base and rolls them back.
+type Manager struct {
+	kb      *knowledge.KnowledgeBase
+	loader  *knowledge.RuleLoader
+	store   *Store
+	trusted []ed25519.PublicKey
+	log     logger.Logger
+}
+
+// NewManager creates a Manager. Changes are persisted through loader when
+// it is not nil. When trusted keys are given, only bundles signed by one of
+// them can be installed.
+func NewManager(kb *knowledge.KnowledgeBase, loader *knowledge.RuleLoader, store *Store, trusted []ed25519.PublicKey) *Manager {
+	return &Manager{
+		kb:      kb,
+		loader:  loader,
+		store:   store,
+		trusted: trusted,
+		log:     logger.NewLogger("kb-bundles"),
+	}
+}
+
+// DefaultDir is where installed bundle versions are kept unless configured.
+const DefaultDir = "data/knowledge/bundles"
+
+// NewManagerFromConfig opens the bundle store and trusted keys from cfg.
+// New rules from bundles are saved under cfg.RulesDir when it is set.
+func NewManagerFromConfig(cfg config.BundleConfig, kb *knowledge.KnowledgeBase, loader *knowledge.RuleLoader) (*Manager, error) {
+	dir := cfg.Dir
+	if dir == "" {
+		dir = DefaultDir
+	}
+	store, err := NewStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	trusted, err := ParsePublicKeys(cfg.TrustedKeys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted bundle key: %w", err)
+	}
+	if loader != nil && cfg.RulesDir != "" {
+		loader.SetRepoDir(cfg.RulesDir)
+	}
+	return NewManager(kb, loader, store, trusted), nil
+}
+
+// Plan verifies b and previews installing it without changing anything.
+func (m *Manager) Plan(b *Bundle, strategy Strategy) (*Plan, error) {
+	if err := b.Verify(m.trusted); err != nil {
+		return nil, err
+	}
+	current, err := m.store.Current(b.Manifest.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var base []knowledge.Rule
+	from := ""
+	if current != nil {
+		ok, err := newer(b.Manifest.Version, current.Manifest.Version)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: %s %s, installed %s", ErrNotNewer,
+				b.Manifest.Name, b.Manifest.Version, current.Manifest.Version)
+		}
+		base = current.Rules
+		from = current.Manifest.Version
+	}
+
+	plan, err := m.merge(base, b.Rules, strategy)
+	if err != nil {
+		return nil, err
+	}
+	plan.Bundle = b.Manifest
+	plan.From = from
+	return plan, nil
+}
+
+// Install merges b into the knowledge base and records it as installed.
+// With StrategyFail nothing changes while any rule conflicts; the returned
+// plan lists the conflicts.
+func (m *Manager) Install(b *Bundle, strategy Strategy) (*Plan, error) {
+	plan, err := m.Plan(b, strategy)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Blocked() {
+		return plan, ErrConflict
+	}
+	if err := m.apply(plan); err != nil {
+		return plan, err
+	}
+	if err := m.store.Record(b); err != nil {
+		return plan, fmt.Errorf("rules applied but install not recorded: %w", err)
+	}
+	m.log.Infof("Installed rule bundle %s %s (%d changes, %d conflicts)",
+		b.Manifest.Name, b.Manifest.Version, len(plan.Changes), len(plan.Conflicts))
+	return plan, nil
+}
+
+// Rollback reinstalls the version of a bundle installed before the current
+// one, keeping local edits made since where they do not conflict.
+func (m *Manager) Rollback(name string, strategy Strategy) (*Plan, error) {
+	current, err := m.store.Current(name)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, fmt.Errorf("bundle %s is not installed", name)
+	}
+	previous, err := m.store.Previous(name)
+	if err != nil {
+		return nil, err
+	}
+	if previous == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoPrevious, name, current.Manifest.Version)
+	}
+
+	plan, err := m.merge(current.Rules, previous.Rules, strategy)
+	if err != nil {
+		return nil, err
+	}
+	plan.Bundle = previous.Manifest
+	plan.From = current.Manifest.Version
+	if plan.Blocked() {
+		return plan, ErrConflict
+	}
+	if err := m.apply(plan); err != nil {
+		return plan, err
+	}
+	if err := m.store.Pop(name); err != nil {
+		return plan, fmt.Errorf("rules rolled back but history not updated: %w", err)
+	}
+	m.log.Infof("Rolled back rule bundle %s from %s to %s", name, current.Manifest.Version, previous.Manifest.Version)
+	return plan, nil
+}
+
+// Installed lists installed bundles.
+func (m *Manager) Installed() ([]Installed, error) {
+	return m.store.List()
+}
+
+func (m *Manager) merge(base, upstream []knowledge.Rule, strategy Strategy) (*Plan, error) {
+	all, err := m.kb.GetAllRules()
+	if err != nil {
+		return nil, err
+	}
+	local := make([]knowledge.Rule, 0, len(all))
+	for _, r := range all {
+		local = append(local, *r)
+	}
+	return Merge(base, local, upstream, strategy), nil
+}
+
+// apply makes the plan's changes in the knowledge base as one swap, then on
+// disk. When a rule cannot be saved the previous rules are put back in both.
+func (m *Manager) apply(plan *Plan) error {
+	var upserts []*knowledge.Rule
+	var deletes []string
+	// previous holds the rules the plan touches as they are now, nil for
+	// rules it adds
+	previous := make(map[string]*knowledge.Rule)
+	now := time.Now()
+	for _, c := range plan.Changes {
+		switch c.Action {
+		case ActionAdd, ActionUpdate:
+			r := *c.Rule
+			previous[r.ID] = m.snapshot(r.ID)
+			if prev := previous[r.ID]; prev != nil {
+				r.CreatedAt = prev.CreatedAt
+			} else if r.CreatedAt.IsZero() {
+				r.CreatedAt = now
+			}
+			r.UpdatedAt = now
+			upserts = append(upserts, &r)
+		case ActionDelete:
+			previous[c.RuleID] = m.snapshot(c.RuleID)
+			deletes = append(deletes, c.RuleID)
+		}
+	}
+
+	if err := m.kb.ApplyRules(upserts, deletes); err != nil {
+		return fmt.Errorf("failed to apply rules: %w", err)
+	}
+	if m.loader == nil {
+		return nil
+	}
+
+	for i, r := range upserts {
+		if err := m.loader.SaveRule(r); err != nil {
+			m.restore(previous, upserts[:i])
+			return fmt.Errorf("failed to save rule %s: %w", r.ID, err)
+		}
+	}
+	for _, id := range deletes {
+		if err := m.loader.DeleteRule(id); err != nil {
+			m.log.Warnf("Rule %s removed from the knowledge base but not from disk: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// snapshot returns a copy of the rule with id, or nil if there is none
+func (m *Manager) snapshot(id string) *knowledge.Rule {
+	r, err := m.kb.GetRule(id)
+	if err != nil {
+		return nil
+	}
+	cp := *r
+	return &cp
+}
+
+// restore puts the rules an apply touched back as they were before it,
+// rewriting the files of the rules it had already saved
+func (m *Manager) restore(previous map[string]*knowledge.Rule, saved []*knowledge.Rule) {
+	var upserts []*knowledge.Rule
+	var deletes []string
+	for id, r := range previous {
+		if r != nil {
+			upserts = append(upserts, r)
+		} else {
+			deletes = append(deletes, id)
+		}
+	}
+	if err := m.kb.ApplyRules(upserts, deletes); err != nil {
+		m.log.Errorf("Failed to restore rules after a failed bundle apply: %v", err)
+	}
+
+	for _, r := range saved {
+		var err error
+		if prev := previous[r.ID]; prev != nil {
+			err = m.loader.SaveRule(prev)
+		} else {
+			err = m.loader.DeleteRule(r.ID)
+		}
+		if err != nil {
+			m.log.Warnf("Rule %s restored in the knowledge base but not on disk: %v", r.ID, err)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/knowledge"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ruleByID(rules []knowledge.Rule, id string) knowledge.Rule {
+	for _, r := range rules {
+		if r.ID == id {
+			return r
+		}
+	}
+	return knowledge.Rule{}
+}
+
+func TestMerge(t *testing.T) {
+	base := testRules()
+	local := testRules()
+	upstream := testRules()
+
+	// Local edit to a rule upstream left alone is kept
+	local[0].Condition = "memory_usage_ratio > 0.9"
+	// Upstream change to a rule not edited locally is applied
+	upstream[1].Priority = 7
+	// New upstream rule is added
+	upstream = append(upstream, knowledge.Rule{ID: "redis-slow-001", Name: "Slow Log", MiddlewareType: "redis", Condition: "len(slowlogs) > 100"})
+
+	plan := Merge(base, local, upstream, StrategyFail)
+	assert.False(t, plan.Blocked())
+	require.Len(t, plan.Changes, 3)
+	assert.Equal(t, Change{RuleID: "redis-conn-001", Action: ActionUpdate, Rule: &upstream[1], Fields: []string{"priority"}}, plan.Changes[0])
+	assert.Equal(t, ActionKeep, plan.Changes[1].Action)
+	assert.Equal(t, []string{"condition"}, plan.Changes[1].Fields)
+	assert.Equal(t, ActionAdd, plan.Changes[2].Action)
+
+	// Editing the same rule on both sides conflicts
+	upstream[0].Condition = "memory_usage_ratio > 0.85"
+	plan = Merge(base, local, upstream, StrategyFail)
+	assert.True(t, plan.Blocked())
+	require.Len(t, plan.Conflicts, 1)
+	assert.Equal(t, "redis-mem-001", plan.Conflicts[0].RuleID)
+
+	plan = Merge(base, local, upstream, StrategyTheirs)
+	assert.False(t, plan.Blocked())
+	assert.Equal(t, ActionUpdate, plan.Conflicts[0].Resolution)
+
+	plan = Merge(base, local, upstream, StrategyOurs)
+	assert.Equal(t, ActionKeep, plan.Conflicts[0].Resolution)
+	for _, c := range plan.Changes {
+		assert.NotEqual(t, "redis-mem-001", c.RuleID)
+	}
+}
+
+func TestMerge_Deletes(t *testing.T) {
+	base := testRules()
+	local := testRules()[:1]
+	upstream := testRules()[1:]
+
+	// redis-mem-001 removed upstream and untouched locally: deleted.
+	// redis-conn-001 deleted locally and unchanged upstream: stays deleted.
+	plan := Merge(base, local, upstream, StrategyFail)
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, Change{RuleID: "redis-mem-001", Action: ActionDelete, Rule: &local[0]}, plan.Changes[0])
+	assert.Empty(t, plan.Conflicts)
+
+	local[0].Priority = 99
+	plan = Merge(base, local, upstream, StrategyFail)
+	require.Len(t, plan.Conflicts, 1)
+	assert.Equal(t, "edited locally, removed upstream", plan.Conflicts[0].Reason)
+}
+
+func TestManager_InstallAndRollback(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	kb := knowledge.NewKnowledgeBase()
+	mgr := NewManager(kb, nil, store, nil)
+
+	v1, err := New(Manifest{Name: "redis-core", Version: "1.0.0"}, testRules())
+	require.NoError(t, err)
+	_, err = mgr.Install(v1, StrategyFail)
+	require.NoError(t, err)
+	all, _ := kb.GetAllRules()
+	assert.Len(t, all, 2)
+
+	_, err = mgr.Install(v1, StrategyFail)
+	assert.ErrorIs(t, err, ErrNotNewer)
+
+	// A local edit survives an upgrade that does not touch the rule
+	r, err := kb.GetRule("redis-mem-001")
+	require.NoError(t, err)
+	edited := *r
+	edited.Recommendation = "Raise maxmemory"
+	require.NoError(t, kb.UpdateRule(&edited))
+
+	rules := testRules()
+	rules[1].Priority = 7
+	rules = append(rules, knowledge.Rule{ID: "redis-slow-001", Name: "Slow Log", MiddlewareType: "redis", Condition: "len(slowlogs) > 100"})
+	v2, err := New(Manifest{Name: "redis-core", Version: "1.1.0"}, rules)
+	require.NoError(t, err)
+	_, err = mgr.Install(v2, StrategyFail)
+	require.NoError(t, err)
+
+	r, _ = kb.GetRule("redis-mem-001")
+	assert.Equal(t, "Raise maxmemory", r.Recommendation)
+	r, _ = kb.GetRule("redis-conn-001")
+	assert.Equal(t, 7, r.Priority)
+	_, err = kb.GetRule("redis-slow-001")
+	assert.NoError(t, err)
+
+	installed, err := mgr.Installed()
+	require.NoError(t, err)
+	require.Len(t, installed, 1)
+	assert.Equal(t, "1.1.0", installed[0].Current.Version)
+	assert.Equal(t, []string{"1.0.0", "1.1.0"}, installed[0].Versions)
+
+	// Rolling back restores 1.0.0 and still keeps the local edit
+	plan, err := mgr.Rollback("redis-core", StrategyFail)
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", plan.Bundle.Version)
+	assert.Equal(t, "1.1.0", plan.From)
+
+	r, _ = kb.GetRule("redis-conn-001")
+	assert.Equal(t, ruleByID(testRules(), "redis-conn-001").Priority, r.Priority)
+	r, _ = kb.GetRule("redis-mem-001")
+	assert.Equal(t, "Raise maxmemory", r.Recommendation)
+	_, err = kb.GetRule("redis-slow-001")
+	assert.Error(t, err)
+
+	_, err = mgr.Rollback("redis-core", StrategyFail)
+	assert.ErrorIs(t, err, ErrNoPrevious)
+}
+
+func TestManager_InstallConflict(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	kb := knowledge.NewKnowledgeBase()
+	mgr := NewManager(kb, nil, store, nil)
+
+	v1, err := New(Manifest{Name: "redis-core", Version: "1.0.0"}, testRules())
+	require.NoError(t, err)
+	_, err = mgr.Install(v1, StrategyFail)
+	require.NoError(t, err)
+
+	r, _ := kb.GetRule("redis-mem-001")
+	edited := *r
+	edited.Condition = "memory_usage_ratio > 0.95"
+	require.NoError(t, kb.UpdateRule(&edited))
+
+	rules := testRules()
+	rules[0].Condition = "memory_usage_ratio > 0.85"
+	v2, err := New(Manifest{Name: "redis-core", Version: "2.0.0"}, rules)
+	require.NoError(t, err)
+
+	plan, err := mgr.Install(v2, StrategyFail)
+	assert.ErrorIs(t, err, ErrConflict)
+	require.Len(t, plan.Conflicts, 1)
+	r, _ = kb.GetRule("redis-mem-001")
+	assert.Equal(t, "memory_usage_ratio > 0.95", r.Condition, "nothing applied on conflict")
+	current, err := store.Current("redis-core")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", current.Manifest.Version)
+
+	_, err = mgr.Install(v2, StrategyTheirs)
+	require.NoError(t, err)
+	r, _ = kb.GetRule("redis-mem-001")
+	assert.Equal(t, "memory_usage_ratio > 0.85", r.Condition)
+}
+
+func TestManager_InstallRestoresRulesWhenSaveFails(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	kb := knowledge.NewKnowledgeBase()
+	loader := knowledge.NewRuleLoader(kb)
+	rulesDir := t.TempDir()
+	loader.SetRepoDir(rulesDir)
+	mgr := NewManager(kb, loader, store, nil)
+
+	v1, err := New(Manifest{Name: "core", Version: "1.0.0"}, testRules())
+	require.NoError(t, err)
+	_, err = mgr.Install(v1, StrategyFail)
+	require.NoError(t, err)
+	saved, err := os.ReadFile(filepath.Join(rulesDir, "redis_rules.yaml"))
+	require.NoError(t, err)
+
+	// The redis rule is saved before the new mysql rule fails to
+	require.NoError(t, os.Mkdir(filepath.Join(rulesDir, "mysql_rules.yaml"), 0755))
+	rules := testRules()
+	rules[1].Priority = 7
+	rules = append(rules, knowledge.Rule{ID: "mysql-conn-001", Name: "Too Many Connections", MiddlewareType: "mysql", Condition: "threads_connected > 500"})
+	v2, err := New(Manifest{Name: "core", Version: "1.1.0"}, rules)
+	require.NoError(t, err)
+	_, err = mgr.Install(v2, StrategyFail)
+	require.Error(t, err)
+
+	r, err := kb.GetRule("redis-conn-001")
+	require.NoError(t, err)
+	assert.Equal(t, 5, r.Priority)
+	_, err = kb.GetRule("mysql-conn-001")
+	assert.Error(t, err)
+	all, _ := kb.GetAllRules()
+	assert.Len(t, all, 2)
+
+	restored, err := os.ReadFile(filepath.Join(rulesDir, "redis_rules.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, string(saved), string(restored))
+	current, err := store.Current("core")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", current.Manifest.Version)
+}
@@ -0,0 +1,198 @@
+package bundle
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/knowledge"
+)
+
+// Strategy decides how conflicting rules are resolved.
+type Strategy string
+
+const (
+	// StrategyFail applies nothing while any rule conflicts
+	StrategyFail Strategy = "fail"
+
+	// StrategyOurs keeps the local version of conflicting rules
+	StrategyOurs Strategy = "ours"
+
+	// StrategyTheirs takes the upstream version of conflicting rules
+	StrategyTheirs Strategy = "theirs"
+)
+
+// ParseStrategy parses a strategy name; empty means StrategyFail.
+func ParseStrategy(s string) (Strategy, error) {
+	switch Strategy(s) {
+	case "", StrategyFail:
+		return StrategyFail, nil
+	case StrategyOurs, StrategyTheirs:
+		return Strategy(s), nil
+	}
+	return "", fmt.Errorf("unknown merge strategy %q (fail, ours, theirs)", s)
+}
+
+// Action is what installing a bundle does to one rule.
+type Action string
+
+const (
+	ActionAdd    Action = "add"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	// ActionKeep keeps a local edit that upstream did not touch
+	ActionKeep Action = "keep"
+)
+
+// Change is one rule change an install applies.
+type Change struct {
+	RuleID string          `json:"rule_id"`
+	Action Action          `json:"action"`
+	Rule   *knowledge.Rule `json:"rule,omitempty"`
+	// Fields lists the fields that differ from the local rule
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Conflict is a rule both edited locally and changed upstream.
+type Conflict struct {
+	RuleID   string          `json:"rule_id"`
+	Reason   string          `json:"reason"`
+	Local    *knowledge.Rule `json:"local,omitempty"`
+	Upstream *knowledge.Rule `json:"upstream,omitempty"`
+	// Resolution is the action taken under the strategy, if any
+	Resolution Action `json:"resolution,omitempty"`
+}
+
+// Plan is the result of merging a bundle into the local rules.
+type Plan struct {
+	Bundle    Manifest   `json:"bundle"`
+	From      string     `json:"from,omitempty"`
+	Changes   []Change   `json:"changes"`
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+	Strategy  Strategy   `json:"strategy"`
+}
+
+// Blocked reports whether conflicts prevent applying the plan.
+func (p *Plan) Blocked() bool {
+	return p.Strategy == StrategyFail && len(p.Conflicts) > 0
+}
+
+// Merge computes a three-way merge of upstream into local. base is the
+// bundle version installed before, or nil for a first install; local holds
+// the current rules. Only rules in base or upstream are considered, so
+// rules from other bundles and hand-written rules are left alone.
+func Merge(base, local, upstream []knowledge.Rule, strategy Strategy) *Plan {
+	baseBy, localBy, upBy := byID(base), byID(local), byID(upstream)
+
+	ids := make(map[string]bool)
+	for id := range baseBy {
+		ids[id] = true
+	}
+	for id := range upBy {
+		ids[id] = true
+	}
+	sorted := make([]string, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+
+	plan := &Plan{Strategy: strategy, Changes: []Change{}}
+	for _, id := range sorted {
+		b, l, u := baseBy[id], localBy[id], upBy[id]
+		switch {
+		case u == nil && l == nil:
+			// Removed upstream and locally
+		case u == nil:
+			if b == nil || sameRule(l, b) {
+				plan.Changes = append(plan.Changes, Change{RuleID: id, Action: ActionDelete, Rule: l})
+			} else {
+				plan.conflict(id, "edited locally, removed upstream", l, nil)
+			}
+		case l == nil:
+			switch {
+			case b == nil:
+				plan.Changes = append(plan.Changes, Change{RuleID: id, Action: ActionAdd, Rule: u})
+			case sameRule(u, b):
+				// Deleted locally and unchanged upstream: stay deleted
+			default:
+				plan.conflict(id, "deleted locally, changed upstream", nil, u)
+			}
+		case sameRule(l, u):
+			// Already up to date
+		case b != nil && sameRule(l, b):
+			plan.Changes = append(plan.Changes, Change{RuleID: id, Action: ActionUpdate, Rule: u, Fields: diffFields(l, u)})
+		case b != nil && sameRule(u, b):
+			plan.Changes = append(plan.Changes, Change{RuleID: id, Action: ActionKeep, Rule: l, Fields: diffFields(b, l)})
+		case b == nil:
+			plan.conflict(id, "added both locally and upstream", l, u)
+		default:
+			plan.conflict(id, "edited both locally and upstream", l, u)
+		}
+	}
+	return plan
+}
+
+// conflict records a conflict and, unless the strategy is StrategyFail,
+// the change that resolves it.
+func (p *Plan) conflict(id, reason string, local, upstream *knowledge.Rule) {
+	c := Conflict{RuleID: id, Reason: reason, Local: local, Upstream: upstream}
+	switch p.Strategy {
+	case StrategyTheirs:
+		switch {
+		case upstream == nil:
+			c.Resolution = ActionDelete
+			p.Changes = append(p.Changes, Change{RuleID: id, Action: ActionDelete, Rule: local})
+		case local == nil:
+			c.Resolution = ActionAdd
+			p.Changes = append(p.Changes, Change{RuleID: id, Action: ActionAdd, Rule: upstream})
+		default:
+			c.Resolution = ActionUpdate
+			p.Changes = append(p.Changes, Change{RuleID: id, Action: ActionUpdate, Rule: upstream, Fields: diffFields(local, upstream)})
+		}
+	case StrategyOurs:
+		c.Resolution = ActionKeep
+	}
+	p.Conflicts = append(p.Conflicts, c)
+}
+
+func byID(rules []knowledge.Rule) map[string]*knowledge.Rule {
+	out := make(map[string]*knowledge.Rule, len(rules))
+	for i := range rules {
+		out[rules[i].ID] = &rules[i]
+	}
+	return out
+}
+
+// sameRule compares rule content, ignoring timestamps
+func sameRule(a, b *knowledge.Rule) bool {
+	return len(diffFields(a, b)) == 0
+}
+
+// diffFields lists the content fields that differ between two rules
+func diffFields(a, b *knowledge.Rule) []string {
+	var fields []string
+	check := func(name string, x, y interface{}) {
+		if !reflect.DeepEqual(x, y) {
+			fields = append(fields, name)
+		}
+	}
+	check("name", a.Name, b.Name)
+	check("middleware_type", a.MiddlewareType, b.MiddlewareType)
+	check("category", a.Category, b.Category)
+	check("severity", a.Severity, b.Severity)
+	check("condition", strings.TrimSpace(a.Condition), strings.TrimSpace(b.Condition))
+	check("recommendation", strings.TrimSpace(a.Recommendation), strings.TrimSpace(b.Recommendation))
+	check("priority", a.Priority, b.Priority)
+	check("tags", nonNil(a.Tags), nonNil(b.Tags))
+	check("version", a.Version, b.Version)
+	return fields
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,151 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Installed describes one installed bundle and its install history.
+type Installed struct {
+	Name     string   `json:"name"`
+	Current  Manifest `json:"current"`
+	Versions []string `json:"versions"`
+}
+
+// Store keeps a copy of every installed bundle version, so that an update
+// can be merged against the version it replaces and rolled back later.
+// Bundles live at <dir>/<name>/<version>.yaml; installed.json records the
+// install order per bundle.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStore opens or creates a bundle store in dir.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create bundle store %s: %w", dir, err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Current returns the installed version of a bundle, or nil if none is.
+func (s *Store) Current(name string) (*Bundle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.at(name, 1)
+}
+
+// Previous returns the version installed before the current one, or nil.
+func (s *Store) Previous(name string) (*Bundle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.at(name, 2)
+}
+
+// Record stores b and makes it the current version.
+func (s *Store) Record(b *Bundle) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := filepath.Join(s.dir, b.Manifest.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := b.Save(filepath.Join(dir, b.Manifest.Version+".yaml")); err != nil {
+		return err
+	}
+
+	history, err := s.history()
+	if err != nil {
+		return err
+	}
+	history[b.Manifest.Name] = append(history[b.Manifest.Name], b.Manifest.Version)
+	return s.saveHistory(history)
+}
+
+// Pop drops the current version of a bundle from the history, making the
+// previous one current again. The stored copy is kept.
+func (s *Store) Pop(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history, err := s.history()
+	if err != nil {
+		return err
+	}
+	versions := history[name]
+	if len(versions) == 0 {
+		return fmt.Errorf("bundle %s is not installed", name)
+	}
+	history[name] = versions[:len(versions)-1]
+	if len(history[name]) == 0 {
+		delete(history, name)
+	}
+	return s.saveHistory(history)
+}
+
+// List returns every installed bundle, sorted by name.
+func (s *Store) List() ([]Installed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history, err := s.history()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Installed, 0, len(history))
+	for name, versions := range history {
+		current, err := s.at(name, 1)
+		if err != nil {
+			return nil, err
+		}
+		inst := Installed{Name: name, Versions: versions}
+		if current != nil {
+			inst.Current = current.Manifest
+		}
+		out = append(out, inst)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// at returns the n-th most recent installed version of a bundle
+func (s *Store) at(name string, n int) (*Bundle, error) {
+	history, err := s.history()
+	if err != nil {
+		return nil, err
+	}
+	versions := history[name]
+	if len(versions) < n {
+		return nil, nil
+	}
+	return Load(filepath.Join(s.dir, name, versions[len(versions)-n]+".yaml"))
+}
+
+func (s *Store) history() (map[string][]string, error) {
+	history := make(map[string][]string)
+	data, err := os.ReadFile(filepath.Join(s.dir, "installed.json"))
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle history: %w", err)
+	}
+	return history, nil
+}
+
+func (s *Store) saveHistory(history map[string][]string) error {
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.dir, "installed.json"), data, 0644)
+}
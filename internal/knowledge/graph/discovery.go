@@ -0,0 +1,706 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/context/k8s"
+	"github.com/kubestack-ai/kubestack-ai/internal/storage/graph"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DiscoveredByLabel marks nodes created by Kubernetes discovery, so that
+	// stale ones can be removed without touching manually added nodes.
+	DiscoveredByLabel = "kubestack-ai/discovered-by"
+	// DiscoverySourceKubernetes is the value of DiscoveredByLabel for discovered nodes.
+	DiscoverySourceKubernetes = "kubernetes"
+)
+
+// wellKnownPorts maps default middleware ports to middleware types.
+var wellKnownPorts = map[int32]string{
+	6379:  "redis",
+	26379: "redis",
+	3306:  "mysql",
+	5432:  "postgresql",
+	9092:  "kafka",
+	2181:  "zookeeper",
+	27017: "mongodb",
+	5672:  "rabbitmq",
+	9200:  "elasticsearch",
+	11211: "memcached",
+}
+
+// imageHints maps image name fragments to middleware types; checked in order.
+var imageHints = []struct {
+	fragment       string
+	middlewareType string
+}{
+	{"redis", "redis"},
+	{"mysql", "mysql"},
+	{"mariadb", "mysql"},
+	{"postgres", "postgresql"},
+	{"kafka", "kafka"},
+	{"zookeeper", "zookeeper"},
+	{"mongo", "mongodb"},
+	{"rabbitmq", "rabbitmq"},
+	{"elasticsearch", "elasticsearch"},
+	{"memcached", "memcached"},
+}
+
+var dnsNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+// DiscoveryResult is the graph inferred from the cluster.
+type DiscoveryResult struct {
+	Nodes []*graph.Node `json:"nodes"`
+	Edges []*graph.Edge `json:"edges"`
+}
+
+// GraphDiff describes the changes needed to bring the store in line with a discovery result.
+type GraphDiff struct {
+	AddedNodes   []*graph.Node `json:"added_nodes"`
+	UpdatedNodes []*graph.Node `json:"updated_nodes"`
+	RemovedNodes []*graph.Node `json:"removed_nodes"`
+	AddedEdges   []*graph.Edge `json:"added_edges"`
+	UpdatedEdges []*graph.Edge `json:"updated_edges"`
+	RemovedEdges []*graph.Edge `json:"removed_edges"`
+}
+
+// Empty reports whether the diff contains no changes.
+func (d *GraphDiff) Empty() bool {
+	return len(d.AddedNodes)+len(d.UpdatedNodes)+len(d.RemovedNodes)+
+		len(d.AddedEdges)+len(d.UpdatedEdges)+len(d.RemovedEdges) == 0
+}
+
+// Summary returns a one-line description of the diff.
+func (d *GraphDiff) Summary() string {
+	return fmt.Sprintf("nodes: +%d ~%d -%d, edges: +%d ~%d -%d",
+		len(d.AddedNodes), len(d.UpdatedNodes), len(d.RemovedNodes),
+		len(d.AddedEdges), len(d.UpdatedEdges), len(d.RemovedEdges))
+}
+
+// Discoverer infers service→middleware dependencies from Kubernetes resources
+// and keeps the knowledge graph in sync with them.
+type Discoverer struct {
+	client    *k8s.Client
+	store     graph.GraphStore
+	namespace string
+	log       logger.Logger
+}
+
+// NewDiscoverer creates a Discoverer. An empty namespace discovers all namespaces.
+func NewDiscoverer(client *k8s.Client, store graph.GraphStore, namespace string) *Discoverer {
+	return &Discoverer{
+		client:    client,
+		store:     store,
+		namespace: namespace,
+		log:       logger.NewLogger("graph-discovery"),
+	}
+}
+
+// workload is a Deployment or StatefulSet reduced to what discovery needs.
+type workload struct {
+	kind           string
+	namespace      string
+	name           string
+	labels         map[string]string
+	podLabels      map[string]string
+	replicas       int
+	podSpec        corev1.PodSpec
+	middlewareType string
+	nodeID         string
+}
+
+// Discover walks Deployments, StatefulSets, Services and Endpoints, classifies
+// workloads as services or middleware (by image and well-known ports) and
+// infers dependency edges from container environment variables, including
+// values referenced from ConfigMaps and Secrets. Secret values are only parsed
+// for host names and never stored.
+func (d *Discoverer) Discover(ctx context.Context) (*DiscoveryResult, error) {
+	workloads, err := d.listWorkloads(ctx)
+	if err != nil {
+		return nil, err
+	}
+	services, err := d.client.ListServices(ctx, d.namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	endpoints, err := d.client.ListEndpoints(ctx, d.namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list endpoints: %w", err)
+	}
+	addresses := make(map[string][]string)
+	for _, ep := range endpoints.Items {
+		key := ep.Namespace + "/" + ep.Name
+		for _, subset := range ep.Subsets {
+			for _, addr := range subset.Addresses {
+				for _, port := range subset.Ports {
+					addresses[key] = append(addresses[key], fmt.Sprintf("%s:%d", addr.IP, port.Port))
+				}
+			}
+		}
+	}
+
+	// A workload selected by a Service on a well-known port is middleware even if its image is custom
+	backing := make(map[string][]*workload) // namespace/service -> workloads
+	for i := range services.Items {
+		svc := &services.Items[i]
+		key := svc.Namespace + "/" + svc.Name
+		portType := servicePortType(svc)
+		for _, w := range workloads {
+			if w.namespace == svc.Namespace && selectorMatches(svc.Spec.Selector, w.podLabels) {
+				backing[key] = append(backing[key], w)
+				if w.middlewareType == "" {
+					w.middlewareType = portType
+				}
+			}
+		}
+	}
+
+	now := time.Now()
+	result := &DiscoveryResult{}
+	nodeIDs := make(map[string]bool)
+	addNode := func(n *graph.Node) {
+		if !nodeIDs[n.ID] {
+			nodeIDs[n.ID] = true
+			result.Nodes = append(result.Nodes, n)
+		}
+	}
+
+	for _, w := range workloads {
+		props := map[string]interface{}{"workload_kind": w.kind, "replicas": w.replicas}
+		nodeType := graph.NodeTypeService
+		if w.middlewareType != "" {
+			nodeType = graph.NodeTypeMiddleware
+			props["middleware_type"] = w.middlewareType
+		}
+		w.nodeID = GenerateID(nodeType, w.namespace, w.name)
+		addNode(&graph.Node{
+			ID:         w.nodeID,
+			Type:       nodeType,
+			Name:       w.name,
+			Namespace:  w.namespace,
+			Labels:     discoveredLabels(w.labels),
+			Properties: props,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		})
+	}
+
+	// Resolve each Service to the graph nodes it routes to
+	targets := make(map[string][]string) // namespace/service -> node IDs
+	for i := range services.Items {
+		svc := &services.Items[i]
+		key := svc.Namespace + "/" + svc.Name
+		for _, w := range backing[key] {
+			targets[key] = append(targets[key], w.nodeID)
+			if w.middlewareType != "" && len(addresses[key]) > 0 {
+				for _, n := range result.Nodes {
+					if n.ID == w.nodeID {
+						n.Properties["endpoints"] = addresses[key]
+					}
+				}
+			}
+		}
+		if len(backing[key]) > 0 {
+			continue
+		}
+		// Middleware outside the cluster or without a selector (e.g. ExternalName, manual Endpoints)
+		if mwType := servicePortType(svc); mwType != "" {
+			props := map[string]interface{}{"workload_kind": "service", "middleware_type": mwType}
+			if svc.Spec.ExternalName != "" {
+				props["endpoints"] = []string{svc.Spec.ExternalName}
+			} else if len(addresses[key]) > 0 {
+				props["endpoints"] = addresses[key]
+			}
+			id := GenerateID(graph.NodeTypeMiddleware, svc.Namespace, svc.Name)
+			addNode(&graph.Node{
+				ID:         id,
+				Type:       graph.NodeTypeMiddleware,
+				Name:       svc.Name,
+				Namespace:  svc.Namespace,
+				Labels:     discoveredLabels(svc.Labels),
+				Properties: props,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			})
+			targets[key] = []string{id}
+		}
+	}
+
+	nodeTypes := make(map[string]string)
+	for _, n := range result.Nodes {
+		if mw, ok := n.Properties["middleware_type"].(string); ok {
+			nodeTypes[n.ID] = mw
+		}
+	}
+
+	// Infer edges from environment references
+	edges := make(map[string]*graph.Edge)
+	for _, w := range workloads {
+		refs, err := d.envReferences(ctx, w)
+		if err != nil {
+			d.log.Warnf("Skipping environment of %s %s/%s: %v", w.kind, w.namespace, w.name, err)
+			continue
+		}
+		for _, ref := range refs {
+			for _, host := range extractHosts(ref.value) {
+				key, ok := resolveServiceHost(host, w.namespace)
+				if !ok {
+					continue
+				}
+				for _, target := range targets[key] {
+					if target == w.nodeID || nodeTypes[target] == "" {
+						continue
+					}
+					id := fmt.Sprintf("%s-%s-%s", w.nodeID, graph.EdgeTypeDependsOn, target)
+					edge, exists := edges[id]
+					if !exists {
+						edge = &graph.Edge{
+							ID:     id,
+							FromID: w.nodeID,
+							ToID:   target,
+							Type:   graph.EdgeTypeDependsOn,
+							Properties: map[string]interface{}{
+								"discovered_by": DiscoverySourceKubernetes,
+								"protocol":      nodeTypes[target],
+							},
+							CreatedAt: now,
+						}
+						edges[id] = edge
+					}
+					edge.Properties["via"] = appendUnique(edge.Properties["via"], ref.source)
+				}
+			}
+		}
+	}
+
+	for _, e := range edges {
+		result.Edges = append(result.Edges, e)
+	}
+	sort.Slice(result.Nodes, func(i, j int) bool { return result.Nodes[i].ID < result.Nodes[j].ID })
+	sort.Slice(result.Edges, func(i, j int) bool { return result.Edges[i].ID < result.Edges[j].ID })
+	return result, nil
+}
+
+func (d *Discoverer) listWorkloads(ctx context.Context) ([]*workload, error) {
+	deployments, err := d.client.ListDeployments(ctx, d.namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	statefulSets, err := d.client.ListStatefulSets(ctx, d.namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+
+	var workloads []*workload
+	for _, dep := range deployments.Items {
+		replicas := 1
+		if dep.Spec.Replicas != nil {
+			replicas = int(*dep.Spec.Replicas)
+		}
+		workloads = append(workloads, &workload{
+			kind:      "deployment",
+			namespace: dep.Namespace,
+			name:      dep.Name,
+			labels:    dep.Labels,
+			podLabels: dep.Spec.Template.Labels,
+			replicas:  replicas,
+			podSpec:   dep.Spec.Template.Spec,
+		})
+	}
+	for _, sts := range statefulSets.Items {
+		replicas := 1
+		if sts.Spec.Replicas != nil {
+			replicas = int(*sts.Spec.Replicas)
+		}
+		workloads = append(workloads, &workload{
+			kind:      "statefulset",
+			namespace: sts.Namespace,
+			name:      sts.Name,
+			labels:    sts.Labels,
+			podLabels: sts.Spec.Template.Labels,
+			replicas:  replicas,
+			podSpec:   sts.Spec.Template.Spec,
+		})
+	}
+	for _, w := range workloads {
+		w.middlewareType = podMiddlewareType(w.podSpec)
+	}
+	return workloads, nil
+}
+
+// envReference is one environment value and where it came from.
+type envReference struct {
+	source string // e.g. "env:REDIS_HOST", "configmap:app-config/DB_URL"
+	value  string
+}
+
+func (d *Discoverer) envReferences(ctx context.Context, w *workload) ([]envReference, error) {
+	configMaps := make(map[string]map[string]string)
+	secrets := make(map[string]map[string]string)
+	getConfigMap := func(name string) (map[string]string, error) {
+		if data, ok := configMaps[name]; ok {
+			return data, nil
+		}
+		cm, err := d.client.GetConfigMap(ctx, w.namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		configMaps[name] = cm.Data
+		return cm.Data, nil
+	}
+	getSecret := func(name string) (map[string]string, error) {
+		if data, ok := secrets[name]; ok {
+			return data, nil
+		}
+		secret, err := d.client.GetSecret(ctx, w.namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		data := make(map[string]string, len(secret.Data)+len(secret.StringData))
+		for k, v := range secret.Data {
+			data[k] = string(v)
+		}
+		for k, v := range secret.StringData {
+			data[k] = v
+		}
+		secrets[name] = data
+		return data, nil
+	}
+
+	var refs []envReference
+	containers := append(append([]corev1.Container{}, w.podSpec.InitContainers...), w.podSpec.Containers...)
+	for _, c := range containers {
+		for _, env := range c.Env {
+			switch {
+			case env.Value != "":
+				refs = append(refs, envReference{source: "env:" + env.Name, value: env.Value})
+			case env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil:
+				ref := env.ValueFrom.ConfigMapKeyRef
+				data, err := getConfigMap(ref.Name)
+				if err != nil {
+					d.log.Debugf("ConfigMap %s/%s not readable: %v", w.namespace, ref.Name, err)
+					continue
+				}
+				refs = append(refs, envReference{source: fmt.Sprintf("configmap:%s/%s", ref.Name, ref.Key), value: data[ref.Key]})
+			case env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil:
+				ref := env.ValueFrom.SecretKeyRef
+				data, err := getSecret(ref.Name)
+				if err != nil {
+					d.log.Debugf("Secret %s/%s not readable: %v", w.namespace, ref.Name, err)
+					continue
+				}
+				refs = append(refs, envReference{source: fmt.Sprintf("secret:%s/%s", ref.Name, ref.Key), value: data[ref.Key]})
+			}
+		}
+		for _, from := range c.EnvFrom {
+			var (
+				data   map[string]string
+				source string
+				err    error
+			)
+			switch {
+			case from.ConfigMapRef != nil:
+				source = "configmap:" + from.ConfigMapRef.Name
+				data, err = getConfigMap(from.ConfigMapRef.Name)
+			case from.SecretRef != nil:
+				source = "secret:" + from.SecretRef.Name
+				data, err = getSecret(from.SecretRef.Name)
+			default:
+				continue
+			}
+			if err != nil {
+				d.log.Debugf("Skipping %s in %s: %v", source, w.namespace, err)
+				continue
+			}
+			for k, v := range data {
+				refs = append(refs, envReference{source: source + "/" + k, value: v})
+			}
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].source < refs[j].source })
+	return refs, nil
+}
+
+// Diff compares a discovery result with the discovered part of the store.
+// Nodes that were not created by discovery are never updated or removed.
+func (d *Discoverer) Diff(ctx context.Context, result *DiscoveryResult) (*GraphDiff, error) {
+	existingNodes, err := d.store.ListNodes(ctx, graph.NodeFilter{
+		Namespace:  d.namespace,
+		LabelMatch: map[string]string{DiscoveredByLabel: DiscoverySourceKubernetes},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list discovered nodes: %w", err)
+	}
+	existing := make(map[string]*graph.Node, len(existingNodes))
+	for _, n := range existingNodes {
+		existing[n.ID] = n
+	}
+
+	diff := &GraphDiff{}
+	wanted := make(map[string]bool, len(result.Nodes))
+	for _, n := range result.Nodes {
+		wanted[n.ID] = true
+		old, ok := existing[n.ID]
+		if !ok {
+			if _, err := d.store.GetNode(ctx, n.ID); err == nil {
+				// Manually maintained node with the same ID takes precedence
+				continue
+			}
+			diff.AddedNodes = append(diff.AddedNodes, n)
+			continue
+		}
+		if old.Type != n.Type || !sameJSON(old.Labels, n.Labels) || !sameJSON(old.Properties, n.Properties) {
+			diff.UpdatedNodes = append(diff.UpdatedNodes, n)
+		}
+	}
+	for _, n := range existingNodes {
+		if !wanted[n.ID] {
+			diff.RemovedNodes = append(diff.RemovedNodes, n)
+		}
+	}
+
+	existingEdges, err := d.store.ListEdges(ctx, graph.EdgeFilter{Types: []graph.EdgeType{graph.EdgeTypeDependsOn}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list edges: %w", err)
+	}
+	oldEdges := make(map[string]*graph.Edge)
+	for _, e := range existingEdges {
+		// Only edges created by discovery from nodes in scope are managed here
+		if e.Properties["discovered_by"] != DiscoverySourceKubernetes {
+			continue
+		}
+		if _, ok := existing[e.FromID]; !ok && !wanted[e.FromID] {
+			continue
+		}
+		oldEdges[e.ID] = e
+	}
+	wantedEdges := make(map[string]bool, len(result.Edges))
+	for _, e := range result.Edges {
+		wantedEdges[e.ID] = true
+		old, ok := oldEdges[e.ID]
+		if !ok {
+			diff.AddedEdges = append(diff.AddedEdges, e)
+		} else if !sameJSON(old.Properties, e.Properties) {
+			diff.UpdatedEdges = append(diff.UpdatedEdges, e)
+		}
+	}
+	for _, e := range existingEdges {
+		if _, managed := oldEdges[e.ID]; managed && !wantedEdges[e.ID] {
+			diff.RemovedEdges = append(diff.RemovedEdges, e)
+		}
+	}
+
+	sort.Slice(diff.RemovedNodes, func(i, j int) bool { return diff.RemovedNodes[i].ID < diff.RemovedNodes[j].ID })
+	sort.Slice(diff.RemovedEdges, func(i, j int) bool { return diff.RemovedEdges[i].ID < diff.RemovedEdges[j].ID })
+	return diff, nil
+}
+
+// Apply writes a diff to the store: stale edges and nodes are removed first,
+// then nodes and edges are upserted.
+func (d *Discoverer) Apply(ctx context.Context, diff *GraphDiff) error {
+	for _, e := range diff.RemovedEdges {
+		if err := d.store.DeleteEdge(ctx, e.ID); err != nil && err != graph.ErrEdgeNotFound {
+			return fmt.Errorf("failed to remove edge %s: %w", e.ID, err)
+		}
+	}
+	for _, n := range diff.RemovedNodes {
+		if err := d.store.DeleteNode(ctx, n.ID); err != nil && err != graph.ErrNodeNotFound {
+			return fmt.Errorf("failed to remove node %s: %w", n.ID, err)
+		}
+	}
+	for _, n := range diff.AddedNodes {
+		if err := d.store.AddNode(ctx, n); err != nil && err != graph.ErrNodeExists {
+			return fmt.Errorf("failed to add node %s: %w", n.ID, err)
+		}
+	}
+	for _, n := range diff.UpdatedNodes {
+		if err := d.store.UpdateNode(ctx, n); err != nil {
+			return fmt.Errorf("failed to update node %s: %w", n.ID, err)
+		}
+	}
+	for _, e := range diff.UpdatedEdges {
+		if err := d.store.DeleteEdge(ctx, e.ID); err != nil && err != graph.ErrEdgeNotFound {
+			return fmt.Errorf("failed to replace edge %s: %w", e.ID, err)
+		}
+	}
+	for _, e := range append(append([]*graph.Edge{}, diff.AddedEdges...), diff.UpdatedEdges...) {
+		if err := d.store.AddEdge(ctx, e); err != nil && err != graph.ErrEdgeExists {
+			return fmt.Errorf("failed to add edge %s: %w", e.ID, err)
+		}
+	}
+	return nil
+}
+
+// Sync discovers the current topology and applies the resulting diff.
+func (d *Discoverer) Sync(ctx context.Context) (*GraphDiff, error) {
+	result, err := d.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := d.Diff(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.Apply(ctx, diff); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// Run syncs the graph immediately and then on every interval until ctx is done.
+func (d *Discoverer) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if diff, err := d.Sync(ctx); err != nil {
+			d.log.Errorf("Graph discovery failed: %v", err)
+		} else if !diff.Empty() {
+			d.log.Infof("Graph discovery applied changes (%s)", diff.Summary())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func discoveredLabels(labels map[string]string) map[string]string {
+	out := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		out[k] = v
+	}
+	out[DiscoveredByLabel] = DiscoverySourceKubernetes
+	return out
+}
+
+func podMiddlewareType(spec corev1.PodSpec) string {
+	for _, c := range spec.Containers {
+		image := strings.ToLower(c.Image)
+		// Ignore the registry and tag, match on the repository path
+		if i := strings.LastIndex(image, "@"); i >= 0 {
+			image = image[:i]
+		}
+		if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+			image = image[:i]
+		}
+		for _, hint := range imageHints {
+			if strings.Contains(image[strings.LastIndex(image, "/")+1:], hint.fragment) {
+				return hint.middlewareType
+			}
+		}
+	}
+	for _, c := range spec.Containers {
+		for _, p := range c.Ports {
+			if t, ok := wellKnownPorts[p.ContainerPort]; ok {
+				return t
+			}
+		}
+	}
+	return ""
+}
+
+func servicePortType(svc *corev1.Service) string {
+	for _, p := range svc.Spec.Ports {
+		if t, ok := wellKnownPorts[p.Port]; ok {
+			return t
+		}
+		if t, ok := wellKnownPorts[int32(p.TargetPort.IntValue())]; ok {
+			return t
+		}
+	}
+	return ""
+}
+
+func selectorMatches(selector, labels map[string]string) bool {
+	if len(selector) == 0 {
+		return false
+	}
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// extractHosts finds host names in an environment value such as "redis-master",
+// "redis-master:6379", "redis://:pw@redis-master.cache.svc:6379/0" or a
+// comma-separated broker list.
+func extractHosts(value string) []string {
+	var hosts []string
+	for _, token := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n'
+	}) {
+		host := token
+		if strings.Contains(token, "://") {
+			u, err := url.Parse(token)
+			if err != nil {
+				continue
+			}
+			host = u.Hostname()
+		} else {
+			if i := strings.LastIndex(host, "@"); i >= 0 {
+				host = host[i+1:]
+			}
+			if i := strings.IndexAny(host, "/?"); i >= 0 {
+				host = host[:i]
+			}
+			if i := strings.LastIndex(host, ":"); i >= 0 {
+				host = host[:i]
+			}
+		}
+		host = strings.TrimSuffix(strings.ToLower(host), ".")
+		if host != "" && dnsNamePattern.MatchString(host) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// resolveServiceHost maps a cluster DNS name to "namespace/service". Accepted
+// forms are "svc", "svc.ns", "svc.ns.svc" and "svc.ns.svc.<cluster-domain>".
+func resolveServiceHost(host, defaultNamespace string) (string, bool) {
+	parts := strings.Split(host, ".")
+	switch {
+	case len(parts) == 1:
+		return defaultNamespace + "/" + parts[0], true
+	case len(parts) == 2:
+		return parts[1] + "/" + parts[0], true
+	case len(parts) >= 3 && parts[2] == "svc":
+		return parts[1] + "/" + parts[0], true
+	}
+	return "", false
+}
+
+func appendUnique(existing interface{}, value string) []string {
+	list, _ := existing.([]string)
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// sameJSON compares two values by their JSON encoding, which tolerates the
+// type changes (e.g. int to float64) introduced by stores that round-trip JSON.
+func sameJSON(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
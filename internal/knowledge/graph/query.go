@@ -3,6 +3,9 @@ package graph
 import (
 	"context"
 	"fmt"
+	"net"
+	"sort"
+	"strings"
 
 	"github.com/kubestack-ai/kubestack-ai/internal/storage/graph"
 )
@@ -112,3 +115,149 @@ func (q *QueryEngine) GetDependencyChain(ctx context.Context, serviceID string)
     }
     return sub.Nodes, sub.Edges, nil
 }
+
+// ResolveNode finds the service or middleware node an instance name refers
+// to. instance may be a node ID, a workload or Service name, a pod name, a
+// cluster DNS name or an "ip:port" endpoint; namespace narrows the search
+// when set. It returns graph.ErrNodeNotFound when nothing matches.
+func (q *QueryEngine) ResolveNode(ctx context.Context, namespace, instance string) (*graph.Node, error) {
+	if instance == "" {
+		return nil, graph.ErrNodeNotFound
+	}
+	if node, err := q.store.GetNode(ctx, instance); err == nil {
+		return node, nil
+	}
+
+	nodes, err := q.store.ListNodes(ctx, graph.NodeFilter{
+		Types:     []graph.NodeType{graph.NodeTypeService, graph.NodeTypeMiddleware},
+		Namespace: namespace,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+	// Endpoints are the most specific match
+	for _, n := range nodes {
+		for _, ep := range propertyStrings(n.Properties["endpoints"]) {
+			if ep == instance || endpointHost(ep) == endpointHost(instance) {
+				return n, nil
+			}
+		}
+	}
+
+	hosts := extractHosts(instance)
+	if len(hosts) == 0 {
+		return nil, graph.ErrNodeNotFound
+	}
+	name, ns := hosts[0], namespace
+	if key, ok := resolveServiceHost(hosts[0], namespace); ok && strings.Contains(hosts[0], ".") {
+		parts := strings.SplitN(key, "/", 2)
+		ns, name = parts[0], parts[1]
+	}
+
+	// Pod names carry generated suffixes ("redis-0", "api-7d9f8b6c5-xk2lp"),
+	// so also try the workload names they are derived from
+	for _, candidate := range workloadNames(name) {
+		for _, n := range nodes {
+			if n.Name == candidate && (ns == "" || n.Namespace == ns) {
+				return n, nil
+			}
+		}
+	}
+	return nil, graph.ErrNodeNotFound
+}
+
+// podNameAlphabet is the alphabet of the random strings Kubernetes appends
+// to generated names (k8s.io/apimachinery/pkg/util/rand.SafeEncodeString).
+const podNameAlphabet = "bcdfghjklmnpqrstvwxz2456789"
+
+// workloadNames returns name followed by the names of the workloads a pod
+// called name may belong to, most specific first. Only suffixes Kubernetes
+// generates are stripped: a StatefulSet ordinal ("redis-0"), a 5 character
+// pod id ("agent-x7k2p") and a pod-template-hash before it
+// ("api-7d9f8b6c5-xk2lp"), so that "db-proxy-abc" never resolves to "db".
+func workloadNames(name string) []string {
+	names := []string{name}
+	base, last, ok := cutLastSegment(name)
+	if !ok {
+		return names
+	}
+	if isOrdinal(last) {
+		return append(names, base)
+	}
+	if len(last) != 5 || !isGeneratedString(last) {
+		return names
+	}
+	names = append(names, base)
+	if owner, hash, ok := cutLastSegment(base); ok && len(hash) >= 6 && len(hash) <= 10 && isGeneratedString(hash) {
+		names = append(names, owner)
+	}
+	return names
+}
+
+func cutLastSegment(name string) (string, string, bool) {
+	i := strings.LastIndex(name, "-")
+	if i <= 0 || i == len(name)-1 {
+		return "", "", false
+	}
+	return name[:i], name[i+1:], true
+}
+
+func isOrdinal(s string) bool {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isGeneratedString(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune(podNameAlphabet, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// ConnectedWithin returns the nodes reachable from id over at most hops
+// dependency edges, in either direction.
+func (q *QueryEngine) ConnectedWithin(ctx context.Context, id string, hops int) ([]*graph.Node, error) {
+	return q.store.GetNeighbors(ctx, id, "both", hops, []graph.EdgeType{graph.EdgeTypeDependsOn})
+}
+
+// Dependencies returns the nodes id depends on, directly or through at
+// most hops dependency edges.
+func (q *QueryEngine) Dependencies(ctx context.Context, id string, hops int) ([]*graph.Node, error) {
+	return q.store.GetNeighbors(ctx, id, "out", hops, []graph.EdgeType{graph.EdgeTypeDependsOn})
+}
+
+func endpointHost(endpoint string) string {
+	if host, _, err := net.SplitHostPort(endpoint); err == nil {
+		return host
+	}
+	return endpoint
+}
+
+// propertyStrings reads a string list property, which stores that
+// round-trip JSON return as []interface{}.
+func propertyStrings(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
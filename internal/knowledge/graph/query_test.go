@@ -62,3 +62,32 @@ func TestQuery_TraceRootCause(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "redis1", res.RootCauseNode.ID)
 }
+
+func TestQuery_ResolveNodeFromPodName(t *testing.T) {
+	store := memory.NewMemoryGraphStore()
+	q := NewQueryEngine(store)
+	ctx := context.Background()
+
+	for _, name := range []string{"db", "redis", "api", "agent"} {
+		store.AddNode(ctx, &graph.Node{ID: "prod/" + name, Type: graph.NodeTypeMiddleware, Name: name, Namespace: "prod"})
+	}
+
+	cases := map[string]string{
+		"redis-0":             "prod/redis",
+		"redis":               "prod/redis",
+		"api-7d9f8b6c5-xk2lp": "prod/api",
+		"agent-x7k2p":         "prod/agent",
+	}
+	for instance, want := range cases {
+		node, err := q.ResolveNode(ctx, "prod", instance)
+		if assert.NoError(t, err, instance) {
+			assert.Equal(t, want, node.ID, instance)
+		}
+	}
+
+	// Only generated suffixes are stripped
+	for _, instance := range []string{"db-proxy-abc", "db-proxy", "redis-cache-01", "api-canary-xk2lp"} {
+		_, err := q.ResolveNode(ctx, "prod", instance)
+		assert.ErrorIs(t, err, graph.ErrNodeNotFound, instance)
+	}
+}
@@ -0,0 +1,22 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/storage/graph"
+	"github.com/kubestack-ai/kubestack-ai/internal/storage/graph/memory"
+	"github.com/kubestack-ai/kubestack-ai/internal/storage/graph/neo4j"
+)
+
+// NewStore creates the graph store selected in the configuration.
+// The in-memory store is used when no store is configured.
+func NewStore(cfg config.GraphConfig) (graph.GraphStore, error) {
+	switch cfg.Store {
+	case "", "memory":
+		return memory.NewMemoryGraphStore(), nil
+	case "neo4j":
+		return neo4j.NewClient(cfg.Neo4j.URI, cfg.Neo4j.Username, cfg.Neo4j.Password, cfg.Neo4j.Database)
+	}
+	return nil, fmt.Errorf("unsupported graph store: %s", cfg.Store)
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/diagnosis/rules"
 	"gopkg.in/yaml.v2"
 )
 
@@ -21,36 +22,45 @@ type RuleLoader struct {
 	watcher    *fsnotify.Watcher
 	mu         sync.Mutex
 	loadedFiles map[string]string // ID -> Filepath
+	repoDir     string            // where new rules are saved
 }
 
+// DefaultRepoDir is where new rules are saved unless SetRepoDir is called.
+const DefaultRepoDir = "internal/knowledge/repository"
+
 // NewRuleLoader creates a new RuleLoader.
 func NewRuleLoader(kb *KnowledgeBase) *RuleLoader {
 	return &RuleLoader{
 		kb:          kb,
 		log:         logger.NewLogger("rule-loader"),
 		loadedFiles: make(map[string]string),
+		repoDir:     DefaultRepoDir,
 	}
 }
 
-// LoadFromFile loads rules from a YAML file.
+// SetRepoDir sets the directory new rules are saved to.
+func (rl *RuleLoader) SetRepoDir(dir string) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.repoDir = dir
+}
+
+// LoadFromFile loads rules from a YAML or JSON file in the shared rule schema.
 func (rl *RuleLoader) LoadFromFile(path string) error {
-	data, err := ioutil.ReadFile(path)
+	ruleSet, err := rules.LoadFile(path)
 	if err != nil {
-		return fmt.Errorf("failed to read file %s: %w", path, err)
-	}
-
-	var rules []Rule
-	if err := yaml.Unmarshal(data, &rules); err != nil {
-		return fmt.Errorf("failed to parse YAML in %s: %w", path, err)
+		return err
 	}
 
 	successCount := 0
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	for _, rule := range rules {
-		// Create a copy of the loop variable
-		r := rule
+	for _, spec := range ruleSet.Rules {
+		if !spec.IsEnabled() {
+			continue
+		}
+		r := fromSharedRule(spec)
 		if err := rl.kb.AddRule(&r); err != nil {
 			rl.log.Errorf("Failed to add rule %s from %s: %v", r.ID, path, err)
 			continue
@@ -63,6 +73,22 @@ func (rl *RuleLoader) LoadFromFile(path string) error {
 	return nil
 }
 
+// fromSharedRule converts a rule in the shared schema into a knowledge base rule.
+func fromSharedRule(r rules.Rule) Rule {
+	return Rule{
+		ID:             r.ID,
+		Name:           r.Name,
+		MiddlewareType: r.MiddlewareType,
+		Category:       r.Category,
+		Severity:       r.Severity,
+		Condition:      r.Condition.Expression,
+		Recommendation: r.Recommendation,
+		Priority:       r.Priority,
+		Tags:           r.Tags,
+		Version:        r.Version,
+	}
+}
+
 // LoadFromDirectory loads all .yaml or .json files from a directory.
 func (rl *RuleLoader) LoadFromDirectory(dir string) error {
 	files, err := ioutil.ReadDir(dir)
@@ -144,7 +170,7 @@ func (rl *RuleLoader) SaveRule(rule *Rule) error {
 	if !exists {
 		// New rule, default to repo directory based on middleware
 		// Ensure directory exists
-		repoDir := "internal/knowledge/repository"
+		repoDir := rl.repoDir
 		if _, err := os.Stat(repoDir); os.IsNotExist(err) {
 			_ = os.MkdirAll(repoDir, 0755)
 		}
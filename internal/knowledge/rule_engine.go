@@ -5,37 +5,30 @@ import (
 	"sort"
 	"time"
 
-	"github.com/Knetic/govaluate"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/diagnosis/rules"
 )
 
-// ConditionEvaluator evaluates rule conditions against metrics.
-type ConditionEvaluator struct{}
+// ConditionEvaluator evaluates rule conditions against metrics. Conditions
+// use the expression language shared with the diagnosis and RCA engines.
+type ConditionEvaluator struct {
+	evaluator *rules.Evaluator
+}
 
 // NewConditionEvaluator creates a new ConditionEvaluator.
 func NewConditionEvaluator() *ConditionEvaluator {
-	return &ConditionEvaluator{}
+	return &ConditionEvaluator{evaluator: rules.NewEvaluator()}
 }
 
 // Evaluate checks if the condition expression evaluates to true given the context.
+// A condition reading a metric missing from the context does not match.
 func (ce *ConditionEvaluator) Evaluate(condition string, context map[string]interface{}) (bool, error) {
-	expression, err := govaluate.NewEvaluableExpression(condition)
-	if err != nil {
-		return false, fmt.Errorf("failed to parse condition '%s': %w", condition, err)
-	}
-
-	result, err := expression.Evaluate(context)
+	matched, err := ce.evaluator.Evaluate(condition, context)
 	if err != nil {
-		return false, fmt.Errorf("failed to evaluate condition '%s': %w", condition, err)
+		return false, fmt.Errorf("condition '%s': %w", condition, err)
 	}
-
-	boolResult, ok := result.(bool)
-	if !ok {
-		return false, fmt.Errorf("condition result is not boolean: %v", result)
-	}
-
-	return boolResult, nil
+	return matched, nil
 }
 
 // RuleMatch represents a successful match of a rule.
@@ -83,7 +76,7 @@ func NewRuleEngine(kb *KnowledgeBase) *RuleEngine {
 // Match finds all rules that match the current context.
 func (re *RuleEngine) Match(ctx *DiagnosisContext) ([]*RuleMatch, error) {
 	// 1. Query potentially relevant rules
-	rules, err := re.kb.QueryRules(QueryOptions{
+	candidates, err := re.kb.QueryRules(QueryOptions{
 		MiddlewareType: ctx.MiddlewareType,
 	})
 	if err != nil {
@@ -91,7 +84,7 @@ func (re *RuleEngine) Match(ctx *DiagnosisContext) ([]*RuleMatch, error) {
 	}
 
 	var matches []*RuleMatch
-	for _, rule := range rules {
+	for _, rule := range candidates {
 		// 2. Evaluate condition
 		matched, err := re.evaluator.Evaluate(rule.Condition, ctx.Metrics)
 		if err != nil {
@@ -78,7 +78,7 @@ func (c *DiagnosisChain) Execute(ctx context.Context, question string) (*parser.
 		ResponseFormat: "json_object",
 		Temperature:    0.2, // Low temp for diagnosis
 	}
-	resp, err := c.llmClient.SendMessage(ctx, req)
+	resp, err := c.llmClient.SendMessage(interfaces.WithFeature(ctx, "diagnosis_chain"), req)
 	if err != nil {
 		return nil, fmt.Errorf("llm call failed: %w", err)
 	}
@@ -19,11 +19,15 @@ import (
 	"fmt"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
 	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // NewClientFromConfig is a factory function that creates the appropriate LLMClient
 // based on the application's configuration. It acts as a selector to switch
-// between different providers like OpenAI and Gemini.
+// between different providers like OpenAI, Gemini and a local Ollama server.
+// The "replay" provider wraps another provider (replay.provider) with a
+// cassette for recording and deterministic offline playback, and "router"
+// fails over across the providers listed in router.providers.
 //
 // Parameters:
 //   - cfg: The LLM configuration containing the provider and its settings.
@@ -34,11 +38,76 @@ import (
 func NewClientFromConfig(cfg *config.LLMConfig) (interfaces.LLMClient, error) {
 	switch cfg.Provider {
 	case "openai":
-		return NewOpenAIClient(cfg.OpenAI.APIKey)
+		return NewOpenAIClientWithConfig(&cfg.OpenAI)
 	case "gemini":
 		// Gemini client requires a context for initialization.
 		return NewGeminiClient(context.Background(), cfg.Gemini.APIKey)
+	case "ollama":
+		return NewOllamaClient(&cfg.Ollama)
+	case "replay":
+		return newReplayClientFromConfig(cfg)
+	case "router":
+		return newRouterFromConfig(cfg)
 	default:
 		return nil, fmt.Errorf("unknown LLM provider: %s", cfg.Provider)
 	}
-}
\ No newline at end of file
+}
+
+// newReplayClientFromConfig builds the upstream provider only when the mode
+// may call it, so replay mode works without any credentials or network.
+func newReplayClientFromConfig(cfg *config.LLMConfig) (interfaces.LLMClient, error) {
+	mode := ReplayMode(cfg.Replay.Mode)
+	if mode == "" || mode == ReplayModeReplay {
+		return NewReplayClient(cfg.Replay.Cassette, ReplayModeReplay, nil)
+	}
+
+	if cfg.Replay.Provider == "" || cfg.Replay.Provider == "replay" {
+		return nil, fmt.Errorf("replay mode %q requires an upstream provider", mode)
+	}
+	upstreamCfg := *cfg
+	upstreamCfg.Provider = cfg.Replay.Provider
+	upstream, err := NewClientFromConfig(&upstreamCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upstream provider: %w", err)
+	}
+	return NewReplayClient(cfg.Replay.Cassette, mode, upstream)
+}
+
+// newRouterFromConfig builds every provider named in the router configuration
+// and registers the router metrics with the default Prometheus registry.
+func newRouterFromConfig(cfg *config.LLMConfig) (interfaces.LLMClient, error) {
+	var providers []RouterProvider
+	for _, name := range cfg.Router.Providers {
+		if name == "router" {
+			return nil, fmt.Errorf("router cannot route to itself")
+		}
+		providerCfg := *cfg
+		providerCfg.Provider = name
+		c, err := NewClientFromConfig(&providerCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create router provider %s: %w", name, err)
+		}
+		providers = append(providers, RouterProvider{Name: name, Client: c, Model: defaultModel(cfg, name)})
+	}
+	return NewRouter(providers, &cfg.Router, NewRouterMetrics(prometheus.DefaultRegisterer))
+}
+
+// defaultModel returns the model a provider uses when a request names none
+func defaultModel(cfg *config.LLMConfig, provider string) string {
+	switch provider {
+	case "openai":
+		if cfg.OpenAI.Model != "" {
+			return cfg.OpenAI.Model
+		}
+		return defaultOpenAIModel
+	case "gemini":
+		// The Gemini client always uses gemini-pro
+		return "gemini-pro"
+	case "ollama":
+		if cfg.Ollama.Model != "" {
+			return cfg.Ollama.Model
+		}
+		return defaultOllamaModel
+	}
+	return ""
+}
@@ -0,0 +1,394 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
+)
+
+const (
+	defaultOllamaBaseURL        = "http://localhost:11434"
+	defaultOllamaModel          = "llama3.1"
+	defaultOllamaEmbeddingModel = "nomic-embed-text"
+	defaultOllamaTimeout        = 5 * time.Minute
+)
+
+// OllamaClient implements the LLMClient interface against a local Ollama
+// server using its native HTTP API, so no internet egress is required.
+type OllamaClient struct {
+	baseURL        string
+	model          string
+	embeddingModel string
+	timeout        time.Duration
+	httpClient     *http.Client
+	log            logger.Logger
+}
+
+// NewOllamaClient creates a new OllamaClient. Empty fields fall back to a
+// server on localhost and common default models.
+func NewOllamaClient(cfg *config.OllamaConfig) (*OllamaClient, error) {
+	c := &OllamaClient{
+		baseURL:        strings.TrimRight(cfg.BaseURL, "/"),
+		model:          cfg.Model,
+		embeddingModel: cfg.EmbeddingModel,
+		timeout:        cfg.Timeout,
+		// Streams can legitimately run for minutes; non-streaming calls are
+		// bounded with a context deadline instead of a client timeout.
+		httpClient: &http.Client{},
+		log:        logger.NewLogger("ollama-client"),
+	}
+	if c.baseURL == "" {
+		c.baseURL = defaultOllamaBaseURL
+	}
+	if !strings.HasPrefix(c.baseURL, "http://") && !strings.HasPrefix(c.baseURL, "https://") {
+		return nil, fmt.Errorf("invalid Ollama base URL %q", cfg.BaseURL)
+	}
+	if c.model == "" {
+		c.model = defaultOllamaModel
+	}
+	if c.embeddingModel == "" {
+		c.embeddingModel = defaultOllamaEmbeddingModel
+	}
+	if c.timeout <= 0 {
+		c.timeout = defaultOllamaTimeout
+	}
+	return c, nil
+}
+
+// ollamaMessage is a chat message in Ollama's wire format
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaTool struct {
+	Type     string                    `json:"type"`
+	Function interfaces.ToolDefinition `json:"function"`
+}
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   string          `json:"format,omitempty"`
+	Tools    []ollamaTool    `json:"tools,omitempty"`
+	Options  map[string]any  `json:"options,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+// SendMessage sends a request to the LLM and waits for a complete response.
+func (c *OllamaClient) SendMessage(ctx context.Context, req *interfaces.LLMRequest) (*interfaces.LLMResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	body, err := c.post(ctx, "/api/chat", c.toChatRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var resp ollamaChatResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode ollama response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("ollama error: %s", resp.Error)
+	}
+
+	toolCalls := fromOllamaToolCalls(resp.Message.ToolCalls)
+	return &interfaces.LLMResponse{
+		Message: interfaces.Message{
+			Role:      interfaces.RoleAssistant,
+			Content:   resp.Message.Content,
+			ToolCalls: toolCalls,
+		},
+		Usage:        resp.usage(),
+		FinishReason: finishReason(resp.DoneReason, toolCalls),
+	}, nil
+}
+
+// SendStreamingMessage sends a request and returns a channel for response chunks.
+// Ollama streams newline-delimited JSON objects; the last one carries the
+// token counts and is delivered with any tool calls in the final chunk.
+func (c *OllamaClient) SendStreamingMessage(ctx context.Context, req *interfaces.LLMRequest) (<-chan interfaces.StreamingChunk, error) {
+	body, err := c.post(ctx, "/api/chat", c.toChatRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+
+	chunkChan := make(chan interfaces.StreamingChunk)
+	go func() {
+		defer close(chunkChan)
+		defer body.Close()
+
+		send := func(chunk interfaces.StreamingChunk) bool {
+			select {
+			case chunkChan <- chunk:
+				return true
+			case <-ctx.Done():
+				c.log.Warn("Context cancelled during stream, stopping goroutine.")
+				return false
+			}
+		}
+
+		var toolCalls []interfaces.ToolCall
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var resp ollamaChatResponse
+			if err := json.Unmarshal(line, &resp); err != nil {
+				send(interfaces.StreamingChunk{Err: fmt.Errorf("failed to decode ollama stream: %w", err)})
+				return
+			}
+			if resp.Error != "" {
+				send(interfaces.StreamingChunk{Err: fmt.Errorf("ollama error: %s", resp.Error)})
+				return
+			}
+
+			// Ollama sends each tool call whole, never in fragments
+			toolCalls = append(toolCalls, fromOllamaToolCalls(resp.Message.ToolCalls)...)
+			if resp.Message.Content != "" {
+				if !send(interfaces.StreamingChunk{Content: resp.Message.Content}) {
+					return
+				}
+			}
+			if resp.Done {
+				renumberToolCalls(toolCalls)
+				usage := resp.usage()
+				send(interfaces.StreamingChunk{
+					ToolCalls:    toolCalls,
+					FinishReason: finishReason(resp.DoneReason, toolCalls),
+					Usage:        &usage,
+				})
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			c.log.Errorf("Error reading Ollama stream: %v", err)
+			send(interfaces.StreamingChunk{Err: fmt.Errorf("ollama stream error: %w", err)})
+			return
+		}
+		send(interfaces.StreamingChunk{Err: fmt.Errorf("ollama stream ended before completion")})
+	}()
+
+	return chunkChan, nil
+}
+
+// GenerateEmbedding generates vector embeddings.
+func (c *OllamaClient) GenerateEmbedding(ctx context.Context, req *interfaces.EmbeddingRequest) (*interfaces.EmbeddingResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	model := req.Model
+	if model == "" {
+		model = c.embeddingModel
+	}
+
+	body, err := c.post(ctx, "/api/embed", map[string]any{"model": model, "input": req.Input})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var resp struct {
+		Embeddings      [][]float32 `json:"embeddings"`
+		PromptEvalCount int         `json:"prompt_eval_count"`
+		Error           string      `json:"error"`
+	}
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode ollama embeddings: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("ollama error: %s", resp.Error)
+	}
+	if len(resp.Embeddings) != len(req.Input) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d inputs", len(resp.Embeddings), len(req.Input))
+	}
+
+	return &interfaces.EmbeddingResponse{
+		Embeddings: resp.Embeddings,
+		Usage: interfaces.UsageStats{
+			PromptTokens: resp.PromptEvalCount,
+			TotalTokens:  resp.PromptEvalCount,
+		},
+	}, nil
+}
+
+// Complete sends a single user prompt and returns the response content.
+func (c *OllamaClient) Complete(ctx context.Context, prompt string, options ...interfaces.LLMOption) (string, error) {
+	req := &interfaces.LLMRequest{
+		Model: c.model,
+		Messages: []interfaces.Message{
+			{Role: interfaces.RoleUser, Content: prompt},
+		},
+	}
+	for _, opt := range options {
+		opt(req)
+	}
+	resp, err := c.SendMessage(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return resp.Message.Content, nil
+}
+
+// post sends a JSON request and returns the body of a successful response
+func (c *OllamaClient) post(ctx context.Context, path string, payload any) (io.ReadCloser, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ollama request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("ollama request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		statusErr := &StatusError{Provider: "ollama", StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(msg, &apiErr) == nil && apiErr.Error != "" {
+			statusErr.Message = apiErr.Error
+		}
+		return nil, statusErr
+	}
+	return resp.Body, nil
+}
+
+// toChatRequest converts our request format to Ollama's chat request.
+func (c *OllamaClient) toChatRequest(req *interfaces.LLMRequest, stream bool) ollamaChatRequest {
+	model := req.Model
+	if model == "" {
+		model = c.model
+	}
+
+	// Ollama identifies tool results by tool name rather than call ID
+	toolNames := make(map[string]string)
+	messages := make([]ollamaMessage, len(req.Messages))
+	for i, m := range req.Messages {
+		msg := ollamaMessage{Role: m.Role, Content: m.Content}
+		for _, call := range m.ToolCalls {
+			toolNames[call.ID] = call.Name
+			var tc ollamaToolCall
+			tc.Function.Name = call.Name
+			tc.Function.Arguments = json.RawMessage(call.Arguments)
+			if !json.Valid(tc.Function.Arguments) {
+				tc.Function.Arguments = json.RawMessage("{}")
+			}
+			msg.ToolCalls = append(msg.ToolCalls, tc)
+		}
+		if m.Role == interfaces.RoleTool {
+			msg.ToolName = toolNames[m.ToolCallID]
+		}
+		messages[i] = msg
+	}
+
+	chatReq := ollamaChatRequest{
+		Model:    model,
+		Messages: messages,
+		Stream:   stream,
+		Options:  map[string]any{},
+	}
+	if req.Temperature != 0 {
+		chatReq.Options["temperature"] = req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		chatReq.Options["num_predict"] = req.MaxTokens
+	}
+	if req.ResponseFormat == "json_object" || req.ResponseFormat == "json" {
+		chatReq.Format = "json"
+	}
+	// Ollama has no tool_choice: "none" is honoured by not offering tools
+	if req.ToolChoice != interfaces.ToolChoiceNone {
+		for _, t := range req.Tools {
+			chatReq.Tools = append(chatReq.Tools, ollamaTool{Type: "function", Function: t})
+		}
+	}
+	return chatReq
+}
+
+func (r *ollamaChatResponse) usage() interfaces.UsageStats {
+	return interfaces.UsageStats{
+		PromptTokens:     r.PromptEvalCount,
+		CompletionTokens: r.EvalCount,
+		TotalTokens:      r.PromptEvalCount + r.EvalCount,
+	}
+}
+
+// fromOllamaToolCalls converts tool calls, assigning the IDs Ollama omits
+func fromOllamaToolCalls(calls []ollamaToolCall) []interfaces.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	out := make([]interfaces.ToolCall, len(calls))
+	for i, call := range calls {
+		args := string(call.Function.Arguments)
+		if args == "" || args == "null" {
+			args = "{}"
+		}
+		out[i] = interfaces.ToolCall{
+			ID:        fmt.Sprintf("call_%d", i),
+			Name:      call.Function.Name,
+			Arguments: args,
+		}
+	}
+	return out
+}
+
+// renumberToolCalls keeps IDs unique when calls arrive in separate stream messages
+func renumberToolCalls(calls []interfaces.ToolCall) {
+	for i := range calls {
+		calls[i].ID = fmt.Sprintf("call_%d", i)
+	}
+}
+
+// finishReason maps Ollama's done_reason to the OpenAI vocabulary used by
+// the rest of the code base
+func finishReason(doneReason string, toolCalls []interfaces.ToolCall) string {
+	if len(toolCalls) > 0 {
+		return "tool_calls"
+	}
+	if doneReason == "" {
+		return "stop"
+	}
+	return doneReason
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOllamaClient(t *testing.T, handler http.HandlerFunc) *OllamaClient {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewOllamaClient(&config.OllamaConfig{BaseURL: srv.URL})
+	require.NoError(t, err)
+	return c
+}
+
+func TestOllamaClient_SendMessage(t *testing.T) {
+	var got ollamaChatRequest
+	c := newTestOllamaClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_pods","arguments":{"namespace":"shop"}}}]},
+			"done":true,"done_reason":"stop","prompt_eval_count":12,"eval_count":4}`)
+	})
+
+	resp, err := c.SendMessage(context.Background(), &interfaces.LLMRequest{
+		Messages: []interfaces.Message{
+			{Role: interfaces.RoleUser, Content: "pods?"},
+			{Role: interfaces.RoleAssistant, ToolCalls: []interfaces.ToolCall{{ID: "call_0", Name: "get_nodes", Arguments: "{}"}}},
+			{Role: interfaces.RoleTool, ToolCallID: "call_0", Content: "[]"},
+		},
+		MaxTokens:      100,
+		ResponseFormat: "json_object",
+		Tools:          []interfaces.ToolDefinition{{Name: "get_pods"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, defaultOllamaModel, got.Model)
+	assert.False(t, got.Stream)
+	assert.Equal(t, "json", got.Format)
+	assert.EqualValues(t, 100, got.Options["num_predict"])
+	assert.Equal(t, "get_nodes", got.Messages[2].ToolName, "tool results are matched by name")
+	require.Len(t, got.Tools, 1)
+
+	assert.Equal(t, "tool_calls", resp.FinishReason)
+	assert.Equal(t, interfaces.UsageStats{PromptTokens: 12, CompletionTokens: 4, TotalTokens: 16}, resp.Usage)
+	assert.Equal(t, []interfaces.ToolCall{{ID: "call_0", Name: "get_pods", Arguments: `{"namespace":"shop"}`}}, resp.Message.ToolCalls)
+}
+
+func TestOllamaClient_Streaming(t *testing.T) {
+	c := newTestOllamaClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Redis "},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"is healthy"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":5,"eval_count":3}`)
+	})
+
+	chunks, err := c.SendStreamingMessage(context.Background(), &interfaces.LLMRequest{
+		Messages: []interfaces.Message{{Role: interfaces.RoleUser, Content: "status?"}},
+	})
+	require.NoError(t, err)
+
+	var content string
+	var last interfaces.StreamingChunk
+	for chunk := range chunks {
+		require.NoError(t, chunk.Err)
+		content += chunk.Content
+		last = chunk
+	}
+	assert.Equal(t, "Redis is healthy", content)
+	assert.Equal(t, "stop", last.FinishReason)
+	assert.Equal(t, 8, last.Usage.TotalTokens)
+}
+
+func TestOllamaClient_GenerateEmbeddingAndErrors(t *testing.T) {
+	c := newTestOllamaClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req["model"] == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"model \"missing\" not found, try pulling it first"}`)
+			return
+		}
+		assert.Equal(t, defaultOllamaEmbeddingModel, req["model"])
+		fmt.Fprint(w, `{"embeddings":[[0.1,0.2],[0.3,0.4]],"prompt_eval_count":6}`)
+	})
+
+	resp, err := c.GenerateEmbedding(context.Background(), &interfaces.EmbeddingRequest{Input: []string{"a", "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}, {0.3, 0.4}}, resp.Embeddings)
+	assert.Equal(t, 6, resp.Usage.TotalTokens)
+
+	_, err = c.GenerateEmbedding(context.Background(), &interfaces.EmbeddingRequest{Input: []string{"a"}, Model: "missing"})
+	assert.ErrorContains(t, err, "ollama returned 404: model \"missing\" not found")
+}
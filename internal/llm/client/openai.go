@@ -2,40 +2,68 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
 
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
 	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
 	openai "github.com/sashabaranov/go-openai"
 )
 
-// OpenAIClient implements the LLMClient interface using the OpenAI API.
+const (
+	defaultOpenAIModel          = "gpt-4"
+	defaultOpenAIEmbeddingModel = string(openai.SmallEmbedding3)
+)
+
+// OpenAIClient implements the LLMClient interface using the OpenAI API or any
+// OpenAI-compatible server (vLLM, Ollama, llama.cpp) reachable at a base URL.
 type OpenAIClient struct {
-	client *openai.Client
+	client         *openai.Client
+	model          string
+	embeddingModel string
+	log            logger.Logger
 }
 
 // NewOpenAIClient creates a new OpenAIClient.
 func NewOpenAIClient(apiKey string) (*OpenAIClient, error) {
-	client := openai.NewClient(apiKey)
-	return &OpenAIClient{client: client}, nil
+	return NewOpenAIClientWithConfig(&config.OpenAIConfig{APIKey: apiKey})
 }
 
-// SendMessage sends a request to the LLM and waits for a complete response.
-func (c *OpenAIClient) SendMessage(ctx context.Context, req *interfaces.LLMRequest) (*interfaces.LLMResponse, error) {
-	messages := make([]openai.ChatCompletionMessage, len(req.Messages))
-	for i, m := range req.Messages {
-		messages[i] = openai.ChatCompletionMessage{
-			Role:    m.Role,
-			Content: m.Content,
-		}
+// NewOpenAIClientWithConfig creates a new OpenAIClient from configuration. The
+// API key may be empty when BaseURL points at a local server without auth.
+func NewOpenAIClientWithConfig(cfg *config.OpenAIConfig) (*OpenAIClient, error) {
+	if cfg.APIKey == "" && cfg.BaseURL == "" {
+		return nil, errors.New("OpenAI API key cannot be empty")
 	}
 
-	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model:       req.Model,
-		Messages:    messages,
-		Temperature: float32(req.Temperature), // Cast might be needed depending on go-openai version, it usually takes float32
-		MaxTokens:   req.MaxTokens,
-	})
+	clientCfg := openai.DefaultConfig(cfg.APIKey)
+	if cfg.BaseURL != "" {
+		clientCfg.BaseURL = cfg.BaseURL
+	}
 
+	model := cfg.Model
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	embeddingModel := cfg.EmbeddingModel
+	if embeddingModel == "" {
+		embeddingModel = defaultOpenAIEmbeddingModel
+	}
+
+	return &OpenAIClient{
+		client:         openai.NewClientWithConfig(clientCfg),
+		model:          model,
+		embeddingModel: embeddingModel,
+		log:            logger.NewLogger("openai-client"),
+	}, nil
+}
+
+// SendMessage sends a request to the LLM and waits for a complete response.
+func (c *OpenAIClient) SendMessage(ctx context.Context, req *interfaces.LLMRequest) (*interfaces.LLMResponse, error) {
+	resp, err := c.client.CreateChatCompletion(ctx, c.toChatRequest(req))
 	if err != nil {
 		return nil, fmt.Errorf("openai completion error: %w", err)
 	}
@@ -44,42 +72,280 @@ func (c *OpenAIClient) SendMessage(ctx context.Context, req *interfaces.LLMReque
 		return nil, fmt.Errorf("openai returned no choices")
 	}
 
+	choice := resp.Choices[0]
 	return &interfaces.LLMResponse{
 		Message: interfaces.Message{
-			Role:    resp.Choices[0].Message.Role,
-			Content: resp.Choices[0].Message.Content,
-		},
-		Usage: interfaces.UsageStats{
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
+			Role:      choice.Message.Role,
+			Content:   choice.Message.Content,
+			ToolCalls: fromOpenAIToolCalls(choice.Message.ToolCalls),
 		},
+		Usage:        fromOpenAIUsage(resp.Usage),
+		FinishReason: string(choice.FinishReason),
 	}, nil
 }
 
 // SendStreamingMessage sends a request and returns a channel for response chunks.
+// Content is forwarded as it arrives; tool call fragments are assembled and
+// delivered with the finish reason and usage in the last chunk.
 func (c *OpenAIClient) SendStreamingMessage(ctx context.Context, req *interfaces.LLMRequest) (<-chan interfaces.StreamingChunk, error) {
-	// TODO: Implement streaming
-	return nil, fmt.Errorf("streaming not implemented")
+	chatReq := c.toChatRequest(req)
+	chatReq.Stream = true
+	chatReq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+
+	stream, err := c.client.CreateChatCompletionStream(ctx, chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("openai stream error: %w", err)
+	}
+
+	chunkChan := make(chan interfaces.StreamingChunk)
+	go func() {
+		defer close(chunkChan)
+		defer stream.Close()
+
+		send := func(chunk interfaces.StreamingChunk) bool {
+			select {
+			case chunkChan <- chunk:
+				return true
+			case <-ctx.Done():
+				c.log.Warn("Context cancelled during stream, stopping goroutine.")
+				return false
+			}
+		}
+
+		calls := newToolCallAccumulator()
+		final := interfaces.StreamingChunk{}
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				final.ToolCalls = calls.result()
+				if final.FinishReason != "" || final.Usage != nil || len(final.ToolCalls) > 0 {
+					send(final)
+				}
+				return
+			}
+			if err != nil {
+				c.log.Errorf("Error receiving OpenAI stream chunk: %v", err)
+				send(interfaces.StreamingChunk{Err: fmt.Errorf("openai stream error: %w", err)})
+				return
+			}
+
+			if resp.Usage != nil {
+				usage := fromOpenAIUsage(*resp.Usage)
+				final.Usage = &usage
+			}
+			if len(resp.Choices) == 0 {
+				continue
+			}
+
+			choice := resp.Choices[0]
+			calls.add(choice.Delta.ToolCalls)
+			if choice.FinishReason != "" {
+				final.FinishReason = string(choice.FinishReason)
+			}
+			if choice.Delta.Content != "" {
+				if !send(interfaces.StreamingChunk{Content: choice.Delta.Content}) {
+					return
+				}
+			}
+		}
+	}()
+
+	return chunkChan, nil
 }
 
 // GenerateEmbedding generates vector embeddings.
 func (c *OpenAIClient) GenerateEmbedding(ctx context.Context, req *interfaces.EmbeddingRequest) (*interfaces.EmbeddingResponse, error) {
-	// TODO: Implement embedding
-	return nil, fmt.Errorf("embedding not implemented")
+	model := req.Model
+	if model == "" {
+		model = c.embeddingModel
+	}
+
+	resp, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: req.Input,
+		Model: openai.EmbeddingModel(model),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("openai embedding error: %w", err)
+	}
+
+	// The API does not guarantee the order of data, only the index of each item
+	embeddings := make([][]float32, len(req.Input))
+	for _, e := range resp.Data {
+		if e.Index < 0 || e.Index >= len(embeddings) {
+			return nil, fmt.Errorf("openai returned embedding for unknown input %d", e.Index)
+		}
+		embeddings[e.Index] = e.Embedding
+	}
+	for i, e := range embeddings {
+		if e == nil {
+			return nil, fmt.Errorf("openai returned no embedding for input %d", i)
+		}
+	}
+
+	return &interfaces.EmbeddingResponse{
+		Embeddings: embeddings,
+		Usage:      fromOpenAIUsage(resp.Usage),
+	}, nil
 }
 
 // Legacy Complete method for backward compatibility if needed, but we should migrate.
 func (c *OpenAIClient) Complete(ctx context.Context, prompt string, options ...interfaces.LLMOption) (string, error) {
 	req := &interfaces.LLMRequest{
-		Model: "gpt-4", // Default
+		Model: c.model,
 		Messages: []interfaces.Message{
-			{Role: "user", Content: prompt},
+			{Role: interfaces.RoleUser, Content: prompt},
 		},
 	}
+	for _, opt := range options {
+		opt(req)
+	}
 	resp, err := c.SendMessage(ctx, req)
 	if err != nil {
 		return "", err
 	}
 	return resp.Message.Content, nil
 }
+
+// toChatRequest converts our request format to the go-openai request.
+func (c *OpenAIClient) toChatRequest(req *interfaces.LLMRequest) openai.ChatCompletionRequest {
+	model := req.Model
+	if model == "" {
+		model = c.model
+	}
+
+	messages := make([]openai.ChatCompletionMessage, len(req.Messages))
+	for i, m := range req.Messages {
+		messages[i] = openai.ChatCompletionMessage{
+			Role:       m.Role,
+			Content:    m.Content,
+			ToolCalls:  toOpenAIToolCalls(m.ToolCalls),
+			ToolCallID: m.ToolCallID,
+		}
+	}
+
+	chatReq := openai.ChatCompletionRequest{
+		Model:       model,
+		Messages:    messages,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	}
+
+	if req.ResponseFormat != "" {
+		chatReq.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatType(req.ResponseFormat),
+		}
+	}
+
+	for _, t := range req.Tools {
+		chatReq.Tools = append(chatReq.Tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		})
+	}
+	switch req.ToolChoice {
+	case "":
+	case interfaces.ToolChoiceAuto, interfaces.ToolChoiceNone, interfaces.ToolChoiceRequired:
+		chatReq.ToolChoice = req.ToolChoice
+	default:
+		chatReq.ToolChoice = openai.ToolChoice{
+			Type:     openai.ToolTypeFunction,
+			Function: openai.ToolFunction{Name: req.ToolChoice},
+		}
+	}
+
+	return chatReq
+}
+
+func toOpenAIToolCalls(calls []interfaces.ToolCall) []openai.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	out := make([]openai.ToolCall, len(calls))
+	for i, call := range calls {
+		out[i] = openai.ToolCall{
+			ID:   call.ID,
+			Type: openai.ToolTypeFunction,
+			Function: openai.FunctionCall{
+				Name:      call.Name,
+				Arguments: call.Arguments,
+			},
+		}
+	}
+	return out
+}
+
+func fromOpenAIToolCalls(calls []openai.ToolCall) []interfaces.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	out := make([]interfaces.ToolCall, len(calls))
+	for i, call := range calls {
+		out[i] = interfaces.ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		}
+	}
+	return out
+}
+
+func fromOpenAIUsage(u openai.Usage) interfaces.UsageStats {
+	return interfaces.UsageStats{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
+
+// toolCallAccumulator assembles tool calls streamed as fragments. The first
+// fragment of a call carries its index, ID and name; later fragments with the
+// same index append to the arguments.
+type toolCallAccumulator struct {
+	calls map[int]*interfaces.ToolCall
+}
+
+func newToolCallAccumulator() *toolCallAccumulator {
+	return &toolCallAccumulator{calls: make(map[int]*interfaces.ToolCall)}
+}
+
+func (a *toolCallAccumulator) add(deltas []openai.ToolCall) {
+	for i, d := range deltas {
+		index := i
+		if d.Index != nil {
+			index = *d.Index
+		}
+		call, ok := a.calls[index]
+		if !ok {
+			call = &interfaces.ToolCall{}
+			a.calls[index] = call
+		}
+		if d.ID != "" {
+			call.ID = d.ID
+		}
+		if d.Function.Name != "" {
+			call.Name = d.Function.Name
+		}
+		call.Arguments += d.Function.Arguments
+	}
+}
+
+func (a *toolCallAccumulator) result() []interfaces.ToolCall {
+	if len(a.calls) == 0 {
+		return nil
+	}
+	indexes := make([]int, 0, len(a.calls))
+	for i := range a.calls {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	out := make([]interfaces.ToolCall, 0, len(indexes))
+	for _, i := range indexes {
+		out = append(out, *a.calls[i])
+	}
+	return out
+}
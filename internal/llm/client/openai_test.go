@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOpenAIClient(t *testing.T, handler http.HandlerFunc) *OpenAIClient {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewOpenAIClientWithConfig(&config.OpenAIConfig{BaseURL: srv.URL + "/v1", Model: "local-model"})
+	require.NoError(t, err)
+	return c
+}
+
+func TestOpenAIClient_SendMessageWithTools(t *testing.T) {
+	var got map[string]any
+	c := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		fmt.Fprint(w, `{"choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","tool_calls":[
+			{"id":"call_1","type":"function","function":{"name":"get_pods","arguments":"{\"namespace\":\"shop\"}"}}]}}],
+			"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`)
+	})
+
+	resp, err := c.SendMessage(context.Background(), &interfaces.LLMRequest{
+		Messages:   []interfaces.Message{{Role: interfaces.RoleUser, Content: "how many pods?"}},
+		Tools:      []interfaces.ToolDefinition{{Name: "get_pods", Parameters: json.RawMessage(`{"type":"object"}`)}},
+		ToolChoice: "get_pods",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "local-model", got["model"])
+	assert.Equal(t, "get_pods", got["tools"].([]any)[0].(map[string]any)["function"].(map[string]any)["name"])
+	assert.Equal(t, "get_pods", got["tool_choice"].(map[string]any)["function"].(map[string]any)["name"])
+
+	assert.Equal(t, "tool_calls", resp.FinishReason)
+	assert.Equal(t, 15, resp.Usage.TotalTokens)
+	require.Len(t, resp.Message.ToolCalls, 1)
+	assert.Equal(t, interfaces.ToolCall{ID: "call_1", Name: "get_pods", Arguments: `{"namespace":"shop"}`}, resp.Message.ToolCalls[0])
+}
+
+func TestOpenAIClient_StreamingAssemblesToolCalls(t *testing.T) {
+	events := []string{
+		`{"choices":[{"index":0,"delta":{"role":"assistant","content":"Checking"}}]}`,
+		`{"choices":[{"index":0,"delta":{"content":" pods"}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_pods","arguments":"{\"name"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"space\":\"shop\"}"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":7,"completion_tokens":3,"total_tokens":10}}`,
+	}
+	c := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, true, req["stream"])
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			fmt.Fprintf(w, "data: %s\n\n", e)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	chunks, err := c.SendStreamingMessage(context.Background(), &interfaces.LLMRequest{
+		Messages: []interfaces.Message{{Role: interfaces.RoleUser, Content: "pods?"}},
+	})
+	require.NoError(t, err)
+
+	var content string
+	var last interfaces.StreamingChunk
+	for chunk := range chunks {
+		require.NoError(t, chunk.Err)
+		content += chunk.Content
+		last = chunk
+	}
+
+	assert.Equal(t, "Checking pods", content)
+	assert.Equal(t, "tool_calls", last.FinishReason)
+	require.NotNil(t, last.Usage)
+	assert.Equal(t, 10, last.Usage.TotalTokens)
+	assert.Equal(t, []interfaces.ToolCall{{ID: "call_1", Name: "get_pods", Arguments: `{"namespace":"shop"}`}}, last.ToolCalls)
+}
+
+func TestOpenAIClient_GenerateEmbeddingOrdersByIndex(t *testing.T) {
+	c := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, defaultOpenAIEmbeddingModel, req["model"])
+		fmt.Fprint(w, `{"data":[{"index":1,"embedding":[0.2]},{"index":0,"embedding":[0.1]}],"usage":{"prompt_tokens":4,"total_tokens":4}}`)
+	})
+
+	resp, err := c.GenerateEmbedding(context.Background(), &interfaces.EmbeddingRequest{Input: []string{"a", "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1}, {0.2}}, resp.Embeddings)
+	assert.Equal(t, 4, resp.Usage.TotalTokens)
+}
+
+func TestNewOpenAIClientWithConfig_RequiresKeyOrBaseURL(t *testing.T) {
+	_, err := NewOpenAIClientWithConfig(&config.OpenAIConfig{})
+	assert.Error(t, err)
+}
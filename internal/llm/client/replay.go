@@ -0,0 +1,417 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
+)
+
+// ReplayMode selects how a ReplayClient uses its cassette
+type ReplayMode string
+
+const (
+	// ReplayModeRecord sends every request upstream and records a new cassette
+	ReplayModeRecord ReplayMode = "record"
+	// ReplayModeReplay serves requests from the cassette only
+	ReplayModeReplay ReplayMode = "replay"
+	// ReplayModeAuto serves recorded requests and records unknown ones
+	ReplayModeAuto ReplayMode = "auto"
+)
+
+// cassetteVersion is the current cassette file format
+const cassetteVersion = 1
+
+// Interaction kinds
+const (
+	interactionChat      = "chat"
+	interactionStream    = "stream"
+	interactionEmbedding = "embedding"
+)
+
+// ErrCassetteMiss is returned in replay mode for a request that was never recorded
+var ErrCassetteMiss = errors.New("no recorded interaction matches the request")
+
+// Cassette is the on-disk record of LLM interactions
+type Cassette struct {
+	Version      int            `json:"version"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its outcome. Exactly one of the
+// response fields is set, according to Kind, unless the call failed.
+type Interaction struct {
+	Kind              string                        `json:"kind"`
+	Request           *interfaces.LLMRequest        `json:"request,omitempty"`
+	EmbeddingRequest  *interfaces.EmbeddingRequest  `json:"embedding_request,omitempty"`
+	Response          *interfaces.LLMResponse       `json:"response,omitempty"`
+	Chunks            []interfaces.StreamingChunk   `json:"chunks,omitempty"`
+	EmbeddingResponse *interfaces.EmbeddingResponse `json:"embedding_response,omitempty"`
+	Error             string                        `json:"error,omitempty"`
+}
+
+// ReplayClient implements the LLMClient interface on top of a cassette file.
+// It records exchanges with an upstream client and serves them back
+// deterministically, so LLM-driven components can be regression-tested
+// offline. Requests match on their full content; identical requests recorded
+// several times are replayed in recording order, the last one repeating.
+type ReplayClient struct {
+	path     string
+	mode     ReplayMode
+	upstream interfaces.LLMClient
+	log      logger.Logger
+
+	mu       sync.Mutex
+	cassette *Cassette
+	index    map[string][]*Interaction
+	served   map[string]int
+}
+
+// NewReplayClient creates a ReplayClient. Record and auto modes need an
+// upstream client; replay mode requires the cassette to exist.
+func NewReplayClient(path string, mode ReplayMode, upstream interfaces.LLMClient) (*ReplayClient, error) {
+	if path == "" {
+		return nil, errors.New("replay cassette path cannot be empty")
+	}
+	if mode == "" {
+		mode = ReplayModeReplay
+	}
+
+	c := &ReplayClient{
+		path:     path,
+		mode:     mode,
+		upstream: upstream,
+		log:      logger.NewLogger("replay-client"),
+		cassette: &Cassette{Version: cassetteVersion},
+		index:    make(map[string][]*Interaction),
+		served:   make(map[string]int),
+	}
+
+	switch mode {
+	case ReplayModeRecord:
+		if upstream == nil {
+			return nil, errors.New("record mode requires an upstream LLM client")
+		}
+		return c, nil
+	case ReplayModeAuto:
+		if upstream == nil {
+			return nil, errors.New("auto mode requires an upstream LLM client")
+		}
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return c, nil
+		}
+	case ReplayModeReplay:
+	default:
+		return nil, fmt.Errorf("unknown replay mode: %s", mode)
+	}
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// SendMessage serves or records a complete response.
+func (c *ReplayClient) SendMessage(ctx context.Context, req *interfaces.LLMRequest) (*interfaces.LLMResponse, error) {
+	key, err := chatKey(interactionChat, req)
+	if err != nil {
+		return nil, err
+	}
+	if it, ok := c.lookup(key); ok {
+		if it.Error != "" {
+			return nil, errors.New(it.Error)
+		}
+		return snapshot(it.Response), nil
+	}
+	if c.mode == ReplayModeReplay {
+		return nil, fmt.Errorf("%w: chat request for model %q", ErrCassetteMiss, req.Model)
+	}
+
+	resp, err := c.upstream.SendMessage(ctx, req)
+	if ctx.Err() != nil {
+		return resp, err
+	}
+	it := &Interaction{Kind: interactionChat, Request: snapshot(req), Response: snapshot(resp)}
+	if err != nil {
+		it.Response, it.Error = nil, err.Error()
+	}
+	if recErr := c.record(key, it); recErr != nil {
+		return nil, recErr
+	}
+	return resp, err
+}
+
+// SendStreamingMessage serves or records a stream. Recorded chunks are
+// replayed in order; a recorded stream failure is replayed as the final chunk.
+func (c *ReplayClient) SendStreamingMessage(ctx context.Context, req *interfaces.LLMRequest) (<-chan interfaces.StreamingChunk, error) {
+	key, err := chatKey(interactionStream, req)
+	if err != nil {
+		return nil, err
+	}
+	if it, ok := c.lookup(key); ok {
+		return c.replayStream(ctx, it), nil
+	}
+	if c.mode == ReplayModeReplay {
+		return nil, fmt.Errorf("%w: streaming request for model %q", ErrCassetteMiss, req.Model)
+	}
+
+	upstream, err := c.upstream.SendStreamingMessage(ctx, req)
+	if err != nil {
+		if ctx.Err() == nil {
+			if recErr := c.record(key, &Interaction{Kind: interactionStream, Request: snapshot(req), Error: err.Error()}); recErr != nil {
+				return nil, recErr
+			}
+		}
+		return nil, err
+	}
+
+	out := make(chan interfaces.StreamingChunk)
+	go func() {
+		defer close(out)
+		it := &Interaction{Kind: interactionStream, Request: snapshot(req)}
+		consumerGone := false
+		for chunk := range upstream {
+			if chunk.Err != nil {
+				it.Error = chunk.Err.Error()
+			} else {
+				it.Chunks = append(it.Chunks, chunk)
+			}
+			if consumerGone {
+				continue
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				// Keep draining so the upstream goroutine can exit
+				consumerGone = true
+			}
+		}
+		// A cancelled stream is incomplete and must not become a fixture
+		if ctx.Err() != nil {
+			return
+		}
+		if err := c.record(key, it); err != nil {
+			c.log.Errorf("Failed to record stream: %v", err)
+		}
+	}()
+	return out, nil
+}
+
+// GenerateEmbedding serves or records embeddings.
+func (c *ReplayClient) GenerateEmbedding(ctx context.Context, req *interfaces.EmbeddingRequest) (*interfaces.EmbeddingResponse, error) {
+	key, err := requestKey(interactionEmbedding, req)
+	if err != nil {
+		return nil, err
+	}
+	if it, ok := c.lookup(key); ok {
+		if it.Error != "" {
+			return nil, errors.New(it.Error)
+		}
+		return snapshot(it.EmbeddingResponse), nil
+	}
+	if c.mode == ReplayModeReplay {
+		return nil, fmt.Errorf("%w: embedding request for %d inputs", ErrCassetteMiss, len(req.Input))
+	}
+
+	resp, err := c.upstream.GenerateEmbedding(ctx, req)
+	if ctx.Err() != nil {
+		return resp, err
+	}
+	it := &Interaction{Kind: interactionEmbedding, EmbeddingRequest: snapshot(req), EmbeddingResponse: snapshot(resp)}
+	if err != nil {
+		it.EmbeddingResponse, it.Error = nil, err.Error()
+	}
+	if recErr := c.record(key, it); recErr != nil {
+		return nil, recErr
+	}
+	return resp, err
+}
+
+// Complete sends a single user prompt and returns the response content.
+func (c *ReplayClient) Complete(ctx context.Context, prompt string, options ...interfaces.LLMOption) (string, error) {
+	req := &interfaces.LLMRequest{
+		Messages: []interfaces.Message{
+			{Role: interfaces.RoleUser, Content: prompt},
+		},
+	}
+	for _, opt := range options {
+		opt(req)
+	}
+	resp, err := c.SendMessage(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return resp.Message.Content, nil
+}
+
+// Interactions returns the number of interactions in the cassette
+func (c *ReplayClient) Interactions() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.cassette.Interactions)
+}
+
+func (c *ReplayClient) replayStream(ctx context.Context, it *Interaction) <-chan interfaces.StreamingChunk {
+	out := make(chan interfaces.StreamingChunk)
+	go func() {
+		defer close(out)
+		chunks := it.Chunks
+		if it.Error != "" {
+			chunks = append(append([]interfaces.StreamingChunk(nil), chunks...), interfaces.StreamingChunk{Err: errors.New(it.Error)})
+		}
+		for _, chunk := range chunks {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// lookup returns the next recorded interaction for key. Record mode never
+// replays, so a fresh cassette captures every call.
+func (c *ReplayClient) lookup(key string) (*Interaction, bool) {
+	if c.mode == ReplayModeRecord {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	recorded := c.index[key]
+	if len(recorded) == 0 {
+		return nil, false
+	}
+	n := c.served[key]
+	if n >= len(recorded) {
+		return recorded[len(recorded)-1], true
+	}
+	c.served[key] = n + 1
+	return recorded[n], true
+}
+
+// record appends an interaction and rewrites the cassette
+func (c *ReplayClient) record(key string, it *Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cassette.Interactions = append(c.cassette.Interactions, it)
+	c.index[key] = append(c.index[key], it)
+	return c.save()
+}
+
+func (c *ReplayClient) load() error {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return fmt.Errorf("failed to parse cassette %s: %w", c.path, err)
+	}
+	if cassette.Version != cassetteVersion {
+		return fmt.Errorf("unsupported cassette version %d in %s", cassette.Version, c.path)
+	}
+
+	for i, it := range cassette.Interactions {
+		key, err := it.key()
+		if err != nil {
+			return fmt.Errorf("invalid interaction %d in %s: %w", i, c.path, err)
+		}
+		c.index[key] = append(c.index[key], it)
+	}
+	c.cassette = &cassette
+	return nil
+}
+
+// save writes the cassette atomically; callers hold c.mu
+func (c *ReplayClient) save() error {
+	data, err := json.MarshalIndent(c.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if dir := filepath.Dir(c.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create cassette directory: %w", err)
+		}
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
+
+func (it *Interaction) key() (string, error) {
+	switch it.Kind {
+	case interactionChat, interactionStream:
+		if it.Request == nil {
+			return "", errors.New("missing request")
+		}
+		if it.Error == "" && it.Kind == interactionChat && it.Response == nil {
+			return "", errors.New("missing response")
+		}
+		return chatKey(it.Kind, it.Request)
+	case interactionEmbedding:
+		if it.EmbeddingRequest == nil {
+			return "", errors.New("missing embedding request")
+		}
+		if it.Error == "" && it.EmbeddingResponse == nil {
+			return "", errors.New("missing embedding response")
+		}
+		return requestKey(it.Kind, it.EmbeddingRequest)
+	}
+	return "", fmt.Errorf("unknown interaction kind %q", it.Kind)
+}
+
+// chatKey ignores the Stream flag, which the kind already captures
+func chatKey(kind string, req *interfaces.LLMRequest) (string, error) {
+	normalized := *req
+	normalized.Stream = false
+	return requestKey(kind, &normalized)
+}
+
+// requestKey hashes the canonical JSON encoding of a request. Decoding into
+// generic values first sorts the keys of embedded raw JSON such as tool
+// schemas, so a request hashes the same before and after a cassette round trip.
+func requestKey(kind string, req any) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	if data, err = json.Marshal(generic); err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	sum := sha256.Sum256(append([]byte(kind+"\n"), data...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// snapshot deep-copies a value through JSON so later changes by the caller
+// cannot alter what was recorded
+func snapshot[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out T
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return &out
+}
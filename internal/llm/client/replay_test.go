@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedClient answers each call with the next scripted reply
+type scriptedClient struct {
+	replies []string
+	calls   int
+}
+
+func (s *scriptedClient) SendMessage(ctx context.Context, req *interfaces.LLMRequest) (*interfaces.LLMResponse, error) {
+	if s.calls >= len(s.replies) {
+		return nil, errors.New("upstream unavailable")
+	}
+	s.calls++
+	return &interfaces.LLMResponse{
+		Message: interfaces.Message{Role: interfaces.RoleAssistant, Content: s.replies[s.calls-1]},
+		Usage:   interfaces.UsageStats{TotalTokens: 10},
+	}, nil
+}
+
+func (s *scriptedClient) SendStreamingMessage(ctx context.Context, req *interfaces.LLMRequest) (<-chan interfaces.StreamingChunk, error) {
+	ch := make(chan interfaces.StreamingChunk, 3)
+	ch <- interfaces.StreamingChunk{Content: "hel"}
+	ch <- interfaces.StreamingChunk{Content: "lo"}
+	ch <- interfaces.StreamingChunk{FinishReason: "stop", Usage: &interfaces.UsageStats{TotalTokens: 2}}
+	close(ch)
+	return ch, nil
+}
+
+func (s *scriptedClient) GenerateEmbedding(ctx context.Context, req *interfaces.EmbeddingRequest) (*interfaces.EmbeddingResponse, error) {
+	return &interfaces.EmbeddingResponse{Embeddings: [][]float32{{1, 2}}}, nil
+}
+
+func (s *scriptedClient) Complete(ctx context.Context, prompt string, options ...interfaces.LLMOption) (string, error) {
+	return "", errors.New("not used")
+}
+
+func collect(t *testing.T, ch <-chan interfaces.StreamingChunk) (string, interfaces.StreamingChunk) {
+	var content string
+	var last interfaces.StreamingChunk
+	for chunk := range ch {
+		require.NoError(t, chunk.Err)
+		content += chunk.Content
+		last = chunk
+	}
+	return content, last
+}
+
+func TestReplayClient_RecordsAndReplays(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cassettes", "diagnosis.json")
+	ask := func() *interfaces.LLMRequest {
+		return &interfaces.LLMRequest{
+			Model:    "gpt-4",
+			Messages: []interfaces.Message{{Role: interfaces.RoleUser, Content: "diagnose redis"}},
+			Tools:    []interfaces.ToolDefinition{{Name: "info", Parameters: []byte(`{"type":"object","properties":{}}`)}},
+		}
+	}
+
+	upstream := &scriptedClient{replies: []string{"first", "second"}}
+	recorder, err := NewReplayClient(path, ReplayModeRecord, upstream)
+	require.NoError(t, err)
+
+	for _, want := range []string{"first", "second"} {
+		resp, err := recorder.SendMessage(ctx, ask())
+		require.NoError(t, err)
+		assert.Equal(t, want, resp.Message.Content)
+	}
+	_, err = recorder.SendMessage(ctx, &interfaces.LLMRequest{Model: "gpt-4"})
+	require.EqualError(t, err, "upstream unavailable")
+	content, _ := collect(t, mustStream(t, recorder, ask()))
+	assert.Equal(t, "hello", content)
+	_, err = recorder.GenerateEmbedding(ctx, &interfaces.EmbeddingRequest{Input: []string{"x"}})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return recorder.Interactions() == 5 }, time.Second, 10*time.Millisecond)
+
+	// A fresh client without any upstream serves the cassette
+	player, err := NewClientFromConfig(&config.LLMConfig{Provider: "replay", Replay: config.ReplayConfig{Cassette: path}})
+	require.NoError(t, err)
+
+	for _, want := range []string{"first", "second", "second"} {
+		resp, err := player.SendMessage(ctx, ask())
+		require.NoError(t, err)
+		assert.Equal(t, want, resp.Message.Content, "identical requests replay in order, then repeat the last")
+	}
+	_, err = player.SendMessage(ctx, &interfaces.LLMRequest{Model: "gpt-4"})
+	assert.EqualError(t, err, "upstream unavailable", "recorded failures are replayed")
+
+	content, last := collect(t, mustStream(t, player, ask()))
+	assert.Equal(t, "hello", content)
+	assert.Equal(t, "stop", last.FinishReason)
+	assert.Equal(t, 2, last.Usage.TotalTokens)
+
+	emb, err := player.GenerateEmbedding(ctx, &interfaces.EmbeddingRequest{Input: []string{"x"}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 2}}, emb.Embeddings)
+
+	_, err = player.SendMessage(ctx, &interfaces.LLMRequest{Model: "gpt-4", Temperature: 0.5})
+	assert.ErrorIs(t, err, ErrCassetteMiss)
+}
+
+func TestReplayClient_AutoModeRecordsOnlyMisses(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "auto.json")
+	upstream := &scriptedClient{replies: []string{"a", "b"}}
+
+	c, err := NewReplayClient(path, ReplayModeAuto, upstream)
+	require.NoError(t, err)
+	out, err := c.Complete(ctx, "one")
+	require.NoError(t, err)
+	assert.Equal(t, "a", out)
+
+	c, err = NewReplayClient(path, ReplayModeAuto, upstream)
+	require.NoError(t, err)
+	out, err = c.Complete(ctx, "one")
+	require.NoError(t, err)
+	assert.Equal(t, "a", out)
+	out, err = c.Complete(ctx, "two")
+	require.NoError(t, err)
+	assert.Equal(t, "b", out)
+	assert.Equal(t, 2, upstream.calls)
+	assert.Equal(t, 2, c.Interactions())
+}
+
+func TestNewReplayClient_Validation(t *testing.T) {
+	_, err := NewReplayClient(filepath.Join(t.TempDir(), "missing.json"), ReplayModeReplay, nil)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = NewReplayClient("x.json", ReplayModeRecord, nil)
+	assert.Error(t, err)
+
+	_, err = NewClientFromConfig(&config.LLMConfig{Provider: "replay", Replay: config.ReplayConfig{Cassette: "x.json", Mode: "record", Provider: "replay"}})
+	assert.Error(t, err)
+}
+
+func mustStream(t *testing.T, c interfaces.LLMClient, req *interfaces.LLMRequest) <-chan interfaces.StreamingChunk {
+	ch, err := c.SendStreamingMessage(context.Background(), req)
+	require.NoError(t, err)
+	return ch
+}
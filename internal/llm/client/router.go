@@ -0,0 +1,451 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
+	"github.com/sony/gobreaker"
+)
+
+const (
+	defaultRouterMaxRetries      = 2
+	defaultRouterInitialBackoff  = 500 * time.Millisecond
+	defaultRouterMaxBackoff      = 10 * time.Second
+	defaultRouterBreakerFailures = 5
+	defaultRouterBreakerTimeout  = 30 * time.Second
+)
+
+// ErrBudgetExceeded is returned when a call would exceed a token budget
+var ErrBudgetExceeded = errors.New("LLM token budget exceeded")
+
+// RouterProvider is one upstream of a Router
+type RouterProvider struct {
+	Name   string
+	Client interfaces.LLMClient
+	// Model is the model the provider serves requests with. It labels
+	// metrics and selects pricing, and replaces the model a request names
+	// when the call falls back to this provider.
+	Model string
+}
+
+// Router implements the LLMClient interface on top of several providers. A
+// call goes to the first provider whose circuit breaker is closed; retryable
+// failures are retried with jittered exponential backoff, and once retries
+// are spent the next provider is tried. Token usage is charged against the
+// budgets matching the tenant and feature tagged on the context.
+//
+// Streams fail over only until the first chunk arrives. Embeddings never
+// fail over, because vectors from different models are not comparable; they
+// use the first provider with retries.
+type Router struct {
+	providers []*routedProvider
+	cfg       config.LLMRouterConfig
+	budgets   *budgetTracker
+	metrics   *RouterMetrics
+	log       logger.Logger
+
+	// sleep is replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+type routedProvider struct {
+	RouterProvider
+	breaker *gobreaker.CircuitBreaker
+	// primary is set on the first provider, whose models requests name
+	primary bool
+}
+
+// NewRouter creates a Router over providers in fallback order. Metrics may be
+// nil to disable instrumentation.
+func NewRouter(providers []RouterProvider, cfg *config.LLMRouterConfig, metrics *RouterMetrics) (*Router, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("router requires at least one provider")
+	}
+
+	r := &Router{
+		cfg:     *cfg,
+		budgets: newBudgetTracker(cfg.Budgets),
+		metrics: metrics,
+		log:     logger.NewLogger("llm-router"),
+		sleep:   sleepContext,
+	}
+	if r.cfg.MaxRetries < 0 {
+		r.cfg.MaxRetries = 0
+	} else if r.cfg.MaxRetries == 0 {
+		r.cfg.MaxRetries = defaultRouterMaxRetries
+	}
+	if r.cfg.InitialBackoff <= 0 {
+		r.cfg.InitialBackoff = defaultRouterInitialBackoff
+	}
+	if r.cfg.MaxBackoff <= 0 {
+		r.cfg.MaxBackoff = defaultRouterMaxBackoff
+	}
+	if r.cfg.BreakerFailures == 0 {
+		r.cfg.BreakerFailures = defaultRouterBreakerFailures
+	}
+	if r.cfg.BreakerTimeout <= 0 {
+		r.cfg.BreakerTimeout = defaultRouterBreakerTimeout
+	}
+
+	for i, p := range providers {
+		if p.Client == nil {
+			return nil, fmt.Errorf("router provider %s has no client", p.Name)
+		}
+		r.providers = append(r.providers, &routedProvider{
+			RouterProvider: p,
+			breaker:        r.newBreaker(p.Name),
+			primary:        i == 0,
+		})
+	}
+	return r, nil
+}
+
+func (r *Router) newBreaker(name string) *gobreaker.CircuitBreaker {
+	threshold := r.cfg.BreakerFailures
+	return gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        name,
+		MaxRequests: 1,
+		Timeout:     r.cfg.BreakerTimeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= threshold
+		},
+		// The caller giving up or sending a bad request says nothing about
+		// the health of the provider
+		IsSuccessful: func(err error) bool {
+			return err == nil || errors.Is(err, context.Canceled) || isRequestError(err)
+		},
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			r.log.Warnf("LLM provider %s circuit breaker changed from %s to %s", name, from, to)
+		},
+	})
+}
+
+// SendMessage sends the request to the first healthy provider.
+func (r *Router) SendMessage(ctx context.Context, req *interfaces.LLMRequest) (*interfaces.LLMResponse, error) {
+	tenant, feature := interfaces.TenantFromContext(ctx), interfaces.FeatureFromContext(ctx)
+	if err := r.checkBudget(tenant, feature); err != nil {
+		return nil, err
+	}
+
+	var resp *interfaces.LLMResponse
+	p, err := r.route(ctx, req.Model, "chat", func(p *routedProvider, model string) error {
+		var err error
+		resp, err = p.Client.SendMessage(ctx, withModel(req, model))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.charge(p, req.Model, tenant, feature, resp.Usage)
+	return resp, nil
+}
+
+// SendStreamingMessage opens a stream on the first healthy provider whose
+// stream does not fail before its first chunk.
+func (r *Router) SendStreamingMessage(ctx context.Context, req *interfaces.LLMRequest) (<-chan interfaces.StreamingChunk, error) {
+	tenant, feature := interfaces.TenantFromContext(ctx), interfaces.FeatureFromContext(ctx)
+	if err := r.checkBudget(tenant, feature); err != nil {
+		return nil, err
+	}
+
+	var upstream <-chan interfaces.StreamingChunk
+	var first *interfaces.StreamingChunk
+	p, err := r.route(ctx, req.Model, "stream", func(p *routedProvider, model string) error {
+		stream, err := p.Client.SendStreamingMessage(ctx, withModel(req, model))
+		if err != nil {
+			return err
+		}
+		// A stream failing before its first chunk is retried like a failed call
+		select {
+		case chunk, ok := <-stream:
+			if ok && chunk.Err != nil {
+				go drainStream(stream)
+				return chunk.Err
+			}
+			upstream, first = stream, nil
+			if ok {
+				first = &chunk
+			}
+			return nil
+		case <-ctx.Done():
+			go drainStream(stream)
+			return ctx.Err()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan interfaces.StreamingChunk)
+	go func() {
+		defer close(out)
+		var usage *interfaces.UsageStats
+		consumerGone := false
+		forward := func(chunk interfaces.StreamingChunk) {
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+			if consumerGone {
+				return
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				// Keep draining so the provider goroutine can exit
+				consumerGone = true
+			}
+		}
+		if first != nil {
+			forward(*first)
+		}
+		for chunk := range upstream {
+			forward(chunk)
+		}
+		if usage != nil {
+			r.charge(p, req.Model, tenant, feature, *usage)
+		}
+	}()
+	return out, nil
+}
+
+// drainStream reads an abandoned stream to its end so that its provider
+// goroutine can exit.
+func drainStream(stream <-chan interfaces.StreamingChunk) {
+	for range stream {
+	}
+}
+
+// GenerateEmbedding generates embeddings with the first provider.
+func (r *Router) GenerateEmbedding(ctx context.Context, req *interfaces.EmbeddingRequest) (*interfaces.EmbeddingResponse, error) {
+	tenant, feature := interfaces.TenantFromContext(ctx), interfaces.FeatureFromContext(ctx)
+	if err := r.checkBudget(tenant, feature); err != nil {
+		return nil, err
+	}
+
+	p := r.providers[0]
+	var resp *interfaces.EmbeddingResponse
+	err := r.tryProvider(ctx, p, req.Model, "embedding", func(p *routedProvider, _ string) error {
+		var err error
+		resp, err = p.Client.GenerateEmbedding(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.charge(p, req.Model, tenant, feature, resp.Usage)
+	return resp, nil
+}
+
+// Complete sends a single user prompt and returns the response content.
+func (r *Router) Complete(ctx context.Context, prompt string, options ...interfaces.LLMOption) (string, error) {
+	req := &interfaces.LLMRequest{
+		Messages: []interfaces.Message{
+			{Role: interfaces.RoleUser, Content: prompt},
+		},
+	}
+	for _, opt := range options {
+		opt(req)
+	}
+	resp, err := r.SendMessage(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return resp.Message.Content, nil
+}
+
+// route tries providers in order and returns the one that succeeded
+func (r *Router) route(ctx context.Context, model, op string, call func(p *routedProvider, model string) error) (*routedProvider, error) {
+	var errs []error
+	for i, p := range r.providers {
+		err := r.tryProvider(ctx, p, model, op, call)
+		if err == nil {
+			return p, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+		if i < len(r.providers)-1 {
+			r.log.Warnf("LLM provider %s failed, falling back to %s: %v", p.Name, r.providers[i+1].Name, err)
+			r.metrics.recordFallback(p.Name)
+		}
+	}
+	return nil, fmt.Errorf("all LLM providers failed: %w", errors.Join(errs...))
+}
+
+// tryProvider calls one provider through its breaker, retrying retryable
+// errors. call receives the model the provider should serve the request with.
+func (r *Router) tryProvider(ctx context.Context, p *routedProvider, model, op string, call func(p *routedProvider, model string) error) error {
+	model = p.modelFor(model)
+	label := modelLabel(model)
+	var err error
+	for attempt := 0; attempt <= r.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if sleepErr := r.sleep(ctx, r.backoff(attempt)); sleepErr != nil {
+				return sleepErr
+			}
+		}
+
+		start := time.Now()
+		_, err = p.breaker.Execute(func() (interface{}, error) {
+			return nil, call(p, model)
+		})
+		if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+			r.metrics.recordRequest(p.Name, label, op, "circuit_open", 0)
+			return fmt.Errorf("circuit open: %w", err)
+		}
+		if err == nil {
+			r.metrics.recordRequest(p.Name, label, op, "success", time.Since(start))
+			return nil
+		}
+		r.metrics.recordRequest(p.Name, label, op, "error", time.Since(start))
+		if !IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+		if attempt < r.cfg.MaxRetries {
+			r.log.Debugf("Retrying LLM provider %s after attempt %d: %v", p.Name, attempt+1, err)
+		}
+	}
+	return err
+}
+
+// backoff returns a full-jitter exponential delay for a retry attempt
+func (r *Router) backoff(attempt int) time.Duration {
+	d := r.cfg.InitialBackoff << uint(attempt-1)
+	if d <= 0 || d > r.cfg.MaxBackoff {
+		d = r.cfg.MaxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (r *Router) checkBudget(tenant, feature string) error {
+	if err := r.budgets.check(tenant, feature); err != nil {
+		r.metrics.recordBudgetRejection(tenant, feature)
+		return err
+	}
+	return nil
+}
+
+func (r *Router) charge(p *routedProvider, model, tenant, feature string, usage interfaces.UsageStats) {
+	model = p.modelFor(model)
+	r.budgets.charge(tenant, feature, usage.TotalTokens)
+	r.metrics.recordUsage(p.Name, modelLabel(model), tenant, feature, usage, r.cost(model, usage))
+}
+
+// cost returns the USD cost of usage, or 0 for models without pricing
+func (r *Router) cost(model string, usage interfaces.UsageStats) float64 {
+	price, ok := r.cfg.Pricing[model]
+	if !ok {
+		return 0
+	}
+	return float64(usage.PromptTokens)/1000*price.PromptPer1K + float64(usage.CompletionTokens)/1000*price.CompletionPer1K
+}
+
+// modelFor maps the model a request names, one of the primary provider's
+// models, to the model p serves the request with. A fallback provider
+// does not know the primary's models and uses its own.
+func (p *routedProvider) modelFor(model string) string {
+	if model != "" && (p.primary || p.Model == "") {
+		return model
+	}
+	return p.Model
+}
+
+// modelLabel names model in metrics, where the provider's own default has
+// no name
+func modelLabel(model string) string {
+	if model == "" {
+		return "default"
+	}
+	return model
+}
+
+// withModel returns req, or a copy of it asking for model
+func withModel(req *interfaces.LLMRequest, model string) *interfaces.LLMRequest {
+	if req.Model == model {
+		return req
+	}
+	copied := *req
+	copied.Model = model
+	return &copied
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// budgetTracker enforces token budgets over fixed windows. A call is refused
+// once a matching budget is spent; the call that crosses the limit is allowed
+// to finish, since its usage is only known afterwards.
+type budgetTracker struct {
+	mu      sync.Mutex
+	budgets []*budgetState
+	now     func() time.Time
+}
+
+type budgetState struct {
+	config.TokenBudgetConfig
+	used        int
+	windowStart time.Time
+}
+
+func newBudgetTracker(budgets []config.TokenBudgetConfig) *budgetTracker {
+	t := &budgetTracker{now: time.Now}
+	for _, b := range budgets {
+		if b.Tokens > 0 {
+			t.budgets = append(t.budgets, &budgetState{TokenBudgetConfig: b, windowStart: t.now()})
+		}
+	}
+	return t
+}
+
+func (t *budgetTracker) check(tenant, feature string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, b := range t.matching(tenant, feature) {
+		if b.used >= b.Tokens {
+			return fmt.Errorf("%w: %d of %d tokens used by tenant %q feature %q", ErrBudgetExceeded, b.used, b.Tokens, b.Tenant, b.Feature)
+		}
+	}
+	return nil
+}
+
+func (t *budgetTracker) charge(tenant, feature string, tokens int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, b := range t.matching(tenant, feature) {
+		b.used += tokens
+	}
+}
+
+// matching returns the budgets that apply, rolling over expired windows;
+// callers hold t.mu
+func (t *budgetTracker) matching(tenant, feature string) []*budgetState {
+	now := t.now()
+	var out []*budgetState
+	for _, b := range t.budgets {
+		if (b.Tenant != "" && b.Tenant != tenant) || (b.Feature != "" && b.Feature != feature) {
+			continue
+		}
+		if b.Window > 0 && now.Sub(b.windowStart) >= b.Window {
+			b.used = 0
+			b.windowStart = now
+		}
+		out = append(out, b)
+	}
+	return out
+}
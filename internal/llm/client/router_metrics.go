@@ -0,0 +1,104 @@
+package client
+
+import (
+	"errors"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RouterMetrics holds the Prometheus collectors of a Router. All methods are
+// safe to call on a nil *RouterMetrics.
+type RouterMetrics struct {
+	requests         *prometheus.CounterVec
+	latency          *prometheus.HistogramVec
+	tokens           *prometheus.CounterVec
+	cost             *prometheus.CounterVec
+	featureTokens    *prometheus.CounterVec
+	fallbacks        *prometheus.CounterVec
+	budgetRejections *prometheus.CounterVec
+}
+
+// NewRouterMetrics creates the router collectors and registers them with reg.
+// Collectors already registered, e.g. by another router, are shared.
+func NewRouterMetrics(reg prometheus.Registerer) *RouterMetrics {
+	return &RouterMetrics{
+		requests: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ksa_llm_requests_total",
+			Help: "LLM provider calls by outcome (success, error, circuit_open).",
+		}, []string{"provider", "model", "operation", "outcome"})),
+		latency: register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ksa_llm_request_duration_seconds",
+			Help:    "Latency of LLM provider calls.",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		}, []string{"provider", "model", "operation"})),
+		tokens: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ksa_llm_tokens_total",
+			Help: "Tokens used per model, by type (prompt, completion).",
+		}, []string{"provider", "model", "type"})),
+		cost: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ksa_llm_cost_usd_total",
+			Help: "Estimated cost of LLM usage in USD per model.",
+		}, []string{"provider", "model"})),
+		featureTokens: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ksa_llm_feature_tokens_total",
+			Help: "Tokens used per tenant and feature.",
+		}, []string{"tenant", "feature"})),
+		fallbacks: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ksa_llm_fallbacks_total",
+			Help: "Calls that failed over from a provider to the next one.",
+		}, []string{"provider"})),
+		budgetRejections: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ksa_llm_budget_rejections_total",
+			Help: "Calls refused because a token budget was spent.",
+		}, []string{"tenant", "feature"})),
+	}
+}
+
+func register[T prometheus.Collector](reg prometheus.Registerer, c T) T {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+func (m *RouterMetrics) recordRequest(provider, model, op, outcome string, latency time.Duration) {
+	if m == nil {
+		return
+	}
+	m.requests.WithLabelValues(provider, model, op, outcome).Inc()
+	if outcome != "circuit_open" {
+		m.latency.WithLabelValues(provider, model, op).Observe(latency.Seconds())
+	}
+}
+
+func (m *RouterMetrics) recordUsage(provider, model, tenant, feature string, usage interfaces.UsageStats, cost float64) {
+	if m == nil {
+		return
+	}
+	m.tokens.WithLabelValues(provider, model, "prompt").Add(float64(usage.PromptTokens))
+	m.tokens.WithLabelValues(provider, model, "completion").Add(float64(usage.CompletionTokens))
+	m.cost.WithLabelValues(provider, model).Add(cost)
+	m.featureTokens.WithLabelValues(tenant, feature).Add(float64(usage.TotalTokens))
+}
+
+func (m *RouterMetrics) recordFallback(provider string) {
+	if m == nil {
+		return
+	}
+	m.fallbacks.WithLabelValues(provider).Inc()
+}
+
+func (m *RouterMetrics) recordBudgetRejection(tenant, feature string) {
+	if m == nil {
+		return
+	}
+	m.budgetRejections.WithLabelValues(tenant, feature).Inc()
+}
@@ -0,0 +1,262 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyClient fails with the queued errors before answering
+type flakyClient struct {
+	name  string
+	errs  []error
+	calls int
+	// models records the model each chat request asked for
+	models []string
+	// streamErrs fail the queued streams after they were opened
+	streamErrs []error
+}
+
+func (f *flakyClient) SendMessage(ctx context.Context, req *interfaces.LLMRequest) (*interfaces.LLMResponse, error) {
+	f.calls++
+	if req != nil {
+		f.models = append(f.models, req.Model)
+	}
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	return &interfaces.LLMResponse{
+		Message: interfaces.Message{Role: interfaces.RoleAssistant, Content: f.name},
+		Usage:   interfaces.UsageStats{PromptTokens: 800, CompletionTokens: 200, TotalTokens: 1000},
+	}, nil
+}
+
+func (f *flakyClient) SendStreamingMessage(ctx context.Context, req *interfaces.LLMRequest) (<-chan interfaces.StreamingChunk, error) {
+	if _, err := f.SendMessage(ctx, req); err != nil {
+		return nil, err
+	}
+	ch := make(chan interfaces.StreamingChunk, 2)
+	if len(f.streamErrs) > 0 {
+		ch <- interfaces.StreamingChunk{Err: f.streamErrs[0]}
+		f.streamErrs = f.streamErrs[1:]
+		close(ch)
+		return ch, nil
+	}
+	ch <- interfaces.StreamingChunk{Content: f.name}
+	ch <- interfaces.StreamingChunk{FinishReason: "stop", Usage: &interfaces.UsageStats{TotalTokens: 300}}
+	close(ch)
+	return ch, nil
+}
+
+func (f *flakyClient) GenerateEmbedding(ctx context.Context, req *interfaces.EmbeddingRequest) (*interfaces.EmbeddingResponse, error) {
+	if _, err := f.SendMessage(ctx, nil); err != nil {
+		return nil, err
+	}
+	return &interfaces.EmbeddingResponse{Embeddings: [][]float32{{1}}}, nil
+}
+
+func (f *flakyClient) Complete(ctx context.Context, prompt string, options ...interfaces.LLMOption) (string, error) {
+	return "", errors.New("not used")
+}
+
+var (
+	errRateLimited = &StatusError{Provider: "test", StatusCode: http.StatusTooManyRequests, Message: "slow down"}
+	errUnavailable = &StatusError{Provider: "test", StatusCode: http.StatusServiceUnavailable, Message: "overloaded"}
+	errBadRequest  = &StatusError{Provider: "test", StatusCode: http.StatusBadRequest, Message: "bad"}
+)
+
+func newTestRouter(t *testing.T, cfg config.LLMRouterConfig, clients ...*flakyClient) (*Router, *RouterMetrics, *[]time.Duration) {
+	var providers []RouterProvider
+	for _, c := range clients {
+		providers = append(providers, RouterProvider{Name: c.name, Client: c, Model: c.name + "-model"})
+	}
+	metrics := NewRouterMetrics(prometheus.NewRegistry())
+	r, err := NewRouter(providers, &cfg, metrics)
+	require.NoError(t, err)
+
+	var sleeps []time.Duration
+	r.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	return r, metrics, &sleeps
+}
+
+func TestRouter_RetriesThenFallsBack(t *testing.T) {
+	primary := &flakyClient{name: "primary", errs: []error{errRateLimited, errUnavailable, errUnavailable}}
+	secondary := &flakyClient{name: "secondary"}
+	r, metrics, sleeps := newTestRouter(t, config.LLMRouterConfig{MaxRetries: 2, InitialBackoff: 100 * time.Millisecond}, primary, secondary)
+
+	resp, err := r.SendMessage(context.Background(), &interfaces.LLMRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "secondary", resp.Message.Content)
+	assert.Equal(t, 3, primary.calls)
+
+	require.Len(t, *sleeps, 2)
+	assert.GreaterOrEqual(t, (*sleeps)[0], 50*time.Millisecond)
+	assert.LessOrEqual(t, (*sleeps)[0], 100*time.Millisecond)
+	assert.GreaterOrEqual(t, (*sleeps)[1], 100*time.Millisecond)
+	assert.LessOrEqual(t, (*sleeps)[1], 200*time.Millisecond)
+
+	assert.Equal(t, 3.0, testutil.ToFloat64(metrics.requests.WithLabelValues("primary", "primary-model", "chat", "error")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.fallbacks.WithLabelValues("primary")))
+	assert.Equal(t, 800.0, testutil.ToFloat64(metrics.tokens.WithLabelValues("secondary", "secondary-model", "prompt")))
+}
+
+func TestRouter_FallbackUsesItsOwnModel(t *testing.T) {
+	primary := &flakyClient{name: "primary", errs: []error{errBadRequest}}
+	secondary := &flakyClient{name: "secondary"}
+	r, metrics, _ := newTestRouter(t, config.LLMRouterConfig{
+		Pricing: map[string]config.ModelPricingConfig{
+			"gpt-4o":          {PromptPer1K: 1, CompletionPer1K: 1},
+			"secondary-model": {PromptPer1K: 0.01, CompletionPer1K: 0.03},
+		},
+	}, primary, secondary)
+
+	req := &interfaces.LLMRequest{Model: "gpt-4o"}
+	_, err := r.SendMessage(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gpt-4o"}, primary.models)
+	assert.Equal(t, []string{"secondary-model"}, secondary.models)
+	assert.Equal(t, "gpt-4o", req.Model, "the caller's request is not modified")
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("secondary", "secondary-model", "chat", "success")))
+	assert.InDelta(t, 0.008+0.006, testutil.ToFloat64(metrics.cost.WithLabelValues("secondary", "secondary-model")), 1e-9)
+}
+
+func TestRouter_NonRetryableErrorFallsBackImmediately(t *testing.T) {
+	primary := &flakyClient{name: "primary", errs: []error{errBadRequest}}
+	secondary := &flakyClient{name: "secondary", errs: []error{errBadRequest}}
+	r, _, sleeps := newTestRouter(t, config.LLMRouterConfig{}, primary, secondary)
+
+	_, err := r.SendMessage(context.Background(), &interfaces.LLMRequest{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "all LLM providers failed")
+	assert.ErrorIs(t, err, errBadRequest)
+	assert.Equal(t, 1, primary.calls)
+	assert.Empty(t, *sleeps)
+}
+
+func TestRouter_CircuitBreakerSkipsFailingProvider(t *testing.T) {
+	primary := &flakyClient{name: "primary", errs: []error{errUnavailable, errUnavailable, errUnavailable}}
+	secondary := &flakyClient{name: "secondary"}
+	r, metrics, _ := newTestRouter(t, config.LLMRouterConfig{MaxRetries: -1, BreakerFailures: 2, BreakerTimeout: time.Hour}, primary, secondary)
+
+	for i := 0; i < 4; i++ {
+		resp, err := r.SendMessage(context.Background(), &interfaces.LLMRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "secondary", resp.Message.Content)
+	}
+	assert.Equal(t, 2, primary.calls, "the open breaker stops calls to the primary")
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.requests.WithLabelValues("primary", "primary-model", "chat", "circuit_open")))
+}
+
+func TestRouter_BadRequestsDoNotTripBreaker(t *testing.T) {
+	primary := &flakyClient{name: "primary", errs: []error{errBadRequest, errBadRequest, errBadRequest}}
+	r, _, _ := newTestRouter(t, config.LLMRouterConfig{BreakerFailures: 2}, primary)
+
+	for i := 0; i < 3; i++ {
+		_, err := r.SendMessage(context.Background(), &interfaces.LLMRequest{})
+		require.ErrorIs(t, err, errBadRequest)
+	}
+	resp, err := r.SendMessage(context.Background(), &interfaces.LLMRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "primary", resp.Message.Content)
+}
+
+func TestRouter_EnforcesTokenBudgets(t *testing.T) {
+	primary := &flakyClient{name: "primary"}
+	r, metrics, _ := newTestRouter(t, config.LLMRouterConfig{
+		Budgets: []config.TokenBudgetConfig{
+			{Tenant: "team-a", Feature: "diagnosis", Tokens: 1500, Window: time.Hour},
+		},
+		Pricing: map[string]config.ModelPricingConfig{
+			"primary-model": {PromptPer1K: 0.01, CompletionPer1K: 0.03},
+		},
+	}, primary)
+	now := time.Now()
+	r.budgets.now = func() time.Time { return now }
+
+	ctx := interfaces.WithFeature(interfaces.WithTenant(context.Background(), "team-a"), "diagnosis")
+	for i := 0; i < 2; i++ {
+		_, err := r.SendMessage(ctx, &interfaces.LLMRequest{})
+		require.NoError(t, err)
+	}
+	_, err := r.SendMessage(ctx, &interfaces.LLMRequest{})
+	require.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Equal(t, 2, primary.calls)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.budgetRejections.WithLabelValues("team-a", "diagnosis")))
+	assert.InDelta(t, 2*(0.008+0.006), testutil.ToFloat64(metrics.cost.WithLabelValues("primary", "primary-model")), 1e-9)
+
+	// Other features and tenants are not limited by the budget
+	_, err = r.SendMessage(interfaces.WithTenant(context.Background(), "team-a"), &interfaces.LLMRequest{})
+	require.NoError(t, err)
+
+	// The budget resets with the next window
+	now = now.Add(time.Hour)
+	_, err = r.SendMessage(ctx, &interfaces.LLMRequest{})
+	require.NoError(t, err)
+}
+
+func TestRouter_StreamingFallsBackAndChargesUsage(t *testing.T) {
+	primary := &flakyClient{name: "primary", errs: []error{errBadRequest}}
+	secondary := &flakyClient{name: "secondary"}
+	r, metrics, _ := newTestRouter(t, config.LLMRouterConfig{}, primary, secondary)
+
+	ch, err := r.SendStreamingMessage(interfaces.WithFeature(context.Background(), "chat"), &interfaces.LLMRequest{})
+	require.NoError(t, err)
+	var content string
+	for chunk := range ch {
+		content += chunk.Content
+	}
+	assert.Equal(t, "secondary", content)
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.featureTokens.WithLabelValues("", "chat")) == 300
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRouter_StreamingFailsOverBeforeFirstChunk(t *testing.T) {
+	primary := &flakyClient{name: "primary", streamErrs: []error{errUnavailable, errUnavailable}}
+	secondary := &flakyClient{name: "secondary"}
+	r, _, _ := newTestRouter(t, config.LLMRouterConfig{MaxRetries: 1}, primary, secondary)
+
+	ch, err := r.SendStreamingMessage(context.Background(), &interfaces.LLMRequest{})
+	require.NoError(t, err)
+	var content string
+	for chunk := range ch {
+		require.NoError(t, chunk.Err)
+		content += chunk.Content
+	}
+	assert.Equal(t, "secondary", content)
+	assert.Equal(t, 2, primary.calls, "the failed stream is retried before failing over")
+}
+
+func TestRouter_EmbeddingsDoNotFailOver(t *testing.T) {
+	primary := &flakyClient{name: "primary", errs: []error{errBadRequest}}
+	secondary := &flakyClient{name: "secondary"}
+	r, _, _ := newTestRouter(t, config.LLMRouterConfig{}, primary, secondary)
+
+	_, err := r.GenerateEmbedding(context.Background(), &interfaces.EmbeddingRequest{Input: []string{"x"}})
+	require.ErrorIs(t, err, errBadRequest)
+	assert.Zero(t, secondary.calls)
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(errRateLimited))
+	assert.True(t, IsRetryable(errUnavailable))
+	assert.True(t, IsRetryable(context.DeadlineExceeded))
+	assert.False(t, IsRetryable(errBadRequest))
+	assert.False(t, IsRetryable(context.Canceled))
+	assert.False(t, IsRetryable(ErrBudgetExceeded))
+}
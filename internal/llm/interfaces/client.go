@@ -19,20 +19,58 @@ import (
 	"context"
 )
 
+// Message roles.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
 // Message represents a single message in a conversation.
+// An assistant message may carry ToolCalls instead of Content; the result of
+// each call is sent back as a RoleTool message with the matching ToolCallID.
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// ToolDefinition describes a function the model may call.
+type ToolDefinition struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Parameters is the JSON schema of the arguments object.
+	Parameters any `json:"parameters,omitempty"`
 }
 
+// ToolCall is a function call requested by the model.
+type ToolCall struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Arguments is the JSON encoded arguments object.
+	Arguments string `json:"arguments"`
+}
+
+// Tool choice values for LLMRequest.ToolChoice. Any other value forces the
+// model to call the tool with that name.
+const (
+	ToolChoiceAuto     = "auto"
+	ToolChoiceNone     = "none"
+	ToolChoiceRequired = "required"
+)
+
 // LLMRequest encapsulates parameters for LLM request.
 type LLMRequest struct {
-	Model          string    `json:"model"`
-	Messages       []Message `json:"messages"`
-	Temperature    float32   `json:"temperature,omitempty"`
-	MaxTokens      int       `json:"max_tokens,omitempty"`
-	Stream         bool      `json:"stream,omitempty"`
-	ResponseFormat string    `json:"response_format,omitempty"`
+	Model          string           `json:"model"`
+	Messages       []Message        `json:"messages"`
+	Temperature    float32          `json:"temperature,omitempty"`
+	MaxTokens      int              `json:"max_tokens,omitempty"`
+	Stream         bool             `json:"stream,omitempty"`
+	ResponseFormat string           `json:"response_format,omitempty"`
+	Tools          []ToolDefinition `json:"tools,omitempty"`
+	ToolChoice     string           `json:"tool_choice,omitempty"`
 }
 
 // UsageStats contains token usage info.
@@ -44,14 +82,20 @@ type UsageStats struct {
 
 // LLMResponse contains the response.
 type LLMResponse struct {
-	Message Message    `json:"message"`
-	Usage   UsageStats `json:"usage"`
+	Message      Message    `json:"message"`
+	Usage        UsageStats `json:"usage"`
+	FinishReason string     `json:"finish_reason,omitempty"`
 }
 
 // StreamingChunk represents a streaming chunk.
+// Tool calls are streamed in fragments by providers; they are delivered once,
+// complete, in the final chunk together with the finish reason and usage.
 type StreamingChunk struct {
-	Content string `json:"content"`
-	Err     error  `json:"-"`
+	Content      string      `json:"content"`
+	ToolCalls    []ToolCall  `json:"tool_calls,omitempty"`
+	FinishReason string      `json:"finish_reason,omitempty"`
+	Usage        *UsageStats `json:"usage,omitempty"`
+	Err          error       `json:"-"`
 }
 
 // EmbeddingRequest for embeddings.
@@ -0,0 +1,34 @@
+package interfaces
+
+import "context"
+
+type contextKey int
+
+const (
+	tenantKey contextKey = iota
+	featureKey
+)
+
+// WithTenant tags LLM calls made with ctx with the tenant they are made for,
+// so usage can be attributed and budgeted per tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// WithFeature tags LLM calls made with ctx with the feature making them,
+// e.g. "diagnosis" or "plan_reflection".
+func WithFeature(ctx context.Context, feature string) context.Context {
+	return context.WithValue(ctx, featureKey, feature)
+}
+
+// TenantFromContext returns the tenant set with WithTenant, or "".
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey).(string)
+	return tenant
+}
+
+// FeatureFromContext returns the feature set with WithFeature, or "".
+func FeatureFromContext(ctx context.Context) string {
+	feature, _ := ctx.Value(featureKey).(string)
+	return feature
+}
@@ -33,25 +33,44 @@ func NewStructuredOutputParser() *StructuredOutputParser {
 
 // Parse parses the raw string output from LLM into a DiagnosisResult.
 func (p *StructuredOutputParser) Parse(llmOutput string) (*DiagnosisResult, error) {
+	var result DiagnosisResult
+	if err := p.ParseInto(llmOutput, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ParseInto parses the raw string output from LLM into out, a pointer to a
+// struct, and validates it against the struct's `validate` tags.
+func (p *StructuredOutputParser) ParseInto(llmOutput string, out interface{}) error {
 	cleanJSON := p.cleanOutput(llmOutput)
 
-	var result DiagnosisResult
-	if err := json.Unmarshal([]byte(cleanJSON), &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	if err := json.Unmarshal([]byte(cleanJSON), out); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
 	}
 
-	if err := p.validator.Struct(&result); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+	if err := p.validator.Struct(out); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
 	}
 
-	return &result, nil
+	return nil
 }
 
-// cleanOutput removes potential markdown code blocks from the output.
+// cleanOutput removes potential markdown code blocks from the output, and
+// any prose the model wrapped around a JSON object.
 func (p *StructuredOutputParser) cleanOutput(output string) string {
 	cleaned := strings.TrimSpace(output)
 	cleaned = strings.TrimPrefix(cleaned, "```json")
 	cleaned = strings.TrimPrefix(cleaned, "```")
 	cleaned = strings.TrimSuffix(cleaned, "```")
-	return strings.TrimSpace(cleaned)
+	cleaned = strings.TrimSpace(cleaned)
+
+	if !strings.HasPrefix(cleaned, "{") && !strings.HasPrefix(cleaned, "[") {
+		start := strings.Index(cleaned, "{")
+		end := strings.LastIndex(cleaned, "}")
+		if start >= 0 && end > start {
+			cleaned = cleaned[start : end+1]
+		}
+	}
+	return cleaned
 }
@@ -43,6 +43,18 @@ func TestStructuredOutputParser_Parse(t *testing.T) {
 				assert.Equal(t, "critical", res.Severity)
 			},
 		},
+		{
+			name: "Valid JSON with Surrounding Prose",
+			input: "Here is the analysis:\n" + `{
+				"root_cause": "OOM",
+				"severity": "low",
+				"confidence": 0.4
+			}` + "\nLet me know if you need more.",
+			wantErr: false,
+			checkResult: func(res *DiagnosisResult) {
+				assert.Equal(t, "low", res.Severity)
+			},
+		},
 		{
 			name: "Missing Required Field",
 			input: `{
@@ -0,0 +1,186 @@
+package prompt
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// ErrUnknownPrompt is returned for names that are not in the catalog.
+var ErrUnknownPrompt = errors.New("unknown prompt")
+
+// Argument describes a value a catalog prompt is rendered with. Arguments
+// are strings; list arguments take a JSON array.
+type Argument struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// Definition is one of the prompt templates shipped in templates/.
+type Definition struct {
+	Name        string
+	Description string
+	Arguments   []Argument
+
+	data func(args map[string]string) (interface{}, error)
+}
+
+// Document is a knowledge snippet in the diagnosis analysis prompt.
+type Document struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// Metric is a metric reading in the diagnosis analysis prompt.
+type Metric struct {
+	Name      string      `json:"name"`
+	Value     interface{} `json:"value"`
+	Threshold interface{} `json:"threshold"`
+}
+
+// LogLine is a log entry in the diagnosis analysis prompt.
+type LogLine struct {
+	Timestamp string `json:"timestamp"`
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+}
+
+var catalog = []Definition{
+	{
+		Name:        "diagnosis_analysis",
+		Description: "Analyse a middleware problem from the question, knowledge, metrics and logs and return a structured diagnosis",
+		Arguments: []Argument{
+			{Name: "service_type", Description: "Middleware type, e.g. Redis, MySQL, Kafka", Required: true},
+			{Name: "question", Description: "The problem to diagnose", Required: true},
+			{Name: "documents", Description: `JSON array of {"title", "content"} knowledge snippets`},
+			{Name: "metrics", Description: `JSON array of {"name", "value", "threshold"} readings`},
+			{Name: "logs", Description: `JSON array of {"timestamp", "level", "message"} entries`},
+			{Name: "examples", Description: `JSON array of {"id", "input", "analysis", "output"} few-shot examples`},
+		},
+		data: func(args map[string]string) (interface{}, error) {
+			data := map[string]interface{}{
+				"ServiceType": args["service_type"],
+				"Question":    args["question"],
+			}
+			var docs []Document
+			var metrics []Metric
+			var logs []LogLine
+			var examples []*FewShotExample
+			for name, target := range map[string]interface{}{
+				"documents": &docs, "metrics": &metrics, "logs": &logs, "examples": &examples,
+			} {
+				if err := jsonArgument(args, name, target); err != nil {
+					return nil, err
+				}
+			}
+			data["RetrievedDocuments"] = docs
+			data["Metrics"] = metrics
+			data["Logs"] = logs
+			data["FewShotExamples"] = examples
+			return data, nil
+		},
+	},
+	{
+		Name:        "solution_generation",
+		Description: "Turn an identified root cause into a step-by-step repair plan",
+		Arguments: []Argument{
+			{Name: "root_cause", Description: "The identified root cause", Required: true},
+			{Name: "severity", Description: "low, medium, high or critical"},
+			{Name: "affected_components", Description: "Comma-separated affected components"},
+			{Name: "contributing_factors", Description: "Comma-separated contributing factors"},
+			{Name: "system_constraints", Description: "Constraints the plan must respect, e.g. maintenance windows"},
+		},
+		data: func(args map[string]string) (interface{}, error) {
+			return map[string]interface{}{
+				"RootCause":           args["root_cause"],
+				"Severity":            args["severity"],
+				"AffectedComponents":  splitList(args["affected_components"]),
+				"ContributingFactors": splitList(args["contributing_factors"]),
+				"SystemConstraints":   args["system_constraints"],
+			}, nil
+		},
+	},
+	{
+		Name:        "question_clarification",
+		Description: "Ask clarifying questions about an ambiguous problem report",
+		Arguments: []Argument{
+			{Name: "user_input", Description: "The ambiguous user input", Required: true},
+			{Name: "ambiguity_analysis", Description: "What is missing or unclear"},
+		},
+		data: func(args map[string]string) (interface{}, error) {
+			return map[string]interface{}{
+				"UserInput":         args["user_input"],
+				"AmbiguityAnalysis": args["ambiguity_analysis"],
+			}, nil
+		},
+	},
+}
+
+// Catalog returns the built-in prompt definitions sorted by name.
+func Catalog() []Definition {
+	out := make([]Definition, len(catalog))
+	copy(out, catalog)
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Render renders the named catalog prompt with args. Missing required
+// arguments and malformed list arguments are errors.
+func Render(name string, args map[string]string) (string, error) {
+	var def *Definition
+	for i := range catalog {
+		if catalog[i].Name == name {
+			def = &catalog[i]
+		}
+	}
+	if def == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownPrompt, name)
+	}
+	for _, arg := range def.Arguments {
+		if arg.Required && strings.TrimSpace(args[arg.Name]) == "" {
+			return "", fmt.Errorf("prompt %s requires argument %q", name, arg.Name)
+		}
+	}
+
+	raw, err := templateFS.ReadFile("templates/" + name + ".tmpl")
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := NewGoTemplate(name, string(raw))
+	if err != nil {
+		return "", err
+	}
+	data, err := def.data(args)
+	if err != nil {
+		return "", err
+	}
+	return tmpl.Render(data)
+}
+
+func jsonArgument(args map[string]string, name string, target interface{}) error {
+	value := strings.TrimSpace(args[name])
+	if value == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(value), target); err != nil {
+		return fmt.Errorf("argument %q must be a JSON array: %w", name, err)
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	var out []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package prompt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog_RendersEveryTemplate(t *testing.T) {
+	args := map[string]string{
+		"service_type":        "Redis",
+		"question":            "Why is memory usage high?",
+		"metrics":             `[{"name":"used_memory","value":"7.5GB","threshold":"6GB"}]`,
+		"logs":                `[{"timestamp":"10:00","level":"WARN","message":"OOM command not allowed"}]`,
+		"root_cause":          "maxmemory too low",
+		"affected_components": "cache, sessions",
+		"user_input":          "redis is slow",
+	}
+
+	catalog := Catalog()
+	require.Len(t, catalog, 3)
+	for _, def := range catalog {
+		out, err := Render(def.Name, args)
+		require.NoError(t, err, def.Name)
+		assert.NotEmpty(t, out, def.Name)
+	}
+
+	out, err := Render("diagnosis_analysis", args)
+	require.NoError(t, err)
+	assert.Contains(t, out, "used_memory")
+	assert.Contains(t, out, "OOM command not allowed")
+}
+
+func TestCatalog_RenderErrors(t *testing.T) {
+	_, err := Render("nope", nil)
+	assert.True(t, errors.Is(err, ErrUnknownPrompt))
+
+	_, err = Render("diagnosis_analysis", map[string]string{"service_type": "Redis"})
+	assert.ErrorContains(t, err, "question")
+
+	_, err = Render("diagnosis_analysis", map[string]string{"service_type": "Redis", "question": "q", "metrics": "not json"})
+	assert.ErrorContains(t, err, "JSON array")
+}
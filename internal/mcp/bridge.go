@@ -3,24 +3,108 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"os"
 	"sync"
+	"time"
 
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
 	"github.com/kubestack-ai/kubestack-ai/internal/mcp/client"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
 	"github.com/kubestack-ai/kubestack-ai/internal/tools"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
 )
 
 // BridgeConfig holds configuration for the MCP bridge
 type BridgeConfig struct {
 	Servers      []ServerEntry
 	AutoDiscover bool
+	// MaxIdleTime closes connections unused for this long
+	MaxIdleTime time.Duration
 }
 
-// ServerEntry represents an MCP server configuration
+// ServerEntry represents an MCP server configuration. Command starts a
+// local server over stdio; URL reaches a remote one over Streamable HTTP.
 type ServerEntry struct {
-	ID      string
-	Command string
-	Args    []string
-	Env     []string
+	ID          string
+	Command     string
+	Args        []string
+	Env         []string
+	URL         string
+	Headers     map[string]string
+	TokenSource oauth2.TokenSource
+	Policy      client.ToolPolicy
+}
+
+// BridgeConfigFromConfig builds the bridge configuration of the external
+// servers in cfg, expanding environment variables in credentials, headers
+// and URLs.
+func BridgeConfigFromConfig(cfg config.MCPConfig) (BridgeConfig, error) {
+	bc := BridgeConfig{
+		AutoDiscover: cfg.AutoDiscover,
+		MaxIdleTime:  cfg.Pool.MaxIdleTime,
+	}
+	seen := make(map[string]bool)
+	for _, srv := range cfg.Servers {
+		if srv.ID == "" {
+			return BridgeConfig{}, fmt.Errorf("mcp server without id")
+		}
+		if seen[srv.ID] {
+			return BridgeConfig{}, fmt.Errorf("duplicate mcp server %s", srv.ID)
+		}
+		seen[srv.ID] = true
+		if (srv.Command == "") == (srv.URL == "") {
+			return BridgeConfig{}, fmt.Errorf("mcp server %s must set exactly one of command or url", srv.ID)
+		}
+
+		entry := ServerEntry{
+			ID:      srv.ID,
+			Command: srv.Command,
+			Args:    srv.Args,
+			URL:     os.ExpandEnv(srv.URL),
+			Policy: client.ToolPolicy{
+				Allow:            srv.Tools.Allow,
+				Deny:             srv.Tools.Deny,
+				AllowDestructive: srv.Tools.AllowDestructive,
+				Remote:           srv.URL != "",
+			},
+		}
+		for _, env := range srv.Env {
+			entry.Env = append(entry.Env, os.ExpandEnv(env))
+		}
+		if len(srv.Headers) > 0 {
+			entry.Headers = make(map[string]string, len(srv.Headers))
+			for k, v := range srv.Headers {
+				entry.Headers[k] = os.ExpandEnv(v)
+			}
+		}
+
+		auth := srv.Auth
+		switch {
+		case auth.BearerToken != "" && auth.OAuth.TokenURL != "":
+			return BridgeConfig{}, fmt.Errorf("mcp server %s sets both bearer_token and oauth", srv.ID)
+		case auth.BearerToken != "":
+			entry.TokenSource = oauth2.StaticTokenSource(&oauth2.Token{
+				AccessToken: os.ExpandEnv(auth.BearerToken),
+				TokenType:   "Bearer",
+			})
+		case auth.OAuth.TokenURL != "":
+			cc := &clientcredentials.Config{
+				ClientID:     os.ExpandEnv(auth.OAuth.ClientID),
+				ClientSecret: os.ExpandEnv(auth.OAuth.ClientSecret),
+				TokenURL:     os.ExpandEnv(auth.OAuth.TokenURL),
+				Scopes:       auth.OAuth.Scopes,
+			}
+			entry.TokenSource = cc.TokenSource(context.Background())
+		}
+		if entry.TokenSource != nil && entry.URL == "" {
+			return BridgeConfig{}, fmt.Errorf("mcp server %s sets auth without url", srv.ID)
+		}
+
+		bc.Servers = append(bc.Servers, entry)
+	}
+	return bc, nil
 }
 
 // MCPBridge bridges between MCP servers and local tools
@@ -34,7 +118,7 @@ type MCPBridge struct {
 
 // NewMCPBridge creates a new MCP bridge
 func NewMCPBridge(registry tools.Registry, cfg BridgeConfig) (*MCPBridge, error) {
-	pool := client.NewConnectionPool(0) // Use default idle timeout
+	pool := client.NewConnectionPool(cfg.MaxIdleTime) // Zero uses the default idle timeout
 
 	// Add servers to pool
 	for _, server := range cfg.Servers {
@@ -42,6 +126,10 @@ func NewMCPBridge(registry tools.Registry, cfg BridgeConfig) (*MCPBridge, error)
 			ServerCommand: server.Command,
 			ServerArgs:    server.Args,
 			ServerEnv:     server.Env,
+			ServerURL:     server.URL,
+			Headers:       server.Headers,
+			TokenSource:   server.TokenSource,
+			AutoReconnect: true,
 		}
 		pool.AddServer(server.ID, clientCfg)
 	}
@@ -102,6 +190,9 @@ func (b *MCPBridge) ConnectAndDiscover(ctx context.Context, serverID string) err
 
 	// Create discovery service
 	discovery := client.NewDiscovery(mcpClient, b.registry, serverID)
+	if server, ok := b.server(serverID); ok {
+		discovery.SetPolicy(server.Policy)
+	}
 
 	b.mu.Lock()
 	b.discoveries[serverID] = discovery
@@ -113,17 +204,43 @@ func (b *MCPBridge) ConnectAndDiscover(ctx context.Context, serverID string) err
 		return fmt.Errorf("failed to discover tools: %w", err)
 	}
 
-	fmt.Printf("Discovered %d tools from server %s\n", count, serverID)
+	logger.NewLogger("mcp").Infof("Discovered %d tools from server %s", count, serverID)
 	return nil
 }
 
-// CallTool calls a tool on a specific MCP server
+func (b *MCPBridge) server(serverID string) (ServerEntry, bool) {
+	for _, server := range b.config.Servers {
+		if server.ID == serverID {
+			return server, true
+		}
+	}
+	return ServerEntry{}, false
+}
+
+// CallTool calls a tool on a specific MCP server, subject to the server's
+// tool policy and the tool's input schema
 func (b *MCPBridge) CallTool(ctx context.Context, serverID, toolName string, args map[string]any) (any, error) {
 	mcpClient, err := b.pool.GetClient(ctx, serverID)
 	if err != nil {
 		return nil, err
 	}
 
+	def := protocol.ToolDefinition{Name: toolName}
+	for _, cached := range mcpClient.GetCachedTools() {
+		if cached.Name == toolName {
+			def = cached
+			break
+		}
+	}
+	if server, ok := b.server(serverID); ok {
+		if err := server.Policy.Check(def); err != nil {
+			return nil, err
+		}
+	}
+	if err := tools.ValidateArguments(def.InputSchema, args); err != nil {
+		return nil, fmt.Errorf("invalid arguments for %s: %w", toolName, err)
+	}
+
 	result, err := mcpClient.CallTool(ctx, toolName, args)
 	if err != nil {
 		return nil, err
@@ -3,11 +3,13 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+	"golang.org/x/oauth2"
 )
 
 // ClientConfig holds configuration for an MCP client
@@ -15,16 +17,23 @@ type ClientConfig struct {
 	ServerCommand string
 	ServerArgs    []string
 	ServerEnv     []string
+	// ServerURL reaches a remote server over Streamable HTTP instead of
+	// spawning ServerCommand
+	ServerURL string
+	Headers   map[string]string
+	// TokenSource supplies the bearer token sent to ServerURL
+	TokenSource   oauth2.TokenSource
 	Timeout       time.Duration
 	AutoReconnect bool
 }
 
 // Client represents an MCP client
 type Client struct {
-	config  ClientConfig
-	session *protocol.Session
-	tools   []protocol.ToolDefinition
-	mu      sync.RWMutex
+	config       ClientConfig
+	session      *protocol.Session
+	tools        []protocol.ToolDefinition
+	toolsChanged []func()
+	mu           sync.RWMutex
 }
 
 // NewClient creates a new MCP client
@@ -39,21 +48,48 @@ func NewClient(cfg ClientConfig) *Client {
 
 // Connect establishes a connection to the MCP server
 func (c *Client) Connect(ctx context.Context) error {
+	session, err := c.dial()
+	if err != nil {
+		return err
+	}
+
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.session = session
+	c.mu.Unlock()
+
+	// Discover tools
+	if err := c.discoverTools(ctx); err != nil {
+		// Log warning but don't fail connection
+		// Tools can be discovered later
+	}
 
-	// Create stdio transport
-	transport, err := protocol.NewStdioTransport(
-		c.config.ServerCommand,
-		c.config.ServerArgs,
-		c.config.ServerEnv,
-	)
+	return nil
+}
+
+// dial opens and initializes a session with the server
+func (c *Client) dial() (*protocol.Session, error) {
+	var transport protocol.Transport
+	var err error
+	if c.config.ServerURL != "" {
+		transport, err = protocol.NewHTTPTransport(protocol.HTTPTransportConfig{
+			URL:         c.config.ServerURL,
+			Headers:     c.config.Headers,
+			TokenSource: c.config.TokenSource,
+		})
+	} else {
+		transport, err = protocol.NewStdioTransport(
+			c.config.ServerCommand,
+			c.config.ServerArgs,
+			c.config.ServerEnv,
+		)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create transport: %w", err)
+		return nil, fmt.Errorf("failed to create transport: %w", err)
 	}
 
 	// Create session
-	c.session = protocol.NewSession(transport)
+	session := protocol.NewSession(transport)
+	session.SetNotificationHandler(c.handleNotification)
 
 	// Initialize session
 	clientInfo := protocol.ClientInfo{
@@ -67,19 +103,96 @@ func (c *Client) Connect(ctx context.Context) error {
 		},
 	}
 
-	if err := c.session.Initialize(clientInfo, capabilities); err != nil {
-		c.session.Close()
-		c.session = nil
-		return fmt.Errorf("failed to initialize session: %w", err)
+	if err := session.Initialize(clientInfo, capabilities); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to initialize session: %w", err)
 	}
+	return session, nil
+}
 
-	// Discover tools
-	if err := c.discoverTools(ctx); err != nil {
-		// Log warning but don't fail connection
-		// Tools can be discovered later
+// OnToolsChanged registers fn to run when the server reports that its
+// tools changed, and after the client reconnected
+func (c *Client) OnToolsChanged(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.toolsChanged = append(c.toolsChanged, fn)
+}
+
+func (c *Client) handleNotification(method string, params any) {
+	if method == protocol.MethodToolsListChanged {
+		c.notifyToolsChanged()
 	}
+}
 
-	return nil
+func (c *Client) notifyToolsChanged() {
+	c.mu.RLock()
+	listeners := make([]func(), len(c.toolsChanged))
+	copy(listeners, c.toolsChanged)
+	c.mu.RUnlock()
+
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+// call makes a request, reconnecting once when AutoReconnect is set and
+// the session expired or the connection was lost
+func (c *Client) call(ctx context.Context, method string, params any) (any, error) {
+	c.mu.RLock()
+	session := c.session
+	c.mu.RUnlock()
+
+	if session == nil {
+		return nil, fmt.Errorf("client is not connected")
+	}
+
+	result, err := session.CallContext(ctx, method, params, c.config.Timeout)
+	if err == nil || !c.config.AutoReconnect || ctx.Err() != nil {
+		return result, err
+	}
+	if !errors.Is(err, protocol.ErrSessionExpired) && session.CurrentState() != protocol.StateError {
+		return result, err
+	}
+
+	session, rerr := c.reconnect(session)
+	if rerr != nil {
+		return nil, fmt.Errorf("%w (reconnect failed: %v)", err, rerr)
+	}
+	return session.CallContext(ctx, method, params, c.config.Timeout)
+}
+
+// reconnect replaces a broken session, unless another call already did
+func (c *Client) reconnect(broken *protocol.Session) (*protocol.Session, error) {
+	c.mu.Lock()
+	if c.session != broken {
+		session := c.session
+		c.mu.Unlock()
+		if session == nil {
+			return nil, fmt.Errorf("client is not connected")
+		}
+		return session, nil
+	}
+	c.mu.Unlock()
+
+	broken.Close()
+	session, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.session != broken {
+		// Disconnected or reconnected meanwhile
+		c.mu.Unlock()
+		session.Close()
+		return nil, fmt.Errorf("client is not connected")
+	}
+	c.session = session
+	c.mu.Unlock()
+
+	// The new session may expose different tools
+	go c.notifyToolsChanged()
+	return session, nil
 }
 
 // Disconnect closes the connection to the MCP server
@@ -99,20 +212,12 @@ func (c *Client) Disconnect() error {
 
 // CallTool calls a tool on the MCP server
 func (c *Client) CallTool(ctx context.Context, name string, args map[string]any) (*protocol.ToolCallResult, error) {
-	c.mu.RLock()
-	session := c.session
-	c.mu.RUnlock()
-
-	if session == nil {
-		return nil, fmt.Errorf("client is not connected")
-	}
-
 	params := protocol.ToolCallParams{
 		Name:      name,
 		Arguments: args,
 	}
 
-	result, err := session.Call(protocol.MethodToolsCall, params)
+	result, err := c.call(ctx, protocol.MethodToolsCall, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call tool: %w", err)
 	}
@@ -142,15 +247,7 @@ func (c *Client) CallTool(ctx context.Context, name string, args map[string]any)
 
 // ListTools retrieves the list of available tools from the server
 func (c *Client) ListTools(ctx context.Context) ([]protocol.ToolDefinition, error) {
-	c.mu.RLock()
-	session := c.session
-	c.mu.RUnlock()
-
-	if session == nil {
-		return nil, fmt.Errorf("client is not connected")
-	}
-
-	result, err := session.Call(protocol.MethodToolsList, nil)
+	result, err := c.call(ctx, protocol.MethodToolsList, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tools: %w", err)
 	}
@@ -186,15 +283,7 @@ func (c *Client) GetCachedTools() []protocol.ToolDefinition {
 
 // Ping sends a ping request to verify the connection
 func (c *Client) Ping(ctx context.Context) error {
-	c.mu.RLock()
-	session := c.session
-	c.mu.RUnlock()
-
-	if session == nil {
-		return fmt.Errorf("client is not connected")
-	}
-
-	_, err := session.Call(protocol.MethodPing, nil)
+	_, err := c.call(ctx, protocol.MethodPing, nil)
 	return err
 }
 
@@ -207,7 +296,7 @@ func (c *Client) IsConnected() bool {
 		return false
 	}
 
-	return c.session.State == protocol.StateConnected
+	return c.session.CurrentState() == protocol.StateConnected
 }
 
 // discoverTools discovers tools after connection
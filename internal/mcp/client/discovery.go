@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
 	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
 	"github.com/kubestack-ai/kubestack-ai/internal/tools"
 )
@@ -14,15 +16,41 @@ type Discovery struct {
 	client   *Client
 	registry tools.Registry
 	serverID string
+	log      logger.Logger
+
+	mu     sync.RWMutex
+	policy ToolPolicy
 }
 
-// NewDiscovery creates a new discovery service
+// NewDiscovery creates a new discovery service. Registered tools are
+// refreshed whenever the server reports that its tools changed.
 func NewDiscovery(client *Client, registry tools.Registry, serverID string) *Discovery {
-	return &Discovery{
+	d := &Discovery{
 		client:   client,
 		registry: registry,
 		serverID: serverID,
+		log:      logger.NewLogger("mcp"),
 	}
+	client.OnToolsChanged(func() {
+		if err := d.RefreshTools(context.Background()); err != nil {
+			d.log.Warnf("Failed to refresh tools of MCP server %s: %v", serverID, err)
+		}
+	})
+	return d
+}
+
+// SetPolicy sets the policy deciding which tools are registered and may be
+// called. It applies to tools discovered afterwards.
+func (d *Discovery) SetPolicy(policy ToolPolicy) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.policy = policy
+}
+
+func (d *Discovery) getPolicy() ToolPolicy {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.policy
 }
 
 // DiscoverAndRegister discovers tools from the MCP server and registers them
@@ -37,8 +65,14 @@ func (d *Discovery) DiscoverAndRegister(ctx context.Context) (int, error) {
 		return 0, fmt.Errorf("failed to list tools: %w", err)
 	}
 
+	policy := d.getPolicy()
 	count := 0
 	for _, def := range toolDefs {
+		if err := policy.Check(def); err != nil {
+			d.log.Debugf("Skipping tool of MCP server %s: %v", d.serverID, err)
+			continue
+		}
+
 		tool, err := d.convertToLocalTool(def)
 		if err != nil {
 			// Log error but continue with other tools
@@ -60,8 +94,10 @@ func (d *Discovery) DiscoverAndRegister(ctx context.Context) (int, error) {
 func (d *Discovery) convertToLocalTool(def protocol.ToolDefinition) (*tools.Tool, error) {
 	// Create tool wrapper
 	wrapper := &MCPToolWrapper{
-		client:   d.client,
-		toolName: def.Name,
+		client:     d.client,
+		toolName:   def.Name,
+		definition: def,
+		policy:     d.getPolicy(),
 	}
 
 	tool := &tools.Tool{
@@ -89,12 +125,22 @@ func (d *Discovery) RefreshTools(ctx context.Context) error {
 
 // MCPToolWrapper wraps MCP tool calls
 type MCPToolWrapper struct {
-	client   *Client
-	toolName string
+	client     *Client
+	toolName   string
+	definition protocol.ToolDefinition
+	policy     ToolPolicy
 }
 
-// Execute executes the MCP tool
+// Execute executes the MCP tool. Calls the policy rejects or whose
+// arguments do not match the tool's input schema are not forwarded.
 func (w *MCPToolWrapper) Execute(ctx context.Context, args map[string]any) (any, error) {
+	if err := w.policy.Check(w.definition); err != nil {
+		return nil, err
+	}
+	if err := tools.ValidateArguments(w.definition.InputSchema, args); err != nil {
+		return nil, fmt.Errorf("invalid arguments for %s: %w", w.toolName, err)
+	}
+
 	result, err := w.client.CallTool(ctx, w.toolName, args)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+)
+
+// ErrToolNotAllowed is returned for tools a server policy does not expose
+var ErrToolNotAllowed = errors.New("tool not allowed")
+
+// ToolPolicy decides which tools of a server are registered and may be
+// called. Allow and Deny hold path.Match patterns over the tool names of
+// the server, e.g. "read_*"; Deny wins, and an empty Allow allows all.
+type ToolPolicy struct {
+	Allow []string
+	Deny  []string
+	// AllowDestructive exposes tools the server annotates as destructive
+	AllowDestructive bool
+	// Remote marks a server reached over the network. Its tools count as
+	// destructive unless annotated read-only, since a missing hint says
+	// nothing about what a third-party tool does.
+	Remote bool
+}
+
+// Check returns ErrToolNotAllowed, wrapped with the reason, when the policy
+// rejects def
+func (p ToolPolicy) Check(def protocol.ToolDefinition) error {
+	if matchAny(p.Deny, def.Name) {
+		return fmt.Errorf("%w: %s is denied", ErrToolNotAllowed, def.Name)
+	}
+	if len(p.Allow) > 0 && !matchAny(p.Allow, def.Name) {
+		return fmt.Errorf("%w: %s is not in the allow list", ErrToolNotAllowed, def.Name)
+	}
+	if !p.AllowDestructive && p.destructive(def) {
+		if p.Remote {
+			return fmt.Errorf("%w: %s is not annotated read-only", ErrToolNotAllowed, def.Name)
+		}
+		return fmt.Errorf("%w: %s is destructive", ErrToolNotAllowed, def.Name)
+	}
+	return nil
+}
+
+func (p ToolPolicy) destructive(def protocol.ToolDefinition) bool {
+	a := def.Annotations
+	if a != nil && a.DestructiveHint != nil && *a.DestructiveHint {
+		return true
+	}
+	if p.Remote {
+		return a == nil || a.ReadOnlyHint == nil || !*a.ReadOnlyHint
+	}
+	return false
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+)
+
+func TestToolPolicy_Check(t *testing.T) {
+	destructive, readOnly, notReadOnly := true, true, false
+	tests := []struct {
+		name    string
+		policy  ToolPolicy
+		def     protocol.ToolDefinition
+		allowed bool
+	}{
+		{"empty policy allows all", ToolPolicy{}, protocol.ToolDefinition{Name: "anything"}, true},
+		{"allow list match", ToolPolicy{Allow: []string{"read_*"}}, protocol.ToolDefinition{Name: "read_file"}, true},
+		{"allow list miss", ToolPolicy{Allow: []string{"read_*"}}, protocol.ToolDefinition{Name: "write_file"}, false},
+		{"deny wins over allow", ToolPolicy{Allow: []string{"*"}, Deny: []string{"write_*"}}, protocol.ToolDefinition{Name: "write_file"}, false},
+		{"destructive rejected",
+			ToolPolicy{},
+			protocol.ToolDefinition{Name: "drop", Annotations: &protocol.ToolAnnotations{DestructiveHint: &destructive}},
+			false},
+		{"destructive allowed",
+			ToolPolicy{AllowDestructive: true},
+			protocol.ToolDefinition{Name: "drop", Annotations: &protocol.ToolAnnotations{DestructiveHint: &destructive}},
+			true},
+		{"remote tool without hints rejected", ToolPolicy{Remote: true}, protocol.ToolDefinition{Name: "anything"}, false},
+		{"remote tool not read-only rejected",
+			ToolPolicy{Remote: true, Allow: []string{"*"}},
+			protocol.ToolDefinition{Name: "update", Annotations: &protocol.ToolAnnotations{ReadOnlyHint: &notReadOnly}},
+			false},
+		{"remote read-only tool allowed",
+			ToolPolicy{Remote: true},
+			protocol.ToolDefinition{Name: "read", Annotations: &protocol.ToolAnnotations{ReadOnlyHint: &readOnly}},
+			true},
+		{"remote read-only but destructive rejected",
+			ToolPolicy{Remote: true},
+			protocol.ToolDefinition{Name: "read", Annotations: &protocol.ToolAnnotations{ReadOnlyHint: &readOnly, DestructiveHint: &destructive}},
+			false},
+		{"remote tool allowed explicitly", ToolPolicy{Remote: true, AllowDestructive: true}, protocol.ToolDefinition{Name: "anything"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.def)
+			if tt.allowed && err != nil {
+				t.Errorf("Expected %s to be allowed, got %v", tt.def.Name, err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrToolNotAllowed) {
+				t.Errorf("Expected ErrToolNotAllowed for %s, got %v", tt.def.Name, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/server"
+	"github.com/kubestack-ai/kubestack-ai/internal/tools"
+	"golang.org/x/oauth2"
+)
+
+const testToken = "s3cret"
+
+// remoteServer is an MCP server over Streamable HTTP. expire makes the
+// next request with a session fail as if the session had expired, and
+// notify pushes a tools/list_changed notification to listening clients.
+type remoteServer struct {
+	*httptest.Server
+	registry tools.Registry
+	calls    atomic.Int32
+	expire   atomic.Bool
+	notify   chan struct{}
+}
+
+func newRemoteServer(t *testing.T) *remoteServer {
+	t.Helper()
+	rs := &remoteServer{registry: tools.NewRegistry(), notify: make(chan struct{}, 1)}
+	register := func(name string, schema json.RawMessage) {
+		if err := rs.registry.Register(&tools.Tool{
+			Name:   name,
+			Source: tools.SourceLocal,
+			Schema: schema,
+			Handler: func(ctx context.Context, args map[string]any) (any, error) {
+				rs.calls.Add(1)
+				return fmt.Sprintf("%s %v", name, args["text"]), nil
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	register("echo", json.RawMessage(`{"type":"object","properties":{"text":{"type":"string"}},"required":["text"]}`))
+	register("read_logs", nil)
+	register("delete_logs", nil)
+
+	srv := server.NewServer(server.ServerConfig{
+		Name:         "remote",
+		Version:      "1.0.0",
+		Capabilities: protocol.ServerCapabilities{Tools: &protocol.ToolsCapability{ListChanged: true}},
+	}, rs.registry)
+	handler := srv.HTTPHandler(server.HTTPOptions{AuthToken: testToken})
+
+	rs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(protocol.SessionHeader) != "" && rs.expire.CompareAndSwap(true, false) {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			rs.stream(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(rs.Close)
+	return rs
+}
+
+// stream serves the GET event stream the server package does not offer
+func (rs *remoteServer) stream(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-rs.notify:
+			data, _ := protocol.NewCodec().EncodeRequest(nil, protocol.MethodToolsListChanged, nil)
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			w.(http.Flusher).Flush()
+		}
+	}
+}
+
+func (rs *remoteServer) connect(t *testing.T, autoReconnect bool) *Client {
+	t.Helper()
+	c := NewClient(ClientConfig{
+		ServerURL:     rs.URL,
+		TokenSource:   oauth2.StaticTokenSource(&oauth2.Token{AccessToken: testToken}),
+		Timeout:       5 * time.Second,
+		AutoReconnect: autoReconnect,
+	})
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { c.Disconnect() })
+	return c
+}
+
+func TestClient_RemoteHTTP(t *testing.T) {
+	rs := newRemoteServer(t)
+
+	unauthorized := NewClient(ClientConfig{ServerURL: rs.URL, Timeout: time.Second})
+	if err := unauthorized.Connect(context.Background()); err == nil {
+		t.Fatal("Expected connecting without a token to fail")
+	}
+
+	c := rs.connect(t, false)
+	if !c.IsConnected() {
+		t.Fatal("Expected client to be connected")
+	}
+	if got := len(c.GetCachedTools()); got != 3 {
+		t.Errorf("Expected 3 tools, got %d", got)
+	}
+
+	result, err := c.CallTool(context.Background(), "echo", map[string]any{"text": "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Content) != 1 || result.Content[0].Text != "echo hi" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if err := c.Ping(context.Background()); err != nil {
+		t.Errorf("Ping failed: %v", err)
+	}
+}
+
+func TestDiscovery_RemotePolicyAndSchema(t *testing.T) {
+	rs := newRemoteServer(t)
+	c := rs.connect(t, false)
+
+	registry := tools.NewRegistry()
+	d := NewDiscovery(c, registry, "remote")
+	d.SetPolicy(ToolPolicy{Deny: []string{"delete_*"}})
+
+	count, err := d.DiscoverAndRegister(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 tools registered, got %d", count)
+	}
+	if _, err := registry.Get("mcp:remote:delete_logs"); err == nil {
+		t.Error("Expected denied tool not to be registered")
+	}
+
+	ctx := context.Background()
+	if _, err := registry.Execute(ctx, "mcp:remote:echo", map[string]any{"text": 5}); err == nil {
+		t.Error("Expected arguments not matching the schema to be rejected")
+	}
+	if _, err := registry.Execute(ctx, "mcp:remote:echo", nil); err == nil {
+		t.Error("Expected missing required argument to be rejected")
+	}
+	if got := rs.calls.Load(); got != 0 {
+		t.Errorf("Expected rejected calls not to reach the server, got %d calls", got)
+	}
+
+	result, err := registry.Execute(ctx, "mcp:remote:echo", map[string]any{"text": "ok"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "echo ok" {
+		t.Errorf("Expected %q, got %v", "echo ok", result)
+	}
+}
+
+func TestDiscovery_ToolsListChanged(t *testing.T) {
+	rs := newRemoteServer(t)
+	c := rs.connect(t, false)
+
+	registry := tools.NewRegistry()
+	if _, err := NewDiscovery(c, registry, "remote").DiscoverAndRegister(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rs.registry.Register(&tools.Tool{
+		Name:    "added",
+		Source:  tools.SourceLocal,
+		Handler: func(ctx context.Context, args map[string]any) (any, error) { return "added", nil },
+	}); err != nil {
+		t.Fatal(err)
+	}
+	rs.notify <- struct{}{}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := registry.Get("mcp:remote:added"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected tools to be refreshed after tools/list_changed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClient_ReconnectsExpiredSession(t *testing.T) {
+	rs := newRemoteServer(t)
+
+	// Without AutoReconnect the expired session is reported
+	c := rs.connect(t, false)
+	rs.expire.Store(true)
+	if _, err := c.CallTool(context.Background(), "echo", map[string]any{"text": "a"}); !errors.Is(err, protocol.ErrSessionExpired) {
+		t.Fatalf("Expected ErrSessionExpired, got %v", err)
+	}
+
+	c = rs.connect(t, true)
+	changed := make(chan struct{}, 1)
+	c.OnToolsChanged(func() { changed <- struct{}{} })
+
+	rs.expire.Store(true)
+	result, err := c.CallTool(context.Background(), "echo", map[string]any{"text": "b"})
+	if err != nil {
+		t.Fatalf("Expected call to succeed after reconnecting, got %v", err)
+	}
+	if result.Content[0].Text != "echo b" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Error("Expected tools changed listeners to run after reconnecting")
+	}
+}
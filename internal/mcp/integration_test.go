@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
 	"github.com/kubestack-ai/kubestack-ai/internal/mcp/client"
 	"github.com/kubestack-ai/kubestack-ai/internal/tools"
 )
@@ -252,3 +253,51 @@ func TestMCPBridge_MultipleServers(t *testing.T) {
 		t.Errorf("Expected 2 total servers in pool, got %d", stats.TotalServers)
 	}
 }
+
+func TestBridgeConfigFromConfig(t *testing.T) {
+	t.Setenv("TEST_MCP_TOKEN", "from-env")
+	cfg := config.MCPConfig{
+		AutoDiscover: true,
+		Pool:         config.MCPPoolConfig{MaxIdleTime: time.Minute},
+		Servers: []config.MCPRemoteConfig{
+			{ID: "local", Command: "mcp-server", Env: []string{"TOKEN=${TEST_MCP_TOKEN}"}},
+			{
+				ID:    "remote",
+				URL:   "https://mcp.example.com/mcp",
+				Auth:  config.MCPAuthConfig{BearerToken: "${TEST_MCP_TOKEN}"},
+				Tools: config.MCPToolsConfig{Deny: []string{"delete_*"}},
+			},
+		},
+	}
+
+	bc, err := BridgeConfigFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bc.AutoDiscover || bc.MaxIdleTime != time.Minute || len(bc.Servers) != 2 {
+		t.Fatalf("Unexpected bridge config: %+v", bc)
+	}
+	if bc.Servers[0].Env[0] != "TOKEN=from-env" {
+		t.Errorf("Expected env to be expanded, got %v", bc.Servers[0].Env)
+	}
+	remote := bc.Servers[1]
+	token, err := remote.TokenSource.Token()
+	if err != nil || token.AccessToken != "from-env" {
+		t.Errorf("Expected bearer token from env, got %v (%v)", token, err)
+	}
+	if len(remote.Policy.Deny) != 1 {
+		t.Errorf("Expected tool policy to be set, got %+v", remote.Policy)
+	}
+
+	invalid := []config.MCPRemoteConfig{
+		{ID: "both", Command: "x", URL: "http://localhost"},
+		{ID: "neither"},
+		{Command: "no-id"},
+		{ID: "auth-without-url", Command: "x", Auth: config.MCPAuthConfig{BearerToken: "t"}},
+	}
+	for _, srv := range invalid {
+		if _, err := BridgeConfigFromConfig(config.MCPConfig{Servers: []config.MCPRemoteConfig{srv}}); err == nil {
+			t.Errorf("Expected server %+v to be rejected", srv)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// SessionHeader carries the session ID of the Streamable HTTP transport
+const SessionHeader = "Mcp-Session-Id"
+
+// ErrSessionExpired is returned when the server no longer knows the
+// session; the client has to initialize a new one
+var ErrSessionExpired = errors.New("mcp session expired")
+
+const maxHTTPMessageSize = 4 * 1024 * 1024
+
+// HTTPTransportConfig configures an HTTPTransport
+type HTTPTransportConfig struct {
+	URL     string
+	Headers map[string]string
+	// TokenSource supplies the bearer token sent with every request, e.g.
+	// oauth2.StaticTokenSource or an OAuth client credentials source
+	TokenSource oauth2.TokenSource
+	HTTPClient  *http.Client
+	// MaxRetries is how often a request that could not reach the server is
+	// retried, and how often the event stream is reopened in a row
+	MaxRetries   int
+	RetryBackoff time.Duration
+}
+
+// HTTPTransport implements Transport over MCP Streamable HTTP: messages are
+// POSTed to the server URL, which answers with JSON or an SSE stream.
+// Once a session is established, server-initiated messages such as
+// notifications/tools/list_changed are read from a GET event stream that
+// is reopened with Last-Event-ID when it drops.
+type HTTPTransport struct {
+	config   HTTPTransportConfig
+	client   *http.Client
+	incoming chan []byte
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+
+	mu          sync.Mutex
+	sessionID   string
+	lastEventID string
+	listening   bool
+	closed      bool
+}
+
+// NewHTTPTransport creates a transport to the server at cfg.URL
+func NewHTTPTransport(cfg HTTPTransportConfig) (*HTTPTransport, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid server URL %q", cfg.URL)
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.RetryBackoff == 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		// No client timeout: event streams stay open
+		httpClient = &http.Client{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HTTPTransport{
+		config:   cfg,
+		client:   httpClient,
+		incoming: make(chan []byte, 64),
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+// Send posts a message. Responses and notifications the server answers
+// with are delivered through Receive.
+func (t *HTTPTransport) Send(data []byte) error {
+	t.mu.Lock()
+	closed, hadSession := t.closed, t.sessionID != ""
+	t.mu.Unlock()
+	if closed {
+		return fmt.Errorf("transport is closed")
+	}
+
+	resp, err := t.post(bytes.TrimSpace(data))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && hadSession:
+		resp.Body.Close()
+		t.mu.Lock()
+		t.sessionID = ""
+		t.mu.Unlock()
+		return ErrSessionExpired
+	case resp.StatusCode >= 400:
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("server returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if id := resp.Header.Get(SessionHeader); id != "" {
+		t.setSession(id)
+	}
+	if resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusNoContent {
+		resp.Body.Close()
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		// Long-running calls stream progress before their response
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			t.readStream(resp.Body, false)
+		}()
+		return nil
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPMessageSize))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	t.deliver(body)
+	return nil
+}
+
+// Receive returns the next message from the server
+func (t *HTTPTransport) Receive() ([]byte, error) {
+	select {
+	case data := <-t.incoming:
+		return data, nil
+	case <-t.ctx.Done():
+		return nil, io.EOF
+	}
+}
+
+// Close ends the session on the server and stops the event stream
+func (t *HTTPTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	sessionID := t.sessionID
+	t.mu.Unlock()
+
+	if sessionID != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		if req, err := t.newRequest(ctx, http.MethodDelete, nil); err == nil {
+			if resp, err := t.client.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+		cancel()
+	}
+
+	t.cancel()
+	t.wg.Wait()
+	return nil
+}
+
+// SessionID returns the session ID assigned by the server
+func (t *HTTPTransport) SessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+// post sends a message, retrying when the server cannot be reached. Calls
+// that reached the server are not retried: they may have run.
+func (t *HTTPTransport) post(data []byte) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; attempt <= t.config.MaxRetries; attempt++ {
+		if attempt > 0 && !t.sleep(time.Duration(attempt)*t.config.RetryBackoff) {
+			break
+		}
+		req, err := t.newRequest(t.ctx, http.MethodPost, data)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+
+		resp, err := t.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		switch resp.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			resp.Body.Close()
+			lastErr = fmt.Errorf("server returned %s", resp.Status)
+			continue
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("failed to reach %s: %w", t.config.URL, lastErr)
+}
+
+func (t *HTTPTransport) newRequest(ctx context.Context, method string, body []byte) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, t.config.URL, reader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range t.config.Headers {
+		req.Header.Set(k, v)
+	}
+	if t.config.TokenSource != nil {
+		token, err := t.config.TokenSource.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get access token: %w", err)
+		}
+		token.SetAuthHeader(req)
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set(SessionHeader, t.sessionID)
+	}
+	t.mu.Unlock()
+	return req, nil
+}
+
+// setSession records the session ID and opens the event stream of a new
+// session
+func (t *HTTPTransport) setSession(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != id {
+		t.lastEventID = ""
+	}
+	t.sessionID = id
+	if !t.listening && !t.closed {
+		t.listening = true
+		t.wg.Add(1)
+		go t.listen()
+	}
+}
+
+// listen reads server-initiated messages from the GET event stream,
+// resuming after the last event seen when the stream drops
+func (t *HTTPTransport) listen() {
+	defer t.wg.Done()
+	defer func() {
+		t.mu.Lock()
+		t.listening = false
+		t.mu.Unlock()
+	}()
+
+	failures := 0
+	for t.ctx.Err() == nil {
+		req, err := t.newRequest(t.ctx, http.MethodGet, nil)
+		if err != nil {
+			return
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		t.mu.Lock()
+		if t.sessionID == "" {
+			t.mu.Unlock()
+			return
+		}
+		if t.lastEventID != "" {
+			req.Header.Set("Last-Event-ID", t.lastEventID)
+		}
+		t.mu.Unlock()
+
+		resp, err := t.client.Do(req)
+		switch {
+		case err != nil:
+		case resp.StatusCode == http.StatusMethodNotAllowed, resp.StatusCode == http.StatusNotFound:
+			// The server offers no stream, or the session is gone
+			resp.Body.Close()
+			return
+		case resp.StatusCode == http.StatusOK:
+			failures = 0
+			t.readStream(resp.Body, true)
+		default:
+			resp.Body.Close()
+		}
+
+		failures++
+		if failures > t.config.MaxRetries || !t.sleep(time.Duration(failures)*t.config.RetryBackoff) {
+			return
+		}
+	}
+}
+
+// readStream delivers the messages of an SSE stream until it ends
+func (t *HTTPTransport) readStream(body io.ReadCloser, resumable bool) {
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxHTTPMessageSize)
+	var data []string
+	event := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 && (event == "" || event == "message") {
+				t.deliver([]byte(strings.Join(data, "\n")))
+			}
+			data, event = nil, ""
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			data = append(data, value)
+		case "event":
+			event = value
+		case "id":
+			if resumable {
+				t.mu.Lock()
+				t.lastEventID = value
+				t.mu.Unlock()
+			}
+		}
+	}
+}
+
+// deliver queues a message, or each message of a batch
+func (t *HTTPTransport) deliver(data []byte) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return
+	}
+	messages := []json.RawMessage{data}
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return
+		}
+	}
+	for _, msg := range messages {
+		select {
+		case t.incoming <- []byte(msg):
+		case <-t.ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *HTTPTransport) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-t.ctx.Done():
+		return false
+	}
+}
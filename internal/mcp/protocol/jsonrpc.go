@@ -14,6 +14,9 @@ const (
 	MethodNotFound = -32601
 	InvalidParams  = -32602
 	InternalError  = -32603
+
+	// MCP error codes
+	ResourceNotFound = -32002
 )
 
 // Request represents a JSON-RPC 2.0 request
@@ -13,8 +13,25 @@ const (
 	MethodPromptsList   = "prompts/list"
 	MethodPromptsGet    = "prompts/get"
 	MethodPing          = "ping"
+
+	MethodResourceTemplatesList = "resources/templates/list"
+	MethodCancelled             = "notifications/cancelled"
+	MethodProgress              = "notifications/progress"
+	MethodToolsListChanged      = "notifications/tools/list_changed"
 )
 
+// Protocol versions
+const (
+	// ProtocolVersion is the oldest version supported
+	ProtocolVersion = "2024-11-05"
+	// LatestProtocolVersion adds the Streamable HTTP transport and tool
+	// annotations; clients propose it during initialize
+	LatestProtocolVersion = "2025-03-26"
+)
+
+// SupportedProtocolVersions lists the versions the server accepts, newest first
+var SupportedProtocolVersions = []string{LatestProtocolVersion, ProtocolVersion}
+
 // InitializeParams represents parameters for the initialize request
 type InitializeParams struct {
 	ProtocolVersion string             `json:"protocolVersion"`
@@ -90,9 +107,20 @@ type ToolsListResult struct {
 
 // ToolDefinition represents a tool definition
 type ToolDefinition struct {
-	Name        string          `json:"name"`
-	Description string          `json:"description,omitempty"`
-	InputSchema json.RawMessage `json:"inputSchema"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	InputSchema json.RawMessage  `json:"inputSchema"`
+	Annotations *ToolAnnotations `json:"annotations,omitempty"`
+}
+
+// ToolAnnotations are hints a server gives about a tool's behaviour. They
+// are not verified and must not be trusted from untrusted servers.
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"`
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`
 }
 
 // ToolCallParams represents parameters for a tools/call request
@@ -149,6 +177,20 @@ type ResourceContent struct {
 	Blob     string `json:"blob,omitempty"`
 }
 
+// ResourceTemplate describes a parameterised resource as an RFC 6570 URI template
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceTemplatesListResult represents the response to a resources/templates/list request
+type ResourceTemplatesListResult struct {
+	ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+	NextCursor        *string            `json:"nextCursor,omitempty"`
+}
+
 // PromptsListResult represents the response to a prompts/list request
 type PromptsListResult struct {
 	Prompts    []PromptDefinition `json:"prompts"`
@@ -196,3 +238,17 @@ type MessageContent struct {
 
 // PingResult represents the response to a ping request
 type PingResult struct{}
+
+// CancelledParams represents parameters for a notifications/cancelled notification
+type CancelledParams struct {
+	RequestID any    `json:"requestId"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// ProgressParams represents parameters for a notifications/progress notification
+type ProgressParams struct {
+	ProgressToken any     `json:"progressToken"`
+	Progress      float64 `json:"progress"`
+	Total         float64 `json:"total,omitempty"`
+	Message       string  `json:"message,omitempty"`
+}
@@ -34,6 +34,9 @@ func (s SessionState) String() string {
 	}
 }
 
+// NotificationHandler receives notifications sent by the server
+type NotificationHandler func(method string, params any)
+
 // Session represents an MCP session
 type Session struct {
 	ID              string
@@ -42,8 +45,10 @@ type Session struct {
 	ServerInfo      *ServerInfo
 	Capabilities    *ServerCapabilities
 	State           SessionState
-	pendingRequests map[any]chan *Response
+	pendingRequests map[string]chan *Response
 	nextID          int64
+	onNotification  NotificationHandler
+	received        chan struct{} // closed when the receive loop stops
 	mu              sync.RWMutex
 	ctx             context.Context
 	cancel          context.CancelFunc
@@ -58,8 +63,9 @@ func NewSession(transport Transport) *Session {
 		Transport:       transport,
 		Codec:           NewCodec(),
 		State:           StateDisconnected,
-		pendingRequests: make(map[any]chan *Response),
+		pendingRequests: make(map[string]chan *Response),
 		nextID:          0,
+		received:        make(chan struct{}),
 		ctx:             ctx,
 		cancel:          cancel,
 	}
@@ -76,7 +82,7 @@ func (s *Session) Initialize(clientInfo ClientInfo, caps ClientCapabilities) err
 	s.setState(StateConnecting)
 
 	params := InitializeParams{
-		ProtocolVersion: "2024-11-05",
+		ProtocolVersion: LatestProtocolVersion,
 		Capabilities:    caps,
 		ClientInfo:      clientInfo,
 	}
@@ -120,25 +126,31 @@ func (s *Session) Call(method string, params any) (any, error) {
 
 // CallWithTimeout makes a synchronous RPC call with timeout
 func (s *Session) CallWithTimeout(method string, params any, timeout time.Duration) (any, error) {
-	if s.State == StateDisconnected || s.State == StateError {
+	return s.CallContext(context.Background(), method, params, timeout)
+}
+
+// CallContext makes a synchronous RPC call that ends when ctx is done or
+// the timeout expires, telling the server to cancel it
+func (s *Session) CallContext(ctx context.Context, method string, params any, timeout time.Duration) (any, error) {
+	if state := s.CurrentState(); state == StateDisconnected || state == StateError {
 		return nil, fmt.Errorf("session is not connected")
 	}
 
 	// Generate unique ID
 	id := atomic.AddInt64(&s.nextID, 1)
+	key := requestKey(id)
 
 	// Create response channel
 	respChan := make(chan *Response, 1)
 
 	s.mu.Lock()
-	s.pendingRequests[id] = respChan
+	s.pendingRequests[key] = respChan
 	s.mu.Unlock()
 
 	defer func() {
 		s.mu.Lock()
-		delete(s.pendingRequests, id)
+		delete(s.pendingRequests, key)
 		s.mu.Unlock()
-		close(respChan)
 	}()
 
 	// Encode and send request
@@ -152,18 +164,26 @@ func (s *Session) CallWithTimeout(method string, params any, timeout time.Durati
 	}
 
 	// Wait for response with timeout
-	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	select {
 	case resp := <-respChan:
-		if resp.Error != nil {
-			return nil, resp.Error
+		return responseResult(resp)
+	case <-s.ctx.Done():
+		return nil, fmt.Errorf("session closed")
+	case <-s.received:
+		// A response may have arrived just before the connection dropped
+		select {
+		case resp := <-respChan:
+			return responseResult(resp)
+		default:
 		}
-		return resp.Result, nil
-	case <-ctx.Done():
-		if s.ctx.Err() != nil {
-			return nil, fmt.Errorf("session closed")
+		return nil, fmt.Errorf("connection to server lost")
+	case <-waitCtx.Done():
+		_ = s.Notify(MethodCancelled, CancelledParams{RequestID: id, Reason: waitCtx.Err().Error()})
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
 		return nil, fmt.Errorf("request timeout after %v", timeout)
 	}
@@ -171,7 +191,7 @@ func (s *Session) CallWithTimeout(method string, params any, timeout time.Durati
 
 // Notify sends a notification (no response expected)
 func (s *Session) Notify(method string, params any) error {
-	if s.State == StateDisconnected || s.State == StateError {
+	if state := s.CurrentState(); state == StateDisconnected || state == StateError {
 		return fmt.Errorf("session is not connected")
 	}
 
@@ -191,6 +211,7 @@ func (s *Session) Notify(method string, params any) error {
 // receiveLoop continuously receives and processes messages
 func (s *Session) receiveLoop() {
 	defer s.wg.Done()
+	defer close(s.received)
 
 	for {
 		select {
@@ -201,12 +222,23 @@ func (s *Session) receiveLoop() {
 
 		data, err := s.Transport.Receive()
 		if err != nil {
-			if err.Error() != "EOF" && !s.isClosed() {
+			// The server went away unless we closed the session; calls
+			// fail fast and clients may reconnect
+			if !s.isClosed() {
 				s.setState(StateError)
 			}
 			return
 		}
 
+		// Requests and notifications from the server carry a method
+		var probe struct {
+			Method string `json:"method"`
+		}
+		if json.Unmarshal(data, &probe) == nil && probe.Method != "" {
+			s.handleServerMessage(data)
+			continue
+		}
+
 		// Decode response
 		resp, err := s.Codec.DecodeResponse(data)
 		if err != nil {
@@ -217,39 +249,78 @@ func (s *Session) receiveLoop() {
 		// Handle response
 		if resp.ID != nil {
 			s.mu.RLock()
-			respChan, exists := s.pendingRequests[resp.ID]
+			respChan, exists := s.pendingRequests[requestKey(resp.ID)]
 			s.mu.RUnlock()
 
 			if exists {
 				select {
 				case respChan <- resp:
-				case <-s.ctx.Done():
-					return
+				default:
+					// Duplicate response
 				}
 			}
 		}
-		// Note: Notifications from server could be handled here
+	}
+}
+
+// SetNotificationHandler sets the handler of server notifications, such as
+// notifications/tools/list_changed. It runs on its own goroutine and may
+// call the session.
+func (s *Session) SetNotificationHandler(handler NotificationHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onNotification = handler
+}
+
+// handleServerMessage dispatches a notification or answers a request the
+// server sent
+func (s *Session) handleServerMessage(data []byte) {
+	req, err := s.Codec.DecodeRequest(data)
+	if err != nil {
+		return
+	}
+
+	if req.IsNotification() {
+		s.mu.RLock()
+		handler := s.onNotification
+		s.mu.RUnlock()
+		if handler != nil {
+			go handler(req.Method, req.Params)
+		}
+		return
+	}
+
+	// The client offers no sampling or roots; it only answers pings
+	var reply []byte
+	if req.Method == MethodPing {
+		reply, err = s.Codec.EncodeResponse(req.ID, PingResult{})
+	} else {
+		reply, err = s.Codec.EncodeError(req.ID, MethodNotFound, "Method not found", req.Method)
+	}
+	if err == nil {
+		go s.Transport.Send(reply)
 	}
 }
 
 // Close closes the session
 func (s *Session) Close() error {
 	s.setState(StateDisconnected)
+	// Pending calls return once the context is cancelled
 	s.cancel()
 
-	// Close all pending requests
-	s.mu.Lock()
-	for _, ch := range s.pendingRequests {
-		close(ch)
-	}
-	s.pendingRequests = make(map[any]chan *Response)
-	s.mu.Unlock()
+	// Close the transport first: the receive loop may be blocked reading it
+	err := s.Transport.Close()
 
 	// Wait for receive loop to finish
 	s.wg.Wait()
+	return err
+}
 
-	// Close transport
-	return s.Transport.Close()
+// CurrentState returns the state of the session
+func (s *Session) CurrentState() SessionState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.State
 }
 
 // setState safely updates the session state
@@ -269,6 +340,19 @@ func (s *Session) isClosed() bool {
 	}
 }
 
+func responseResult(resp *Response) (any, error) {
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return resp.Result, nil
+}
+
+// requestKey matches response IDs, decoded from JSON as float64, with the
+// int64 IDs of requests
+func requestKey(id any) string {
+	return fmt.Sprint(id)
+}
+
 // generateSessionID generates a unique session ID
 func generateSessionID() string {
 	return fmt.Sprintf("session-%d", time.Now().UnixNano())
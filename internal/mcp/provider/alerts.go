@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/server"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/storage"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/types"
+)
+
+// defaultAlertLimit bounds the alert history read without a limit
+const defaultAlertLimit = 100
+
+// Alerts serves the alert history as ksa://alerts/history and the current
+// alert states as ksa://alerts/active
+type Alerts struct {
+	store storage.AlertStore
+}
+
+// NewAlerts creates a provider over an alert store
+func NewAlerts(store storage.AlertStore) *Alerts {
+	return &Alerts{store: store}
+}
+
+// Resources lists the alert history and active alerts
+func (p *Alerts) Resources(ctx context.Context) ([]protocol.ResourceDefinition, error) {
+	return []protocol.ResourceDefinition{
+		{
+			URI:         "ksa://alerts/history",
+			Name:        "Alert history",
+			Description: fmt.Sprintf("The %d most recent alerts", defaultAlertLimit),
+			MimeType:    mimeJSON,
+		},
+		{
+			URI:         "ksa://alerts/active",
+			Name:        "Active alerts",
+			Description: "Alert instances that are pending or firing",
+			MimeType:    mimeJSON,
+		},
+	}, nil
+}
+
+// Templates describes filtered alert history URIs
+func (p *Alerts) Templates() []protocol.ResourceTemplate {
+	return []protocol.ResourceTemplate{{
+		URITemplate: "ksa://alerts/history{?severity,status,limit}",
+		Name:        "Alert history",
+		Description: "Recent alerts filtered by severity and status (firing/resolved)",
+		MimeType:    mimeJSON,
+	}}
+}
+
+// Read queries the alert store
+func (p *Alerts) Read(ctx context.Context, uri string) ([]protocol.ResourceContent, error) {
+	segments, query, ok := parseURI(uri, "alerts")
+	if !ok || len(segments) != 1 {
+		return nil, server.ErrResourceNotFound
+	}
+
+	switch segments[0] {
+	case "history":
+		limit := defaultAlertLimit
+		if v := query.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return nil, &protocol.RPCError{Code: protocol.InvalidParams, Message: "Invalid params", Data: "limit must be a positive integer"}
+			}
+			limit = n
+		}
+		alerts, err := p.store.Query(ctx, &storage.AlertQuery{
+			Severity: query.Get("severity"),
+			Status:   query.Get("status"),
+			Limit:    strconv.Itoa(limit),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to query alerts: %w", err)
+		}
+		if alerts == nil {
+			alerts = []*types.Alert{}
+		}
+		return jsonContent(uri, alerts)
+	case "active":
+		states, err := p.store.ListStates(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list alert states: %w", err)
+		}
+		active := []*types.AlertInstance{}
+		for _, s := range states {
+			if s.State == types.AlertStatePending || s.State == types.AlertStateFiring {
+				active = append(active, s)
+			}
+		}
+		return jsonContent(uri, active)
+	}
+	return nil, server.ErrResourceNotFound
+}
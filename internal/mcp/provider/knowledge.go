@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"context"
+	"sort"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/knowledge"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/server"
+)
+
+// Knowledge serves knowledge base rules as ksa://knowledge/rules/{id}
+type Knowledge struct {
+	kb *knowledge.KnowledgeBase
+}
+
+// NewKnowledge creates a provider for the rules of kb
+func NewKnowledge(kb *knowledge.KnowledgeBase) *Knowledge {
+	return &Knowledge{kb: kb}
+}
+
+// Resources lists every rule
+func (p *Knowledge) Resources(ctx context.Context) ([]protocol.ResourceDefinition, error) {
+	rules, err := p.kb.GetAllRules()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	resources := make([]protocol.ResourceDefinition, 0, len(rules))
+	for _, rule := range rules {
+		resources = append(resources, protocol.ResourceDefinition{
+			URI:         "ksa://knowledge/rules/" + rule.ID,
+			Name:        rule.Name,
+			Description: rule.Recommendation,
+			MimeType:    mimeJSON,
+		})
+	}
+	return resources, nil
+}
+
+// Templates describes rule URIs
+func (p *Knowledge) Templates() []protocol.ResourceTemplate {
+	return []protocol.ResourceTemplate{
+		{
+			URITemplate: "ksa://knowledge/rules/{id}",
+			Name:        "Knowledge rule",
+			Description: "A diagnosis rule of the knowledge base",
+			MimeType:    mimeJSON,
+		},
+		{
+			URITemplate: "ksa://knowledge/rules{?middleware}",
+			Name:        "Knowledge rules",
+			Description: "The rules of the knowledge base, optionally of one middleware type",
+			MimeType:    mimeJSON,
+		},
+	}
+}
+
+// Read returns a rule, or the rules of a middleware type
+func (p *Knowledge) Read(ctx context.Context, uri string) ([]protocol.ResourceContent, error) {
+	segments, query, ok := parseURI(uri, "knowledge")
+	if !ok || len(segments) == 0 || segments[0] != "rules" {
+		return nil, server.ErrResourceNotFound
+	}
+
+	switch len(segments) {
+	case 1:
+		rules, err := p.kb.QueryRules(knowledge.QueryOptions{MiddlewareType: query.Get("middleware")})
+		if err != nil {
+			return nil, err
+		}
+		if rules == nil {
+			rules = []*knowledge.Rule{}
+		}
+		// Highest priority first
+		return jsonContent(uri, rules)
+	case 2:
+		rule, err := p.kb.GetRule(segments[1])
+		if err != nil {
+			return nil, server.ErrResourceNotFound
+		}
+		return jsonContent(uri, rule)
+	}
+	return nil, server.ErrResourceNotFound
+}
@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/server"
+)
+
+// Metrics serves a snapshot of the metrics a plugin collects from an
+// instance as ksa://metrics/{middleware}/{instance}
+type Metrics struct {
+	plugins interfaces.PluginManager
+}
+
+// NewMetrics creates a provider collecting through the plugin manager
+func NewMetrics(plugins interfaces.PluginManager) *Metrics {
+	return &Metrics{plugins: plugins}
+}
+
+// MetricSnapshot is the content of a metrics resource
+type MetricSnapshot struct {
+	Middleware  string                 `json:"middleware"`
+	Instance    string                 `json:"instance"`
+	Namespace   string                 `json:"namespace,omitempty"`
+	CollectedAt time.Time              `json:"collected_at"`
+	Metrics     map[string]interface{} `json:"metrics"`
+}
+
+// Resources lists nothing: instances are named by the client
+func (p *Metrics) Resources(ctx context.Context) ([]protocol.ResourceDefinition, error) {
+	return nil, nil
+}
+
+// Templates describes metric snapshot URIs
+func (p *Metrics) Templates() []protocol.ResourceTemplate {
+	return []protocol.ResourceTemplate{{
+		URITemplate: "ksa://metrics/{middleware}/{instance}{?namespace}",
+		Name:        "Metric snapshot",
+		Description: "Current metrics of a middleware instance, collected by its plugin",
+		MimeType:    mimeJSON,
+	}}
+}
+
+// Read collects the metrics of an instance
+func (p *Metrics) Read(ctx context.Context, uri string) ([]protocol.ResourceContent, error) {
+	segments, query, ok := parseURI(uri, "metrics")
+	if !ok || len(segments) != 2 {
+		return nil, server.ErrResourceNotFound
+	}
+
+	middleware, err := enum.ParseMiddlewareType(segments[0])
+	if err != nil {
+		return nil, server.ErrResourceNotFound
+	}
+	req := &models.DiagnosisRequest{
+		TargetMiddleware: middleware,
+		Instance:         segments[1],
+		Namespace:        query.Get("namespace"),
+	}
+	data, err := p.plugins.CollectData(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect metrics of %s/%s: %w", segments[0], segments[1], err)
+	}
+
+	snapshot := MetricSnapshot{
+		Middleware:  segments[0],
+		Instance:    segments[1],
+		Namespace:   req.Namespace,
+		CollectedAt: time.Now().UTC(),
+		Metrics:     map[string]interface{}{},
+	}
+	if data != nil && data.Metrics != nil && data.Metrics.Data != nil {
+		snapshot.Metrics = data.Metrics.Data
+	}
+	return jsonContent(uri, snapshot)
+}
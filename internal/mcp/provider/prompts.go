@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/prompt"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/server"
+)
+
+// Prompts serves the diagnosis prompt templates of the prompt catalog
+type Prompts struct{}
+
+// NewPrompts creates the prompt catalog provider
+func NewPrompts() *Prompts {
+	return &Prompts{}
+}
+
+// Prompts lists the catalog
+func (p *Prompts) Prompts(ctx context.Context) ([]protocol.PromptDefinition, error) {
+	catalog := prompt.Catalog()
+	prompts := make([]protocol.PromptDefinition, 0, len(catalog))
+	for _, def := range catalog {
+		args := make([]protocol.PromptArgument, 0, len(def.Arguments))
+		for _, a := range def.Arguments {
+			args = append(args, protocol.PromptArgument{Name: a.Name, Description: a.Description, Required: a.Required})
+		}
+		prompts = append(prompts, protocol.PromptDefinition{
+			Name:        def.Name,
+			Description: def.Description,
+			Arguments:   args,
+		})
+	}
+	return prompts, nil
+}
+
+// GetPrompt renders a catalog prompt as a single user message
+func (p *Prompts) GetPrompt(ctx context.Context, name string, args map[string]string) (*protocol.PromptsGetResult, error) {
+	text, err := prompt.Render(name, args)
+	if errors.Is(err, prompt.ErrUnknownPrompt) {
+		return nil, server.ErrPromptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var description string
+	for _, def := range prompt.Catalog() {
+		if def.Name == name {
+			description = def.Description
+		}
+	}
+	return &protocol.PromptsGetResult{
+		Description: description,
+		Messages: []protocol.PromptMessage{{
+			Role:    "user",
+			Content: protocol.MessageContent{Type: "text", Text: text},
+		}},
+	}, nil
+}
@@ -0,0 +1,55 @@
+// Package provider exposes KubeStack-AI data to MCP clients as resources
+// and prompts of the MCP server.
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/server"
+)
+
+// Scheme is the URI scheme of KubeStack-AI resources
+const Scheme = "ksa"
+
+const mimeJSON = "application/json"
+
+// parseURI splits a ksa:// URI of the given host into its path segments
+// and query. ok is false for URIs of other schemes or hosts.
+func parseURI(uri, host string) (segments []string, query url.Values, ok bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != Scheme || u.Host != host {
+		return nil, nil, false
+	}
+	for _, s := range strings.Split(strings.Trim(u.Path, "/"), "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments, u.Query(), true
+}
+
+// jsonContent encodes v as the JSON content of uri
+func jsonContent(uri string, v any) ([]protocol.ResourceContent, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	// Rule conditions read better with their comparison operators intact
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	text := strings.TrimSuffix(buf.String(), "\n")
+	return []protocol.ResourceContent{{URI: uri, MimeType: mimeJSON, Text: text}}, nil
+}
+
+var (
+	_ server.ResourceProvider = (*Reports)(nil)
+	_ server.ResourceProvider = (*Knowledge)(nil)
+	_ server.ResourceProvider = (*Alerts)(nil)
+	_ server.ResourceProvider = (*Metrics)(nil)
+	_ server.PromptProvider   = (*Prompts)(nil)
+)
@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/knowledge"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/server"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/storage"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubManager struct {
+	interfaces.DiagnosisManager
+	runs int
+}
+
+func (m *stubManager) RunDiagnosis(ctx context.Context, req *models.DiagnosisRequest, progress chan<- interfaces.DiagnosisProgress) (*models.DiagnosisResult, error) {
+	m.runs++
+	return &models.DiagnosisResult{ID: req.Instance, Timestamp: time.Now(), Summary: "summary of " + req.Instance}, nil
+}
+
+func (m *stubManager) GetDiagnosisResult(id string) (*models.DiagnosisResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *stubManager) GenerateReport(result *models.DiagnosisResult) (string, error) {
+	return "# Report " + result.ID, nil
+}
+
+func TestReports_RecordsDiagnoses(t *testing.T) {
+	ctx := context.Background()
+	log := NewReportLog(2)
+	manager := RecordReports(&stubManager{}, log)
+	for _, id := range []string{"a", "b", "c"} {
+		_, err := manager.RunDiagnosis(ctx, &models.DiagnosisRequest{Instance: id}, nil)
+		require.NoError(t, err)
+	}
+
+	p := NewReports(log, manager)
+	resources, err := p.Resources(ctx)
+	require.NoError(t, err)
+	require.Len(t, resources, 2, "the oldest report is evicted")
+	assert.Equal(t, "ksa://diagnosis/reports/c", resources[0].URI)
+
+	contents, err := p.Read(ctx, "ksa://diagnosis/reports/b")
+	require.NoError(t, err)
+	assert.Equal(t, "# Report b", contents[0].Text)
+
+	_, err = p.Read(ctx, "ksa://diagnosis/reports/a")
+	assert.ErrorIs(t, err, server.ErrResourceNotFound)
+	_, err = p.Read(ctx, "ksa://knowledge/rules/b")
+	assert.ErrorIs(t, err, server.ErrResourceNotFound)
+}
+
+func TestKnowledge_ReadsRules(t *testing.T) {
+	ctx := context.Background()
+	kb := knowledge.NewKnowledgeBase()
+	require.NoError(t, kb.AddRule(&knowledge.Rule{ID: "redis-1", Name: "Memory", MiddlewareType: "redis", Severity: "high", Condition: "memory > 90"}))
+	require.NoError(t, kb.AddRule(&knowledge.Rule{ID: "mysql-1", Name: "Slow", MiddlewareType: "mysql", Severity: "low", Condition: "slow > 10"}))
+
+	p := NewKnowledge(kb)
+	resources, err := p.Resources(ctx)
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+	assert.Equal(t, "ksa://knowledge/rules/mysql-1", resources[0].URI)
+
+	contents, err := p.Read(ctx, "ksa://knowledge/rules/redis-1")
+	require.NoError(t, err)
+	assert.Contains(t, contents[0].Text, `"condition": "memory > 90"`)
+
+	contents, err = p.Read(ctx, "ksa://knowledge/rules?middleware=mysql")
+	require.NoError(t, err)
+	assert.Contains(t, contents[0].Text, "mysql-1")
+	assert.NotContains(t, contents[0].Text, "redis-1")
+
+	_, err = p.Read(ctx, "ksa://knowledge/rules/missing")
+	assert.ErrorIs(t, err, server.ErrResourceNotFound)
+}
+
+func TestAlerts_FiltersHistory(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteAlertStore(filepath.Join(t.TempDir(), "alerts.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	for i, severity := range []string{"critical", "warning", "critical"} {
+		require.NoError(t, store.Save(ctx, &types.Alert{
+			RuleName: "rule", Severity: severity, Status: "firing",
+			FiredAt: time.Now().Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	p := NewAlerts(store)
+	contents, err := p.Read(ctx, "ksa://alerts/history?severity=critical&limit=5")
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(contents[0].Text, `"severity": "critical"`))
+	assert.NotContains(t, contents[0].Text, "warning")
+
+	_, err = p.Read(ctx, "ksa://alerts/history?limit=-1")
+	assert.Error(t, err)
+
+	contents, err = p.Read(ctx, "ksa://alerts/active")
+	require.NoError(t, err)
+	assert.Equal(t, "[]", contents[0].Text)
+}
+
+func TestPrompts_RendersCatalog(t *testing.T) {
+	ctx := context.Background()
+	p := NewPrompts()
+	prompts, err := p.Prompts(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, prompts)
+
+	result, err := p.GetPrompt(ctx, "question_clarification", map[string]string{"user_input": "redis is slow"})
+	require.NoError(t, err)
+	require.Len(t, result.Messages, 1)
+	assert.Contains(t, result.Messages[0].Content.Text, "redis is slow")
+
+	_, err = p.GetPrompt(ctx, "nope", nil)
+	assert.ErrorIs(t, err, server.ErrPromptNotFound)
+}
@@ -0,0 +1,159 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/server"
+)
+
+// DefaultReportLogSize is the number of reports a ReportLog keeps by default
+const DefaultReportLogSize = 100
+
+// ReportLog keeps the most recent diagnosis results in memory
+type ReportLog struct {
+	mu      sync.RWMutex
+	max     int
+	order   []string
+	results map[string]*models.DiagnosisResult
+}
+
+// NewReportLog creates a ReportLog keeping up to max results
+func NewReportLog(max int) *ReportLog {
+	if max <= 0 {
+		max = DefaultReportLogSize
+	}
+	return &ReportLog{max: max, results: make(map[string]*models.DiagnosisResult)}
+}
+
+// Add records a result, evicting the oldest one when full
+func (l *ReportLog) Add(result *models.DiagnosisResult) {
+	if result == nil || result.ID == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.results[result.ID]; !ok {
+		l.order = append(l.order, result.ID)
+	}
+	l.results[result.ID] = result
+	for len(l.order) > l.max {
+		delete(l.results, l.order[0])
+		l.order = l.order[1:]
+	}
+}
+
+// Get returns a recorded result
+func (l *ReportLog) Get(id string) (*models.DiagnosisResult, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	result, ok := l.results[id]
+	return result, ok
+}
+
+// List returns the recorded results, newest first
+func (l *ReportLog) List() []*models.DiagnosisResult {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := make([]*models.DiagnosisResult, 0, len(l.order))
+	for i := len(l.order) - 1; i >= 0; i-- {
+		out = append(out, l.results[l.order[i]])
+	}
+	return out
+}
+
+// RecordReports wraps a diagnosis manager so that the results of the
+// diagnoses it runs are recorded in log and can be retrieved by ID.
+func RecordReports(m interfaces.DiagnosisManager, log *ReportLog) interfaces.DiagnosisManager {
+	return &recordingManager{DiagnosisManager: m, log: log}
+}
+
+type recordingManager struct {
+	interfaces.DiagnosisManager
+	log *ReportLog
+}
+
+func (m *recordingManager) RunDiagnosis(ctx context.Context, req *models.DiagnosisRequest, progress chan<- interfaces.DiagnosisProgress) (*models.DiagnosisResult, error) {
+	result, err := m.DiagnosisManager.RunDiagnosis(ctx, req, progress)
+	if err == nil {
+		m.log.Add(result)
+	}
+	return result, err
+}
+
+func (m *recordingManager) GetDiagnosisResult(id string) (*models.DiagnosisResult, error) {
+	if result, ok := m.log.Get(id); ok {
+		return result, nil
+	}
+	return m.DiagnosisManager.GetDiagnosisResult(id)
+}
+
+// Reports serves diagnosis reports as ksa://diagnosis/reports/{id}
+type Reports struct {
+	log     *ReportLog
+	manager interfaces.DiagnosisManager
+}
+
+// NewReports creates a provider listing the reports in log and reading
+// them through manager
+func NewReports(log *ReportLog, manager interfaces.DiagnosisManager) *Reports {
+	return &Reports{log: log, manager: manager}
+}
+
+// Resources lists the recorded reports
+func (p *Reports) Resources(ctx context.Context) ([]protocol.ResourceDefinition, error) {
+	results := p.log.List()
+	resources := make([]protocol.ResourceDefinition, 0, len(results))
+	for _, result := range results {
+		resources = append(resources, protocol.ResourceDefinition{
+			URI:         reportURI(result.ID),
+			Name:        fmt.Sprintf("Diagnosis %s (%s)", result.ID, result.Timestamp.Format("2006-01-02 15:04:05")),
+			Description: result.Summary,
+			MimeType:    "text/markdown",
+		})
+	}
+	return resources, nil
+}
+
+// Templates describes report URIs
+func (p *Reports) Templates() []protocol.ResourceTemplate {
+	return []protocol.ResourceTemplate{{
+		URITemplate: "ksa://diagnosis/reports/{id}",
+		Name:        "Diagnosis report",
+		Description: "Report of a completed diagnosis",
+		MimeType:    "text/markdown",
+	}}
+}
+
+// Read renders a report
+func (p *Reports) Read(ctx context.Context, uri string) ([]protocol.ResourceContent, error) {
+	segments, _, ok := parseURI(uri, "diagnosis")
+	if !ok || len(segments) != 2 || segments[0] != "reports" {
+		return nil, server.ErrResourceNotFound
+	}
+	result, found := p.log.Get(segments[1])
+	if !found && p.manager != nil {
+		if r, err := p.manager.GetDiagnosisResult(segments[1]); err == nil && r != nil {
+			result, found = r, true
+		}
+	}
+	if !found {
+		return nil, server.ErrResourceNotFound
+	}
+
+	if p.manager != nil {
+		if report, err := p.manager.GenerateReport(result); err == nil {
+			return []protocol.ResourceContent{{URI: uri, MimeType: "text/markdown", Text: report}}, nil
+		}
+	}
+	return jsonContent(uri, result)
+}
+
+func reportURI(id string) string {
+	return "ksa://diagnosis/reports/" + id
+}
@@ -41,14 +41,18 @@ func (h *InitializeHandler) Handle(ctx context.Context, params any) (any, error)
 		return nil, fmt.Errorf("failed to parse initialize params: %w", err)
 	}
 
-	// Validate protocol version
-	if initParams.ProtocolVersion != "2024-11-05" {
-		// Accept it anyway but log warning
+	// Answer with the requested version when supported, otherwise with
+	// the latest one and let the client decide whether to continue
+	version := protocol.LatestProtocolVersion
+	for _, v := range protocol.SupportedProtocolVersions {
+		if v == initParams.ProtocolVersion {
+			version = v
+		}
 	}
 
 	// Return initialize result
 	result := protocol.InitializeResult{
-		ProtocolVersion: "2024-11-05",
+		ProtocolVersion: version,
 		Capabilities:    h.capabilities,
 		ServerInfo:      h.serverInfo,
 	}
@@ -135,7 +139,7 @@ func (h *ToolsCallHandler) Handle(ctx context.Context, params any) (any, error)
 	content := []protocol.ContentBlock{
 		{
 			Type: "text",
-			Text: fmt.Sprintf("%v", result),
+			Text: resultText(result),
 		},
 	}
 
@@ -157,3 +161,42 @@ func NewPingHandler() *PingHandler {
 func (h *PingHandler) Handle(ctx context.Context, params any) (any, error) {
 	return protocol.PingResult{}, nil
 }
+
+// resultText renders a tool result for a text content block. Strings are
+// passed through and structured results such as diagnosis reports are
+// encoded as JSON.
+func resultText(result any) string {
+	switch v := result.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	case nil:
+		return ""
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", result)
+	}
+	return string(data)
+}
+
+// decodeParams converts the generic params of a request into v
+func decodeParams(params any, v any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return invalidParams(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return invalidParams(err)
+	}
+	return nil
+}
+
+func invalidParams(err error) *protocol.RPCError {
+	return &protocol.RPCError{
+		Code:    protocol.InvalidParams,
+		Message: "Invalid params",
+		Data:    err.Error(),
+	}
+}
@@ -0,0 +1,383 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+)
+
+// SessionHeader carries the session ID of the Streamable HTTP transport
+const SessionHeader = protocol.SessionHeader
+
+const (
+	// DefaultSessionTimeout drops HTTP sessions idle for longer
+	DefaultSessionTimeout = 30 * time.Minute
+	maxHTTPBodySize       = 4 * 1024 * 1024
+)
+
+// HTTPOptions configures the Streamable HTTP transport
+type HTTPOptions struct {
+	// AuthToken, when set, must be sent as a bearer token on every request
+	AuthToken string
+	// AllowedOrigins lists the browser origins accepted, "*" accepts any.
+	// When empty only localhost origins are; requests without an Origin
+	// header are always accepted.
+	AllowedOrigins []string
+	// SessionTimeout drops sessions idle for longer
+	SessionTimeout time.Duration
+}
+
+// httpTransport implements the MCP Streamable HTTP transport: clients POST
+// JSON-RPC messages to a single endpoint and receive the responses either
+// as JSON or, when they accept it and asked for progress, as an SSE stream.
+type httpTransport struct {
+	server *Server
+	opts   HTTPOptions
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// HTTPHandler returns a handler serving the Streamable HTTP transport
+func (s *Server) HTTPHandler(opts HTTPOptions) http.Handler {
+	if opts.SessionTimeout <= 0 {
+		opts.SessionTimeout = DefaultSessionTimeout
+	}
+	return &httpTransport{
+		server:   s,
+		opts:     opts,
+		sessions: make(map[string]*session),
+	}
+}
+
+func (t *httpTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !t.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !t.originAllowed(r.Header.Get("Origin")) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	if t.server.isShutdown() {
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		t.handlePost(w, r)
+	case http.MethodDelete:
+		t.handleDelete(w, r)
+	default:
+		// The server never initiates requests, so there is no stream to GET
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (t *httpTransport) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPBodySize+1))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxHTTPBodySize {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	messages, batch, err := splitMessages(body)
+	if err != nil {
+		t.writeJSON(w, http.StatusBadRequest, decodeErrorResponse(err))
+		return
+	}
+
+	var requests, notifications []*protocol.Request
+	initialize := false
+	for _, raw := range messages {
+		var probe struct {
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal(raw, &probe); err == nil && probe.Method == "" {
+			// A response; the server sends no requests that need one
+			continue
+		}
+		req, err := t.server.codec.DecodeRequest(raw)
+		if err != nil {
+			t.writeJSON(w, http.StatusBadRequest, decodeErrorResponse(err))
+			return
+		}
+		if req.IsNotification() {
+			notifications = append(notifications, req)
+			continue
+		}
+		if req.Method == protocol.MethodInitialize {
+			initialize = true
+		}
+		requests = append(requests, req)
+	}
+
+	sess, status := t.session(r, initialize)
+	if sess == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	w.Header().Set(SessionHeader, sess.id)
+
+	for _, n := range notifications {
+		sess.notification(n)
+	}
+	if len(requests) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if acceptsEventStream(r) && wantsProgress(requests) {
+		t.stream(w, r, sess, requests)
+		return
+	}
+
+	responses := make([]*protocol.Response, len(requests))
+	var wg sync.WaitGroup
+	for i, req := range requests {
+		wg.Add(1)
+		go func(i int, req *protocol.Request) {
+			defer wg.Done()
+			responses[i] = sess.call(r.Context(), req, nil)
+		}(i, req)
+	}
+	wg.Wait()
+
+	var answered []*protocol.Response
+	for _, resp := range responses {
+		if resp != nil {
+			answered = append(answered, resp)
+		}
+	}
+	switch {
+	case len(answered) == 0:
+		w.WriteHeader(http.StatusAccepted)
+	case batch:
+		t.writeJSON(w, http.StatusOK, answered)
+	default:
+		t.writeJSON(w, http.StatusOK, answered[0])
+	}
+}
+
+// stream answers requests over SSE, sending their progress notifications
+// before the responses
+func (t *httpTransport) stream(w http.ResponseWriter, r *http.Request, sess *session, requests []*protocol.Request) {
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	var writeMu sync.Mutex
+	send := func(data []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", data); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+	notify := func(method string, params any) error {
+		data, err := t.server.codec.EncodeRequest(nil, method, params)
+		if err != nil {
+			return err
+		}
+		return send(data)
+	}
+
+	var wg sync.WaitGroup
+	for _, req := range requests {
+		wg.Add(1)
+		go func(req *protocol.Request) {
+			defer wg.Done()
+			resp := sess.call(r.Context(), req, notify)
+			if resp == nil {
+				return
+			}
+			if data, err := t.server.encodeResponse(resp); err == nil {
+				_ = send(data)
+			}
+		}(req)
+	}
+	wg.Wait()
+}
+
+func (t *httpTransport) handleDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get(SessionHeader)
+	if id == "" {
+		http.Error(w, "missing "+SessionHeader, http.StatusBadRequest)
+		return
+	}
+	t.mu.Lock()
+	sess, ok := t.sessions[id]
+	delete(t.sessions, id)
+	t.mu.Unlock()
+	if !ok {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	sess.close()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// session returns the session of a request, starting one on initialize.
+// Without a session it returns the status to answer with.
+func (t *httpTransport) session(r *http.Request, initialize bool) (*session, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.expireSessions()
+	if initialize {
+		sess := t.server.newSession(context.Background(), newSessionID())
+		t.sessions[sess.id] = sess
+		return sess, http.StatusOK
+	}
+
+	id := r.Header.Get(SessionHeader)
+	if id == "" {
+		return nil, http.StatusBadRequest
+	}
+	sess, ok := t.sessions[id]
+	if !ok {
+		return nil, http.StatusNotFound
+	}
+	sess.touch()
+	return sess, http.StatusOK
+}
+
+// expireSessions drops idle sessions; t.mu must be held
+func (t *httpTransport) expireSessions() {
+	deadline := time.Now().Add(-t.opts.SessionTimeout)
+	for id, sess := range t.sessions {
+		if sess.idleSince().Before(deadline) {
+			sess.close()
+			delete(t.sessions, id)
+		}
+	}
+}
+
+func (t *httpTransport) authorized(r *http.Request) bool {
+	if t.opts.AuthToken == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(t.opts.AuthToken)) == 1
+}
+
+// originAllowed guards against DNS rebinding from browsers
+func (t *httpTransport) originAllowed(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range t.opts.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	if len(t.opts.AllowedOrigins) > 0 {
+		return false
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (t *httpTransport) writeJSON(w http.ResponseWriter, status int, v any) {
+	var data []byte
+	var err error
+	switch v := v.(type) {
+	case *protocol.Response:
+		data, err = t.server.encodeResponse(v)
+	case []*protocol.Response:
+		parts := make([]json.RawMessage, 0, len(v))
+		for _, resp := range v {
+			part, encErr := t.server.encodeResponse(resp)
+			if encErr != nil {
+				err = encErr
+				break
+			}
+			parts = append(parts, part)
+		}
+		if err == nil {
+			data, err = json.Marshal(parts)
+		}
+	}
+	if err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+// splitMessages splits a POST body into its JSON-RPC messages
+func splitMessages(body []byte) ([]json.RawMessage, bool, error) {
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "[") {
+		var batch []json.RawMessage
+		if err := json.Unmarshal([]byte(trimmed), &batch); err != nil {
+			return nil, true, err
+		}
+		if len(batch) == 0 {
+			return nil, true, &protocol.RPCError{Code: protocol.InvalidRequest, Message: "Invalid Request", Data: "empty batch"}
+		}
+		return batch, true, nil
+	}
+	if !json.Valid([]byte(trimmed)) {
+		return nil, false, fmt.Errorf("invalid JSON")
+	}
+	return []json.RawMessage{json.RawMessage(trimmed)}, false, nil
+}
+
+func acceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+func wantsProgress(requests []*protocol.Request) bool {
+	for _, req := range requests {
+		if progressToken(req.Params) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
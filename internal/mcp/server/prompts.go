@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+)
+
+// ErrPromptNotFound is returned by a PromptProvider for unknown prompt names
+var ErrPromptNotFound = errors.New("prompt not found")
+
+// PromptProvider exposes prompt templates
+type PromptProvider interface {
+	Prompts(ctx context.Context) ([]protocol.PromptDefinition, error)
+	// GetPrompt renders a prompt, or returns ErrPromptNotFound
+	GetPrompt(ctx context.Context, name string, args map[string]string) (*protocol.PromptsGetResult, error)
+}
+
+// PromptsListHandler handles prompts/list requests
+type PromptsListHandler struct {
+	providers []PromptProvider
+}
+
+// NewPromptsListHandler creates a new prompts/list handler
+func NewPromptsListHandler(providers []PromptProvider) *PromptsListHandler {
+	return &PromptsListHandler{providers: providers}
+}
+
+// Handle processes the prompts/list request
+func (h *PromptsListHandler) Handle(ctx context.Context, params any) (any, error) {
+	prompts := make([]protocol.PromptDefinition, 0)
+	for _, p := range h.providers {
+		list, err := p.Prompts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, list...)
+	}
+	return protocol.PromptsListResult{Prompts: prompts}, nil
+}
+
+// PromptsGetHandler handles prompts/get requests
+type PromptsGetHandler struct {
+	providers []PromptProvider
+}
+
+// NewPromptsGetHandler creates a new prompts/get handler
+func NewPromptsGetHandler(providers []PromptProvider) *PromptsGetHandler {
+	return &PromptsGetHandler{providers: providers}
+}
+
+// Handle processes the prompts/get request
+func (h *PromptsGetHandler) Handle(ctx context.Context, params any) (any, error) {
+	var getParams protocol.PromptsGetParams
+	if err := decodeParams(params, &getParams); err != nil {
+		return nil, err
+	}
+
+	// Prompt arguments are strings on the wire; tolerate other JSON values
+	args := make(map[string]string, len(getParams.Arguments))
+	for k, v := range getParams.Arguments {
+		if s, ok := v.(string); ok {
+			args[k] = s
+		} else {
+			args[k] = fmt.Sprint(v)
+		}
+	}
+
+	for _, p := range h.providers {
+		result, err := p.GetPrompt(ctx, getParams.Name, args)
+		if errors.Is(err, ErrPromptNotFound) {
+			continue
+		}
+		if err != nil {
+			// Missing or malformed arguments
+			return nil, invalidParams(err)
+		}
+		return result, nil
+	}
+	return nil, invalidParams(fmt.Errorf("unknown prompt: %s", getParams.Name))
+}
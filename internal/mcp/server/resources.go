@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+)
+
+// ErrResourceNotFound is returned by a ResourceProvider for URIs it does not serve
+var ErrResourceNotFound = errors.New("resource not found")
+
+// ResourceProvider exposes a family of resources, e.g. diagnosis reports
+type ResourceProvider interface {
+	// Resources lists the resources currently available
+	Resources(ctx context.Context) ([]protocol.ResourceDefinition, error)
+	// Templates describes parameterised resources that are read without
+	// being listed
+	Templates() []protocol.ResourceTemplate
+	// Read returns the contents of uri, or ErrResourceNotFound
+	Read(ctx context.Context, uri string) ([]protocol.ResourceContent, error)
+}
+
+// ResourcesListHandler handles resources/list requests
+type ResourcesListHandler struct {
+	providers []ResourceProvider
+}
+
+// NewResourcesListHandler creates a new resources/list handler
+func NewResourcesListHandler(providers []ResourceProvider) *ResourcesListHandler {
+	return &ResourcesListHandler{providers: providers}
+}
+
+// Handle processes the resources/list request
+func (h *ResourcesListHandler) Handle(ctx context.Context, params any) (any, error) {
+	resources := make([]protocol.ResourceDefinition, 0)
+	for _, p := range h.providers {
+		list, err := p.Resources(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, list...)
+	}
+	return protocol.ResourcesListResult{Resources: resources}, nil
+}
+
+// ResourceTemplatesListHandler handles resources/templates/list requests
+type ResourceTemplatesListHandler struct {
+	providers []ResourceProvider
+}
+
+// NewResourceTemplatesListHandler creates a new resources/templates/list handler
+func NewResourceTemplatesListHandler(providers []ResourceProvider) *ResourceTemplatesListHandler {
+	return &ResourceTemplatesListHandler{providers: providers}
+}
+
+// Handle processes the resources/templates/list request
+func (h *ResourceTemplatesListHandler) Handle(ctx context.Context, params any) (any, error) {
+	templates := make([]protocol.ResourceTemplate, 0)
+	for _, p := range h.providers {
+		templates = append(templates, p.Templates()...)
+	}
+	return protocol.ResourceTemplatesListResult{ResourceTemplates: templates}, nil
+}
+
+// ResourcesReadHandler handles resources/read requests
+type ResourcesReadHandler struct {
+	providers []ResourceProvider
+}
+
+// NewResourcesReadHandler creates a new resources/read handler
+func NewResourcesReadHandler(providers []ResourceProvider) *ResourcesReadHandler {
+	return &ResourcesReadHandler{providers: providers}
+}
+
+// Handle processes the resources/read request
+func (h *ResourcesReadHandler) Handle(ctx context.Context, params any) (any, error) {
+	var readParams protocol.ResourcesReadParams
+	if err := decodeParams(params, &readParams); err != nil {
+		return nil, err
+	}
+	if readParams.URI == "" {
+		return nil, invalidParams(fmt.Errorf("uri is required"))
+	}
+
+	for _, p := range h.providers {
+		contents, err := p.Read(ctx, readParams.URI)
+		if errors.Is(err, ErrResourceNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return protocol.ResourcesReadResult{Contents: contents}, nil
+	}
+	return nil, &protocol.RPCError{
+		Code:    protocol.ResourceNotFound,
+		Message: "Resource not found",
+		Data:    map[string]string{"uri": readParams.URI},
+	}
+}
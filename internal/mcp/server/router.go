@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -55,6 +56,15 @@ func (r *Router) Handle(ctx context.Context, req *protocol.Request) *protocol.Re
 
 	result, err := handler.Handle(ctx, req.Params)
 	if err != nil {
+		// Handlers return protocol errors for invalid params or unknown resources
+		var rpcErr *protocol.RPCError
+		if errors.As(err, &rpcErr) {
+			return &protocol.Response{
+				JSONRPC: protocol.JSONRPC20,
+				ID:      req.ID,
+				Error:   rpcErr,
+			}
+		}
 		return &protocol.Response{
 			JSONRPC: protocol.JSONRPC20,
 			ID:      req.ID,
@@ -2,35 +2,59 @@ package server
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
 	"github.com/kubestack-ai/kubestack-ai/internal/tools"
 )
 
+// DefaultMaxConcurrency is the number of requests a session runs at once
+// unless ServerConfig.MaxConcurrency is set
+const DefaultMaxConcurrency = 16
+
 // ServerConfig holds configuration for an MCP server
 type ServerConfig struct {
 	Name         string
 	Version      string
 	Capabilities protocol.ServerCapabilities
+	// Resources and Prompts are served when set; the matching capabilities
+	// are advertised automatically
+	Resources []ResourceProvider
+	Prompts   []PromptProvider
+	// MaxConcurrency bounds the requests each client session runs at once
+	MaxConcurrency int
 }
 
 // Server represents an MCP server
 type Server struct {
-	config   ServerConfig
-	router   *Router
-	codec    *protocol.Codec
-	registry tools.Registry
-	mu       sync.RWMutex
-	shutdown bool
+	config     ServerConfig
+	router     *Router
+	codec      *protocol.Codec
+	registry   tools.Registry
+	mu         sync.RWMutex
+	shutdown   bool
+	httpServer *http.Server
 }
 
 // NewServer creates a new MCP server
 func NewServer(cfg ServerConfig, registry tools.Registry) *Server {
+	if cfg.MaxConcurrency <= 0 {
+		cfg.MaxConcurrency = DefaultMaxConcurrency
+	}
+	if len(cfg.Resources) > 0 && cfg.Capabilities.Resources == nil {
+		cfg.Capabilities.Resources = &protocol.ResourcesCapability{}
+	}
+	if len(cfg.Prompts) > 0 && cfg.Capabilities.Prompts == nil {
+		cfg.Capabilities.Prompts = &protocol.PromptsCapability{}
+	}
+
 	server := &Server{
 		config:   cfg,
 		router:   NewRouter(),
@@ -49,6 +73,16 @@ func NewServer(cfg ServerConfig, registry tools.Registry) *Server {
 	server.router.Register(protocol.MethodToolsCall, NewToolsCallHandler(registry))
 	server.router.Register(protocol.MethodPing, NewPingHandler())
 
+	if len(cfg.Resources) > 0 {
+		server.router.Register(protocol.MethodResourcesList, NewResourcesListHandler(cfg.Resources))
+		server.router.Register(protocol.MethodResourcesRead, NewResourcesReadHandler(cfg.Resources))
+		server.router.Register(protocol.MethodResourceTemplatesList, NewResourceTemplatesListHandler(cfg.Resources))
+	}
+	if len(cfg.Prompts) > 0 {
+		server.router.Register(protocol.MethodPromptsList, NewPromptsListHandler(cfg.Prompts))
+		server.router.Register(protocol.MethodPromptsGet, NewPromptsGetHandler(cfg.Prompts))
+	}
+
 	return server
 }
 
@@ -59,54 +93,97 @@ func (s *Server) RegisterHandler(method string, handler Handler) {
 
 // ServeStdio serves MCP protocol over stdio
 func (s *Server) ServeStdio(ctx context.Context) error {
-	scanner := bufio.NewScanner(os.Stdin)
-	// Set larger buffer for large messages
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024) // 1MB max
+	return s.Serve(ctx, os.Stdin, os.Stdout)
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
+// Serve serves a single client over newline-delimited JSON-RPC messages
+// read from r and written to w. Requests run concurrently and can be
+// cancelled with notifications/cancelled. Serve returns when r is
+// exhausted, once the requests in flight have been answered, or when ctx
+// is cancelled.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	sess := s.newSession(ctx, "stdio")
+	defer sess.close()
 
-		s.mu.RLock()
-		if s.shutdown {
-			s.mu.RUnlock()
-			return nil
+	var writeMu sync.Mutex
+	write := func(data []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_, err := w.Write(data)
+		return err
+	}
+	notify := func(method string, params any) error {
+		data, err := s.codec.EncodeRequest(nil, method, params)
+		if err != nil {
+			return err
 		}
-		s.mu.RUnlock()
+		return write(data)
+	}
 
-		if !scanner.Scan() {
-			if err := scanner.Err(); err != nil {
-				if err == io.EOF {
-					return nil
-				}
-				return fmt.Errorf("scanner error: %w", err)
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		// Set larger buffer for large messages
+		buf := make([]byte, 0, 64*1024)
+		scanner.Buffer(buf, 1024*1024) // 1MB max
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-sess.ctx.Done():
+				return
 			}
-			return nil
 		}
-
-		data := scanner.Bytes()
-		if len(data) == 0 {
-			continue
+		if err := scanner.Err(); err != nil {
+			readErr <- fmt.Errorf("scanner error: %w", err)
+			return
 		}
+		readErr <- nil
+	}()
 
-		// Process request
-		response := s.handleRequest(ctx, data)
-		if response != nil {
-			// Encode response
-			respData, err := s.encodeResponse(response)
-			if err != nil {
-				// Log error but continue
+	// Answer the requests in flight before returning
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			return err
+		case data := <-lines:
+			if s.isShutdown() {
+				return nil
+			}
+			if len(bytes.TrimSpace(data)) == 0 {
 				continue
 			}
 
-			// Write response to stdout
-			if _, err := os.Stdout.Write(respData); err != nil {
-				return fmt.Errorf("failed to write response: %w", err)
+			req, err := s.codec.DecodeRequest(data)
+			if err != nil {
+				if respData, err := s.encodeResponse(decodeErrorResponse(err)); err == nil {
+					if err := write(respData); err != nil {
+						return fmt.Errorf("failed to write response: %w", err)
+					}
+				}
+				continue
 			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				response := sess.call(sess.ctx, req, notify)
+				if response == nil {
+					return
+				}
+				respData, err := s.encodeResponse(response)
+				if err != nil {
+					// Log error but continue
+					return
+				}
+				_ = write(respData)
+			}()
 		}
 	}
 }
@@ -117,15 +194,7 @@ func (s *Server) handleRequest(ctx context.Context, data []byte) *protocol.Respo
 	req, err := s.codec.DecodeRequest(data)
 	if err != nil {
 		// Return error response
-		return &protocol.Response{
-			JSONRPC: protocol.JSONRPC20,
-			ID:      nil,
-			Error: &protocol.RPCError{
-				Code:    protocol.ParseError,
-				Message: "Parse error",
-				Data:    err.Error(),
-			},
-		}
+		return decodeErrorResponse(err)
 	}
 
 	// Skip notifications (no response needed)
@@ -137,6 +206,23 @@ func (s *Server) handleRequest(ctx context.Context, data []byte) *protocol.Respo
 	return s.router.Handle(ctx, req)
 }
 
+// decodeErrorResponse answers a message that could not be decoded
+func decodeErrorResponse(err error) *protocol.Response {
+	rpcErr, ok := err.(*protocol.RPCError)
+	if !ok || rpcErr.Code != protocol.InvalidRequest {
+		rpcErr = &protocol.RPCError{
+			Code:    protocol.ParseError,
+			Message: "Parse error",
+			Data:    err.Error(),
+		}
+	}
+	return &protocol.Response{
+		JSONRPC: protocol.JSONRPC20,
+		ID:      nil,
+		Error:   rpcErr,
+	}
+}
+
 // encodeResponse encodes a response
 func (s *Server) encodeResponse(resp *protocol.Response) ([]byte, error) {
 	if resp.Error != nil {
@@ -145,11 +231,56 @@ func (s *Server) encodeResponse(resp *protocol.Response) ([]byte, error) {
 	return s.codec.EncodeResponse(resp.ID, resp.Result)
 }
 
+func (s *Server) isShutdown() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.shutdown
+}
+
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	s.shutdown = true
+	httpServer := s.httpServer
+	s.mu.Unlock()
+
+	// Outside the lock: HTTP handlers still running may need it
+	if httpServer != nil {
+		return httpServer.Shutdown(ctx)
+	}
 	return nil
 }
+
+// ListenAndServeHTTP serves the Streamable HTTP transport at addr on path
+// until ctx is cancelled or the server is shut down.
+func (s *Server) ListenAndServeHTTP(ctx context.Context, addr, path string, opts HTTPOptions) error {
+	mux := http.NewServeMux()
+	mux.Handle(path, s.HTTPHandler(opts))
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	s.mu.Lock()
+	s.httpServer = httpServer
+	s.mu.Unlock()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+		return ctx.Err()
+	case err := <-errCh:
+		if err == http.ErrServerClosed {
+			return nil
+		}
+		return err
+	}
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+	"github.com/kubestack-ai/kubestack-ai/internal/tools"
+)
+
+// notifyFunc sends a notification to the client that made a request
+type notifyFunc func(method string, params any) error
+
+// session is one connected client. It runs requests concurrently, bounded
+// by the server's MaxConcurrency, and tracks them so that
+// notifications/cancelled can cancel them.
+type session struct {
+	id     string
+	server *Server
+	ctx    context.Context
+	cancel context.CancelFunc
+	slots  chan struct{}
+
+	mu       sync.Mutex
+	inflight map[string]context.CancelFunc
+	lastSeen time.Time
+}
+
+func (s *Server) newSession(parent context.Context, id string) *session {
+	ctx, cancel := context.WithCancel(parent)
+	return &session{
+		id:       id,
+		server:   s,
+		ctx:      ctx,
+		cancel:   cancel,
+		slots:    make(chan struct{}, s.config.MaxConcurrency),
+		inflight: make(map[string]context.CancelFunc),
+		lastSeen: time.Now(),
+	}
+}
+
+// call handles a message of the client and returns the response to send,
+// or nil for notifications and for requests that were cancelled. When the
+// request carries a progress token, tool progress is sent through notify.
+func (ss *session) call(ctx context.Context, req *protocol.Request, notify notifyFunc) *protocol.Response {
+	if req.IsNotification() {
+		ss.notification(req)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(ss.ctx, cancel)
+	defer stop()
+
+	key := requestKey(req.ID)
+	ss.mu.Lock()
+	ss.inflight[key] = cancel
+	ss.lastSeen = time.Now()
+	ss.mu.Unlock()
+	defer func() {
+		ss.mu.Lock()
+		delete(ss.inflight, key)
+		ss.mu.Unlock()
+	}()
+
+	select {
+	case ss.slots <- struct{}{}:
+		defer func() { <-ss.slots }()
+	case <-ctx.Done():
+		return nil
+	}
+
+	// Progress reported after the response would confuse clients
+	var done atomic.Bool
+	defer done.Store(true)
+	if token := progressToken(req.Params); token != nil && notify != nil {
+		ctx = tools.WithProgress(ctx, func(progress, total float64, message string) {
+			if done.Load() {
+				return
+			}
+			_ = notify(protocol.MethodProgress, protocol.ProgressParams{
+				ProgressToken: token,
+				Progress:      progress,
+				Total:         total,
+				Message:       message,
+			})
+		})
+	}
+
+	response := ss.server.router.Handle(ctx, req)
+	if ctx.Err() != nil {
+		// Cancelled requests are not answered
+		return nil
+	}
+	return response
+}
+
+// notification handles a notification of the client
+func (ss *session) notification(req *protocol.Request) {
+	switch req.Method {
+	case protocol.MethodCancelled:
+		var params protocol.CancelledParams
+		if err := decodeParams(req.Params, &params); err != nil || params.RequestID == nil {
+			return
+		}
+		ss.mu.Lock()
+		cancel, ok := ss.inflight[requestKey(params.RequestID)]
+		ss.mu.Unlock()
+		if ok {
+			cancel()
+		}
+	}
+}
+
+// close cancels the requests in flight
+func (ss *session) close() {
+	ss.cancel()
+}
+
+func (ss *session) idleSince() time.Time {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if len(ss.inflight) > 0 {
+		return time.Now()
+	}
+	return ss.lastSeen
+}
+
+func (ss *session) touch() {
+	ss.mu.Lock()
+	ss.lastSeen = time.Now()
+	ss.mu.Unlock()
+}
+
+// requestKey identifies a request ID; IDs decoded from JSON are float64 or
+// string, so the type keeps 1 and "1" apart
+func requestKey(id any) string {
+	return fmt.Sprintf("%T:%v", id, id)
+}
+
+// progressToken returns params._meta.progressToken, or nil
+func progressToken(params any) any {
+	p, ok := params.(map[string]any)
+	if !ok {
+		return nil
+	}
+	meta, ok := p["_meta"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	return meta["progressToken"]
+}
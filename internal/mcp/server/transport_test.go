@@ -0,0 +1,291 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+	"github.com/kubestack-ai/kubestack-ai/internal/tools"
+)
+
+type fakeResources struct{}
+
+func (fakeResources) Resources(ctx context.Context) ([]protocol.ResourceDefinition, error) {
+	return []protocol.ResourceDefinition{{URI: "ksa://test/a", Name: "a"}}, nil
+}
+
+func (fakeResources) Templates() []protocol.ResourceTemplate {
+	return []protocol.ResourceTemplate{{URITemplate: "ksa://test/{name}", Name: "test"}}
+}
+
+func (fakeResources) Read(ctx context.Context, uri string) ([]protocol.ResourceContent, error) {
+	if uri != "ksa://test/a" {
+		return nil, ErrResourceNotFound
+	}
+	return []protocol.ResourceContent{{URI: uri, Text: "hello"}}, nil
+}
+
+type fakePrompts struct{}
+
+func (fakePrompts) Prompts(ctx context.Context) ([]protocol.PromptDefinition, error) {
+	return []protocol.PromptDefinition{{Name: "greet", Arguments: []protocol.PromptArgument{{Name: "who", Required: true}}}}, nil
+}
+
+func (fakePrompts) GetPrompt(ctx context.Context, name string, args map[string]string) (*protocol.PromptsGetResult, error) {
+	if name != "greet" {
+		return nil, ErrPromptNotFound
+	}
+	if args["who"] == "" {
+		return nil, errors.New("who is required")
+	}
+	return &protocol.PromptsGetResult{Messages: []protocol.PromptMessage{{
+		Role:    "user",
+		Content: protocol.MessageContent{Type: "text", Text: "hello " + args["who"]},
+	}}}, nil
+}
+
+// newTestServer serves "echo", and "slow", which reports progress and then
+// blocks until cancelled
+func newTestServer(t *testing.T) *Server {
+	registry := tools.NewRegistry()
+	if err := registry.Register(&tools.Tool{
+		Name: "echo",
+		Handler: func(ctx context.Context, args map[string]any) (any, error) {
+			return args["text"], nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(&tools.Tool{
+		Name: "slow",
+		Handler: func(ctx context.Context, args map[string]any) (any, error) {
+			tools.ReportProgress(ctx, 1, 2, "halfway")
+			if args["finish"] == true {
+				return "done", nil
+			}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(ServerConfig{
+		Name:         "test-server",
+		Version:      "1.0.0",
+		Capabilities: protocol.ServerCapabilities{Tools: &protocol.ToolsCapability{}},
+		Resources:    []ResourceProvider{fakeResources{}},
+		Prompts:      []PromptProvider{fakePrompts{}},
+	}, registry)
+}
+
+func TestServer_ResourcesAndPrompts(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		req     string
+		code    int
+		inReply string
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"resources/list"}`, 0, "ksa://test/a"},
+		{`{"jsonrpc":"2.0","id":2,"method":"resources/templates/list"}`, 0, "ksa://test/{name}"},
+		{`{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"ksa://test/a"}}`, 0, "hello"},
+		{`{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"ksa://test/b"}}`, protocol.ResourceNotFound, ""},
+		{`{"jsonrpc":"2.0","id":5,"method":"prompts/list"}`, 0, "greet"},
+		{`{"jsonrpc":"2.0","id":6,"method":"prompts/get","params":{"name":"greet","arguments":{"who":"ops"}}}`, 0, "hello ops"},
+		{`{"jsonrpc":"2.0","id":7,"method":"prompts/get","params":{"name":"greet"}}`, protocol.InvalidParams, ""},
+		{`{"jsonrpc":"2.0","id":8,"method":"prompts/get","params":{"name":"nope"}}`, protocol.InvalidParams, ""},
+	}
+	for _, c := range cases {
+		resp := server.handleRequest(ctx, []byte(c.req))
+		if resp == nil {
+			t.Fatalf("%s: expected response", c.req)
+		}
+		if c.code != 0 {
+			if resp.Error == nil || resp.Error.Code != c.code {
+				t.Errorf("%s: expected error %d, got %+v", c.req, c.code, resp.Error)
+			}
+			continue
+		}
+		if resp.Error != nil {
+			t.Errorf("%s: unexpected error %v", c.req, resp.Error)
+			continue
+		}
+		data, _ := json.Marshal(resp.Result)
+		if !strings.Contains(string(data), c.inReply) {
+			t.Errorf("%s: expected %q in %s", c.req, c.inReply, data)
+		}
+	}
+
+	resp := server.handleRequest(ctx, []byte(`{"jsonrpc":"2.0","id":9,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`))
+	result := resp.Result.(protocol.InitializeResult)
+	if result.ProtocolVersion != "2025-03-26" {
+		t.Errorf("Expected negotiated version 2025-03-26, got %s", result.ProtocolVersion)
+	}
+	if result.Capabilities.Resources == nil || result.Capabilities.Prompts == nil {
+		t.Error("Expected resources and prompts capabilities")
+	}
+}
+
+func TestServer_Serve_ConcurrentCancelAndProgress(t *testing.T) {
+	server := newTestServer(t)
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(context.Background(), inR, outW)
+		outW.Close()
+	}()
+
+	messages := make(chan map[string]any, 16)
+	go func() {
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			var msg map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &msg); err == nil {
+				messages <- msg
+			}
+		}
+		close(messages)
+	}()
+	next := func() map[string]any {
+		select {
+		case msg := <-messages:
+			return msg
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a message")
+			return nil
+		}
+	}
+	send := func(line string) {
+		if _, err := io.WriteString(inW, line+"\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow","_meta":{"progressToken":"p1"}}}`)
+	progress := next()
+	if progress["method"] != protocol.MethodProgress {
+		t.Fatalf("Expected progress notification, got %v", progress)
+	}
+	params := progress["params"].(map[string]any)
+	if params["progressToken"] != "p1" || params["progress"] != 1.0 || params["message"] != "halfway" {
+		t.Errorf("Unexpected progress %v", params)
+	}
+
+	// The blocked call does not hold up others
+	send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`)
+	if resp := next(); resp["id"] != 2.0 || resp["error"] != nil {
+		t.Fatalf("Expected response to request 2, got %v", resp)
+	}
+
+	send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1,"reason":"user"}}`)
+	send(`{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	inW.Close()
+
+	if resp := next(); resp["id"] != 3.0 {
+		t.Fatalf("Expected response to request 3, got %v", resp)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Serve returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after the cancelled request")
+	}
+	// The cancelled request is never answered
+	for msg := range messages {
+		t.Errorf("Unexpected message %v", msg)
+	}
+}
+
+func TestHTTPHandler(t *testing.T) {
+	server := newTestServer(t)
+	ts := httptest.NewServer(server.HTTPHandler(HTTPOptions{AuthToken: "secret"}))
+	defer ts.Close()
+
+	post := func(body, session, accept string, header map[string]string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer secret")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if session != "" {
+			req.Header.Set(SessionHeader, session)
+		}
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	readBody := func(resp *http.Response) string {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`
+	if resp := post(initialize, "", "", map[string]string{"Authorization": "Bearer wrong"}); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong token, got %d", resp.StatusCode)
+	}
+	if resp := post(initialize, "", "", map[string]string{"Origin": "http://evil.example.com"}); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for a foreign origin, got %d", resp.StatusCode)
+	}
+
+	resp := post(initialize, "", "application/json, text/event-stream", map[string]string{"Origin": "http://localhost:3000"})
+	body := readBody(resp)
+	session := resp.Header.Get(SessionHeader)
+	if resp.StatusCode != http.StatusOK || session == "" || !strings.Contains(body, `"protocolVersion":"2025-03-26"`) {
+		t.Fatalf("Unexpected initialize response %d %q: %s", resp.StatusCode, session, body)
+	}
+
+	list := `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`
+	if resp := post(list, "", "", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a session, got %d", resp.StatusCode)
+	}
+	if resp := post(`{"jsonrpc":"2.0","method":"notifications/initialized"}`, session, "", nil); resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected 202 for a notification, got %d", resp.StatusCode)
+	}
+
+	batch := `[{"jsonrpc":"2.0","id":3,"method":"ping"},{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}]`
+	resp = post(batch, session, "", nil)
+	var replies []map[string]any
+	if err := json.Unmarshal([]byte(readBody(resp)), &replies); err != nil || len(replies) != 2 {
+		t.Fatalf("Expected two batch replies, got %v (%v)", replies, err)
+	}
+
+	stream := `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"slow","arguments":{"finish":true},"_meta":{"progressToken":7}}}`
+	resp = post(stream, session, "application/json, text/event-stream", nil)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %s", ct)
+	}
+	events := strings.Split(strings.TrimSpace(readBody(resp)), "\n\n")
+	if len(events) != 2 || !strings.Contains(events[0], `"method":"notifications/progress"`) || !strings.Contains(events[1], `"id":5`) {
+		t.Errorf("Expected progress then response events, got %q", events)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set(SessionHeader, session)
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected 204 on delete, got %v %v", resp, err)
+	}
+	if resp := post(list, session, "", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a terminated session, got %d", resp.StatusCode)
+	}
+}
@@ -2,41 +2,76 @@ package alert
 
 import (
 	"context"
-	"strconv"
-	"strings"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
-	"github.com/kubestack-ai/kubestack-ai/internal/monitor/model"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/promql"
 	"github.com/kubestack-ai/kubestack-ai/internal/monitor/storage"
 	"github.com/kubestack-ai/kubestack-ai/internal/monitor/types"
 )
 
+// DefaultResolvedRetention is how long resolved alert instances are kept
+// before they are forgotten
+const DefaultResolvedRetention = 15 * time.Minute
+
 // AlertEvaluator evaluates alert rules
 type AlertEvaluator struct {
 	ruleEngine *RuleEngine
 	store      storage.TimeseriesStore
+	engine     *promql.Engine
 	alertStore storage.AlertStore
 	notifier   *Notifier
 	silence    *SilenceManager
 	log        logger.Logger
 
-	// State tracking (rule name -> first firing time)
-	firingState map[string]time.Time
-	mu          sync.RWMutex
+	// Alert instances (rule name -> label-set fingerprint -> instance)
+	instances         map[string]map[string]*types.AlertInstance
+	resolvedRetention time.Duration
+	mu                sync.RWMutex
 }
 
-// NewAlertEvaluator creates a new evaluator
+// NewAlertEvaluator creates a new evaluator and restores alert instance
+// state persisted by a previous run
 func NewAlertEvaluator(engine *RuleEngine, store storage.TimeseriesStore, alertStore storage.AlertStore, notifier *Notifier, silence *SilenceManager, log logger.Logger) *AlertEvaluator {
-	return &AlertEvaluator{
-		ruleEngine:  engine,
-		store:       store,
-		alertStore:  alertStore,
-		notifier:    notifier,
-		silence:     silence,
-		log:         log,
-		firingState: make(map[string]time.Time),
+	e := &AlertEvaluator{
+		ruleEngine:        engine,
+		store:             store,
+		engine:            promql.NewEngine(store),
+		alertStore:        alertStore,
+		notifier:          notifier,
+		silence:           silence,
+		log:               log,
+		instances:         make(map[string]map[string]*types.AlertInstance),
+		resolvedRetention: DefaultResolvedRetention,
+	}
+	e.restore(context.Background())
+	return e
+}
+
+// restore loads persisted alert instances so a restart neither refires
+// firing alerts nor forgets them
+func (e *AlertEvaluator) restore(ctx context.Context) {
+	if e.alertStore == nil {
+		return
+	}
+	states, err := e.alertStore.ListStates(ctx)
+	if err != nil {
+		e.log.Warnf("Failed to restore alert state: %v", err)
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, inst := range states {
+		if e.instances[inst.RuleName] == nil {
+			e.instances[inst.RuleName] = make(map[string]*types.AlertInstance)
+		}
+		e.instances[inst.RuleName][inst.Fingerprint] = inst
+	}
+	if len(states) > 0 {
+		e.log.Infof("Restored %d alert instances", len(states))
 	}
 }
 
@@ -50,158 +85,230 @@ func (e *AlertEvaluator) Start(ctx context.Context, interval time.Duration) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			e.evaluate(ctx)
+			e.evaluate(ctx, time.Now())
 		}
 	}
 }
 
-func (e *AlertEvaluator) evaluate(ctx context.Context) {
+func (e *AlertEvaluator) evaluate(ctx context.Context, now time.Time) {
 	rules := e.ruleEngine.GetRules()
 
+	loaded := make(map[string]bool, len(rules))
 	for _, rule := range rules {
-		// 1. Query timeseries data
-		query := e.buildQuery(rule.Expr)
-		points, err := e.store.Query(ctx, query)
-		if err != nil {
-			e.log.Errorf("Query metrics failed [%s]: %v", rule.Name, err)
-			continue
-		}
+		loaded[rule.Name] = true
+		e.evaluateRule(ctx, rule, now)
+	}
 
-		// 2. Check condition
-		triggered, value := e.checkCondition(rule, points)
-
-		if triggered {
-			// 3. Check duration (For)
-			if e.shouldFire(rule) {
-				// 4. Check silence
-				if e.silence.IsSilenced(rule.Name, rule.Labels) {
-					e.log.Debugf("Rule [%s] is silenced", rule.Name)
-					continue
-				}
-
-				// 5. Send Alert
-				alert := &types.Alert{
-					RuleName:    rule.Name,
-					Severity:    rule.Severity,
-					Status:      "firing",
-					Labels:      rule.Labels,
-					Annotations: rule.Annotations,
-					FiredAt:     time.Now(),
-					Value:       value,
-				}
-
-				if err := e.notifier.Send(ctx, alert, rule.Notifiers); err != nil {
-					e.log.Errorf("Failed to send alert [%s]: %v", rule.Name, err)
-				}
-
-				// 6. Save history
-				if err := e.alertStore.Save(ctx, alert); err != nil {
-					e.log.Errorf("Failed to save alert: %v", err)
-				}
-			}
-		} else {
-			// Rule resolved
-			if e.isFiring(rule.Name) {
-				e.clearFiringState(rule.Name)
-
-				// Send recovery notification
-				recoveryAlert := &types.Alert{
-					RuleName:   rule.Name,
-					Status:     "resolved",
-					ResolvedAt: time.Now(),
-					Labels:      rule.Labels,
-					Annotations: rule.Annotations,
-				}
-				_ = e.notifier.Send(ctx, recoveryAlert, rule.Notifiers)
-
-				// Save resolved state
-				_ = e.alertStore.Save(ctx, recoveryAlert)
-			}
-		}
+	e.forgetRemovedRules(ctx, loaded)
+}
+
+// transition is a state change that needs to be persisted and possibly notified
+type transition struct {
+	instance *types.AlertInstance
+	notify   bool
+	deleted  bool
+}
+
+func (e *AlertEvaluator) evaluateRule(ctx context.Context, rule *types.AlertRule, now time.Time) {
+	// 1. Evaluate the rule expression; each sample is one alert instance
+	expr, ok := e.ruleEngine.GetExpr(rule.Name)
+	if !ok {
+		return
+	}
+	samples, err := e.engine.InstantVector(ctx, expr, now)
+	if err != nil {
+		e.log.Errorf("Evaluate rule failed [%s]: %v", rule.Name, err)
+		return
+	}
+
+	// 2. Advance the state machine of each instance
+	transitions := e.advance(rule, samples, now)
+
+	// 3. Persist, notify and record history outside the lock
+	for _, t := range transitions {
+		e.apply(ctx, rule, t)
 	}
 }
 
-func (e *AlertEvaluator) shouldFire(rule *types.AlertRule) bool {
+// advance moves every instance of the rule through
+// inactive -> pending -> firing -> resolved and returns the changes
+func (e *AlertEvaluator) advance(rule *types.AlertRule, samples promql.Vector, now time.Time) []transition {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	now := time.Now()
-	firstFiredAt, exists := e.firingState[rule.Name]
+	instances := e.instances[rule.Name]
+	if instances == nil {
+		instances = make(map[string]*types.AlertInstance)
+		e.instances[rule.Name] = instances
+	}
+
+	var transitions []transition
+	seen := make(map[string]bool, len(samples))
+
+	for _, sample := range samples {
+		labels := alertLabels(rule, sample.Labels)
+		fp := promql.Labels(labels).Fingerprint()
+		seen[fp] = true
+
+		inst, exists := instances[fp]
+		changed := false
+		if !exists || inst.State == types.AlertStateResolved || inst.State == types.AlertStateInactive {
+			inst = &types.AlertInstance{
+				RuleName:    rule.Name,
+				Fingerprint: fp,
+				Labels:      labels,
+				State:       types.AlertStatePending,
+				ActiveAt:    now,
+			}
+			instances[fp] = inst
+			changed = true
+		}
+		inst.Severity = rule.Severity
+		inst.Annotations = rule.Annotations
+		inst.Value = sample.Value
+		inst.LastEvalAt = now
+
+		notify := false
+		if inst.State == types.AlertStatePending && now.Sub(inst.ActiveAt) >= rule.For {
+			inst.State = types.AlertStateFiring
+			inst.FiredAt = now
+			changed = true
+			notify = true
+		}
 
-	if !exists {
-		e.firingState[rule.Name] = now
-		return rule.For == 0 // Fire immediately if For is 0
+		if changed {
+			transitions = append(transitions, transition{instance: copyInstance(inst), notify: notify})
+		}
 	}
 
-	if now.Sub(firstFiredAt) >= rule.For {
-		return true
+	for fp, inst := range instances {
+		if seen[fp] {
+			continue
+		}
+		switch inst.State {
+		case types.AlertStatePending:
+			// Condition cleared before the For duration elapsed
+			inst.State = types.AlertStateInactive
+			delete(instances, fp)
+			transitions = append(transitions, transition{instance: copyInstance(inst), deleted: true})
+		case types.AlertStateFiring:
+			inst.State = types.AlertStateResolved
+			inst.ResolvedAt = now
+			inst.LastEvalAt = now
+			transitions = append(transitions, transition{instance: copyInstance(inst), notify: true})
+		case types.AlertStateResolved:
+			if now.Sub(inst.ResolvedAt) >= e.resolvedRetention {
+				delete(instances, fp)
+				transitions = append(transitions, transition{instance: copyInstance(inst), deleted: true})
+			}
+		}
 	}
 
-	return false
+	return transitions
 }
 
-func (e *AlertEvaluator) buildQuery(expr string) *storage.Query {
-	// Parse expression to extract metric name
-	// Example: "cpu_usage > 80" -> Query{Metric: "cpu_usage", Range: "5m"}
-	parts := strings.Fields(expr)
-	metricName := parts[0]
+func (e *AlertEvaluator) apply(ctx context.Context, rule *types.AlertRule, t transition) {
+	inst := t.instance
 
-	return &storage.Query{
-		Metric: metricName,
-		Start:  time.Now().Add(-5 * time.Minute), // Query recent 5m
-		End:    time.Now(),
+	if e.alertStore != nil {
+		var err error
+		if t.deleted {
+			err = e.alertStore.DeleteState(ctx, inst.RuleName, inst.Fingerprint)
+		} else {
+			err = e.alertStore.SaveState(ctx, inst)
+		}
+		if err != nil {
+			e.log.Errorf("Failed to persist alert state [%s]: %v", rule.Name, err)
+		}
 	}
-}
-
-func (e *AlertEvaluator) checkCondition(rule *types.AlertRule, points []*model.MetricPoint) (bool, float64) {
-	// Need to fix import cycle if using collector.MetricPoint, but we moved it to model package.
-	// points type is []*model.MetricPoint
 
-	if len(points) == 0 {
-		return false, 0
+	if !t.notify {
+		return
 	}
 
-	// Simplified: check latest value
-	latestValue := points[len(points)-1].Value
+	alert := inst.ToAlert()
 
-	parts := strings.Fields(rule.Expr)
-	if len(parts) != 3 {
-		return false, 0
+	// Save history
+	if e.alertStore != nil {
+		if err := e.alertStore.Save(ctx, alert); err != nil {
+			e.log.Errorf("Failed to save alert: %v", err)
+		}
 	}
 
-	operator := parts[1]
-	threshold, _ := strconv.ParseFloat(parts[2], 64)
+	// Check silence
+	if e.silence != nil && e.silence.IsSilenced(rule.Name, inst.Labels) {
+		e.log.Debugf("Rule [%s] %v is silenced", rule.Name, inst.Labels)
+		return
+	}
 
-	var triggered bool
-	switch operator {
-	case ">":
-		triggered = latestValue > threshold
-	case ">=":
-		triggered = latestValue >= threshold
-	case "<":
-		triggered = latestValue < threshold
-	case "<=":
-		triggered = latestValue <= threshold
-	case "==":
-		triggered = latestValue == threshold
-	case "!=":
-		triggered = latestValue != threshold
-	default:
-		triggered = false
+	if err := e.notifier.Send(ctx, alert, rule.Notifiers); err != nil {
+		e.log.Errorf("Failed to send %s alert [%s]: %v", alert.Status, rule.Name, err)
 	}
+}
 
-	return triggered, latestValue
+// forgetRemovedRules drops the instances of rules that are no longer loaded
+func (e *AlertEvaluator) forgetRemovedRules(ctx context.Context, loaded map[string]bool) {
+	e.mu.Lock()
+	var removed []*types.AlertInstance
+	for ruleName, instances := range e.instances {
+		if loaded[ruleName] {
+			continue
+		}
+		for _, inst := range instances {
+			removed = append(removed, inst)
+		}
+		delete(e.instances, ruleName)
+	}
+	e.mu.Unlock()
+
+	if e.alertStore == nil {
+		return
+	}
+	for _, inst := range removed {
+		if err := e.alertStore.DeleteState(ctx, inst.RuleName, inst.Fingerprint); err != nil {
+			e.log.Errorf("Failed to delete alert state [%s]: %v", inst.RuleName, err)
+		}
+	}
 }
 
-func (e *AlertEvaluator) isFiring(ruleName string) bool {
+// ActiveAlerts returns a snapshot of pending, firing and recently resolved
+// alert instances, ordered by rule name and fingerprint
+func (e *AlertEvaluator) ActiveAlerts() []*types.AlertInstance {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-	_, exists := e.firingState[ruleName]
-	return exists
+
+	var out []*types.AlertInstance
+	for _, instances := range e.instances {
+		for _, inst := range instances {
+			out = append(out, copyInstance(inst))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].RuleName != out[j].RuleName {
+			return out[i].RuleName < out[j].RuleName
+		}
+		return out[i].Fingerprint < out[j].Fingerprint
+	})
+	return out
 }
 
-func (e *AlertEvaluator) clearFiringState(ruleName string) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-	delete(e.firingState, ruleName)
+func copyInstance(inst *types.AlertInstance) *types.AlertInstance {
+	c := *inst
+	return &c
+}
+
+// alertLabels merges the series labels of a result sample with the rule's
+// static labels; rule labels win on conflict.
+func alertLabels(rule *types.AlertRule, seriesLabels promql.Labels) map[string]string {
+	labels := make(map[string]string, len(seriesLabels)+len(rule.Labels))
+	for k, v := range seriesLabels {
+		if k == promql.MetricNameLabel {
+			continue
+		}
+		labels[k] = v
+	}
+	for k, v := range rule.Labels {
+		labels[k] = v
+	}
+	return labels
 }
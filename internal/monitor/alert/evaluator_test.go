@@ -0,0 +1,167 @@
+package alert
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/model"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/storage"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingChannel struct {
+	mu     sync.Mutex
+	alerts []*types.Alert
+}
+
+func (c *recordingChannel) Send(ctx context.Context, alert *types.Alert) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.alerts = append(c.alerts, alert)
+	return nil
+}
+
+func (c *recordingChannel) Name() string { return "recorder" }
+
+func (c *recordingChannel) take() []*types.Alert {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := c.alerts
+	c.alerts = nil
+	return out
+}
+
+type evaluatorFixture struct {
+	ts         storage.TimeseriesStore
+	alertStore storage.AlertStore
+	engine     *RuleEngine
+	channel    *recordingChannel
+	log        logger.Logger
+}
+
+func newEvaluatorFixture(t *testing.T, forDuration time.Duration) *evaluatorFixture {
+	dbPath := filepath.Join(t.TempDir(), "monitor.db")
+	ts, err := storage.NewSQLiteTimeseriesStore(dbPath)
+	require.NoError(t, err)
+	alertStore, err := storage.NewSQLiteAlertStore(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ts.Close()
+		alertStore.Close()
+	})
+
+	log := logger.NewLogger("alert-test")
+	engine := NewRuleEngine(log)
+	require.NoError(t, engine.LoadRules([]config.AlertRuleConfig{{
+		Name:      "redis-memory-high",
+		Expr:      "redis_memory_percent > 90",
+		For:       forDuration,
+		Severity:  "warning",
+		Notifiers: []string{"recorder"},
+	}}))
+
+	return &evaluatorFixture{ts: ts, alertStore: alertStore, engine: engine, channel: &recordingChannel{}, log: log}
+}
+
+func (f *evaluatorFixture) newEvaluator() *AlertEvaluator {
+	notifier := NewNotifier(f.log)
+	notifier.Register(f.channel)
+	return NewAlertEvaluator(f.engine, f.ts, f.alertStore, notifier, NewSilenceManager(nil), f.log)
+}
+
+func (f *evaluatorFixture) write(t *testing.T, at time.Time, values map[string]float64) {
+	var points []*model.MetricPoint
+	for instance, v := range values {
+		points = append(points, &model.MetricPoint{
+			Name:      "redis_memory_percent",
+			Value:     v,
+			Timestamp: at,
+			Labels:    map[string]string{"instance": instance},
+		})
+	}
+	require.NoError(t, f.ts.Write(context.Background(), points))
+}
+
+func statesByInstance(e *AlertEvaluator) map[string]types.AlertState {
+	out := make(map[string]types.AlertState)
+	for _, inst := range e.ActiveAlerts() {
+		out[inst.Labels["instance"]] = inst.State
+	}
+	return out
+}
+
+func TestAlertEvaluator_PerLabelSetLifecycle(t *testing.T) {
+	f := newEvaluatorFixture(t, 2*time.Minute)
+	e := f.newEvaluator()
+	ctx := context.Background()
+	t0 := time.Now().Add(-10 * time.Minute)
+
+	// Both instances breach: pending, nothing sent yet
+	f.write(t, t0, map[string]float64{"redis-0": 95, "redis-1": 97})
+	e.evaluate(ctx, t0.Add(time.Second))
+	assert.Equal(t, map[string]types.AlertState{"redis-0": types.AlertStatePending, "redis-1": types.AlertStatePending}, statesByInstance(e))
+	assert.Empty(t, f.channel.take())
+
+	// redis-1 recovers before the For duration: dropped silently
+	t1 := t0.Add(3 * time.Minute)
+	f.write(t, t1, map[string]float64{"redis-0": 96, "redis-1": 40})
+	e.evaluate(ctx, t1.Add(time.Second))
+	assert.Equal(t, map[string]types.AlertState{"redis-0": types.AlertStateFiring}, statesByInstance(e))
+
+	sent := f.channel.take()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "firing", sent[0].Status)
+	assert.Equal(t, "redis-0", sent[0].Labels["instance"])
+
+	// Still breaching: no refire
+	t2 := t1.Add(time.Minute)
+	f.write(t, t2, map[string]float64{"redis-0": 98, "redis-1": 40})
+	e.evaluate(ctx, t2.Add(time.Second))
+	assert.Empty(t, f.channel.take())
+
+	// Recovery sends a resolved notification
+	t3 := t2.Add(time.Minute)
+	f.write(t, t3, map[string]float64{"redis-0": 50, "redis-1": 40})
+	e.evaluate(ctx, t3.Add(time.Second))
+	assert.Equal(t, map[string]types.AlertState{"redis-0": types.AlertStateResolved}, statesByInstance(e))
+
+	sent = f.channel.take()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "resolved", sent[0].Status)
+	assert.False(t, sent[0].ResolvedAt.IsZero())
+}
+
+func TestAlertEvaluator_StateSurvivesRestart(t *testing.T) {
+	f := newEvaluatorFixture(t, 0)
+	ctx := context.Background()
+	t0 := time.Now().Add(-10 * time.Minute)
+
+	f.write(t, t0, map[string]float64{"redis-0": 95})
+	first := f.newEvaluator()
+	first.evaluate(ctx, t0.Add(time.Second))
+	require.Len(t, f.channel.take(), 1)
+
+	// A new evaluator over the same store must not refire the alert
+	second := f.newEvaluator()
+	assert.Equal(t, map[string]types.AlertState{"redis-0": types.AlertStateFiring}, statesByInstance(second))
+
+	t1 := t0.Add(time.Minute)
+	f.write(t, t1, map[string]float64{"redis-0": 99})
+	second.evaluate(ctx, t1.Add(time.Second))
+	assert.Empty(t, f.channel.take())
+
+	// ...but must still resolve it
+	t2 := t1.Add(time.Minute)
+	f.write(t, t2, map[string]float64{"redis-0": 10})
+	second.evaluate(ctx, t2.Add(time.Second))
+	sent := f.channel.take()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "resolved", sent[0].Status)
+}
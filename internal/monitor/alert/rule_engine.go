@@ -2,17 +2,19 @@ package alert
 
 import (
 	"fmt"
-	"regexp"
 	"sync"
 
 	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/promql"
 	"github.com/kubestack-ai/kubestack-ai/internal/monitor/types"
 )
 
 // RuleEngine manages alert rules
 type RuleEngine struct {
 	rules []*types.AlertRule
+	// Parsed expressions keyed by rule name
+	exprs map[string]promql.Expr
 	mu    sync.RWMutex
 	log   logger.Logger
 }
@@ -21,6 +23,7 @@ type RuleEngine struct {
 func NewRuleEngine(log logger.Logger) *RuleEngine {
 	return &RuleEngine{
 		rules: make([]*types.AlertRule, 0),
+		exprs: make(map[string]promql.Expr),
 		log:   log,
 	}
 }
@@ -31,8 +34,10 @@ func (e *RuleEngine) LoadRules(rulesConfig []config.AlertRuleConfig) error {
 	defer e.mu.Unlock()
 
 	e.rules = make([]*types.AlertRule, 0)
+	e.exprs = make(map[string]promql.Expr)
 	for _, ruleData := range rulesConfig {
-		if err := e.validateExpr(ruleData.Expr); err != nil {
+		expr, err := e.validateExpr(ruleData.Expr)
+		if err != nil {
 			e.log.Warnf("Skipping invalid rule %s: %v", ruleData.Name, err)
 			continue
 		}
@@ -47,23 +52,72 @@ func (e *RuleEngine) LoadRules(rulesConfig []config.AlertRuleConfig) error {
 			Notifiers:   ruleData.Notifiers,
 		}
 		e.rules = append(e.rules, rule)
+		e.exprs[rule.Name] = expr
 	}
 
 	e.log.Infof("Loaded %d alert rules", len(e.rules))
 	return nil
 }
 
-// validateExpr validates the rule expression
-func (e *RuleEngine) validateExpr(expr string) error {
-	// Regex match: metric_name operator value
-	// Example: cpu_usage > 80, redis_memory_percent >= 90
-	// Simplified regex for MVP
-	pattern := `^([\w_]+)\s*(>|>=|<|<=|==|!=)\s*([\d.]+)$`
-	matched, _ := regexp.MatchString(pattern, expr)
-	if !matched {
-		return fmt.Errorf("unsupported expression format: %s", expr)
+// AddRule validates and adds a single rule, replacing a loaded rule of the
+// same name
+func (e *RuleEngine) AddRule(ruleData config.AlertRuleConfig) (*types.AlertRule, error) {
+	expr, err := e.validateExpr(ruleData.Expr)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+
+	rule := &types.AlertRule{
+		Name:        ruleData.Name,
+		Expr:        ruleData.Expr,
+		For:         ruleData.For,
+		Severity:    ruleData.Severity,
+		Labels:      ruleData.Labels,
+		Annotations: ruleData.Annotations,
+		Notifiers:   ruleData.Notifiers,
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	rules := make([]*types.AlertRule, 0, len(e.rules)+1)
+	for _, r := range e.rules {
+		if r.Name != rule.Name {
+			rules = append(rules, r)
+		}
+	}
+	e.rules = append(rules, rule)
+	e.exprs[rule.Name] = expr
+
+	e.log.Infof("Added alert rule %s: %s", rule.Name, rule.Expr)
+	return rule, nil
+}
+
+// validateExpr parses the rule expression. Alert rules must evaluate to an
+// instant vector; every sample of the result is an active alert.
+// Example: cpu_usage > 80, rate(redis_evicted_keys[5m]) > 10
+func (e *RuleEngine) validateExpr(expr string) (promql.Expr, error) {
+	parsed, err := promql.ParseExpr(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", expr, err)
+	}
+	if parsed.Type() != promql.ValueTypeVector {
+		return nil, fmt.Errorf("invalid expression %q: rule must evaluate to an instant vector, got %s", expr, parsed.Type())
+	}
+	return parsed, nil
+}
+
+// ValidateExpr checks an expression without loading it
+func (e *RuleEngine) ValidateExpr(expr string) error {
+	_, err := e.validateExpr(expr)
+	return err
+}
+
+// GetExpr returns the parsed expression of a loaded rule
+func (e *RuleEngine) GetExpr(ruleName string) (promql.Expr, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	expr, ok := e.exprs[ruleName]
+	return expr, ok
 }
 
 // GetRules returns all rules
@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"context"
+	"sort"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/core/detection/detectors"
+	detectionmodels "github.com/kubestack-ai/kubestack-ai/internal/core/detection/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/model"
+)
+
+// AnomalyScoreMetric is the metric collected series' anomalies are recorded
+// as, so alert rules can use them, e.g. `baseline_anomaly_score{metric="redis_ops_per_sec"} > 5`.
+const AnomalyScoreMetric = "baseline_anomaly_score"
+
+// AnomalyDetector scores a time series, such as a detection.AnomalyDetector
+// built with learned baselines.
+type AnomalyDetector interface {
+	Detect(ctx context.Context, input *detectionmodels.DetectionInput) (*detectionmodels.DetectionResult, error)
+}
+
+// DetectAnomalies runs detector over every metric and instance series in
+// points and returns one AnomalyScoreMetric point per anomaly found.
+func DetectAnomalies(ctx context.Context, detector AnomalyDetector, points []*model.MetricPoint) ([]*model.MetricPoint, error) {
+	type seriesKey struct{ metric, instance string }
+	series := make(map[seriesKey][]detectionmodels.DataPoint)
+	for _, p := range points {
+		if p.Name == AnomalyScoreMetric {
+			continue
+		}
+		k := seriesKey{p.Name, p.Labels[detectors.ContextInstance]}
+		series[k] = append(series[k], detectionmodels.DataPoint{Time: p.Timestamp, Value: p.Value})
+	}
+	keys := make([]seriesKey, 0, len(series))
+	for k := range series {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].metric != keys[j].metric {
+			return keys[i].metric < keys[j].metric
+		}
+		return keys[i].instance < keys[j].instance
+	})
+
+	var out []*model.MetricPoint
+	for _, k := range keys {
+		result, err := detector.Detect(ctx, &detectionmodels.DetectionInput{
+			TimeSeries: series[k],
+			Context:    map[string]string{detectors.ContextMetric: k.metric, detectors.ContextInstance: k.instance},
+		})
+		if err != nil {
+			return out, err
+		}
+		for _, a := range result.Anomalies {
+			score := 1.0
+			if a.Expected != nil {
+				score = a.Expected.Score
+			}
+			out = append(out, &model.MetricPoint{
+				Name:      AnomalyScoreMetric,
+				Value:     score,
+				Timestamp: a.StartTime,
+				Labels: map[string]string{
+					"metric":   k.metric,
+					"instance": k.instance,
+					"type":     a.Type,
+					"severity": a.Severity,
+				},
+			})
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,43 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/model"
+	"github.com/kubestack-ai/kubestack-ai/internal/plugins/builtin/kafka"
+)
+
+// KafkaLagCollector collects per-partition consumer group lag and lag trends
+// from a Kafka cluster, so alert rules can fire per group/topic/partition.
+type KafkaLagCollector struct {
+	cluster string
+	lag     *kafka.LagCollector
+}
+
+// NewKafkaLagCollector creates a lag collector for the given bootstrap brokers
+func NewKafkaLagCollector(brokers []string, log logger.Logger) *KafkaLagCollector {
+	return &KafkaLagCollector{
+		cluster: strings.Join(brokers, ","),
+		lag:     kafka.NewLagCollector(brokers, log),
+	}
+}
+
+func (c *KafkaLagCollector) Collect(ctx context.Context) ([]*model.MetricPoint, error) {
+	snapshot, err := c.lag.CollectConsumerGroupLag(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect kafka consumer lag: %w", err)
+	}
+	return kafka.LagMetricPoints(snapshot, c.lag.Trends(), map[string]string{"type": "kafka", "cluster": c.cluster}), nil
+}
+
+func (c *KafkaLagCollector) Name() string {
+	return "kafka-lag-" + c.cluster
+}
+
+func (c *KafkaLagCollector) Interval() time.Duration {
+	return 30 * time.Second
+}
@@ -29,6 +29,7 @@ type CollectorScheduler struct {
     stopCh     chan struct{}
     wg         sync.WaitGroup
 	log        logger.Logger
+	detector   AnomalyDetector
 }
 
 // NewCollectorScheduler creates a new scheduler
@@ -46,6 +47,12 @@ func (s *CollectorScheduler) Register(collector MetricsCollector) {
     s.collectors = append(s.collectors, collector)
 }
 
+// SetDetector scores every collected series with detector and records the
+// anomalies it finds as AnomalyScoreMetric points.
+func (s *CollectorScheduler) SetDetector(detector AnomalyDetector) {
+	s.detector = detector
+}
+
 // Start starts the scheduler
 func (s *CollectorScheduler) Start(ctx context.Context) error {
     for _, collector := range s.collectors {
@@ -81,10 +88,29 @@ func (s *CollectorScheduler) runCollector(ctx context.Context, collector Metrics
             } else {
                 s.log.Debugf("[%s] Collected %d metrics", collector.Name(), len(points))
             }
+            if s.detector != nil {
+                s.detectAnomalies(ctx, collector.Name(), points)
+            }
         }
     }
 }
 
+func (s *CollectorScheduler) detectAnomalies(ctx context.Context, name string, points []*model.MetricPoint) {
+	anomalies, err := DetectAnomalies(ctx, s.detector, points)
+	if err != nil {
+		s.log.Errorf("[%s] Anomaly detection failed: %v", name, err)
+	}
+	if len(anomalies) == 0 {
+		return
+	}
+	for _, a := range anomalies {
+		s.log.Warnf("[%s] %s anomaly in %s on %s (score %.2f)", name, a.Labels["type"], a.Labels["metric"], a.Labels["instance"], a.Value)
+	}
+	if err := s.store.Write(ctx, anomalies); err != nil {
+		s.log.Errorf("[%s] Storage of anomalies failed: %v", name, err)
+	}
+}
+
 // Stop stops the scheduler
 func (s *CollectorScheduler) Stop() {
     close(s.stopCh)
@@ -0,0 +1,352 @@
+// Package promql implements a PromQL-style expression language for alert
+// rules evaluated against the monitor TimeseriesStore.
+//
+// Supported syntax:
+//
+//	redis_memory_used_bytes{instance="redis-0", role=~"master|primary"}
+//	rate(redis_evicted_keys[5m]) > 10
+//	sum by (instance) (increase(kafka_consumer_lag[10m]))
+//	avg_over_time(cpu_usage[15m]) > 80 and max(cpu_usage) > 95
+//	(mysql_threads_running / mysql_max_connections) * 100 >= 90
+package promql
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pos is a byte offset into the expression source
+type Pos int
+
+// ValueType describes the type an expression evaluates to
+type ValueType string
+
+const (
+	ValueTypeScalar ValueType = "scalar"
+	ValueTypeVector ValueType = "vector"
+	ValueTypeMatrix ValueType = "matrix"
+)
+
+// Expr is a node of the expression AST
+type Expr interface {
+	// Type returns the value type the expression evaluates to
+	Type() ValueType
+	// PositionRange returns the source offset of the node
+	PositionRange() Pos
+	String() string
+}
+
+// NumberLiteral is a constant scalar
+type NumberLiteral struct {
+	Val float64
+	Pos Pos
+}
+
+// VectorSelector selects the latest sample of every matching series
+type VectorSelector struct {
+	Name     string
+	Matchers []*LabelMatcher
+	Pos      Pos
+}
+
+// MatrixSelector selects every sample of matching series within a range
+type MatrixSelector struct {
+	Vector *VectorSelector
+	Range  time.Duration
+	Pos    Pos
+}
+
+// Call is a function call such as rate(x[5m])
+type Call struct {
+	Func *Function
+	Args []Expr
+	Pos  Pos
+}
+
+// AggregateExpr aggregates a vector, optionally grouped by labels
+type AggregateExpr struct {
+	Op       string
+	Expr     Expr
+	Grouping []string
+	Without  bool
+	Pos      Pos
+}
+
+// VectorMatching restricts the labels used to match series of the two
+// operands of a binary expression
+type VectorMatching struct {
+	// On matches on the listed labels only; otherwise the listed labels
+	// are ignored
+	On     bool
+	Labels []string
+}
+
+// BinaryExpr is an arithmetic, comparison or set operation
+type BinaryExpr struct {
+	Op         tokenType
+	LHS, RHS   Expr
+	ReturnBool bool
+	Matching   *VectorMatching
+	Pos        Pos
+}
+
+// UnaryExpr is a negated expression
+type UnaryExpr struct {
+	Op   tokenType
+	Expr Expr
+	Pos  Pos
+}
+
+// ParenExpr wraps an expression in parentheses
+type ParenExpr struct {
+	Expr Expr
+	Pos  Pos
+}
+
+func (e *NumberLiteral) Type() ValueType  { return ValueTypeScalar }
+func (e *VectorSelector) Type() ValueType { return ValueTypeVector }
+func (e *MatrixSelector) Type() ValueType { return ValueTypeMatrix }
+func (e *Call) Type() ValueType           { return e.Func.ReturnType }
+func (e *AggregateExpr) Type() ValueType  { return ValueTypeVector }
+func (e *UnaryExpr) Type() ValueType      { return e.Expr.Type() }
+func (e *ParenExpr) Type() ValueType      { return e.Expr.Type() }
+func (e *BinaryExpr) Type() ValueType {
+	if e.LHS.Type() == ValueTypeScalar && e.RHS.Type() == ValueTypeScalar {
+		return ValueTypeScalar
+	}
+	return ValueTypeVector
+}
+
+func (e *NumberLiteral) PositionRange() Pos  { return e.Pos }
+func (e *VectorSelector) PositionRange() Pos { return e.Pos }
+func (e *MatrixSelector) PositionRange() Pos { return e.Pos }
+func (e *Call) PositionRange() Pos           { return e.Pos }
+func (e *AggregateExpr) PositionRange() Pos  { return e.Pos }
+func (e *BinaryExpr) PositionRange() Pos     { return e.Pos }
+func (e *UnaryExpr) PositionRange() Pos      { return e.Pos }
+func (e *ParenExpr) PositionRange() Pos      { return e.Pos }
+
+func (e *NumberLiteral) String() string {
+	return strconv.FormatFloat(e.Val, 'g', -1, 64)
+}
+
+func (e *VectorSelector) String() string {
+	if len(e.Matchers) == 0 {
+		return e.Name
+	}
+	parts := make([]string, 0, len(e.Matchers))
+	for _, m := range e.Matchers {
+		parts = append(parts, m.String())
+	}
+	return fmt.Sprintf("%s{%s}", e.Name, strings.Join(parts, ","))
+}
+
+func (e *MatrixSelector) String() string {
+	return fmt.Sprintf("%s[%s]", e.Vector.String(), formatDuration(e.Range))
+}
+
+func (e *Call) String() string {
+	args := make([]string, 0, len(e.Args))
+	for _, a := range e.Args {
+		args = append(args, a.String())
+	}
+	return fmt.Sprintf("%s(%s)", e.Func.Name, strings.Join(args, ", "))
+}
+
+func (e *AggregateExpr) String() string {
+	s := e.Op
+	if len(e.Grouping) > 0 {
+		kw := "by"
+		if e.Without {
+			kw = "without"
+		}
+		s += fmt.Sprintf(" %s (%s)", kw, strings.Join(e.Grouping, ", "))
+	}
+	return fmt.Sprintf("%s (%s)", s, e.Expr.String())
+}
+
+func (e *BinaryExpr) String() string {
+	op := strings.Trim(e.Op.String(), "\"")
+	if e.ReturnBool {
+		op += " bool"
+	}
+	if e.Matching != nil {
+		kw := "ignoring"
+		if e.Matching.On {
+			kw = "on"
+		}
+		op += fmt.Sprintf(" %s (%s)", kw, strings.Join(e.Matching.Labels, ", "))
+	}
+	return fmt.Sprintf("%s %s %s", e.LHS.String(), op, e.RHS.String())
+}
+
+func (e *UnaryExpr) String() string {
+	return "-" + e.Expr.String()
+}
+
+func (e *ParenExpr) String() string {
+	return "(" + e.Expr.String() + ")"
+}
+
+// MatchType is the operator of a label matcher
+type MatchType string
+
+const (
+	MatchEqual     MatchType = "="
+	MatchNotEqual  MatchType = "!="
+	MatchRegexp    MatchType = "=~"
+	MatchNotRegexp MatchType = "!~"
+)
+
+// LabelMatcher matches a single label value
+type LabelMatcher struct {
+	Name  string
+	Type  MatchType
+	Value string
+	re    *regexp.Regexp
+}
+
+// NewLabelMatcher creates a matcher, compiling regular expressions anchored
+// at both ends like Prometheus does
+func NewLabelMatcher(t MatchType, name, value string) (*LabelMatcher, error) {
+	m := &LabelMatcher{Name: name, Type: t, Value: value}
+	if t == MatchRegexp || t == MatchNotRegexp {
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, err
+		}
+		m.re = re
+	}
+	return m, nil
+}
+
+// Matches reports whether the label value satisfies the matcher. A missing
+// label is treated as the empty string.
+func (m *LabelMatcher) Matches(v string) bool {
+	switch m.Type {
+	case MatchEqual:
+		return v == m.Value
+	case MatchNotEqual:
+		return v != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(v)
+	case MatchNotRegexp:
+		return !m.re.MatchString(v)
+	}
+	return false
+}
+
+func (m *LabelMatcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.Name, m.Type, m.Value)
+}
+
+// MetricNameLabel is the reserved label carrying the metric name
+const MetricNameLabel = "__name__"
+
+// Labels is a set of label name/value pairs
+type Labels map[string]string
+
+// Copy returns a shallow copy of the label set
+func (l Labels) Copy() Labels {
+	out := make(Labels, len(l))
+	for k, v := range l {
+		out[k] = v
+	}
+	return out
+}
+
+// WithoutName returns a copy of the label set without the metric name
+func (l Labels) WithoutName() Labels {
+	out := l.Copy()
+	delete(out, MetricNameLabel)
+	return out
+}
+
+// Fingerprint returns a stable string identifying the label set
+func (l Labels) Fingerprint() string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(l[k]))
+	}
+	return sb.String()
+}
+
+func (l Labels) String() string {
+	return "{" + l.Fingerprint() + "}"
+}
+
+// parseDuration parses Prometheus-style durations such as 5m, 1h30m or 7d
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	units := map[string]time.Duration{
+		"ms": time.Millisecond,
+		"s":  time.Second,
+		"m":  time.Minute,
+		"h":  time.Hour,
+		"d":  24 * time.Hour,
+		"w":  7 * 24 * time.Hour,
+		"y":  365 * 24 * time.Hour,
+	}
+
+	var total time.Duration
+	rest := s
+	for rest != "" {
+		i := 0
+		for i < len(rest) && isDigit(rune(rest[i])) {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("expected number in %q", s)
+		}
+		n, err := strconv.ParseInt(rest[:i], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		rest = rest[i:]
+
+		j := 0
+		for j < len(rest) && !isDigit(rune(rest[j])) {
+			j++
+		}
+		unit, ok := units[rest[:j]]
+		if !ok {
+			return 0, fmt.Errorf("unknown unit %q", rest[:j])
+		}
+		total += time.Duration(n) * unit
+		rest = rest[j:]
+	}
+	if total <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	return total, nil
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	case d%time.Second == 0:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+	return fmt.Sprintf("%dms", d/time.Millisecond)
+}
@@ -0,0 +1,480 @@
+package promql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/storage"
+)
+
+// DefaultLookbackDelta is how far back an instant selector looks for the
+// latest sample of a series
+const DefaultLookbackDelta = 5 * time.Minute
+
+// Engine evaluates parsed expressions against a TimeseriesStore
+type Engine struct {
+	store         storage.TimeseriesStore
+	lookbackDelta time.Duration
+}
+
+// NewEngine creates a new evaluation engine
+func NewEngine(store storage.TimeseriesStore) *Engine {
+	return &Engine{
+		store:         store,
+		lookbackDelta: DefaultLookbackDelta,
+	}
+}
+
+// SetLookbackDelta overrides the instant selector lookback window
+func (e *Engine) SetLookbackDelta(d time.Duration) {
+	if d > 0 {
+		e.lookbackDelta = d
+	}
+}
+
+// Instant evaluates the expression at the given time
+func (e *Engine) Instant(ctx context.Context, expr Expr, ts time.Time) (Value, error) {
+	ev := &evaluator{ctx: ctx, engine: e, ts: ts}
+	return ev.eval(expr)
+}
+
+// InstantVector evaluates an expression that must produce an instant vector,
+// which is what alert rules require: every sample is one active alert.
+func (e *Engine) InstantVector(ctx context.Context, expr Expr, ts time.Time) (Vector, error) {
+	val, err := e.Instant(ctx, expr, ts)
+	if err != nil {
+		return nil, err
+	}
+	vec, ok := val.(Vector)
+	if !ok {
+		return nil, fmt.Errorf("expression %q returned %s, expected instant vector", expr, val.Type())
+	}
+	return vec, nil
+}
+
+type evaluator struct {
+	ctx    context.Context
+	engine *Engine
+	ts     time.Time
+}
+
+func (ev *evaluator) eval(expr Expr) (Value, error) {
+	if err := ev.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	switch e := expr.(type) {
+	case *NumberLiteral:
+		return Scalar{V: e.Val, T: ev.ts}, nil
+	case *ParenExpr:
+		return ev.eval(e.Expr)
+	case *UnaryExpr:
+		return ev.evalUnary(e)
+	case *VectorSelector:
+		return ev.evalVectorSelector(e)
+	case *MatrixSelector:
+		return ev.evalMatrixSelector(e)
+	case *Call:
+		return ev.evalCall(e)
+	case *AggregateExpr:
+		return ev.evalAggregate(e)
+	case *BinaryExpr:
+		return ev.evalBinary(e)
+	}
+	return nil, fmt.Errorf("unhandled expression of type %T", expr)
+}
+
+// selectSeries fetches all series of a selector within (start, end]
+func (ev *evaluator) selectSeries(vs *VectorSelector, start, end time.Time) (Matrix, error) {
+	q := &storage.Query{
+		Metric: vs.Name,
+		Labels: map[string]string{},
+		Start:  start,
+		End:    end,
+	}
+	// Equality matchers are pushed down to the store
+	for _, m := range vs.Matchers {
+		if m.Type == MatchEqual && m.Value != "" {
+			q.Labels[m.Name] = m.Value
+		}
+	}
+
+	points, err := ev.engine.store.Query(ev.ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", vs, err)
+	}
+
+	byFingerprint := make(map[string]*Series)
+	var order []string
+	for _, p := range points {
+		if !p.Timestamp.After(start) || p.Timestamp.After(end) {
+			continue
+		}
+		lbls := make(Labels, len(p.Labels)+1)
+		for k, v := range p.Labels {
+			lbls[k] = v
+		}
+		lbls[MetricNameLabel] = p.Name
+
+		matched := true
+		for _, m := range vs.Matchers {
+			if !m.Matches(lbls[m.Name]) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		fp := lbls.Fingerprint()
+		s, ok := byFingerprint[fp]
+		if !ok {
+			s = &Series{Labels: lbls}
+			byFingerprint[fp] = s
+			order = append(order, fp)
+		}
+		s.Points = append(s.Points, Point{T: p.Timestamp, V: p.Value})
+	}
+
+	sort.Strings(order)
+	out := make(Matrix, 0, len(order))
+	for _, fp := range order {
+		s := byFingerprint[fp]
+		sort.SliceStable(s.Points, func(i, j int) bool { return s.Points[i].T.Before(s.Points[j].T) })
+		out = append(out, *s)
+	}
+	return out, nil
+}
+
+func (ev *evaluator) evalVectorSelector(vs *VectorSelector) (Value, error) {
+	matrix, err := ev.selectSeries(vs, ev.ts.Add(-ev.engine.lookbackDelta), ev.ts)
+	if err != nil {
+		return nil, err
+	}
+	vec := make(Vector, 0, len(matrix))
+	for _, s := range matrix {
+		if len(s.Points) == 0 {
+			continue
+		}
+		last := s.Points[len(s.Points)-1]
+		vec = append(vec, Sample{Labels: s.Labels, Value: last.V, T: ev.ts})
+	}
+	return vec, nil
+}
+
+func (ev *evaluator) evalMatrixSelector(ms *MatrixSelector) (Value, error) {
+	return ev.selectSeries(ms.Vector, ev.ts.Add(-ms.Range), ev.ts)
+}
+
+func (ev *evaluator) evalUnary(e *UnaryExpr) (Value, error) {
+	val, err := ev.eval(e.Expr)
+	if err != nil {
+		return nil, err
+	}
+	switch v := val.(type) {
+	case Scalar:
+		return Scalar{V: -v.V, T: v.T}, nil
+	case Vector:
+		out := make(Vector, 0, len(v))
+		for _, s := range v {
+			out = append(out, Sample{Labels: s.Labels.WithoutName(), Value: -s.Value, T: s.T})
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unary minus not supported on %s", val.Type())
+}
+
+func (ev *evaluator) evalCall(e *Call) (Value, error) {
+	arg, err := ev.eval(e.Args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	out := Vector{}
+	switch v := arg.(type) {
+	case Matrix:
+		for _, s := range v {
+			if val, ok := e.Func.call(s.Points); ok {
+				out = append(out, Sample{Labels: s.Labels.WithoutName(), Value: val, T: ev.ts})
+			}
+		}
+	case Vector:
+		for _, s := range v {
+			if val, ok := e.Func.call([]Point{{T: s.T, V: s.Value}}); ok {
+				out = append(out, Sample{Labels: s.Labels.WithoutName(), Value: val, T: ev.ts})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("function %s: unexpected argument type %s", e.Func.Name, arg.Type())
+	}
+	return out, nil
+}
+
+func (ev *evaluator) evalAggregate(e *AggregateExpr) (Value, error) {
+	val, err := ev.eval(e.Expr)
+	if err != nil {
+		return nil, err
+	}
+	vec, ok := val.(Vector)
+	if !ok {
+		return nil, fmt.Errorf("aggregation %s: expected instant vector, got %s", e.Op, val.Type())
+	}
+
+	type group struct {
+		labels Labels
+		values []float64
+	}
+	groups := make(map[string]*group)
+	var order []string
+
+	for _, s := range vec {
+		lbls := groupingLabels(s.Labels, e.Grouping, e.Without)
+		fp := lbls.Fingerprint()
+		g, ok := groups[fp]
+		if !ok {
+			g = &group{labels: lbls}
+			groups[fp] = g
+			order = append(order, fp)
+		}
+		g.values = append(g.values, s.Value)
+	}
+
+	out := make(Vector, 0, len(groups))
+	for _, fp := range order {
+		g := groups[fp]
+		var v float64
+		switch e.Op {
+		case "sum":
+			v = sum(g.values)
+		case "avg":
+			v = sum(g.values) / float64(len(g.values))
+		case "min":
+			v = extremum(g.values, math.Min)
+		case "max":
+			v = extremum(g.values, math.Max)
+		case "count":
+			v = float64(len(g.values))
+		default:
+			return nil, fmt.Errorf("unknown aggregation %q", e.Op)
+		}
+		out = append(out, Sample{Labels: g.labels, Value: v, T: ev.ts})
+	}
+	sortVector(out)
+	return out, nil
+}
+
+// groupingLabels returns the labels kept by a by/without clause
+func groupingLabels(lbls Labels, grouping []string, without bool) Labels {
+	out := Labels{}
+	if without {
+		drop := make(map[string]bool, len(grouping)+1)
+		drop[MetricNameLabel] = true
+		for _, g := range grouping {
+			drop[g] = true
+		}
+		for k, v := range lbls {
+			if !drop[k] {
+				out[k] = v
+			}
+		}
+		return out
+	}
+	for _, g := range grouping {
+		if v, ok := lbls[g]; ok {
+			out[g] = v
+		}
+	}
+	return out
+}
+
+// matchSignature returns the identity used to pair series across operands
+func matchSignature(lbls Labels, matching *VectorMatching) string {
+	if matching == nil {
+		return lbls.WithoutName().Fingerprint()
+	}
+	return groupingLabels(lbls, matching.Labels, !matching.On).Fingerprint()
+}
+
+func (ev *evaluator) evalBinary(e *BinaryExpr) (Value, error) {
+	lhs, err := ev.eval(e.LHS)
+	if err != nil {
+		return nil, err
+	}
+	rhs, err := ev.eval(e.RHS)
+	if err != nil {
+		return nil, err
+	}
+
+	switch l := lhs.(type) {
+	case Scalar:
+		switch r := rhs.(type) {
+		case Scalar:
+			v, keep := binaryOp(e.Op, l.V, r.V)
+			if isComparisonOp(e.Op) {
+				v = boolValue(keep)
+			}
+			return Scalar{V: v, T: ev.ts}, nil
+		case Vector:
+			return ev.vectorScalar(e, r, l.V, true), nil
+		}
+	case Vector:
+		switch r := rhs.(type) {
+		case Scalar:
+			return ev.vectorScalar(e, l, r.V, false), nil
+		case Vector:
+			if isSetOp(e.Op) {
+				return vectorSetOp(e, l, r), nil
+			}
+			return ev.vectorVector(e, l, r)
+		}
+	}
+	return nil, fmt.Errorf("unsupported operand types %s and %s", lhs.Type(), rhs.Type())
+}
+
+func (ev *evaluator) vectorScalar(e *BinaryExpr, vec Vector, scalar float64, scalarLeft bool) Vector {
+	out := make(Vector, 0, len(vec))
+	for _, s := range vec {
+		a, b := s.Value, scalar
+		if scalarLeft {
+			a, b = scalar, s.Value
+		}
+		v, keep := binaryOp(e.Op, a, b)
+		lbls := s.Labels
+		if isComparisonOp(e.Op) {
+			if e.ReturnBool {
+				v = boolValue(keep)
+				lbls = lbls.WithoutName()
+			} else if !keep {
+				continue
+			} else {
+				// Filtering comparisons keep the vector's own value
+				v = s.Value
+			}
+		} else {
+			lbls = lbls.WithoutName()
+		}
+		out = append(out, Sample{Labels: lbls, Value: v, T: ev.ts})
+	}
+	return out
+}
+
+func (ev *evaluator) vectorVector(e *BinaryExpr, lhs, rhs Vector) (Vector, error) {
+	rightBySig := make(map[string]Sample, len(rhs))
+	for _, s := range rhs {
+		sig := matchSignature(s.Labels, e.Matching)
+		if _, dup := rightBySig[sig]; dup {
+			return nil, fmt.Errorf("found duplicate series for the match group %s on the right hand-side of the operation", sig)
+		}
+		rightBySig[sig] = s
+	}
+
+	out := make(Vector, 0, len(lhs))
+	seen := make(map[string]bool, len(lhs))
+	for _, l := range lhs {
+		sig := matchSignature(l.Labels, e.Matching)
+		r, ok := rightBySig[sig]
+		if !ok {
+			continue
+		}
+		if seen[sig] {
+			return nil, fmt.Errorf("many-to-many matching not allowed: found duplicate series for the match group %s on the left hand-side of the operation", sig)
+		}
+		seen[sig] = true
+
+		v, keep := binaryOp(e.Op, l.Value, r.Value)
+		lbls := l.Labels.WithoutName()
+		if e.Matching != nil && e.Matching.On {
+			lbls = groupingLabels(l.Labels, e.Matching.Labels, false)
+		}
+		if isComparisonOp(e.Op) {
+			if e.ReturnBool {
+				v = boolValue(keep)
+			} else if !keep {
+				continue
+			} else {
+				v = l.Value
+				lbls = l.Labels
+			}
+		}
+		out = append(out, Sample{Labels: lbls, Value: v, T: ev.ts})
+	}
+	return out, nil
+}
+
+func vectorSetOp(e *BinaryExpr, lhs, rhs Vector) Vector {
+	rightSigs := make(map[string]bool, len(rhs))
+	for _, s := range rhs {
+		rightSigs[matchSignature(s.Labels, e.Matching)] = true
+	}
+
+	out := Vector{}
+	switch e.Op {
+	case tokAnd:
+		for _, s := range lhs {
+			if rightSigs[matchSignature(s.Labels, e.Matching)] {
+				out = append(out, s)
+			}
+		}
+	case tokUnless:
+		for _, s := range lhs {
+			if !rightSigs[matchSignature(s.Labels, e.Matching)] {
+				out = append(out, s)
+			}
+		}
+	case tokOr:
+		leftSigs := make(map[string]bool, len(lhs))
+		for _, s := range lhs {
+			leftSigs[matchSignature(s.Labels, e.Matching)] = true
+			out = append(out, s)
+		}
+		for _, s := range rhs {
+			if !leftSigs[matchSignature(s.Labels, e.Matching)] {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// binaryOp applies an arithmetic or comparison operator. For comparisons
+// the boolean result is returned in keep.
+func binaryOp(op tokenType, a, b float64) (value float64, keep bool) {
+	switch op {
+	case tokAdd:
+		return a + b, true
+	case tokSub:
+		return a - b, true
+	case tokMul:
+		return a * b, true
+	case tokDiv:
+		return a / b, true
+	case tokMod:
+		return math.Mod(a, b), true
+	case tokPow:
+		return math.Pow(a, b), true
+	case tokEQL:
+		return a, a == b
+	case tokNEQ:
+		return a, a != b
+	case tokGTR:
+		return a, a > b
+	case tokLSS:
+		return a, a < b
+	case tokGTE:
+		return a, a >= b
+	case tokLTE:
+		return a, a <= b
+	}
+	return math.NaN(), false
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
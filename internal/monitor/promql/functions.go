@@ -0,0 +1,149 @@
+package promql
+
+import (
+	"math"
+)
+
+// Function describes a builtin function
+type Function struct {
+	Name       string
+	ArgTypes   []ValueType
+	ReturnType ValueType
+	// call computes the result for one series (range functions) or one
+	// sample (instant functions); ok=false drops the series from the output
+	call func(points []Point) (value float64, ok bool)
+}
+
+var functions = map[string]*Function{}
+
+func init() {
+	rangeFuncs := map[string]func([]Point) (float64, bool){
+		"rate":            funcRate,
+		"increase":        funcIncrease,
+		"delta":           funcDelta,
+		"deriv":           funcDeriv,
+		"avg_over_time":   aggrOverTime(func(vs []float64) float64 { return sum(vs) / float64(len(vs)) }),
+		"sum_over_time":   aggrOverTime(sum),
+		"min_over_time":   aggrOverTime(func(vs []float64) float64 { return extremum(vs, math.Min) }),
+		"max_over_time":   aggrOverTime(func(vs []float64) float64 { return extremum(vs, math.Max) }),
+		"count_over_time": aggrOverTime(func(vs []float64) float64 { return float64(len(vs)) }),
+		"last_over_time":  aggrOverTime(func(vs []float64) float64 { return vs[len(vs)-1] }),
+	}
+	for name, fn := range rangeFuncs {
+		functions[name] = &Function{
+			Name:       name,
+			ArgTypes:   []ValueType{ValueTypeMatrix},
+			ReturnType: ValueTypeVector,
+			call:       fn,
+		}
+	}
+
+	instantFuncs := map[string]func(float64) float64{
+		"abs":   math.Abs,
+		"ceil":  math.Ceil,
+		"floor": math.Floor,
+		"round": math.Round,
+	}
+	for name, fn := range instantFuncs {
+		fn := fn
+		functions[name] = &Function{
+			Name:       name,
+			ArgTypes:   []ValueType{ValueTypeVector},
+			ReturnType: ValueTypeVector,
+			call: func(points []Point) (float64, bool) {
+				return fn(points[0].V), true
+			},
+		}
+	}
+}
+
+// funcIncrease returns the increase of a counter over the range, taking
+// counter resets into account
+func funcIncrease(points []Point) (float64, bool) {
+	if len(points) < 2 {
+		return 0, false
+	}
+	var total float64
+	for i := 1; i < len(points); i++ {
+		prev, cur := points[i-1].V, points[i].V
+		if cur < prev {
+			// Counter reset: the counter restarted from zero
+			total += cur
+		} else {
+			total += cur - prev
+		}
+	}
+	return total, true
+}
+
+// funcRate returns the per-second increase over the sampled interval
+func funcRate(points []Point) (float64, bool) {
+	inc, ok := funcIncrease(points)
+	if !ok {
+		return 0, false
+	}
+	elapsed := points[len(points)-1].T.Sub(points[0].T).Seconds()
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return inc / elapsed, true
+}
+
+// funcDelta returns the difference between the last and first value of a gauge
+func funcDelta(points []Point) (float64, bool) {
+	if len(points) < 2 {
+		return 0, false
+	}
+	return points[len(points)-1].V - points[0].V, true
+}
+
+// funcDeriv returns the per-second derivative using simple linear regression
+func funcDeriv(points []Point) (float64, bool) {
+	if len(points) < 2 {
+		return 0, false
+	}
+	t0 := points[0].T
+	var n, sumX, sumY, sumXY, sumX2 float64
+	for _, p := range points {
+		x := p.T.Sub(t0).Seconds()
+		n++
+		sumX += x
+		sumY += p.V
+		sumXY += x * p.V
+		sumX2 += x * x
+	}
+	denom := n*sumX2 - sumX*sumX
+	if denom == 0 {
+		return 0, false
+	}
+	return (n*sumXY - sumX*sumY) / denom, true
+}
+
+func aggrOverTime(fn func([]float64) float64) func([]Point) (float64, bool) {
+	return func(points []Point) (float64, bool) {
+		if len(points) == 0 {
+			return 0, false
+		}
+		vals := make([]float64, len(points))
+		for i, p := range points {
+			vals[i] = p.V
+		}
+		return fn(vals), true
+	}
+}
+
+func sum(vs []float64) float64 {
+	var s float64
+	for _, v := range vs {
+		s += v
+	}
+	return s
+}
+
+func extremum(vs []float64, pick func(a, b float64) float64) float64 {
+	out := vs[0]
+	for _, v := range vs[1:] {
+		out = pick(out, v)
+	}
+	return out
+}
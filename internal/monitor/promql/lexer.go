@@ -0,0 +1,362 @@
+package promql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenType identifies the kind of a lexical token
+type tokenType int
+
+const (
+	tokEOF tokenType = iota
+	tokIdentifier
+	tokNumber
+	tokString
+	tokDuration
+
+	tokLeftParen
+	tokRightParen
+	tokLeftBrace
+	tokRightBrace
+	tokLeftBracket
+	tokRightBracket
+	tokComma
+
+	// Operators
+	tokAdd
+	tokSub
+	tokMul
+	tokDiv
+	tokMod
+	tokPow
+	tokEQL // == (comparison)
+	tokNEQ
+	tokGTR
+	tokLSS
+	tokGTE
+	tokLTE
+	tokAssign // = (label matcher)
+	tokEQLRegex
+	tokNEQRegex
+
+	// Keywords
+	tokAnd
+	tokOr
+	tokUnless
+	tokBy
+	tokWithout
+	tokBool
+	tokOn
+	tokIgnoring
+)
+
+var keywords = map[string]tokenType{
+	"and":      tokAnd,
+	"or":       tokOr,
+	"unless":   tokUnless,
+	"by":       tokBy,
+	"without":  tokWithout,
+	"bool":     tokBool,
+	"on":       tokOn,
+	"ignoring": tokIgnoring,
+}
+
+var tokenNames = map[tokenType]string{
+	tokEOF:          "end of input",
+	tokIdentifier:   "identifier",
+	tokNumber:       "number",
+	tokString:       "string",
+	tokDuration:     "duration",
+	tokLeftParen:    "\"(\"",
+	tokRightParen:   "\")\"",
+	tokLeftBrace:    "\"{\"",
+	tokRightBrace:   "\"}\"",
+	tokLeftBracket:  "\"[\"",
+	tokRightBracket: "\"]\"",
+	tokComma:        "\",\"",
+	tokAdd:          "\"+\"",
+	tokSub:          "\"-\"",
+	tokMul:          "\"*\"",
+	tokDiv:          "\"/\"",
+	tokMod:          "\"%\"",
+	tokPow:          "\"^\"",
+	tokEQL:          "\"==\"",
+	tokNEQ:          "\"!=\"",
+	tokGTR:          "\">\"",
+	tokLSS:          "\"<\"",
+	tokGTE:          "\">=\"",
+	tokLTE:          "\"<=\"",
+	tokAssign:       "\"=\"",
+	tokEQLRegex:     "\"=~\"",
+	tokNEQRegex:     "\"!~\"",
+	tokAnd:          "\"and\"",
+	tokOr:           "\"or\"",
+	tokUnless:       "\"unless\"",
+	tokBy:           "\"by\"",
+	tokWithout:      "\"without\"",
+	tokBool:         "\"bool\"",
+	tokOn:           "\"on\"",
+	tokIgnoring:     "\"ignoring\"",
+}
+
+func (t tokenType) String() string {
+	if name, ok := tokenNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("token(%d)", int(t))
+}
+
+// token is a lexical token with its byte offset in the input
+type token struct {
+	typ tokenType
+	val string
+	pos Pos
+}
+
+// lexer splits an expression into tokens
+type lexer struct {
+	input string
+	pos   int
+	// inRange is set between "[" and "]" where durations are lexed
+	inRange bool
+}
+
+func newLexer(input string) *lexer {
+	return &lexer{input: input}
+}
+
+// lexAll tokenizes the complete input
+func lexAll(input string) ([]token, error) {
+	l := newLexer(input)
+	var tokens []token
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		if tok.typ == tokEOF {
+			return tokens, nil
+		}
+	}
+}
+
+func (l *lexer) errorf(pos int, format string, args ...interface{}) error {
+	return newParseError(l.input, Pos(pos), fmt.Sprintf(format, args...))
+}
+
+func (l *lexer) peekRune() rune {
+	if l.pos >= len(l.input) {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(l.input[l.pos:])
+	return r
+}
+
+func (l *lexer) next() (token, error) {
+	// Skip whitespace and comments
+	for l.pos < len(l.input) {
+		r := l.peekRune()
+		if unicode.IsSpace(r) {
+			l.pos += utf8.RuneLen(r)
+			continue
+		}
+		if r == '#' {
+			for l.pos < len(l.input) && l.input[l.pos] != '\n' {
+				l.pos++
+			}
+			continue
+		}
+		break
+	}
+
+	start := l.pos
+	if l.pos >= len(l.input) {
+		return token{typ: tokEOF, pos: Pos(start)}, nil
+	}
+
+	r := l.peekRune()
+
+	if l.inRange && isDigit(r) {
+		return l.lexDuration()
+	}
+
+	switch {
+	case isDigit(r) || (r == '.' && l.pos+1 < len(l.input) && isDigit(rune(l.input[l.pos+1]))):
+		return l.lexNumber()
+	case isIdentStart(r):
+		return l.lexIdentifier()
+	case r == '"' || r == '\'':
+		return l.lexString(r)
+	}
+
+	emit := func(typ tokenType, width int) (token, error) {
+		l.pos += width
+		return token{typ: typ, val: l.input[start:l.pos], pos: Pos(start)}, nil
+	}
+
+	two := ""
+	if l.pos+2 <= len(l.input) {
+		two = l.input[l.pos : l.pos+2]
+	}
+	switch two {
+	case "==":
+		return emit(tokEQL, 2)
+	case "!=":
+		return emit(tokNEQ, 2)
+	case ">=":
+		return emit(tokGTE, 2)
+	case "<=":
+		return emit(tokLTE, 2)
+	case "=~":
+		return emit(tokEQLRegex, 2)
+	case "!~":
+		return emit(tokNEQRegex, 2)
+	}
+
+	switch r {
+	case '(':
+		return emit(tokLeftParen, 1)
+	case ')':
+		return emit(tokRightParen, 1)
+	case '{':
+		return emit(tokLeftBrace, 1)
+	case '}':
+		return emit(tokRightBrace, 1)
+	case '[':
+		l.inRange = true
+		return emit(tokLeftBracket, 1)
+	case ']':
+		l.inRange = false
+		return emit(tokRightBracket, 1)
+	case ',':
+		return emit(tokComma, 1)
+	case '+':
+		return emit(tokAdd, 1)
+	case '-':
+		return emit(tokSub, 1)
+	case '*':
+		return emit(tokMul, 1)
+	case '/':
+		return emit(tokDiv, 1)
+	case '%':
+		return emit(tokMod, 1)
+	case '^':
+		return emit(tokPow, 1)
+	case '>':
+		return emit(tokGTR, 1)
+	case '<':
+		return emit(tokLSS, 1)
+	case '=':
+		return emit(tokAssign, 1)
+	}
+
+	return token{}, l.errorf(start, "unexpected character %q", r)
+}
+
+func (l *lexer) lexNumber() (token, error) {
+	start := l.pos
+	for l.pos < len(l.input) && (isDigit(rune(l.input[l.pos])) || l.input[l.pos] == '.') {
+		l.pos++
+	}
+	// Exponent
+	if l.pos < len(l.input) && (l.input[l.pos] == 'e' || l.input[l.pos] == 'E') {
+		l.pos++
+		if l.pos < len(l.input) && (l.input[l.pos] == '+' || l.input[l.pos] == '-') {
+			l.pos++
+		}
+		for l.pos < len(l.input) && isDigit(rune(l.input[l.pos])) {
+			l.pos++
+		}
+	}
+	if l.pos < len(l.input) && isIdentStart(l.peekRune()) {
+		return token{}, l.errorf(l.pos, "bad number or duration syntax %q", l.input[start:l.pos+1])
+	}
+	return token{typ: tokNumber, val: l.input[start:l.pos], pos: Pos(start)}, nil
+}
+
+func (l *lexer) lexDuration() (token, error) {
+	start := l.pos
+	for l.pos < len(l.input) && (isDigit(rune(l.input[l.pos])) || isDurationUnit(rune(l.input[l.pos]))) {
+		l.pos++
+	}
+	val := l.input[start:l.pos]
+	if _, err := parseDuration(val); err != nil {
+		return token{}, l.errorf(start, "bad duration %q: %v", val, err)
+	}
+	return token{typ: tokDuration, val: val, pos: Pos(start)}, nil
+}
+
+func (l *lexer) lexIdentifier() (token, error) {
+	start := l.pos
+	for l.pos < len(l.input) {
+		r := l.peekRune()
+		if !isIdentChar(r) {
+			break
+		}
+		l.pos += utf8.RuneLen(r)
+	}
+	val := l.input[start:l.pos]
+	if kw, ok := keywords[strings.ToLower(val)]; ok {
+		return token{typ: kw, val: val, pos: Pos(start)}, nil
+	}
+	return token{typ: tokIdentifier, val: val, pos: Pos(start)}, nil
+}
+
+func (l *lexer) lexString(quote rune) (token, error) {
+	start := l.pos
+	l.pos++ // opening quote
+	var sb strings.Builder
+	for {
+		if l.pos >= len(l.input) {
+			return token{}, l.errorf(start, "unterminated string")
+		}
+		r, width := utf8.DecodeRuneInString(l.input[l.pos:])
+		l.pos += width
+		switch r {
+		case quote:
+			return token{typ: tokString, val: sb.String(), pos: Pos(start)}, nil
+		case '\\':
+			if l.pos >= len(l.input) {
+				return token{}, l.errorf(start, "unterminated string")
+			}
+			esc, w := utf8.DecodeRuneInString(l.input[l.pos:])
+			l.pos += w
+			switch esc {
+			case 'n':
+				sb.WriteRune('\n')
+			case 't':
+				sb.WriteRune('\t')
+			default:
+				sb.WriteRune(esc)
+			}
+		case '\n':
+			return token{}, l.errorf(start, "unterminated string")
+		default:
+			sb.WriteRune(r)
+		}
+	}
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || r == ':' || unicode.IsLetter(r)
+}
+
+func isIdentChar(r rune) bool {
+	return isIdentStart(r) || isDigit(r)
+}
+
+func isDurationUnit(r rune) bool {
+	switch r {
+	case 's', 'm', 'h', 'd', 'w', 'y':
+		return true
+	}
+	return false
+}
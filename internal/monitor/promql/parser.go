@@ -0,0 +1,510 @@
+package promql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseError is returned for malformed expressions. It carries the byte
+// offset of the offending token together with its line and column.
+type ParseError struct {
+	Pos    Pos
+	Line   int
+	Column int
+	Msg    string
+	Query  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse error at line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+func newParseError(input string, pos Pos, msg string) *ParseError {
+	if int(pos) > len(input) {
+		pos = Pos(len(input))
+	}
+	line, col := 1, 1
+	prefix := input[:pos]
+	if idx := strings.LastIndexByte(prefix, '\n'); idx >= 0 {
+		line += strings.Count(prefix, "\n")
+		prefix = prefix[idx+1:]
+	}
+	col += utf8.RuneCountInString(prefix)
+	return &ParseError{Pos: pos, Line: line, Column: col, Msg: msg, Query: input}
+}
+
+// operator precedence, higher binds tighter
+var binaryPrecedence = map[tokenType]int{
+	tokOr:     1,
+	tokAnd:    2,
+	tokUnless: 2,
+	tokEQL:    3,
+	tokNEQ:    3,
+	tokGTR:    3,
+	tokLSS:    3,
+	tokGTE:    3,
+	tokLTE:    3,
+	tokAdd:    4,
+	tokSub:    4,
+	tokMul:    5,
+	tokDiv:    5,
+	tokMod:    5,
+	tokPow:    6,
+}
+
+func isComparisonOp(t tokenType) bool {
+	switch t {
+	case tokEQL, tokNEQ, tokGTR, tokLSS, tokGTE, tokLTE:
+		return true
+	}
+	return false
+}
+
+func isSetOp(t tokenType) bool {
+	return t == tokAnd || t == tokOr || t == tokUnless
+}
+
+// aggregateOps lists the supported aggregation operators
+var aggregateOps = map[string]bool{
+	"sum":   true,
+	"avg":   true,
+	"min":   true,
+	"max":   true,
+	"count": true,
+}
+
+type parser struct {
+	input  string
+	tokens []token
+	pos    int
+}
+
+// ParseExpr parses an expression and type-checks it
+func ParseExpr(input string) (Expr, error) {
+	tokens, err := lexAll(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{input: input, tokens: tokens}
+
+	if p.peek().typ == tokEOF {
+		return nil, p.errorf(p.peek().pos, "empty expression")
+	}
+
+	expr, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.typ != tokEOF {
+		return nil, p.errorf(tok.pos, "unexpected %s", describe(tok))
+	}
+	return expr, nil
+}
+
+func (p *parser) errorf(pos Pos, format string, args ...interface{}) error {
+	return newParseError(p.input, pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.pos]
+	if tok.typ != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(typ tokenType, context string) (token, error) {
+	tok := p.advance()
+	if tok.typ != typ {
+		return tok, p.errorf(tok.pos, "unexpected %s in %s, expected %s", describe(tok), context, typ)
+	}
+	return tok, nil
+}
+
+func describe(tok token) string {
+	switch tok.typ {
+	case tokEOF:
+		return "end of input"
+	case tokIdentifier, tokNumber, tokDuration:
+		return fmt.Sprintf("%s %q", tok.typ, tok.val)
+	case tokString:
+		return fmt.Sprintf("string %q", tok.val)
+	}
+	return tok.typ.String()
+}
+
+func (p *parser) parseExpr(minPrec int) (Expr, error) {
+	lhs, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		opTok := p.peek()
+		prec, ok := binaryPrecedence[opTok.typ]
+		if !ok || prec < minPrec {
+			return lhs, nil
+		}
+		p.advance()
+
+		returnBool := false
+		if p.peek().typ == tokBool {
+			if !isComparisonOp(opTok.typ) {
+				return nil, p.errorf(p.peek().pos, "bool modifier can only be used on comparison operators")
+			}
+			p.advance()
+			returnBool = true
+		}
+
+		var matching *VectorMatching
+		if t := p.peek().typ; t == tokOn || t == tokIgnoring {
+			p.advance()
+			labels, err := p.parseGrouping()
+			if err != nil {
+				return nil, err
+			}
+			matching = &VectorMatching{On: t == tokOn, Labels: labels}
+		}
+
+		// ^ is right associative, everything else left associative
+		nextMin := prec + 1
+		if opTok.typ == tokPow {
+			nextMin = prec
+		}
+		rhs, err := p.parseExpr(nextMin)
+		if err != nil {
+			return nil, err
+		}
+
+		bin := &BinaryExpr{Op: opTok.typ, LHS: lhs, RHS: rhs, ReturnBool: returnBool, Matching: matching, Pos: opTok.pos}
+		if err := p.checkBinary(bin, opTok); err != nil {
+			return nil, err
+		}
+		lhs = bin
+	}
+}
+
+func (p *parser) checkBinary(e *BinaryExpr, opTok token) error {
+	for _, operand := range []Expr{e.LHS, e.RHS} {
+		if operand.Type() == ValueTypeMatrix {
+			return p.errorf(operand.PositionRange(), "binary expression must contain only scalar and instant vector types, got range vector %s", operand)
+		}
+	}
+	if isSetOp(e.Op) {
+		if e.LHS.Type() != ValueTypeVector || e.RHS.Type() != ValueTypeVector {
+			return p.errorf(opTok.pos, "set operator %s not allowed in binary scalar expression", opTok.typ)
+		}
+	}
+	if e.Matching != nil && (e.LHS.Type() != ValueTypeVector || e.RHS.Type() != ValueTypeVector) {
+		return p.errorf(opTok.pos, "vector matching only allowed between instant vectors")
+	}
+	if isComparisonOp(e.Op) && !e.ReturnBool &&
+		e.LHS.Type() == ValueTypeScalar && e.RHS.Type() == ValueTypeScalar {
+		return p.errorf(opTok.pos, "comparisons between scalars must use the bool modifier")
+	}
+	return nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	tok := p.peek()
+	if tok.typ == tokSub || tok.typ == tokAdd {
+		p.advance()
+		// Unary operators bind tighter than everything except ^
+		expr, err := p.parseExpr(binaryPrecedence[tokPow])
+		if err != nil {
+			return nil, err
+		}
+		if expr.Type() == ValueTypeMatrix {
+			return nil, p.errorf(tok.pos, "unary expression only allowed on expressions of type scalar or instant vector")
+		}
+		if tok.typ == tokAdd {
+			return expr, nil
+		}
+		if num, ok := expr.(*NumberLiteral); ok {
+			num.Val = -num.Val
+			num.Pos = tok.pos
+			return num, nil
+		}
+		return &UnaryExpr{Op: tokSub, Expr: expr, Pos: tok.pos}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.peek()
+	switch tok.typ {
+	case tokNumber:
+		p.advance()
+		val, err := strconv.ParseFloat(tok.val, 64)
+		if err != nil {
+			return nil, p.errorf(tok.pos, "invalid number %q", tok.val)
+		}
+		return &NumberLiteral{Val: val, Pos: tok.pos}, nil
+
+	case tokLeftParen:
+		p.advance()
+		inner, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRightParen, "parenthesized expression"); err != nil {
+			return nil, err
+		}
+		if p.peek().typ == tokLeftBracket {
+			return nil, p.errorf(p.peek().pos, "ranges only allowed for vector selectors")
+		}
+		return &ParenExpr{Expr: inner, Pos: tok.pos}, nil
+
+	case tokLeftBrace:
+		return p.parseSelector("", tok.pos)
+
+	case tokIdentifier:
+		next := p.peekAt(1)
+		if aggregateOps[strings.ToLower(tok.val)] &&
+			(next.typ == tokLeftParen || next.typ == tokBy || next.typ == tokWithout) {
+			return p.parseAggregate()
+		}
+		if next.typ == tokLeftParen {
+			return p.parseCall()
+		}
+		p.advance()
+		return p.parseSelector(tok.val, tok.pos)
+	}
+
+	return nil, p.errorf(tok.pos, "unexpected %s", describe(tok))
+}
+
+func (p *parser) parseSelector(name string, pos Pos) (Expr, error) {
+	vs := &VectorSelector{Name: name, Pos: pos}
+
+	if p.peek().typ == tokLeftBrace {
+		matchers, err := p.parseLabelMatchers()
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matchers {
+			if m.Name == MetricNameLabel {
+				if vs.Name != "" {
+					return nil, p.errorf(pos, "metric name must not be set twice: %q", vs.Name)
+				}
+				if m.Type == MatchEqual {
+					vs.Name = m.Value
+					continue
+				}
+			}
+			vs.Matchers = append(vs.Matchers, m)
+		}
+	}
+
+	if vs.Name == "" {
+		nonEmpty := false
+		for _, m := range vs.Matchers {
+			if !m.Matches("") {
+				nonEmpty = true
+				break
+			}
+		}
+		if !nonEmpty {
+			return nil, p.errorf(pos, "vector selector must contain at least one non-empty matcher")
+		}
+	}
+
+	if p.peek().typ != tokLeftBracket {
+		return vs, nil
+	}
+
+	p.advance()
+	durTok, err := p.expect(tokDuration, "range selector")
+	if err != nil {
+		return nil, err
+	}
+	rng, err := parseDuration(durTok.val)
+	if err != nil {
+		return nil, p.errorf(durTok.pos, "bad duration %q: %v", durTok.val, err)
+	}
+	if _, err := p.expect(tokRightBracket, "range selector"); err != nil {
+		return nil, err
+	}
+	return &MatrixSelector{Vector: vs, Range: rng, Pos: pos}, nil
+}
+
+func (p *parser) parseLabelMatchers() ([]*LabelMatcher, error) {
+	if _, err := p.expect(tokLeftBrace, "label matching"); err != nil {
+		return nil, err
+	}
+
+	var matchers []*LabelMatcher
+	for p.peek().typ != tokRightBrace {
+		nameTok := p.advance()
+		if nameTok.typ != tokIdentifier && keywords[strings.ToLower(nameTok.val)] == 0 {
+			return nil, p.errorf(nameTok.pos, "unexpected %s in label matching, expected label name", describe(nameTok))
+		}
+
+		opTok := p.advance()
+		var mt MatchType
+		switch opTok.typ {
+		case tokAssign:
+			mt = MatchEqual
+		case tokNEQ:
+			mt = MatchNotEqual
+		case tokEQLRegex:
+			mt = MatchRegexp
+		case tokNEQRegex:
+			mt = MatchNotRegexp
+		case tokEQL:
+			return nil, p.errorf(opTok.pos, "unexpected \"==\" in label matching, expected \"=\"")
+		default:
+			return nil, p.errorf(opTok.pos, "unexpected %s in label matching, expected label matching operator", describe(opTok))
+		}
+
+		valTok, err := p.expect(tokString, "label matching")
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := NewLabelMatcher(mt, nameTok.val, valTok.val)
+		if err != nil {
+			return nil, p.errorf(valTok.pos, "invalid regular expression %q: %v", valTok.val, err)
+		}
+		matchers = append(matchers, m)
+
+		if p.peek().typ == tokComma {
+			p.advance()
+			continue
+		}
+		if p.peek().typ != tokRightBrace {
+			return nil, p.errorf(p.peek().pos, "unexpected %s in label matching, expected \",\" or \"}\"", describe(p.peek()))
+		}
+	}
+	p.advance()
+	return matchers, nil
+}
+
+func (p *parser) parseGrouping() ([]string, error) {
+	if _, err := p.expect(tokLeftParen, "grouping"); err != nil {
+		return nil, err
+	}
+	var labels []string
+	for p.peek().typ != tokRightParen {
+		tok := p.advance()
+		if tok.typ != tokIdentifier {
+			return nil, p.errorf(tok.pos, "unexpected %s in grouping, expected label name", describe(tok))
+		}
+		labels = append(labels, tok.val)
+		if p.peek().typ == tokComma {
+			p.advance()
+			continue
+		}
+		if p.peek().typ != tokRightParen {
+			return nil, p.errorf(p.peek().pos, "unexpected %s in grouping, expected \",\" or \")\"", describe(p.peek()))
+		}
+	}
+	p.advance()
+	return labels, nil
+}
+
+func (p *parser) parseAggregate() (Expr, error) {
+	opTok := p.advance()
+	agg := &AggregateExpr{Op: strings.ToLower(opTok.val), Pos: opTok.pos}
+
+	parseModifier := func() error {
+		switch p.peek().typ {
+		case tokBy, tokWithout:
+			if agg.Grouping != nil {
+				return p.errorf(p.peek().pos, "aggregation grouping specified twice")
+			}
+			agg.Without = p.advance().typ == tokWithout
+			grouping, err := p.parseGrouping()
+			if err != nil {
+				return err
+			}
+			if grouping == nil {
+				grouping = []string{}
+			}
+			agg.Grouping = grouping
+		}
+		return nil
+	}
+
+	if err := parseModifier(); err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokLeftParen, "aggregation"); err != nil {
+		return nil, err
+	}
+	inner, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokRightParen, "aggregation"); err != nil {
+		return nil, err
+	}
+	if err := parseModifier(); err != nil {
+		return nil, err
+	}
+
+	if inner.Type() != ValueTypeVector {
+		return nil, p.errorf(inner.PositionRange(), "expected type instant vector in aggregation expression, got %s", inner.Type())
+	}
+	agg.Expr = inner
+	return agg, nil
+}
+
+func (p *parser) parseCall() (Expr, error) {
+	nameTok := p.advance()
+	fn, ok := functions[nameTok.val]
+	if !ok {
+		return nil, p.errorf(nameTok.pos, "unknown function with name %q", nameTok.val)
+	}
+	p.advance() // (
+
+	call := &Call{Func: fn, Pos: nameTok.pos}
+	for p.peek().typ != tokRightParen {
+		arg, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+		if p.peek().typ == tokComma {
+			p.advance()
+			continue
+		}
+		if p.peek().typ != tokRightParen {
+			return nil, p.errorf(p.peek().pos, "unexpected %s in function call, expected \",\" or \")\"", describe(p.peek()))
+		}
+	}
+	closeTok := p.advance()
+
+	if len(call.Args) != len(fn.ArgTypes) {
+		return nil, p.errorf(closeTok.pos, "expected %d argument(s) in call to %q, got %d", len(fn.ArgTypes), fn.Name, len(call.Args))
+	}
+	for i, arg := range call.Args {
+		if arg.Type() != fn.ArgTypes[i] {
+			return nil, p.errorf(arg.PositionRange(), "expected type %s in call to function %q, got %s", describeType(fn.ArgTypes[i]), fn.Name, describeType(arg.Type()))
+		}
+	}
+	return call, nil
+}
+
+func describeType(t ValueType) string {
+	switch t {
+	case ValueTypeVector:
+		return "instant vector"
+	case ValueTypeMatrix:
+		return "range vector"
+	}
+	return string(t)
+}
@@ -0,0 +1,223 @@
+package promql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/model"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is a minimal in-memory TimeseriesStore for tests
+type memoryStore struct {
+	points []*model.MetricPoint
+}
+
+func (m *memoryStore) Write(ctx context.Context, points []*model.MetricPoint) error {
+	m.points = append(m.points, points...)
+	return nil
+}
+
+func (m *memoryStore) Query(ctx context.Context, q *storage.Query) ([]*model.MetricPoint, error) {
+	var out []*model.MetricPoint
+	for _, p := range m.points {
+		if q.Metric != "" && p.Name != q.Metric {
+			continue
+		}
+		if p.Timestamp.Before(q.Start) || p.Timestamp.After(q.End) {
+			continue
+		}
+		match := true
+		for k, v := range q.Labels {
+			if p.Labels[k] != v {
+				match = false
+				break
+			}
+		}
+		if match {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryStore) Close() error { return nil }
+
+func TestParseExpr_Valid(t *testing.T) {
+	exprs := []string{
+		"cpu_usage > 80",
+		"redis_memory_percent >= 90",
+		`rate(redis_evicted_keys[5m]) > 10`,
+		`avg_over_time(cpu_usage{instance=~"node-.*"}[15m]) > 80`,
+		`sum by (instance) (increase(kafka_consumer_lag[10m]))`,
+		`max without (pod) (mysql_threads_running) > 100`,
+		`(mysql_threads_running / mysql_max_connections) * 100 >= 90`,
+		`redis_memory_percent > 90 and redis_connected_clients > 1000`,
+		`up == 0 or on (instance) redis_rejected_connections > 0`,
+		`deriv(disk_used_bytes[1h]) > 0 unless disk_free_bytes > 1e9`,
+		`cpu_usage > bool 80`,
+		`-cpu_usage < -5`,
+		`{__name__="cpu_usage", job!="test"}`,
+	}
+	for _, e := range exprs {
+		_, err := ParseExpr(e)
+		assert.NoError(t, err, e)
+	}
+}
+
+func TestParseExpr_Errors(t *testing.T) {
+	tests := []struct {
+		expr   string
+		column int
+		msg    string
+	}{
+		{"cpu_usage >", 12, "unexpected end of input"},
+		{"rate(cpu_usage) > 1", 6, "expected type range vector"},
+		{"unknown_fn(cpu_usage[5m])", 1, "unknown function"},
+		{`cpu_usage{instance="a"`, 23, "expected \",\" or \"}\""},
+		{"cpu_usage[5x]", 11, "bad duration"},
+		{"sum(cpu_usage[5m])", 5, "expected type instant vector in aggregation"},
+		{"1 > 2", 3, "bool modifier"},
+		{"cpu_usage and 5", 11, "set operator"},
+		{"cpu_usage{instance==\"a\"}", 19, "expected \"=\""},
+		{"", 1, "empty expression"},
+	}
+	for _, tt := range tests {
+		_, err := ParseExpr(tt.expr)
+		require.Error(t, err, tt.expr)
+
+		var perr *ParseError
+		require.True(t, errors.As(err, &perr), tt.expr)
+		assert.Equal(t, tt.column, perr.Column, "column for %q: %v", tt.expr, err)
+		assert.Contains(t, err.Error(), tt.msg, tt.expr)
+	}
+}
+
+func TestParseError_MultiLine(t *testing.T) {
+	_, err := ParseExpr("sum by (instance) (\n  rate(x[5m]\n)")
+	require.Error(t, err)
+	var perr *ParseError
+	require.True(t, errors.As(err, &perr))
+	assert.Equal(t, 3, perr.Line)
+	assert.Equal(t, 2, perr.Column)
+}
+
+func newTestEngine(t *testing.T, now time.Time) *Engine {
+	store := &memoryStore{}
+	add := func(name string, labels map[string]string, values ...float64) {
+		for i, v := range values {
+			ts := now.Add(time.Duration(i-len(values)+1) * time.Minute)
+			require.NoError(t, store.Write(context.Background(), []*model.MetricPoint{{
+				Name: name, Value: v, Timestamp: ts, Labels: labels,
+			}}))
+		}
+	}
+
+	add("redis_evicted_keys", map[string]string{"instance": "redis-0"}, 0, 600, 1200, 1800, 2400)
+	add("redis_evicted_keys", map[string]string{"instance": "redis-1"}, 0, 10, 20, 30, 40)
+	add("redis_memory_percent", map[string]string{"instance": "redis-0"}, 95)
+	add("redis_memory_percent", map[string]string{"instance": "redis-1"}, 50)
+	add("redis_connected_clients", map[string]string{"instance": "redis-0"}, 10)
+	add("redis_connected_clients", map[string]string{"instance": "redis-1"}, 5000)
+	// Counter with a reset in the middle
+	add("requests_total", map[string]string{"instance": "app-0"}, 100, 200, 50, 150)
+
+	return NewEngine(store)
+}
+
+func evalVector(t *testing.T, engine *Engine, now time.Time, input string) Vector {
+	expr, err := ParseExpr(input)
+	require.NoError(t, err, input)
+	vec, err := engine.InstantVector(context.Background(), expr, now)
+	require.NoError(t, err, input)
+	sortVector(vec)
+	return vec
+}
+
+func TestEngine_RateFiresPerInstance(t *testing.T) {
+	now := time.Now()
+	engine := newTestEngine(t, now)
+
+	vec := evalVector(t, engine, now, "rate(redis_evicted_keys[5m]) > 5")
+	require.Len(t, vec, 1)
+	assert.Equal(t, "redis-0", vec[0].Labels["instance"])
+	assert.InDelta(t, 10.0, vec[0].Value, 0.001)
+	_, hasName := vec[0].Labels[MetricNameLabel]
+	assert.False(t, hasName)
+}
+
+func TestEngine_IncreaseHandlesCounterReset(t *testing.T) {
+	now := time.Now()
+	engine := newTestEngine(t, now)
+
+	vec := evalVector(t, engine, now, "increase(requests_total[10m])")
+	require.Len(t, vec, 1)
+	// 100 -> 200 (+100), reset to 50 (+50), 50 -> 150 (+100)
+	assert.Equal(t, 250.0, vec[0].Value)
+}
+
+func TestEngine_ComparisonKeepsLabelsAndValue(t *testing.T) {
+	now := time.Now()
+	engine := newTestEngine(t, now)
+
+	vec := evalVector(t, engine, now, "redis_memory_percent > 90")
+	require.Len(t, vec, 1)
+	assert.Equal(t, "redis_memory_percent", vec[0].Labels[MetricNameLabel])
+	assert.Equal(t, 95.0, vec[0].Value)
+}
+
+func TestEngine_SetOperators(t *testing.T) {
+	now := time.Now()
+	engine := newTestEngine(t, now)
+
+	vec := evalVector(t, engine, now, "redis_memory_percent > 90 and redis_connected_clients > 1000")
+	assert.Len(t, vec, 0)
+
+	vec = evalVector(t, engine, now, "redis_memory_percent > 90 or redis_connected_clients > 1000")
+	require.Len(t, vec, 2)
+
+	vec = evalVector(t, engine, now, "redis_memory_percent unless redis_connected_clients > 1000")
+	require.Len(t, vec, 1)
+	assert.Equal(t, "redis-0", vec[0].Labels["instance"])
+}
+
+func TestEngine_AggregationAndArithmetic(t *testing.T) {
+	now := time.Now()
+	engine := newTestEngine(t, now)
+
+	vec := evalVector(t, engine, now, "sum(redis_connected_clients)")
+	require.Len(t, vec, 1)
+	assert.Equal(t, 5010.0, vec[0].Value)
+	assert.Empty(t, vec[0].Labels)
+
+	vec = evalVector(t, engine, now, "max by (instance) (redis_memory_percent)")
+	require.Len(t, vec, 2)
+
+	vec = evalVector(t, engine, now, "redis_connected_clients / redis_memory_percent")
+	require.Len(t, vec, 2)
+	assert.InDelta(t, 10.0/95.0, vec[0].Value, 0.0001)
+	assert.Equal(t, 100.0, vec[1].Value)
+}
+
+func TestEngine_RegexMatcher(t *testing.T) {
+	now := time.Now()
+	engine := newTestEngine(t, now)
+
+	vec := evalVector(t, engine, now, `redis_memory_percent{instance=~"redis-[1-9]"}`)
+	require.Len(t, vec, 1)
+	assert.Equal(t, "redis-1", vec[0].Labels["instance"])
+}
+
+func TestEngine_ScalarResultRejected(t *testing.T) {
+	engine := NewEngine(&memoryStore{})
+	expr, err := ParseExpr("1 + 2")
+	require.NoError(t, err)
+	_, err = engine.InstantVector(context.Background(), expr, time.Now())
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "expected instant vector"))
+}
@@ -0,0 +1,53 @@
+package promql
+
+import (
+	"sort"
+	"time"
+)
+
+// Value is the result of evaluating an expression
+type Value interface {
+	Type() ValueType
+}
+
+// Scalar is a single numeric value
+type Scalar struct {
+	V float64
+	T time.Time
+}
+
+// Sample is a single value of one series at the evaluation time
+type Sample struct {
+	Labels Labels
+	Value  float64
+	T      time.Time
+}
+
+// Vector is a set of samples, at most one per label set
+type Vector []Sample
+
+// Point is a timestamped value within a series
+type Point struct {
+	T time.Time
+	V float64
+}
+
+// Series is a labelled, time-ordered list of points
+type Series struct {
+	Labels Labels
+	Points []Point
+}
+
+// Matrix is a set of series
+type Matrix []Series
+
+func (Scalar) Type() ValueType { return ValueTypeScalar }
+func (Vector) Type() ValueType { return ValueTypeVector }
+func (Matrix) Type() ValueType { return ValueTypeMatrix }
+
+// sortVector orders samples by label fingerprint so results are stable
+func sortVector(v Vector) {
+	sort.Slice(v, func(i, j int) bool {
+		return v[i].Labels.Fingerprint() < v[j].Labels.Fingerprint()
+	})
+}
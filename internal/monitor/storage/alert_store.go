@@ -26,6 +26,14 @@ type AlertQuery struct {
 type AlertStore interface {
     Save(ctx context.Context, alert *types.Alert) error
     Query(ctx context.Context, query *AlertQuery) ([]*types.Alert, error)
+
+    // SaveState upserts the current state of an alert instance
+    SaveState(ctx context.Context, instance *types.AlertInstance) error
+    // ListStates returns all persisted alert instances
+    ListStates(ctx context.Context) ([]*types.AlertInstance, error)
+    // DeleteState removes the state of an alert instance
+    DeleteState(ctx context.Context, ruleName, fingerprint string) error
+
     Close() error
 }
 
@@ -55,6 +63,20 @@ func NewSQLiteAlertStore(path string) (*SQLiteAlertStore, error) {
         resolved_at DATETIME
     );
     CREATE INDEX IF NOT EXISTS idx_alerts_fired_at ON alerts(fired_at);
+    CREATE TABLE IF NOT EXISTS alert_states (
+        rule_name TEXT NOT NULL,
+        fingerprint TEXT NOT NULL,
+        state TEXT NOT NULL,
+        severity TEXT,
+        labels TEXT,
+        annotations TEXT,
+        value REAL,
+        active_at DATETIME NOT NULL,
+        fired_at DATETIME,
+        resolved_at DATETIME,
+        last_eval_at DATETIME,
+        PRIMARY KEY (rule_name, fingerprint)
+    );
     `
     if _, err := db.Exec(query); err != nil {
         db.Close()
@@ -140,6 +162,75 @@ func (s *SQLiteAlertStore) Query(ctx context.Context, q *AlertQuery) ([]*types.A
     return alerts, nil
 }
 
+func (s *SQLiteAlertStore) SaveState(ctx context.Context, i *types.AlertInstance) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    labelsJSON, _ := json.Marshal(i.Labels)
+    annotationsJSON, _ := json.Marshal(i.Annotations)
+
+    query := `INSERT OR REPLACE INTO alert_states
+              (rule_name, fingerprint, state, severity, labels, annotations, value, active_at, fired_at, resolved_at, last_eval_at)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+    _, err := s.db.ExecContext(ctx, query,
+        i.RuleName, i.Fingerprint, string(i.State), i.Severity,
+        string(labelsJSON), string(annotationsJSON), i.Value,
+        i.ActiveAt, i.FiredAt, i.ResolvedAt, i.LastEvalAt)
+    return err
+}
+
+func (s *SQLiteAlertStore) ListStates(ctx context.Context) ([]*types.AlertInstance, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    rows, err := s.db.QueryContext(ctx, `SELECT rule_name, fingerprint, state, severity, labels, annotations, value,
+        active_at, fired_at, resolved_at, last_eval_at FROM alert_states`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var instances []*types.AlertInstance
+    for rows.Next() {
+        var i types.AlertInstance
+        var state string
+        var severity, labelsJSON, annotationsJSON sql.NullString
+        var firedAt, resolvedAt, lastEvalAt sql.NullTime
+
+        if err := rows.Scan(&i.RuleName, &i.Fingerprint, &state, &severity, &labelsJSON, &annotationsJSON, &i.Value,
+            &i.ActiveAt, &firedAt, &resolvedAt, &lastEvalAt); err != nil {
+            return nil, err
+        }
+
+        i.State = types.AlertState(state)
+        i.Severity = severity.String
+        if firedAt.Valid {
+            i.FiredAt = firedAt.Time
+        }
+        if resolvedAt.Valid {
+            i.ResolvedAt = resolvedAt.Time
+        }
+        if lastEvalAt.Valid {
+            i.LastEvalAt = lastEvalAt.Time
+        }
+        _ = json.Unmarshal([]byte(labelsJSON.String), &i.Labels)
+        _ = json.Unmarshal([]byte(annotationsJSON.String), &i.Annotations)
+
+        instances = append(instances, &i)
+    }
+
+    return instances, rows.Err()
+}
+
+func (s *SQLiteAlertStore) DeleteState(ctx context.Context, ruleName, fingerprint string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    _, err := s.db.ExecContext(ctx, "DELETE FROM alert_states WHERE rule_name = ? AND fingerprint = ?", ruleName, fingerprint)
+    return err
+}
+
 func (s *SQLiteAlertStore) Close() error {
     return s.db.Close()
 }
@@ -7,6 +7,7 @@ import (
 // Alert represents an alert event
 type Alert struct {
     RuleName    string            `json:"rule_name"`
+    Fingerprint string            `json:"fingerprint,omitempty"` // Identifies the label set within the rule
     Severity    string            `json:"severity"`
     Status      string            `json:"status"` // firing/resolved
     Labels      map[string]string `json:"labels"`
@@ -16,6 +17,48 @@ type Alert struct {
     ResolvedAt  time.Time         `json:"resolved_at,omitempty"`
 }
 
+// AlertState is the lifecycle state of an alert instance
+type AlertState string
+
+const (
+    AlertStateInactive AlertState = "inactive"
+    AlertStatePending  AlertState = "pending"  // Condition met, waiting for the For duration
+    AlertStateFiring   AlertState = "firing"   // Condition held for the For duration, notified
+    AlertStateResolved AlertState = "resolved" // Was firing, condition no longer met
+)
+
+// AlertInstance is the state of one rule for one label set.
+// A rule matching two instances yields two independent AlertInstances.
+type AlertInstance struct {
+    RuleName    string            `json:"rule_name"`
+    Fingerprint string            `json:"fingerprint"`
+    Labels      map[string]string `json:"labels"`
+    Annotations map[string]string `json:"annotations"`
+    Severity    string            `json:"severity"`
+    State       AlertState        `json:"state"`
+    Value       float64           `json:"value"`
+    ActiveAt    time.Time         `json:"active_at"`             // Condition first met
+    FiredAt     time.Time         `json:"fired_at,omitempty"`    // Transition to firing
+    ResolvedAt  time.Time         `json:"resolved_at,omitempty"` // Transition to resolved
+    LastEvalAt  time.Time         `json:"last_eval_at"`
+}
+
+// ToAlert converts the instance to an alert event for notification
+func (i *AlertInstance) ToAlert() *Alert {
+    status := string(i.State)
+    return &Alert{
+        RuleName:    i.RuleName,
+        Fingerprint: i.Fingerprint,
+        Severity:    i.Severity,
+        Status:      status,
+        Labels:      i.Labels,
+        Annotations: i.Annotations,
+        Value:       i.Value,
+        FiredAt:     i.FiredAt,
+        ResolvedAt:  i.ResolvedAt,
+    }
+}
+
 // AlertRule represents an alerting rule
 type AlertRule struct {
     Name        string            // Rule name
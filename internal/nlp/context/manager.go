@@ -25,6 +25,17 @@ type ConversationContext struct {
 	CreatedAt    time.Time                                   `json:"created_at"`
 	UpdatedAt    time.Time                                   `json:"updated_at"`
 	MaxTurns     int                                         `json:"max_turns"`
+	// Pending is the question the assistant is waiting on, if any.
+	Pending *Clarification `json:"pending,omitempty"`
+}
+
+// Clarification is a question asked because an intent lacked required slots.
+// The intent is resumed once the user supplies them.
+type Clarification struct {
+	Intent   *intent.Intent      `json:"intent"`
+	Missing  []entity.EntityType `json:"missing"`
+	Question string              `json:"question"`
+	AskedAt  time.Time           `json:"asked_at"`
 }
 
 // Turn represents a single turn in the conversation.
@@ -67,6 +78,30 @@ func (c *ConversationContext) RecentIntents() []*intent.Intent {
 	return intents
 }
 
+// Ask records a clarification question the next turn should answer.
+func (c *ConversationContext) Ask(clarification *Clarification) {
+	if clarification.AskedAt.IsZero() {
+		clarification.AskedAt = time.Now()
+	}
+	c.Pending = clarification
+}
+
+// TakePending returns and clears the pending clarification.
+func (c *ConversationContext) TakePending() *Clarification {
+	p := c.Pending
+	c.Pending = nil
+	return p
+}
+
+// SetActiveEntity makes e the active entity of its type, e.g. when the user
+// answered a clarification with a bare value.
+func (c *ConversationContext) SetActiveEntity(e entity.Entity) {
+	if c.ActiveEntity == nil {
+		c.ActiveEntity = make(map[entity.EntityType]entity.Entity)
+	}
+	c.ActiveEntity[e.Type] = e
+}
+
 // GetActiveEntity gets the currently active entity of a given type.
 func (c *ConversationContext) GetActiveEntity(entityType entity.EntityType) (entity.Entity, bool) {
 	if c.ActiveEntity == nil {
@@ -24,13 +24,16 @@ type PatternBasedExtractor struct {
 func BuildDefaultExtractor() *PatternBasedExtractor {
 	e := &PatternBasedExtractor{
 		patterns: map[EntityType][]*EntityPattern{
-			EntityTimeRange:  timeRangePatterns,
-			EntityThreshold:  thresholdPatterns,
-			EntityInstanceID: instanceIDPatterns,
+			EntityTimeRange:   timeRangePatterns,
+			EntityThreshold:   thresholdPatterns,
+			EntityInstanceID:  instanceIDPatterns,
+			EntityNamespace:   namespacePatterns,
+			EntityConfigValue: configValuePatterns,
 		},
 		dictionaries: map[EntityType]map[string]string{
 			EntityMiddlewareType: middlewareTypeDict,
 			EntityMetricName:     metricNameDict,
+			EntityConfigKey:      configKeyDict,
 		},
 		dictionaryRegexs: make(map[EntityType]*regexp.Regexp),
 	}
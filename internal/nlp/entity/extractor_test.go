@@ -128,3 +128,62 @@ func TestEntityExtractor_InstanceID(t *testing.T) {
 		assert.True(t, found, "should find instance ID in: %s", tc)
 	}
 }
+
+func TestEntityExtractor_NamespaceAndConfig(t *testing.T) {
+	extractor := entity.BuildDefaultExtractor()
+	ctx := context.Background()
+
+	cases := []struct {
+		input    string
+		expected map[entity.EntityType]string
+	}{
+		{"诊断 redis-cluster-01 namespace cache-prod", map[entity.EntityType]string{
+			entity.EntityInstanceID: "redis-cluster-01",
+			entity.EntityNamespace:  "cache-prod",
+		}},
+		{"检查mysql-master -n Prod", map[entity.EntityType]string{
+			entity.EntityInstanceID: "mysql-master",
+			entity.EntityNamespace:  "prod",
+		}},
+		{"把redis-0的maxmemory改成4gb", map[entity.EntityType]string{
+			entity.EntityInstanceID:  "redis-0",
+			entity.EntityConfigKey:   "maxmemory",
+			entity.EntityConfigValue: "4gb",
+		}},
+		{"set max_connections=500 ns=db", map[entity.EntityType]string{
+			entity.EntityConfigKey:   "max_connections",
+			entity.EntityConfigValue: "500",
+			entity.EntityNamespace:   "db",
+		}},
+		{"静默redis-0的告警2小时", map[entity.EntityType]string{
+			entity.EntityInstanceID: "redis-0",
+			entity.EntityTimeRange:  "2h",
+		}},
+		{"silence mysql-master for 30m", map[entity.EntityType]string{
+			entity.EntityInstanceID: "mysql-master",
+			entity.EntityTimeRange:  "30m",
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			entities, err := extractor.Extract(ctx, tc.input, nil)
+			require.NoError(t, err)
+
+			got := make(map[entity.EntityType]string)
+			for _, e := range entities {
+				got[e.Type] = e.NormValue
+			}
+			for entityType, want := range tc.expected {
+				assert.Equal(t, want, got[entityType], "entity %s", entityType)
+			}
+		})
+	}
+
+	// A word merely starting with "ns" is not a namespace
+	entities, err := extractor.Extract(ctx, "nslookup redis-0", nil)
+	require.NoError(t, err)
+	for _, e := range entities {
+		assert.NotEqual(t, entity.EntityNamespace, e.Type)
+	}
+}
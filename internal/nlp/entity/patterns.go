@@ -51,19 +51,29 @@ var metricNameDict = map[string]string{
 	"堆积":      "consumer_lag",
 }
 
+// Config key dictionary, covering the parameters most often tuned by hand
+var configKeyDict = map[string]string{
+	"maxmemory":               "maxmemory",
+	"maxclients":              "maxclients",
+	"timeout":                 "timeout",
+	"max_connections":         "max_connections",
+	"innodb_buffer_pool_size": "innodb_buffer_pool_size",
+	"shared_buffers":          "shared_buffers",
+	"work_mem":                "work_mem",
+	"num.partitions":          "num.partitions",
+	"log.retention.hours":     "log.retention.hours",
+}
+
 // Time range patterns
 var timeRangePatterns = []*EntityPattern{
 	{
-		Regex: regexp.MustCompile(`最近(\d+)(小时|分钟|天|周|h|m|d|w)`),
-		Normalizer: func(match string) string {
-			// Basic normalization, e.g., "最近1小时" -> "1h"
-			match = strings.Replace(match, "最近", "", 1)
-			match = strings.Replace(match, "小时", "h", 1)
-			match = strings.Replace(match, "分钟", "m", 1)
-			match = strings.Replace(match, "天", "d", 1)
-			match = strings.Replace(match, "周", "w", 1)
-			return match
-		},
+		Regex:      regexp.MustCompile(`最近(\d+)(小时|分钟|天|周|h|m|d|w)`),
+		Normalizer: normalizeDuration,
+	},
+	{
+		// Bare durations, e.g. "静默2小时", "for 30m"
+		Regex:      regexp.MustCompile(`(?i)(\d+)\s*(小时|分钟|天|周)|\b(for|last|past)\s+\d+\s*(h|m|d|w)\b`),
+		Normalizer: normalizeDuration,
 	},
 	{
 		Regex: regexp.MustCompile(`(今天|昨天|本周|上周|today|yesterday)`),
@@ -73,6 +83,18 @@ var timeRangePatterns = []*EntityPattern{
 	},
 }
 
+var durationPrefix = regexp.MustCompile(`(?i)^(最近|for|last|past)\s*`)
+
+// normalizeDuration normalizes a duration match, e.g. "最近1小时" -> "1h"
+func normalizeDuration(match string) string {
+	match = durationPrefix.ReplaceAllString(strings.TrimSpace(match), "")
+	match = strings.Replace(match, "小时", "h", 1)
+	match = strings.Replace(match, "分钟", "m", 1)
+	match = strings.Replace(match, "天", "d", 1)
+	match = strings.Replace(match, "周", "w", 1)
+	return strings.ToLower(strings.Join(strings.Fields(match), ""))
+}
+
 // Threshold patterns
 var thresholdPatterns = []*EntityPattern{
 	{
@@ -102,3 +124,28 @@ var instanceIDPatterns = []*EntityPattern{
 		Normalizer: nil,
 	},
 }
+
+// Config value patterns: the new value following an assignment verb
+var configValuePatterns = []*EntityPattern{
+	{
+		Regex: regexp.MustCompile(`(?i)(改成|改为|设为|设置为|调整为|=)\s*[\w.%-]+`),
+		Normalizer: func(match string) string {
+			return strings.TrimSpace(configAssignVerb.ReplaceAllString(match, ""))
+		},
+	},
+}
+
+var configAssignVerb = regexp.MustCompile(`(?i)^(改成|改为|设为|设置为|调整为|=)`)
+
+// Namespace patterns
+var namespacePatterns = []*EntityPattern{
+	{
+		// Matches: namespace prod, ns=prod, -n prod, 命名空间 prod
+		Regex: regexp.MustCompile(`(?i)(\bnamespace|\bns|(^|\s)-n|命名空间)(\s*[=:：]\s*|\s+)[a-z0-9][a-z0-9-]*`),
+		Normalizer: func(match string) string {
+			return strings.ToLower(namespacePrefix.ReplaceAllString(strings.TrimSpace(match), ""))
+		},
+	},
+}
+
+var namespacePrefix = regexp.MustCompile(`(?i)^(namespace|ns|-n|命名空间)\s*[=:：]?\s*`)
@@ -12,6 +12,7 @@ const (
 	EntityCommand        EntityType = "command"         // FLUSHALL, KILL
 	EntityConfigKey      EntityType = "config_key"      // maxmemory
 	EntityConfigValue    EntityType = "config_value"    // 2gb
+	EntityNamespace      EntityType = "namespace"       // prod, cache-system
 )
 
 // Entity represents an extracted entity.
@@ -130,6 +130,7 @@ var defaultIntentPatterns = map[IntentType][]string{
 	IntentAlert: {
 		`(?i)(设置|配置|添加).*(告警|监控|阈值|通知|alert)`,
 		`(?i)(当|如果).*(超过|低于|达到).*(通知|报警|告诉我)`,
+		`(?i)(静默|屏蔽|silence|mute)`,
 	},
 	IntentConfig: {
 		`(?i)(修改|设置|调整|更新|set|update|config).*(配置|参数|max|min|timeout|buffer)`,
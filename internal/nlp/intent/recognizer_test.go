@@ -72,3 +72,19 @@ func TestRuleBasedRecognizer_FixIntent(t *testing.T) {
 		assert.Equal(t, intent.IntentFix, result.Type)
 	}
 }
+
+func TestRuleBasedRecognizer_SilenceIntent(t *testing.T) {
+	recognizer := intent.NewRuleBasedRecognizer()
+	ctx := context.Background()
+
+	testCases := []string{
+		"静默redis-0的告警2小时",
+		"屏蔽mysql告警",
+		"silence kafka-broker-1 for 30m",
+	}
+
+	for _, tc := range testCases {
+		result, _ := recognizer.Recognize(ctx, &intent.RecognizeRequest{Text: tc})
+		assert.Equal(t, intent.IntentAlert, result.Type, tc)
+	}
+}
@@ -156,6 +156,12 @@ func (p *NLPProcessor) Process(ctx context.Context, req *ProcessRequest) (*Proce
 	return result, nil
 }
 
+// SaveContext persists a conversation context changed after Process, e.g.
+// by a clarification question or the assistant's response.
+func (p *NLPProcessor) SaveContext(ctx context.Context, sessionID string, convCtx *ncontext.ConversationContext) error {
+	return p.contextManager.SaveContext(ctx, sessionID, convCtx)
+}
+
 func (p *NLPProcessor) preprocess(text string) string {
 	return text
 }
@@ -0,0 +1,196 @@
+package planning
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/expr-lang/expr"
+)
+
+// ExprConditionEvaluator evaluates Condition steps and argument templates as
+// expr-lang expressions over the evaluation environment built by PlanEnv:
+//
+//	steps.check_mem.output.used_memory_pct > 90 && input.env == "prod"
+type ExprConditionEvaluator struct{}
+
+// Evaluate evaluates condition, which must yield a bool
+func (e *ExprConditionEvaluator) Evaluate(ctx context.Context, condition string, env map[string]any) (bool, error) {
+	if env == nil {
+		env = map[string]any{}
+	}
+	program, err := expr.Compile(condition, expr.Env(env), expr.AsBool())
+	if err != nil {
+		return false, fmt.Errorf("compile condition %q failed: %w", condition, err)
+	}
+	result, err := expr.Run(program, env)
+	if err != nil {
+		return false, fmt.Errorf("evaluate condition %q failed: %w", condition, err)
+	}
+	return result.(bool), nil
+}
+
+// PlanEnv builds the evaluation environment for the next steps of a plan:
+// "input" holds the plan inputs and "steps.<id>" the status and output of
+// every step run so far. Outputs are converted to their JSON form so struct
+// fields are addressed by their JSON names.
+func PlanEnv(plan *Plan, state *ExecutionState) map[string]any {
+	steps := make(map[string]any, len(state.StepStates))
+	for id, s := range state.StepStates {
+		steps[id] = map[string]any{
+			"status": string(s.Status),
+			"output": jsonValue(s.Output),
+			"error":  s.Error,
+		}
+	}
+	input := plan.Inputs
+	if input == nil {
+		input = map[string]any{}
+	}
+	return map[string]any{
+		"steps": steps,
+		"input": input,
+	}
+}
+
+// jsonValue converts v to the generic form encoding/json decodes into
+func jsonValue(v any) any {
+	switch v.(type) {
+	case nil, bool, string, float64, int, int64, map[string]any, []any:
+		return v
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// templatePattern matches {{ expression }} placeholders in tool arguments
+var templatePattern = regexp.MustCompile(`\{\{\s*(.+?)\s*\}\}`)
+
+// RenderArgs resolves {{ expression }} placeholders in tool arguments against
+// env. An argument consisting of a single placeholder takes the value of the
+// expression with its type; placeholders inside longer strings are formatted.
+func RenderArgs(args map[string]any, env map[string]any) (map[string]any, error) {
+	if args == nil {
+		return nil, nil
+	}
+	rendered, err := renderValue(args, env)
+	if err != nil {
+		return nil, err
+	}
+	return rendered.(map[string]any), nil
+}
+
+func renderValue(v any, env map[string]any) (any, error) {
+	switch val := v.(type) {
+	case string:
+		return renderString(val, env)
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			r, err := renderValue(item, env)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			r, err := renderValue(item, env)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+func renderString(s string, env map[string]any) (any, error) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+	if m := templatePattern.FindStringSubmatchIndex(s); m != nil && m[0] == 0 && m[1] == len(s) {
+		return evalTemplate(s[m[2]:m[3]], env)
+	}
+
+	var evalErr error
+	out := templatePattern.ReplaceAllStringFunc(s, func(match string) string {
+		value, err := evalTemplate(templatePattern.FindStringSubmatch(match)[1], env)
+		if err != nil {
+			evalErr = err
+			return match
+		}
+		return fmt.Sprint(value)
+	})
+	if evalErr != nil {
+		return nil, evalErr
+	}
+	return out, nil
+}
+
+func evalTemplate(expression string, env map[string]any) (any, error) {
+	value, err := expr.Eval(expression, env)
+	if err != nil {
+		return nil, fmt.Errorf("evaluate template {{ %s }} failed: %w", expression, err)
+	}
+	return value, nil
+}
+
+// skipReason reports why step must not run given the outcome of the steps
+// before it, or "" if it should run. A step is skipped when it belongs to the
+// branch a condition did not take, or when every step it depends on was
+// skipped.
+func skipReason(plan *Plan, step *Step, state *ExecutionState) string {
+	if len(step.DependsOn) == 0 {
+		return ""
+	}
+
+	allSkipped := true
+	for _, depID := range step.DependsOn {
+		depState, ran := state.StepStates[depID]
+		if !ran || depState.Status != StepStatusSkipped {
+			allSkipped = false
+		}
+
+		dep, ok := plan.GetStep(depID)
+		if !ok || !ran || dep.Type != StepTypeCondition || depState.Status != StepStatusCompleted {
+			continue
+		}
+		taken, _ := depState.Output.(bool)
+		switch {
+		case containsID(dep.Action.Then, step.ID) && !taken:
+			return fmt.Sprintf("condition %s was false", depID)
+		case containsID(dep.Action.Else, step.ID) && taken:
+			return fmt.Sprintf("condition %s was true", depID)
+		case len(dep.Action.Then) == 0 && len(dep.Action.Else) == 0 && !taken:
+			// Without explicit branches a condition gates its dependents
+			return fmt.Sprintf("condition %s was false", depID)
+		}
+	}
+	if allSkipped {
+		return "all dependencies were skipped"
+	}
+	return ""
+}
+
+func containsID(ids []string, id string) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package planning
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type memoryStats struct {
+	UsedMemoryPct float64 `json:"used_memory_pct"`
+	Instance      string  `json:"instance"`
+}
+
+func TestExprConditionEvaluator(t *testing.T) {
+	env := map[string]any{
+		"steps": map[string]any{
+			"check_mem": map[string]any{"output": jsonValue(memoryStats{UsedMemoryPct: 93, Instance: "redis-0"})},
+		},
+		"input": map[string]any{"env": "prod"},
+	}
+	evaluator := &ExprConditionEvaluator{}
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"true", true},
+		{`steps.check_mem.output.used_memory_pct > 90 && input.env == "prod"`, true},
+		{`steps.check_mem.output.used_memory_pct > 95 || input.env == "staging"`, false},
+		{`steps.check_mem.output.instance startsWith "redis"`, true},
+	}
+	for _, tt := range tests {
+		got, err := evaluator.Evaluate(context.Background(), tt.condition, env)
+		if err != nil {
+			t.Errorf("Evaluate(%q) returned error: %v", tt.condition, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tt.condition, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"steps.check_mem.output.instance", "used_memory_pct >"} {
+		if _, err := evaluator.Evaluate(context.Background(), bad, env); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestRenderArgs(t *testing.T) {
+	env := map[string]any{
+		"steps": map[string]any{
+			"find": map[string]any{"output": map[string]any{"instance": "redis-0", "keys": []any{"a", "b"}}},
+		},
+		"input": map[string]any{"namespace": "cache"},
+	}
+	args := map[string]any{
+		"instance": "{{ steps.find.output.instance }}",
+		"keys":     "{{steps.find.output.keys}}",
+		"target":   "{{ input.namespace }}/{{ steps.find.output.instance }}",
+		"nested":   map[string]any{"count": "{{ len(steps.find.output.keys) }}"},
+		"literal":  42,
+	}
+
+	rendered, err := RenderArgs(args, env)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rendered["instance"] != "redis-0" {
+		t.Errorf("expected instance redis-0, got %v", rendered["instance"])
+	}
+	if keys, ok := rendered["keys"].([]any); !ok || len(keys) != 2 {
+		t.Errorf("expected the keys slice to keep its type, got %#v", rendered["keys"])
+	}
+	if rendered["target"] != "cache/redis-0" {
+		t.Errorf("expected target cache/redis-0, got %v", rendered["target"])
+	}
+	if rendered["nested"].(map[string]any)["count"] != 2 {
+		t.Errorf("expected nested count 2, got %v", rendered["nested"])
+	}
+	if rendered["literal"] != 42 {
+		t.Errorf("expected literal to be unchanged, got %v", rendered["literal"])
+	}
+	if args["instance"] != "{{ steps.find.output.instance }}" {
+		t.Error("expected the step arguments not to be modified")
+	}
+
+	if _, err := RenderArgs(map[string]any{"x": "{{ steps.missing.output.y + }}"}, env); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+}
+
+func TestPlanEngine_ConditionBranches(t *testing.T) {
+	tests := []struct {
+		name     string
+		usage    float64
+		ran      []string
+		skipped  []string
+		released any
+	}{
+		{name: "then branch", usage: 95, ran: []string{"check_mem", "check", "free", "report"}, skipped: []string{"noop", "after_noop"}, released: "redis-0"},
+		{name: "else branch", usage: 40, ran: []string{"check_mem", "check", "noop", "after_noop"}, skipped: []string{"free", "report"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var released any
+			mockTools := NewMockToolRegistry()
+			mockTools.executeFunc = func(ctx context.Context, toolName string, args map[string]any) (any, error) {
+				mockTools.calls = append(mockTools.calls, toolName)
+				switch toolName {
+				case "check_mem":
+					return memoryStats{UsedMemoryPct: tt.usage, Instance: "redis-0"}, nil
+				case "free":
+					released = args["instance"]
+				}
+				return "ok", nil
+			}
+			engine := NewPlanEngine(NewDefaultStepExecutor(mockTools, nil), NewMemoryStateStore(), DefaultPlanEngineConfig())
+
+			plan := NewPlan("plan-branches", "Free memory", []Step{
+				{ID: "check_mem", Name: "Check memory", Type: StepTypeToolCall, Action: ActionSpec{ToolName: "check_mem"}},
+				{ID: "check", Name: "Memory high?", Type: StepTypeCondition, DependsOn: []string{"check_mem"}, Action: ActionSpec{
+					Condition: `steps.check_mem.output.used_memory_pct > 90 && input.env == "prod"`,
+					Then:      []string{"free"},
+					Else:      []string{"noop"},
+				}},
+				{ID: "free", Name: "Free memory", Type: StepTypeToolCall, DependsOn: []string{"check"},
+					Action: ActionSpec{ToolName: "free", ToolArgs: map[string]any{"instance": "{{ steps.check_mem.output.instance }}"}}},
+				{ID: "noop", Name: "Nothing to do", Type: StepTypeToolCall, DependsOn: []string{"check"}, Action: ActionSpec{ToolName: "noop"}},
+				{ID: "report", Name: "Report", Type: StepTypeToolCall, DependsOn: []string{"free"}, Action: ActionSpec{ToolName: "report"}},
+				{ID: "after_noop", Name: "After noop", Type: StepTypeToolCall, DependsOn: []string{"noop"}, Action: ActionSpec{ToolName: "after_noop"}},
+			})
+			plan.Inputs = map[string]any{"env": "prod"}
+
+			state, err := engine.ExecutePlan(context.Background(), plan)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if state.Status != PlanStatusCompleted {
+				t.Errorf("expected status Completed, got %s", state.Status)
+			}
+			for _, id := range tt.ran {
+				if s := state.StepStates[id]; s == nil || s.Status != StepStatusCompleted {
+					t.Errorf("expected step %s to be Completed, got %+v", id, s)
+				}
+			}
+			for _, id := range tt.skipped {
+				if s := state.StepStates[id]; s == nil || s.Status != StepStatusSkipped {
+					t.Errorf("expected step %s to be Skipped, got %+v", id, s)
+				}
+			}
+			if released != tt.released {
+				t.Errorf("expected free to be called for %v, got %v", tt.released, released)
+			}
+		})
+	}
+}
+
+func TestPlanEngine_ConditionWithoutBranchesGatesDependents(t *testing.T) {
+	mockTools := NewMockToolRegistry()
+	engine := NewPlanEngine(NewDefaultStepExecutor(mockTools, nil), NewMemoryStateStore(), DefaultPlanEngineConfig())
+
+	plan := NewPlan("plan-gate", "Gate", []Step{
+		{ID: "check", Name: "Production?", Type: StepTypeCondition, Action: ActionSpec{Condition: `input.env == "prod"`}},
+		{ID: "restart", Name: "Restart", Type: StepTypeToolCall, DependsOn: []string{"check"}, Action: ActionSpec{ToolName: "restart"}},
+	})
+	plan.Inputs = map[string]any{"env": "staging"}
+
+	state, err := engine.ExecutePlan(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.StepStates["restart"].Status != StepStatusSkipped {
+		t.Errorf("expected restart to be Skipped, got %s", state.StepStates["restart"].Status)
+	}
+	if len(mockTools.calls) != 0 {
+		t.Errorf("expected no tool calls, got %v", mockTools.calls)
+	}
+}
+
+func TestPlan_ValidateBranches(t *testing.T) {
+	plan := NewPlan("plan-invalid", "Invalid branches", []Step{
+		{ID: "check", Name: "Check", Type: StepTypeCondition, Action: ActionSpec{Condition: "true", Then: []string{"a", "missing"}}},
+		{ID: "a", Name: "A", Type: StepTypeToolCall, Action: ActionSpec{ToolName: "a"}},
+	})
+
+	err := plan.Validate()
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+	for _, want := range []string{"step a must depend on condition check", "non-existent step: missing"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
@@ -2,9 +2,12 @@ package planning
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
 )
 
 // PlanEngineConfig contains configuration for the plan engine
@@ -13,8 +16,26 @@ type PlanEngineConfig struct {
 	EnableReflection bool `yaml:"enable_reflection" json:"enable_reflection"`
 	EnableRollback   bool `yaml:"enable_rollback" json:"enable_rollback"`
 	DefaultTimeout   time.Duration `yaml:"default_timeout" json:"default_timeout"`
+	// RecoveryPolicy decides what RecoverInterrupted does with executions
+	// left running by a previous process
+	RecoveryPolicy RecoveryPolicy `yaml:"recovery_policy" json:"recovery_policy"`
 }
 
+// RecoveryPolicy is how executions interrupted by a restart are handled
+type RecoveryPolicy string
+
+const (
+	// RecoveryRollback fails the execution and rolls back its completed steps
+	RecoveryRollback RecoveryPolicy = "rollback"
+	// RecoveryResume runs the unfinished steps again
+	RecoveryResume RecoveryPolicy = "resume"
+	// RecoveryFail only marks the execution failed
+	RecoveryFail RecoveryPolicy = "fail"
+)
+
+// errInterrupted is recorded for steps that were running when the process died
+var errInterrupted = errors.New("execution interrupted by restart")
+
 // DefaultPlanEngineConfig returns default configuration
 func DefaultPlanEngineConfig() PlanEngineConfig {
 	return PlanEngineConfig{
@@ -22,6 +43,7 @@ func DefaultPlanEngineConfig() PlanEngineConfig {
 		EnableReflection: true,
 		EnableRollback:   true,
 		DefaultTimeout:   5 * time.Minute,
+		RecoveryPolicy:   RecoveryRollback,
 	}
 }
 
@@ -35,6 +57,7 @@ type PlanEngine struct {
 	parallelExecutor *ParallelExecutor
 	mu               sync.RWMutex
 	activePlans      map[string]context.CancelFunc
+	log              logger.Logger
 }
 
 // NewPlanEngine creates a new PlanEngine
@@ -45,6 +68,7 @@ func NewPlanEngine(executor StepExecutor, stateStore StateStore, cfg PlanEngineC
 		config:           cfg,
 		parallelExecutor: NewParallelExecutor(cfg.MaxParallel),
 		activePlans:      make(map[string]context.CancelFunc),
+		log:              logger.NewLogger("plan-engine"),
 	}
 
 	// Set plan engine reference in executor if it's DefaultStepExecutor
@@ -69,29 +93,57 @@ func (e *PlanEngine) ExecutePlan(ctx context.Context, plan *Plan) (*ExecutionSta
 		return nil, fmt.Errorf("plan validation failed: %w", err)
 	}
 
+	planCtx, release, err := e.activate(ctx, plan.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// Create execution state
 	state := NewExecutionState(plan.ID)
 	state.Status = PlanStatusRunning
 
-	// Store cancel function for this plan
-	planCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	e.mu.Lock()
-	e.activePlans[plan.ID] = cancel
-	e.mu.Unlock()
-
-	defer func() {
-		e.mu.Lock()
-		delete(e.activePlans, plan.ID)
-		e.mu.Unlock()
-	}()
+	// Durable stores keep the plan so the execution can be recovered
+	if durable, ok := e.stateStore.(DurableStateStore); ok {
+		if err := durable.SavePlan(plan); err != nil {
+			return nil, fmt.Errorf("failed to save plan: %w", err)
+		}
+	}
 
 	// Save initial state
 	if err := e.stateStore.Save(state); err != nil {
 		return nil, fmt.Errorf("failed to save initial state: %w", err)
 	}
+	e.record(state, "")
+
+	return e.run(planCtx, plan, state)
+}
+
+// activate registers planID as executing and returns the context its steps
+// run under together with the func that unregisters it. The check and the
+// registration share one lock so a plan never runs twice at once.
+func (e *PlanEngine) activate(ctx context.Context, planID string) (context.Context, func(), error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, active := e.activePlans[planID]; active {
+		return nil, nil, fmt.Errorf("plan %s is currently executing", planID)
+	}
 
+	planCtx, cancel := context.WithCancel(ctx)
+	e.activePlans[planID] = cancel
+
+	return planCtx, func() {
+		cancel()
+		e.mu.Lock()
+		delete(e.activePlans, planID)
+		e.mu.Unlock()
+	}, nil
+}
+
+// run executes the unfinished steps of plan and settles the final state.
+// The plan must have been activated with planCtx.
+func (e *PlanEngine) run(planCtx context.Context, plan *Plan, state *ExecutionState) (*ExecutionState, error) {
 	// Execute plan
 	if err := e.executePlanSteps(planCtx, plan, state); err != nil {
 		state.Status = PlanStatusFailed
@@ -101,13 +153,10 @@ func (e *PlanEngine) ExecutePlan(ctx context.Context, plan *Plan) (*ExecutionSta
 
 		// Rollback if enabled and there was a failure
 		if e.config.EnableRollback && state.HasFailedSteps() {
-			if rollbackErr := e.rollbackManager.Rollback(planCtx, plan, state); rollbackErr != nil {
-				state.Error = fmt.Sprintf("%s; rollback error: %v", state.Error, rollbackErr)
-			} else {
-				state.Status = PlanStatusRolledBack
-			}
+			e.rollback(planCtx, plan, state)
 		}
 
+		e.record(state, "")
 		e.stateStore.Save(state)
 		return state, err
 	}
@@ -116,6 +165,7 @@ func (e *PlanEngine) ExecutePlan(ctx context.Context, plan *Plan) (*ExecutionSta
 	state.Status = PlanStatusCompleted
 	now := time.Now()
 	state.CompletedAt = &now
+	e.record(state, "")
 
 	// Save final state
 	if err := e.stateStore.Save(state); err != nil {
@@ -126,13 +176,53 @@ func (e *PlanEngine) ExecutePlan(ctx context.Context, plan *Plan) (*ExecutionSta
 	if e.config.EnableReflection && e.reflection != nil {
 		if _, err := e.reflection.Evaluate(planCtx, plan, state); err != nil {
 			// Log but don't fail the execution
-			fmt.Printf("Reflection evaluation failed: %v\n", err)
+			e.log.Warnf("Reflection evaluation failed: %v", err)
 		}
 	}
 
 	return state, nil
 }
 
+// rollback rolls back the completed steps of a failed execution
+func (e *PlanEngine) rollback(ctx context.Context, plan *Plan, state *ExecutionState) {
+	if err := e.rollbackManager.Rollback(ctx, plan, state); err != nil {
+		state.Error = fmt.Sprintf("%s; rollback error: %v", state.Error, err)
+		return
+	}
+	state.Status = PlanStatusRolledBack
+	for _, step := range plan.Steps {
+		if s, ok := state.StepStates[step.ID]; ok && s.Status == StepStatusRolledBack {
+			e.record(state, step.ID)
+		}
+	}
+}
+
+// record appends the current status of a step, or of the plan when stepID is
+// empty, to the event log of durable state stores
+func (e *PlanEngine) record(state *ExecutionState, stepID string) {
+	durable, ok := e.stateStore.(DurableStateStore)
+	if !ok {
+		return
+	}
+
+	event := &ExecutionEvent{
+		PlanID:    state.PlanID,
+		Status:    string(state.Status),
+		Error:     state.Error,
+		Timestamp: time.Now(),
+	}
+	if stepID != "" {
+		s := state.GetStepState(stepID)
+		event.StepID = stepID
+		event.Status = string(s.Status)
+		event.Output = s.Output
+		event.Error = s.Error
+	}
+	if err := durable.AppendEvent(event); err != nil {
+		e.log.Warnf("Failed to record event: %v", err)
+	}
+}
+
 // executePlanSteps executes the steps of a plan according to DAG
 func (e *PlanEngine) executePlanSteps(ctx context.Context, plan *Plan, state *ExecutionState) error {
 	dag := NewDAG(plan.Steps)
@@ -146,29 +236,45 @@ func (e *PlanEngine) executePlanSteps(ctx context.Context, plan *Plan, state *Ex
 		default:
 		}
 
-		// Get steps for this group
-		steps := plan.GetStepsByIDs(group)
+		// Get steps for this group, skipping branches not taken
+		var steps []*Step
+		for _, step := range plan.GetStepsByIDs(group) {
+			// Steps finished before a resume are not run again
+			if s, ok := state.StepStates[step.ID]; ok && (s.Status == StepStatusCompleted || s.Status == StepStatusSkipped) {
+				continue
+			}
+			if reason := skipReason(plan, step, state); reason != "" {
+				state.MarkStepSkipped(step.ID)
+				state.GetStepState(step.ID).Error = reason
+				e.record(state, step.ID)
+				continue
+			}
+			steps = append(steps, step)
+		}
 
 		if len(steps) == 0 {
 			continue
 		}
 
+		// Steps see the plan inputs and the outputs of earlier groups
+		input := PlanEnv(plan, state)
+
 		// Execute group
 		if len(steps) == 1 {
 			// Serial execution for single step
-			if err := e.executeStep(ctx, steps[0], state, make(map[string]any)); err != nil {
+			if err := e.executeStep(ctx, steps[0], state, input); err != nil {
 				return err
 			}
 		} else {
 			// Parallel execution for multiple steps
-			if err := e.executeParallelGroup(ctx, steps, state); err != nil {
+			if err := e.executeParallelGroup(ctx, steps, state, input); err != nil {
 				return err
 			}
 		}
 
 		// Save state after each group
 		if err := e.stateStore.Save(state); err != nil {
-			fmt.Printf("Warning: failed to save state: %v\n", err)
+			e.log.Warnf("Failed to save state: %v", err)
 		}
 
 		// Check for failures
@@ -184,28 +290,32 @@ func (e *PlanEngine) executePlanSteps(ctx context.Context, plan *Plan, state *Ex
 func (e *PlanEngine) executeStep(ctx context.Context, step *Step, state *ExecutionState, input map[string]any) error {
 	// Mark step as started
 	state.MarkStepStarted(step.ID)
+	e.record(state, step.ID)
 
 	// Execute step
 	output, err := e.executor.Execute(ctx, step, input)
 	if err != nil {
 		state.MarkStepFailed(step.ID, err)
+		e.record(state, step.ID)
 		return fmt.Errorf("step %s failed: %w", step.ID, err)
 	}
 
 	// Mark step as completed
 	state.MarkStepCompleted(step.ID, output)
+	e.record(state, step.ID)
 	return nil
 }
 
 // executeParallelGroup executes a group of steps in parallel
-func (e *PlanEngine) executeParallelGroup(ctx context.Context, steps []*Step, state *ExecutionState) error {
+func (e *PlanEngine) executeParallelGroup(ctx context.Context, steps []*Step, state *ExecutionState, input map[string]any) error {
 	// Mark all steps as started
 	for _, step := range steps {
 		state.MarkStepStarted(step.ID)
+		e.record(state, step.ID)
 	}
 
 	// Execute in parallel
-	results := e.parallelExecutor.ExecuteParallel(ctx, steps, e.executor, make(map[string]any))
+	results := e.parallelExecutor.ExecuteParallel(ctx, steps, e.executor, input)
 
 	// Process results
 	var hasError bool
@@ -216,6 +326,7 @@ func (e *PlanEngine) executeParallelGroup(ctx context.Context, steps []*Step, st
 		} else {
 			state.MarkStepCompleted(stepID, result.Output)
 		}
+		e.record(state, stepID)
 	}
 
 	if hasError {
@@ -225,24 +336,179 @@ func (e *PlanEngine) executeParallelGroup(ctx context.Context, steps []*Step, st
 	return nil
 }
 
-// ResumePlan resumes execution of a paused or failed plan
+// ResumePlan resumes execution of an interrupted, paused or failed plan.
+// Completed and skipped steps are kept; steps that were running or failed run
+// again. The state store must be a DurableStateStore.
 func (e *PlanEngine) ResumePlan(ctx context.Context, planID string) (*ExecutionState, error) {
-	// Load existing state
-	state, err := e.stateStore.Load(planID)
+	planCtx, release, err := e.activate(ctx, planID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load plan state: %w", err)
+		return nil, err
+	}
+	defer release()
+
+	plan, state, err := e.loadExecution(planID)
+	if err != nil {
+		return nil, err
 	}
 
 	if state.Status == PlanStatusCompleted {
 		return state, fmt.Errorf("plan already completed")
 	}
 
-	// This is a simplified resume - in a full implementation, we would:
-	// 1. Load the original plan
-	// 2. Identify incomplete steps
-	// 3. Continue execution from where it stopped
-	// For now, return the state as-is
-	return state, fmt.Errorf("resume not fully implemented")
+	for _, s := range state.StepStates {
+		if s.Status == StepStatusRunning || s.Status == StepStatusFailed {
+			s.Status = StepStatusPending
+			s.Error = ""
+		}
+	}
+	state.Status = PlanStatusRunning
+	state.Error = ""
+	state.CompletedAt = nil
+
+	if err := e.stateStore.Save(state); err != nil {
+		return nil, fmt.Errorf("failed to save state: %w", err)
+	}
+	e.record(state, "")
+
+	return e.run(planCtx, plan, state)
+}
+
+// RecoverInterrupted handles executions a previous process left running,
+// typically because it crashed. Depending on the recovery policy they are
+// resumed, rolled back or only marked failed. It returns the resulting states.
+func (e *PlanEngine) RecoverInterrupted(ctx context.Context) ([]*ExecutionState, error) {
+	if _, ok := e.stateStore.(DurableStateStore); !ok {
+		return nil, nil
+	}
+
+	states, err := e.stateStore.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list executions: %w", err)
+	}
+
+	var recovered []*ExecutionState
+	for _, s := range states {
+		if s.Status != PlanStatusRunning || e.isActive(s.PlanID) {
+			continue
+		}
+
+		var state *ExecutionState
+		switch e.config.RecoveryPolicy {
+		case RecoveryResume:
+			state, err = e.ResumePlan(ctx, s.PlanID)
+		case RecoveryFail:
+			state, err = e.abandon(ctx, s.PlanID, false)
+		default:
+			state, err = e.abandon(ctx, s.PlanID, true)
+		}
+		if err != nil {
+			e.log.Warnf("Recovery of plan %s failed: %v", s.PlanID, err)
+		}
+		if state != nil {
+			recovered = append(recovered, state)
+		}
+	}
+	return recovered, nil
+}
+
+// abandon fails an interrupted execution, rolling back its completed steps
+// if requested
+func (e *PlanEngine) abandon(ctx context.Context, planID string, rollback bool) (*ExecutionState, error) {
+	plan, state, err := e.loadExecution(planID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, step := range plan.Steps {
+		if s, ok := state.StepStates[step.ID]; ok && s.Status == StepStatusRunning {
+			state.MarkStepFailed(step.ID, errInterrupted)
+			e.record(state, step.ID)
+		}
+	}
+	state.Status = PlanStatusFailed
+	state.Error = errInterrupted.Error()
+	now := time.Now()
+	state.CompletedAt = &now
+
+	if rollback {
+		e.rollback(ctx, plan, state)
+	}
+
+	e.record(state, "")
+	if err := e.stateStore.Save(state); err != nil {
+		return state, fmt.Errorf("failed to save state: %w", err)
+	}
+	return state, nil
+}
+
+// loadExecution loads a plan and its state. The state is rebuilt from the
+// event log, which also covers transitions after the last snapshot.
+func (e *PlanEngine) loadExecution(planID string) (*Plan, *ExecutionState, error) {
+	durable, ok := e.stateStore.(DurableStateStore)
+	if !ok {
+		return nil, nil, fmt.Errorf("state store does not keep plans")
+	}
+
+	plan, err := durable.LoadPlan(planID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load plan: %w", err)
+	}
+	events, err := durable.Events(planID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load events: %w", err)
+	}
+	if len(events) > 0 {
+		return plan, ReplayEvents(planID, events), nil
+	}
+
+	state, err := e.stateStore.Load(planID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load plan state: %w", err)
+	}
+	return plan, state, nil
+}
+
+func (e *PlanEngine) isActive(planID string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	_, active := e.activePlans[planID]
+	return active
+}
+
+// ReplayEvents rebuilds the state of an execution from its event log
+func ReplayEvents(planID string, events []*ExecutionEvent) *ExecutionState {
+	state := NewExecutionState(planID)
+	for i, ev := range events {
+		if i == 0 {
+			state.StartedAt = ev.Timestamp
+		}
+		at := ev.Timestamp
+		if ev.StepID == "" {
+			state.Status = PlanStatus(ev.Status)
+			state.Error = ev.Error
+			switch state.Status {
+			case PlanStatusRunning, PlanStatusPaused:
+				state.CompletedAt = nil
+			default:
+				state.CompletedAt = &at
+			}
+			continue
+		}
+
+		s := state.GetStepState(ev.StepID)
+		s.Status = StepStatus(ev.Status)
+		s.Error = ev.Error
+		switch s.Status {
+		case StepStatusRunning:
+			s.StartedAt = &at
+			s.CompletedAt = nil
+			s.Attempts++
+		case StepStatusCompleted, StepStatusFailed:
+			s.CompletedAt = &at
+			s.Output = ev.Output
+		}
+	}
+	return state
 }
 
 // GetState retrieves the execution state for a plan
@@ -250,6 +516,24 @@ func (e *PlanEngine) GetState(planID string) (*ExecutionState, error) {
 	return e.stateStore.Load(planID)
 }
 
+// GetPlan retrieves the plan of an execution from a durable state store
+func (e *PlanEngine) GetPlan(planID string) (*Plan, error) {
+	durable, ok := e.stateStore.(DurableStateStore)
+	if !ok {
+		return nil, fmt.Errorf("state store does not keep plans")
+	}
+	return durable.LoadPlan(planID)
+}
+
+// GetEvents retrieves the event log of an execution from a durable state store
+func (e *PlanEngine) GetEvents(planID string) ([]*ExecutionEvent, error) {
+	durable, ok := e.stateStore.(DurableStateStore)
+	if !ok {
+		return nil, fmt.Errorf("state store does not keep an event log")
+	}
+	return durable.Events(planID)
+}
+
 // CancelPlan cancels an active plan execution
 func (e *PlanEngine) CancelPlan(planID string) error {
 	e.mu.Lock()
@@ -273,6 +557,7 @@ func (e *PlanEngine) CancelPlan(planID string) error {
 	now := time.Now()
 	state.CompletedAt = &now
 	state.Error = "execution cancelled"
+	e.record(state, "")
 
 	return e.stateStore.Save(state)
 }
@@ -291,6 +576,7 @@ func (e *PlanEngine) PausePlan(planID string) error {
 	}
 
 	state.Status = PlanStatusPaused
+	e.record(state, "")
 	return e.stateStore.Save(state)
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -23,6 +24,21 @@ type LLMClient interface {
 	Complete(ctx context.Context, prompt string) (string, error)
 }
 
+// providerLLMClient adapts a provider client to LLMClient
+type providerLLMClient struct {
+	client interfaces.LLMClient
+}
+
+// NewLLMClient adapts any provider client (OpenAI, Ollama, replay) for use by
+// the step executor and reflection loop
+func NewLLMClient(client interfaces.LLMClient) LLMClient {
+	return &providerLLMClient{client: client}
+}
+
+func (p *providerLLMClient) Complete(ctx context.Context, prompt string) (string, error) {
+	return p.client.Complete(ctx, prompt)
+}
+
 // ConditionEvaluator is an interface for evaluating conditions
 type ConditionEvaluator interface {
 	Evaluate(ctx context.Context, condition string, context map[string]any) (bool, error)
@@ -41,7 +57,7 @@ func NewDefaultStepExecutor(tools ToolRegistry, llm LLMClient) *DefaultStepExecu
 	return &DefaultStepExecutor{
 		toolRegistry:       tools,
 		llmClient:          llm,
-		conditionEvaluator: &SimpleConditionEvaluator{},
+		conditionEvaluator: &ExprConditionEvaluator{},
 	}
 }
 
@@ -95,7 +111,11 @@ func (e *DefaultStepExecutor) executeOnce(ctx context.Context, step *Step, input
 		if e.toolRegistry == nil {
 			return nil, fmt.Errorf("tool registry not configured")
 		}
-		return e.toolRegistry.Execute(ctx, step.Action.ToolName, step.Action.ToolArgs)
+		args, err := RenderArgs(step.Action.ToolArgs, input)
+		if err != nil {
+			return nil, err
+		}
+		return e.toolRegistry.Execute(ctx, step.Action.ToolName, args)
 
 	case StepTypeLLMQuery:
 		if e.llmClient == nil {
@@ -3,8 +3,12 @@ package planning
 import (
 	"context"
 	"errors"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/core/llm"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/client"
 )
 
 // MockToolRegistry for testing
@@ -122,6 +126,38 @@ func TestDefaultStepExecutor_ExecuteLLMQuery(t *testing.T) {
 	}
 }
 
+func TestDefaultStepExecutor_ReplaysRecordedLLMQuery(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "plan.json")
+	step := &Step{
+		ID:     "step1",
+		Type:   StepTypeLLMQuery,
+		Action: ActionSpec{Prompt: "summarize the redis incident"},
+	}
+
+	upstream := llm.NewMockClient()
+	upstream.SetResponse("evictions caused by maxmemory")
+	recorder, err := client.NewReplayClient(cassette, client.ReplayModeRecord, upstream)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := NewDefaultStepExecutor(nil, NewLLMClient(recorder)).Execute(context.Background(), step, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Replay needs no upstream at all
+	player, err := client.NewReplayClient(cassette, client.ReplayModeReplay, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := NewDefaultStepExecutor(nil, NewLLMClient(player)).Execute(context.Background(), step, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "evictions caused by maxmemory" {
+		t.Errorf("expected the recorded response, got %v", result)
+	}
+}
+
 func TestDefaultStepExecutor_ExecuteCondition(t *testing.T) {
 	executor := NewDefaultStepExecutor(nil, nil)
 
@@ -0,0 +1,367 @@
+package planning
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/parser"
+	"github.com/kubestack-ai/kubestack-ai/internal/tools"
+)
+
+// DefaultMaxRepairs bounds how often an invalid plan is sent back to the LLM
+const DefaultMaxRepairs = 2
+
+// PlanGenerator turns a natural language goal into a validated plan. The LLM
+// is asked for a JSON document matching PlanSchema; plans that fail
+// validation are sent back together with the errors until they pass or the
+// repair budget is spent.
+type PlanGenerator struct {
+	llm        LLMClient
+	registry   tools.Registry
+	parser     *parser.StructuredOutputParser
+	maxRepairs int
+	inputs     map[string]any
+}
+
+// NewPlanGenerator creates a generator whose ToolCall steps are restricted to
+// the tools in registry. A nil registry allows no tool calls.
+func NewPlanGenerator(llm LLMClient, registry tools.Registry) *PlanGenerator {
+	return &PlanGenerator{
+		llm:        llm,
+		registry:   registry,
+		parser:     parser.NewStructuredOutputParser(),
+		maxRepairs: DefaultMaxRepairs,
+	}
+}
+
+// SetMaxRepairs sets how many repair prompts follow an invalid plan
+func (g *PlanGenerator) SetMaxRepairs(n int) {
+	if n < 0 {
+		n = 0
+	}
+	g.maxRepairs = n
+}
+
+// SetInputs sets the inputs of generated plans. Their names and values are
+// shown to the LLM so conditions and tool arguments can refer to them.
+func (g *PlanGenerator) SetInputs(inputs map[string]any) {
+	g.inputs = inputs
+}
+
+// generatedPlan is the document the LLM produces. Timeouts are in seconds,
+// as models handle those far better than Go durations.
+type generatedPlan struct {
+	Name  string          `json:"name" validate:"required"`
+	Steps []generatedStep `json:"steps" validate:"required,min=1,dive"`
+}
+
+type generatedStep struct {
+	ID             string       `json:"id" validate:"required"`
+	Name           string       `json:"name" validate:"required"`
+	Type           StepType     `json:"type" validate:"required,oneof=ToolCall LLMQuery Condition"`
+	DependsOn      []string     `json:"depends_on"`
+	Action         ActionSpec   `json:"action"`
+	Rollback       *ActionSpec  `json:"rollback,omitempty"`
+	TimeoutSeconds int          `json:"timeout_seconds,omitempty" validate:"gte=0"`
+	RetryPolicy    *RetryPolicy `json:"retry_policy,omitempty"`
+}
+
+// PlanValidationError lists what is wrong with a generated plan
+type PlanValidationError struct {
+	Problems []string
+}
+
+func (e *PlanValidationError) Error() string {
+	return "invalid plan: " + strings.Join(e.Problems, "; ")
+}
+
+// Generate asks the LLM for a plan reaching goal
+func (g *PlanGenerator) Generate(ctx context.Context, goal string) (*Plan, error) {
+	if g.llm == nil {
+		return nil, fmt.Errorf("LLM client not initialized")
+	}
+
+	available := g.availableTools()
+	prompt := g.planPrompt(goal, available)
+	var lastErr error
+	for attempt := 0; attempt <= g.maxRepairs; attempt++ {
+		response, err := g.llm.Complete(ctx, prompt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate plan: %w", err)
+		}
+
+		plan, err := g.parsePlan(goal, response, available)
+		if err == nil {
+			plan.Metadata["attempts"] = fmt.Sprintf("%d", attempt+1)
+			return plan, nil
+		}
+		lastErr = err
+		prompt = g.repairPrompt(goal, response, err, available)
+	}
+
+	return nil, fmt.Errorf("no valid plan after %d attempts: %w", g.maxRepairs+1, lastErr)
+}
+
+// parsePlan parses and validates one LLM response
+func (g *PlanGenerator) parsePlan(goal, response string, available map[string]*tools.Tool) (*Plan, error) {
+	var doc generatedPlan
+	if err := g.parser.ParseInto(response, &doc); err != nil {
+		return nil, &PlanValidationError{Problems: []string{err.Error()}}
+	}
+
+	steps := make([]Step, 0, len(doc.Steps))
+	var problems []string
+	for _, s := range doc.Steps {
+		problems = append(problems, validateStepAction(s, available)...)
+		steps = append(steps, Step{
+			ID:          s.ID,
+			Name:        s.Name,
+			Type:        s.Type,
+			DependsOn:   s.DependsOn,
+			Action:      s.Action,
+			Rollback:    s.Rollback,
+			Timeout:     time.Duration(s.TimeoutSeconds) * time.Second,
+			RetryPolicy: s.RetryPolicy,
+		})
+	}
+
+	plan := NewPlan("plan-"+uuid.New().String(), doc.Name, steps)
+	plan.Description = goal
+	plan.Inputs = g.inputs
+	plan.Metadata["source"] = "llm"
+	// Validate covers duplicate IDs, dangling dependencies and cycles
+	if err := plan.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) > 0 {
+		return nil, &PlanValidationError{Problems: problems}
+	}
+	return plan, nil
+}
+
+// validateStepAction checks that the action matches the step type, and that
+// tool calls name a registered tool with arguments fitting its schema
+func validateStepAction(s generatedStep, available map[string]*tools.Tool) []string {
+	switch s.Type {
+	case StepTypeLLMQuery:
+		if s.Action.Prompt == "" {
+			return []string{fmt.Sprintf("step %s: LLMQuery steps need action.prompt", s.ID)}
+		}
+	case StepTypeCondition:
+		if s.Action.Condition == "" {
+			return []string{fmt.Sprintf("step %s: Condition steps need action.condition", s.ID)}
+		}
+	case StepTypeToolCall:
+		tool, ok := available[s.Action.ToolName]
+		if !ok {
+			return []string{fmt.Sprintf("step %s: unknown tool %q, use one of the listed tools", s.ID, s.Action.ToolName)}
+		}
+		var problems []string
+		for _, p := range checkToolArgs(tool.Schema, s.Action.ToolArgs) {
+			problems = append(problems, fmt.Sprintf("step %s: tool %s: %s", s.ID, tool.Name, p))
+		}
+		return problems
+	}
+	return nil
+}
+
+// toolArgsSchema is the subset of JSON Schema checked for tool arguments
+type toolArgsSchema struct {
+	Properties map[string]struct {
+		Type string `json:"type"`
+	} `json:"properties"`
+	Required             []string `json:"required"`
+	AdditionalProperties *bool    `json:"additionalProperties"`
+}
+
+// checkToolArgs checks args against the required properties, property types
+// and additionalProperties of a tool's JSON schema
+func checkToolArgs(schema json.RawMessage, args map[string]any) []string {
+	if len(schema) == 0 {
+		return nil
+	}
+	var s toolArgsSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil
+	}
+
+	var problems []string
+	for _, name := range s.Required {
+		if _, ok := args[name]; !ok {
+			problems = append(problems, fmt.Sprintf("missing required argument %q", name))
+		}
+	}
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				problems = append(problems, fmt.Sprintf("unknown argument %q", name))
+			}
+			continue
+		}
+		// Templated values are only known once earlier steps have run
+		if str, ok := args[name].(string); ok && templatePattern.MatchString(str) {
+			continue
+		}
+		if prop.Type != "" && !jsonTypeMatches(prop.Type, args[name]) {
+			problems = append(problems, fmt.Sprintf("argument %q must be of type %s", name, prop.Type))
+		}
+	}
+	return problems
+}
+
+func jsonTypeMatches(jsonType string, v any) bool {
+	switch jsonType {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == float64(int64(f))
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	default:
+		return true
+	}
+}
+
+func (g *PlanGenerator) availableTools() map[string]*tools.Tool {
+	available := make(map[string]*tools.Tool)
+	if g.registry != nil {
+		for _, t := range g.registry.List() {
+			available[t.Name] = t
+		}
+	}
+	return available
+}
+
+// PlanSchema returns the JSON schema of the plan document the LLM must
+// produce, with tool names restricted to toolNames
+func PlanSchema(toolNames []string) json.RawMessage {
+	toolName := map[string]any{"type": "string"}
+	if len(toolNames) > 0 {
+		toolName["enum"] = toolNames
+	}
+	action := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"tool_name": toolName,
+			"tool_args": map[string]any{"type": "object"},
+			"prompt":    map[string]any{"type": "string"},
+			"condition": map[string]any{"type": "string"},
+			"then":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"else":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	}
+	schema := map[string]any{
+		"type":     "object",
+		"required": []string{"name", "steps"},
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string"},
+			"steps": map[string]any{
+				"type":     "array",
+				"minItems": 1,
+				"items": map[string]any{
+					"type":     "object",
+					"required": []string{"id", "name", "type", "action"},
+					"properties": map[string]any{
+						"id":              map[string]any{"type": "string"},
+						"name":            map[string]any{"type": "string"},
+						"type":            map[string]any{"type": "string", "enum": []StepType{StepTypeToolCall, StepTypeLLMQuery, StepTypeCondition}},
+						"depends_on":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+						"action":          action,
+						"rollback":        action,
+						"timeout_seconds": map[string]any{"type": "integer", "minimum": 0},
+						"retry_policy": map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"max_retries": map[string]any{"type": "integer", "minimum": 0},
+								"backoff_ms":  map[string]any{"type": "integer", "minimum": 0},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	data, _ := json.MarshalIndent(schema, "", "  ")
+	return data
+}
+
+func (g *PlanGenerator) planPrompt(goal string, available map[string]*tools.Tool) string {
+	names := make([]string, 0, len(available))
+	for name := range available {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("You are an SRE planning a remediation runbook for middleware infrastructure.\n")
+	fmt.Fprintf(&b, "Goal: %s\n\n", goal)
+	b.WriteString("Break the goal into steps. Steps without a dependency between them run in parallel.\n")
+	b.WriteString("Step types:\n")
+	b.WriteString("- ToolCall: call one of the tools below with action.tool_name and action.tool_args\n")
+	b.WriteString("- LLMQuery: ask a language model, with action.prompt\n")
+	b.WriteString("- Condition: evaluate the boolean expression action.condition, then run the steps in\n")
+	b.WriteString("  action.then if it holds and those in action.else otherwise; both must depend on the condition\n\n")
+	b.WriteString("Conditions and {{ expression }} placeholders in tool_args can use the plan inputs as input.<name>\n")
+	b.WriteString("and earlier results as steps.<id>.output, e.g. steps.check_mem.output.used_memory_pct > 90 && input.env == \"prod\".\n\n")
+	if len(g.inputs) > 0 {
+		inputs, _ := json.Marshal(g.inputs)
+		fmt.Fprintf(&b, "Plan inputs: %s\n\n", inputs)
+	}
+	if len(names) == 0 {
+		b.WriteString("No tools are available; do not use ToolCall steps.\n\n")
+	} else {
+		b.WriteString("Available tools (name, description, argument schema):\n")
+		for _, name := range names {
+			t := available[name]
+			schema := string(t.Schema)
+			if schema == "" {
+				schema = "{}"
+			}
+			fmt.Fprintf(&b, "- %s: %s\n  %s\n", t.Name, t.Description, schema)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("Respond with a single JSON object, and nothing else, matching this schema:\n")
+	b.Write(PlanSchema(names))
+	return b.String()
+}
+
+func (g *PlanGenerator) repairPrompt(goal, response string, err error, available map[string]*tools.Tool) string {
+	var b strings.Builder
+	b.WriteString(g.planPrompt(goal, available))
+	b.WriteString("\n\nYour previous plan was rejected:\n")
+	b.WriteString(response)
+	b.WriteString("\n\nProblems:\n")
+	if verr, ok := err.(*PlanValidationError); ok {
+		for _, p := range verr.Problems {
+			fmt.Fprintf(&b, "- %s\n", p)
+		}
+	} else {
+		fmt.Fprintf(&b, "- %s\n", err)
+	}
+	b.WriteString("Fix every problem and respond with the corrected JSON object only.")
+	return b.String()
+}
@@ -0,0 +1,129 @@
+package planning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/tools"
+)
+
+// scriptedLLMClient answers prompts with the given responses in order
+type scriptedLLMClient struct {
+	responses []string
+	prompts   []string
+}
+
+func (c *scriptedLLMClient) Complete(ctx context.Context, prompt string) (string, error) {
+	c.prompts = append(c.prompts, prompt)
+	if len(c.prompts) > len(c.responses) {
+		return "", errors.New("no more responses")
+	}
+	return c.responses[len(c.prompts)-1], nil
+}
+
+func newGeneratorRegistry(t *testing.T) tools.Registry {
+	registry := tools.NewRegistry()
+	err := registry.Register(&tools.Tool{
+		Name:        "diagnose",
+		Description: "Run a diagnosis of a middleware instance",
+		Schema: json.RawMessage(`{
+			"type": "object",
+			"properties": {"middleware": {"type": "string"}, "instance": {"type": "string"}},
+			"required": ["middleware", "instance"],
+			"additionalProperties": false
+		}`),
+	})
+	if err != nil {
+		t.Fatalf("failed to register tool: %v", err)
+	}
+	return registry
+}
+
+const validGeneratedPlan = "```json\n" + `{
+	"name": "Investigate redis memory",
+	"steps": [
+		{"id": "diag", "name": "Diagnose", "type": "ToolCall",
+		 "action": {"tool_name": "diagnose", "tool_args": {"middleware": "redis", "instance": "redis-0"}},
+		 "timeout_seconds": 60},
+		{"id": "explain", "name": "Explain", "type": "LLMQuery", "depends_on": ["diag"],
+		 "action": {"prompt": "Summarize the diagnosis"}},
+		{"id": "check", "name": "Check", "type": "Condition", "depends_on": ["diag"],
+		 "action": {"condition": "true"}}
+	]
+}` + "\n```"
+
+func TestPlanGenerator_Generate(t *testing.T) {
+	llm := &scriptedLLMClient{responses: []string{validGeneratedPlan}}
+	gen := NewPlanGenerator(llm, newGeneratorRegistry(t))
+
+	plan, err := gen.Generate(context.Background(), "find out why redis-0 uses so much memory")
+	if err != nil {
+		t.Fatalf("expected a valid plan, got error: %v", err)
+	}
+	if plan.Name != "Investigate redis memory" || len(plan.Steps) != 3 {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	if plan.Steps[0].Timeout.Seconds() != 60 {
+		t.Errorf("expected 60s timeout, got %v", plan.Steps[0].Timeout)
+	}
+	groups := NewDAG(plan.Steps).GetParallelGroups()
+	if len(groups) != 2 || len(groups[1]) != 2 {
+		t.Errorf("expected explain and check to run in parallel, got %v", groups)
+	}
+	if !strings.Contains(llm.prompts[0], `"diagnose"`) {
+		t.Error("expected the prompt to publish the tool names in the schema")
+	}
+}
+
+func TestPlanGenerator_RepairsInvalidPlan(t *testing.T) {
+	invalid := `{
+		"name": "Broken",
+		"steps": [
+			{"id": "a", "name": "A", "type": "ToolCall", "depends_on": ["b"],
+			 "action": {"tool_name": "flushall", "tool_args": {}}},
+			{"id": "b", "name": "B", "type": "ToolCall", "depends_on": ["a"],
+			 "action": {"tool_name": "diagnose", "tool_args": {"instance": 3, "force": true}}}
+		]
+	}`
+	llm := &scriptedLLMClient{responses: []string{invalid, validGeneratedPlan}}
+	gen := NewPlanGenerator(llm, newGeneratorRegistry(t))
+
+	plan, err := gen.Generate(context.Background(), "check redis")
+	if err != nil {
+		t.Fatalf("expected the repaired plan, got error: %v", err)
+	}
+	if plan.Metadata["attempts"] != "2" {
+		t.Errorf("expected 2 attempts, got %s", plan.Metadata["attempts"])
+	}
+
+	repair := llm.prompts[1]
+	for _, problem := range []string{
+		`unknown tool "flushall"`,
+		`missing required argument "middleware"`,
+		`argument "instance" must be of type string`,
+		`unknown argument "force"`,
+		"cyclic dependencies",
+	} {
+		if !strings.Contains(repair, problem) {
+			t.Errorf("expected repair prompt to mention %q", problem)
+		}
+	}
+}
+
+func TestPlanGenerator_GivesUpAfterMaxRepairs(t *testing.T) {
+	llm := &scriptedLLMClient{responses: []string{"not json", "still not json", "nope"}}
+	gen := NewPlanGenerator(llm, nil)
+	gen.SetMaxRepairs(1)
+
+	_, err := gen.Generate(context.Background(), "check redis")
+	var verr *PlanValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected a PlanValidationError, got %v", err)
+	}
+	if len(llm.prompts) != 2 {
+		t.Errorf("expected 2 LLM calls, got %d", len(llm.prompts))
+	}
+}
@@ -46,6 +46,24 @@ func (p *Plan) Validate() error {
 		}
 	}
 
+	// Check that branch targets exist and wait for their condition
+	for _, step := range p.Steps {
+		for _, target := range append(append([]string{}, step.Action.Then...), step.Action.Else...) {
+			if step.Type != StepTypeCondition {
+				errors = append(errors, fmt.Errorf("step %s is not a Condition but has branch %s", step.ID, target))
+				continue
+			}
+			branch, ok := p.GetStep(target)
+			if !ok {
+				errors = append(errors, fmt.Errorf("condition %s branches to non-existent step: %s", step.ID, target))
+				continue
+			}
+			if !containsID(branch.DependsOn, step.ID) {
+				errors = append(errors, fmt.Errorf("step %s must depend on condition %s to be one of its branches", target, step.ID))
+			}
+		}
+	}
+
 	// Check for cyclic dependencies using DAG
 	dag := NewDAG(p.Steps)
 	if dag.DetectCycle() {
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
 )
 
 // ReflectionResult represents the result of reflection evaluation
@@ -34,7 +36,7 @@ func (r *ReflectionLoop) Evaluate(ctx context.Context, plan *Plan, state *Execut
 	}
 
 	prompt := r.buildReflectionPrompt(plan, state)
-	response, err := r.llmClient.Complete(ctx, prompt)
+	response, err := r.llmClient.Complete(interfaces.WithFeature(ctx, "plan_reflection"), prompt)
 	if err != nil {
 		// Fallback to basic evaluation
 		return r.basicEvaluation(plan, state), nil
@@ -195,7 +197,7 @@ func (r *ReflectionLoop) GenerateImprovementPlan(ctx context.Context, plan *Plan
 
 	sb.WriteString("\nProvide concrete improvement suggestions for the plan:\n")
 
-	response, err := r.llmClient.Complete(ctx, sb.String())
+	response, err := r.llmClient.Complete(interfaces.WithFeature(ctx, "plan_reflection"), sb.String())
 	if err != nil {
 		return r.basicImprovementSuggestion(result), nil
 	}
@@ -0,0 +1,143 @@
+package planning
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/knowledge/search"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/chain"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/client"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/parser"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/prompt"
+)
+
+const sessionCassette = "testdata/cassettes/redis_memory_session.json"
+
+const sessionQuestion = "redis-0 in namespace cache is evicting keys and clients report OOM command not allowed errors"
+
+const sessionTemplate = `Diagnose the following middleware incident.
+Question: {{.Question}}
+{{range .RetrievedDocuments}}Reference: {{.Content}}
+{{end}}Respond with a JSON object with the fields root_cause, severity, confidence,
+contributing_factors, affected_components, evidence and next_steps.`
+
+// runbookRetriever serves the same knowledge base article for every query
+type runbookRetriever struct{}
+
+func (runbookRetriever) Retrieve(ctx context.Context, query string, topK int) ([]search.Document, error) {
+	return []search.Document{{
+		Content: "Redis evicts keys once used_memory reaches maxmemory; with noeviction it rejects writes with OOM errors instead.",
+		Score:   0.92,
+	}}, nil
+}
+
+func (r runbookRetriever) HybridRetrieve(ctx context.Context, query string, opts *search.RetrieveOptions) ([]search.Document, error) {
+	return r.Retrieve(ctx, query, opts.TopK)
+}
+
+// failureRecorder remembers the errors of an LLMClient, as the reflection
+// loop hides them behind its basic evaluation
+type failureRecorder struct {
+	llm LLMClient
+
+	mu     sync.Mutex
+	calls  int
+	errors []error
+}
+
+func (f *failureRecorder) Complete(ctx context.Context, prompt string) (string, error) {
+	out, err := f.llm.Complete(ctx, prompt)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if err != nil {
+		f.errors = append(f.errors, err)
+	}
+	return out, err
+}
+
+// recordedSession is what one diagnosis-to-remediation session produced
+type recordedSession struct {
+	diagnosis *parser.DiagnosisResult
+	plan      *Plan
+	state     *ExecutionState
+	llm       *failureRecorder
+}
+
+// runSession diagnoses the incident with the diagnosis chain, has the
+// generator plan a remediation for the root cause and runs the plan with
+// reflection, all against llm
+func runSession(t *testing.T, llm interfaces.LLMClient) *recordedSession {
+	t.Helper()
+	ctx := context.Background()
+
+	tmpl, err := prompt.NewGoTemplate("incident", sessionTemplate)
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+	diagnosis, err := chain.NewDiagnosisChain(runbookRetriever{}, llm, tmpl, parser.NewStructuredOutputParser(), nil).Execute(ctx, sessionQuestion)
+	if err != nil {
+		t.Fatalf("diagnosis chain failed: %v", err)
+	}
+
+	recorder := &failureRecorder{llm: NewLLMClient(llm)}
+	plan, err := NewPlanGenerator(recorder, nil).Generate(ctx, "Remediate redis-0 in namespace cache: "+diagnosis.RootCause)
+	if err != nil {
+		t.Fatalf("plan generation failed: %v", err)
+	}
+
+	engine := NewPlanEngine(NewDefaultStepExecutor(nil, recorder), NewMemoryStateStore(), DefaultPlanEngineConfig())
+	engine.SetReflectionLoop(NewReflectionLoop(recorder))
+	state, err := engine.ExecutePlan(ctx, plan)
+	if err != nil {
+		t.Fatalf("plan execution failed: %v", err)
+	}
+	return &recordedSession{diagnosis: diagnosis, plan: plan, state: state, llm: recorder}
+}
+
+// TestReplaySession_DiagnosisToPlan replays a recorded session end to end,
+// from the diagnosis chain through plan generation, execution and
+// reflection, so a change to any of their prompts or to how a response is
+// handled shows up as a cassette miss or a different result. Re-record with
+// the "replay" provider in "record" mode.
+func TestReplaySession_DiagnosisToPlan(t *testing.T) {
+	replay, err := client.NewReplayClient(sessionCassette, client.ReplayModeReplay, nil)
+	if err != nil {
+		t.Fatalf("failed to load cassette: %v", err)
+	}
+
+	session := runSession(t, replay)
+
+	if session.diagnosis.Severity != "high" || !strings.Contains(session.diagnosis.RootCause, "maxmemory") {
+		t.Errorf("unexpected diagnosis: %+v", session.diagnosis)
+	}
+
+	if session.plan.Name != "Raise redis-0 maxmemory" || len(session.plan.Steps) != 2 {
+		t.Fatalf("unexpected plan: %+v", session.plan)
+	}
+	if session.plan.Metadata["attempts"] != "1" {
+		t.Errorf("expected the recorded plan to be valid first time, took %s attempts", session.plan.Metadata["attempts"])
+	}
+
+	if session.state.Status != PlanStatusCompleted {
+		t.Fatalf("expected plan to complete, got %s: %s", session.state.Status, session.state.Error)
+	}
+	size := session.state.StepStates["size"]
+	if size == nil || size.Output != "Set maxmemory to 1536mb: peak used_memory is 1.2gb and the pod limit is 2gi." {
+		t.Errorf("unexpected output of step size: %+v", size)
+	}
+	if runbook := session.state.StepStates["runbook"]; runbook == nil || runbook.Status != StepStatusCompleted {
+		t.Errorf("expected step runbook to complete: %+v", runbook)
+	}
+
+	// Plan generation, both steps and the reflection all came from the cassette
+	if session.llm.calls != 4 || len(session.llm.errors) != 0 {
+		t.Errorf("expected 4 replayed completions, got %d with errors %v", session.llm.calls, session.llm.errors)
+	}
+	if replay.Interactions() != 5 {
+		t.Errorf("expected the session to use all 5 recorded interactions, cassette has %d", replay.Interactions())
+	}
+}
@@ -4,17 +4,21 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
 )
 
 // RollbackManager manages rollback operations
 type RollbackManager struct {
 	executor StepExecutor
+	log      logger.Logger
 }
 
 // NewRollbackManager creates a new RollbackManager
 func NewRollbackManager(executor StepExecutor) *RollbackManager {
 	return &RollbackManager{
 		executor: executor,
+		log:      logger.NewLogger("plan-rollback"),
 	}
 }
 
@@ -41,7 +45,7 @@ func (r *RollbackManager) Rollback(ctx context.Context, plan *Plan, state *Execu
 		step := stepsToRollback[i]
 		if err := r.RollbackStep(ctx, step); err != nil {
 			// Log error but continue with other rollbacks
-			fmt.Printf("Warning: failed to rollback step %s: %v\n", step.ID, err)
+			r.log.Warnf("Failed to rollback step %s: %v", step.ID, err)
 			// Store the error in state
 			if stepState, exists := state.StepStates[step.ID]; exists {
 				stepState.Error = fmt.Sprintf("rollback failed: %v", err)
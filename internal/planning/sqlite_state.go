@@ -0,0 +1,243 @@
+package planning
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteStateStore is a DurableStateStore backed by SQLite. The latest state
+// of each execution is kept as a snapshot next to its plan, and every
+// transition is appended to an event log that is never rewritten.
+type SQLiteStateStore struct {
+	db *sql.DB
+	mu sync.RWMutex
+}
+
+// NewSQLiteStateStore opens or creates the plan state database at path
+func NewSQLiteStateStore(path string) (*SQLiteStateStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+	CREATE TABLE IF NOT EXISTS plan_executions (
+		plan_id TEXT PRIMARY KEY,
+		status TEXT,
+		plan TEXT,
+		state TEXT,
+		started_at DATETIME,
+		updated_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_plan_executions_status ON plan_executions(status);
+	CREATE TABLE IF NOT EXISTS plan_events (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT,
+		plan_id TEXT NOT NULL,
+		step_id TEXT,
+		status TEXT NOT NULL,
+		output TEXT,
+		error TEXT,
+		timestamp DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_plan_events_plan_id ON plan_events(plan_id, seq);
+	`
+	if _, err := db.Exec(query); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to init plan state db: %w", err)
+	}
+
+	return &SQLiteStateStore{db: db}, nil
+}
+
+// Save upserts the state snapshot of an execution
+func (s *SQLiteStateStore) Save(state *ExecutionState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.db.Exec(`INSERT INTO plan_executions (plan_id, status, state, started_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(plan_id) DO UPDATE SET status = excluded.status, state = excluded.state,
+			started_at = excluded.started_at, updated_at = excluded.updated_at`,
+		state.PlanID, string(state.Status), string(data), state.StartedAt, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// Load returns the latest state snapshot of an execution
+func (s *SQLiteStateStore) Load(planID string) (*ExecutionState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var data sql.NullString
+	err := s.db.QueryRow(`SELECT state FROM plan_executions WHERE plan_id = ?`, planID).Scan(&data)
+	if err == sql.ErrNoRows || (err == nil && !data.Valid) {
+		return nil, fmt.Errorf("state not found for plan: %s", planID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	var state ExecutionState
+	if err := json.Unmarshal([]byte(data.String), &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+	return &state, nil
+}
+
+// Delete removes an execution together with its plan and event log
+func (s *SQLiteStateStore) Delete(planID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM plan_executions WHERE plan_id = ?`, planID)
+	if err != nil {
+		return fmt.Errorf("failed to delete state: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("state not found for plan: %s", planID)
+	}
+	if _, err := tx.Exec(`DELETE FROM plan_events WHERE plan_id = ?`, planID); err != nil {
+		return fmt.Errorf("failed to delete events: %w", err)
+	}
+	return tx.Commit()
+}
+
+// List returns the state of every execution, most recently started first
+func (s *SQLiteStateStore) List() ([]*ExecutionState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`SELECT state FROM plan_executions WHERE state IS NOT NULL ORDER BY started_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list states: %w", err)
+	}
+	defer rows.Close()
+
+	var states []*ExecutionState
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var state ExecutionState
+		if err := json.Unmarshal([]byte(data), &state); err != nil {
+			continue
+		}
+		states = append(states, &state)
+	}
+	return states, rows.Err()
+}
+
+// SavePlan stores the plan an execution runs
+func (s *SQLiteStateStore) SavePlan(plan *Plan) error {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.db.Exec(`INSERT INTO plan_executions (plan_id, plan, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(plan_id) DO UPDATE SET plan = excluded.plan, updated_at = excluded.updated_at`,
+		plan.ID, string(data), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+	return nil
+}
+
+// LoadPlan returns the plan stored for an execution
+func (s *SQLiteStateStore) LoadPlan(planID string) (*Plan, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var data sql.NullString
+	err := s.db.QueryRow(`SELECT plan FROM plan_executions WHERE plan_id = ?`, planID).Scan(&data)
+	if err == sql.ErrNoRows || (err == nil && !data.Valid) {
+		return nil, fmt.Errorf("plan not found: %s", planID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plan: %w", err)
+	}
+
+	var plan Plan
+	if err := json.Unmarshal([]byte(data.String), &plan); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal plan: %w", err)
+	}
+	return &plan, nil
+}
+
+// AppendEvent appends an event to the log and sets its sequence number
+func (s *SQLiteStateStore) AppendEvent(event *ExecutionEvent) error {
+	var output sql.NullString
+	if event.Output != nil {
+		if data, err := json.Marshal(event.Output); err == nil {
+			output = sql.NullString{String: string(data), Valid: true}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`INSERT INTO plan_events (plan_id, step_id, status, output, error, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		event.PlanID, event.StepID, event.Status, output, event.Error, event.Timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+	event.Seq, _ = res.LastInsertId()
+	return nil
+}
+
+// Events returns the log of an execution in order
+func (s *SQLiteStateStore) Events(planID string) ([]*ExecutionEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`SELECT seq, plan_id, step_id, status, output, error, timestamp
+		FROM plan_events WHERE plan_id = ? ORDER BY seq`, planID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*ExecutionEvent
+	for rows.Next() {
+		var e ExecutionEvent
+		var stepID, output, errMsg sql.NullString
+		if err := rows.Scan(&e.Seq, &e.PlanID, &stepID, &e.Status, &output, &errMsg, &e.Timestamp); err != nil {
+			return nil, err
+		}
+		e.StepID = stepID.String
+		e.Error = errMsg.String
+		if output.Valid {
+			_ = json.Unmarshal([]byte(output.String), &e.Output)
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+// Close closes the database
+func (s *SQLiteStateStore) Close() error {
+	return s.db.Close()
+}
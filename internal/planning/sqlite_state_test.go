@@ -0,0 +1,240 @@
+package planning
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestSQLiteStateStore(t *testing.T) *SQLiteStateStore {
+	t.Helper()
+	store, err := NewSQLiteStateStore(filepath.Join(t.TempDir(), "plans.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSQLiteStateStore_ExecutionHistory(t *testing.T) {
+	store := newTestSQLiteStateStore(t)
+	mockTools := NewMockToolRegistry()
+	mockTools.results["check_tool"] = map[string]any{"used_memory_pct": 42.0}
+	engine := NewPlanEngine(NewDefaultStepExecutor(mockTools, nil), store, DefaultPlanEngineConfig())
+
+	plan := NewPlan("plan-history", "History", []Step{
+		{ID: "check", Name: "Check", Type: StepTypeToolCall, Action: ActionSpec{ToolName: "check_tool"}},
+		{ID: "report", Name: "Report", Type: StepTypeToolCall, DependsOn: []string{"check"}, Action: ActionSpec{ToolName: "report_tool"}},
+	})
+	if _, err := engine.ExecutePlan(context.Background(), plan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	state, err := store.Load(plan.ID)
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if state.Status != PlanStatusCompleted {
+		t.Errorf("expected status Completed, got %s", state.Status)
+	}
+	stored, err := store.LoadPlan(plan.ID)
+	if err != nil || len(stored.Steps) != 2 {
+		t.Fatalf("expected the plan to be stored, got %+v, %v", stored, err)
+	}
+
+	events, err := store.Events(plan.ID)
+	if err != nil {
+		t.Fatalf("failed to load events: %v", err)
+	}
+	var got []string
+	for _, ev := range events {
+		got = append(got, ev.StepID+":"+ev.Status)
+	}
+	want := []string{":Running", "check:Running", "check:Completed", "report:Running", "report:Completed", ":Completed"}
+	if len(got) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+	if out, ok := events[2].Output.(map[string]any); !ok || out["used_memory_pct"] != 42.0 {
+		t.Errorf("expected the step output in the event, got %#v", events[2].Output)
+	}
+
+	replayed := ReplayEvents(plan.ID, events)
+	if replayed.Status != PlanStatusCompleted || replayed.StepStates["report"].Status != StepStatusCompleted {
+		t.Errorf("expected the replayed state to match, got %+v", replayed)
+	}
+
+	if err := store.Delete(plan.ID); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if events, _ := store.Events(plan.ID); len(events) != 0 {
+		t.Errorf("expected the events to be deleted, got %d", len(events))
+	}
+}
+
+// interruptedExecution leaves the store as a process dying while step2 runs
+// would: step1 completed, step2 started, the plan still running
+func interruptedExecution(t *testing.T, store DurableStateStore) *Plan {
+	t.Helper()
+	plan := NewPlan("plan-crash", "Crash", []Step{
+		{ID: "step1", Name: "Step 1", Type: StepTypeToolCall, Action: ActionSpec{ToolName: "step1_tool"},
+			Rollback: &ActionSpec{ToolName: "undo_step1"}},
+		{ID: "step2", Name: "Step 2", Type: StepTypeToolCall, DependsOn: []string{"step1"}, Action: ActionSpec{ToolName: "step2_tool"}},
+		{ID: "step3", Name: "Step 3", Type: StepTypeToolCall, DependsOn: []string{"step2"}, Action: ActionSpec{ToolName: "step3_tool"}},
+	})
+	if err := store.SavePlan(plan); err != nil {
+		t.Fatalf("failed to save plan: %v", err)
+	}
+
+	state := NewExecutionState(plan.ID)
+	state.Status = PlanStatusRunning
+	if err := store.Save(state); err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+	now := time.Now()
+	for _, ev := range []*ExecutionEvent{
+		{PlanID: plan.ID, Status: string(PlanStatusRunning)},
+		{PlanID: plan.ID, StepID: "step1", Status: string(StepStatusRunning)},
+		{PlanID: plan.ID, StepID: "step1", Status: string(StepStatusCompleted), Output: "done"},
+		{PlanID: plan.ID, StepID: "step2", Status: string(StepStatusRunning)},
+	} {
+		ev.Timestamp = now
+		if err := store.AppendEvent(ev); err != nil {
+			t.Fatalf("failed to append event: %v", err)
+		}
+	}
+	return plan
+}
+
+func TestPlanEngine_RecoverInterrupted_Resume(t *testing.T) {
+	store := newTestSQLiteStateStore(t)
+	plan := interruptedExecution(t, store)
+
+	mockTools := NewMockToolRegistry()
+	config := DefaultPlanEngineConfig()
+	config.RecoveryPolicy = RecoveryResume
+	engine := NewPlanEngine(NewDefaultStepExecutor(mockTools, nil), store, config)
+
+	recovered, err := engine.RecoverInterrupted(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recovered) != 1 || recovered[0].Status != PlanStatusCompleted {
+		t.Fatalf("expected the plan to complete, got %+v", recovered)
+	}
+	if len(mockTools.calls) != 2 || mockTools.calls[0] != "step2_tool" || mockTools.calls[1] != "step3_tool" {
+		t.Errorf("expected only step2 and step3 to run, got %v", mockTools.calls)
+	}
+	if attempts := recovered[0].StepStates["step2"].Attempts; attempts != 2 {
+		t.Errorf("expected step2 to count 2 attempts, got %d", attempts)
+	}
+
+	state, err := store.Load(plan.ID)
+	if err != nil || state.Status != PlanStatusCompleted {
+		t.Errorf("expected the stored state to be Completed, got %+v, %v", state, err)
+	}
+
+	// Nothing is left to recover
+	if again, _ := engine.RecoverInterrupted(context.Background()); len(again) != 0 {
+		t.Errorf("expected no interrupted executions, got %d", len(again))
+	}
+}
+
+func TestPlanEngine_RecoverInterrupted_Rollback(t *testing.T) {
+	store := NewMemoryStateStore()
+	plan := interruptedExecution(t, store)
+
+	mockTools := NewMockToolRegistry()
+	engine := NewPlanEngine(NewDefaultStepExecutor(mockTools, nil), store, DefaultPlanEngineConfig())
+
+	recovered, err := engine.RecoverInterrupted(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recovered) != 1 {
+		t.Fatalf("expected one recovered execution, got %d", len(recovered))
+	}
+	state := recovered[0]
+	if state.Status != PlanStatusRolledBack {
+		t.Errorf("expected status RolledBack, got %s", state.Status)
+	}
+	if state.StepStates["step1"].Status != StepStatusRolledBack {
+		t.Errorf("expected step1 to be rolled back, got %s", state.StepStates["step1"].Status)
+	}
+	if s := state.StepStates["step2"]; s.Status != StepStatusFailed || s.Error != errInterrupted.Error() {
+		t.Errorf("expected step2 to fail as interrupted, got %+v", s)
+	}
+	if len(mockTools.calls) != 1 || mockTools.calls[0] != "undo_step1" {
+		t.Errorf("expected only the rollback of step1 to run, got %v", mockTools.calls)
+	}
+
+	events, _ := store.Events(plan.ID)
+	if last := events[len(events)-1]; last.StepID != "" || last.Status != string(PlanStatusRolledBack) {
+		t.Errorf("expected the log to end with the rollback, got %+v", last)
+	}
+}
+
+// gatedExecutor blocks every step until release is closed and counts the
+// steps started
+type gatedExecutor struct {
+	started atomic.Int32
+	release chan struct{}
+}
+
+func (g *gatedExecutor) Execute(ctx context.Context, step *Step, input map[string]any) (any, error) {
+	g.started.Add(1)
+	select {
+	case <-g.release:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestPlanEngine_ResumePlan_RunsOnce(t *testing.T) {
+	store := NewMemoryStateStore()
+	plan := interruptedExecution(t, store)
+
+	executor := &gatedExecutor{release: make(chan struct{})}
+	engine := NewPlanEngine(executor, store, DefaultPlanEngineConfig())
+
+	const callers = 5
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			_, err := engine.ResumePlan(context.Background(), plan.ID)
+			errs <- err
+		}()
+	}
+
+	// All but one caller find the plan executing and return at once
+	for i := 0; i < callers-1; i++ {
+		select {
+		case err := <-errs:
+			if err == nil {
+				t.Fatalf("expected a concurrent resume to be rejected")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected %d resumes to be rejected, got %d", callers-1, i)
+		}
+	}
+
+	close(executor.release)
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("resumed plan did not finish")
+	}
+	if started := executor.started.Load(); started != 2 {
+		t.Errorf("expected step2 and step3 to run once, got %d steps", started)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 )
 
 // StateStore is the interface for persisting execution states
@@ -14,9 +15,34 @@ type StateStore interface {
 	List() ([]*ExecutionState, error)
 }
 
+// ExecutionEvent is one entry of the append-only log of an execution: a
+// transition of the plan (StepID empty) or of one of its steps
+type ExecutionEvent struct {
+	Seq       int64     `json:"seq"`
+	PlanID    string    `json:"plan_id"`
+	StepID    string    `json:"step_id,omitempty"`
+	Status    string    `json:"status"`
+	Output    any       `json:"output,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// DurableStateStore is a StateStore that also keeps the plans being executed
+// and a log of every transition, which is what resuming or rolling back an
+// execution after a restart needs
+type DurableStateStore interface {
+	StateStore
+	SavePlan(plan *Plan) error
+	LoadPlan(planID string) (*Plan, error)
+	AppendEvent(event *ExecutionEvent) error
+	Events(planID string) ([]*ExecutionEvent, error)
+}
+
 // MemoryStateStore is an in-memory implementation of StateStore
 type MemoryStateStore struct {
 	states map[string]*ExecutionState
+	plans  map[string]*Plan
+	events map[string][]*ExecutionEvent
 	mu     sync.RWMutex
 }
 
@@ -24,6 +50,8 @@ type MemoryStateStore struct {
 func NewMemoryStateStore() *MemoryStateStore {
 	return &MemoryStateStore{
 		states: make(map[string]*ExecutionState),
+		plans:  make(map[string]*Plan),
+		events: make(map[string][]*ExecutionEvent),
 	}
 }
 
@@ -81,6 +109,8 @@ func (s *MemoryStateStore) Delete(planID string) error {
 	}
 
 	delete(s.states, planID)
+	delete(s.plans, planID)
+	delete(s.events, planID)
 	return nil
 }
 
@@ -106,6 +136,57 @@ func (s *MemoryStateStore) List() ([]*ExecutionState, error) {
 	return states, nil
 }
 
+// SavePlan stores the plan an execution runs
+func (s *MemoryStateStore) SavePlan(plan *Plan) error {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	var copied Plan
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return fmt.Errorf("failed to unmarshal plan: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.plans[plan.ID] = &copied
+	return nil
+}
+
+// LoadPlan returns the plan stored for an execution
+func (s *MemoryStateStore) LoadPlan(planID string) (*Plan, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	plan, exists := s.plans[planID]
+	if !exists {
+		return nil, fmt.Errorf("plan not found: %s", planID)
+	}
+	copied := *plan
+	copied.Steps = append([]Step(nil), plan.Steps...)
+	return &copied, nil
+}
+
+// AppendEvent appends an event to the log of its execution
+func (s *MemoryStateStore) AppendEvent(event *ExecutionEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *event
+	copied.Seq = int64(len(s.events[event.PlanID]) + 1)
+	s.events[event.PlanID] = append(s.events[event.PlanID], &copied)
+	event.Seq = copied.Seq
+	return nil
+}
+
+// Events returns the log of an execution in order
+func (s *MemoryStateStore) Events(planID string) ([]*ExecutionEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]*ExecutionEvent(nil), s.events[planID]...), nil
+}
+
 // PersistentStateStore is a persistent implementation using memory.Store
 type PersistentStateStore struct {
 	store interface{} // memory.Store interface
@@ -38,6 +38,10 @@ type ActionSpec struct {
 	ToolArgs  map[string]any `json:"tool_args,omitempty"`
 	Prompt    string         `json:"prompt,omitempty"`
 	Condition string         `json:"condition,omitempty"`
+	// Then and Else list the steps run when a Condition holds or not. They
+	// must depend on the condition step; the other branch is skipped.
+	Then []string `json:"then,omitempty"`
+	Else []string `json:"else,omitempty"`
 }
 
 // Step represents a single execution step in a plan
@@ -59,6 +63,7 @@ type Plan struct {
 	Name        string            `json:"name"`
 	Description string            `json:"description"`
 	Steps       []Step            `json:"steps"`
+	Inputs      map[string]any    `json:"inputs,omitempty"`
 	CreatedAt   time.Time         `json:"created_at"`
 	Metadata    map[string]string `json:"metadata,omitempty"`
 }
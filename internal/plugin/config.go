@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // PluginManagerConfig contains configuration for the plugin manager
@@ -21,13 +22,18 @@ type BuiltinConfig struct {
 	Enabled  bool                   `yaml:"enabled" json:"enabled"`
 	Priority int                    `yaml:"priority" json:"priority"`
 	Settings map[string]interface{} `yaml:"settings" json:"settings"`
+	// Process runs the plugin in a child process under the sandbox limits
+	Process bool `yaml:"process" json:"process"`
 }
 
 // SandboxConfig contains sandbox configuration
 type SandboxConfig struct {
-	Enabled     bool   `yaml:"enabled" json:"enabled"`
-	Timeout     string `yaml:"timeout" json:"timeout"`
-	MemoryLimit string `yaml:"memory_limit" json:"memory_limit"`
+	Enabled     bool    `yaml:"enabled" json:"enabled"`
+	Timeout     string  `yaml:"timeout" json:"timeout"`
+	MemoryLimit string  `yaml:"memory_limit" json:"memory_limit"`
+	CPULimit    float64 `yaml:"cpu_limit" json:"cpu_limit"`
+	// CgroupParent caps memory and CPU of out-of-process plugins via cgroup v2
+	CgroupParent string `yaml:"cgroup_parent" json:"cgroup_parent"`
 }
 
 // LoadPluginManagerConfig loads plugin manager configuration from a file
@@ -65,12 +71,22 @@ func (sc *SandboxConfig) ToSandboxOptions() SandboxOptions {
 	}
 	
 	memLimit := int64(256 * 1024 * 1024) // 256MB default
-	// Parse MemoryLimit if needed (e.g., "256Mi")
+	if sc.MemoryLimit != "" {
+		if q, err := resource.ParseQuantity(sc.MemoryLimit); err == nil {
+			memLimit = q.Value()
+		}
+	}
+	
+	cpuLimit := 1.0
+	if sc.CPULimit > 0 {
+		cpuLimit = sc.CPULimit
+	}
 	
 	return SandboxOptions{
-		Timeout:     timeout,
-		MemoryLimit: memLimit,
-		CPULimit:    1.0,
+		Timeout:      timeout,
+		MemoryLimit:  memLimit,
+		CPULimit:     cpuLimit,
+		CgroupParent: sc.CgroupParent,
 		AllowedOperations: []string{
 			"diagnose", "get-metrics", "health-check",
 			"get-slow-logs", "get-client-list", "get-config",
@@ -8,14 +8,53 @@ import (
 	"time"
 )
 
+// HealthPolicy controls how running plugins are health checked and restarted
+type HealthPolicy struct {
+	// Interval between health checks
+	Interval time.Duration
+	// FailureThreshold is the number of consecutive failed checks that triggers a restart
+	FailureThreshold int
+	// MaxRestarts bounds the restarts of a plugin after it was started; 0 disables restarts
+	MaxRestarts int
+	// RestartBackoff is the delay before the first restart, doubled for each further one
+	RestartBackoff time.Duration
+	// MaxBackoff caps the restart delay
+	MaxBackoff time.Duration
+}
+
+// DefaultHealthPolicy returns the health policy of in-process plugins: they
+// are checked every 30 seconds and marked as errored, never restarted, since
+// restarting them in place cannot recover a wedged goroutine
+func DefaultHealthPolicy() HealthPolicy {
+	return HealthPolicy{
+		Interval:         30 * time.Second,
+		FailureThreshold: 1,
+	}
+}
+
+// DefaultProcessHealthPolicy returns the health policy of out-of-process
+// plugins, whose crashed or killed child is restarted with backoff
+func DefaultProcessHealthPolicy() HealthPolicy {
+	return HealthPolicy{
+		Interval:         30 * time.Second,
+		FailureThreshold: 1,
+		MaxRestarts:      3,
+		RestartBackoff:   time.Second,
+		MaxBackoff:       time.Minute,
+	}
+}
+
 // LifecycleManager manages plugin lifecycle operations
 type LifecycleManager struct {
-	registry   *EnhancedRegistry
-	loader     *Loader
-	hooks      []PluginHooks
-	shutdownCh chan struct{}
-	wg         sync.WaitGroup
-	mu         sync.Mutex
+	registry      *EnhancedRegistry
+	loader        *Loader
+	hooks         []PluginHooks
+	policy        HealthPolicy
+	processPolicy HealthPolicy
+	restarts      map[string]int
+	shutdownCh    chan struct{}
+	wg            sync.WaitGroup
+	mu            sync.Mutex
 }
 
 // NewLifecycleManager creates a new lifecycle manager
@@ -24,8 +63,45 @@ func NewLifecycleManager(registry *EnhancedRegistry, loader *Loader) *LifecycleM
 		registry:   registry,
 		loader:     loader,
 		hooks:      make([]PluginHooks, 0),
-		shutdownCh: make(chan struct{}),
+		policy:        DefaultHealthPolicy(),
+		processPolicy: DefaultProcessHealthPolicy(),
+		restarts:      make(map[string]int),
+		shutdownCh:    make(chan struct{}),
+	}
+}
+
+// SetHealthPolicy sets the health policy used for in-process plugins started
+// afterwards
+func (m *LifecycleManager) SetHealthPolicy(policy HealthPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policy = normalizeHealthPolicy(policy)
+}
+
+// SetProcessHealthPolicy sets the health policy used for out-of-process
+// plugins started afterwards
+func (m *LifecycleManager) SetProcessHealthPolicy(policy HealthPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.processPolicy = normalizeHealthPolicy(policy)
+}
+
+// normalizeHealthPolicy fills in the interval and failure threshold
+func normalizeHealthPolicy(policy HealthPolicy) HealthPolicy {
+	if policy.Interval <= 0 {
+		policy.Interval = DefaultHealthPolicy().Interval
+	}
+	if policy.FailureThreshold <= 0 {
+		policy.FailureThreshold = 1
 	}
+	return policy
+}
+
+// RestartCount returns how many times a plugin was restarted by the health policy
+func (m *LifecycleManager) RestartCount(id string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.restarts[id]
 }
 
 // AddHooks adds lifecycle hooks
@@ -83,8 +159,16 @@ func (m *LifecycleManager) StartPlugin(ctx context.Context, id string) error {
 	}
 	
 	// Start health check goroutine
+	m.mu.Lock()
+	policy := m.policy
+	if _, ok := plugin.(*ProcessPlugin); ok {
+		policy = m.processPolicy
+	}
+	m.restarts[id] = 0
+	m.mu.Unlock()
+
 	m.wg.Add(1)
-	go m.healthCheckLoop(ctx, id, 30*time.Second)
+	go m.healthCheckLoop(ctx, id, policy)
 	
 	return nil
 }
@@ -178,13 +262,15 @@ func (m *LifecycleManager) StopAll(ctx context.Context) error {
 	return nil
 }
 
-// healthCheckLoop periodically checks plugin health
-func (m *LifecycleManager) healthCheckLoop(ctx context.Context, id string, interval time.Duration) {
+// healthCheckLoop periodically checks plugin health and restarts plugins
+// that keep failing, as allowed by the health policy
+func (m *LifecycleManager) healthCheckLoop(ctx context.Context, id string, policy HealthPolicy) {
 	defer m.wg.Done()
 	
-	ticker := time.NewTicker(interval)
+	ticker := time.NewTicker(policy.Interval)
 	defer ticker.Stop()
 	
+	failures := 0
 	for {
 		select {
 		case <-m.shutdownCh:
@@ -192,6 +278,10 @@ func (m *LifecycleManager) healthCheckLoop(ctx context.Context, id string, inter
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			if m.registry.GetState(id) == StateStopped {
+				return
+			}
+			
 			plugin, err := m.registry.Get(id)
 			if err != nil {
 				log.Printf("Plugin %s not found in registry", id)
@@ -199,12 +289,87 @@ func (m *LifecycleManager) healthCheckLoop(ctx context.Context, id string, inter
 			}
 			
 			if err := plugin.HealthCheck(ctx); err != nil {
+				failures++
 				log.Printf("Health check failed for plugin %s: %v", id, err)
 				m.registry.SetState(id, StateError)
 				m.triggerOnError(plugin, err)
+				
+				if failures < policy.FailureThreshold {
+					continue
+				}
+				
+				m.mu.Lock()
+				restarts := m.restarts[id]
+				m.mu.Unlock()
+				if restarts >= policy.MaxRestarts {
+					continue
+				}
+				
+				if !m.sleep(ctx, restartBackoff(policy, restarts)) {
+					return
+				}
+				
+				m.mu.Lock()
+				m.restarts[id]++
+				m.mu.Unlock()
+				
+				if err := m.restartPlugin(ctx, id, plugin); err != nil {
+					log.Printf("Failed to restart plugin %s: %v", id, err)
+					continue
+				}
+				failures = 0
+				continue
 			}
+			failures = 0
+		}
+	}
+}
+
+// restartPlugin stops and starts a plugin in place
+func (m *LifecycleManager) restartPlugin(ctx context.Context, id string, plugin Plugin) error {
+	log.Printf("Restarting plugin %s", id)
+	
+	if err := plugin.Stop(ctx); err != nil {
+		log.Printf("Stop before restart failed for plugin %s: %v", id, err)
+	}
+	
+	if err := plugin.Start(ctx); err != nil {
+		m.registry.SetState(id, StateError)
+		m.triggerOnError(plugin, err)
+		return fmt.Errorf("start failed: %w", err)
+	}
+	
+	return m.registry.SetState(id, StateRunning)
+}
+
+// sleep waits for d unless the manager shuts down first
+func (m *LifecycleManager) sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	
+	select {
+	case <-timer.C:
+		return true
+	case <-m.shutdownCh:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// restartBackoff returns the delay before the given restart attempt
+func restartBackoff(policy HealthPolicy, attempt int) time.Duration {
+	backoff := policy.RestartBackoff
+	for i := 0; i < attempt && backoff > 0; i++ {
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff >= policy.MaxBackoff {
+			return policy.MaxBackoff
 		}
 	}
+	return backoff
 }
 
 // triggerOnLoad triggers OnLoad hooks
@@ -258,6 +258,19 @@ func (l *Loader) LoadBuiltin(ctx context.Context, id string, config PluginConfig
 	return plugin, nil
 }
 
+// LoadProcess loads a plugin that runs command in a child process under the
+// sandbox timeout, memory and CPU limits. The child is started by the
+// LifecycleManager like any other plugin.
+func (l *Loader) LoadProcess(ctx context.Context, id string, sandbox *Sandbox, command string, args []string, metrics *PluginMetrics) (Plugin, error) {
+	plugin := sandbox.NewProcessPlugin(id, command, args, metrics)
+	
+	l.mu.Lock()
+	l.loaded[id] = plugin
+	l.mu.Unlock()
+	
+	return plugin, nil
+}
+
 // Get retrieves a loaded plugin by ID
 func (l *Loader) Get(id string) (Plugin, bool) {
 	l.mu.RLock()
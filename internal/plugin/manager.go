@@ -3,33 +3,60 @@ package plugin
 import (
 	"context"
 	"fmt"
+	"os"
 	"sync"
+
+	"go.uber.org/zap"
 )
 
 // Manager is a centralized plugin management system
 type Manager struct {
+	config    *PluginManagerConfig
 	loader    *Loader
 	registry  *EnhancedRegistry
 	lifecycle *LifecycleManager
 	sandbox   *Sandbox
+	metrics   *PluginMetrics
 	mu        sync.RWMutex
+
+	// serveCommand returns the command line of a child serving a plugin type
+	serveCommand func(MiddlewareType) (string, []string, error)
 }
 
-// NewManager creates a new plugin manager
+// NewManager creates a new plugin manager with the default configuration
 func NewManager() *Manager {
-	loader := NewLoader("")
+	return NewManagerWithConfig(DefaultPluginManagerConfig())
+}
+
+// NewManagerWithConfig creates a plugin manager whose sandbox limits come from
+// the configuration. Builtins configured with process: true run out of process.
+func NewManagerWithConfig(config *PluginManagerConfig) *Manager {
+	loader := NewLoaderWithDir("", config.PluginDirectory)
 	registry := NewEnhancedRegistry()
 	lifecycle := NewLifecycleManager(registry, loader)
-	sandbox := DefaultSandbox()
+	sandbox := NewSandbox(config.Sandbox.ToSandboxOptions())
 	
 	return &Manager{
-		loader:    loader,
-		registry:  registry,
-		lifecycle: lifecycle,
-		sandbox:   sandbox,
+		config:       config,
+		loader:       loader,
+		registry:     registry,
+		lifecycle:    lifecycle,
+		sandbox:      sandbox,
+		metrics:      NewPluginMetrics(zap.NewNop()),
+		serveCommand: selfServeCommand,
 	}
 }
 
+// selfServeCommand serves a plugin type with "ksa plugin serve <type>" from
+// the running binary
+func selfServeCommand(mwType MiddlewareType) (string, []string, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to locate ksa binary: %w", err)
+	}
+	return self, []string{"plugin", "serve", string(mwType)}, nil
+}
+
 // RegisterBuiltinPlugin registers a builtin plugin factory
 func (m *Manager) RegisterBuiltinPlugin(id string, factory PluginFactory) {
 	m.loader.RegisterBuiltin(id, factory)
@@ -38,7 +65,13 @@ func (m *Manager) RegisterBuiltinPlugin(id string, factory PluginFactory) {
 // LoadPlugin loads a plugin by ID
 func (m *Manager) LoadPlugin(ctx context.Context, id string, config PluginConfig) error {
 	// Load the plugin
-	plugin, err := m.loader.LoadBuiltin(ctx, id, config)
+	var plugin Plugin
+	var err error
+	if m.config.Builtin[id].Process {
+		plugin, err = m.loadProcess(ctx, id, config)
+	} else {
+		plugin, err = m.loader.LoadBuiltin(ctx, id, config)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to load plugin: %w", err)
 	}
@@ -60,6 +93,22 @@ func (m *Manager) LoadPlugin(ctx context.Context, id string, config PluginConfig
 	return nil
 }
 
+// loadProcess loads a builtin that runs in a sandboxed child process
+func (m *Manager) loadProcess(ctx context.Context, id string, config PluginConfig) (Plugin, error) {
+	if !m.config.Sandbox.Enabled {
+		return nil, fmt.Errorf("plugin %s is configured to run out of process but the sandbox is disabled", id)
+	}
+	if config.Type == "" {
+		return nil, fmt.Errorf("plugin %s needs a middleware type to run out of process", id)
+	}
+	
+	command, args, err := m.serveCommand(config.Type)
+	if err != nil {
+		return nil, err
+	}
+	return m.loader.LoadProcess(ctx, id, m.sandbox, command, args, m.metrics)
+}
+
 // GetPlugin retrieves a plugin by ID
 func (m *Manager) GetPlugin(id string) (Plugin, error) {
 	return m.registry.Get(id)
@@ -85,6 +134,11 @@ func (m *Manager) GetSandbox() *Sandbox {
 	return m.sandbox
 }
 
+// GetMetrics returns the call and crash metrics of out-of-process plugins
+func (m *Manager) GetMetrics() *PluginMetrics {
+	return m.metrics
+}
+
 // Shutdown shuts down all plugins
 func (m *Manager) Shutdown(ctx context.Context) error {
 	return m.lifecycle.StopAll(ctx)
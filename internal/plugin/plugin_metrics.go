@@ -1,6 +1,7 @@
 package plugin
 
 import (
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -11,11 +12,20 @@ import (
 // but the structure is ready for it.
 type PluginMetrics struct {
 	logger *zap.Logger
+
+	mu          sync.Mutex
+	crashes     map[string][]CrashReport
+	crashCounts map[string]int
 }
 
+// maxCrashReports is the number of recent crash reports kept per plugin
+const maxCrashReports = 20
+
 func NewPluginMetrics(logger *zap.Logger) *PluginMetrics {
 	return &PluginMetrics{
-		logger: logger,
+		logger:      logger,
+		crashes:     make(map[string][]CrashReport),
+		crashCounts: make(map[string]int),
 	}
 }
 
@@ -39,3 +49,41 @@ func (m *PluginMetrics) TrackActiveRequest(pluginName string) func() {
 		// Here we would decrement active request gauge
 	}
 }
+
+// RecordCrash records an unexpected exit of an out-of-process plugin.
+func (m *PluginMetrics) RecordCrash(report CrashReport) {
+	m.logger.Error("plugin process crashed",
+		zap.String("plugin", report.Plugin),
+		zap.Int("pid", report.PID),
+		zap.String("reason", string(report.Reason)),
+		zap.String("method", report.Method),
+		zap.Int("exit_code", report.ExitCode),
+		zap.String("signal", report.Signal),
+		zap.Duration("uptime", report.Uptime),
+		zap.String("stderr", report.Stderr),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.crashCounts[report.Plugin]++
+	reports := append(m.crashes[report.Plugin], report)
+	if len(reports) > maxCrashReports {
+		reports = reports[len(reports)-maxCrashReports:]
+	}
+	m.crashes[report.Plugin] = reports
+	// Here we would increment the crash counter labelled by reason
+}
+
+// CrashCount returns the total number of crashes recorded for a plugin.
+func (m *PluginMetrics) CrashCount(pluginName string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.crashCounts[pluginName]
+}
+
+// RecentCrashes returns the most recent crash reports for a plugin, oldest first.
+func (m *PluginMetrics) RecentCrashes(pluginName string) []CrashReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]CrashReport(nil), m.crashes[pluginName]...)
+}
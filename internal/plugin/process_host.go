@@ -0,0 +1,714 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrPluginNotRunning = errors.New("plugin process is not running")
+	ErrPluginCrashed    = errors.New("plugin process exited")
+)
+
+const (
+	defaultStartTimeout  = 10 * time.Second
+	defaultHealthTimeout = 10 * time.Second
+	stopGracePeriod      = 5 * time.Second
+	stderrHeadSize       = 2 * 1024
+	stderrTailSize       = 6 * 1024
+)
+
+// ResourceLimits caps the resources of an out-of-process plugin
+type ResourceLimits struct {
+	// MemoryBytes caps the child's memory (cgroup memory.max, or RLIMIT_DATA
+	// set before exec without a cgroup)
+	MemoryBytes int64
+	// CPUCores caps CPU time as a number of cores (cgroup cpu.max). It is only
+	// enforced when CgroupParent is set.
+	CPUCores float64
+	// CgroupParent is a delegated cgroup v2 directory with the memory and cpu
+	// controllers enabled. Each child gets its own group below it.
+	CgroupParent string
+}
+
+// ProcessOptions configures an out-of-process plugin
+type ProcessOptions struct {
+	ID      string
+	Command string
+	Args    []string
+	Env     []string
+	Limits  ResourceLimits
+
+	// Timeout bounds every call; a child that does not answer in time is killed
+	Timeout      time.Duration
+	StartTimeout time.Duration
+	Metrics      *PluginMetrics
+}
+
+// CrashReason classifies why a plugin process died
+type CrashReason string
+
+const (
+	CrashReasonExit    CrashReason = "exit"
+	CrashReasonSignal  CrashReason = "signal"
+	CrashReasonTimeout CrashReason = "timeout"
+	CrashReasonOOM     CrashReason = "oom"
+)
+
+// CrashReport describes an unexpected exit of a plugin process
+type CrashReport struct {
+	Plugin   string        `json:"plugin"`
+	PID      int           `json:"pid"`
+	Reason   CrashReason   `json:"reason"`
+	Method   string        `json:"method,omitempty"`
+	ExitCode int           `json:"exit_code"`
+	Signal   string        `json:"signal,omitempty"`
+	Stderr   string        `json:"stderr,omitempty"`
+	Uptime   time.Duration `json:"uptime"`
+	Time     time.Time     `json:"time"`
+}
+
+// ProcessPlugin runs a MiddlewarePlugin in a child process and proxies the
+// MiddlewarePlugin interface to it. It also implements Plugin so the
+// LifecycleManager can start, health check and restart the child.
+type ProcessPlugin struct {
+	opts    ProcessOptions
+	metrics *PluginMetrics
+	log     logger.Logger
+
+	mu         sync.Mutex
+	proc       *pluginProcess
+	info       HandshakeResult
+	connConfig *ConnectionConfig
+	connected  atomic.Bool
+	nextID     int64
+}
+
+// pluginProcess is one incarnation of the child process
+type pluginProcess struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	limits    *processLimits
+	stderr    *stderrBuffer
+	codec     *protocol.Codec
+	startedAt time.Time
+	writeMu   sync.Mutex
+
+	mu       sync.Mutex
+	pending  map[int64]chan *protocol.Response
+	killed   CrashReason
+	method   string
+	stopping bool
+
+	done    chan struct{}
+	exitErr error
+}
+
+// NewProcessPlugin creates an out-of-process plugin. The child is not started
+// until Start is called.
+func NewProcessPlugin(opts ProcessOptions) *ProcessPlugin {
+	if opts.ID == "" {
+		opts.ID = filepath.Base(opts.Command)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+	if opts.StartTimeout <= 0 {
+		opts.StartTimeout = defaultStartTimeout
+	}
+	metrics := opts.Metrics
+	if metrics == nil {
+		metrics = NewPluginMetrics(zap.NewNop())
+	}
+	return &ProcessPlugin{
+		opts:    opts,
+		metrics: metrics,
+		log:     logger.NewLogger("ProcessPlugin"),
+	}
+}
+
+// === Plugin ===
+
+// Info returns plugin metadata
+func (p *ProcessPlugin) Info() EnhancedPluginInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	name := p.info.Name
+	if name == "" {
+		name = p.opts.ID
+	}
+	caps := make([]string, 0, len(p.info.Commands))
+	for _, c := range p.info.Commands {
+		caps = append(caps, c.Name)
+	}
+	return EnhancedPluginInfo{
+		ID:           p.opts.ID,
+		Name:         name,
+		Version:      p.info.Version,
+		Type:         PluginTypeMiddleware,
+		Description:  fmt.Sprintf("out-of-process %s plugin (%s)", p.info.Type, p.opts.Command),
+		Capabilities: caps,
+	}
+}
+
+// Init records the connection used whenever the child (re)starts
+func (p *ProcessPlugin) Init(ctx context.Context, config PluginConfig) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.connConfig = config.Connection
+	return nil
+}
+
+// Start spawns the child, performs the protocol handshake and reconnects it
+// to the middleware if a connection was configured
+func (p *ProcessPlugin) Start(ctx context.Context) error {
+	p.mu.Lock()
+	if p.proc != nil && !p.proc.exited() {
+		p.mu.Unlock()
+		return nil
+	}
+	p.mu.Unlock()
+
+	proc, err := p.spawn()
+	if err != nil {
+		return err
+	}
+
+	var hs HandshakeResult
+	params := HandshakeParams{ProtocolVersion: ProcessProtocolVersion}
+	if err := p.callProc(ctx, proc, MethodPluginHandshake, params, &hs, p.opts.StartTimeout); err != nil {
+		proc.stop()
+		return fmt.Errorf("plugin %s handshake failed: %w", p.opts.ID, err)
+	}
+	if hs.ProtocolVersion != ProcessProtocolVersion {
+		proc.stop()
+		return fmt.Errorf("plugin %s speaks protocol %q, expected %q", p.opts.ID, hs.ProtocolVersion, ProcessProtocolVersion)
+	}
+
+	p.mu.Lock()
+	p.proc = proc
+	p.info = hs
+	connConfig := p.connConfig
+	p.mu.Unlock()
+	p.connected.Store(false)
+
+	p.log.Infof("Plugin %s started in process %d", p.opts.ID, proc.cmd.Process.Pid)
+
+	if connConfig != nil {
+		var res ConnectedResult
+		if err := p.call(ctx, MethodPluginConnect, connConfig, &res); err != nil {
+			return fmt.Errorf("plugin %s connect failed: %w", p.opts.ID, err)
+		}
+		p.connected.Store(res.Connected)
+	}
+	return nil
+}
+
+// Stop asks the child to shut down and kills it if it does not exit in time
+func (p *ProcessPlugin) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	proc := p.proc
+	p.proc = nil
+	p.mu.Unlock()
+	p.connected.Store(false)
+
+	if proc == nil {
+		return nil
+	}
+	proc.mu.Lock()
+	proc.stopping = true
+	proc.mu.Unlock()
+
+	if !proc.exited() {
+		p.callProc(ctx, proc, MethodPluginShutdown, nil, nil, stopGracePeriod)
+	}
+	proc.stop()
+	return nil
+}
+
+// HealthCheck verifies the child is alive and answering requests. A child that
+// does not answer in time is killed, so the next restart starts from scratch.
+func (p *ProcessPlugin) HealthCheck(ctx context.Context) error {
+	p.mu.Lock()
+	proc := p.proc
+	p.mu.Unlock()
+
+	if proc == nil {
+		return ErrPluginNotRunning
+	}
+	if proc.exited() {
+		return fmt.Errorf("%w: %s", ErrPluginCrashed, proc.describeExit())
+	}
+
+	timeout := defaultHealthTimeout
+	if p.opts.Timeout < timeout {
+		timeout = p.opts.Timeout
+	}
+	var res ConnectedResult
+	err := p.callProc(ctx, proc, MethodPluginPing, nil, &res, timeout)
+	switch {
+	case err == nil:
+		p.connected.Store(res.Connected)
+	case errors.Is(err, ErrTimeout), errors.Is(err, ErrPluginCrashed):
+		return err
+	default:
+		// The child answered: the middleware is unreachable, which a restart
+		// of the plugin process would not fix
+		p.connected.Store(false)
+		p.log.Warnf("Plugin %s is alive but reports: %v", p.opts.ID, err)
+	}
+	return nil
+}
+
+// === MiddlewarePlugin ===
+
+// Name returns the plugin name reported by the child
+func (p *ProcessPlugin) Name() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.info.Name == "" {
+		return p.opts.ID
+	}
+	return p.info.Name
+}
+
+// Type returns the middleware type reported by the child
+func (p *ProcessPlugin) Type() MiddlewareType {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.info.Type
+}
+
+// Version returns the plugin version reported by the child
+func (p *ProcessPlugin) Version() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.info.Version
+}
+
+// Connect connects the child to the middleware. The configuration is kept so a
+// restarted child reconnects automatically.
+func (p *ProcessPlugin) Connect(ctx context.Context, config *ConnectionConfig) error {
+	p.mu.Lock()
+	p.connConfig = config
+	p.mu.Unlock()
+
+	var res ConnectedResult
+	if err := p.call(ctx, MethodPluginConnect, config, &res); err != nil {
+		return err
+	}
+	p.connected.Store(res.Connected)
+	return nil
+}
+
+// Disconnect disconnects the child from the middleware
+func (p *ProcessPlugin) Disconnect(ctx context.Context) error {
+	p.mu.Lock()
+	p.connConfig = nil
+	p.mu.Unlock()
+
+	var res ConnectedResult
+	err := p.call(ctx, MethodPluginDisconnect, nil, &res)
+	p.connected.Store(false)
+	if errors.Is(err, ErrPluginNotRunning) {
+		return nil
+	}
+	return err
+}
+
+// Ping checks the middleware connection through the child
+func (p *ProcessPlugin) Ping(ctx context.Context) error {
+	var res ConnectedResult
+	if err := p.call(ctx, MethodPluginPing, nil, &res); err != nil {
+		return err
+	}
+	p.connected.Store(res.Connected)
+	if !res.Connected {
+		return fmt.Errorf("plugin %s is not connected", p.Name())
+	}
+	return nil
+}
+
+// IsConnected returns the last connection state reported by the child
+func (p *ProcessPlugin) IsConnected() bool {
+	return p.connected.Load()
+}
+
+// CollectMetrics collects all metrics
+func (p *ProcessPlugin) CollectMetrics(ctx context.Context) (*MetricsSnapshot, error) {
+	var snapshot MetricsSnapshot
+	if err := p.call(ctx, MethodPluginCollect, nil, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// CollectSpecificMetric collects a specific metric group or value
+func (p *ProcessPlugin) CollectSpecificMetric(ctx context.Context, metricName string) (interface{}, error) {
+	var value interface{}
+	if err := p.call(ctx, MethodPluginCollectMetric, MetricParams{Name: metricName}, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Execute executes a command
+func (p *ProcessPlugin) Execute(ctx context.Context, cmd *Command) (*CommandResult, error) {
+	var result CommandResult
+	if err := p.call(ctx, MethodPluginExecute, cmd, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SupportedCommands returns the commands reported in the handshake
+func (p *ProcessPlugin) SupportedCommands() []CommandSpec {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.info.Commands
+}
+
+// GetDiagnosticData collects all data needed for diagnosis
+func (p *ProcessPlugin) GetDiagnosticData(ctx context.Context) (*DiagnosticData, error) {
+	var data DiagnosticData
+	if err := p.call(ctx, MethodPluginDiagnostic, nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetBuiltinRules returns the rules reported in the handshake
+func (p *ProcessPlugin) GetBuiltinRules() []DiagnosisRule {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.info.Rules
+}
+
+// PID returns the PID of the running child, or 0
+func (p *ProcessPlugin) PID() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.proc == nil || p.proc.exited() {
+		return 0
+	}
+	return p.proc.cmd.Process.Pid
+}
+
+// === RPC ===
+
+// call invokes a method on the current child with the configured timeout
+func (p *ProcessPlugin) call(ctx context.Context, method string, params any, out any) error {
+	p.mu.Lock()
+	proc := p.proc
+	p.mu.Unlock()
+
+	if proc == nil {
+		return fmt.Errorf("%w: %s", ErrPluginNotRunning, p.opts.ID)
+	}
+	return p.callProc(ctx, proc, method, params, out, p.opts.Timeout)
+}
+
+// callProc sends a request and waits for the answer. If the child does not
+// answer within timeout it is killed; cancelling ctx only cancels the request.
+func (p *ProcessPlugin) callProc(ctx context.Context, proc *pluginProcess, method string, params any, out any, timeout time.Duration) (err error) {
+	start := time.Now()
+	done := p.metrics.TrackActiveRequest(p.opts.ID)
+	defer func() {
+		done()
+		p.metrics.RecordCall(p.opts.ID, method, time.Since(start), err)
+	}()
+
+	if proc.exited() {
+		return fmt.Errorf("%w: %s", ErrPluginCrashed, proc.describeExit())
+	}
+
+	id := atomic.AddInt64(&p.nextID, 1)
+	respCh := make(chan *protocol.Response, 1)
+	proc.mu.Lock()
+	proc.pending[id] = respCh
+	proc.mu.Unlock()
+	defer func() {
+		proc.mu.Lock()
+		delete(proc.pending, id)
+		proc.mu.Unlock()
+	}()
+
+	if err := proc.send(id, method, params); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case resp := <-respCh:
+		if resp.Error != nil {
+			if resp.Error.Code == protocol.InternalError {
+				return errors.New(resp.Error.Message)
+			}
+			return resp.Error
+		}
+		return decodeResult(resp.Result, out)
+	case <-proc.done:
+		return fmt.Errorf("%w: %s", ErrPluginCrashed, proc.describeExit())
+	case <-ctx.Done():
+		proc.send(nil, MethodPluginCancel, CancelParams{ID: id})
+		return ctx.Err()
+	case <-timer.C:
+		p.log.Warnf("Plugin %s did not answer %s within %v, killing process", p.opts.ID, method, timeout)
+		proc.kill(CrashReasonTimeout, method)
+		<-proc.done
+		return fmt.Errorf("%w: %s did not answer %s within %v", ErrTimeout, p.opts.ID, method, timeout)
+	}
+}
+
+// spawn starts a new child process with the configured limits
+func (p *ProcessPlugin) spawn() (*pluginProcess, error) {
+	cmd := exec.Command(p.opts.Command, p.opts.Args...)
+	if len(p.opts.Env) > 0 {
+		cmd.Env = p.opts.Env
+	}
+
+	limits, err := prepareLimits(cmd, p.opts.ID, p.opts.Limits)
+	if err != nil {
+		return nil, err
+	}
+	if p.opts.Limits.CPUCores > 0 && !limits.enforcesCPU() {
+		p.log.Warnf("CPU limit for plugin %s is not enforced without a cgroup parent", p.opts.ID)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		limits.cleanup()
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		limits.cleanup()
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	stderr := &stderrBuffer{}
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		limits.cleanup()
+		return nil, fmt.Errorf("failed to start plugin %s: %w", p.opts.ID, err)
+	}
+
+	proc := &pluginProcess{
+		cmd:       cmd,
+		stdin:     stdin,
+		limits:    limits,
+		stderr:    stderr,
+		codec:     protocol.NewCodec(),
+		startedAt: time.Now(),
+		pending:   make(map[int64]chan *protocol.Response),
+		done:      make(chan struct{}),
+	}
+
+	readDone := make(chan struct{})
+	go proc.readLoop(stdout, readDone)
+	go func() {
+		<-readDone
+		proc.exitErr = cmd.Wait()
+		limits.cleanup()
+		close(proc.done)
+		if report, crashed := proc.crashReport(p.opts.ID); crashed {
+			p.log.Errorf("Plugin %s process %d crashed (%s, exit code %d)", p.opts.ID, report.PID, report.Reason, report.ExitCode)
+			p.connected.Store(false)
+			p.metrics.RecordCrash(report)
+		}
+	}()
+
+	return proc, nil
+}
+
+// readLoop routes responses from the child to the waiting callers
+func (proc *pluginProcess) readLoop(stdout io.Reader, done chan struct{}) {
+	defer close(done)
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		resp, err := proc.codec.DecodeResponse(scanner.Bytes())
+		if err != nil {
+			continue
+		}
+		id, ok := resp.ID.(float64)
+		if !ok {
+			continue
+		}
+		proc.mu.Lock()
+		ch, exists := proc.pending[int64(id)]
+		proc.mu.Unlock()
+		if exists {
+			select {
+			case ch <- resp:
+			default:
+			}
+		}
+	}
+	// Drain anything left so the child never blocks on a full pipe
+	io.Copy(io.Discard, stdout)
+}
+
+// send writes a request, or a notification when id is nil
+func (proc *pluginProcess) send(id any, method string, params any) error {
+	data, err := proc.codec.EncodeRequest(id, method, params)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	proc.writeMu.Lock()
+	defer proc.writeMu.Unlock()
+	if _, err := proc.stdin.Write(data); err != nil {
+		return fmt.Errorf("failed to write to plugin: %w", err)
+	}
+	return nil
+}
+
+// kill terminates the child, remembering why the host killed it
+func (proc *pluginProcess) kill(reason CrashReason, method string) {
+	proc.mu.Lock()
+	if proc.killed == "" {
+		proc.killed = reason
+		proc.method = method
+	}
+	proc.mu.Unlock()
+	killProcess(proc.cmd)
+}
+
+// stop closes stdin and waits for the child, killing it after a grace period
+func (proc *pluginProcess) stop() {
+	proc.mu.Lock()
+	proc.stopping = true
+	proc.mu.Unlock()
+
+	proc.stdin.Close()
+	select {
+	case <-proc.done:
+	case <-time.After(stopGracePeriod):
+		killProcess(proc.cmd)
+		<-proc.done
+	}
+}
+
+// exited reports whether the child has exited
+func (proc *pluginProcess) exited() bool {
+	select {
+	case <-proc.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// describeExit summarises how the child exited
+func (proc *pluginProcess) describeExit() string {
+	proc.mu.Lock()
+	killed := proc.killed
+	proc.mu.Unlock()
+	if killed != "" {
+		return fmt.Sprintf("killed (%s)", killed)
+	}
+	if proc.exitErr != nil {
+		return proc.exitErr.Error()
+	}
+	return "exited"
+}
+
+// crashReport builds the report for an exit the host did not ask for. A kill
+// on timeout counts as a crash; a requested shutdown does not.
+func (proc *pluginProcess) crashReport(plugin string) (CrashReport, bool) {
+	proc.mu.Lock()
+	killed, method, stopping := proc.killed, proc.method, proc.stopping
+	proc.mu.Unlock()
+
+	if stopping && killed == "" {
+		return CrashReport{}, false
+	}
+
+	report := CrashReport{
+		Plugin: plugin,
+		PID:    proc.cmd.Process.Pid,
+		Reason: killed,
+		Method: method,
+		Stderr: proc.stderr.String(),
+		Uptime: time.Since(proc.startedAt),
+		Time:   time.Now(),
+	}
+	if state := proc.cmd.ProcessState; state != nil {
+		report.ExitCode = state.ExitCode()
+		if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			report.Signal = ws.Signal().String()
+		}
+	}
+
+	if report.Reason == "" {
+		switch {
+		case proc.limits.oomKilled() || containsOOM(report.Stderr):
+			report.Reason = CrashReasonOOM
+		case report.Signal != "":
+			report.Reason = CrashReasonSignal
+		default:
+			report.Reason = CrashReasonExit
+		}
+	}
+	return report, true
+}
+
+// containsOOM recognises the Go runtime's out-of-memory fatal error, which is
+// how a child hitting RLIMIT_DATA dies
+func containsOOM(stderr string) bool {
+	return strings.Contains(stderr, "out of memory") || strings.Contains(stderr, "cannot allocate memory")
+}
+
+// stderrBuffer keeps the beginning and the end of a child's stderr. The head
+// holds the fatal error line of a Go runtime crash, the tail the last output.
+type stderrBuffer struct {
+	mu        sync.Mutex
+	head      []byte
+	tail      []byte
+	truncated bool
+}
+
+func (b *stderrBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	written := len(p)
+	if room := stderrHeadSize - len(b.head); room > 0 {
+		n := min(room, len(p))
+		b.head = append(b.head, p[:n]...)
+		p = p[n:]
+	}
+	b.tail = append(b.tail, p...)
+	if len(b.tail) > stderrTailSize {
+		b.tail = append([]byte(nil), b.tail[len(b.tail)-stderrTailSize:]...)
+		b.truncated = true
+	}
+	return written, nil
+}
+
+func (b *stderrBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.truncated {
+		return string(b.head) + "\n...\n" + string(b.tail)
+	}
+	return string(b.head) + string(b.tail)
+}
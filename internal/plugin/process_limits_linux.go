@@ -0,0 +1,189 @@
+//go:build linux
+
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// cpuPeriod is the cgroup v2 cpu.max period in microseconds
+const cpuPeriod = 100000
+
+// rlimitWrapperEnv asks a re-executed host binary to act as the rlimit
+// wrapper: set RLIMIT_DATA to its value and exec the plugin command
+const rlimitWrapperEnv = "KSA_PLUGIN_RLIMIT_DATA"
+
+// The host starts plugins without a cgroup through a copy of itself, so the
+// memory rlimit is in place before the plugin's first instruction. This hook
+// runs before main in every binary that links the plugin package.
+func init() {
+	if limit, ok := os.LookupEnv(rlimitWrapperEnv); ok {
+		execWithRlimit(limit)
+	}
+}
+
+// execWithRlimit is the wrapper side: os.Args[1] is the plugin command and
+// os.Args[2:] its argv. It only returns by exiting.
+func execWithRlimit(limit string) {
+	os.Unsetenv(rlimitWrapperEnv)
+	bytes, err := strconv.ParseUint(limit, 10, 64)
+	if err == nil && len(os.Args) < 3 {
+		err = fmt.Errorf("no plugin command")
+	}
+	if err == nil {
+		err = unix.Setrlimit(unix.RLIMIT_DATA, &unix.Rlimit{Cur: bytes, Max: bytes})
+	}
+	if err == nil {
+		err = syscall.Exec(os.Args[1], os.Args[2:], os.Environ())
+	}
+	fmt.Fprintf(os.Stderr, "plugin rlimit wrapper: %v\n", err)
+	os.Exit(127)
+}
+
+// processLimits enforces ResourceLimits on one child process. With a cgroup
+// parent the child is started directly inside a fresh cgroup v2 group that
+// caps memory.max and cpu.max; otherwise memory is capped with RLIMIT_DATA,
+// set by the rlimit wrapper before exec, and the CPU limit cannot be enforced.
+type processLimits struct {
+	limits    ResourceLimits
+	cgroupDir string
+	cgroupFD  int
+}
+
+// prepareLimits configures cmd before it is started
+func prepareLimits(cmd *exec.Cmd, name string, limits ResourceLimits) (*processLimits, error) {
+	pl := &processLimits{limits: limits, cgroupFD: -1}
+
+	// Own process group so a timeout kill takes grandchildren with it
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if limits.CgroupParent == "" {
+		if limits.MemoryBytes > 0 {
+			if err := wrapWithRlimit(cmd, limits.MemoryBytes); err != nil {
+				return nil, err
+			}
+		}
+		return pl, nil
+	}
+
+	dir := filepath.Join(limits.CgroupParent, fmt.Sprintf("ksa-plugin-%s-%d", sanitizeCgroupName(name), time.Now().UnixNano()))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cgroup %s: %w", dir, err)
+	}
+	pl.cgroupDir = dir
+
+	if limits.MemoryBytes > 0 {
+		if err := writeCgroupFile(dir, "memory.max", strconv.FormatInt(limits.MemoryBytes, 10)); err != nil {
+			pl.cleanup()
+			return nil, err
+		}
+		// Best effort: not every kernel has swap accounting enabled
+		writeCgroupFile(dir, "memory.swap.max", "0")
+	}
+	if limits.CPUCores > 0 {
+		quota := int64(limits.CPUCores * cpuPeriod)
+		if err := writeCgroupFile(dir, "cpu.max", fmt.Sprintf("%d %d", quota, cpuPeriod)); err != nil {
+			pl.cleanup()
+			return nil, err
+		}
+	}
+
+	fd, err := unix.Open(dir, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		pl.cleanup()
+		return nil, fmt.Errorf("failed to open cgroup %s: %w", dir, err)
+	}
+	pl.cgroupFD = fd
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = fd
+	return pl, nil
+}
+
+// wrapWithRlimit rewrites cmd to start through the rlimit wrapper, which
+// caps RLIMIT_DATA at memBytes and then execs the original command
+func wrapWithRlimit(cmd *exec.Cmd, memBytes int64) error {
+	if cmd.Err != nil {
+		return cmd.Err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the rlimit wrapper: %w", err)
+	}
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(env, fmt.Sprintf("%s=%d", rlimitWrapperEnv, memBytes))
+	cmd.Args = append([]string{self, cmd.Path}, cmd.Args...)
+	cmd.Path = self
+	return nil
+}
+
+// enforcesCPU reports whether the CPU limit is actually enforced
+func (pl *processLimits) enforcesCPU() bool {
+	return pl.cgroupDir != ""
+}
+
+// oomKilled reports whether the kernel OOM-killed a process in the cgroup
+func (pl *processLimits) oomKilled() bool {
+	if pl.cgroupDir == "" {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join(pl.cgroupDir, "memory.events"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "oom_kill" && fields[1] != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanup releases the cgroup once the child has exited
+func (pl *processLimits) cleanup() {
+	if pl.cgroupFD >= 0 {
+		unix.Close(pl.cgroupFD)
+		pl.cgroupFD = -1
+	}
+	if pl.cgroupDir != "" {
+		os.Remove(pl.cgroupDir)
+	}
+}
+
+// killProcess kills the child and its process group
+func killProcess(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		cmd.Process.Kill()
+	}
+}
+
+func writeCgroupFile(dir, file, value string) error {
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0o644); err != nil {
+		return fmt.Errorf("failed to set %s: %w", file, err)
+	}
+	return nil
+}
+
+func sanitizeCgroupName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,27 @@
+//go:build !linux
+
+package plugin
+
+import (
+	"os/exec"
+)
+
+// processLimits is a no-op outside Linux: only the call timeout is enforced,
+// by killing the child process.
+type processLimits struct{}
+
+func prepareLimits(cmd *exec.Cmd, name string, limits ResourceLimits) (*processLimits, error) {
+	return &processLimits{}, nil
+}
+
+func (pl *processLimits) enforcesCPU() bool { return false }
+
+func (pl *processLimits) oomKilled() bool { return false }
+
+func (pl *processLimits) cleanup() {}
+
+func killProcess(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+}
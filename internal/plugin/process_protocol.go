@@ -0,0 +1,76 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ProcessProtocolVersion is the version of the plugin RPC protocol spoken between
+// the host and an out-of-process plugin. The host refuses to talk to a child that
+// reports a different version.
+const ProcessProtocolVersion = "1"
+
+// Plugin RPC methods. Messages are JSON-RPC 2.0 framed one per line over the
+// child's stdin/stdout, the same framing used by internal/mcp/protocol.
+const (
+	MethodPluginHandshake     = "plugin/handshake"
+	MethodPluginConnect       = "plugin/connect"
+	MethodPluginDisconnect    = "plugin/disconnect"
+	MethodPluginPing          = "plugin/ping"
+	MethodPluginCollect       = "plugin/collectMetrics"
+	MethodPluginCollectMetric = "plugin/collectSpecificMetric"
+	MethodPluginExecute       = "plugin/execute"
+	MethodPluginDiagnostic    = "plugin/diagnosticData"
+	MethodPluginShutdown      = "plugin/shutdown"
+
+	// MethodPluginCancel is a notification asking the child to cancel the
+	// context of an in-flight request.
+	MethodPluginCancel = "plugin/cancel"
+)
+
+// HandshakeParams is sent by the host when the child starts
+type HandshakeParams struct {
+	ProtocolVersion string `json:"protocolVersion"`
+}
+
+// HandshakeResult describes the plugin served by the child. The static parts of
+// the MiddlewarePlugin interface are answered from it without a round trip.
+type HandshakeResult struct {
+	ProtocolVersion string          `json:"protocolVersion"`
+	Name            string          `json:"name"`
+	Type            MiddlewareType  `json:"type"`
+	Version         string          `json:"version"`
+	PID             int             `json:"pid"`
+	Commands        []CommandSpec   `json:"commands,omitempty"`
+	Rules           []DiagnosisRule `json:"rules,omitempty"`
+}
+
+// MetricParams selects a single metric for plugin/collectSpecificMetric
+type MetricParams struct {
+	Name string `json:"name"`
+}
+
+// CancelParams identifies the request to cancel
+type CancelParams struct {
+	ID int64 `json:"id"`
+}
+
+// ConnectedResult reports the connection state after connect/disconnect/ping
+type ConnectedResult struct {
+	Connected bool `json:"connected"`
+}
+
+// decodeResult converts a generically decoded JSON-RPC result into out
+func decodeResult(result any, out any) error {
+	if out == nil {
+		return nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/mcp/protocol"
+)
+
+// processServer is the child side of an out-of-process plugin
+type processServer struct {
+	plugin   MiddlewarePlugin
+	codec    *protocol.Codec
+	out      io.Writer
+	writeMu  sync.Mutex
+	inflight map[string]context.CancelFunc
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+}
+
+// ServeStdio serves a plugin to the host over the process' stdin and stdout.
+// Anything the plugin itself prints to stdout is redirected to stderr so it
+// cannot corrupt the protocol stream; the host captures stderr for crash reports.
+func ServeStdio(ctx context.Context, p MiddlewarePlugin) error {
+	out := os.Stdout
+	os.Stdout = os.Stderr
+	return ServeProcess(ctx, p, os.Stdin, out)
+}
+
+// ServeProcess answers plugin RPC requests read from in until in is closed, the
+// host sends plugin/shutdown or ctx is cancelled. Requests are handled
+// concurrently, each with its own cancellable context.
+func ServeProcess(ctx context.Context, p MiddlewarePlugin, in io.Reader, out io.Writer) error {
+	s := &processServer{
+		plugin:   p,
+		codec:    protocol.NewCodec(),
+		out:      out,
+		inflight: make(map[string]context.CancelFunc),
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	defer func() {
+		cancel()
+		s.wg.Wait()
+		p.Disconnect(context.Background())
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			return err
+		case line := <-lines:
+			req, err := s.codec.DecodeRequest(line)
+			if err != nil {
+				if rpcErr, ok := err.(*protocol.RPCError); ok {
+					s.writeError(nil, rpcErr.Code, rpcErr.Message, rpcErr.Data)
+				}
+				continue
+			}
+
+			switch req.Method {
+			case MethodPluginCancel:
+				var params CancelParams
+				if decodeResult(req.Params, &params) == nil {
+					s.cancel(fmt.Sprint(params.ID))
+				}
+			case MethodPluginShutdown:
+				s.writeResult(req.ID, struct{}{})
+				return nil
+			default:
+				s.handle(ctx, req)
+			}
+		}
+	}
+}
+
+// handle dispatches a request on its own goroutine
+func (s *processServer) handle(ctx context.Context, req *protocol.Request) {
+	key := fmt.Sprint(req.ID)
+	reqCtx, cancel := context.WithCancel(ctx)
+	if req.ID != nil {
+		s.mu.Lock()
+		s.inflight[key] = cancel
+		s.mu.Unlock()
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.inflight, key)
+			s.mu.Unlock()
+			cancel()
+		}()
+
+		result, err := s.dispatch(reqCtx, req)
+		if req.IsNotification() {
+			return
+		}
+		if err != nil {
+			if rpcErr, ok := err.(*protocol.RPCError); ok {
+				s.writeError(req.ID, rpcErr.Code, rpcErr.Message, rpcErr.Data)
+				return
+			}
+			s.writeError(req.ID, protocol.InternalError, err.Error(), nil)
+			return
+		}
+		s.writeResult(req.ID, result)
+	}()
+}
+
+// dispatch invokes the plugin method behind an RPC method
+func (s *processServer) dispatch(ctx context.Context, req *protocol.Request) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrPanic, r)
+		}
+	}()
+
+	p := s.plugin
+	switch req.Method {
+	case MethodPluginHandshake:
+		var params HandshakeParams
+		if err := decodeResult(req.Params, &params); err != nil {
+			return nil, &protocol.RPCError{Code: protocol.InvalidParams, Message: err.Error()}
+		}
+		if params.ProtocolVersion != ProcessProtocolVersion {
+			return nil, &protocol.RPCError{
+				Code:    protocol.InvalidRequest,
+				Message: "unsupported protocol version",
+				Data:    fmt.Sprintf("host speaks %q, plugin speaks %q", params.ProtocolVersion, ProcessProtocolVersion),
+			}
+		}
+		return &HandshakeResult{
+			ProtocolVersion: ProcessProtocolVersion,
+			Name:            p.Name(),
+			Type:            p.Type(),
+			Version:         p.Version(),
+			PID:             os.Getpid(),
+			Commands:        p.SupportedCommands(),
+			Rules:           p.GetBuiltinRules(),
+		}, nil
+
+	case MethodPluginConnect:
+		var cfg ConnectionConfig
+		if err := decodeResult(req.Params, &cfg); err != nil {
+			return nil, &protocol.RPCError{Code: protocol.InvalidParams, Message: err.Error()}
+		}
+		if err := p.Connect(ctx, &cfg); err != nil {
+			return nil, err
+		}
+		return &ConnectedResult{Connected: p.IsConnected()}, nil
+
+	case MethodPluginDisconnect:
+		if err := p.Disconnect(ctx); err != nil {
+			return nil, err
+		}
+		return &ConnectedResult{Connected: p.IsConnected()}, nil
+
+	case MethodPluginPing:
+		if !p.IsConnected() {
+			return &ConnectedResult{Connected: false}, nil
+		}
+		if err := p.Ping(ctx); err != nil {
+			return nil, err
+		}
+		return &ConnectedResult{Connected: true}, nil
+
+	case MethodPluginCollect:
+		return p.CollectMetrics(ctx)
+
+	case MethodPluginCollectMetric:
+		var params MetricParams
+		if err := decodeResult(req.Params, &params); err != nil {
+			return nil, &protocol.RPCError{Code: protocol.InvalidParams, Message: err.Error()}
+		}
+		return p.CollectSpecificMetric(ctx, params.Name)
+
+	case MethodPluginExecute:
+		var cmd Command
+		if err := decodeResult(req.Params, &cmd); err != nil {
+			return nil, &protocol.RPCError{Code: protocol.InvalidParams, Message: err.Error()}
+		}
+		return p.Execute(ctx, &cmd)
+
+	case MethodPluginDiagnostic:
+		return p.GetDiagnosticData(ctx)
+
+	default:
+		return nil, &protocol.RPCError{Code: protocol.MethodNotFound, Message: "method not found", Data: req.Method}
+	}
+}
+
+// cancel cancels an in-flight request
+func (s *processServer) cancel(key string) {
+	s.mu.Lock()
+	cancel, ok := s.inflight[key]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+func (s *processServer) writeResult(id any, result any) {
+	data, err := s.codec.EncodeResponse(id, result)
+	if err != nil {
+		s.writeError(id, protocol.InternalError, err.Error(), nil)
+		return
+	}
+	s.write(data)
+}
+
+func (s *processServer) writeError(id any, code int, message string, data any) {
+	encoded, err := s.codec.EncodeError(id, code, message, data)
+	if err != nil {
+		return
+	}
+	s.write(encoded)
+}
+
+func (s *processServer) write(data []byte) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.out.Write(data)
+}
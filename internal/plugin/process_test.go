@@ -0,0 +1,249 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const helperEnv = "KSA_TEST_PROCESS_PLUGIN"
+
+// fakeMiddlewarePlugin is served by the test binary re-executed as a child
+type fakeMiddlewarePlugin struct {
+	connected bool
+	host      string
+}
+
+func (f *fakeMiddlewarePlugin) Name() string         { return "fake" }
+func (f *fakeMiddlewarePlugin) Type() MiddlewareType { return MiddlewareRedis }
+func (f *fakeMiddlewarePlugin) Version() string      { return "9.9.9" }
+func (f *fakeMiddlewarePlugin) IsConnected() bool    { return f.connected }
+func (f *fakeMiddlewarePlugin) Ping(context.Context) error {
+	return nil
+}
+func (f *fakeMiddlewarePlugin) Connect(_ context.Context, cfg *ConnectionConfig) error {
+	f.connected, f.host = true, cfg.Host
+	return nil
+}
+func (f *fakeMiddlewarePlugin) Disconnect(context.Context) error {
+	f.connected = false
+	return nil
+}
+func (f *fakeMiddlewarePlugin) CollectMetrics(context.Context) (*MetricsSnapshot, error) {
+	return &MetricsSnapshot{Metrics: map[string]MetricValue{
+		"used_memory": {Name: "used_memory", Value: 1024, Labels: map[string]string{"host": f.host}},
+	}}, nil
+}
+func (f *fakeMiddlewarePlugin) CollectSpecificMetric(_ context.Context, name string) (interface{}, error) {
+	return nil, fmt.Errorf("unknown metric %s", name)
+}
+func (f *fakeMiddlewarePlugin) Execute(ctx context.Context, cmd *Command) (*CommandResult, error) {
+	switch cmd.Name {
+	case "echo":
+		return &CommandResult{Success: true, Output: fmt.Sprint(cmd.Args...)}, nil
+	case "hang":
+		// Ignores cancellation like a misbehaving plugin
+		select {}
+	case "crash":
+		fmt.Fprintln(os.Stderr, "panic: corrupted state")
+		os.Exit(3)
+	case "alloc":
+		var chunks [][]byte
+		for i := 0; i < 64; i++ {
+			chunk := make([]byte, 32<<20)
+			for j := range chunk {
+				chunk[j] = byte(j)
+			}
+			chunks = append(chunks, chunk)
+		}
+		return &CommandResult{Success: true, Output: fmt.Sprint(len(chunks))}, nil
+	}
+	return nil, fmt.Errorf("unknown command %s", cmd.Name)
+}
+func (f *fakeMiddlewarePlugin) SupportedCommands() []CommandSpec {
+	return []CommandSpec{{Name: "echo"}, {Name: "hang"}, {Name: "crash"}, {Name: "alloc"}}
+}
+func (f *fakeMiddlewarePlugin) GetDiagnosticData(context.Context) (*DiagnosticData, error) {
+	return &DiagnosticData{}, nil
+}
+func (f *fakeMiddlewarePlugin) GetBuiltinRules() []DiagnosisRule { return nil }
+
+// TestProcessPluginHelper is not a real test: it is the child process
+func TestProcessPluginHelper(t *testing.T) {
+	if os.Getenv(helperEnv) != "1" {
+		t.Skip("helper process")
+	}
+	ServeStdio(context.Background(), &fakeMiddlewarePlugin{})
+	os.Exit(0)
+}
+
+func newTestProcessPlugin(t *testing.T, timeout time.Duration, limits ResourceLimits) (*ProcessPlugin, *PluginMetrics) {
+	metrics := NewPluginMetrics(zap.NewNop())
+	p := NewProcessPlugin(ProcessOptions{
+		ID:      "fake",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestProcessPluginHelper$"},
+		Env:     append(os.Environ(), helperEnv+"=1"),
+		Timeout: timeout,
+		Limits:  limits,
+		Metrics: metrics,
+	})
+	t.Cleanup(func() { p.Stop(context.Background()) })
+	return p, metrics
+}
+
+func TestProcessPlugin_ProxiesMiddlewarePlugin(t *testing.T) {
+	ctx := context.Background()
+	p, metrics := newTestProcessPlugin(t, 5*time.Second, ResourceLimits{})
+	require.NoError(t, p.Start(ctx))
+
+	assert.Equal(t, "fake", p.Name())
+	assert.Equal(t, MiddlewareRedis, p.Type())
+	assert.Equal(t, "9.9.9", p.Version())
+	assert.Len(t, p.SupportedCommands(), 4)
+	assert.NotEqual(t, os.Getpid(), p.PID())
+
+	require.NoError(t, p.Connect(ctx, &ConnectionConfig{Host: "redis-0"}))
+	assert.True(t, p.IsConnected())
+
+	snapshot, err := p.CollectMetrics(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1024.0, snapshot.Metrics["used_memory"].Value)
+	assert.Equal(t, "redis-0", snapshot.Metrics["used_memory"].Labels["host"])
+
+	result, err := p.Execute(ctx, &Command{Name: "echo", Args: []interface{}{"pong"}})
+	require.NoError(t, err)
+	assert.Equal(t, "pong", result.Output)
+
+	_, err = p.CollectSpecificMetric(ctx, "nope")
+	assert.EqualError(t, err, "unknown metric nope")
+
+	require.NoError(t, p.Stop(ctx))
+	assert.Zero(t, metrics.CrashCount("fake"), "a requested shutdown is not a crash")
+}
+
+func TestProcessPlugin_KillsOnTimeout(t *testing.T) {
+	ctx := context.Background()
+	p, metrics := newTestProcessPlugin(t, 300*time.Millisecond, ResourceLimits{})
+	require.NoError(t, p.Start(ctx))
+
+	_, err := p.Execute(ctx, &Command{Name: "hang"})
+	require.ErrorIs(t, err, ErrTimeout)
+	assert.Zero(t, p.PID(), "the child was killed")
+
+	require.Eventually(t, func() bool { return metrics.CrashCount("fake") == 1 }, time.Second, 10*time.Millisecond)
+	report := metrics.RecentCrashes("fake")[0]
+	assert.Equal(t, CrashReasonTimeout, report.Reason)
+	assert.Equal(t, MethodPluginExecute, report.Method)
+
+	assert.ErrorIs(t, p.HealthCheck(ctx), ErrPluginCrashed)
+}
+
+func TestProcessPlugin_ReportsCrash(t *testing.T) {
+	ctx := context.Background()
+	p, metrics := newTestProcessPlugin(t, 5*time.Second, ResourceLimits{})
+	require.NoError(t, p.Start(ctx))
+
+	_, err := p.Execute(ctx, &Command{Name: "crash"})
+	require.ErrorIs(t, err, ErrPluginCrashed)
+
+	require.Eventually(t, func() bool { return metrics.CrashCount("fake") == 1 }, time.Second, 10*time.Millisecond)
+	report := metrics.RecentCrashes("fake")[0]
+	assert.Equal(t, CrashReasonExit, report.Reason)
+	assert.Equal(t, 3, report.ExitCode)
+	assert.Contains(t, report.Stderr, "corrupted state")
+}
+
+func TestProcessPlugin_MemoryLimit(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("memory limits are enforced on Linux only")
+	}
+	ctx := context.Background()
+	p, metrics := newTestProcessPlugin(t, 10*time.Second, ResourceLimits{MemoryBytes: 512 << 20})
+	require.NoError(t, p.Start(ctx))
+
+	// 2GiB of allocations cannot fit in 512MiB
+	_, err := p.Execute(ctx, &Command{Name: "alloc"})
+	require.ErrorIs(t, err, ErrPluginCrashed)
+
+	require.Eventually(t, func() bool { return metrics.CrashCount("fake") == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, CrashReasonOOM, metrics.RecentCrashes("fake")[0].Reason)
+}
+
+func TestLifecycleManager_RestartsCrashedProcessPlugin(t *testing.T) {
+	ctx := context.Background()
+	p, metrics := newTestProcessPlugin(t, 5*time.Second, ResourceLimits{})
+
+	registry := NewEnhancedRegistry()
+	require.NoError(t, registry.Register(p, PluginConfig{}))
+	m := NewLifecycleManager(registry, nil)
+	m.SetProcessHealthPolicy(HealthPolicy{Interval: 50 * time.Millisecond, FailureThreshold: 1, MaxRestarts: 1})
+	t.Cleanup(func() { m.StopAll(ctx) })
+
+	require.NoError(t, m.InitPlugin(ctx, "fake", PluginConfig{Connection: &ConnectionConfig{Host: "redis-0"}}))
+	require.NoError(t, m.StartPlugin(ctx, "fake"))
+	firstPID := p.PID()
+	assert.True(t, p.IsConnected())
+
+	_, err := p.Execute(ctx, &Command{Name: "crash"})
+	require.True(t, errors.Is(err, ErrPluginCrashed))
+
+	require.Eventually(t, func() bool {
+		return m.RestartCount("fake") == 1 && registry.GetState("fake") == StateRunning
+	}, 2*time.Second, 20*time.Millisecond)
+	assert.NotEqual(t, firstPID, p.PID())
+	assert.True(t, p.IsConnected(), "the restarted child is reconnected")
+	assert.Equal(t, 1, metrics.CrashCount("fake"))
+
+	// The restart budget is spent: a second crash leaves the plugin in error
+	_, err = p.Execute(ctx, &Command{Name: "crash"})
+	require.ErrorIs(t, err, ErrPluginCrashed)
+	require.Eventually(t, func() bool { return registry.GetState("fake") == StateError }, time.Second, 20*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 1, m.RestartCount("fake"))
+	assert.Zero(t, p.PID())
+}
+
+func TestManager_LoadsProcessBuiltinWithConfiguredLimits(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("memory limits are enforced on Linux only")
+	}
+	ctx := context.Background()
+	t.Setenv(helperEnv, "1")
+
+	config := DefaultPluginManagerConfig()
+	config.Builtin["fake"] = BuiltinConfig{Enabled: true, Process: true}
+	config.Sandbox.MemoryLimit = "384Mi"
+	m := NewManagerWithConfig(config)
+	m.serveCommand = func(MiddlewareType) (string, []string, error) {
+		return os.Args[0], []string{"-test.run=^TestProcessPluginHelper$"}, nil
+	}
+	t.Cleanup(func() { m.Shutdown(ctx) })
+
+	require.NoError(t, m.LoadPlugin(ctx, "fake", PluginConfig{Type: MiddlewareRedis}))
+	loaded, err := m.GetPlugin("fake")
+	require.NoError(t, err)
+	p, ok := loaded.(*ProcessPlugin)
+	require.True(t, ok, "a process builtin is loaded as a ProcessPlugin")
+	assert.Equal(t, StateRunning, m.GetRegistry().GetState("fake"))
+
+	// The rlimit is in place in the child itself, not set after it started
+	limits, err := os.ReadFile(fmt.Sprintf("/proc/%d/limits", p.PID()))
+	require.NoError(t, err)
+	assert.Regexp(t, `Max data size\s+402653184\s+402653184`, string(limits))
+}
+
+func TestLifecycleManager_DoesNotRestartInProcessPlugins(t *testing.T) {
+	policy := DefaultHealthPolicy()
+	assert.Zero(t, policy.MaxRestarts)
+	assert.Equal(t, 30*time.Second, policy.Interval)
+	assert.Positive(t, DefaultProcessHealthPolicy().MaxRestarts)
+}
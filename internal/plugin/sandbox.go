@@ -18,14 +18,23 @@ type SandboxOptions struct {
 	MemoryLimit       int64
 	CPULimit          float64
 	AllowedOperations []string
+	// CgroupParent is a delegated cgroup v2 directory used to cap the memory
+	// and CPU of out-of-process plugins
+	CgroupParent string
 }
 
-// Sandbox provides isolated execution environment for plugins
+// Sandbox provides isolated execution environment for plugins.
+//
+// Execute and ExecutePlugin run in-process and only enforce the timeout: a
+// function that ignores cancellation keeps running after it. Memory and CPU
+// limits are enforced for plugins started with NewProcessPlugin, which run in
+// a child process that is killed on timeout.
 type Sandbox struct {
-	timeout    time.Duration
-	memLimit   int64
-	cpuLimit   float64
-	allowedOps []string
+	timeout      time.Duration
+	memLimit     int64
+	cpuLimit     float64
+	allowedOps   []string
+	cgroupParent string
 }
 
 // NewSandbox creates a new sandbox with the given options
@@ -34,10 +43,28 @@ func NewSandbox(opts SandboxOptions) *Sandbox {
 		timeout:    opts.Timeout,
 		memLimit:   opts.MemoryLimit,
 		cpuLimit:   opts.CPULimit,
-		allowedOps: opts.AllowedOperations,
+		allowedOps:   opts.AllowedOperations,
+		cgroupParent: opts.CgroupParent,
 	}
 }
 
+// NewProcessPlugin creates an out-of-process plugin running command under the
+// sandbox timeout, memory and CPU limits
+func (s *Sandbox) NewProcessPlugin(id, command string, args []string, metrics *PluginMetrics) *ProcessPlugin {
+	return NewProcessPlugin(ProcessOptions{
+		ID:      id,
+		Command: command,
+		Args:    args,
+		Timeout: s.timeout,
+		Metrics: metrics,
+		Limits: ResourceLimits{
+			MemoryBytes:  s.memLimit,
+			CPUCores:     s.cpuLimit,
+			CgroupParent: s.cgroupParent,
+		},
+	})
+}
+
 // Execute executes a function in the sandbox with timeout and panic recovery
 func (s *Sandbox) Execute(ctx context.Context, fn func(context.Context) (interface{}, error)) (result interface{}, err error) {
 	// Create context with timeout
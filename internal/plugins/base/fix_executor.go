@@ -17,8 +17,10 @@ package base
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"time"
 
+	"github.com/expr-lang/expr"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
 )
@@ -117,3 +119,149 @@ func (e *FixExecutor) Execute(
 		Message: fmt.Sprintf("Fix executed successfully in %s", duration),
 	}, nil
 }
+
+// CommandFunc sends one command to the middleware and returns its reply.
+type CommandFunc func(ctx context.Context, command string) (interface{}, error)
+
+// beforePattern matches the {{before}} placeholder of compensating commands
+var beforePattern = regexp.MustCompile(`\{\{\s*before\s*\}\}`)
+
+// Run executes action.Command through run, guarded by the action's probes:
+//  1. The precondition is probed and its value recorded as Before; the fix is
+//     not applied unless it holds.
+//  2. The command runs.
+//  3. After the verification's settle time, it is probed and its value
+//     recorded as After. If the command failed or the verification does not
+//     hold, the compensating RollbackCommand runs with {{before}} replaced by
+//     the recorded value.
+//
+// On success the rendered compensating command is returned in the result, so
+// the caller can undo the fix if a later action of the same plan fails.
+func (e *FixExecutor) Run(ctx context.Context, action *models.FixAction, run CommandFunc) (*models.FixResult, error) {
+	if IsDryRun(ctx) {
+		e.log.Infof("[DryRun] Would execute fix: %s (Command: %s)", action.Description, action.Command)
+		return &models.FixResult{
+			Success: true,
+			Message: fmt.Sprintf("[DryRun] Simulated execution of: %s", action.Description),
+		}, nil
+	}
+
+	result := &models.FixResult{}
+	start := time.Now()
+
+	if probe := action.Precondition; probe != nil {
+		before, err := e.probe(ctx, probe, run, nil, false)
+		result.Before = before
+		if err != nil {
+			result.Message = fmt.Sprintf("Precondition not met, fix not applied: %v", err)
+			return result, fmt.Errorf("precondition of %s: %w", action.ID, err)
+		}
+	}
+
+	e.log.Infof("Executing fix: %s", action.Description)
+	compensation, compErr := renderCompensation(action.RollbackCommand, result.Before, action.Precondition != nil)
+
+	if _, err := run(ctx, action.Command); err != nil {
+		e.log.Errorf("Fix execution failed after %s: %v", time.Since(start), err)
+		result.Message = fmt.Sprintf("Execution failed: %v.", err)
+		e.compensate(ctx, result, compensation, compErr, run)
+		return result, err
+	}
+
+	if probe := action.Verification; probe != nil {
+		after, err := e.probe(ctx, probe, run, result.Before, true)
+		result.After = after
+		if err != nil {
+			e.log.Warnf("Fix %s did not verify: %v", action.ID, err)
+			result.Message = fmt.Sprintf("Verification failed: %v.", err)
+			e.compensate(ctx, result, compensation, compErr, run)
+			return result, fmt.Errorf("verification of %s: %w", action.ID, err)
+		}
+	}
+
+	result.Success = true
+	result.Compensation = compensation
+	result.Message = fmt.Sprintf("Fix executed successfully in %s", time.Since(start))
+	if action.Verification != nil {
+		result.Message += fmt.Sprintf(" and verified (before: %v, after: %v)", result.Before, result.After)
+	}
+	e.log.Info(result.Message)
+	return result, nil
+}
+
+// probe waits for the probe's settle time, runs it and checks its expectation
+func (e *FixExecutor) probe(ctx context.Context, probe *models.FixProbe, run CommandFunc, before interface{}, after bool) (interface{}, error) {
+	if probe.Settle > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(probe.Settle):
+		}
+	}
+
+	value, err := run(ctx, probe.Command)
+	if err != nil {
+		return nil, fmt.Errorf("probe %q failed: %w", probe.Command, err)
+	}
+	ok, err := CheckProbe(probe, value, before, after)
+	if err != nil {
+		return value, err
+	}
+	if !ok {
+		return value, fmt.Errorf("%s is false for value %v", probe.Expect, value)
+	}
+	return value, nil
+}
+
+// compensate runs the compensating command after a failed or unverified fix
+func (e *FixExecutor) compensate(ctx context.Context, result *models.FixResult, command string, renderErr error, run CommandFunc) {
+	if renderErr != nil {
+		result.Message += fmt.Sprintf(" Rollback not possible: %v", renderErr)
+		return
+	}
+	if command == "" {
+		return
+	}
+
+	e.log.Infof("Attempting rollback: %s", command)
+	if _, err := run(ctx, command); err != nil {
+		e.log.Errorf("Rollback failed: %v", err)
+		result.Message += fmt.Sprintf(" Rollback also failed: %v", err)
+		return
+	}
+	e.log.Info("Rollback successful.")
+	result.RolledBack = true
+	result.Message += " Rollback successful."
+}
+
+// CheckProbe evaluates the probe's expectation for value. When after is true
+// the precondition's value is available to the expression as `before`.
+func CheckProbe(probe *models.FixProbe, value, before interface{}, after bool) (bool, error) {
+	if probe.Expect == "" {
+		return true, nil
+	}
+	env := map[string]interface{}{"value": value}
+	if after {
+		env["before"] = before
+	}
+	program, err := expr.Compile(probe.Expect, expr.Env(env), expr.AsBool())
+	if err != nil {
+		return false, fmt.Errorf("invalid expectation %q: %w", probe.Expect, err)
+	}
+	out, err := expr.Run(program, env)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate %q: %w", probe.Expect, err)
+	}
+	return out.(bool), nil
+}
+
+// renderCompensation fills {{before}} in a compensating command
+func renderCompensation(command string, before interface{}, probed bool) (string, error) {
+	if !beforePattern.MatchString(command) {
+		return command, nil
+	}
+	if !probed {
+		return "", fmt.Errorf("rollback command %q needs a precondition probe to record {{before}}", command)
+	}
+	return beforePattern.ReplaceAllString(command, fmt.Sprint(before)), nil
+}
@@ -3,7 +3,9 @@ package base
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
@@ -68,3 +70,81 @@ func TestFixExecutor_DryRun(t *testing.T) {
 	assert.True(t, res.Success)
 	assert.Contains(t, res.Message, "Simulated execution")
 }
+
+// fakeMiddleware is a key-value store driven by "GET key" and "SET key value" commands
+type fakeMiddleware struct {
+	values   map[string]string
+	commands []string
+	ignore   bool // SET succeeds without changing anything
+}
+
+func (f *fakeMiddleware) run(ctx context.Context, command string) (interface{}, error) {
+	f.commands = append(f.commands, command)
+	parts := strings.Fields(command)
+	switch {
+	case len(parts) == 2 && parts[0] == "GET":
+		return f.values[parts[1]], nil
+	case len(parts) == 3 && parts[0] == "SET":
+		if !f.ignore {
+			f.values[parts[1]] = parts[2]
+		}
+		return "OK", nil
+	}
+	return nil, fmt.Errorf("unknown command %q", command)
+}
+
+func policyFix() *models.FixAction {
+	return &models.FixAction{
+		ID:              "fix-policy",
+		Description:     "Change the eviction policy",
+		Command:         "SET policy allkeys-lru",
+		RollbackCommand: "SET policy {{ before }}",
+		Precondition:    &models.FixProbe{Command: "GET policy", Expect: `value == "noeviction"`},
+		Verification:    &models.FixProbe{Command: "GET policy", Expect: `value == "allkeys-lru" && before != value`, Settle: time.Millisecond},
+	}
+}
+
+func TestFixExecutor_Run(t *testing.T) {
+	exec := NewFixExecutor(logger.NewLogger("test"))
+	ctx := context.Background()
+
+	t.Run("Verified", func(t *testing.T) {
+		mw := &fakeMiddleware{values: map[string]string{"policy": "noeviction"}}
+		res, err := exec.Run(ctx, policyFix(), mw.run)
+		assert.NoError(t, err)
+		assert.True(t, res.Success)
+		assert.Equal(t, "noeviction", res.Before)
+		assert.Equal(t, "allkeys-lru", res.After)
+		assert.Equal(t, "SET policy noeviction", res.Compensation)
+		assert.False(t, res.RolledBack)
+	})
+
+	t.Run("Precondition Not Met", func(t *testing.T) {
+		mw := &fakeMiddleware{values: map[string]string{"policy": "volatile-lru"}}
+		res, err := exec.Run(ctx, policyFix(), mw.run)
+		assert.Error(t, err)
+		assert.False(t, res.Success)
+		assert.Equal(t, []string{"GET policy"}, mw.commands, "the fix must not run")
+	})
+
+	t.Run("Verification Failure Rolls Back", func(t *testing.T) {
+		mw := &fakeMiddleware{values: map[string]string{"policy": "noeviction"}, ignore: true}
+		res, err := exec.Run(ctx, policyFix(), mw.run)
+		assert.Error(t, err)
+		assert.False(t, res.Success)
+		assert.True(t, res.RolledBack)
+		assert.Equal(t, "noeviction", res.After)
+		assert.Equal(t, "SET policy noeviction", mw.commands[len(mw.commands)-1])
+	})
+
+	t.Run("Rollback Needs Precondition", func(t *testing.T) {
+		mw := &fakeMiddleware{values: map[string]string{"policy": "noeviction"}, ignore: true}
+		fix := policyFix()
+		fix.Precondition = nil
+		fix.Verification.Expect = `value == "allkeys-lru"`
+		res, err := exec.Run(ctx, fix, mw.run)
+		assert.Error(t, err)
+		assert.False(t, res.RolledBack)
+		assert.Contains(t, res.Message, "Rollback not possible")
+	})
+}
@@ -16,6 +16,8 @@ package kafka
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
@@ -107,9 +109,10 @@ func (a *analyzer) analyzePartitionHealth(metadata *Metadata) []*models.Issue {
 
 	if len(underReplicatedPartitions) > 0 {
 		issues = append(issues, &models.Issue{
-			Title:    "Under-Replicated Partitions Detected",
+			Title:    IssueTitleUnderReplicated,
 			Severity: enum.SeverityHigh,
 			Evidence: fmt.Sprintf("Found %d under-replicated partitions. Example: %s. This means some replicas are not in sync with the leader.", len(underReplicatedPartitions), underReplicatedPartitions[0]),
+			Metadata: map[string]string{"partitions": strings.Join(underReplicatedPartitions, ",")},
 			Recommendations: []*models.Recommendation{{
 				Description: "Under-replicated partitions reduce fault tolerance and can lead to data loss if the leader fails. Check the health and logs of the brokers that are supposed to be hosting the out-of-sync replicas for these partitions (brokers in 'Replicas' but not in 'ISR').",
 			}},
@@ -160,4 +163,60 @@ func (a *analyzer) analyzeTopicConfiguration(metadata *Metadata) []*models.Issue
 	return issues
 }
 
+// defaultLagThreshold is the total lag (in messages) of a group on a topic above
+// which a steadily growing lag is reported even if the group is still consuming.
+const defaultLagThreshold = 1000
+
+// AnalyzeConsumerLag inspects consumer group lag trends and reports groups that
+// have stopped committing offsets (stalled) separately from groups that are
+// consuming but falling behind producers (slow). Each issue carries the group
+// and topic in its Metadata so that fixes can target them.
+//
+// Parameters:
+//   trends ([]LagTrend): The lag trends derived from recent lag snapshots.
+//
+// Returns:
+//   []*models.Issue: A slice of consumer lag issues.
+func (a *analyzer) AnalyzeConsumerLag(trends []LagTrend) []*models.Issue {
+	var issues []*models.Issue
+
+	for _, t := range trends {
+		metadata := map[string]string{
+			"group":  t.GroupID,
+			"topic":  t.Topic,
+			"lag":    fmt.Sprintf("%d", t.Lag),
+			"status": string(t.Status),
+		}
+
+		switch {
+		case t.Status == LagStatusStalled:
+			evidence := fmt.Sprintf("Consumer group '%s' has a lag of %d messages on topic '%s' and its committed offsets did not advance over the last %s.", t.GroupID, t.Lag, t.Topic, t.Window.Round(time.Second))
+			recommendation := "The consumers are not committing offsets. Check the consumer application logs for processing errors, poison messages, or hung threads, and verify that the consumer instances are running."
+			if t.ActiveMembers == 0 {
+				evidence += " The group has no active members."
+				recommendation = "The group has no active members, so nothing is consuming this topic. Restart or scale up the consumer application."
+			}
+			issues = append(issues, &models.Issue{
+				Title:           IssueTitleConsumerStalled,
+				Severity:        enum.SeverityCritical,
+				Evidence:        evidence,
+				Metadata:        metadata,
+				Recommendations: []*models.Recommendation{{Description: recommendation}},
+			})
+		case t.Status == LagStatusSlow && t.Lag >= defaultLagThreshold:
+			issues = append(issues, &models.Issue{
+				Title:    IssueTitleConsumerLag,
+				Severity: enum.SeverityHigh,
+				Evidence: fmt.Sprintf("Consumer group '%s' has a lag of %d messages on topic '%s', growing by %.1f msg/s (consuming %.1f msg/s, producing %.1f msg/s).", t.GroupID, t.Lag, t.Topic, t.LagGrowthRate, t.ConsumeRate, t.ProduceRate),
+				Metadata: metadata,
+				Recommendations: []*models.Recommendation{{
+					Description: "The consumers are processing messages but cannot keep up with the producers. Consider adding consumer instances (up to the partition count), increasing the number of partitions, or optimizing per-message processing.",
+				}},
+			})
+		}
+	}
+
+	return issues
+}
+
 //Personal.AI order the ending
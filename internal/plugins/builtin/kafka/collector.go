@@ -26,6 +26,7 @@ import (
 // collector is responsible for gathering data from a Kafka cluster.
 type collector struct {
 	conn *kafka.Conn
+	lag  *LagCollector
 	log  logger.Logger
 }
 
@@ -33,12 +34,13 @@ type collector struct {
 //
 // Parameters:
 //   conn (*kafka.Conn): An active connection to a Kafka broker.
+//   lag (*LagCollector): The collector used to compute consumer group lag.
 //   log (logger.Logger): A contextualized logger for the collector.
 //
 // Returns:
 //   *collector: A new instance of the Kafka collector.
-func newCollector(conn *kafka.Conn, log logger.Logger) *collector {
-	return &collector{conn: conn, log: log}
+func newCollector(conn *kafka.Conn, lag *LagCollector, log logger.Logger) *collector {
+	return &collector{conn: conn, lag: lag, log: log}
 }
 
 // Metadata is a custom struct that aggregates the rich metadata fetched from a
@@ -124,15 +126,32 @@ func (c *collector) CollectMetrics(ctx context.Context) (*models.MetricsData, er
 	metrics["partition_count"] = float64(partitionCount)
 	metrics["under_replicated_partitions_count"] = float64(underReplicatedPartitions)
 
+	if snapshot, err := c.CollectConsumerGroupLag(ctx); err != nil {
+		c.log.Warnf("Failed to collect consumer group lag: %v", err)
+	} else {
+		var totalLag int64
+		for _, g := range snapshot.Groups {
+			totalLag += g.TotalLag
+		}
+		metrics["consumer_group_count"] = float64(len(snapshot.Groups))
+		metrics["consumer_group_total_lag"] = float64(totalLag)
+	}
+
 	c.log.Info("Note: Throughput and latency metrics require a dedicated monitoring setup (e.g., JMX Exporter).")
 
 	return &models.MetricsData{Data: metrics}, nil
 }
 
-// TODO: Implement CollectConsumerGroupLag. This is a critical metric for monitoring consumer health. It would involve:
-// 1. Using `ListConsumerGroups` on an AdminClient.
-// 2. For each group, using `DescribeConsumerGroups` to find members and their assignments.
-// 3. For each topic partition assigned to a member, using `FetchOffset` to get the latest log-end-offset.
-// 4. Comparing the latest offset with the group's committed offset to calculate the lag.
+// CollectConsumerGroupLag computes the lag of every consumer group on every
+// partition it has committed offsets for or is assigned to, and records the
+// snapshot so that lag trends can be derived across collections.
+//
+// Returns:
+//   *LagSnapshot: The per-group, per-partition lag at collection time.
+//   error: An error if the consumer groups or offsets cannot be read.
+func (c *collector) CollectConsumerGroupLag(ctx context.Context) (*LagSnapshot, error) {
+	c.log.Info("Collecting Kafka consumer group lag.")
+	return c.lag.CollectConsumerGroupLag(ctx)
+}
 
 //Personal.AI order the ending
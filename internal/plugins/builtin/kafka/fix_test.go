@@ -0,0 +1,80 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/plugins/base"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFixPlugin(client *fakeGroupClient) *kafkaPlugin {
+	p := &kafkaPlugin{offsets: client}
+	p.Plugin.Init("kafka", "0.1.0", "Kafka diagnostic plugin")
+	p.fixer = base.NewFixExecutor(logger.NewLogger("kafka-test"))
+	return p
+}
+
+func stalledIssue() *models.Issue {
+	return &models.Issue{
+		Title:    IssueTitleConsumerStalled,
+		Metadata: map[string]string{"group": "billing", "topic": "orders", "lag": "1500"},
+	}
+}
+
+func TestResetOffset_RecordsAndValidates(t *testing.T) {
+	client := &fakeGroupClient{
+		committed: map[string]map[int]int64{"billing": {0: 100, 1: 400}},
+		logEnd:    map[int]int64{0: 1100, 1: 900},
+		members:   map[string]int{"billing": 0},
+	}
+	p := newFixPlugin(client)
+	ctx := context.Background()
+
+	ok, fix := p.CanAutoFix(stalledIssue())
+	require.True(t, ok)
+	result, err := p.ExecuteFix(ctx, fix)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "0:100,1:400", result.Before)
+	assert.Equal(t, "0:1100,1:900", result.After)
+	assert.Equal(t, map[int]int64{0: 1100, 1: 900}, client.committed["billing"])
+
+	valid, msg, err := p.ValidateFix(ctx, stalledIssue(), result)
+	require.NoError(t, err)
+	assert.True(t, valid, msg)
+
+	// The recorded compensation restores the skipped backlog
+	_, err = p.ExecuteFix(ctx, &models.FixAction{ID: "undo", Command: result.Compensation, Parameters: fix.Parameters})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int64{0: 100, 1: 400}, client.committed["billing"])
+
+	valid, msg, err = p.ValidateFix(ctx, stalledIssue(), &models.FixResult{Success: true})
+	require.NoError(t, err)
+	assert.False(t, valid, msg)
+}
+
+func TestResetOffset_RejectedWhileGroupIsActive(t *testing.T) {
+	client := &fakeGroupClient{
+		committed: map[string]map[int]int64{"billing": {0: 100}},
+		logEnd:    map[int]int64{0: 1100},
+		members:   map[string]int{"billing": 1},
+	}
+	p := newFixPlugin(client)
+
+	_, fix := p.CanAutoFix(stalledIssue())
+	result, err := p.ExecuteFix(context.Background(), fix)
+	require.Error(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, map[int]int64{0: 100}, client.committed["billing"])
+
+	// Without committed offsets there is nothing to restore, so the reset is refused
+	client.committed["billing"] = map[int]int64{}
+	client.members["billing"] = 0
+	result, err = p.ExecuteFix(context.Background(), fix)
+	require.Error(t, err)
+	assert.Contains(t, result.Message, "Precondition not met")
+}
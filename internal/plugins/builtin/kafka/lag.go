@@ -0,0 +1,469 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/model"
+	"github.com/segmentio/kafka-go"
+)
+
+// groupOffsetClient is the subset of the kafka-go admin client used to compute
+// consumer group lag. *kafka.Client satisfies it; tests provide a fake.
+type groupOffsetClient interface {
+	ListGroups(ctx context.Context, req *kafka.ListGroupsRequest) (*kafka.ListGroupsResponse, error)
+	DescribeGroups(ctx context.Context, req *kafka.DescribeGroupsRequest) (*kafka.DescribeGroupsResponse, error)
+	OffsetFetch(ctx context.Context, req *kafka.OffsetFetchRequest) (*kafka.OffsetFetchResponse, error)
+	ListOffsets(ctx context.Context, req *kafka.ListOffsetsRequest) (*kafka.ListOffsetsResponse, error)
+}
+
+// GroupMember describes one member of a consumer group and its assignment.
+type GroupMember struct {
+	MemberID   string           `json:"member_id"`
+	ClientID   string           `json:"client_id"`
+	ClientHost string           `json:"client_host"`
+	Assignment map[string][]int `json:"assignment"`
+}
+
+// PartitionLag is the lag of one consumer group on one topic partition.
+type PartitionLag struct {
+	Topic     string `json:"topic"`
+	Partition int    `json:"partition"`
+	// CommittedOffset is the group's committed offset, or -1 if nothing was committed.
+	CommittedOffset int64 `json:"committed_offset"`
+	// LogEndOffset is the offset of the next message to be written to the partition.
+	LogEndOffset int64 `json:"log_end_offset"`
+	// Lag is LogEndOffset - CommittedOffset; zero when nothing was committed.
+	Lag int64 `json:"lag"`
+	// MemberID is the group member the partition is assigned to, empty if unassigned.
+	MemberID string `json:"member_id,omitempty"`
+}
+
+// ConsumerGroupLag aggregates lag information for one consumer group.
+type ConsumerGroupLag struct {
+	GroupID    string         `json:"group_id"`
+	State      string         `json:"state"`
+	Members    []GroupMember  `json:"members"`
+	Partitions []PartitionLag `json:"partitions"`
+	TotalLag   int64          `json:"total_lag"`
+}
+
+// TopicLag returns the summed lag of the group on a topic.
+func (g *ConsumerGroupLag) TopicLag(topic string) int64 {
+	var total int64
+	for _, p := range g.Partitions {
+		if p.Topic == topic {
+			total += p.Lag
+		}
+	}
+	return total
+}
+
+// LagSnapshot is the lag of every consumer group at one point in time.
+type LagSnapshot struct {
+	CollectedAt time.Time           `json:"collected_at"`
+	Groups      []*ConsumerGroupLag `json:"groups"`
+}
+
+// LagStatus classifies the lag trend of a group on a topic.
+type LagStatus string
+
+const (
+	// LagStatusOK means the group keeps up with producers.
+	LagStatusOK LagStatus = "ok"
+	// LagStatusCatchingUp means lag is positive but shrinking.
+	LagStatusCatchingUp LagStatus = "catching_up"
+	// LagStatusSlow means the group is consuming, but slower than producers write.
+	LagStatusSlow LagStatus = "slow"
+	// LagStatusStalled means the committed offsets did not move while lag is positive.
+	LagStatusStalled LagStatus = "stalled"
+	// LagStatusUnknown means there are not enough snapshots to judge the trend.
+	LagStatusUnknown LagStatus = "unknown"
+)
+
+// LagTrend describes how the lag of a group on a topic evolved across snapshots.
+type LagTrend struct {
+	GroupID string    `json:"group_id"`
+	Topic   string    `json:"topic"`
+	Lag     int64     `json:"lag"`
+	Status  LagStatus `json:"status"`
+	// LagGrowthRate is the change of lag in messages per second (negative when shrinking).
+	LagGrowthRate float64 `json:"lag_growth_rate"`
+	// ConsumeRate is the rate at which committed offsets advance, in messages per second.
+	ConsumeRate float64 `json:"consume_rate"`
+	// ProduceRate is the rate at which log-end offsets advance, in messages per second.
+	ProduceRate float64       `json:"produce_rate"`
+	Window      time.Duration `json:"window"`
+	Samples     int           `json:"samples"`
+	// ActiveMembers is the number of members in the group at the latest snapshot.
+	ActiveMembers int `json:"active_members"`
+}
+
+// LagCollector collects consumer group lag and keeps a short history of
+// snapshots to derive lag trends.
+type LagCollector struct {
+	client     groupOffsetClient
+	log        logger.Logger
+	maxHistory int
+
+	mu      sync.Mutex
+	history []*LagSnapshot
+}
+
+// defaultLagHistory is the number of snapshots kept for trend analysis.
+const defaultLagHistory = 10
+
+// minStallWindow is the shortest window over which unmoved committed offsets
+// mean a group is stalled. Consumers commit periodically (every 5s by default
+// with auto-commit, often less often with manual commits), so over a shorter
+// window a healthy group may simply not have committed yet.
+const minStallWindow = time.Minute
+
+// NewLagCollector creates a lag collector for the given bootstrap brokers.
+func NewLagCollector(brokers []string, log logger.Logger) *LagCollector {
+	return newLagCollector(newAdminClient(brokers), log)
+}
+
+// newAdminClient creates the kafka-go client used for group and offset requests.
+func newAdminClient(brokers []string) *kafka.Client {
+	return &kafka.Client{
+		Addr:    kafka.TCP(brokers...),
+		Timeout: 10 * time.Second,
+	}
+}
+
+func newLagCollector(client groupOffsetClient, log logger.Logger) *LagCollector {
+	return &LagCollector{client: client, log: log, maxHistory: defaultLagHistory}
+}
+
+// CollectConsumerGroupLag lists every consumer group, describes its members and
+// assignments, and computes per-partition lag as the log-end offset minus the
+// committed offset. The snapshot is added to the trend history.
+func (c *LagCollector) CollectConsumerGroupLag(ctx context.Context) (*LagSnapshot, error) {
+	listResp, err := c.client.ListGroups(ctx, &kafka.ListGroupsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list consumer groups: %w", err)
+	}
+	if listResp.Error != nil {
+		return nil, fmt.Errorf("failed to list consumer groups: %w", listResp.Error)
+	}
+
+	var groupIDs []string
+	for _, g := range listResp.Groups {
+		// Connect and other group types do not commit consumer offsets
+		if g.ProtocolType != "" && g.ProtocolType != "consumer" {
+			continue
+		}
+		groupIDs = append(groupIDs, g.GroupID)
+	}
+	sort.Strings(groupIDs)
+
+	snapshot := &LagSnapshot{CollectedAt: time.Now()}
+	if len(groupIDs) == 0 {
+		c.record(snapshot)
+		return snapshot, nil
+	}
+
+	descResp, err := c.client.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: groupIDs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe consumer groups: %w", err)
+	}
+
+	// Committed offsets per group and the set of partitions whose log-end offsets are needed
+	type topicPartition struct {
+		topic     string
+		partition int
+	}
+	committed := make(map[string]map[topicPartition]int64)
+	needed := make(map[topicPartition]bool)
+	groups := make([]*ConsumerGroupLag, 0, len(descResp.Groups))
+
+	for _, desc := range descResp.Groups {
+		if desc.Error != nil {
+			c.log.Warnf("Failed to describe consumer group %s: %v", desc.GroupID, desc.Error)
+			continue
+		}
+
+		group := &ConsumerGroupLag{GroupID: desc.GroupID, State: desc.GroupState}
+		assigned := make(map[topicPartition]string)
+		for _, m := range desc.Members {
+			member := GroupMember{
+				MemberID:   m.MemberID,
+				ClientID:   m.ClientID,
+				ClientHost: m.ClientHost,
+				Assignment: make(map[string][]int),
+			}
+			for _, t := range m.MemberAssignments.Topics {
+				member.Assignment[t.Topic] = append(member.Assignment[t.Topic], t.Partitions...)
+				for _, p := range t.Partitions {
+					tp := topicPartition{t.Topic, p}
+					assigned[tp] = m.MemberID
+					needed[tp] = true
+				}
+			}
+			group.Members = append(group.Members, member)
+		}
+
+		offResp, err := c.client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: desc.GroupID})
+		if err != nil {
+			c.log.Warnf("Failed to fetch committed offsets for group %s: %v", desc.GroupID, err)
+			continue
+		}
+		offsets := make(map[topicPartition]int64)
+		for topic, partitions := range offResp.Topics {
+			for _, p := range partitions {
+				if p.Error != nil {
+					continue
+				}
+				tp := topicPartition{topic, p.Partition}
+				offsets[tp] = p.CommittedOffset
+				needed[tp] = true
+			}
+		}
+		// Assigned partitions without a commit still show up with offset -1
+		for tp := range assigned {
+			if _, ok := offsets[tp]; !ok {
+				offsets[tp] = -1
+			}
+		}
+		committed[desc.GroupID] = offsets
+
+		for tp := range offsets {
+			group.Partitions = append(group.Partitions, PartitionLag{
+				Topic:           tp.topic,
+				Partition:       tp.partition,
+				CommittedOffset: offsets[tp],
+				MemberID:        assigned[tp],
+			})
+		}
+		groups = append(groups, group)
+	}
+
+	// Fetch log-end offsets for every partition in a single request
+	offsetReq := &kafka.ListOffsetsRequest{Topics: make(map[string][]kafka.OffsetRequest)}
+	for tp := range needed {
+		offsetReq.Topics[tp.topic] = append(offsetReq.Topics[tp.topic], kafka.LastOffsetOf(tp.partition))
+	}
+	logEnd := make(map[topicPartition]int64)
+	if len(offsetReq.Topics) > 0 {
+		listOffResp, err := c.client.ListOffsets(ctx, offsetReq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list log-end offsets: %w", err)
+		}
+		for topic, partitions := range listOffResp.Topics {
+			for _, p := range partitions {
+				if p.Error != nil {
+					c.log.Warnf("Failed to read log-end offset of %s-%d: %v", topic, p.Partition, p.Error)
+					continue
+				}
+				logEnd[topicPartition{topic, p.Partition}] = p.LastOffset
+			}
+		}
+	}
+
+	for _, group := range groups {
+		for i := range group.Partitions {
+			p := &group.Partitions[i]
+			p.LogEndOffset = logEnd[topicPartition{p.Topic, p.Partition}]
+			if p.CommittedOffset >= 0 && p.LogEndOffset > p.CommittedOffset {
+				p.Lag = p.LogEndOffset - p.CommittedOffset
+			}
+			group.TotalLag += p.Lag
+		}
+		sort.Slice(group.Partitions, func(i, j int) bool {
+			if group.Partitions[i].Topic != group.Partitions[j].Topic {
+				return group.Partitions[i].Topic < group.Partitions[j].Topic
+			}
+			return group.Partitions[i].Partition < group.Partitions[j].Partition
+		})
+	}
+
+	snapshot.Groups = groups
+	c.record(snapshot)
+	return snapshot, nil
+}
+
+func (c *LagCollector) record(snapshot *LagSnapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.history = append(c.history, snapshot)
+	if len(c.history) > c.maxHistory {
+		c.history = c.history[len(c.history)-c.maxHistory:]
+	}
+}
+
+// Trends derives the lag trend of every group/topic from the recorded snapshots.
+func (c *LagCollector) Trends() []LagTrend {
+	c.mu.Lock()
+	history := append([]*LagSnapshot(nil), c.history...)
+	c.mu.Unlock()
+	return ComputeLagTrends(history)
+}
+
+// groupTopicTotals sums committed offsets, log-end offsets and lag of a group on a topic.
+type groupTopicTotals struct {
+	committed, logEnd, lag int64
+	members                int
+}
+
+func totalsOf(snapshot *LagSnapshot) map[[2]string]groupTopicTotals {
+	out := make(map[[2]string]groupTopicTotals)
+	for _, g := range snapshot.Groups {
+		for _, p := range g.Partitions {
+			key := [2]string{g.GroupID, p.Topic}
+			t := out[key]
+			if p.CommittedOffset >= 0 {
+				t.committed += p.CommittedOffset
+			}
+			t.logEnd += p.LogEndOffset
+			t.lag += p.Lag
+			t.members = len(g.Members)
+			out[key] = t
+		}
+	}
+	return out
+}
+
+// ComputeLagTrends compares the oldest and newest snapshot in which each
+// group/topic appears. A group whose committed offsets did not move while lag
+// is positive for at least minStallWindow is stalled; one that consumes but
+// whose lag keeps growing is slow.
+func ComputeLagTrends(history []*LagSnapshot) []LagTrend {
+	if len(history) == 0 {
+		return nil
+	}
+	latest := history[len(history)-1]
+	latestTotals := totalsOf(latest)
+
+	keys := make([][2]string, 0, len(latestTotals))
+	for k := range latestTotals {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	trends := make([]LagTrend, 0, len(keys))
+	for _, key := range keys {
+		last := latestTotals[key]
+		trend := LagTrend{
+			GroupID:       key[0],
+			Topic:         key[1],
+			Lag:           last.lag,
+			Status:        LagStatusUnknown,
+			Samples:       1,
+			ActiveMembers: last.members,
+		}
+
+		// Oldest snapshot containing this group/topic
+		var first *groupTopicTotals
+		var firstAt time.Time
+		for _, snap := range history[:len(history)-1] {
+			if t, ok := totalsOf(snap)[key]; ok {
+				if first == nil {
+					tt := t
+					first = &tt
+					firstAt = snap.CollectedAt
+				}
+				trend.Samples++
+			}
+		}
+
+		if first != nil {
+			window := latest.CollectedAt.Sub(firstAt)
+			trend.Window = window
+			if secs := window.Seconds(); secs > 0 {
+				trend.ConsumeRate = float64(last.committed-first.committed) / secs
+				trend.ProduceRate = float64(last.logEnd-first.logEnd) / secs
+				trend.LagGrowthRate = float64(last.lag-first.lag) / secs
+				trend.Status = classifyLag(last.lag, last.committed-first.committed, trend.LagGrowthRate, window)
+			}
+		}
+		trends = append(trends, trend)
+	}
+	return trends
+}
+
+func classifyLag(lag, committedDelta int64, growthRate float64, window time.Duration) LagStatus {
+	switch {
+	case lag == 0:
+		return LagStatusOK
+	case committedDelta == 0 && window >= minStallWindow:
+		return LagStatusStalled
+	case growthRate > 0:
+		return LagStatusSlow
+	case growthRate < 0:
+		return LagStatusCatchingUp
+	}
+	return LagStatusOK
+}
+
+// LagMetricPoints converts a snapshot and its trends into monitor metric points
+// so alert rules can use them, e.g. `sum by (group) (kafka_consumer_group_lag) > 10000`.
+func LagMetricPoints(snapshot *LagSnapshot, trends []LagTrend, extraLabels map[string]string) []*model.MetricPoint {
+	labels := func(kv ...string) map[string]string {
+		out := make(map[string]string, len(extraLabels)+len(kv)/2)
+		for k, v := range extraLabels {
+			out[k] = v
+		}
+		for i := 0; i+1 < len(kv); i += 2 {
+			out[kv[i]] = kv[i+1]
+		}
+		return out
+	}
+
+	ts := snapshot.CollectedAt
+	var points []*model.MetricPoint
+	for _, g := range snapshot.Groups {
+		points = append(points,
+			&model.MetricPoint{Name: "kafka_consumer_group_members", Value: float64(len(g.Members)), Timestamp: ts, Labels: labels("group", g.GroupID)},
+			&model.MetricPoint{Name: "kafka_consumer_group_total_lag", Value: float64(g.TotalLag), Timestamp: ts, Labels: labels("group", g.GroupID)},
+		)
+		for _, p := range g.Partitions {
+			pl := labels("group", g.GroupID, "topic", p.Topic, "partition", strconv.Itoa(p.Partition))
+			points = append(points,
+				&model.MetricPoint{Name: "kafka_consumer_group_lag", Value: float64(p.Lag), Timestamp: ts, Labels: pl},
+				&model.MetricPoint{Name: "kafka_consumer_group_committed_offset", Value: float64(p.CommittedOffset), Timestamp: ts, Labels: pl},
+				&model.MetricPoint{Name: "kafka_topic_partition_log_end_offset", Value: float64(p.LogEndOffset), Timestamp: ts, Labels: pl},
+			)
+		}
+	}
+	for _, t := range trends {
+		if t.Status == LagStatusUnknown {
+			continue
+		}
+		tl := labels("group", t.GroupID, "topic", t.Topic)
+		stalled := 0.0
+		if t.Status == LagStatusStalled {
+			stalled = 1
+		}
+		points = append(points,
+			&model.MetricPoint{Name: "kafka_consumer_group_lag_growth_rate", Value: t.LagGrowthRate, Timestamp: ts, Labels: tl},
+			&model.MetricPoint{Name: "kafka_consumer_group_consume_rate", Value: t.ConsumeRate, Timestamp: ts, Labels: tl},
+			&model.MetricPoint{Name: "kafka_consumer_group_stalled", Value: stalled, Timestamp: ts, Labels: tl},
+		)
+	}
+	return points
+}
@@ -0,0 +1,167 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGroupClient serves fixed groups with mutable committed and log-end offsets.
+type fakeGroupClient struct {
+	committed map[string]map[int]int64 // group -> partition -> offset, all on topic "orders"
+	logEnd    map[int]int64
+	members   map[string]int // group -> number of members
+}
+
+func (f *fakeGroupClient) ListGroups(ctx context.Context, req *kafka.ListGroupsRequest) (*kafka.ListGroupsResponse, error) {
+	resp := &kafka.ListGroupsResponse{}
+	for g := range f.committed {
+		resp.Groups = append(resp.Groups, kafka.ListGroupsResponseGroup{GroupID: g, ProtocolType: "consumer"})
+	}
+	resp.Groups = append(resp.Groups, kafka.ListGroupsResponseGroup{GroupID: "connect-cluster", ProtocolType: "connect"})
+	return resp, nil
+}
+
+func (f *fakeGroupClient) DescribeGroups(ctx context.Context, req *kafka.DescribeGroupsRequest) (*kafka.DescribeGroupsResponse, error) {
+	resp := &kafka.DescribeGroupsResponse{}
+	for _, id := range req.GroupIDs {
+		group := kafka.DescribeGroupsResponseGroup{GroupID: id, GroupState: "Stable"}
+		if f.members[id] > 0 {
+			group.Members = append(group.Members, kafka.DescribeGroupsResponseMember{
+				MemberID: id + "-member-0",
+				ClientID: id,
+				MemberAssignments: kafka.DescribeGroupsResponseAssignments{
+					Topics: []kafka.GroupMemberTopic{{Topic: "orders", Partitions: []int{0, 1}}},
+				},
+			})
+		} else {
+			group.GroupState = "Empty"
+		}
+		resp.Groups = append(resp.Groups, group)
+	}
+	return resp, nil
+}
+
+func (f *fakeGroupClient) OffsetFetch(ctx context.Context, req *kafka.OffsetFetchRequest) (*kafka.OffsetFetchResponse, error) {
+	resp := &kafka.OffsetFetchResponse{Topics: map[string][]kafka.OffsetFetchPartition{}}
+	for p, off := range f.committed[req.GroupID] {
+		resp.Topics["orders"] = append(resp.Topics["orders"], kafka.OffsetFetchPartition{Partition: p, CommittedOffset: off})
+	}
+	return resp, nil
+}
+
+func (f *fakeGroupClient) ListOffsets(ctx context.Context, req *kafka.ListOffsetsRequest) (*kafka.ListOffsetsResponse, error) {
+	resp := &kafka.ListOffsetsResponse{Topics: map[string][]kafka.PartitionOffsets{}}
+	for topic, reqs := range req.Topics {
+		for _, r := range reqs {
+			resp.Topics[topic] = append(resp.Topics[topic], kafka.PartitionOffsets{Partition: r.Partition, LastOffset: f.logEnd[r.Partition]})
+		}
+	}
+	return resp, nil
+}
+
+// OffsetCommit moves committed offsets like a group coordinator, which only
+// accepts commits outside a generation while the group has no members.
+func (f *fakeGroupClient) OffsetCommit(ctx context.Context, req *kafka.OffsetCommitRequest) (*kafka.OffsetCommitResponse, error) {
+	resp := &kafka.OffsetCommitResponse{Topics: map[string][]kafka.OffsetCommitPartition{}}
+	for topic, commits := range req.Topics {
+		for _, c := range commits {
+			partition := kafka.OffsetCommitPartition{Partition: c.Partition}
+			if f.members[req.GroupID] > 0 {
+				partition.Error = kafka.UnknownMemberId
+			} else {
+				f.committed[req.GroupID][c.Partition] = c.Offset
+			}
+			resp.Topics[topic] = append(resp.Topics[topic], partition)
+		}
+	}
+	return resp, nil
+}
+
+func TestLagCollector_ComputesPartitionLag(t *testing.T) {
+	client := &fakeGroupClient{
+		committed: map[string]map[int]int64{"billing": {0: 90}},
+		logEnd:    map[int]int64{0: 100, 1: 50},
+		members:   map[string]int{"billing": 1},
+	}
+	c := newLagCollector(client, logger.NewLogger("kafka-test"))
+
+	snapshot, err := c.CollectConsumerGroupLag(context.Background())
+	require.NoError(t, err)
+	require.Len(t, snapshot.Groups, 1, "non-consumer groups are skipped")
+
+	g := snapshot.Groups[0]
+	assert.Equal(t, "billing", g.GroupID)
+	require.Len(t, g.Partitions, 2)
+	assert.Equal(t, PartitionLag{Topic: "orders", Partition: 0, CommittedOffset: 90, LogEndOffset: 100, Lag: 10, MemberID: "billing-member-0"}, g.Partitions[0])
+	// Assigned but never committed: no lag is reported
+	assert.Equal(t, int64(-1), g.Partitions[1].CommittedOffset)
+	assert.Equal(t, int64(0), g.Partitions[1].Lag)
+	assert.Equal(t, int64(10), g.TotalLag)
+}
+
+func TestComputeLagTrends_StalledVersusSlow(t *testing.T) {
+	client := &fakeGroupClient{
+		committed: map[string]map[int]int64{
+			"stalled": {0: 100, 1: 100},
+			"slow":    {0: 100, 1: 100},
+			"healthy": {0: 100, 1: 100},
+		},
+		logEnd:  map[int]int64{0: 100, 1: 100},
+		members: map[string]int{"stalled": 0, "slow": 1, "healthy": 1},
+	}
+	c := newLagCollector(client, logger.NewLogger("kafka-test"))
+	ctx := context.Background()
+
+	first, err := c.CollectConsumerGroupLag(ctx)
+	require.NoError(t, err)
+
+	// Producers write 2000 messages per partition; consumers progress at different speeds
+	client.logEnd = map[int]int64{0: 2100, 1: 2100}
+	client.committed["slow"] = map[int]int64{0: 600, 1: 600}
+	client.committed["healthy"] = map[int]int64{0: 2100, 1: 2100}
+	second, err := c.CollectConsumerGroupLag(ctx)
+	require.NoError(t, err)
+	// Make the window deterministic
+	second.CollectedAt = first.CollectedAt.Add(10 * time.Second)
+
+	// Ten seconds may fall between two commits of a healthy group, so no
+	// group is stalled yet
+	for _, tr := range c.Trends() {
+		assert.NotEqual(t, LagStatusStalled, tr.Status, tr.GroupID)
+	}
+	second.CollectedAt = first.CollectedAt.Add(100 * time.Second)
+
+	byGroup := make(map[string]LagTrend)
+	for _, tr := range c.Trends() {
+		byGroup[tr.GroupID] = tr
+	}
+
+	assert.Equal(t, LagStatusStalled, byGroup["stalled"].Status)
+	assert.Equal(t, 0, byGroup["stalled"].ActiveMembers)
+	assert.Equal(t, LagStatusSlow, byGroup["slow"].Status)
+	assert.InDelta(t, 30.0, byGroup["slow"].LagGrowthRate, 0.001)
+	assert.InDelta(t, 10.0, byGroup["slow"].ConsumeRate, 0.001)
+	assert.InDelta(t, 40.0, byGroup["slow"].ProduceRate, 0.001)
+	assert.Equal(t, LagStatusOK, byGroup["healthy"].Status)
+
+	issues := newAnalyzer(logger.NewLogger("kafka-test")).AnalyzeConsumerLag(c.Trends())
+	require.Len(t, issues, 2)
+	titles := map[string]string{}
+	for _, issue := range issues {
+		titles[issue.Metadata["group"]] = issue.Title
+		assert.Equal(t, "orders", issue.Metadata["topic"])
+	}
+	assert.Equal(t, map[string]string{"stalled": IssueTitleConsumerStalled, "slow": IssueTitleConsumerLag}, titles)
+
+	// Fix parameters come from the issue rather than placeholders
+	p := &kafkaPlugin{}
+	ok, fix := p.CanAutoFix(issues[0])
+	require.True(t, ok)
+	assert.Equal(t, issues[0].Metadata["group"], fix.Parameters["group"])
+}
@@ -0,0 +1,187 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// offsetCommitClient is the subset of the kafka-go admin client used to read
+// and move the committed offsets of a consumer group. *kafka.Client satisfies
+// it; tests provide a fake.
+type offsetCommitClient interface {
+	OffsetFetch(ctx context.Context, req *kafka.OffsetFetchRequest) (*kafka.OffsetFetchResponse, error)
+	ListOffsets(ctx context.Context, req *kafka.ListOffsetsRequest) (*kafka.ListOffsetsResponse, error)
+	OffsetCommit(ctx context.Context, req *kafka.OffsetCommitRequest) (*kafka.OffsetCommitResponse, error)
+}
+
+// committedOffsets returns the offsets the group committed on topic by
+// partition. Partitions without a commit are left out.
+func committedOffsets(ctx context.Context, client offsetCommitClient, group, topic string) (map[int]int64, error) {
+	resp, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: group})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets of group %s: %w", group, err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets of group %s: %w", group, resp.Error)
+	}
+	offsets := make(map[int]int64)
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("failed to fetch committed offset of %s-%d: %w", topic, p.Partition, p.Error)
+		}
+		if p.CommittedOffset >= 0 {
+			offsets[p.Partition] = p.CommittedOffset
+		}
+	}
+	return offsets, nil
+}
+
+// resetToLatest commits the log-end offset of every partition the group has
+// committed on topic, skipping the backlog, and returns the new offsets.
+func resetToLatest(ctx context.Context, client offsetCommitClient, group, topic string) (map[int]int64, error) {
+	current, err := committedOffsets(ctx, client, group, topic)
+	if err != nil {
+		return nil, err
+	}
+	if len(current) == 0 {
+		return nil, fmt.Errorf("group %s has no committed offsets on topic %s", group, topic)
+	}
+
+	latest, err := logEndOffsets(ctx, client, topic, current)
+	if err != nil {
+		return nil, err
+	}
+	return latest, commitOffsets(ctx, client, group, topic, latest)
+}
+
+// topicLag returns the summed lag of the group on topic: the log-end offset
+// minus the committed offset of every partition the group committed on.
+func topicLag(ctx context.Context, client offsetCommitClient, group, topic string) (int64, error) {
+	committed, err := committedOffsets(ctx, client, group, topic)
+	if err != nil {
+		return 0, err
+	}
+	logEnd, err := logEndOffsets(ctx, client, topic, committed)
+	if err != nil {
+		return 0, err
+	}
+	var lag int64
+	for partition, offset := range committed {
+		if logEnd[partition] > offset {
+			lag += logEnd[partition] - offset
+		}
+	}
+	return lag, nil
+}
+
+// logEndOffsets returns the log-end offset of each partition of topic that is
+// a key of partitions.
+func logEndOffsets(ctx context.Context, client offsetCommitClient, topic string, partitions map[int]int64) (map[int]int64, error) {
+	if len(partitions) == 0 {
+		return map[int]int64{}, nil
+	}
+	req := &kafka.ListOffsetsRequest{Topics: map[string][]kafka.OffsetRequest{}}
+	for _, partition := range sortedPartitions(partitions) {
+		req.Topics[topic] = append(req.Topics[topic], kafka.LastOffsetOf(partition))
+	}
+	resp, err := client.ListOffsets(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log-end offsets of topic %s: %w", topic, err)
+	}
+	logEnd := make(map[int]int64, len(partitions))
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("failed to read log-end offset of %s-%d: %w", topic, p.Partition, p.Error)
+		}
+		logEnd[p.Partition] = p.LastOffset
+	}
+	for partition := range partitions {
+		if _, ok := logEnd[partition]; !ok {
+			return nil, fmt.Errorf("no log-end offset returned for %s-%d", topic, partition)
+		}
+	}
+	return logEnd, nil
+}
+
+// commitOffsets commits offsets for the group on topic outside of a group
+// generation, as kafka-consumer-groups --reset-offsets does. The coordinator
+// rejects this while the group has active members.
+func commitOffsets(ctx context.Context, client offsetCommitClient, group, topic string, offsets map[int]int64) error {
+	commits := make([]kafka.OffsetCommit, 0, len(offsets))
+	for _, partition := range sortedPartitions(offsets) {
+		commits = append(commits, kafka.OffsetCommit{Partition: partition, Offset: offsets[partition]})
+	}
+	resp, err := client.OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      group,
+		GenerationID: -1,
+		Topics:       map[string][]kafka.OffsetCommit{topic: commits},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit offsets of group %s: %w", group, err)
+	}
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return fmt.Errorf("failed to commit offset of %s-%d for group %s: %w", topic, p.Partition, group, p.Error)
+		}
+	}
+	return nil
+}
+
+// formatOffsets renders offsets as "partition:offset,..." ordered by partition,
+// the form probes record and KAFKA_SET_OFFSETS accepts.
+func formatOffsets(offsets map[int]int64) string {
+	parts := make([]string, 0, len(offsets))
+	for _, partition := range sortedPartitions(offsets) {
+		parts = append(parts, fmt.Sprintf("%d:%d", partition, offsets[partition]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseOffsets is the inverse of formatOffsets.
+func parseOffsets(s string) (map[int]int64, error) {
+	offsets := make(map[int]int64)
+	for _, part := range strings.Split(s, ",") {
+		partition, offset, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid partition offset %q", part)
+		}
+		p, err := strconv.Atoi(partition)
+		if err != nil {
+			return nil, fmt.Errorf("invalid partition in %q: %w", part, err)
+		}
+		o, err := strconv.ParseInt(offset, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset in %q: %w", part, err)
+		}
+		offsets[p] = o
+	}
+	return offsets, nil
+}
+
+func sortedPartitions(offsets map[int]int64) []int {
+	partitions := make([]int, 0, len(offsets))
+	for partition := range offsets {
+		partitions = append(partitions, partition)
+	}
+	sort.Ints(partitions)
+	return partitions
+}
@@ -18,6 +18,8 @@ package kafka
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
@@ -28,8 +30,9 @@ import (
 )
 
 const (
-	IssueTitleConsumerLag   = "High Consumer Lag"
-	IssueTitleUnderReplicated = "Under Replicated Partitions"
+	IssueTitleConsumerLag     = "High Consumer Lag"
+	IssueTitleConsumerStalled = "Stalled Consumer Group"
+	IssueTitleUnderReplicated = "Under-Replicated Partitions Detected"
 )
 
 // kafkaPlugin is the concrete implementation of the MiddlewarePlugin for Kafka.
@@ -39,6 +42,8 @@ type kafkaPlugin struct {
 	collector *collector
 	analyzer  *analyzer
 	fixer     *base.FixExecutor
+	// offsets reads and commits consumer group offsets for fixes
+	offsets offsetCommitClient
 }
 
 func New() (interfaces.MiddlewarePlugin, error) {
@@ -51,8 +56,10 @@ func New() (interfaces.MiddlewarePlugin, error) {
 		return nil, fmt.Errorf("failed to connect to kafka broker %s: %w", brokers[0], err)
 	}
 
+	client := newAdminClient(brokers)
 	p.conn = conn
-	p.collector = newCollector(conn, p.Log)
+	p.offsets = client
+	p.collector = newCollector(conn, newLagCollector(client, p.Log), p.Log)
 	p.analyzer = newAnalyzer(p.Log)
 	p.fixer = base.NewFixExecutor(p.Log)
 
@@ -73,6 +80,14 @@ func (p *kafkaPlugin) Diagnose(ctx context.Context, _ *models.DiagnosisRequest)
 		return nil, fmt.Errorf("failed to collect kafka metadata: %w", err)
 	}
 	issues := p.analyzer.Analyze(metadata)
+
+	// Lag trends need at least two snapshots; earlier collections (e.g. from
+	// CollectMetrics) are part of the history.
+	if _, err := p.collector.CollectConsumerGroupLag(ctx); err != nil {
+		p.Log.Warnf("Skipping consumer lag analysis: %v", err)
+	} else {
+		issues = append(issues, p.analyzer.AnalyzeConsumerLag(p.collector.lag.Trends())...)
+	}
 	result := &models.DiagnosisResult{
 		ID:        fmt.Sprintf("kafka-diag-%d", time.Now().Unix()),
 		Timestamp: time.Now().UTC(),
@@ -128,41 +143,112 @@ func (p *kafkaPlugin) Shutdown() error {
 
 func (p *kafkaPlugin) CanAutoFix(issue *models.Issue) (bool, *models.FixAction) {
 	switch issue.Title {
-	case IssueTitleConsumerLag:
-		// Example fix: Reset offset to latest (Data Loss Risk! But serves as example)
-		return true, &models.FixAction{
-			ID:          "fix-kafka-reset-offset",
-			Description: "Reset consumer group offset to latest",
-			Command:     "KAFKA_RESET_OFFSET",
-			Parameters:  map[string]string{"group": "my-group", "topic": "my-topic"}, // In reality extracted from issue
+	case IssueTitleConsumerLag, IssueTitleConsumerStalled:
+		group, topic := issue.Metadata["group"], issue.Metadata["topic"]
+		if group == "" || topic == "" {
+			return false, nil
 		}
-	case IssueTitleUnderReplicated:
-		// Example fix: Trigger partition reassignment (simplified)
+		// Resetting to latest skips the backlog (data loss risk), so it is only
+		// offered for a concrete group/topic. The committed offsets are recorded
+		// first so the reset can be undone.
 		return true, &models.FixAction{
-			ID:          "fix-kafka-reassign-partition",
-			Description: "Trigger partition reassignment for under-replicated partitions",
-			Command:     "KAFKA_REASSIGN_PARTITION",
-			Parameters:  map[string]string{"topic": "my-topic", "partition": "0"},
+			ID:              "fix-kafka-reset-offset",
+			Description:     fmt.Sprintf("Reset offsets of consumer group %s on topic %s to latest", group, topic),
+			Command:         "KAFKA_RESET_OFFSET",
+			RollbackCommand: "KAFKA_SET_OFFSETS {{before}}",
+			Precondition: &models.FixProbe{
+				Command: "KAFKA_COMMITTED_OFFSETS",
+				Expect:  `value != ""`,
+			},
+			Verification: &models.FixProbe{
+				Command: "KAFKA_COMMITTED_OFFSETS",
+				Expect:  "value != before",
+			},
+			Parameters: map[string]string{"group": group, "topic": topic},
 		}
 	}
+	// Under-replicated partitions need their lagging followers brought back
+	// in sync, which is a broker problem a reassignment cannot safely fix.
 	return false, nil
 }
 
 func (p *kafkaPlugin) ExecuteFix(ctx context.Context, fix *models.FixAction) (*models.FixResult, error) {
-	return p.fixer.Execute(ctx, fix, func(ctx context.Context) error {
-		if fix.Command == "KAFKA_RESET_OFFSET" {
-			// Mock implementation as kafka-go requires more complex setup for offset reset
-			p.Log.Info("Simulating Kafka offset reset to latest.")
-			return nil
-		} else if fix.Command == "KAFKA_REASSIGN_PARTITION" {
-			// Mock implementation for partition reassignment
-			p.Log.Infof("Simulating partition reassignment for topic %s", fix.Parameters["topic"])
-			return nil
+	return p.fixer.Run(ctx, fix, func(ctx context.Context, command string) (interface{}, error) {
+		return p.command(ctx, command, fix.Parameters)
+	})
+}
+
+// command runs one offset command of a fix for the consumer group and topic
+// in params:
+//   - KAFKA_COMMITTED_OFFSETS returns the committed offsets as "partition:offset,...".
+//   - KAFKA_RESET_OFFSET commits the log-end offset of every committed partition.
+//   - KAFKA_SET_OFFSETS <partition:offset,...> commits the given offsets.
+//
+// The group's coordinator only accepts the commits while the group has no
+// active members.
+func (p *kafkaPlugin) command(ctx context.Context, command string, params map[string]string) (interface{}, error) {
+	group, topic := params["group"], params["topic"]
+	if group == "" || topic == "" {
+		return nil, fmt.Errorf("command %s needs a group and topic", command)
+	}
+	name, arg, _ := strings.Cut(command, " ")
+	switch name {
+	case "KAFKA_COMMITTED_OFFSETS":
+		offsets, err := committedOffsets(ctx, p.offsets, group, topic)
+		if err != nil {
+			return nil, err
+		}
+		return formatOffsets(offsets), nil
+	case "KAFKA_RESET_OFFSET":
+		offsets, err := resetToLatest(ctx, p.offsets, group, topic)
+		if err != nil {
+			return nil, err
 		}
-		return fmt.Errorf("unknown command: %s", fix.Command)
-	}, nil)
+		p.Log.Infof("Reset offsets of group %s on topic %s to %s", group, topic, formatOffsets(offsets))
+		return nil, nil
+	case "KAFKA_SET_OFFSETS":
+		offsets, err := parseOffsets(arg)
+		if err != nil {
+			return nil, err
+		}
+		return nil, commitOffsets(ctx, p.offsets, group, topic, offsets)
+	}
+	return nil, fmt.Errorf("unknown command: %s", command)
 }
 
 func (p *kafkaPlugin) ValidateFix(ctx context.Context, issue *models.Issue, result *models.FixResult) (bool, string, error) {
-	return true, "Assumed success", nil
+	if result != nil && !result.Success {
+		return false, "Fix did not succeed: " + result.Message, nil
+	}
+
+	switch issue.Title {
+	case IssueTitleConsumerLag, IssueTitleConsumerStalled:
+		group, topic := issue.Metadata["group"], issue.Metadata["topic"]
+		before, err := strconv.ParseInt(issue.Metadata["lag"], 10, 64)
+		if err != nil {
+			return false, "", fmt.Errorf("issue has no valid lag: %q", issue.Metadata["lag"])
+		}
+		lag, err := topicLag(ctx, p.offsets, group, topic)
+		if err != nil {
+			return false, "", fmt.Errorf("failed to read lag of group %s on topic %s: %w", group, topic, err)
+		}
+		if lag >= before {
+			return false, fmt.Sprintf("Lag of group %s on topic %s is still %d messages (was %d).", group, topic, lag, before), nil
+		}
+		return true, fmt.Sprintf("Lag of group %s on topic %s dropped from %d to %d messages.", group, topic, before, lag), nil
+
+	case IssueTitleUnderReplicated:
+		metadata, err := p.collector.CollectMetadata(ctx)
+		if err != nil {
+			return false, "", fmt.Errorf("failed to collect kafka metadata: %w", err)
+		}
+		for _, remaining := range p.analyzer.analyzePartitionHealth(metadata) {
+			if remaining.Title == IssueTitleUnderReplicated {
+				return false, remaining.Evidence, nil
+			}
+		}
+		return true, "All partitions are fully replicated.", nil
+	}
+
+	return false, "", fmt.Errorf("no validation available for issue %q", issue.Title)
 }
@@ -0,0 +1,479 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongodb
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+)
+
+// Analyzer is responsible for analyzing collected MongoDB data to identify issues.
+type Analyzer struct {
+	log logger.Logger
+}
+
+// NewAnalyzer creates a new analyzer for MongoDB data.
+func NewAnalyzer(log logger.Logger) *Analyzer {
+	return &Analyzer{log: log}
+}
+
+// Thresholds used by the evidence-based checks.
+const (
+	replicationLagLimit = 10 * time.Second
+	electionWindow      = time.Hour
+	// WiredTiger makes application threads evict pages once the cache is
+	// 95% full or 20% dirty, which stalls operations.
+	cacheUsedRatio  = 0.95
+	cacheDirtyRatio = 0.20
+	ticketUsage     = 0.9
+	connectionUsage = 0.8
+	longRunningOp   = longRunningOpSeconds * time.Second
+	// A collection scan is reported when it examines this many documents
+	// and at least collScanRatio documents per document returned.
+	collScanDocsMin = 1000
+	collScanRatio   = 100
+)
+
+// Analyze is the main entry point for the analyzer. Issues that concern a
+// specific operation or collection carry its identifiers in Issue.Metadata.
+func (a *Analyzer) Analyze(snapshot *Snapshot) []*models.Issue {
+	var issues []*models.Issue
+	a.log.Info("Analyzing collected MongoDB data.")
+
+	issues = append(issues, a.analyzeReplication(snapshot.ReplSet)...)
+	issues = append(issues, a.analyzeCache(snapshot.ServerStatus)...)
+	issues = append(issues, a.analyzeTickets(snapshot.ServerStatus)...)
+	issues = append(issues, a.analyzeConnections(snapshot.ServerStatus)...)
+	issues = append(issues, a.analyzeOperations(snapshot.Operations)...)
+	issues = append(issues, a.analyzeSlowOps(snapshot.SlowOps)...)
+
+	return issues
+}
+
+func (a *Analyzer) analyzeReplication(rs *ReplSetStatus) []*models.Issue {
+	if rs == nil {
+		return nil
+	}
+	var issues []*models.Issue
+
+	primary := rs.Primary()
+	if primary == nil {
+		issues = append(issues, &models.Issue{
+			Title:    IssueTitleNoPrimary,
+			Severity: enum.SeverityCritical,
+			Evidence: fmt.Sprintf("Replica set %s has no primary; members: %s.", rs.Set, describeMembers(rs.Members)),
+			Metadata: map[string]string{"replica_set": rs.Set},
+			Recommendations: []*models.Recommendation{{
+				Description: "Writes fail until a primary is elected. Check that a majority of voting members is up and can reach each other.",
+			}},
+		})
+	}
+
+	for _, m := range rs.Members {
+		if m.Health == 1 && isServingState(m.State) {
+			continue
+		}
+		evidence := fmt.Sprintf("Member %s of replica set %s is %s (health %.0f).", m.Name, rs.Set, m.State, m.Health)
+		if m.LastHeartbeatMessage != "" {
+			evidence += " Last heartbeat: " + m.LastHeartbeatMessage
+		}
+		issues = append(issues, &models.Issue{
+			Title:    IssueTitleMemberUnhealthy,
+			Severity: enum.SeverityHigh,
+			Evidence: evidence,
+			Metadata: map[string]string{"replica_set": rs.Set, "member": m.Name, "state": m.State},
+			Recommendations: []*models.Recommendation{{
+				Description: "Check the member's process, disk and network. A member stuck in RECOVERING may have fallen off the oplog and need an initial sync.",
+			}},
+		})
+	}
+
+	if primary == nil {
+		return issues
+	}
+	for _, m := range rs.Members {
+		lag := primary.Optime.Sub(m.Optime)
+		if m.State != "SECONDARY" || lag < replicationLagLimit {
+			continue
+		}
+		issues = append(issues, &models.Issue{
+			Title:    IssueTitleReplicationLag,
+			Severity: enum.SeverityWarning,
+			Evidence: fmt.Sprintf("Secondary %s is %s behind primary %s (syncing from %s).", m.Name, lag.Round(time.Second), primary.Name, orNone(m.SyncSource)),
+			Metadata: map[string]string{
+				"replica_set": rs.Set,
+				"member":      m.Name,
+				"lag_seconds": strconv.Itoa(int(lag.Seconds())),
+			},
+			Recommendations: []*models.Recommendation{{
+				Description: "Check disk and network throughput on the secondary and write load on the primary. Writes with w:majority wait for lagging members, and a secondary that falls past the oplog window needs an initial sync.",
+			}},
+		})
+	}
+
+	now := rs.Date
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if !primary.ElectionDate.IsZero() && now.Sub(primary.ElectionDate) < electionWindow {
+		evidence := fmt.Sprintf("%s became primary of replica set %s at %s (term %d).", primary.Name, rs.Set, primary.ElectionDate.UTC().Format(time.RFC3339), rs.Term)
+		if rs.LastElectionReason != "" {
+			evidence += " Election reason: " + rs.LastElectionReason + "."
+		}
+		issues = append(issues, &models.Issue{
+			Title:    IssueTitleElection,
+			Severity: enum.SeverityWarning,
+			Evidence: evidence,
+			Metadata: map[string]string{
+				"replica_set":   rs.Set,
+				"primary":       primary.Name,
+				"election_date": primary.ElectionDate.UTC().Format(time.RFC3339),
+				"term":          strconv.FormatInt(rs.Term, 10),
+			},
+			Recommendations: []*models.Recommendation{{
+				Description: "Elections interrupt writes. Look for heartbeat timeouts, network partitions or an overloaded former primary in the member logs around the election time.",
+			}},
+		})
+	}
+	return issues
+}
+
+func (a *Analyzer) analyzeCache(status Doc) []*models.Issue {
+	cache := status.Doc("wiredTiger", "cache")
+	maxBytes, ok := cache.Number("maximum bytes configured")
+	if !ok || maxBytes <= 0 {
+		return nil
+	}
+	used, _ := cache.Number("bytes currently in the cache")
+	dirty, _ := cache.Number("tracked dirty bytes in the cache")
+	if used/maxBytes < cacheUsedRatio && dirty/maxBytes < cacheDirtyRatio {
+		return nil
+	}
+	appEvictions, _ := cache.Number("pages evicted by application threads")
+	return []*models.Issue{{
+		Title:    IssueTitleCachePressure,
+		Severity: enum.SeverityHigh,
+		Evidence: fmt.Sprintf("WiredTiger cache is %.1f%% full and %.1f%% dirty (%d MiB configured); application threads have evicted %.0f pages.",
+			used/maxBytes*100, dirty/maxBytes*100, int64(maxBytes)>>20, appEvictions),
+		Metadata: map[string]string{
+			"cache_used_percent":  fmt.Sprintf("%.1f", used/maxBytes*100),
+			"cache_dirty_percent": fmt.Sprintf("%.1f", dirty/maxBytes*100),
+		},
+		Recommendations: []*models.Recommendation{{
+			Description: "When the cache fills up, operations stall while their threads evict pages. Reduce the working set (indexes, unindexed scans), increase storage.wiredTiger.engineConfig.cacheSizeGB or add memory, and check disk write throughput for dirty data.",
+			Fix: models.FixAction{
+				Description: "Increase the WiredTiger cache size",
+				Category:    "Configuration",
+			},
+		}},
+	}}
+}
+
+func (a *Analyzer) analyzeTickets(status Doc) []*models.Issue {
+	var issues []*models.Issue
+	for _, kind := range []string{"read", "write"} {
+		t := tickets(status, kind)
+		out, okOut := t.Number("out")
+		total, okTotal := t.Number("totalTickets")
+		available, _ := t.Number("available")
+		if !okOut || !okTotal || total <= 0 || out/total < ticketUsage {
+			continue
+		}
+		severity := enum.SeverityWarning
+		if available == 0 {
+			severity = enum.SeverityCritical
+		}
+		issues = append(issues, &models.Issue{
+			Title:    IssueTitleTicketExhaustion,
+			Severity: severity,
+			Evidence: fmt.Sprintf("%.0f of %.0f %s tickets are in use, %.0f available; further %ss queue in the storage engine.", out, total, kind, available, kind),
+			Metadata: map[string]string{"ticket_type": kind, "tickets_out": fmt.Sprintf("%.0f", out), "tickets_total": fmt.Sprintf("%.0f", total)},
+			Recommendations: []*models.Recommendation{{
+				Description: "Tickets run out when operations are slow to finish rather than when there are too few of them. Look for long-running operations, unindexed scans and cache pressure before raising the ticket count.",
+			}},
+		})
+	}
+	return issues
+}
+
+func (a *Analyzer) analyzeConnections(status Doc) []*models.Issue {
+	current, okCur := status.Number("connections", "current")
+	available, okAvail := status.Number("connections", "available")
+	if !okCur || !okAvail || current+available <= 0 {
+		return nil
+	}
+	usage := current / (current + available)
+	if usage < connectionUsage {
+		return nil
+	}
+	return []*models.Issue{{
+		Title:    IssueTitleConnSaturation,
+		Severity: enum.SeverityWarning,
+		Evidence: fmt.Sprintf("%.0f connections are open and %.0f available (%.1f%% used).", current, available, usage*100),
+		Metadata: map[string]string{"connections_current": fmt.Sprintf("%.0f", current), "connections_available": fmt.Sprintf("%.0f", available)},
+		Recommendations: []*models.Recommendation{{
+			Description: "Check the connection pool sizes (maxPoolSize) of the applications connecting to this member and look for connection leaks; raise net.maxIncomingConnections and the process file descriptor limit only if the load is expected.",
+		}},
+	}}
+}
+
+func (a *Analyzer) analyzeOperations(ops []Operation) []*models.Issue {
+	var issues []*models.Issue
+	for _, op := range ops {
+		// Only operations of client connections are reported; replication
+		// and other internal threads have no client address
+		if op.Client == "" || time.Duration(op.SecsRunning)*time.Second < longRunningOp || internalNamespace(op.Namespace) {
+			continue
+		}
+		evidence := fmt.Sprintf("Operation %s (%s on %s from %s", op.OpID, op.Op, op.Namespace, op.Client)
+		if op.AppName != "" {
+			evidence += ", app " + op.AppName
+		}
+		evidence += fmt.Sprintf(") has been running for %s", (time.Duration(op.SecsRunning) * time.Second).String())
+		if op.PlanSummary != "" {
+			evidence += " with plan " + op.PlanSummary
+		}
+		if op.WaitingLock {
+			evidence += ", waiting for a lock"
+		}
+		evidence += ". Command: " + truncate(describeDoc(op.Command), 200)
+
+		issues = append(issues, &models.Issue{
+			Title:    IssueTitleLongRunningOp,
+			Severity: enum.SeverityWarning,
+			Evidence: evidence,
+			Metadata: map[string]string{
+				"opid":         op.OpID,
+				"ns":           op.Namespace,
+				"op":           op.Op,
+				"client":       op.Client,
+				"secs_running": strconv.FormatInt(op.SecsRunning, 10),
+				"plan_summary": op.PlanSummary,
+			},
+			Recommendations: []*models.Recommendation{{
+				Description: "Long-running operations hold tickets and cache space. Kill the operation if it is not expected, and bound future queries with maxTimeMS.",
+			}},
+		})
+	}
+	return issues
+}
+
+// scanGroup aggregates the unindexed scans of one query shape
+type scanGroup struct {
+	namespace    string
+	keys         Doc
+	count        int
+	maxMillis    int64
+	docsExamined int64
+	nReturned    int64
+	example      SlowOp
+}
+
+func (a *Analyzer) analyzeSlowOps(ops []SlowOp) []*models.Issue {
+	groups := make(map[string]*scanGroup)
+	var order []string
+	for _, op := range ops {
+		if !strings.HasPrefix(op.PlanSummary, "COLLSCAN") || internalNamespace(op.Namespace) ||
+			op.DocsExamined < collScanDocsMin || op.DocsExamined < collScanRatio*max(op.NReturned, 1) {
+			continue
+		}
+		keys := suggestIndex(op.Filter, op.Sort)
+		id := op.Namespace + " " + describeDoc(keys)
+		g, ok := groups[id]
+		if !ok {
+			g = &scanGroup{namespace: op.Namespace, keys: keys, example: op}
+			groups[id] = g
+			order = append(order, id)
+		}
+		g.count++
+		g.docsExamined += op.DocsExamined
+		g.nReturned += op.NReturned
+		if op.Millis > g.maxMillis {
+			g.maxMillis = op.Millis
+			g.example = op
+		}
+	}
+
+	var issues []*models.Issue
+	for _, id := range order {
+		g := groups[id]
+		db, collection, _ := strings.Cut(g.namespace, ".")
+		metadata := map[string]string{
+			"ns":         g.namespace,
+			"database":   db,
+			"collection": collection,
+			"max_millis": strconv.FormatInt(g.maxMillis, 10),
+		}
+		recommendation := "Add an index that supports the query's filter and sort, or change the query to use an existing index."
+		if len(g.keys) > 0 {
+			metadata["index_keys"] = formatKeys(g.keys)
+			recommendation = fmt.Sprintf("Create the index %s on %s (equality fields, then sort fields, then range fields).", formatKeys(g.keys), g.namespace)
+		}
+		issues = append(issues, &models.Issue{
+			Title:    IssueTitleUnindexedScan,
+			Severity: enum.SeverityWarning,
+			Evidence: fmt.Sprintf("%d profiled %s operation(s) on %s scanned the whole collection, examining %d documents to return %d; the slowest took %dms. Filter: %s, sort: %s.",
+				g.count, g.example.Op, g.namespace, g.docsExamined, g.nReturned, g.maxMillis, describeDoc(g.example.Filter), describeDoc(g.example.Sort)),
+			Metadata: metadata,
+			Recommendations: []*models.Recommendation{{
+				Description: recommendation,
+			}},
+		})
+	}
+	return issues
+}
+
+// suggestIndex derives index keys for a query following the
+// equality-sort-range rule. Logical operators and fields that cannot be
+// indexed usefully ($exists: false, $ne, $nin, $regex) are ignored.
+func suggestIndex(filter, sortSpec Doc) Doc {
+	var equality, ranges Doc
+	seen := make(map[string]bool)
+	add := func(dst *Doc, field string, dir interface{}) {
+		if !seen[field] {
+			seen[field] = true
+			*dst = append(*dst, Elem{field, dir})
+		}
+	}
+	for _, e := range filter {
+		if strings.HasPrefix(e.Key, "$") {
+			continue
+		}
+		ops, isOps := e.Value.(Doc)
+		if !isOps || len(ops) == 0 || !strings.HasPrefix(ops[0].Key, "$") {
+			add(&equality, e.Key, int32(1))
+			continue
+		}
+		switch ops[0].Key {
+		case "$eq", "$in":
+			add(&equality, e.Key, int32(1))
+		case "$gt", "$gte", "$lt", "$lte":
+			add(&ranges, e.Key, int32(1))
+		}
+	}
+
+	keys := equality
+	for _, e := range sortSpec {
+		if dir, ok := toFloat(e.Value); ok && !seen[e.Key] {
+			seen[e.Key] = true
+			keys = append(keys, Elem{e.Key, int32(dir)})
+		}
+	}
+	return append(keys, ranges...)
+}
+
+// formatKeys renders index keys as JSON, preserving their order, e.g.
+// {"status":1,"createdAt":-1}.
+func formatKeys(keys Doc) string {
+	parts := make([]string, len(keys))
+	for i, e := range keys {
+		name, _ := json.Marshal(e.Key)
+		dir, _ := toFloat(e.Value)
+		parts[i] = fmt.Sprintf("%s:%d", name, int(dir))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// parseKeys is the inverse of formatKeys.
+func parseKeys(s string) (Doc, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("index keys must be a JSON object: %q", s)
+	}
+	var keys Doc
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid index keys %q: %w", s, err)
+		}
+		field, _ := t.(string)
+		t, err = dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid index keys %q: %w", s, err)
+		}
+		dir, _ := t.(json.Number)
+		if field == "" || (dir != "1" && dir != "-1") {
+			return nil, fmt.Errorf("invalid index key %q: %v", field, t)
+		}
+		n, _ := dir.Int64()
+		keys = append(keys, Elem{field, int32(n)})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no index keys in %q", s)
+	}
+	return keys, nil
+}
+
+// indexName returns the name MongoDB gives an index by default.
+func indexName(keys Doc) string {
+	parts := make([]string, len(keys))
+	for i, e := range keys {
+		dir, _ := toFloat(e.Value)
+		parts[i] = fmt.Sprintf("%s_%d", e.Key, int(dir))
+	}
+	return strings.Join(parts, "_")
+}
+
+func isServingState(state string) bool {
+	return state == "PRIMARY" || state == "SECONDARY" || state == "ARBITER"
+}
+
+func internalNamespace(ns string) bool {
+	db, _, _ := strings.Cut(ns, ".")
+	return db == "admin" || db == "local" || db == "config"
+}
+
+func describeMembers(members []Member) string {
+	parts := make([]string, len(members))
+	for i, m := range members {
+		parts[i] = m.Name + " " + m.State
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+func describeDoc(d Doc) string {
+	if d == nil {
+		return "{}"
+	}
+	data, err := json.Marshal(d.Map())
+	if err != nil {
+		return fmt.Sprint(d)
+	}
+	return string(data)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
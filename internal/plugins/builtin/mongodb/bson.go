@@ -0,0 +1,276 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongodb
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Elem is one field of a Doc.
+type Elem struct {
+	Key   string
+	Value interface{}
+}
+
+// Doc is a BSON document. Field order is preserved, which matters for
+// commands (the command name comes first), index keys and sort specs.
+// Replies hold plain Go values: float64, string, Doc, []interface{},
+// []byte, bool, time.Time, int32, int64, Timestamp and nil. ObjectIDs are
+// hex strings and regular expressions "/pattern/options".
+type Doc []Elem
+
+// Timestamp is a BSON timestamp, as used by the oplog.
+type Timestamp struct {
+	T uint32 // seconds since the epoch
+	I uint32 // ordinal within the second
+}
+
+// Get returns the value of a top-level field.
+func (d Doc) Get(key string) (interface{}, bool) {
+	for _, e := range d {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Lookup follows path through nested documents and returns the value it
+// ends at, or nil.
+func (d Doc) Lookup(path ...string) interface{} {
+	var cur interface{} = d
+	for _, key := range path {
+		doc, ok := cur.(Doc)
+		if !ok {
+			return nil
+		}
+		if cur, ok = doc.Get(key); !ok {
+			return nil
+		}
+	}
+	return cur
+}
+
+// Doc returns the nested document at path, or nil.
+func (d Doc) Doc(path ...string) Doc {
+	doc, _ := d.Lookup(path...).(Doc)
+	return doc
+}
+
+// String returns the string at path, or "".
+func (d Doc) String(path ...string) string {
+	s, _ := d.Lookup(path...).(string)
+	return s
+}
+
+// Number returns the number at path as a float64.
+func (d Doc) Number(path ...string) (float64, bool) {
+	return toFloat(d.Lookup(path...))
+}
+
+// Time returns the date at path, or the zero time.
+func (d Doc) Time(path ...string) time.Time {
+	t, _ := d.Lookup(path...).(time.Time)
+	return t
+}
+
+// Map converts the document, recursively, into maps for JSON output.
+func (d Doc) Map() map[string]interface{} {
+	m := make(map[string]interface{}, len(d))
+	for _, e := range d {
+		m[e.Key] = plain(e.Value)
+	}
+	return m
+}
+
+func plain(v interface{}) interface{} {
+	switch v := v.(type) {
+	case Doc:
+		return v.Map()
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = plain(item)
+		}
+		return out
+	}
+	return v
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case bool:
+		if n {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// --- Conversion to and from the driver's types ---
+
+// toBSON converts a command into the driver's ordered document.
+func toBSON(d Doc) (bson.D, error) {
+	out := make(bson.D, 0, len(d))
+	for _, e := range d {
+		v, err := toBSONValue(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", e.Key, err)
+		}
+		out = append(out, bson.E{Key: e.Key, Value: v})
+	}
+	return out, nil
+}
+
+func toBSONValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, float64, string, []byte, bool, int32, int64:
+		return v, nil
+	case int:
+		if v >= -1<<31 && v <= 1<<31-1 {
+			return int32(v), nil
+		}
+		return int64(v), nil
+	case time.Time:
+		return primitive.NewDateTimeFromTime(v), nil
+	case Timestamp:
+		return primitive.Timestamp{T: v.T, I: v.I}, nil
+	case Doc:
+		return toBSON(v)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		doc := make(Doc, 0, len(v))
+		for _, k := range keys {
+			doc = append(doc, Elem{k, v[k]})
+		}
+		return toBSON(doc)
+	case []interface{}:
+		arr := make(bson.A, len(v))
+		for i, item := range v {
+			converted, err := toBSONValue(item)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = converted
+		}
+		return arr, nil
+	case []string:
+		arr := make(bson.A, len(v))
+		for i, item := range v {
+			arr[i] = item
+		}
+		return arr, nil
+	case []Doc:
+		arr := make(bson.A, len(v))
+		for i, item := range v {
+			converted, err := toBSON(item)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = converted
+		}
+		return arr, nil
+	}
+	return nil, fmt.Errorf("cannot encode %T as BSON", value)
+}
+
+// fromBSON converts a reply into a Doc.
+func fromBSON(raw bson.Raw) (Doc, error) {
+	elems, err := raw.Elements()
+	if err != nil {
+		return nil, fmt.Errorf("invalid BSON: %w", err)
+	}
+	doc := make(Doc, 0, len(elems))
+	for _, e := range elems {
+		v, err := fromBSONValue(e.Value())
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", e.Key(), err)
+		}
+		doc = append(doc, Elem{e.Key(), v})
+	}
+	return doc, nil
+}
+
+func fromBSONValue(v bson.RawValue) (interface{}, error) {
+	switch v.Type {
+	case bsontype.Double:
+		return v.Double(), nil
+	case bsontype.String:
+		return v.StringValue(), nil
+	case bsontype.JavaScript:
+		return v.JavaScript(), nil
+	case bsontype.Symbol:
+		return v.Symbol(), nil
+	case bsontype.EmbeddedDocument:
+		return fromBSON(v.Document())
+	case bsontype.Array:
+		values, err := v.Array().Values()
+		if err != nil {
+			return nil, err
+		}
+		arr := make([]interface{}, len(values))
+		for i, item := range values {
+			if arr[i], err = fromBSONValue(item); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	case bsontype.Binary:
+		_, data := v.Binary()
+		return data, nil
+	case bsontype.ObjectID:
+		return v.ObjectID().Hex(), nil
+	case bsontype.Boolean:
+		return v.Boolean(), nil
+	case bsontype.DateTime:
+		return time.UnixMilli(v.DateTime()).UTC(), nil
+	case bsontype.Regex:
+		pattern, options := v.Regex()
+		return "/" + pattern + "/" + options, nil
+	case bsontype.DBPointer:
+		ns, _ := v.DBPointer()
+		return ns, nil
+	case bsontype.Int32:
+		return v.Int32(), nil
+	case bsontype.Timestamp:
+		t, i := v.Timestamp()
+		return Timestamp{T: t, I: i}, nil
+	case bsontype.Int64:
+		return v.Int64(), nil
+	case bsontype.Null, bsontype.Undefined, bsontype.MinKey, bsontype.MaxKey,
+		bsontype.CodeWithScope, bsontype.Decimal128: // not needed for diagnostics
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported BSON type %s", v.Type)
+}
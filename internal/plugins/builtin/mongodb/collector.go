@@ -0,0 +1,554 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+)
+
+// Collection limits.
+const (
+	// longRunningOpSeconds is the minimum age of operations read from currentOp
+	longRunningOpSeconds = 60
+	// slowOpMillis is the minimum duration of profiled operations read from system.profile
+	slowOpMillis = 100
+	// maxDatabases bounds the databases whose statistics and profiles are read
+	maxDatabases = 50
+	// maxSlowOps bounds the profiled operations read per database
+	maxSlowOps = 100
+)
+
+// Collector is responsible for gathering raw data and metrics from a MongoDB instance.
+type Collector struct {
+	client Commander
+	log    logger.Logger
+}
+
+// NewCollector creates a new data collector for MongoDB.
+func NewCollector(client Commander, log logger.Logger) *Collector {
+	return &Collector{client: client, log: log}
+}
+
+// Member is a replica set member from replSetGetStatus.
+type Member struct {
+	Name   string
+	State  string
+	Health float64
+	Self   bool
+	// Optime is the time of the last operation the member applied.
+	Optime time.Time
+	// ElectionDate is set on the primary.
+	ElectionDate         time.Time
+	SyncSource           string
+	LastHeartbeatMessage string
+}
+
+// ReplSetStatus is the state of the replica set as seen by the connected member.
+type ReplSetStatus struct {
+	Set  string
+	Term int64
+	// Date is the member's clock when it reported the status.
+	Date    time.Time
+	Members []Member
+	// LastElectionReason is reported by the member that won the last election.
+	LastElectionReason string
+}
+
+// Primary returns the primary member, or nil if the set has none.
+func (s *ReplSetStatus) Primary() *Member {
+	for i := range s.Members {
+		if s.Members[i].State == "PRIMARY" {
+			return &s.Members[i]
+		}
+	}
+	return nil
+}
+
+// DBStats holds the size statistics of a database from dbStats.
+type DBStats struct {
+	Name        string
+	Collections int64
+	Objects     int64
+	DataSize    int64
+	StorageSize int64
+	IndexSize   int64
+}
+
+// Operation is an in-progress operation from currentOp.
+type Operation struct {
+	// OpID is a number on mongod and "shard:number" on mongos.
+	OpID        string
+	Op          string
+	Namespace   string
+	Client      string
+	AppName     string
+	Desc        string
+	SecsRunning int64
+	PlanSummary string
+	Command     Doc
+	WaitingLock bool
+}
+
+// SlowOp is a profiled operation from system.profile.
+type SlowOp struct {
+	Op           string
+	Namespace    string
+	Millis       int64
+	PlanSummary  string
+	DocsExamined int64
+	KeysExamined int64
+	NReturned    int64
+	Filter       Doc
+	Sort         Doc
+	Time         time.Time
+}
+
+// Snapshot is the evidence gathered from a MongoDB instance in one diagnosis run.
+type Snapshot struct {
+	ServerStatus Doc
+	Metrics      map[string]interface{}
+	// ReplSet is nil when the server is not a replica set member.
+	ReplSet    *ReplSetStatus
+	Databases  []DBStats
+	Operations []Operation
+	SlowOps    []SlowOp
+}
+
+// CollectSnapshot gathers serverStatus and the per-object evidence used by
+// the analyzer. Only a failing serverStatus fails the snapshot; the other
+// commands may need privileges the monitoring user lacks, or profiling may
+// be disabled, so their failures are logged and skipped.
+func (c *Collector) CollectSnapshot(ctx context.Context) (*Snapshot, error) {
+	status, err := c.CollectServerStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{ServerStatus: status}
+
+	if snapshot.ReplSet, err = c.CollectReplSetStatus(ctx); err != nil {
+		c.log.Warnf("Failed to run replSetGetStatus: %v", err)
+	}
+	if snapshot.Databases, err = c.CollectDBStats(ctx); err != nil {
+		c.log.Warnf("Failed to collect dbStats: %v", err)
+	}
+	if snapshot.Operations, err = c.CollectCurrentOps(ctx, longRunningOpSeconds); err != nil {
+		c.log.Warnf("Failed to run currentOp: %v", err)
+	}
+	for _, db := range snapshot.Databases {
+		ops, err := c.CollectSlowOps(ctx, db.Name)
+		if err != nil {
+			c.log.Debugf("Failed to read the profiler of %s: %v", db.Name, err)
+			continue
+		}
+		snapshot.SlowOps = append(snapshot.SlowOps, ops...)
+	}
+	snapshot.Metrics = deriveMetrics(status, snapshot.ReplSet, snapshot.Databases)
+	return snapshot, nil
+}
+
+// CollectServerStatus runs serverStatus.
+func (c *Collector) CollectServerStatus(ctx context.Context) (Doc, error) {
+	c.log.Info("Collecting MongoDB serverStatus.")
+	status, err := c.client.RunCommand(ctx, "admin", Doc{{"serverStatus", 1}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run serverStatus: %w", err)
+	}
+	return status, nil
+}
+
+// CollectReplSetStatus runs replSetGetStatus. It returns nil without an
+// error when the server does not run as a replica set member.
+func (c *Collector) CollectReplSetStatus(ctx context.Context) (*ReplSetStatus, error) {
+	reply, err := c.client.RunCommand(ctx, "admin", Doc{{"replSetGetStatus", 1}})
+	if hasCode(err, codeNoReplicationEnabled, codeNotYetInitialized) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ReplSetStatus{
+		Set:                reply.String("set"),
+		Date:               reply.Time("date"),
+		LastElectionReason: reply.String("electionCandidateMetrics", "lastElectionReason"),
+	}
+	if term, ok := reply.Number("term"); ok {
+		status.Term = int64(term)
+	}
+	members, _ := reply.Lookup("members").([]interface{})
+	for _, item := range members {
+		m, ok := item.(Doc)
+		if !ok {
+			continue
+		}
+		member := Member{
+			Name:                 m.String("name"),
+			State:                m.String("stateStr"),
+			Optime:               m.Time("optimeDate"),
+			ElectionDate:         m.Time("electionDate"),
+			SyncSource:           m.String("syncSourceHost"),
+			LastHeartbeatMessage: m.String("lastHeartbeatMessage"),
+		}
+		member.Health, _ = m.Number("health")
+		member.Self, _ = m.Lookup("self").(bool)
+		status.Members = append(status.Members, member)
+	}
+	return status, nil
+}
+
+// CollectDBStats runs dbStats on each database, largest first.
+func (c *Collector) CollectDBStats(ctx context.Context) ([]DBStats, error) {
+	reply, err := c.client.RunCommand(ctx, "admin", Doc{{"listDatabases", 1}, {"nameOnly", true}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list databases: %w", err)
+	}
+	dbs, _ := reply.Lookup("databases").([]interface{})
+
+	var stats []DBStats
+	for _, item := range dbs {
+		if len(stats) >= maxDatabases {
+			c.log.Warnf("Reading statistics of the first %d databases only.", maxDatabases)
+			break
+		}
+		d, _ := item.(Doc)
+		name := d.String("name")
+		if name == "" || name == "local" || name == "config" {
+			continue
+		}
+		s, err := c.client.RunCommand(ctx, name, Doc{{"dbStats", 1}})
+		if err != nil {
+			c.log.Warnf("Failed to run dbStats on %s: %v", name, err)
+			continue
+		}
+		stats = append(stats, DBStats{
+			Name:        name,
+			Collections: docInt(s, "collections"),
+			Objects:     docInt(s, "objects"),
+			DataSize:    docInt(s, "dataSize"),
+			StorageSize: docInt(s, "storageSize"),
+			IndexSize:   docInt(s, "indexSize"),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].DataSize > stats[j].DataSize })
+	return stats, nil
+}
+
+// CollectCurrentOps lists active client operations running for at least
+// minSeconds, longest first.
+func (c *Collector) CollectCurrentOps(ctx context.Context, minSeconds int) ([]Operation, error) {
+	return c.currentOps(ctx, Doc{
+		{"active", true},
+		{"secs_running", Doc{{"$gte", minSeconds}}},
+	})
+}
+
+// GetOperation returns the in-progress operation with the given id, or nil
+// if it no longer runs.
+func (c *Collector) GetOperation(ctx context.Context, opID string) (*Operation, error) {
+	ops, err := c.currentOps(ctx, Doc{{"opid", opIDValue(opID)}})
+	if err != nil || len(ops) == 0 {
+		return nil, err
+	}
+	return &ops[0], nil
+}
+
+func (c *Collector) currentOps(ctx context.Context, filter Doc) ([]Operation, error) {
+	reply, err := c.client.RunCommand(ctx, "admin", append(Doc{{"currentOp", 1}}, filter...))
+	if err != nil {
+		return nil, err
+	}
+	items, _ := reply.Lookup("inprog").([]interface{})
+
+	var ops []Operation
+	for _, item := range items {
+		d, ok := item.(Doc)
+		if !ok {
+			continue
+		}
+		op := Operation{
+			OpID:        fmt.Sprint(d.Lookup("opid")),
+			Op:          d.String("op"),
+			Namespace:   d.String("ns"),
+			Client:      d.String("client"),
+			AppName:     d.String("appName"),
+			Desc:        d.String("desc"),
+			SecsRunning: docInt(d, "secs_running"),
+			PlanSummary: d.String("planSummary"),
+			Command:     d.Doc("command"),
+		}
+		op.WaitingLock, _ = d.Lookup("waitingForLock").(bool)
+		if op.Client == "" {
+			op.Client = d.String("client_s") // mongos
+		}
+		ops = append(ops, op)
+	}
+	sort.SliceStable(ops, func(i, j int) bool { return ops[i].SecsRunning > ops[j].SecsRunning })
+	return ops, nil
+}
+
+// CollectSlowOps reads the most recent operations of a database the
+// profiler recorded as slower than slowOpMillis. Profiling must be enabled
+// on the database (db.setProfilingLevel(1)).
+func (c *Collector) CollectSlowOps(ctx context.Context, db string) ([]SlowOp, error) {
+	reply, err := c.client.RunCommand(ctx, db, Doc{
+		{"find", "system.profile"},
+		{"filter", Doc{{"millis", Doc{{"$gte", slowOpMillis}}}}},
+		{"sort", Doc{{"ts", -1}}},
+		{"limit", maxSlowOps},
+	})
+	if err != nil {
+		return nil, err
+	}
+	items, _ := reply.Lookup("cursor", "firstBatch").([]interface{})
+
+	var ops []SlowOp
+	for _, item := range items {
+		d, ok := item.(Doc)
+		if !ok {
+			continue
+		}
+		op := SlowOp{
+			Op:           d.String("op"),
+			Namespace:    d.String("ns"),
+			Millis:       docInt(d, "millis"),
+			PlanSummary:  d.String("planSummary"),
+			DocsExamined: docInt(d, "docsExamined"),
+			KeysExamined: docInt(d, "keysExamined"),
+			NReturned:    docInt(d, "nreturned"),
+			Time:         d.Time("ts"),
+		}
+		// find, count, delete and update record their predicate differently
+		cmd := d.Doc("command")
+		for _, path := range [][]string{{"filter"}, {"query"}, {"q"}} {
+			if f := cmd.Doc(path...); f != nil {
+				op.Filter = f
+				break
+			}
+		}
+		op.Sort = cmd.Doc("sort")
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// IndexExists reports whether a collection has an index on exactly keys.
+func (c *Collector) IndexExists(ctx context.Context, db, collection string, keys Doc) (bool, error) {
+	reply, err := c.client.RunCommand(ctx, db, Doc{{"listIndexes", collection}})
+	if err != nil {
+		return false, err
+	}
+	items, _ := reply.Lookup("cursor", "firstBatch").([]interface{})
+	for _, item := range items {
+		if d, ok := item.(Doc); ok && sameKeys(d.Doc("key"), keys) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func sameKeys(a, b Doc) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		x, _ := toFloat(a[i].Value)
+		y, _ := toFloat(b[i].Value)
+		if a[i].Key != b[i].Key || x != y {
+			return false
+		}
+	}
+	return true
+}
+
+// CollectMetrics derives a standardized set of key performance indicators
+// from serverStatus and, when available, the replica set status.
+func (c *Collector) CollectMetrics(ctx context.Context) (*models.MetricsData, error) {
+	status, err := c.CollectServerStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	replSet, err := c.CollectReplSetStatus(ctx)
+	if err != nil {
+		c.log.Warnf("Failed to run replSetGetStatus: %v", err)
+	}
+	return &models.MetricsData{Data: deriveMetrics(status, replSet, nil)}, nil
+}
+
+// ticketPaths locate the storage engine admission tickets: MongoDB 7.0
+// moved them from wiredTiger.concurrentTransactions to queues.execution.
+var ticketPaths = [][]string{{"queues", "execution"}, {"wiredTiger", "concurrentTransactions"}}
+
+func tickets(status Doc, kind string) Doc {
+	for _, path := range ticketPaths {
+		if d := status.Doc(append(path, kind)...); d != nil {
+			return d
+		}
+	}
+	return nil
+}
+
+func deriveMetrics(status Doc, replSet *ReplSetStatus, dbs []DBStats) map[string]interface{} {
+	metrics := make(map[string]interface{})
+	set := func(name string, path ...string) (float64, bool) {
+		v, ok := status.Number(path...)
+		if ok {
+			metrics[name] = v
+		}
+		return v, ok
+	}
+
+	set("uptime_seconds", "uptime")
+	current, okCur := set("connections_current", "connections", "current")
+	available, okAvail := set("connections_available", "connections", "available")
+	if okCur && okAvail && current+available > 0 {
+		metrics["connection_usage_percent"] = current / (current + available) * 100
+	}
+	for _, op := range []string{"insert", "query", "update", "delete", "getmore", "command"} {
+		set("opcounters_"+op, "opcounters", op)
+	}
+	set("queued_readers", "globalLock", "currentQueue", "readers")
+	set("queued_writers", "globalLock", "currentQueue", "writers")
+	set("collection_scans", "metrics", "queryExecutor", "collectionScans", "total")
+	set("scanned_objects", "metrics", "queryExecutor", "scannedObjects")
+
+	used, okUsed := set("cache_used_bytes", "wiredTiger", "cache", "bytes currently in the cache")
+	maxBytes, okMax := set("cache_max_bytes", "wiredTiger", "cache", "maximum bytes configured")
+	dirty, okDirty := set("cache_dirty_bytes", "wiredTiger", "cache", "tracked dirty bytes in the cache")
+	set("cache_app_thread_evictions", "wiredTiger", "cache", "pages evicted by application threads")
+	if okMax && maxBytes > 0 {
+		if okUsed {
+			metrics["cache_usage_percent"] = used / maxBytes * 100
+		}
+		if okDirty {
+			metrics["cache_dirty_percent"] = dirty / maxBytes * 100
+		}
+	}
+
+	for _, kind := range []string{"read", "write"} {
+		t := tickets(status, kind)
+		if t == nil {
+			continue
+		}
+		if v, ok := t.Number("out"); ok {
+			metrics[kind+"_tickets_out"] = v
+		}
+		if v, ok := t.Number("available"); ok {
+			metrics[kind+"_tickets_available"] = v
+		}
+	}
+
+	if replSet != nil {
+		metrics["repl_members"] = float64(len(replSet.Members))
+		metrics["repl_term"] = float64(replSet.Term)
+		if primary := replSet.Primary(); primary != nil {
+			var maxLag time.Duration
+			for _, m := range replSet.Members {
+				if m.State == "SECONDARY" && primary.Optime.Sub(m.Optime) > maxLag {
+					maxLag = primary.Optime.Sub(m.Optime)
+				}
+			}
+			metrics["repl_lag_seconds_max"] = maxLag.Seconds()
+		}
+	}
+
+	if len(dbs) > 0 {
+		var data, index float64
+		for _, db := range dbs {
+			data += float64(db.DataSize)
+			index += float64(db.IndexSize)
+		}
+		metrics["data_size_bytes"] = data
+		metrics["index_size_bytes"] = index
+	}
+	return metrics
+}
+
+// CollectConfig returns the server's startup options from getCmdLineOpts,
+// flattened to dotted keys such as "storage.wiredTiger.engineConfig.cacheSizeGB".
+func (c *Collector) CollectConfig(ctx context.Context) (*models.ConfigData, error) {
+	c.log.Info("Collecting MongoDB startup options.")
+	reply, err := c.client.RunCommand(ctx, "admin", Doc{{"getCmdLineOpts", 1}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run getCmdLineOpts: %w", err)
+	}
+	settings := make(map[string]string)
+	flatten(settings, "", reply.Doc("parsed"))
+	return &models.ConfigData{Data: settings}, nil
+}
+
+func flatten(out map[string]string, prefix string, d Doc) {
+	for _, e := range d {
+		key := e.Key
+		if prefix != "" {
+			key = prefix + "." + e.Key
+		}
+		if nested, ok := e.Value.(Doc); ok {
+			flatten(out, key, nested)
+			continue
+		}
+		out[key] = fmt.Sprint(plain(e.Value))
+	}
+}
+
+// CollectLogs returns the most recent lines of the server's in-memory log.
+func (c *Collector) CollectLogs(ctx context.Context, tail int) ([]string, error) {
+	if tail <= 0 {
+		tail = 100
+	}
+	reply, err := c.client.RunCommand(ctx, "admin", Doc{{"getLog", "global"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run getLog: %w", err)
+	}
+	items, _ := reply.Lookup("log").([]interface{})
+	if len(items) > tail {
+		items = items[len(items)-tail:]
+	}
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, strings.TrimRight(fmt.Sprint(item), "\n"))
+	}
+	return lines, nil
+}
+
+// Ping runs the ping command.
+func (c *Collector) Ping(ctx context.Context) error {
+	_, err := c.client.RunCommand(ctx, "admin", Doc{{"ping", 1}})
+	return err
+}
+
+func docInt(d Doc, path ...string) int64 {
+	v, _ := d.Number(path...)
+	return int64(v)
+}
+
+// opIDValue converts an operation id back to the type currentOp reported:
+// a number on mongod and a "shard:number" string on mongos.
+func opIDValue(opID string) interface{} {
+	if n, err := strconv.ParseInt(opID, 10, 64); err == nil {
+		if n >= -1<<31 && n < 1<<31 {
+			return int32(n)
+		}
+		return n
+	}
+	return opID
+}
@@ -0,0 +1,146 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Commander runs a database command and returns its reply.
+type Commander interface {
+	RunCommand(ctx context.Context, db string, cmd Doc) (Doc, error)
+}
+
+// CommandError is a command the server answered with ok: 0.
+type CommandError struct {
+	Code     int
+	CodeName string
+	Message  string
+}
+
+func (e *CommandError) Error() string {
+	if e.CodeName != "" {
+		return fmt.Sprintf("%s (%s)", e.Message, e.CodeName)
+	}
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// Server error codes the plugin handles.
+const (
+	codeNoReplicationEnabled = 76
+	codeNotYetInitialized    = 94
+)
+
+func hasCode(err error, codes ...int) bool {
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+	for _, code := range codes {
+		if cmdErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Client runs commands through the official MongoDB driver, so every
+// connection string the driver accepts works, including mongodb+srv seed
+// lists, TLS options and all authentication mechanisms. Commands go to the
+// server chosen by the URI's read preference (the primary by default); add
+// directConnection=true to diagnose one replica set member. The driver is
+// started on first use, which is also when SRV records are resolved.
+type Client struct {
+	uri string
+
+	mu     sync.Mutex
+	client *mongo.Client
+}
+
+// NewClient checks a mongodb:// or mongodb+srv:// connection string.
+// mongodb:// strings are fully validated here; mongodb+srv:// ones need a
+// DNS lookup and are validated when the client first connects.
+func NewClient(uri string) (*Client, error) {
+	switch {
+	case strings.HasPrefix(uri, "mongodb+srv://"):
+	case strings.HasPrefix(uri, "mongodb://"):
+		if err := options.Client().ApplyURI(uri).Validate(); err != nil {
+			return nil, fmt.Errorf("invalid MongoDB URI: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid MongoDB URI %q", uri)
+	}
+	return &Client{uri: uri}, nil
+}
+
+// RunCommand runs cmd against database db.
+func (c *Client) RunCommand(ctx context.Context, db string, cmd Doc) (Doc, error) {
+	client, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	command, err := toBSON(cmd)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := client.Database(db).RunCommand(ctx, command).Raw()
+	if err != nil {
+		return nil, commandError(err)
+	}
+	return fromBSON(raw)
+}
+
+// Close disconnects the driver.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client == nil {
+		return nil
+	}
+	err := c.client.Disconnect(context.Background())
+	c.client = nil
+	return err
+}
+
+func (c *Client) connect(ctx context.Context) (*mongo.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client != nil {
+		return c.client, nil
+	}
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(c.uri))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+	c.client = client
+	return client, nil
+}
+
+// commandError converts a server error reply into a *CommandError so callers
+// can match codes with hasCode. Other errors are returned unchanged.
+func commandError(err error) error {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return &CommandError{Code: int(cmdErr.Code), CodeName: cmdErr.Name, Message: cmdErr.Message}
+	}
+	return err
+}
@@ -0,0 +1,271 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/plugins/base"
+)
+
+const (
+	IssueTitleReplicationLag   = "High Replication Lag"
+	IssueTitleMemberUnhealthy  = "Unhealthy Replica Set Member"
+	IssueTitleNoPrimary        = "Replica Set Without Primary"
+	IssueTitleElection         = "Recent Primary Election"
+	IssueTitleCachePressure    = "WiredTiger Cache Pressure"
+	IssueTitleTicketExhaustion = "WiredTiger Ticket Exhaustion"
+	IssueTitleUnindexedScan    = "Unindexed Collection Scan"
+	IssueTitleLongRunningOp    = "Long Running Operation"
+	IssueTitleConnSaturation   = "Connection Saturation"
+)
+
+// DefaultURI is the server the plugin connects to unless configured otherwise.
+const DefaultURI = "mongodb://localhost:27017"
+
+type mongoPlugin struct {
+	base.Plugin
+	client    Commander
+	config    *config.PluginConfig
+	collector *Collector
+	analyzer  *Analyzer
+	fixer     *base.FixExecutor
+}
+
+func New() (interfaces.DiagnosticPlugin, error) {
+	return NewFromURI(DefaultURI)
+}
+
+// NewFromURI creates the plugin for a mongodb:// or mongodb+srv:// connection
+// string. The connection is opened on first use.
+func NewFromURI(uri string) (interfaces.DiagnosticPlugin, error) {
+	p := &mongoPlugin{}
+	p.Plugin.Init("mongodb", "1.0.0", "MongoDB diagnostic plugin")
+
+	client, err := NewClient(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mongodb uri: %w", err)
+	}
+
+	p.setClient(client)
+	return p, nil
+}
+
+func (p *mongoPlugin) setClient(client Commander) {
+	p.client = client
+	p.collector = NewCollector(client, p.Log)
+	p.analyzer = NewAnalyzer(p.Log)
+	p.fixer = base.NewFixExecutor(p.Log)
+}
+
+func (p *mongoPlugin) SupportedTypes() []enum.MiddlewareType {
+	return []enum.MiddlewareType{enum.MongoDB}
+}
+
+func (p *mongoPlugin) SupportedVersions() []string {
+	return []string{"4.4", "5.0", "6.0", "7.0"}
+}
+
+// Init implements the DiagnosticPlugin interface
+func (p *mongoPlugin) Init(cfg *config.PluginConfig) error {
+	p.config = cfg
+	return nil
+}
+
+func (p *mongoPlugin) Shutdown() error {
+	if c, ok := p.client.(*Client); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (p *mongoPlugin) Diagnose(ctx context.Context, _ *models.DiagnosisRequest) (*models.DiagnosisResult, error) {
+	snapshot, err := p.collector.CollectSnapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect mongodb data: %w", err)
+	}
+	issues := p.analyzer.Analyze(snapshot)
+	return &models.DiagnosisResult{
+		ID:        fmt.Sprintf("mongo-diag-%d", time.Now().Unix()),
+		Timestamp: time.Now().UTC(),
+		Summary:   fmt.Sprintf("MongoDB diagnosis complete. Found %d potential issues.", len(issues)),
+		Issues:    issues,
+	}, nil
+}
+
+func (p *mongoPlugin) CollectMetrics(ctx context.Context, target string) (*models.MetricsData, error) {
+	return p.collector.CollectMetrics(ctx)
+}
+
+func (p *mongoPlugin) CollectLogs(ctx context.Context, target string, opts *models.LogOptions) (*models.LogData, error) {
+	tail := 0
+	if opts != nil {
+		tail = opts.Tail
+	}
+	entries, err := p.collector.CollectLogs(ctx, tail)
+	if err != nil {
+		return nil, err
+	}
+	return &models.LogData{Entries: entries}, nil
+}
+
+func (p *mongoPlugin) CollectConfig(ctx context.Context, target string) (*models.ConfigData, error) {
+	return p.collector.CollectConfig(ctx)
+}
+
+func (p *mongoPlugin) HealthCheck(ctx context.Context, target string) (*models.HealthStatus, error) {
+	if err := p.Ping(ctx, target); err != nil {
+		return &models.HealthStatus{IsHealthy: false, Message: err.Error()}, nil
+	}
+	return &models.HealthStatus{IsHealthy: true}, nil
+}
+
+func (p *mongoPlugin) Ping(ctx context.Context, target string) error {
+	return p.collector.Ping(ctx)
+}
+
+// --- Fix Capabilities ---
+
+// CanAutoFix only offers fixes for issues that identify their target in
+// Issue.Metadata: killing a specific operation, or building the index
+// suggested for an unindexed scan.
+func (p *mongoPlugin) CanAutoFix(issue *models.Issue) (bool, *models.FixAction) {
+	switch issue.Title {
+	case IssueTitleLongRunningOp:
+		opID := issue.Metadata["opid"]
+		if opID == "" {
+			return false, nil
+		}
+		return true, &models.FixAction{
+			ID:          "fix-mongo-kill-op",
+			Description: fmt.Sprintf("Kill operation %s on %s", opID, issue.Metadata["ns"]),
+			Command:     "KILL_OP",
+			Parameters:  map[string]string{"opid": opID, "ns": issue.Metadata["ns"]},
+			Category:    "Operation",
+		}
+	case IssueTitleUnindexedScan:
+		db, collection, keys := issue.Metadata["database"], issue.Metadata["collection"], issue.Metadata["index_keys"]
+		parsed, err := parseKeys(keys)
+		if db == "" || collection == "" || err != nil {
+			return false, nil
+		}
+		return true, &models.FixAction{
+			ID:          "fix-mongo-create-index",
+			Description: fmt.Sprintf("Create index %s on %s.%s", keys, db, collection),
+			Command:     "CREATE_INDEX",
+			Parameters: map[string]string{
+				"database":   db,
+				"collection": collection,
+				"keys":       keys,
+				"name":       indexName(parsed),
+			},
+			Category: "Query",
+		}
+	}
+	return false, nil
+}
+
+func (p *mongoPlugin) ExecuteFix(ctx context.Context, fix *models.FixAction) (*models.FixResult, error) {
+	return p.fixer.Execute(ctx, fix, func(ctx context.Context) error {
+		switch fix.Command {
+		case "KILL_OP":
+			opID := fix.Parameters["opid"]
+			if opID == "" {
+				return fmt.Errorf("missing opid parameter")
+			}
+			// Operation ids are reused after a restart; only kill the
+			// operation if it still runs on the namespace it was reported on
+			op, err := p.collector.GetOperation(ctx, opID)
+			if err != nil {
+				return fmt.Errorf("failed to look up operation %s: %w", opID, err)
+			}
+			if op == nil {
+				p.Log.Infof("Operation %s already finished.", opID)
+				return nil
+			}
+			if ns := fix.Parameters["ns"]; ns != "" && op.Namespace != ns {
+				return fmt.Errorf("operation %s now runs on %s, not %s; not killing it", opID, op.Namespace, ns)
+			}
+			_, err = p.client.RunCommand(ctx, "admin", Doc{{"killOp", 1}, {"op", opIDValue(opID)}})
+			return err
+		case "CREATE_INDEX":
+			db, collection := fix.Parameters["database"], fix.Parameters["collection"]
+			if db == "" || collection == "" {
+				return fmt.Errorf("missing database or collection parameter")
+			}
+			keys, err := parseKeys(fix.Parameters["keys"])
+			if err != nil {
+				return err
+			}
+			name := fix.Parameters["name"]
+			if name == "" {
+				name = indexName(keys)
+			}
+			_, err = p.client.RunCommand(ctx, db, Doc{
+				{"createIndexes", collection},
+				{"indexes", []Doc{{{"key", keys}, {"name", name}}}},
+			})
+			return err
+		}
+		return fmt.Errorf("unknown command: %s", fix.Command)
+	}, nil)
+}
+
+// ValidateFix re-queries the server: a killed operation must no longer be
+// listed by currentOp, and a suggested index must now exist.
+func (p *mongoPlugin) ValidateFix(ctx context.Context, issue *models.Issue, result *models.FixResult) (bool, string, error) {
+	if result != nil && !result.Success {
+		return false, "Fix did not succeed: " + result.Message, nil
+	}
+
+	switch issue.Title {
+	case IssueTitleLongRunningOp:
+		opID := issue.Metadata["opid"]
+		if opID == "" {
+			return false, "", fmt.Errorf("issue has no opid")
+		}
+		op, err := p.collector.GetOperation(ctx, opID)
+		if err != nil {
+			return false, "", fmt.Errorf("failed to query operation %s: %w", opID, err)
+		}
+		if op != nil && op.Namespace == issue.Metadata["ns"] {
+			return false, fmt.Sprintf("Operation %s is still running after %ds.", opID, op.SecsRunning), nil
+		}
+		return true, fmt.Sprintf("Operation %s is gone.", opID), nil
+
+	case IssueTitleUnindexedScan:
+		db, collection := issue.Metadata["database"], issue.Metadata["collection"]
+		keys, err := parseKeys(issue.Metadata["index_keys"])
+		if err != nil {
+			return false, "", err
+		}
+		exists, err := p.collector.IndexExists(ctx, db, collection, keys)
+		if err != nil {
+			return false, "", fmt.Errorf("failed to list indexes of %s.%s: %w", db, collection, err)
+		}
+		if !exists {
+			return false, fmt.Sprintf("%s.%s has no index on %s.", db, collection, issue.Metadata["index_keys"]), nil
+		}
+		return true, fmt.Sprintf("%s.%s has an index on %s.", db, collection, issue.Metadata["index_keys"]), nil
+	}
+
+	return false, "", fmt.Errorf("no validation available for issue %q", issue.Title)
+}
@@ -0,0 +1,295 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer answers commands by name and records the commands it ran.
+type fakeServer struct {
+	handlers map[string]func(db string, cmd Doc) (Doc, error)
+	ran      []Doc
+}
+
+func (f *fakeServer) RunCommand(ctx context.Context, db string, cmd Doc) (Doc, error) {
+	f.ran = append(f.ran, cmd)
+	if h, ok := f.handlers[cmd[0].Key]; ok {
+		return h(db, cmd)
+	}
+	return nil, &CommandError{Code: 59, CodeName: "CommandNotFound", Message: "no such command: " + cmd[0].Key}
+}
+
+func (f *fakeServer) reply(name string, reply Doc) {
+	f.handlers[name] = func(string, Doc) (Doc, error) { return reply, nil }
+}
+
+func (f *fakeServer) commands(name string) []Doc {
+	var cmds []Doc
+	for _, cmd := range f.ran {
+		if cmd[0].Key == name {
+			cmds = append(cmds, cmd)
+		}
+	}
+	return cmds
+}
+
+func newFakePlugin(t *testing.T) (*mongoPlugin, *fakeServer) {
+	server := &fakeServer{handlers: make(map[string]func(string, Doc) (Doc, error))}
+	server.reply("serverStatus", Doc{{"ok", 1.0}})
+	server.reply("listDatabases", Doc{{"databases", []interface{}{}}})
+	server.reply("currentOp", Doc{{"inprog", []interface{}{}}})
+	server.handlers["replSetGetStatus"] = func(string, Doc) (Doc, error) {
+		return nil, &CommandError{Code: codeNoReplicationEnabled, CodeName: "NoReplicationEnabled", Message: "not running with --replSet"}
+	}
+
+	p := &mongoPlugin{}
+	p.Plugin.Init("mongodb", "1.0.0", "MongoDB diagnostic plugin")
+	p.setClient(server)
+	return p, server
+}
+
+func issueTitles(issues []*models.Issue) []string {
+	titles := make([]string, len(issues))
+	for i, issue := range issues {
+		titles[i] = issue.Title
+	}
+	return titles
+}
+
+func TestReplicaSet_LagElectionAndUnhealthyMember(t *testing.T) {
+	p, server := newFakePlugin(t)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	server.reply("replSetGetStatus", Doc{
+		{"set", "rs0"},
+		{"date", now},
+		{"term", int64(7)},
+		{"electionCandidateMetrics", Doc{{"lastElectionReason", "electionTimeout"}}},
+		{"members", []interface{}{
+			Doc{{"name", "db-0:27017"}, {"stateStr", "PRIMARY"}, {"health", 1.0}, {"optimeDate", now}, {"electionDate", now.Add(-10 * time.Minute)}, {"self", true}},
+			Doc{{"name", "db-1:27017"}, {"stateStr", "SECONDARY"}, {"health", 1.0}, {"optimeDate", now.Add(-45 * time.Second)}, {"syncSourceHost", "db-0:27017"}},
+			Doc{{"name", "db-2:27017"}, {"stateStr", "SECONDARY"}, {"health", 1.0}, {"optimeDate", now.Add(-2 * time.Second)}},
+			Doc{{"name", "db-3:27017"}, {"stateStr", "(not reachable/healthy)"}, {"health", 0.0}, {"lastHeartbeatMessage", "Connection refused"}},
+		}},
+	})
+
+	result, err := p.Diagnose(context.Background(), nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{IssueTitleMemberUnhealthy, IssueTitleReplicationLag, IssueTitleElection}, issueTitles(result.Issues))
+
+	for _, issue := range result.Issues {
+		switch issue.Title {
+		case IssueTitleReplicationLag:
+			assert.Equal(t, "db-1:27017", issue.Metadata["member"], "only the secondary beyond the threshold lags")
+			assert.Equal(t, "45", issue.Metadata["lag_seconds"])
+		case IssueTitleElection:
+			assert.Contains(t, issue.Evidence, "electionTimeout")
+			assert.Equal(t, "7", issue.Metadata["term"])
+		case IssueTitleMemberUnhealthy:
+			assert.Contains(t, issue.Evidence, "Connection refused")
+		}
+	}
+
+	metrics, err := p.CollectMetrics(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, 45.0, metrics.Data["repl_lag_seconds_max"])
+}
+
+func TestReplicaSet_NoPrimary(t *testing.T) {
+	p, _ := newFakePlugin(t)
+	issues := p.analyzer.Analyze(&Snapshot{ReplSet: &ReplSetStatus{Set: "rs0", Members: []Member{
+		{Name: "db-0:27017", State: "SECONDARY", Health: 1},
+		{Name: "db-1:27017", State: "SECONDARY", Health: 1},
+	}}})
+	require.Len(t, issues, 1)
+	assert.Equal(t, IssueTitleNoPrimary, issues[0].Title)
+
+	// Standalone servers have no replica set status to analyze
+	assert.Empty(t, p.analyzer.Analyze(&Snapshot{}))
+}
+
+func TestServerStatus_CacheTicketsAndConnections(t *testing.T) {
+	p, server := newFakePlugin(t)
+	gib := int64(1 << 30)
+	server.reply("serverStatus", Doc{
+		{"connections", Doc{{"current", int32(850)}, {"available", int32(150)}}},
+		{"wiredTiger", Doc{
+			{"cache", Doc{
+				{"maximum bytes configured", float64(gib)},
+				{"bytes currently in the cache", float64(gib) * 0.8},
+				{"tracked dirty bytes in the cache", float64(gib) * 0.25},
+				{"pages evicted by application threads", int64(1200)},
+			}},
+			{"concurrentTransactions", Doc{
+				{"read", Doc{{"out", int32(12)}, {"available", int32(116)}, {"totalTickets", int32(128)}}},
+				{"write", Doc{{"out", int32(128)}, {"available", int32(0)}, {"totalTickets", int32(128)}}},
+			}},
+		}},
+	})
+
+	result, err := p.Diagnose(context.Background(), nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{IssueTitleCachePressure, IssueTitleTicketExhaustion, IssueTitleConnSaturation}, issueTitles(result.Issues))
+	for _, issue := range result.Issues {
+		if issue.Title == IssueTitleTicketExhaustion {
+			assert.Equal(t, "write", issue.Metadata["ticket_type"])
+			assert.Equal(t, enum.SeverityCritical, issue.Severity)
+		}
+		if issue.Title == IssueTitleCachePressure {
+			assert.Equal(t, "25.0", issue.Metadata["cache_dirty_percent"])
+		}
+	}
+
+	metrics, err := p.CollectMetrics(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, 85.0, metrics.Data["connection_usage_percent"])
+	assert.Equal(t, 0.0, metrics.Data["write_tickets_available"])
+}
+
+func TestLongRunningOp_KillsExactOperation(t *testing.T) {
+	p, server := newFakePlugin(t)
+	ctx := context.Background()
+	running := true
+	server.handlers["currentOp"] = func(db string, cmd Doc) (Doc, error) {
+		assert.Equal(t, "admin", db)
+		ops := []interface{}{
+			Doc{{"opid", int32(7001)}, {"op", "query"}, {"ns", "shop.orders"}, {"client", "10.0.0.5:51234"}, {"appName", "billing"},
+				{"secs_running", int64(900)}, {"planSummary", "COLLSCAN"}, {"command", Doc{{"find", "orders"}, {"filter", Doc{{"status", "open"}}}}}},
+			Doc{{"opid", int32(12)}, {"op", "none"}, {"ns", "local.oplog.rs"}, {"desc", "ReplBatcher"}, {"secs_running", int64(86400)}},
+		}
+		if id, ok := cmd.Get("opid"); ok {
+			if !running || id != int32(7001) {
+				return Doc{{"inprog", []interface{}{}}}, nil
+			}
+			ops = ops[:1]
+		}
+		return Doc{{"inprog", ops}}, nil
+	}
+	server.handlers["killOp"] = func(db string, cmd Doc) (Doc, error) {
+		running = false
+		return Doc{{"ok", 1.0}}, nil
+	}
+
+	result, err := p.Diagnose(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, result.Issues, 1, "internal threads are not reported")
+	issue := result.Issues[0]
+	assert.Equal(t, IssueTitleLongRunningOp, issue.Title)
+	assert.Equal(t, "7001", issue.Metadata["opid"])
+	assert.Contains(t, issue.Evidence, "billing")
+
+	ok, fix := p.CanAutoFix(issue)
+	require.True(t, ok)
+	fixResult, err := p.ExecuteFix(ctx, fix)
+	require.NoError(t, err)
+	assert.True(t, fixResult.Success)
+
+	kills := server.commands("killOp")
+	require.Len(t, kills, 1)
+	assert.Equal(t, Doc{{"killOp", 1}, {"op", int32(7001)}}, kills[0])
+
+	valid, msg, err := p.ValidateFix(ctx, issue, fixResult)
+	require.NoError(t, err)
+	assert.True(t, valid, msg)
+
+	// An operation that already finished is not killed again
+	_, err = p.ExecuteFix(ctx, fix)
+	require.NoError(t, err)
+	assert.Len(t, server.commands("killOp"), 1)
+}
+
+func TestLongRunningOp_RefusesReusedOpID(t *testing.T) {
+	p, server := newFakePlugin(t)
+	server.reply("currentOp", Doc{{"inprog", []interface{}{
+		Doc{{"opid", int32(7001)}, {"op", "update"}, {"ns", "shop.carts"}, {"client", "10.0.0.9:40000"}, {"secs_running", int64(1)}},
+	}}})
+
+	issue := &models.Issue{Title: IssueTitleLongRunningOp, Metadata: map[string]string{"opid": "7001", "ns": "shop.orders"}}
+	ok, fix := p.CanAutoFix(issue)
+	require.True(t, ok)
+	result, err := p.ExecuteFix(context.Background(), fix)
+	require.Error(t, err)
+	assert.False(t, result.Success)
+	assert.Empty(t, server.commands("killOp"))
+}
+
+func TestUnindexedScan_SuggestsAndCreatesIndex(t *testing.T) {
+	p, server := newFakePlugin(t)
+	ctx := context.Background()
+	server.reply("listDatabases", Doc{{"databases", []interface{}{Doc{{"name", "shop"}}, Doc{{"name", "local"}}}}})
+	server.reply("dbStats", Doc{{"collections", int32(3)}, {"dataSize", 1024.0}})
+	server.handlers["find"] = func(db string, cmd Doc) (Doc, error) {
+		assert.Equal(t, "shop", db)
+		assert.Equal(t, "system.profile", cmd.String("find"))
+		profile := func(millis int64, examined int64) Doc {
+			return Doc{{"op", "query"}, {"ns", "shop.orders"}, {"millis", millis}, {"planSummary", "COLLSCAN"},
+				{"docsExamined", examined}, {"nreturned", int32(20)}, {"ts", time.Now()},
+				{"command", Doc{
+					{"find", "orders"},
+					{"filter", Doc{{"createdAt", Doc{{"$gte", time.Now()}}}, {"status", "open"}, {"$or", []interface{}{}}}},
+					{"sort", Doc{{"priority", int32(-1)}}},
+				}}}
+		}
+		return Doc{{"cursor", Doc{{"firstBatch", []interface{}{
+			profile(350, 200000),
+			profile(900, 250000),
+			// Selective enough: not reported
+			Doc{{"op", "query"}, {"ns", "shop.users"}, {"millis", int64(150)}, {"planSummary", "COLLSCAN"}, {"docsExamined", int64(2000)}, {"nreturned", int32(1000)}},
+			Doc{{"op", "query"}, {"ns", "shop.items"}, {"millis", int64(150)}, {"planSummary", "IXSCAN { sku: 1 }"}, {"docsExamined", int64(50000)}, {"nreturned", int32(1)}},
+		}}}}}, nil
+	}
+	indexes := []interface{}{Doc{{"key", Doc{{"_id", int32(1)}}}, {"name", "_id_"}}}
+	server.handlers["listIndexes"] = func(db string, cmd Doc) (Doc, error) {
+		return Doc{{"cursor", Doc{{"firstBatch", indexes}}}}, nil
+	}
+	server.handlers["createIndexes"] = func(db string, cmd Doc) (Doc, error) {
+		specs := cmd.Lookup("indexes").([]Doc)
+		indexes = append(indexes, specs[0])
+		return Doc{{"ok", 1.0}}, nil
+	}
+
+	result, err := p.Diagnose(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, result.Issues, 1)
+	issue := result.Issues[0]
+	assert.Equal(t, IssueTitleUnindexedScan, issue.Title)
+	assert.Equal(t, `{"status":1,"priority":-1,"createdAt":1}`, issue.Metadata["index_keys"], "equality, sort, then range fields")
+	assert.Equal(t, "900", issue.Metadata["max_millis"])
+	assert.Contains(t, issue.Evidence, "2 profiled query operation(s)")
+
+	ok, fix := p.CanAutoFix(issue)
+	require.True(t, ok)
+	assert.Equal(t, "status_1_priority_-1_createdAt_1", fix.Parameters["name"])
+
+	valid, _, err := p.ValidateFix(ctx, issue, nil)
+	require.NoError(t, err)
+	assert.False(t, valid, "the index does not exist yet")
+
+	fixResult, err := p.ExecuteFix(ctx, fix)
+	require.NoError(t, err)
+	assert.True(t, fixResult.Success)
+	create := server.commands("createIndexes")
+	require.Len(t, create, 1)
+	assert.Equal(t, "orders", create[0].String("createIndexes"))
+
+	valid, msg, err := p.ValidateFix(ctx, issue, fixResult)
+	require.NoError(t, err)
+	assert.True(t, valid, msg)
+}
+
+func TestParseKeys(t *testing.T) {
+	keys, err := parseKeys(`{"b":1,"a":-1}`)
+	require.NoError(t, err)
+	assert.Equal(t, Doc{{"b", int32(1)}, {"a", int32(-1)}}, keys, "key order is preserved")
+	assert.Equal(t, `{"b":1,"a":-1}`, formatKeys(keys))
+
+	for _, invalid := range []string{``, `{}`, `[1]`, `{"a":2}`, `{"a":"text"}`} {
+		_, err := parseKeys(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
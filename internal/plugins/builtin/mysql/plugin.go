@@ -19,6 +19,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -164,39 +165,71 @@ func (p *mysqlPlugin) CanAutoFix(issue *models.Issue) (bool, *models.FixAction)
 }
 
 func (p *mysqlPlugin) ExecuteFix(ctx context.Context, fix *models.FixAction) (*models.FixResult, error) {
-	return p.fixer.Execute(ctx, fix, func(ctx context.Context) error {
-		if fix.Command == "KILL_SLEEP_CONNECTIONS" {
-			// Logic to kill sleep connections
-			rows, err := p.db.QueryContext(ctx, "SELECT ID FROM information_schema.processlist WHERE Command = 'Sleep' AND Time > 60")
-			if err != nil {
-				return err
-			}
-			defer rows.Close()
-			var ids []int
-			for rows.Next() {
-				var id int
-				if err := rows.Scan(&id); err == nil {
-					ids = append(ids, id)
-				}
-			}
-			for _, id := range ids {
-				p.db.ExecContext(ctx, fmt.Sprintf("KILL %d", id))
-			}
-			return nil
-		} else if strings.HasPrefix(fix.Command, "KILL QUERY") {
-			pidStr := fix.Parameters["process_id"]
-			if pidStr == "" {
-				return fmt.Errorf("missing process_id parameter")
-			}
-			pid, err := strconv.Atoi(pidStr)
-			if err != nil {
-				return fmt.Errorf("invalid process_id: %v", err)
+	return p.fixer.Run(ctx, fix, func(ctx context.Context, command string) (interface{}, error) {
+		return p.command(ctx, command, fix.Parameters)
+	})
+}
+
+var (
+	// setGlobalPattern matches the SET GLOBAL statements of configuration fixes
+	setGlobalPattern = regexp.MustCompile(`^SET GLOBAL (\w+) = ([^'"\\;]*)$`)
+	// globalVarPattern matches the probe reading a global variable
+	globalVarPattern = regexp.MustCompile(`^SELECT @@GLOBAL\.(\w+)$`)
+	// bareValuePattern matches values that need no quoting
+	bareValuePattern = regexp.MustCompile(`^(-?\d+(\.\d+)?|\w+)$`)
+)
+
+// command runs a fix command or probe. Probes of a global variable return its
+// value as a string.
+func (p *mysqlPlugin) command(ctx context.Context, command string, params map[string]string) (interface{}, error) {
+	switch {
+	case command == "KILL_SLEEP_CONNECTIONS":
+		// Logic to kill sleep connections
+		rows, err := p.db.QueryContext(ctx, "SELECT ID FROM information_schema.processlist WHERE Command = 'Sleep' AND Time > 60")
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err == nil {
+				ids = append(ids, id)
 			}
-			_, err = p.db.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", pid))
-			return err
 		}
-		return fmt.Errorf("unknown command: %s", fix.Command)
-	}, nil)
+		for _, id := range ids {
+			p.db.ExecContext(ctx, fmt.Sprintf("KILL %d", id))
+		}
+		return nil, nil
+	case strings.HasPrefix(command, "KILL QUERY"):
+		pidStr := params["process_id"]
+		if pidStr == "" {
+			return nil, fmt.Errorf("missing process_id parameter")
+		}
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid process_id: %v", err)
+		}
+		_, err = p.db.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", pid))
+		return nil, err
+	}
+
+	if m := setGlobalPattern.FindStringSubmatch(command); m != nil {
+		value := m[2]
+		if !bareValuePattern.MatchString(value) {
+			value = "'" + value + "'"
+		}
+		_, err := p.db.ExecContext(ctx, fmt.Sprintf("SET GLOBAL %s = %s", m[1], value))
+		return nil, err
+	}
+	if m := globalVarPattern.FindStringSubmatch(command); m != nil {
+		var value sql.NullString
+		if err := p.db.QueryRowContext(ctx, "SELECT @@GLOBAL."+m[1]).Scan(&value); err != nil {
+			return nil, err
+		}
+		return value.String, nil
+	}
+	return nil, fmt.Errorf("unknown command: %s", command)
 }
 
 func (p *mysqlPlugin) ValidateFix(ctx context.Context, issue *models.Issue, result *models.FixResult) (bool, string, error) {
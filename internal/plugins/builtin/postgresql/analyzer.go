@@ -16,6 +16,8 @@ package postgresql
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
@@ -32,18 +34,49 @@ func NewAnalyzer(log logger.Logger) *Analyzer {
 	return &Analyzer{log: log}
 }
 
-// Analyze is the main entry point for the analyzer.
-func (a *Analyzer) Analyze(metrics map[string]interface{}) []*models.Issue {
+// Thresholds used by the evidence-based checks.
+const (
+	idleTxThreshold        = 5 * time.Minute
+	lockWaitThreshold      = 30 * time.Second
+	deadTupleMinimum       = 10000
+	deadTupleRatio         = 0.2
+	modsSinceAnalyzeMin    = 1000
+	modsSinceAnalyzeRatio  = 0.2
+	slotRetainedBytesLimit = 1 << 30 // 1 GiB
+	replicaLagBytesLimit   = 100 << 20
+	replicaLagLimit        = 30 * time.Second
+)
+
+// Analyze is the main entry point for the analyzer. Every issue that concerns
+// a specific backend, table or slot carries its identifiers in Issue.Metadata.
+func (a *Analyzer) Analyze(snapshot *Snapshot) []*models.Issue {
 	var issues []*models.Issue
 	a.log.Info("Analyzing collected PostgreSQL data.")
 
-	issues = append(issues, a.analyzeCacheHitRatio(metrics)...)
-	issues = append(issues, a.analyzeConnections(metrics)...)
-	issues = append(issues, a.analyzeLongIdleTx(metrics)...)
+	issues = append(issues, a.analyzeCacheHitRatio(snapshot.Metrics)...)
+	issues = append(issues, a.analyzeConnections(snapshot.Metrics)...)
+	issues = append(issues, a.analyzeIdleTransactions(snapshot.IdleInTransaction)...)
+	issues = append(issues, a.analyzeLockWaits(snapshot.LockWaits)...)
+	issues = append(issues, a.analyzeTables(snapshot.Tables)...)
+	issues = append(issues, a.analyzeReplication(snapshot.ReplicationSlots, snapshot.Replicas)...)
 
 	return issues
 }
 
+// formatTime renders a statistics timestamp for Issue.Metadata, empty if never set.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// parseTime is the inverse of formatTime.
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
+
 func (a *Analyzer) analyzeCacheHitRatio(metrics map[string]interface{}) []*models.Issue {
 	var issues []*models.Issue
 	if ratio, ok := metrics["cache_hit_ratio"].(float64); ok {
@@ -87,23 +120,161 @@ func (a *Analyzer) analyzeConnections(metrics map[string]interface{}) []*models.
 	return issues
 }
 
-func (a *Analyzer) analyzeLongIdleTx(metrics map[string]interface{}) []*models.Issue {
+func (a *Analyzer) analyzeIdleTransactions(backends []Backend) []*models.Issue {
 	var issues []*models.Issue
-	if count, ok := metrics["long_idle_tx"].(int64); ok {
-		if count > 0 {
+	for _, b := range backends {
+		if b.StateAge < idleTxThreshold {
+			continue
+		}
+		issues = append(issues, &models.Issue{
+			Title:    IssueTitleIdleTx,
+			Severity: enum.SeverityWarning,
+			Evidence: fmt.Sprintf("Backend PID %d (user %q, database %q, application %q, client %s) has been idle in transaction for %s; transaction open for %s. Last query: %s",
+				b.PID, b.User, b.Database, b.ApplicationName, b.ClientAddr, b.StateAge.Round(time.Second), b.XactAge.Round(time.Second), truncate(b.Query, 200)),
+			Metadata: map[string]string{
+				"pid":           strconv.Itoa(b.PID),
+				"backend_start": formatTime(b.BackendStart),
+				"user":          b.User,
+				"database":      b.Database,
+				"application":   b.ApplicationName,
+				"idle_seconds":  strconv.Itoa(int(b.StateAge.Seconds())),
+			},
+			Recommendations: []*models.Recommendation{{
+				Description: "Idle transactions hold locks and prevent vacuum from removing dead tuples. Terminate the backend and fix the application so it commits or rolls back promptly; consider setting idle_in_transaction_session_timeout.",
+			}},
+		})
+	}
+	return issues
+}
+
+func (a *Analyzer) analyzeLockWaits(waits []LockWait) []*models.Issue {
+	var issues []*models.Issue
+	// Report each blocking backend once, with the longest wait it causes
+	reported := make(map[int]bool)
+	for _, first := range waits {
+		if reported[first.BlockingPID] {
+			continue
+		}
+		reported[first.BlockingPID] = true
+
+		w := first
+		var blocked []string
+		for _, other := range waits {
+			if other.BlockingPID == first.BlockingPID {
+				blocked = append(blocked, strconv.Itoa(other.BlockedPID))
+				if other.WaitDuration > w.WaitDuration {
+					w = other
+				}
+			}
+		}
+		if w.WaitDuration < lockWaitThreshold {
+			continue
+		}
+		issues = append(issues, &models.Issue{
+			Title:    IssueTitleLockContention,
+			Severity: enum.SeverityHigh,
+			Evidence: fmt.Sprintf("Backend PID %d blocks PID(s) %v; the longest wait is %s. Blocking query: %s",
+				w.BlockingPID, blocked, w.WaitDuration.Round(time.Second), truncate(w.BlockingQuery, 200)),
+			Metadata: map[string]string{
+				"pid":           strconv.Itoa(w.BlockingPID),
+				"backend_start": formatTime(w.BlockingBackendStart),
+				"blocked_pid":   strconv.Itoa(w.BlockedPID),
+				"wait_seconds":  strconv.Itoa(int(w.WaitDuration.Seconds())),
+			},
+			Recommendations: []*models.Recommendation{{
+				Description: "Investigate the blocking transaction. If it is stuck, terminating the blocking backend releases its locks; consider setting lock_timeout for the waiting workload.",
+			}},
+		})
+	}
+	return issues
+}
+
+func (a *Analyzer) analyzeTables(tables []TableStats) []*models.Issue {
+	var issues []*models.Issue
+	for _, t := range tables {
+		metadata := map[string]string{
+			"schema":       t.Schema,
+			"table":        t.Name,
+			"dead_tuples":  strconv.FormatInt(t.DeadTuples, 10),
+			"last_vacuum":  formatTime(t.LastVacuum),
+			"last_analyze": formatTime(t.LastAnalyze),
+		}
+
+		if t.DeadTuples >= deadTupleMinimum && float64(t.DeadTuples) >= deadTupleRatio*float64(t.LiveTuples) {
 			issues = append(issues, &models.Issue{
-				Title:    "Long Idle Transactions Detected",
+				Title:    IssueTitleNeedVacuum,
 				Severity: enum.SeverityWarning,
-				Evidence: fmt.Sprintf("Found %d transactions idle for more than 5 minutes.", count),
+				Evidence: fmt.Sprintf("Table %s has %d dead tuples vs %d live tuples; last vacuum: %s.", t.QualifiedName(), t.DeadTuples, t.LiveTuples, describeTime(t.LastVacuum)),
+				Metadata: metadata,
 				Recommendations: []*models.Recommendation{{
-					Description: "Terminate idle transactions to release locks and prevent table bloat. Investigate application logic.",
-					Fix: models.FixAction{
-						Description: "Terminate idle transactions",
-						Category:    "Operation",
-					},
+					Description: "Dead tuples bloat the table and slow down scans. Run VACUUM ANALYZE on the table and check whether autovacuum is keeping up (autovacuum_vacuum_scale_factor, long-running transactions).",
+				}},
+			})
+			continue
+		}
+
+		if t.ModsSinceAnalyze >= modsSinceAnalyzeMin && float64(t.ModsSinceAnalyze) >= modsSinceAnalyzeRatio*float64(t.LiveTuples) {
+			issues = append(issues, &models.Issue{
+				Title:    IssueTitleNeedAnalyze,
+				Severity: enum.SeverityLow,
+				Evidence: fmt.Sprintf("Table %s had %d row modifications since it was last analyzed (%s); planner statistics are stale.", t.QualifiedName(), t.ModsSinceAnalyze, describeTime(t.LastAnalyze)),
+				Metadata: metadata,
+				Recommendations: []*models.Recommendation{{
+					Description: "Stale statistics lead to poor query plans. Run ANALYZE on the table.",
 				}},
 			})
 		}
 	}
 	return issues
 }
+
+func (a *Analyzer) analyzeReplication(slots []ReplicationSlot, replicas []ReplicaLag) []*models.Issue {
+	var issues []*models.Issue
+	for _, s := range slots {
+		if s.Active || s.RetainedBytes < slotRetainedBytesLimit {
+			continue
+		}
+		issues = append(issues, &models.Issue{
+			Title:    IssueTitleInactiveSlot,
+			Severity: enum.SeverityHigh,
+			Evidence: fmt.Sprintf("Inactive %s replication slot %q retains %d MiB of WAL.", s.SlotType, s.Name, s.RetainedBytes>>20),
+			Metadata: map[string]string{"slot": s.Name, "retained_bytes": strconv.FormatInt(s.RetainedBytes, 10)},
+			Recommendations: []*models.Recommendation{{
+				Description: "An inactive slot prevents WAL removal and can fill the disk. Reconnect its consumer or, if it is abandoned, drop it with pg_drop_replication_slot().",
+			}},
+		})
+	}
+	for _, r := range replicas {
+		if r.ReplayLagBytes < replicaLagBytesLimit && r.ReplayLag < replicaLagLimit {
+			continue
+		}
+		issues = append(issues, &models.Issue{
+			Title:    IssueTitleReplicationLag,
+			Severity: enum.SeverityWarning,
+			Evidence: fmt.Sprintf("Standby %q (%s, state %s) is %d MiB / %s behind in replay.", r.ApplicationName, r.ClientAddr, r.State, r.ReplayLagBytes>>20, r.ReplayLag.Round(time.Second)),
+			Metadata: map[string]string{
+				"replica":          r.ApplicationName,
+				"client_addr":      r.ClientAddr,
+				"replay_lag_bytes": strconv.FormatInt(r.ReplayLagBytes, 10),
+			},
+			Recommendations: []*models.Recommendation{{
+				Description: "Check network throughput and I/O on the standby, and long-running queries on the standby that conflict with replay.",
+			}},
+		})
+	}
+	return issues
+}
+
+func describeTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -19,6 +19,8 @@ import (
 	"database/sql"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
@@ -125,3 +127,312 @@ func (c *Collector) CollectVariables(ctx context.Context) (map[string]string, er
 	}
 	return vars, nil
 }
+
+// Backend is a server process from pg_stat_activity.
+type Backend struct {
+	PID             int
+	User            string
+	Database        string
+	ApplicationName string
+	ClientAddr      string
+	State           string
+	Query           string
+	BackendStart    time.Time
+	// XactAge is how long the current transaction has been open.
+	XactAge time.Duration
+	// StateAge is how long the backend has been in its current state.
+	StateAge time.Duration
+}
+
+// TableStats holds vacuum and analyze statistics from pg_stat_user_tables.
+type TableStats struct {
+	Schema           string
+	Name             string
+	LiveTuples       int64
+	DeadTuples       int64
+	ModsSinceAnalyze int64
+	LastVacuum       time.Time // zero if the table was never vacuumed
+	LastAnalyze      time.Time // zero if the table was never analyzed
+}
+
+// QualifiedName returns the schema-qualified table name.
+func (t *TableStats) QualifiedName() string {
+	return t.Schema + "." + t.Name
+}
+
+// ReplicationSlot is a row from pg_replication_slots with the amount of WAL it retains.
+type ReplicationSlot struct {
+	Name          string
+	SlotType      string
+	Active        bool
+	RetainedBytes int64
+}
+
+// ReplicaLag is a standby connected to this primary, from pg_stat_replication.
+type ReplicaLag struct {
+	ApplicationName string
+	ClientAddr      string
+	State           string
+	ReplayLagBytes  int64
+	ReplayLag       time.Duration
+}
+
+// LockWait is a backend waiting on a lock held by another backend.
+type LockWait struct {
+	BlockedPID    int
+	BlockingPID   int
+	BlockedQuery  string
+	BlockingQuery string
+	// BlockingBackendStart identifies the blocking backend across PID reuse.
+	BlockingBackendStart time.Time
+	WaitDuration         time.Duration
+}
+
+// Snapshot is the evidence gathered from a PostgreSQL instance in one diagnosis run.
+type Snapshot struct {
+	Metrics           map[string]interface{}
+	Settings          map[string]string
+	IdleInTransaction []Backend
+	Tables            []TableStats
+	ReplicationSlots  []ReplicationSlot
+	Replicas          []ReplicaLag
+	LockWaits         []LockWait
+}
+
+// CollectSnapshot gathers metrics, settings and the per-object evidence used by
+// the analyzer. Failures of individual evidence queries are logged and skipped,
+// since some views require privileges the monitoring role may not have.
+func (c *Collector) CollectSnapshot(ctx context.Context) (*Snapshot, error) {
+	metrics, err := c.CollectMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{Metrics: metrics.Data}
+
+	if snapshot.Settings, err = c.CollectVariables(ctx); err != nil {
+		c.log.Warnf("Failed to query pg_settings: %v", err)
+	}
+	if snapshot.IdleInTransaction, err = c.CollectIdleInTransaction(ctx); err != nil {
+		c.log.Warnf("Failed to query idle-in-transaction backends: %v", err)
+	}
+	if snapshot.Tables, err = c.CollectTableStats(ctx); err != nil {
+		c.log.Warnf("Failed to query pg_stat_user_tables: %v", err)
+	}
+	if snapshot.ReplicationSlots, err = c.CollectReplicationSlots(ctx); err != nil {
+		c.log.Warnf("Failed to query pg_replication_slots: %v", err)
+	}
+	if snapshot.Replicas, err = c.CollectReplicationLag(ctx); err != nil {
+		c.log.Warnf("Failed to query pg_stat_replication: %v", err)
+	}
+	if snapshot.LockWaits, err = c.CollectLockWaits(ctx); err != nil {
+		c.log.Warnf("Failed to query lock waits: %v", err)
+	}
+	return snapshot, nil
+}
+
+const backendColumns = `
+	pid, coalesce(usename, ''), coalesce(datname, ''), coalesce(application_name, ''),
+	coalesce(host(client_addr), ''), coalesce(state, ''), coalesce(query, ''), backend_start,
+	coalesce(extract(epoch FROM now() - xact_start), 0),
+	coalesce(extract(epoch FROM now() - state_change), 0)`
+
+func scanBackend(scan func(dest ...interface{}) error) (Backend, error) {
+	var b Backend
+	var xactAge, stateAge float64
+	err := scan(&b.PID, &b.User, &b.Database, &b.ApplicationName, &b.ClientAddr, &b.State, &b.Query, &b.BackendStart, &xactAge, &stateAge)
+	b.XactAge = time.Duration(xactAge * float64(time.Second))
+	b.StateAge = time.Duration(stateAge * float64(time.Second))
+	return b, err
+}
+
+// CollectIdleInTransaction lists backends that are idle inside an open
+// transaction, oldest transaction first.
+func (c *Collector) CollectIdleInTransaction(ctx context.Context) ([]Backend, error) {
+	query := `SELECT` + backendColumns + `
+		FROM pg_stat_activity
+		WHERE state IN ('idle in transaction', 'idle in transaction (aborted)') AND pid <> pg_backend_pid()
+		ORDER BY xact_start`
+	rows, err := c.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var backends []Backend
+	for rows.Next() {
+		b, err := scanBackend(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, b)
+	}
+	return backends, rows.Err()
+}
+
+// GetBackend returns the backend with the given PID, or nil if it no longer
+// exists. When backendStart is non-zero, a backend whose start time differs is
+// treated as a different process that reused the PID.
+func (c *Collector) GetBackend(ctx context.Context, pid int, backendStart time.Time) (*Backend, error) {
+	query := `SELECT` + backendColumns + ` FROM pg_stat_activity WHERE pid = $1`
+	b, err := scanBackend(c.db.QueryRowContext(ctx, query, pid).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !backendStart.IsZero() && !b.BackendStart.Equal(backendStart) {
+		return nil, nil
+	}
+	return &b, nil
+}
+
+const tableStatsQuery = `
+	SELECT schemaname, relname, n_live_tup, n_dead_tup, n_mod_since_analyze,
+		greatest(last_vacuum, last_autovacuum), greatest(last_analyze, last_autoanalyze)
+	FROM pg_stat_user_tables`
+
+func scanTableStats(scan func(dest ...interface{}) error) (TableStats, error) {
+	var t TableStats
+	var lastVacuum, lastAnalyze sql.NullTime
+	err := scan(&t.Schema, &t.Name, &t.LiveTuples, &t.DeadTuples, &t.ModsSinceAnalyze, &lastVacuum, &lastAnalyze)
+	t.LastVacuum = lastVacuum.Time
+	t.LastAnalyze = lastAnalyze.Time
+	return t, err
+}
+
+// CollectTableStats reads dead tuple counts and the last (auto)vacuum and
+// (auto)analyze times of user tables, most dead tuples first.
+func (c *Collector) CollectTableStats(ctx context.Context) ([]TableStats, error) {
+	rows, err := c.db.QueryContext(ctx, tableStatsQuery+` ORDER BY n_dead_tup DESC LIMIT 100`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []TableStats
+	for rows.Next() {
+		t, err := scanTableStats(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// GetTableStats returns the current statistics of a single table.
+func (c *Collector) GetTableStats(ctx context.Context, schema, table string) (*TableStats, error) {
+	t, err := scanTableStats(c.db.QueryRowContext(ctx, tableStatsQuery+` WHERE schemaname = $1 AND relname = $2`, schema, table).Scan)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// CollectReplicationSlots lists replication slots with the WAL they hold back.
+func (c *Collector) CollectReplicationSlots(ctx context.Context) ([]ReplicationSlot, error) {
+	query := `
+		SELECT slot_name, slot_type, active,
+			coalesce(pg_wal_lsn_diff(pg_current_wal_lsn(), restart_lsn), 0)::bigint
+		FROM pg_replication_slots`
+	rows, err := c.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var slots []ReplicationSlot
+	for rows.Next() {
+		var s ReplicationSlot
+		if err := rows.Scan(&s.Name, &s.SlotType, &s.Active, &s.RetainedBytes); err != nil {
+			return nil, err
+		}
+		slots = append(slots, s)
+	}
+	return slots, rows.Err()
+}
+
+// CollectReplicationLag lists connected standbys and how far behind they replay.
+func (c *Collector) CollectReplicationLag(ctx context.Context) ([]ReplicaLag, error) {
+	query := `
+		SELECT coalesce(application_name, ''), coalesce(host(client_addr), ''), coalesce(state, ''),
+			coalesce(pg_wal_lsn_diff(pg_current_wal_lsn(), replay_lsn), 0)::bigint,
+			coalesce(extract(epoch FROM replay_lag), 0)
+		FROM pg_stat_replication`
+	rows, err := c.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var replicas []ReplicaLag
+	for rows.Next() {
+		var r ReplicaLag
+		var lagSeconds float64
+		if err := rows.Scan(&r.ApplicationName, &r.ClientAddr, &r.State, &r.ReplayLagBytes, &lagSeconds); err != nil {
+			return nil, err
+		}
+		r.ReplayLag = time.Duration(lagSeconds * float64(time.Second))
+		replicas = append(replicas, r)
+	}
+	return replicas, rows.Err()
+}
+
+// CollectLockWaits lists backends waiting on locks together with the backends
+// blocking them, longest wait first.
+func (c *Collector) CollectLockWaits(ctx context.Context) ([]LockWait, error) {
+	query := `
+		SELECT blocked.pid, blocking.pid, coalesce(blocked.query, ''), coalesce(blocking.query, ''),
+			blocking.backend_start, coalesce(extract(epoch FROM now() - blocked.state_change), 0)
+		FROM pg_stat_activity blocked
+		JOIN LATERAL unnest(pg_blocking_pids(blocked.pid)) AS b(pid) ON true
+		JOIN pg_stat_activity blocking ON blocking.pid = b.pid
+		WHERE blocked.wait_event_type = 'Lock'
+		ORDER BY blocked.state_change`
+	rows, err := c.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var waits []LockWait
+	for rows.Next() {
+		var w LockWait
+		var waitSeconds float64
+		if err := rows.Scan(&w.BlockedPID, &w.BlockingPID, &w.BlockedQuery, &w.BlockingQuery, &w.BlockingBackendStart, &waitSeconds); err != nil {
+			return nil, err
+		}
+		w.WaitDuration = time.Duration(waitSeconds * float64(time.Second))
+		waits = append(waits, w)
+	}
+	return waits, rows.Err()
+}
+
+// CollectLogs reads the tail of the current server log file. This requires the
+// logging collector to be enabled and the pg_read_server_files privilege.
+func (c *Collector) CollectLogs(ctx context.Context, tail int) ([]string, error) {
+	if tail <= 0 {
+		tail = 100
+	}
+	var logFile sql.NullString
+	if err := c.db.QueryRowContext(ctx, "SELECT pg_current_logfile()").Scan(&logFile); err != nil {
+		return nil, fmt.Errorf("failed to locate current log file: %w", err)
+	}
+	if !logFile.Valid || logFile.String == "" {
+		return nil, fmt.Errorf("logging_collector is disabled, no server log file available")
+	}
+
+	// Read at most ~256 bytes per requested line from the end of the file
+	query := `SELECT pg_read_file($1, greatest((pg_stat_file($1)).size - $2, 0), $2)`
+	var content string
+	if err := c.db.QueryRowContext(ctx, query, logFile.String, int64(tail)*256).Scan(&content); err != nil {
+		return nil, fmt.Errorf("failed to read log file %s: %w", logFile.String, err)
+	}
+
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+	return lines, nil
+}
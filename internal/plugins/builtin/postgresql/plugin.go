@@ -18,26 +18,39 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
-	_ "github.com/lib/pq" // PostgreSQL driver
 	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
 	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
 	"github.com/kubestack-ai/kubestack-ai/internal/plugins/base"
+	"github.com/lib/pq"
 )
 
 const (
-	IssueTitleIdleTx    = "Long Running Idle Transaction"
-	IssueTitleNeedAnalyze = "Table Needs Analysis"
+	IssueTitleIdleTx         = "Long Running Idle Transaction"
+	IssueTitleNeedAnalyze    = "Table Needs Analysis"
+	IssueTitleNeedVacuum     = "Table Needs Vacuum"
+	IssueTitleLockContention = "Lock Contention"
+	IssueTitleInactiveSlot   = "Inactive Replication Slot"
+	IssueTitleReplicationLag = "High Replication Lag"
 )
 
+// fixSettle is how long a fix waits before it is verified, giving a
+// terminated backend time to exit and the statistics time to be updated.
+var fixSettle = time.Second
+
 type postgresPlugin struct {
 	base.Plugin
-	db     *sql.DB
-	config *config.PluginConfig
-	fixer  *base.FixExecutor
+	db        *sql.DB
+	config    *config.PluginConfig
+	collector *Collector
+	analyzer  *Analyzer
+	fixer     *base.FixExecutor
 }
 
 func New() (interfaces.DiagnosticPlugin, error) {
@@ -52,9 +65,15 @@ func New() (interfaces.DiagnosticPlugin, error) {
 		return nil, fmt.Errorf("failed to open postgres connection: %w", err)
 	}
 
+	p.setDB(db)
+	return p, nil
+}
+
+func (p *postgresPlugin) setDB(db *sql.DB) {
 	p.db = db
+	p.collector = NewCollector(db, p.Log)
+	p.analyzer = NewAnalyzer(p.Log)
 	p.fixer = base.NewFixExecutor(p.Log)
-	return p, nil
 }
 
 func (p *postgresPlugin) SupportedTypes() []enum.MiddlewareType {
@@ -80,25 +99,41 @@ func (p *postgresPlugin) Shutdown() error {
 }
 
 func (p *postgresPlugin) Diagnose(ctx context.Context, _ *models.DiagnosisRequest) (*models.DiagnosisResult, error) {
-	// Updated to return DiagnosisResult
+	snapshot, err := p.collector.CollectSnapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect postgresql data: %w", err)
+	}
+	issues := p.analyzer.Analyze(snapshot)
 	return &models.DiagnosisResult{
 		ID:        fmt.Sprintf("pg-diag-%d", time.Now().Unix()),
 		Timestamp: time.Now().UTC(),
-		Summary:   "PostgreSQL diagnosis placeholder",
-		Issues:    []*models.Issue{},
+		Summary:   fmt.Sprintf("PostgreSQL diagnosis complete. Found %d potential issues.", len(issues)),
+		Issues:    issues,
 	}, nil
 }
 
 func (p *postgresPlugin) CollectMetrics(ctx context.Context, target string) (*models.MetricsData, error) {
-	return &models.MetricsData{Data: map[string]interface{}{}}, nil
+	return p.collector.CollectMetrics(ctx)
 }
 
 func (p *postgresPlugin) CollectLogs(ctx context.Context, target string, opts *models.LogOptions) (*models.LogData, error) {
-	return &models.LogData{Entries: []string{}}, nil
+	tail := 0
+	if opts != nil {
+		tail = opts.Tail
+	}
+	entries, err := p.collector.CollectLogs(ctx, tail)
+	if err != nil {
+		return nil, err
+	}
+	return &models.LogData{Entries: entries}, nil
 }
 
 func (p *postgresPlugin) CollectConfig(ctx context.Context, target string) (*models.ConfigData, error) {
-	return &models.ConfigData{Data: map[string]string{}}, nil
+	settings, err := p.collector.CollectVariables(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pg_settings: %w", err)
+	}
+	return &models.ConfigData{Data: settings}, nil
 }
 
 func (p *postgresPlugin) HealthCheck(ctx context.Context, target string) (*models.HealthStatus, error) {
@@ -114,64 +149,250 @@ func (p *postgresPlugin) Ping(ctx context.Context, target string) error {
 
 // --- Fix Capabilities ---
 
+// CanAutoFix only offers fixes for issues that identify their target in
+// Issue.Metadata, so a fix never acts on a guessed backend or table.
 func (p *postgresPlugin) CanAutoFix(issue *models.Issue) (bool, *models.FixAction) {
 	switch issue.Title {
-	case IssueTitleIdleTx:
-		// Requires PID from evidence/metrics. Mocking extracting PID=123 for now if not present
+	case IssueTitleIdleTx, IssueTitleLockContention:
+		pid := issue.Metadata["pid"]
+		if _, err := strconv.Atoi(pid); err != nil {
+			return false, nil
+		}
 		return true, &models.FixAction{
-			ID:          "fix-pg-terminate-backend",
-			Description: "Terminate idle backend",
-			Command:     "PG_TERMINATE_BACKEND",
-			Parameters:  map[string]string{"pid": "123"},
+			ID:           "fix-pg-terminate-backend",
+			Description:  fmt.Sprintf("Terminate backend PID %s", pid),
+			Command:      "PG_TERMINATE_BACKEND",
+			Parameters:   map[string]string{"pid": pid, "backend_start": issue.Metadata["backend_start"]},
+			Category:     "Operation",
+			Precondition: &models.FixProbe{Command: "PG_BACKEND_STATE"},
+			Verification: &models.FixProbe{
+				Command: "PG_BACKEND_STATE",
+				Expect:  `value == "gone"`,
+				Settle:  fixSettle,
+			},
+		}
+	case IssueTitleNeedAnalyze, IssueTitleNeedVacuum:
+		schema, table := issue.Metadata["schema"], issue.Metadata["table"]
+		if table == "" {
+			return false, nil
+		}
+		if schema == "" {
+			schema = "public"
+		}
+		command, verb, probe := "ANALYZE", "ANALYZE", "PG_LAST_ANALYZE"
+		if issue.Title == IssueTitleNeedVacuum {
+			command, verb, probe = "VACUUM_ANALYZE", "VACUUM ANALYZE", "PG_LAST_VACUUM"
 		}
-	case IssueTitleNeedAnalyze:
 		return true, &models.FixAction{
-			ID:          "fix-pg-analyze",
-			Description: "Run ANALYZE on table",
-			Command:     "ANALYZE",
-			Parameters:  map[string]string{"table": "mytable"},
+			ID:           "fix-pg-" + strings.ToLower(strings.ReplaceAll(command, "_", "-")),
+			Description:  fmt.Sprintf("Run %s on table %s.%s", verb, schema, table),
+			Command:      command,
+			Parameters:   map[string]string{"schema": schema, "table": table},
+			Category:     "Operation",
+			Precondition: &models.FixProbe{Command: probe},
+			Verification: &models.FixProbe{
+				Command: probe,
+				Expect:  "value > before",
+				Settle:  fixSettle,
+			},
 		}
 	}
 	return false, nil
 }
 
+// ExecuteFix applies the fix, probing the backend or the table statistics
+// before and after it. Terminating a backend and refreshing statistics cannot
+// be undone, so these fixes have no compensating command.
 func (p *postgresPlugin) ExecuteFix(ctx context.Context, fix *models.FixAction) (*models.FixResult, error) {
-	return p.fixer.Execute(ctx, fix, func(ctx context.Context) error {
-		if fix.Command == "PG_TERMINATE_BACKEND" {
-			pid := fix.Parameters["pid"]
-			if pid == "" {
-				return fmt.Errorf("missing pid parameter")
+	return p.fixer.Run(ctx, fix, func(ctx context.Context, command string) (interface{}, error) {
+		return p.command(ctx, command, fix.Parameters)
+	})
+}
+
+// command runs a fix command or probe against the backend or table named by
+// the fix parameters. Probes return the backend's state ("gone" once it has
+// exited) or the table's last vacuum or analyze time ("" if never).
+func (p *postgresPlugin) command(ctx context.Context, command string, params map[string]string) (interface{}, error) {
+	switch command {
+	case "PG_TERMINATE_BACKEND":
+		pid, err := strconv.Atoi(params["pid"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pid parameter %q", params["pid"])
+		}
+		// Matching backend_start guards against terminating a new backend that reused the PID
+		query := "SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE pid = $1"
+		args := []interface{}{pid}
+		if start := parseTime(params["backend_start"]); !start.IsZero() {
+			query += " AND backend_start = $2"
+			args = append(args, start)
+		}
+		var terminated bool
+		err = p.db.QueryRowContext(ctx, query, args...).Scan(&terminated)
+		if err == sql.ErrNoRows {
+			p.Log.Infof("Backend %d already exited.", pid)
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !terminated {
+			return nil, fmt.Errorf("pg_terminate_backend(%d) returned false", pid)
+		}
+		return nil, nil
+	case "PG_BACKEND_STATE":
+		pid, err := strconv.Atoi(params["pid"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pid parameter %q", params["pid"])
+		}
+		backend, err := p.collector.GetBackend(ctx, pid, parseTime(params["backend_start"]))
+		if err != nil {
+			return nil, err
+		}
+		if backend == nil {
+			return "gone", nil
+		}
+		return backend.State, nil
+	case "ANALYZE", "VACUUM_ANALYZE":
+		tableName := params["table"]
+		if tableName == "" {
+			return nil, fmt.Errorf("missing table parameter")
+		}
+		schema := params["schema"]
+		if schema == "" {
+			schema = "public"
+		}
+		verb := "ANALYZE"
+		if command == "VACUUM_ANALYZE" {
+			verb = "VACUUM ANALYZE"
+		}
+		query := fmt.Sprintf("%s %s.%s", verb, pq.QuoteIdentifier(schema), pq.QuoteIdentifier(tableName))
+		_, err := p.db.ExecContext(ctx, query)
+		return nil, err
+	case "PG_LAST_ANALYZE", "PG_LAST_VACUUM":
+		schema := params["schema"]
+		if schema == "" {
+			schema = "public"
+		}
+		stats, err := p.collector.GetTableStats(ctx, schema, params["table"])
+		if err != nil {
+			return nil, err
+		}
+		last := stats.LastAnalyze
+		if command == "PG_LAST_VACUUM" {
+			last = stats.LastVacuum
+		}
+		return probeTime(last), nil
+	}
+	return p.configStatements(ctx, command)
+}
+
+var (
+	// alterSystemPattern matches the ALTER SYSTEM statements of configuration fixes
+	alterSystemPattern = regexp.MustCompile(`^ALTER SYSTEM SET (\w+(?:\.\w+)?) = '([^'\\]*)'$`)
+	// showPattern matches the probe reading a setting
+	showPattern = regexp.MustCompile(`^SHOW (\w+(?:\.\w+)?)$`)
+)
+
+// configStatements runs the ;-separated statements of a configuration fix one
+// at a time, since ALTER SYSTEM cannot run inside a transaction block. A SHOW
+// probe returns the setting's value.
+func (p *postgresPlugin) configStatements(ctx context.Context, command string) (interface{}, error) {
+	var statements []string
+	for _, stmt := range strings.Split(command, ";") {
+		if stmt = strings.TrimSpace(stmt); stmt == "" {
+			continue
+		}
+		// Nothing runs unless every statement is one of the allowed ones
+		if !alterSystemPattern.MatchString(stmt) && !showPattern.MatchString(stmt) && stmt != "SELECT pg_reload_conf()" {
+			return nil, fmt.Errorf("unknown command: %s", stmt)
+		}
+		statements = append(statements, stmt)
+	}
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("unknown command: %s", command)
+	}
+
+	var value interface{}
+	for _, stmt := range statements {
+		switch m := alterSystemPattern.FindStringSubmatch(stmt); {
+		case m != nil:
+			query := fmt.Sprintf("ALTER SYSTEM SET %s = %s", m[1], pq.QuoteLiteral(m[2]))
+			if _, err := p.db.ExecContext(ctx, query); err != nil {
+				return nil, err
 			}
-			query := "SELECT pg_terminate_backend($1)"
-			_, err := p.db.ExecContext(ctx, query, pid)
-			return err
-		} else if fix.Command == "ANALYZE" {
-			tableName := fix.Parameters["table"]
-			if tableName == "" {
-				return fmt.Errorf("missing table parameter")
+		case stmt == "SELECT pg_reload_conf()":
+			var reloaded bool
+			if err := p.db.QueryRowContext(ctx, stmt).Scan(&reloaded); err != nil {
+				return nil, err
 			}
-			// Sanitize tableName in real app to prevent SQL injection
-			// simple protection: only allow alphanumeric and underscore
-			if !isValidTableName(tableName) {
-				return fmt.Errorf("invalid table name")
+			if !reloaded {
+				return nil, fmt.Errorf("pg_reload_conf() returned false")
 			}
-			query := fmt.Sprintf("ANALYZE %s", tableName)
-			_, err := p.db.ExecContext(ctx, query)
-			return err
+		case showPattern.MatchString(stmt):
+			var setting string
+			if err := p.db.QueryRowContext(ctx, stmt).Scan(&setting); err != nil {
+				return nil, err
+			}
+			value = setting
 		}
-		return fmt.Errorf("unknown command")
-	}, nil)
+	}
+	return value, nil
 }
 
-func (p *postgresPlugin) ValidateFix(ctx context.Context, issue *models.Issue, result *models.FixResult) (bool, string, error) {
-	return true, "Assumed success", nil
+// probeTime formats t so that later times compare greater as strings, and
+// a zero time as "".
+func probeTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02T15:04:05.000000Z")
 }
 
-func isValidTableName(name string) bool {
-	for _, r := range name {
-		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_' {
-			return false
+// ValidateFix re-queries the server: a terminated backend must be gone, and a
+// vacuumed or analyzed table must report a newer vacuum/analyze time than the
+// one recorded in the issue.
+func (p *postgresPlugin) ValidateFix(ctx context.Context, issue *models.Issue, result *models.FixResult) (bool, string, error) {
+	if result != nil && !result.Success {
+		return false, "Fix did not succeed: " + result.Message, nil
+	}
+
+	switch issue.Title {
+	case IssueTitleIdleTx, IssueTitleLockContention:
+		pid, err := strconv.Atoi(issue.Metadata["pid"])
+		if err != nil {
+			return false, "", fmt.Errorf("issue has no valid pid: %q", issue.Metadata["pid"])
+		}
+		backend, err := p.collector.GetBackend(ctx, pid, parseTime(issue.Metadata["backend_start"]))
+		if err != nil {
+			return false, "", fmt.Errorf("failed to query backend %d: %w", pid, err)
+		}
+		if backend != nil {
+			return false, fmt.Sprintf("Backend %d is still running (state %q).", pid, backend.State), nil
+		}
+		return true, fmt.Sprintf("Backend %d is gone.", pid), nil
+
+	case IssueTitleNeedAnalyze, IssueTitleNeedVacuum:
+		schema, table := issue.Metadata["schema"], issue.Metadata["table"]
+		if schema == "" {
+			schema = "public"
 		}
+		stats, err := p.collector.GetTableStats(ctx, schema, table)
+		if err != nil {
+			return false, "", fmt.Errorf("failed to query statistics of %s.%s: %w", schema, table, err)
+		}
+		if issue.Title == IssueTitleNeedVacuum {
+			before := parseTime(issue.Metadata["last_vacuum"])
+			if !stats.LastVacuum.After(before) {
+				return false, fmt.Sprintf("Table %s has not been vacuumed since %s.", stats.QualifiedName(), describeTime(before)), nil
+			}
+			return true, fmt.Sprintf("Table %s vacuumed at %s; %d dead tuples remain.", stats.QualifiedName(), describeTime(stats.LastVacuum), stats.DeadTuples), nil
+		}
+		before := parseTime(issue.Metadata["last_analyze"])
+		if !stats.LastAnalyze.After(before) {
+			return false, fmt.Sprintf("Statistics of %s have not been refreshed since %s.", stats.QualifiedName(), describeTime(before)), nil
+		}
+		return true, fmt.Sprintf("Statistics of %s refreshed at %s.", stats.QualifiedName(), describeTime(stats.LastAnalyze)), nil
 	}
-	return true
+
+	return false, "", fmt.Errorf("no validation available for issue %q", issue.Title)
 }
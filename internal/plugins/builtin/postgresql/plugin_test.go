@@ -0,0 +1,196 @@
+package postgresql
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockPlugin(t *testing.T) (*postgresPlugin, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	p := &postgresPlugin{}
+	p.Plugin.Init("postgresql", "1.0.0", "PostgreSQL diagnostic plugin")
+	p.setDB(db)
+
+	settle := fixSettle
+	fixSettle = 0
+	t.Cleanup(func() { fixSettle = settle })
+	return p, mock
+}
+
+var backendCols = []string{"pid", "usename", "datname", "application_name", "client_addr", "state", "query", "backend_start", "xact_age", "state_age"}
+
+func TestIdleTransaction_TargetsExactBackend(t *testing.T) {
+	p, mock := newMockPlugin(t)
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM pg_stat_activity\\s+WHERE state IN").WillReturnRows(sqlmock.NewRows(backendCols).
+		AddRow(4242, "app", "orders", "billing", "10.0.0.5", "idle in transaction", "UPDATE orders SET paid = true", start, 900.0, 840.0).
+		AddRow(4243, "app", "orders", "billing", "10.0.0.6", "idle in transaction", "SELECT 1", start, 10.0, 10.0))
+
+	backends, err := p.collector.CollectIdleInTransaction(ctx)
+	require.NoError(t, err)
+	issues := p.analyzer.Analyze(&Snapshot{IdleInTransaction: backends})
+	require.Len(t, issues, 1, "only backends idle beyond the threshold are reported")
+	assert.Equal(t, IssueTitleIdleTx, issues[0].Title)
+	assert.Equal(t, "4242", issues[0].Metadata["pid"])
+	assert.Contains(t, issues[0].Evidence, "PID 4242")
+
+	ok, fix := p.CanAutoFix(issues[0])
+	require.True(t, ok)
+	assert.Equal(t, "4242", fix.Parameters["pid"])
+
+	// The backend's state is probed before and after it is terminated
+	mock.ExpectQuery("FROM pg_stat_activity WHERE pid = \\$1").WithArgs(4242).WillReturnRows(sqlmock.NewRows(backendCols).
+		AddRow(4242, "app", "orders", "billing", "10.0.0.5", "idle in transaction", "UPDATE orders SET paid = true", start, 900.0, 840.0))
+	mock.ExpectQuery("SELECT pg_terminate_backend\\(pid\\) FROM pg_stat_activity WHERE pid = \\$1 AND backend_start = \\$2").
+		WithArgs(4242, start).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_terminate_backend"}).AddRow(true))
+	mock.ExpectQuery("FROM pg_stat_activity WHERE pid = \\$1").WithArgs(4242).WillReturnRows(sqlmock.NewRows(backendCols))
+	result, err := p.ExecuteFix(ctx, fix)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "idle in transaction", result.Before)
+	assert.Equal(t, "gone", result.After)
+
+	// Validation re-queries pg_stat_activity: the backend is gone
+	mock.ExpectQuery("FROM pg_stat_activity WHERE pid = \\$1").WithArgs(4242).WillReturnRows(sqlmock.NewRows(backendCols))
+	valid, msg, err := p.ValidateFix(ctx, issues[0], result)
+	require.NoError(t, err)
+	assert.True(t, valid, msg)
+
+	// A backend that reused the PID is not mistaken for the original one
+	mock.ExpectQuery("FROM pg_stat_activity WHERE pid = \\$1").WithArgs(4242).WillReturnRows(sqlmock.NewRows(backendCols).
+		AddRow(4242, "app", "orders", "billing", "10.0.0.5", "active", "SELECT 1", start.Add(time.Hour), 0.0, 0.0))
+	valid, _, err = p.ValidateFix(ctx, issues[0], result)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNeedAnalyze_ValidatesFreshStatistics(t *testing.T) {
+	p, mock := newMockPlugin(t)
+	ctx := context.Background()
+	analyzedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tableCols := []string{"schemaname", "relname", "n_live_tup", "n_dead_tup", "n_mod_since_analyze", "last_vacuum", "last_analyze"}
+
+	issues := p.analyzer.Analyze(&Snapshot{Tables: []TableStats{
+		{Schema: "sales", Name: "orders", LiveTuples: 10000, DeadTuples: 100, ModsSinceAnalyze: 5000, LastAnalyze: analyzedAt},
+		{Schema: "sales", Name: "customers", LiveTuples: 10000, DeadTuples: 100, ModsSinceAnalyze: 10},
+	}})
+	require.Len(t, issues, 1)
+	assert.Equal(t, IssueTitleNeedAnalyze, issues[0].Title)
+
+	ok, fix := p.CanAutoFix(issues[0])
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"schema": "sales", "table": "orders"}, fix.Parameters)
+
+	// Statistics that were not refreshed fail the verification
+	mock.ExpectQuery("FROM pg_stat_user_tables WHERE schemaname = \\$1 AND relname = \\$2").WithArgs("sales", "orders").
+		WillReturnRows(sqlmock.NewRows(tableCols).AddRow("sales", "orders", 10000, 100, 5000, nil, analyzedAt))
+	mock.ExpectExec(`ANALYZE "sales"\."orders"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FROM pg_stat_user_tables WHERE schemaname = \\$1 AND relname = \\$2").WithArgs("sales", "orders").
+		WillReturnRows(sqlmock.NewRows(tableCols).AddRow("sales", "orders", 10000, 100, 5000, nil, analyzedAt))
+	result, err := p.ExecuteFix(ctx, fix)
+	require.Error(t, err)
+	assert.False(t, result.Success)
+
+	mock.ExpectQuery("FROM pg_stat_user_tables WHERE schemaname = \\$1 AND relname = \\$2").WithArgs("sales", "orders").
+		WillReturnRows(sqlmock.NewRows(tableCols).AddRow("sales", "orders", 10000, 100, 5000, nil, analyzedAt))
+	mock.ExpectExec(`ANALYZE "sales"\."orders"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FROM pg_stat_user_tables WHERE schemaname = \\$1 AND relname = \\$2").WithArgs("sales", "orders").
+		WillReturnRows(sqlmock.NewRows(tableCols).AddRow("sales", "orders", 10000, 100, 0, nil, analyzedAt.Add(time.Hour)))
+	result, err = p.ExecuteFix(ctx, fix)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-05-01T10:00:00.000000Z", result.Before)
+	assert.Equal(t, "2024-05-01T11:00:00.000000Z", result.After)
+
+	// Statistics not refreshed yet
+	mock.ExpectQuery("FROM pg_stat_user_tables WHERE schemaname = \\$1 AND relname = \\$2").WithArgs("sales", "orders").
+		WillReturnRows(sqlmock.NewRows(tableCols).AddRow("sales", "orders", 10000, 100, 5000, nil, analyzedAt))
+	valid, _, err := p.ValidateFix(ctx, issues[0], result)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	mock.ExpectQuery("FROM pg_stat_user_tables WHERE schemaname = \\$1 AND relname = \\$2").WithArgs("sales", "orders").
+		WillReturnRows(sqlmock.NewRows(tableCols).AddRow("sales", "orders", 10000, 100, 0, nil, analyzedAt.Add(time.Hour)))
+	valid, _, err = p.ValidateFix(ctx, issues[0], result)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConfigFix_ReloadsAndRestoresPreviousValue(t *testing.T) {
+	p, mock := newMockPlugin(t)
+	ctx := context.Background()
+	fix := &models.FixAction{
+		ID:              "fix-work-mem",
+		Command:         "ALTER SYSTEM SET work_mem = '64MB'; SELECT pg_reload_conf()",
+		RollbackCommand: "ALTER SYSTEM SET work_mem = '{{before}}'; SELECT pg_reload_conf()",
+		Precondition:    &models.FixProbe{Command: "SHOW work_mem"},
+		Verification:    &models.FixProbe{Command: "SHOW work_mem", Expect: `value == "64MB" || value != before`},
+	}
+	show := func(value string) {
+		mock.ExpectQuery("SHOW work_mem").WillReturnRows(sqlmock.NewRows([]string{"work_mem"}).AddRow(value))
+	}
+	alter := func(value string) {
+		mock.ExpectExec(regexp.QuoteMeta("ALTER SYSTEM SET work_mem = '" + value + "'")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_reload_conf()")).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_reload_conf"}).AddRow(true))
+	}
+
+	// Each statement runs on its own and the reload applies the setting
+	show("4MB")
+	alter("64MB")
+	show("64MB")
+	result, err := p.ExecuteFix(ctx, fix)
+	require.NoError(t, err)
+	assert.Equal(t, "4MB", result.Before)
+	assert.Equal(t, "64MB", result.After)
+	assert.Equal(t, "ALTER SYSTEM SET work_mem = '4MB'; SELECT pg_reload_conf()", result.Compensation)
+
+	// A setting that did not take is set back to the value it had
+	show("4MB")
+	alter("64MB")
+	show("4MB")
+	alter("4MB")
+	result, err = p.ExecuteFix(ctx, fix)
+	require.Error(t, err)
+	assert.True(t, result.RolledBack, result.Message)
+
+	_, err = p.ExecuteFix(ctx, &models.FixAction{ID: "fix-drop", Command: "ALTER SYSTEM SET work_mem = '1MB'; DROP TABLE orders"})
+	require.Error(t, err, "only configuration statements are run")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLockContention_ReportsLongestWait(t *testing.T) {
+	p, _ := newMockPlugin(t)
+
+	issues := p.analyzer.Analyze(&Snapshot{LockWaits: []LockWait{
+		{BlockedPID: 201, BlockingPID: 100, WaitDuration: 10 * time.Second},
+		{BlockedPID: 202, BlockingPID: 100, WaitDuration: 3 * time.Minute},
+		{BlockedPID: 203, BlockingPID: 100, WaitDuration: 45 * time.Second},
+		{BlockedPID: 301, BlockingPID: 300, WaitDuration: 5 * time.Second},
+	}})
+
+	// The first row of a blocker is below the threshold, its longest wait is not
+	require.Len(t, issues, 1)
+	assert.Equal(t, IssueTitleLockContention, issues[0].Title)
+	assert.Equal(t, "100", issues[0].Metadata["pid"])
+	assert.Equal(t, "202", issues[0].Metadata["blocked_pid"])
+	assert.Equal(t, "180", issues[0].Metadata["wait_seconds"])
+	assert.Contains(t, issues[0].Evidence, "the longest wait is 3m0s")
+}
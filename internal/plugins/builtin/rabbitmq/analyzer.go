@@ -0,0 +1,335 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rabbitmq
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+)
+
+// Analyzer is responsible for analyzing collected RabbitMQ data to identify issues.
+type Analyzer struct {
+	log logger.Logger
+}
+
+// NewAnalyzer creates a new analyzer for RabbitMQ data.
+func NewAnalyzer(log logger.Logger) *Analyzer {
+	return &Analyzer{log: log}
+}
+
+// Thresholds used by the evidence-based checks.
+const (
+	// memoryUsage is relative to the node's memory high watermark, at
+	// which the memory alarm blocks publishers
+	memoryUsage        = 0.8
+	fdUsage            = 0.8
+	queueDepthWarning  = 1000
+	queueDepthCritical = 5000
+	// growthMinSamples is the number of depth samples that must all be
+	// non-decreasing for a queue to count as growing
+	growthMinSamples = 3
+	// connectionChurnRate is the rate of newly opened connections per
+	// second above which clients are assumed not to reuse connections
+	connectionChurnRate = 10.0
+	// maxListedConnections bounds the connections named in one issue
+	maxListedConnections = 50
+)
+
+// Analyze is the main entry point for the analyzer. Issues that concern
+// specific queues or connections carry their identifiers in Issue.Metadata.
+func (a *Analyzer) Analyze(snapshot *Snapshot) []*models.Issue {
+	var issues []*models.Issue
+	a.log.Info("Analyzing collected RabbitMQ data.")
+
+	issues = append(issues, a.analyzeNodes(snapshot.Nodes)...)
+	issues = append(issues, a.analyzeQueues(snapshot.Queues, snapshot.DeadLetterQueues)...)
+	issues = append(issues, a.analyzeConnections(snapshot.Connections)...)
+	if snapshot.Overview != nil {
+		issues = append(issues, a.analyzeChurn(snapshot.Overview)...)
+	}
+	return issues
+}
+
+func (a *Analyzer) analyzeNodes(nodes []Node) []*models.Issue {
+	var issues []*models.Issue
+	for _, n := range nodes {
+		if !n.Running {
+			issues = append(issues, &models.Issue{
+				Title:    IssueTitleNodeDown,
+				Severity: enum.SeverityHigh,
+				Evidence: fmt.Sprintf("Cluster node %s is not running.", n.Name),
+				Metadata: map[string]string{"node": n.Name},
+				Recommendations: []*models.Recommendation{{
+					Description: "Queues whose leader or only replica lives on the node are unavailable. Check the node's process and logs and restart it.",
+				}},
+			})
+			continue
+		}
+
+		if len(n.Partitions) > 0 {
+			issues = append(issues, &models.Issue{
+				Title:    IssueTitlePartition,
+				Severity: enum.SeverityCritical,
+				Evidence: fmt.Sprintf("Node %s is partitioned from %s.", n.Name, strings.Join(n.Partitions, ", ")),
+				Metadata: map[string]string{"node": n.Name, "partitions": strings.Join(n.Partitions, ",")},
+				Recommendations: []*models.Recommendation{{
+					Description: "Both sides of a partition may accept writes that cannot be merged. Restore connectivity, then restart the nodes of the side to discard (unless cluster_partition_handling = pause_minority or autoheal resolves it), and review the partition handling mode.",
+				}},
+			})
+		}
+
+		if n.MemAlarm {
+			issues = append(issues, &models.Issue{
+				Title:    IssueTitleMemoryAlarm,
+				Severity: enum.SeverityCritical,
+				Evidence: fmt.Sprintf("Memory alarm on %s: %s used of a %s high watermark. Publishing connections are blocked cluster-wide.", n.Name, formatBytes(n.MemUsed), formatBytes(n.MemLimit)),
+				Metadata: map[string]string{"node": n.Name, "mem_used": strconv.FormatInt(n.MemUsed, 10), "mem_limit": strconv.FormatInt(n.MemLimit, 10)},
+				Recommendations: []*models.Recommendation{{
+					Description: "Drain the largest queues by adding consumers or purging messages that are no longer needed, and check for consumers that do not acknowledge. Raise vm_memory_high_watermark only if the node has free memory.",
+				}},
+			})
+		} else if n.MemLimit > 0 && float64(n.MemUsed) >= memoryUsage*float64(n.MemLimit) {
+			issues = append(issues, &models.Issue{
+				Title:    IssueTitleHighMemory,
+				Severity: enum.SeverityWarning,
+				Evidence: fmt.Sprintf("Node %s uses %s, %.1f%% of its %s memory high watermark.", n.Name, formatBytes(n.MemUsed), percent(n.MemUsed, n.MemLimit), formatBytes(n.MemLimit)),
+				Metadata: map[string]string{"node": n.Name, "mem_used": strconv.FormatInt(n.MemUsed, 10), "mem_limit": strconv.FormatInt(n.MemLimit, 10)},
+				Recommendations: []*models.Recommendation{{
+					Description: "Publishers are blocked when the watermark is reached. Reduce queue backlogs, or use lazy or quorum queues to keep messages out of memory.",
+				}},
+			})
+		}
+
+		if n.DiskFreeAlarm {
+			issues = append(issues, &models.Issue{
+				Title:    IssueTitleDiskAlarm,
+				Severity: enum.SeverityCritical,
+				Evidence: fmt.Sprintf("Disk alarm on %s: %s free, below the %s limit. Publishing connections are blocked cluster-wide.", n.Name, formatBytes(n.DiskFree), formatBytes(n.DiskFreeLimit)),
+				Metadata: map[string]string{"node": n.Name, "disk_free": strconv.FormatInt(n.DiskFree, 10), "disk_free_limit": strconv.FormatInt(n.DiskFreeLimit, 10)},
+				Recommendations: []*models.Recommendation{{
+					Description: "Free disk space on the node's data directory or grow the volume. Persistent and paged-out messages of large queues are the usual cause.",
+				}},
+			})
+		}
+
+		if n.FDTotal > 0 && float64(n.FDUsed) >= fdUsage*float64(n.FDTotal) {
+			issues = append(issues, &models.Issue{
+				Title:    IssueTitleFDExhaustion,
+				Severity: enum.SeverityWarning,
+				Evidence: fmt.Sprintf("Node %s uses %d of %d file descriptors (%.1f%%); %d sockets are open.", n.Name, n.FDUsed, n.FDTotal, percent(n.FDUsed, n.FDTotal), n.SocketsUsed),
+				Metadata: map[string]string{"node": n.Name, "fd_used": strconv.FormatInt(n.FDUsed, 10), "fd_total": strconv.FormatInt(n.FDTotal, 10)},
+				Recommendations: []*models.Recommendation{{
+					Description: "New connections are refused once file descriptors run out. Look for clients that leak connections, and raise the open file limit (ulimit -n / LimitNOFILE) of the node.",
+				}},
+			})
+		}
+	}
+	return issues
+}
+
+func (a *Analyzer) analyzeQueues(queues []Queue, deadLetterQueues map[string]bool) []*models.Issue {
+	var issues []*models.Issue
+	for i := range queues {
+		q := &queues[i]
+		metadata := map[string]string{
+			"vhost":                   q.Vhost,
+			"queue":                   q.Name,
+			"messages":                strconv.FormatInt(q.Messages, 10),
+			"messages_ready":          strconv.FormatInt(q.MessagesReady, 10),
+			"messages_unacknowledged": strconv.FormatInt(q.MessagesUnacknowledged, 10),
+			"consumers":               strconv.FormatInt(q.Consumers, 10),
+		}
+
+		if deadLetterQueues[queueKey(q.Vhost, q.Name)] {
+			if q.Messages < queueDepthWarning {
+				continue
+			}
+			issues = append(issues, &models.Issue{
+				Title:    IssueTitleDeadLetterBacklog,
+				Severity: enum.SeverityWarning,
+				Evidence: fmt.Sprintf("Dead-letter queue %s in vhost %s holds %d messages (%d ready) rejected or expired elsewhere.", q.Name, q.Vhost, q.Messages, q.MessagesReady),
+				Metadata: metadata,
+				Recommendations: []*models.Recommendation{{
+					Description: "Inspect a sample of the messages to find why they were dead-lettered, fix the cause, then replay or purge them. Purging deletes the ready messages permanently.",
+				}},
+			})
+			continue
+		}
+
+		if growth, window, growing := queueGrowth(q); growing {
+			severity := enum.SeverityWarning
+			if q.Messages >= queueDepthCritical {
+				severity = enum.SeverityCritical
+			}
+			evidence := fmt.Sprintf("Queue %s in vhost %s grew by %.0f messages over the last %s to %d (%d ready, %d unacknowledged) with %d consumer(s); publish rate %.1f/s, deliver rate %.1f/s.",
+				q.Name, q.Vhost, growth, window, q.Messages, q.MessagesReady, q.MessagesUnacknowledged, q.Consumers,
+				q.MessageStats.PublishDetails.Rate, q.MessageStats.DeliverGetDetails.Rate)
+			if _, bounded := q.Setting("max-length"); !bounded {
+				if _, bounded = q.Setting("max-length-bytes"); !bounded {
+					evidence += " The queue has no length limit."
+				}
+			}
+			metadata["growth"] = fmt.Sprintf("%.0f", growth)
+			issues = append(issues, &models.Issue{
+				Title:    IssueTitleQueueGrowth,
+				Severity: severity,
+				Evidence: evidence,
+				Metadata: metadata,
+				Recommendations: []*models.Recommendation{{
+					Description: "Consumers do not keep up with publishers. Add consumers or fix failing ones, and bound the queue with a max-length policy (with a dead-letter exchange) so a backlog cannot exhaust memory or disk.",
+				}},
+			})
+			continue
+		}
+
+		if q.Consumers == 0 && q.MessagesReady > 0 {
+			issues = append(issues, &models.Issue{
+				Title:    IssueTitleNoConsumers,
+				Severity: enum.SeverityWarning,
+				Evidence: fmt.Sprintf("Queue %s in vhost %s has %d ready messages and no consumers.", q.Name, q.Vhost, q.MessagesReady),
+				Metadata: metadata,
+				Recommendations: []*models.Recommendation{{
+					Description: "Check that the consuming application is running and subscribed to this queue. Delete the queue if it is no longer used, or set a message TTL so stale messages do not accumulate.",
+				}},
+			})
+		}
+	}
+	return issues
+}
+
+// queueGrowth reports whether the depth samples of q never decreased and
+// ended above the warning depth, with the growth and the sampled window.
+func queueGrowth(q *Queue) (float64, time.Duration, bool) {
+	samples := q.MessagesDetails.Samples
+	if q.Messages < queueDepthWarning || len(samples) < growthMinSamples {
+		return 0, 0, false
+	}
+	// Samples are ordered newest first
+	for i := 0; i < len(samples)-1; i++ {
+		if samples[i].Sample < samples[i+1].Sample {
+			return 0, 0, false
+		}
+	}
+	newest, oldest := samples[0], samples[len(samples)-1]
+	growth := newest.Sample - oldest.Sample
+	window := time.Duration(newest.Timestamp-oldest.Timestamp) * time.Millisecond
+	return growth, window, growth > 0
+}
+
+func (a *Analyzer) analyzeConnections(conns []Connection) []*models.Issue {
+	var blocked, flow []Connection
+	for _, c := range conns {
+		switch c.State {
+		case "blocked", "blocking":
+			blocked = append(blocked, c)
+		case "flow":
+			flow = append(flow, c)
+		}
+	}
+
+	var issues []*models.Issue
+	if len(blocked) > 0 {
+		names := connectionNames(blocked)
+		encoded, _ := json.Marshal(names)
+		issues = append(issues, &models.Issue{
+			Title:    IssueTitleBlockedConnections,
+			Severity: enum.SeverityHigh,
+			Evidence: fmt.Sprintf("%d connection(s) are blocked by a resource alarm: %s.", len(blocked), describeConnections(blocked)),
+			Metadata: map[string]string{"connections": string(encoded), "count": strconv.Itoa(len(blocked))},
+			Recommendations: []*models.Recommendation{{
+				Description: "Connections stay blocked until the memory or disk alarm clears; resolve the alarm first. Closing blocked connections frees their resources, but clients that reconnect are blocked again while the alarm lasts.",
+			}},
+		})
+	}
+	if len(flow) > 0 {
+		issues = append(issues, &models.Issue{
+			Title:    IssueTitleFlowControl,
+			Severity: enum.SeverityWarning,
+			Evidence: fmt.Sprintf("%d connection(s) are throttled by flow control: %s.", len(flow), describeConnections(flow)),
+			Metadata: map[string]string{"count": strconv.Itoa(len(flow))},
+			Recommendations: []*models.Recommendation{{
+				Description: "Flow control slows publishers the broker cannot keep up with, typically because queues are busy persisting or replicating messages. Spread load across more queues, or reduce the publish rate.",
+			}},
+		})
+	}
+	return issues
+}
+
+func (a *Analyzer) analyzeChurn(o *Overview) []*models.Issue {
+	created := o.ChurnRates.ConnectionCreatedDetails.Rate
+	if created < connectionChurnRate {
+		return nil
+	}
+	return []*models.Issue{{
+		Title:    IssueTitleConnectionChurn,
+		Severity: enum.SeverityWarning,
+		Evidence: fmt.Sprintf("%.1f connections/s are opened and %.1f/s closed (%.1f channels/s opened) with %d connections open.",
+			created, o.ChurnRates.ConnectionClosedDetails.Rate, o.ChurnRates.ChannelCreatedDetails.Rate, o.ObjectTotals.Connections),
+		Metadata: map[string]string{
+			"connection_created_rate": fmt.Sprintf("%.1f", created),
+			"connection_closed_rate":  fmt.Sprintf("%.1f", o.ChurnRates.ConnectionClosedDetails.Rate),
+		},
+		Recommendations: []*models.Recommendation{{
+			Description: "Opening a connection per message or request is expensive for the broker. Use long-lived connections and channels (connection pooling in the clients).",
+		}},
+	}}
+}
+
+func connectionNames(conns []Connection) []string {
+	names := make([]string, 0, len(conns))
+	for i, c := range conns {
+		if i == maxListedConnections {
+			break
+		}
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func describeConnections(conns []Connection) string {
+	parts := make([]string, 0, len(conns))
+	for i, c := range conns {
+		if i == 5 {
+			parts = append(parts, fmt.Sprintf("and %d more", len(conns)-i))
+			break
+		}
+		desc := c.Name + " (user " + c.User
+		if app, _ := c.ClientProperties["connection_name"].(string); app != "" {
+			desc += ", " + app
+		}
+		parts = append(parts, desc+")")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,316 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned for objects the management API does not know,
+// such as a queue that was deleted or a connection that was closed.
+var ErrNotFound = errors.New("not found")
+
+// Client is a minimal client of the RabbitMQ management HTTP API.
+type Client struct {
+	baseURL  string
+	username string
+	password string
+	http     *http.Client
+}
+
+// NewClient creates a client for the management API at baseURL, e.g.
+// http://localhost:15672. Credentials in the URL are used when username is
+// empty.
+func NewClient(baseURL, username, password string) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid management API URL %q", baseURL)
+	}
+	if u.User != nil {
+		if username == "" {
+			username = u.User.Username()
+			password, _ = u.User.Password()
+		}
+		u.User = nil
+	}
+	return &Client{
+		baseURL:  strings.TrimSuffix(u.String(), "/"),
+		username: username,
+		password: password,
+		http:     &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Rate is a counter's rate over the sampling window, with its samples
+// (newest first) when the request asked for them.
+type Rate struct {
+	Rate    float64  `json:"rate"`
+	Samples []Sample `json:"samples"`
+}
+
+// Sample is one value of a sampled counter or gauge.
+type Sample struct {
+	Sample    float64 `json:"sample"`
+	Timestamp int64   `json:"timestamp"` // milliseconds since the epoch
+}
+
+// MessageStats holds message rates of the broker or a queue.
+type MessageStats struct {
+	PublishDetails    Rate `json:"publish_details"`
+	DeliverGetDetails Rate `json:"deliver_get_details"`
+	AckDetails        Rate `json:"ack_details"`
+	RedeliverDetails  Rate `json:"redeliver_details"`
+}
+
+// ChurnRates holds the rates at which connections and channels are opened and closed.
+type ChurnRates struct {
+	ConnectionCreatedDetails Rate `json:"connection_created_details"`
+	ConnectionClosedDetails  Rate `json:"connection_closed_details"`
+	ChannelCreatedDetails    Rate `json:"channel_created_details"`
+	ChannelClosedDetails     Rate `json:"channel_closed_details"`
+}
+
+// Overview is the cluster-wide summary from /api/overview.
+type Overview struct {
+	ClusterName     string       `json:"cluster_name"`
+	RabbitMQVersion string       `json:"rabbitmq_version"`
+	ErlangVersion   string       `json:"erlang_version"`
+	MessageStats    MessageStats `json:"message_stats"`
+	ChurnRates      ChurnRates   `json:"churn_rates"`
+	QueueTotals     struct {
+		Messages               int64 `json:"messages"`
+		MessagesReady          int64 `json:"messages_ready"`
+		MessagesUnacknowledged int64 `json:"messages_unacknowledged"`
+	} `json:"queue_totals"`
+	ObjectTotals struct {
+		Connections int64 `json:"connections"`
+		Channels    int64 `json:"channels"`
+		Queues      int64 `json:"queues"`
+		Consumers   int64 `json:"consumers"`
+	} `json:"object_totals"`
+}
+
+// Node is a cluster node from /api/nodes.
+type Node struct {
+	Name          string `json:"name"`
+	Running       bool   `json:"running"`
+	MemUsed       int64  `json:"mem_used"`
+	MemLimit      int64  `json:"mem_limit"`
+	MemAlarm      bool   `json:"mem_alarm"`
+	DiskFree      int64  `json:"disk_free"`
+	DiskFreeLimit int64  `json:"disk_free_limit"`
+	DiskFreeAlarm bool   `json:"disk_free_alarm"`
+	FDUsed        int64  `json:"fd_used"`
+	FDTotal       int64  `json:"fd_total"`
+	SocketsUsed   int64  `json:"sockets_used"`
+	SocketsTotal  int64  `json:"sockets_total"`
+	// Partitions lists the nodes this node cannot reach since a network partition.
+	Partitions []string `json:"partitions"`
+}
+
+// Queue is a queue from /api/queues.
+type Queue struct {
+	Name                   string                 `json:"name"`
+	Vhost                  string                 `json:"vhost"`
+	Node                   string                 `json:"node"`
+	State                  string                 `json:"state"`
+	Messages               int64                  `json:"messages"`
+	MessagesReady          int64                  `json:"messages_ready"`
+	MessagesUnacknowledged int64                  `json:"messages_unacknowledged"`
+	MessagesDetails        Rate                   `json:"messages_details"`
+	Consumers              int64                  `json:"consumers"`
+	MessageStats           MessageStats           `json:"message_stats"`
+	Arguments              map[string]interface{} `json:"arguments"`
+	Policy                 string                 `json:"policy"`
+	// EffectivePolicy is an object, or an empty list when no policy applies.
+	EffectivePolicy json.RawMessage `json:"effective_policy_definition"`
+}
+
+// Setting returns a queue setting from its x-arguments, or from its
+// effective policy, where the same setting has no "x-" prefix.
+func (q *Queue) Setting(name string) (interface{}, bool) {
+	if v, ok := q.Arguments["x-"+name]; ok {
+		return v, true
+	}
+	var policy map[string]interface{}
+	if json.Unmarshal(q.EffectivePolicy, &policy) == nil {
+		if v, ok := policy[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Connection is a client connection from /api/connections.
+type Connection struct {
+	Name  string `json:"name"`
+	Vhost string `json:"vhost"`
+	User  string `json:"user"`
+	// State is running, flow (throttled by credit flow), blocking (will be
+	// blocked on its next publish) or blocked (by a resource alarm).
+	State            string                 `json:"state"`
+	PeerHost         string                 `json:"peer_host"`
+	PeerPort         int                    `json:"peer_port"`
+	Channels         int64                  `json:"channels"`
+	ConnectedAt      int64                  `json:"connected_at"`
+	ClientProperties map[string]interface{} `json:"client_properties"`
+}
+
+// Binding routes messages from an exchange to a queue or exchange.
+type Binding struct {
+	Source          string `json:"source"`
+	Vhost           string `json:"vhost"`
+	Destination     string `json:"destination"`
+	DestinationType string `json:"destination_type"`
+}
+
+// Overview returns /api/overview.
+func (c *Client) Overview(ctx context.Context) (*Overview, error) {
+	var o Overview
+	if err := c.get(ctx, "/api/overview", &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// Nodes returns /api/nodes.
+func (c *Client) Nodes(ctx context.Context) ([]Node, error) {
+	var nodes []Node
+	if err := c.get(ctx, "/api/nodes", &nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// Queues returns all queues with their depth sampled over the last
+// lengthsAge, one sample per lengthsIncr.
+func (c *Client) Queues(ctx context.Context, lengthsAge, lengthsIncr time.Duration) ([]Queue, error) {
+	var queues []Queue
+	path := fmt.Sprintf("/api/queues?lengths_age=%d&lengths_incr=%d", int(lengthsAge.Seconds()), int(lengthsIncr.Seconds()))
+	if err := c.get(ctx, path, &queues); err != nil {
+		return nil, err
+	}
+	return queues, nil
+}
+
+// Queue returns one queue, or ErrNotFound.
+func (c *Client) Queue(ctx context.Context, vhost, name string) (*Queue, error) {
+	var q Queue
+	if err := c.get(ctx, "/api/queues/"+url.PathEscape(vhost)+"/"+url.PathEscape(name), &q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// PurgeQueue deletes the ready messages of a queue.
+func (c *Client) PurgeQueue(ctx context.Context, vhost, name string) error {
+	return c.delete(ctx, "/api/queues/"+url.PathEscape(vhost)+"/"+url.PathEscape(name)+"/contents", "")
+}
+
+// Connections returns /api/connections.
+func (c *Client) Connections(ctx context.Context) ([]Connection, error) {
+	var conns []Connection
+	if err := c.get(ctx, "/api/connections", &conns); err != nil {
+		return nil, err
+	}
+	return conns, nil
+}
+
+// Connection returns one connection, or ErrNotFound.
+func (c *Client) Connection(ctx context.Context, name string) (*Connection, error) {
+	var conn Connection
+	if err := c.get(ctx, "/api/connections/"+url.PathEscape(name), &conn); err != nil {
+		return nil, err
+	}
+	return &conn, nil
+}
+
+// CloseConnection closes a client connection; reason is sent to the client.
+func (c *Client) CloseConnection(ctx context.Context, name, reason string) error {
+	return c.delete(ctx, "/api/connections/"+url.PathEscape(name), reason)
+}
+
+// Bindings returns /api/bindings.
+func (c *Client) Bindings(ctx context.Context) ([]Binding, error) {
+	var bindings []Binding
+	if err := c.get(ctx, "/api/bindings", &bindings); err != nil {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+// Policies returns the policies of all vhosts as raw objects.
+func (c *Client) Policies(ctx context.Context) ([]map[string]interface{}, error) {
+	var policies []map[string]interface{}
+	if err := c.get(ctx, "/api/policies", &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	res, err := c.do(ctx, http.MethodGet, path, "")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Client) delete(ctx context.Context, path, reason string) error {
+	res, err := c.do(ctx, http.MethodDelete, path, reason)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path, reason string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.username, c.password)
+	req.Header.Set("Accept", "application/json")
+	if reason != "" {
+		req.Header.Set("X-Reason", reason)
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, fmt.Errorf("%s %s: %w", method, path, ErrNotFound)
+	}
+	if res.StatusCode >= 300 {
+		defer res.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return nil, fmt.Errorf("%s %s failed with status %s: %s", method, path, res.Status, strings.TrimSpace(string(body)))
+	}
+	return res, nil
+}
@@ -0,0 +1,245 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+)
+
+// Queue depth sampling window used to detect growth.
+const (
+	lengthsAge  = 10 * time.Minute
+	lengthsIncr = time.Minute
+)
+
+// Collector is responsible for gathering data from the RabbitMQ management API.
+type Collector struct {
+	client *Client
+	log    logger.Logger
+}
+
+// NewCollector creates a new data collector for RabbitMQ.
+func NewCollector(client *Client, log logger.Logger) *Collector {
+	return &Collector{client: client, log: log}
+}
+
+// Snapshot is the evidence gathered from a RabbitMQ cluster in one diagnosis run.
+type Snapshot struct {
+	Overview    *Overview
+	Nodes       []Node
+	Queues      []Queue
+	Connections []Connection
+	// DeadLetterQueues holds the queues bound to an exchange that other
+	// queues dead-letter to, keyed by queueKey.
+	DeadLetterQueues map[string]bool
+	Metrics          map[string]interface{}
+}
+
+// CollectSnapshot gathers the overview, nodes, queues and connections. Only
+// a failing overview fails the snapshot; the other endpoints may be
+// restricted for a monitoring user, so their failures are logged and skipped.
+func (c *Collector) CollectSnapshot(ctx context.Context) (*Snapshot, error) {
+	c.log.Info("Collecting RabbitMQ overview.")
+	overview, err := c.client.Overview(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overview: %w", err)
+	}
+	snapshot := &Snapshot{Overview: overview}
+
+	if snapshot.Nodes, err = c.client.Nodes(ctx); err != nil {
+		c.log.Warnf("Failed to list nodes: %v", err)
+	}
+	if snapshot.Queues, err = c.client.Queues(ctx, lengthsAge, lengthsIncr); err != nil {
+		c.log.Warnf("Failed to list queues: %v", err)
+	}
+	if snapshot.Connections, err = c.client.Connections(ctx); err != nil {
+		c.log.Warnf("Failed to list connections: %v", err)
+	}
+	if snapshot.DeadLetterQueues, err = c.deadLetterQueues(ctx, snapshot.Queues); err != nil {
+		c.log.Warnf("Failed to list bindings: %v", err)
+	}
+	snapshot.Metrics = deriveMetrics(overview, snapshot.Nodes)
+	return snapshot, nil
+}
+
+// deadLetterQueues finds the queues that receive dead-lettered messages:
+// those bound to an exchange some queue names as its dead-letter exchange.
+func (c *Collector) deadLetterQueues(ctx context.Context, queues []Queue) (map[string]bool, error) {
+	exchanges := make(map[string]bool)
+	for i := range queues {
+		if dlx, ok := queues[i].Setting("dead-letter-exchange"); ok {
+			if name, _ := dlx.(string); name != "" {
+				exchanges[queueKey(queues[i].Vhost, name)] = true
+			}
+		}
+	}
+	if len(exchanges) == 0 {
+		return nil, nil
+	}
+	bindings, err := c.client.Bindings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dlqs := make(map[string]bool)
+	for _, b := range bindings {
+		if b.DestinationType == "queue" && exchanges[queueKey(b.Vhost, b.Source)] {
+			dlqs[queueKey(b.Vhost, b.Destination)] = true
+		}
+	}
+	return dlqs, nil
+}
+
+// queueKey identifies a queue (or exchange) across vhosts.
+func queueKey(vhost, name string) string {
+	return vhost + "/" + name
+}
+
+// CollectMetrics derives a standardized set of key performance indicators
+// from the overview and the node statistics.
+func (c *Collector) CollectMetrics(ctx context.Context) (*models.MetricsData, error) {
+	overview, err := c.client.Overview(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overview: %w", err)
+	}
+	nodes, err := c.client.Nodes(ctx)
+	if err != nil {
+		c.log.Warnf("Failed to list nodes: %v", err)
+	}
+	return &models.MetricsData{Data: deriveMetrics(overview, nodes)}, nil
+}
+
+func deriveMetrics(o *Overview, nodes []Node) map[string]interface{} {
+	metrics := map[string]interface{}{
+		"messages":                     o.QueueTotals.Messages,
+		"messages_ready":               o.QueueTotals.MessagesReady,
+		"messages_unacknowledged":      o.QueueTotals.MessagesUnacknowledged,
+		"publish_rate":                 o.MessageStats.PublishDetails.Rate,
+		"deliver_get_rate":             o.MessageStats.DeliverGetDetails.Rate,
+		"ack_rate":                     o.MessageStats.AckDetails.Rate,
+		"redeliver_rate":               o.MessageStats.RedeliverDetails.Rate,
+		"connections":                  o.ObjectTotals.Connections,
+		"channels":                     o.ObjectTotals.Channels,
+		"queues":                       o.ObjectTotals.Queues,
+		"consumers":                    o.ObjectTotals.Consumers,
+		"connection_created_rate":      o.ChurnRates.ConnectionCreatedDetails.Rate,
+		"connection_closed_rate":       o.ChurnRates.ConnectionClosedDetails.Rate,
+		"channel_created_rate":         o.ChurnRates.ChannelCreatedDetails.Rate,
+		"channel_closed_rate":          o.ChurnRates.ChannelClosedDetails.Rate,
+		"nodes":                        len(nodes),
+		"nodes_running":                0,
+		"alarms":                       0,
+		"partitions":                   0,
+		"mem_usage_percent_max":        0.0,
+		"fd_usage_percent_max":         0.0,
+		"disk_free_bytes_min":          int64(0),
+		"disk_free_limit_margin_bytes": int64(0),
+	}
+	if len(nodes) == 0 {
+		return metrics
+	}
+
+	running, alarms, partitions := 0, 0, 0
+	memMax, fdMax := 0.0, 0.0
+	var diskMin, marginMin int64
+	for _, n := range nodes {
+		if !n.Running {
+			continue
+		}
+		// The margin is negative while the disk alarm is raised
+		if margin := n.DiskFree - n.DiskFreeLimit; running == 0 || margin < marginMin {
+			marginMin = margin
+		}
+		if running == 0 || n.DiskFree < diskMin {
+			diskMin = n.DiskFree
+		}
+		running++
+		if n.MemAlarm {
+			alarms++
+		}
+		if n.DiskFreeAlarm {
+			alarms++
+		}
+		partitions += len(n.Partitions)
+		if n.MemLimit > 0 {
+			memMax = max(memMax, percent(n.MemUsed, n.MemLimit))
+		}
+		if n.FDTotal > 0 {
+			fdMax = max(fdMax, percent(n.FDUsed, n.FDTotal))
+		}
+	}
+	metrics["nodes_running"] = running
+	metrics["alarms"] = alarms
+	metrics["partitions"] = partitions
+	metrics["mem_usage_percent_max"] = memMax
+	metrics["fd_usage_percent_max"] = fdMax
+	metrics["disk_free_bytes_min"] = diskMin
+	metrics["disk_free_limit_margin_bytes"] = marginMin
+	return metrics
+}
+
+func percent(used, total int64) float64 {
+	return float64(used) / float64(total) * 100
+}
+
+// CollectConfig returns the node limits and the policies of all vhosts,
+// keyed as "node.<name>.<limit>" and "policy.<vhost>.<name>.<key>".
+func (c *Collector) CollectConfig(ctx context.Context) (*models.ConfigData, error) {
+	c.log.Info("Collecting RabbitMQ limits and policies.")
+	overview, err := c.client.Overview(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overview: %w", err)
+	}
+	settings := map[string]string{
+		"cluster_name":     overview.ClusterName,
+		"rabbitmq_version": overview.RabbitMQVersion,
+		"erlang_version":   overview.ErlangVersion,
+	}
+	nodes, err := c.client.Nodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	for _, n := range nodes {
+		prefix := "node." + n.Name + "."
+		settings[prefix+"mem_limit"] = fmt.Sprint(n.MemLimit)
+		settings[prefix+"disk_free_limit"] = fmt.Sprint(n.DiskFreeLimit)
+		settings[prefix+"fd_total"] = fmt.Sprint(n.FDTotal)
+	}
+	policies, err := c.client.Policies(ctx)
+	if err != nil {
+		c.log.Warnf("Failed to list policies: %v", err)
+	}
+	for _, p := range policies {
+		prefix := fmt.Sprintf("policy.%v.%v.", p["vhost"], p["name"])
+		settings[prefix+"pattern"] = fmt.Sprint(p["pattern"])
+		settings[prefix+"apply-to"] = fmt.Sprint(p["apply-to"])
+		if def, ok := p["definition"].(map[string]interface{}); ok {
+			for k, v := range def {
+				settings[prefix+k] = fmt.Sprint(v)
+			}
+		}
+	}
+	return &models.ConfigData{Data: settings}, nil
+}
+
+// Ping checks that the management API answers with the configured credentials.
+func (c *Collector) Ping(ctx context.Context) error {
+	_, err := c.client.Overview(ctx)
+	return err
+}
@@ -0,0 +1,285 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rabbitmq implements the built-in plugin for diagnosing RabbitMQ
+// clusters through the management HTTP API.
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/plugins/base"
+)
+
+const (
+	IssueTitleMemoryAlarm        = "Memory Alarm"
+	IssueTitleDiskAlarm          = "Disk Alarm"
+	IssueTitleHighMemory         = "High Memory Usage"
+	IssueTitleFDExhaustion       = "File Descriptor Exhaustion"
+	IssueTitlePartition          = "Network Partition"
+	IssueTitleNodeDown           = "Cluster Node Down"
+	IssueTitleNoConsumers        = "Queue Without Consumers"
+	IssueTitleQueueGrowth        = "Unbounded Queue Growth"
+	IssueTitleDeadLetterBacklog  = "Dead-Letter Queue Backlog"
+	IssueTitleBlockedConnections = "Blocked Connections"
+	IssueTitleFlowControl        = "Connections Under Flow Control"
+	IssueTitleConnectionChurn    = "High Connection Churn"
+)
+
+// DefaultURL is the management API the plugin uses unless configured otherwise.
+const DefaultURL = "http://localhost:15672"
+
+// closeReason is shown to clients whose connection a fix closes.
+const closeReason = "Closed by KubeStack-AI: connection blocked by a resource alarm"
+
+type rabbitmqPlugin struct {
+	base.Plugin
+	client    *Client
+	config    *config.PluginConfig
+	collector *Collector
+	analyzer  *Analyzer
+	fixer     *base.FixExecutor
+}
+
+func New() (interfaces.DiagnosticPlugin, error) {
+	return NewFromURL(DefaultURL, "guest", "guest")
+}
+
+// NewFromURL creates the plugin for the management API at baseURL.
+func NewFromURL(baseURL, username, password string) (interfaces.DiagnosticPlugin, error) {
+	p := &rabbitmqPlugin{}
+	p.Plugin.Init("rabbitmq", "1.0.0", "RabbitMQ diagnostic plugin")
+
+	client, err := NewClient(baseURL, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	p.setClient(client)
+	return p, nil
+}
+
+func (p *rabbitmqPlugin) setClient(client *Client) {
+	p.client = client
+	p.collector = NewCollector(client, p.Log)
+	p.analyzer = NewAnalyzer(p.Log)
+	p.fixer = base.NewFixExecutor(p.Log)
+}
+
+func (p *rabbitmqPlugin) SupportedTypes() []enum.MiddlewareType {
+	return []enum.MiddlewareType{enum.RabbitMQ}
+}
+
+func (p *rabbitmqPlugin) SupportedVersions() []string {
+	return []string{"3.10", "3.11", "3.12", "3.13"}
+}
+
+// Init implements the DiagnosticPlugin interface
+func (p *rabbitmqPlugin) Init(cfg *config.PluginConfig) error {
+	p.config = cfg
+	return nil
+}
+
+func (p *rabbitmqPlugin) Shutdown() error {
+	return nil
+}
+
+func (p *rabbitmqPlugin) Diagnose(ctx context.Context, _ *models.DiagnosisRequest) (*models.DiagnosisResult, error) {
+	snapshot, err := p.collector.CollectSnapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rabbitmq data: %w", err)
+	}
+	issues := p.analyzer.Analyze(snapshot)
+	return &models.DiagnosisResult{
+		ID:        fmt.Sprintf("rabbitmq-diag-%d", time.Now().Unix()),
+		Timestamp: time.Now().UTC(),
+		Summary:   fmt.Sprintf("RabbitMQ diagnosis complete. Found %d potential issues.", len(issues)),
+		Issues:    issues,
+		Metrics:   snapshot.Metrics,
+	}, nil
+}
+
+func (p *rabbitmqPlugin) CollectMetrics(ctx context.Context, target string) (*models.MetricsData, error) {
+	return p.collector.CollectMetrics(ctx)
+}
+
+// CollectLogs is not supported: the management API does not expose the node logs.
+func (p *rabbitmqPlugin) CollectLogs(ctx context.Context, target string, _ *models.LogOptions) (*models.LogData, error) {
+	p.Log.Info("RabbitMQ logs are not available through the management API.")
+	return &models.LogData{Entries: []string{}}, nil
+}
+
+func (p *rabbitmqPlugin) CollectConfig(ctx context.Context, target string) (*models.ConfigData, error) {
+	return p.collector.CollectConfig(ctx)
+}
+
+func (p *rabbitmqPlugin) HealthCheck(ctx context.Context, target string) (*models.HealthStatus, error) {
+	if err := p.Ping(ctx, target); err != nil {
+		return &models.HealthStatus{IsHealthy: false, Message: err.Error()}, nil
+	}
+	return &models.HealthStatus{IsHealthy: true}, nil
+}
+
+func (p *rabbitmqPlugin) Ping(ctx context.Context, target string) error {
+	return p.collector.Ping(ctx)
+}
+
+// --- Fix Capabilities ---
+
+// CanAutoFix offers purging a dead-letter queue backlog and closing blocked
+// connections. Their categories map to execution.DefaultRiskRules: a purge
+// deletes messages ("Destructive") and closing connections forces clients
+// to reconnect ("Restart"), so both require approval.
+func (p *rabbitmqPlugin) CanAutoFix(issue *models.Issue) (bool, *models.FixAction) {
+	switch issue.Title {
+	case IssueTitleDeadLetterBacklog:
+		vhost, queue := issue.Metadata["vhost"], issue.Metadata["queue"]
+		if vhost == "" || queue == "" {
+			return false, nil
+		}
+		return true, &models.FixAction{
+			ID:          "fix-rabbitmq-purge-dlq",
+			Description: fmt.Sprintf("Purge the ready messages of dead-letter queue %s in vhost %s", queue, vhost),
+			Command:     "PURGE_QUEUE",
+			Parameters:  map[string]string{"vhost": vhost, "queue": queue},
+			Category:    "Destructive",
+		}
+	case IssueTitleBlockedConnections:
+		names, err := decodeNames(issue.Metadata["connections"])
+		if err != nil || len(names) == 0 {
+			return false, nil
+		}
+		return true, &models.FixAction{
+			ID:          "fix-rabbitmq-close-blocked",
+			Description: fmt.Sprintf("Close %d blocked connection(s)", len(names)),
+			Command:     "CLOSE_CONNECTIONS",
+			Parameters:  map[string]string{"connections": issue.Metadata["connections"]},
+			Category:    "Restart",
+		}
+	}
+	return false, nil
+}
+
+func (p *rabbitmqPlugin) ExecuteFix(ctx context.Context, fix *models.FixAction) (*models.FixResult, error) {
+	return p.fixer.Execute(ctx, fix, func(ctx context.Context) error {
+		switch fix.Command {
+		case "PURGE_QUEUE":
+			vhost, queue := fix.Parameters["vhost"], fix.Parameters["queue"]
+			if vhost == "" || queue == "" {
+				return fmt.Errorf("missing vhost or queue parameter")
+			}
+			return p.client.PurgeQueue(ctx, vhost, queue)
+		case "CLOSE_CONNECTIONS":
+			names, err := decodeNames(fix.Parameters["connections"])
+			if err != nil {
+				return err
+			}
+			var errs []error
+			for _, name := range names {
+				// Only close connections that are still blocked; a name may
+				// have been reused by a new connection from the same port
+				conn, err := p.client.Connection(ctx, name)
+				if errors.Is(err, ErrNotFound) {
+					p.Log.Infof("Connection %q already closed.", name)
+					continue
+				}
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				if !isBlocked(conn.State) {
+					p.Log.Infof("Connection %q is %s, not closing it.", name, conn.State)
+					continue
+				}
+				if err := p.client.CloseConnection(ctx, name, closeReason); err != nil && !errors.Is(err, ErrNotFound) {
+					errs = append(errs, err)
+				}
+			}
+			return errors.Join(errs...)
+		}
+		return fmt.Errorf("unknown command: %s", fix.Command)
+	}, nil)
+}
+
+// ValidateFix re-queries the management API: a purged queue must hold fewer
+// ready messages than reported, and none of the closed connections may
+// still be blocked.
+func (p *rabbitmqPlugin) ValidateFix(ctx context.Context, issue *models.Issue, result *models.FixResult) (bool, string, error) {
+	if result != nil && !result.Success {
+		return false, "Fix did not succeed: " + result.Message, nil
+	}
+
+	switch issue.Title {
+	case IssueTitleDeadLetterBacklog:
+		vhost, name := issue.Metadata["vhost"], issue.Metadata["queue"]
+		q, err := p.client.Queue(ctx, vhost, name)
+		if errors.Is(err, ErrNotFound) {
+			return true, fmt.Sprintf("Queue %s no longer exists.", name), nil
+		}
+		if err != nil {
+			return false, "", fmt.Errorf("failed to read queue %s: %w", name, err)
+		}
+		before, _ := strconv.ParseInt(issue.Metadata["messages_ready"], 10, 64)
+		if q.MessagesReady >= before && q.MessagesReady > 0 {
+			return false, fmt.Sprintf("Queue %s still holds %d ready messages.", name, q.MessagesReady), nil
+		}
+		return true, fmt.Sprintf("Queue %s holds %d ready messages, down from %d.", name, q.MessagesReady, before), nil
+
+	case IssueTitleBlockedConnections:
+		names, err := decodeNames(issue.Metadata["connections"])
+		if err != nil {
+			return false, "", err
+		}
+		remaining := 0
+		for _, name := range names {
+			conn, err := p.client.Connection(ctx, name)
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return false, "", fmt.Errorf("failed to read connection %q: %w", name, err)
+			}
+			if isBlocked(conn.State) {
+				remaining++
+			}
+		}
+		if remaining > 0 {
+			return false, fmt.Sprintf("%d of %d connections are still blocked.", remaining, len(names)), nil
+		}
+		return true, fmt.Sprintf("None of the %d connections is blocked anymore.", len(names)), nil
+	}
+
+	return false, "", fmt.Errorf("no validation available for issue %q", issue.Title)
+}
+
+func isBlocked(state string) bool {
+	return state == "blocked" || state == "blocking"
+}
+
+func decodeNames(s string) ([]string, error) {
+	var names []string
+	if err := json.Unmarshal([]byte(s), &names); err != nil {
+		return nil, fmt.Errorf("invalid connections parameter %q: %w", s, err)
+	}
+	return names, nil
+}
@@ -29,6 +29,10 @@ type analyzer struct {
 	log logger.Logger
 }
 
+// memoryLimitRatio is the share of maxmemory above which the limit is
+// reported as nearly reached.
+const memoryLimitRatio = 0.9
+
 // newAnalyzer creates a new analyzer for Redis data.
 //
 // Parameters:
@@ -66,8 +70,9 @@ func (a *analyzer) Analyze(info map[string]string, config *models.ConfigData, sl
 }
 
 // analyzeMemory checks for memory-related issues, such as high fragmentation,
-// which can indicate wasted memory, and a risky `noeviction` policy, which can
-// cause write failures when the memory limit is reached.
+// which can indicate wasted memory, used memory close to maxmemory, and a
+// risky `noeviction` policy, which can cause write failures when the memory
+// limit is reached.
 func (a *analyzer) analyzeMemory(info map[string]string, config *models.ConfigData) []*models.Issue {
 	var issues []*models.Issue
 
@@ -85,6 +90,21 @@ func (a *analyzer) analyzeMemory(info map[string]string, config *models.ConfigDa
 		}
 	}
 
+	// Check how close used memory is to maxmemory (0 means no limit).
+	used, usedErr := strconv.ParseInt(info["used_memory"], 10, 64)
+	limit, limitErr := strconv.ParseInt(info["maxmemory"], 10, 64)
+	if usedErr == nil && limitErr == nil && limit > 0 && float64(used) >= memoryLimitRatio*float64(limit) {
+		issues = append(issues, &models.Issue{
+			Title:    IssueTitleMemLimit,
+			Severity: enum.SeverityWarning,
+			Evidence: fmt.Sprintf("used_memory is %d bytes, %.0f%% of maxmemory (%d bytes).", used, 100*float64(used)/float64(limit), limit),
+			Metadata: map[string]string{"used_memory": info["used_memory"], "maxmemory": info["maxmemory"]},
+			Recommendations: []*models.Recommendation{{
+				Description: "Once maxmemory is reached Redis evicts keys or, with the 'noeviction' policy, rejects writes. Raise maxmemory if the host has memory to spare, or shrink the data set, e.g. by setting expirations on keys.",
+			}},
+		})
+	}
+
 	// Check maxmemory policy. 'noeviction' can cause write failures when memory is full.
 	if policy, ok := config.Data["maxmemory-policy"]; ok && policy == "noeviction" {
 		issues = append(issues, &models.Issue{
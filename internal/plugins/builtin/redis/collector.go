@@ -64,9 +64,13 @@ func (c *collector) CollectInfo(ctx context.Context) (map[string]string, error)
 		return nil, err
 	}
 
-	infoStr := res.(string)
+	return parseInfo(res.(string)), nil
+}
+
+// parseInfo parses an INFO reply into its fields, skipping section headers.
+func parseInfo(info string) map[string]string {
 	infoMap := make(map[string]string)
-	lines := strings.Split(infoStr, "\r\n")
+	lines := strings.Split(info, "\r\n")
 	for _, line := range lines {
 		if strings.HasPrefix(line, "#") || line == "" {
 			continue
@@ -76,7 +80,7 @@ func (c *collector) CollectInfo(ctx context.Context) (map[string]string, error)
 			infoMap[parts[0]] = parts[1]
 		}
 	}
-	return infoMap, nil
+	return infoMap
 }
 
 // CollectConfig retrieves the live Redis configuration using the `CONFIG GET *` command.
@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/plugins/base"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanAutoFix_Probes(t *testing.T) {
+	p := &redisPlugin{}
+	cases := []struct {
+		title           string
+		metadata        map[string]string
+		before, after   interface{}
+		holds, verifies bool
+	}{
+		{IssueTitleMemoryHigh, nil,
+			map[string]string{"mem_fragmentation_ratio": "1.82"}, map[string]string{"mem_fragmentation_ratio": "1.05"}, true, true},
+		{IssueTitleMemoryHigh, nil,
+			map[string]string{"mem_fragmentation_ratio": "1.82"}, map[string]string{"mem_fragmentation_ratio": "1.90"}, true, false},
+		{IssueTitleSlowLog, nil, int64(128), int64(0), true, true},
+		{IssueTitleSlowLog, nil, int64(0), int64(0), false, false},
+		{IssueTitleConnHigh, nil,
+			map[string]string{"connected_clients": "9800"}, map[string]string{"connected_clients": "12"}, true, true},
+		{IssueTitleConnHigh, nil,
+			map[string]string{"connected_clients": "9800"}, map[string]string{"connected_clients": "9800"}, true, false},
+		{IssueTitleMemLimit, map[string]string{"maxmemory": "1073741824"}, "1073741824", "1342177280", true, true},
+		{IssueTitleMemLimit, map[string]string{"maxmemory": "1073741824"}, "2147483648", "1342177280", false, true},
+		{IssueTitleMemLimit, map[string]string{"maxmemory": "1073741824"}, "1073741824", "1073741824", true, false},
+	}
+	for _, c := range cases {
+		ok, fix := p.CanAutoFix(&models.Issue{Title: c.title, Metadata: c.metadata})
+		require.True(t, ok, c.title)
+		require.NotNil(t, fix.Precondition, c.title)
+		require.NotNil(t, fix.Verification, c.title)
+
+		holds, err := base.CheckProbe(fix.Precondition, c.before, nil, false)
+		require.NoError(t, err, c.title)
+		assert.Equal(t, c.holds, holds, "%s precondition for %v", c.title, c.before)
+		verifies, err := base.CheckProbe(fix.Verification, c.after, c.before, true)
+		require.NoError(t, err, c.title)
+		assert.Equal(t, c.verifies, verifies, "%s verification for %v after %v", c.title, c.after, c.before)
+	}
+
+	ok, fix := p.CanAutoFix(&models.Issue{Title: IssueTitleMemLimit, Metadata: map[string]string{"maxmemory": "1073741824"}})
+	require.True(t, ok)
+	assert.Equal(t, "CONFIG SET maxmemory 1342177280", fix.Command)
+	assert.Equal(t, "CONFIG SET maxmemory {{before}}", fix.RollbackCommand)
+
+	ok, _ = p.CanAutoFix(&models.Issue{Title: IssueTitleMemLimit, Metadata: map[string]string{"maxmemory": "0"}})
+	assert.False(t, ok, "no limit to raise")
+}
+
+func TestAnalyzeMemory_LimitNearlyReached(t *testing.T) {
+	a := &analyzer{}
+	config := &models.ConfigData{Data: map[string]string{"maxmemory-policy": "allkeys-lru"}}
+
+	issues := a.analyzeMemory(map[string]string{"used_memory": "1000000000", "maxmemory": "1073741824"}, config)
+	require.Len(t, issues, 1)
+	assert.Equal(t, IssueTitleMemLimit, issues[0].Title)
+	assert.Equal(t, "1073741824", issues[0].Metadata["maxmemory"])
+
+	assert.Empty(t, a.analyzeMemory(map[string]string{"used_memory": "500000000", "maxmemory": "1073741824"}, config))
+	assert.Empty(t, a.analyzeMemory(map[string]string{"used_memory": "500000000", "maxmemory": "0"}, config))
+}
@@ -18,6 +18,7 @@ package redis
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,6 +34,8 @@ const (
 	IssueTitleMemoryHigh = "High Memory Usage"
 	IssueTitleSlowLog    = "Slow Queries Detected"
 	IssueTitleConnHigh   = "High Connection Count"
+	IssueTitleEviction   = "Risky Memory Eviction Policy"
+	IssueTitleMemLimit   = "Memory Limit Nearly Reached"
 
 	// Fix command categories
 	FixCatMemory = "Memory"
@@ -51,12 +54,17 @@ type redisPlugin struct {
 
 // New is the factory function that creates an instance of the Redis plugin.
 func New() (interfaces.MiddlewarePlugin, error) {
+	return NewFromAddr("localhost:6379")
+}
+
+// NewFromAddr creates the plugin for the Redis server at addr.
+func NewFromAddr(addr string) (interfaces.MiddlewarePlugin, error) {
 	p := &redisPlugin{}
 	// Use base.Plugin Init to set basic info
 	p.Plugin.Init("redis", "0.1.0", "Provides diagnostics for Redis instances.")
 
 	rdb := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
+		Addr:     addr,
 		Password: "", // no password set
 		DB:       0,  // use default DB
 	})
@@ -143,11 +151,18 @@ func (p *redisPlugin) Shutdown() error {
 func (p *redisPlugin) CanAutoFix(issue *models.Issue) (bool, *models.FixAction) {
 	switch issue.Title {
 	case IssueTitleMemoryHigh:
+		// Purging cannot be undone, but it must not leave memory more fragmented
 		return true, &models.FixAction{
-			ID:          "fix-redis-memory-purge",
-			Description: "Execute MEMORY PURGE to release fragmented memory",
-			Command:     "MEMORY PURGE",
-			Category:    FixCatMemory,
+			ID:           "fix-redis-memory-purge",
+			Description:  "Execute MEMORY PURGE to release fragmented memory",
+			Command:      "MEMORY PURGE",
+			Category:     FixCatMemory,
+			Precondition: &models.FixProbe{Command: "INFO memory"},
+			Verification: &models.FixProbe{
+				Command: "INFO memory",
+				Expect:  `float(value.mem_fragmentation_ratio) <= float(before.mem_fragmentation_ratio)`,
+				Settle:  time.Second,
+			},
 		}
 	case IssueTitleSlowLog:
 		return true, &models.FixAction{
@@ -155,34 +170,105 @@ func (p *redisPlugin) CanAutoFix(issue *models.Issue) (bool, *models.FixAction)
 			Description: "Reset slow log to clear old entries",
 			Command:     "SLOWLOG RESET",
 			Category:    FixCatConfig,
+			Precondition: &models.FixProbe{
+				Command: "SLOWLOG LEN",
+				Expect:  "value > 0",
+			},
+			Verification: &models.FixProbe{
+				Command: "SLOWLOG LEN",
+				Expect:  "value < before",
+			},
 		}
 	case IssueTitleConnHigh:
 		return true, &models.FixAction{
-			ID:          "fix-redis-kill-normal-clients",
-			Description: "Kill all normal clients to free up connections",
-			Command:     "CLIENT KILL TYPE normal",
-			Category:    FixCatConn,
+			ID:           "fix-redis-kill-normal-clients",
+			Description:  "Kill all normal clients to free up connections",
+			Command:      "CLIENT KILL TYPE normal",
+			Category:     FixCatConn,
+			Precondition: &models.FixProbe{Command: "INFO clients"},
+			Verification: &models.FixProbe{
+				Command: "INFO clients",
+				Expect:  "int(value.connected_clients) < int(before.connected_clients)",
+			},
+		}
+	case IssueTitleEviction:
+		return true, &models.FixAction{
+			ID:              "fix-redis-eviction-policy",
+			Description:     "Evict least recently used keys instead of rejecting writes when memory is full",
+			Command:         "CONFIG SET maxmemory-policy allkeys-lru",
+			RollbackCommand: "CONFIG SET maxmemory-policy {{before}}",
+			Category:        FixCatConfig,
+			Precondition: &models.FixProbe{
+				Command: "CONFIG GET maxmemory-policy",
+				Expect:  `value == "noeviction"`,
+			},
+			Verification: &models.FixProbe{
+				Command: "CONFIG GET maxmemory-policy",
+				Expect:  `value == "allkeys-lru"`,
+				Settle:  time.Second,
+			},
+		}
+	case IssueTitleMemLimit:
+		current, err := strconv.ParseInt(issue.Metadata["maxmemory"], 10, 64)
+		if err != nil || current <= 0 {
+			return false, nil
+		}
+		raised := current + current/4
+		// The precondition refuses to act if maxmemory changed since the diagnosis
+		return true, &models.FixAction{
+			ID:              "fix-redis-raise-maxmemory",
+			Description:     fmt.Sprintf("Raise maxmemory by 25%% from %d to %d bytes", current, raised),
+			Command:         fmt.Sprintf("CONFIG SET maxmemory %d", raised),
+			RollbackCommand: "CONFIG SET maxmemory {{before}}",
+			Category:        FixCatMemory,
+			Precondition: &models.FixProbe{
+				Command: "CONFIG GET maxmemory",
+				Expect:  fmt.Sprintf(`value == "%d"`, current),
+			},
+			Verification: &models.FixProbe{
+				Command: "CONFIG GET maxmemory",
+				Expect:  fmt.Sprintf(`value == "%d"`, raised),
+				Settle:  time.Second,
+			},
 		}
 	}
 	return false, nil
 }
 
 func (p *redisPlugin) ExecuteFix(ctx context.Context, fix *models.FixAction) (*models.FixResult, error) {
-	return p.fixer.Execute(ctx, fix, func(ctx context.Context) error {
-		parts := strings.Fields(fix.Command)
-		if len(parts) == 0 {
-			return fmt.Errorf("empty command")
-		}
+	return p.fixer.Run(ctx, fix, p.command)
+}
 
-		// cmdName is now unused
-		// cmdName := parts[0]
-		args := make([]interface{}, len(parts))
-		for i, v := range parts {
-			args[i] = v
-		}
+// command sends one command to Redis. The reply to a CONFIG GET of a single
+// parameter is reduced to the parameter's value, or an error if there is no
+// such parameter, and the reply to INFO to a map of its fields.
+func (p *redisPlugin) command(ctx context.Context, command string) (interface{}, error) {
+	parts := strings.Fields(command)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	args := make([]interface{}, len(parts))
+	for i, v := range parts {
+		args[i] = v
+	}
 
-		return p.client.Do(ctx, args...).Err()
-	}, nil) // No rollback for these simple commands
+	reply, err := p.client.Do(ctx, args...).Result()
+	if err != nil {
+		return nil, err
+	}
+	if pair, ok := reply.([]interface{}); ok && len(parts) == 3 &&
+		strings.EqualFold(parts[0], "CONFIG") && strings.EqualFold(parts[1], "GET") {
+		switch len(pair) {
+		case 0:
+			return nil, fmt.Errorf("unknown config parameter %s", parts[2])
+		case 2:
+			return pair[1], nil
+		}
+	}
+	if info, ok := reply.(string); ok && strings.EqualFold(parts[0], "INFO") {
+		return parseInfo(info), nil
+	}
+	return reply, nil
 }
 
 func (p *redisPlugin) ValidateFix(ctx context.Context, issue *models.Issue, result *models.FixResult) (bool, string, error) {
@@ -203,15 +289,25 @@ func (p *redisPlugin) ValidateFix(ctx context.Context, issue *models.Issue, resu
 		}
 		return false, fmt.Sprintf("Slow log still has %d entries", val), nil
 
-	case IssueTitleMemoryHigh:
-		// Simple validation: just check we can ping, actual memory drop is hard to simulate immediately without waiting
-		err := p.client.Ping(ctx).Err()
-		return err == nil, "Redis is responsive after purge", err
+	case IssueTitleMemoryHigh, IssueTitleConnHigh:
+		// The verification probe of the fix compared the state before and after
+		before, _ := result.Before.(map[string]string)
+		after, _ := result.After.(map[string]string)
+		if before == nil || after == nil {
+			return false, "Fix was not verified", nil
+		}
+		field := "mem_fragmentation_ratio"
+		if issue.Title == IssueTitleConnHigh {
+			field = "connected_clients"
+		}
+		return true, fmt.Sprintf("%s changed from %s to %s", field, before[field], after[field]), nil
 
-	case IssueTitleConnHigh:
-		// Validate we are responsive
-		err := p.client.Ping(ctx).Err()
-		return err == nil, "Redis is responsive after connection kill", err
+	case IssueTitleEviction:
+		// The verification probe of the fix already checked the new policy
+		return true, fmt.Sprintf("Eviction policy changed from %v to %v", result.Before, result.After), nil
+
+	case IssueTitleMemLimit:
+		return true, fmt.Sprintf("maxmemory changed from %v to %v bytes", result.Before, result.After), nil
 	}
 
 	return true, "Fix assumed successful", nil
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/kubestack-ai/kubestack-ai/internal/llm"
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
 	"github.com/kubestack-ai/kubestack-ai/internal/llm/prompt"
 )
 
@@ -39,7 +40,7 @@ The passage should be technical and detailed.`,
 	builder, _ := prompt.NewBuilder(tmpl)
 	p, _ := builder.WithData("query", query).Build()
 
-	hypotheticalDoc, err := e.llmClient.Generate(ctx, p)
+	hypotheticalDoc, err := e.llmClient.Generate(interfaces.WithFeature(ctx, "query_expansion"), p)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate hypothetical document: %w", err)
 	}
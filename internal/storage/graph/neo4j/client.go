@@ -2,6 +2,7 @@ package neo4j
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -37,7 +38,9 @@ func (c *Client) AddNode(ctx context.Context, node *graph.Node) error {
 			    n.name = $name,
 			    n.namespace = $namespace,
 			    n.created_at = $created_at,
-			    n.updated_at = $updated_at
+			    n.updated_at = $updated_at,
+			    n.labels_json = $labels_json,
+			    n.properties_json = $properties_json
 			RETURN n`
 
 		params := map[string]interface{}{
@@ -47,14 +50,9 @@ func (c *Client) AddNode(ctx context.Context, node *graph.Node) error {
 			"namespace":  node.Namespace,
 			"created_at": node.CreatedAt.Format(time.RFC3339),
 			"updated_at": node.UpdatedAt.Format(time.RFC3339),
-		}
-
-		// Labels
-		if len(node.Labels) > 0 {
-			// Dynamic labels are tricky in Cypher parameters, usually need string concatenation or APOC
-			// For simplicity, we just store labels as property map or skip for now in this basic impl
-			// Or we could execute a second query to add labels.
-			// Ideally: MERGE (n:Service:Node ...)
+			// Neo4j properties cannot hold maps, so labels and properties are stored as JSON
+			"labels_json":     toJSON(node.Labels),
+			"properties_json": toJSON(node.Properties),
 		}
 
 		_, err := tx.Run(ctx, query, params)
@@ -116,7 +114,53 @@ func (c *Client) DeleteNode(ctx context.Context, id string) error {
 }
 
 func (c *Client) ListNodes(ctx context.Context, filter graph.NodeFilter) ([]*graph.Node, error) {
-	return nil, fmt.Errorf("not implemented")
+	session := c.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: c.dbName})
+	defer session.Close(ctx)
+
+	query := `MATCH (n:Node)
+		WHERE (size($types) = 0 OR n.type IN $types) AND ($namespace = '' OR n.namespace = $namespace)
+		RETURN n.id, n.type, n.name, n.namespace, n.labels_json, n.properties_json`
+	types := make([]string, 0, len(filter.Types))
+	for _, t := range filter.Types {
+		types = append(types, string(t))
+	}
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		res, err := tx.Run(ctx, query, map[string]interface{}{"types": types, "namespace": filter.Namespace})
+		if err != nil {
+			return nil, err
+		}
+
+		var nodes []*graph.Node
+		for res.Next(ctx) {
+			rec := res.Record()
+			node := &graph.Node{
+				ID:        stringValue(rec, "n.id"),
+				Type:      graph.NodeType(stringValue(rec, "n.type")),
+				Name:      stringValue(rec, "n.name"),
+				Namespace: stringValue(rec, "n.namespace"),
+			}
+			fromJSON(stringValue(rec, "n.labels_json"), &node.Labels)
+			fromJSON(stringValue(rec, "n.properties_json"), &node.Properties)
+
+			// Label matching is done here since labels are stored as JSON
+			match := true
+			for k, v := range filter.LabelMatch {
+				if node.Labels[k] != v {
+					match = false
+					break
+				}
+			}
+			if match {
+				nodes = append(nodes, node)
+			}
+		}
+		return nodes, res.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*graph.Node), nil
 }
 
 func (c *Client) AddEdge(ctx context.Context, edge *graph.Edge) error {
@@ -127,15 +171,16 @@ func (c *Client) AddEdge(ctx context.Context, edge *graph.Edge) error {
 		query := `
 			MATCH (a:Node {id: $from}), (b:Node {id: $to})
 			MERGE (a)-[r:EDGE {type: $type}]->(b)
-			SET r.id = $id, r.created_at = $created_at
+			SET r.id = $id, r.created_at = $created_at, r.properties_json = $properties_json
 			RETURN r`
 
 		params := map[string]interface{}{
-			"from":       edge.FromID,
-			"to":         edge.ToID,
-			"type":       string(edge.Type),
-			"id":         edge.ID,
-			"created_at": edge.CreatedAt.Format(time.RFC3339),
+			"from":            edge.FromID,
+			"to":              edge.ToID,
+			"type":            string(edge.Type),
+			"id":              edge.ID,
+			"created_at":      edge.CreatedAt.Format(time.RFC3339),
+			"properties_json": toJSON(edge.Properties),
 		}
 
 		_, err := tx.Run(ctx, query, params)
@@ -149,11 +194,52 @@ func (c *Client) GetEdge(ctx context.Context, id string) (*graph.Edge, error) {
 }
 
 func (c *Client) DeleteEdge(ctx context.Context, id string) error {
-	return fmt.Errorf("not implemented")
+	session := c.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: c.dbName})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		_, err := tx.Run(ctx, `MATCH ()-[r:EDGE {id: $id}]->() DELETE r`, map[string]interface{}{"id": id})
+		return nil, err
+	})
+	return err
 }
 
 func (c *Client) ListEdges(ctx context.Context, filter graph.EdgeFilter) ([]*graph.Edge, error) {
-	return nil, fmt.Errorf("not implemented")
+	session := c.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: c.dbName})
+	defer session.Close(ctx)
+
+	query := `MATCH (a:Node)-[r:EDGE]->(b:Node)
+		WHERE (size($types) = 0 OR r.type IN $types) AND ($from = '' OR a.id = $from) AND ($to = '' OR b.id = $to)
+		RETURN r.id, a.id, b.id, r.type, r.properties_json`
+	types := make([]string, 0, len(filter.Types))
+	for _, t := range filter.Types {
+		types = append(types, string(t))
+	}
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		res, err := tx.Run(ctx, query, map[string]interface{}{"types": types, "from": filter.FromID, "to": filter.ToID})
+		if err != nil {
+			return nil, err
+		}
+
+		var edges []*graph.Edge
+		for res.Next(ctx) {
+			rec := res.Record()
+			edge := &graph.Edge{
+				ID:     stringValue(rec, "r.id"),
+				FromID: stringValue(rec, "a.id"),
+				ToID:   stringValue(rec, "b.id"),
+				Type:   graph.EdgeType(stringValue(rec, "r.type")),
+			}
+			fromJSON(stringValue(rec, "r.properties_json"), &edge.Properties)
+			edges = append(edges, edge)
+		}
+		return edges, res.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*graph.Edge), nil
 }
 
 func (c *Client) GetNeighbors(ctx context.Context, nodeID string, direction string, depth int, edgeTypes []graph.EdgeType) ([]*graph.Node, error) {
@@ -242,6 +328,26 @@ func (c *Client) SubGraph(ctx context.Context, centerID string, depth int) (*gra
 	return nil, fmt.Errorf("not implemented")
 }
 
+func stringValue(rec *neo4j.Record, key string) string {
+	v, _ := rec.Get(key)
+	s, _ := v.(string)
+	return s
+}
+
+func toJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func fromJSON(data string, v interface{}) {
+	if data != "" {
+		_ = json.Unmarshal([]byte(data), v)
+	}
+}
+
 func (c *Client) Close() error {
 	return c.driver.Close(context.Background())
 }
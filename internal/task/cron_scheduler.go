@@ -44,6 +44,7 @@ func (s *CronScheduler) Start() error {
 			Type:      "diagnosis",
 			Payload:   map[string]string{"scope": "all"}, // Default payload
 			CreatedAt: time.Now(),
+			Priority:  PriorityLow, // Alert-triggered and manual diagnoses go first
 		}
 
 		if err := s.queue.Enqueue(context.Background(), task); err != nil {
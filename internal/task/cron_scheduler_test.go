@@ -2,6 +2,7 @@ package task
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,15 +12,21 @@ import (
 
 // MockTaskQueue for testing (redefined here since it's not exported from other test file if running separately)
 type MockTaskQueueCron struct {
-	tasks []*Task
+	TaskQueue // only Enqueue is used by the scheduler
+	mu        sync.Mutex
+	tasks     []*Task
 }
 
 func (q *MockTaskQueueCron) Enqueue(ctx context.Context, task *Task) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
 	q.tasks = append(q.tasks, task)
 	return nil
 }
 
 func (q *MockTaskQueueCron) Dequeue(ctx context.Context) (*Task, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
 	if len(q.tasks) == 0 {
 		return nil, nil // Or error
 	}
@@ -32,6 +39,13 @@ func (q *MockTaskQueueCron) Close() error {
 	return nil
 }
 
+// enqueued returns the tasks enqueued so far
+func (q *MockTaskQueueCron) enqueued() []*Task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]*Task(nil), q.tasks...)
+}
+
 func TestCronScheduler_Start(t *testing.T) {
 	cfg := config.CronConfig{
 		Enabled:            true,
@@ -51,10 +65,16 @@ func TestCronScheduler_Start(t *testing.T) {
 	// Wait for 2 seconds
 	time.Sleep(2100 * time.Millisecond)
 
-	if len(queue.tasks) == 0 {
+	tasks := queue.enqueued()
+	if len(tasks) == 0 {
 		t.Errorf("Expected tasks to be enqueued, got 0")
 	}
-	if len(queue.tasks) < 2 {
-		t.Logf("Expected at least 2 tasks, got %d", len(queue.tasks))
+	for _, task := range tasks {
+		if task.Priority != PriorityLow {
+			t.Errorf("Expected scheduled task %s in the low priority lane, got %s", task.ID, task.Priority)
+		}
+	}
+	if len(tasks) < 2 {
+		t.Logf("Expected at least 2 tasks, got %d", len(tasks))
 	}
 }
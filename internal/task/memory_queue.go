@@ -0,0 +1,318 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type lease struct {
+	task     *Task
+	deadline time.Time
+}
+
+type delayedTask struct {
+	task *Task
+	due  time.Time
+}
+
+// MemoryQueue is an in-process TaskQueue for single-node deployments without
+// Redis. Tasks do not survive a restart.
+type MemoryQueue struct {
+	mu       sync.Mutex
+	opts     QueueOptions
+	lanes    map[Priority][]*Task
+	delayed  []delayedTask
+	inflight map[string]*lease
+	dead     []*Task
+	notify   chan struct{}
+	done     chan struct{}
+	closed   bool
+	now      func() time.Time
+}
+
+// NewMemoryQueue creates an empty MemoryQueue.
+func NewMemoryQueue(opts QueueOptions) *MemoryQueue {
+	return &MemoryQueue{
+		opts:     opts.withDefaults(),
+		lanes:    make(map[Priority][]*Task),
+		inflight: make(map[string]*lease),
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		now:      time.Now,
+	}
+}
+
+// Enqueue adds a task to the lane of its priority.
+func (q *MemoryQueue) Enqueue(ctx context.Context, task *Task) error {
+	if task.ID == "" {
+		return fmt.Errorf("task ID is required")
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	t := *task
+	t.Attempts = 0
+	t.DeadLetteredAt = nil
+	q.push(&t)
+	return nil
+}
+
+// Dequeue leases the next ready task, blocking until one is available.
+func (q *MemoryQueue) Dequeue(ctx context.Context) (*Task, error) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return nil, ErrQueueClosed
+		}
+		now := q.now()
+		q.promote(now)
+		if t := q.pop(); t != nil {
+			t.Attempts++
+			q.inflight[t.ID] = &lease{task: t, deadline: now.Add(q.opts.VisibilityTimeout)}
+			if q.ready() {
+				q.signal()
+			}
+			q.mu.Unlock()
+			out := *t
+			return &out, nil
+		}
+		wait := q.nextWakeup(now)
+		q.mu.Unlock()
+
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			expired = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-q.done:
+		case <-q.notify:
+		case <-expired:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Ack removes a leased task from the queue.
+func (q *MemoryQueue) Ack(ctx context.Context, task *Task) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, err := q.leased(task); err != nil {
+		return err
+	}
+	delete(q.inflight, task.ID)
+	return nil
+}
+
+// Nack requeues a leased task after delay, or dead-letters it once its
+// attempts are used up, in which case task.DeadLetteredAt is set.
+func (q *MemoryQueue) Nack(ctx context.Context, task *Task, delay time.Duration, cause error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	l, err := q.leased(task)
+	if err != nil {
+		return err
+	}
+	delete(q.inflight, task.ID)
+
+	t := l.task
+	t.LastError = errorString(cause)
+	task.LastError = t.LastError
+	if t.Attempts >= q.opts.maxAttempts(t) {
+		q.bury(t)
+		task.DeadLetteredAt = t.DeadLetteredAt
+		return nil
+	}
+	if delay <= 0 {
+		q.push(t)
+		return nil
+	}
+	q.delayed = append(q.delayed, delayedTask{task: t, due: q.now().Add(delay)})
+	q.signal()
+	return nil
+}
+
+// ExtendLease moves the visibility deadline of a leased task to d from now.
+func (q *MemoryQueue) ExtendLease(ctx context.Context, task *Task, d time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	l, err := q.leased(task)
+	if err != nil {
+		return err
+	}
+	l.deadline = q.now().Add(d)
+	return nil
+}
+
+// DeadLetter moves a leased task to the dead-letter queue.
+func (q *MemoryQueue) DeadLetter(ctx context.Context, task *Task, cause error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	l, err := q.leased(task)
+	if err != nil {
+		return err
+	}
+	delete(q.inflight, task.ID)
+	l.task.LastError = errorString(cause)
+	q.bury(l.task)
+	task.LastError = l.task.LastError
+	task.DeadLetteredAt = l.task.DeadLetteredAt
+	return nil
+}
+
+// DeadLetters returns copies of the dead-lettered tasks, oldest first.
+func (q *MemoryQueue) DeadLetters(ctx context.Context) ([]*Task, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.promote(q.now())
+	out := make([]*Task, 0, len(q.dead))
+	for _, t := range q.dead {
+		c := *t
+		out = append(out, &c)
+	}
+	return out, nil
+}
+
+// Replay moves a dead-lettered task back to its lane.
+func (q *MemoryQueue) Replay(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, t := range q.dead {
+		if t.ID != id {
+			continue
+		}
+		q.dead = append(q.dead[:i], q.dead[i+1:]...)
+		t.Attempts = 0
+		t.DeadLetteredAt = nil
+		q.push(t)
+		return nil
+	}
+	return ErrTaskNotFound
+}
+
+// Close wakes up blocked consumers and rejects further operations.
+func (q *MemoryQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.done)
+	}
+	return nil
+}
+
+// leased returns the lease held by task, which must be the latest delivery.
+func (q *MemoryQueue) leased(task *Task) (*lease, error) {
+	if q.closed {
+		return nil, ErrQueueClosed
+	}
+	l, ok := q.inflight[task.ID]
+	if !ok || l.task.Attempts != task.Attempts {
+		return nil, ErrLeaseLost
+	}
+	return l, nil
+}
+
+func (q *MemoryQueue) push(t *Task) {
+	lane := t.Priority.lane()
+	q.lanes[lane] = append(q.lanes[lane], t)
+	q.signal()
+}
+
+func (q *MemoryQueue) pop() *Task {
+	for _, p := range priorities {
+		if lane := q.lanes[p]; len(lane) > 0 {
+			t := lane[0]
+			lane[0] = nil
+			q.lanes[p] = lane[1:]
+			return t
+		}
+	}
+	return nil
+}
+
+func (q *MemoryQueue) ready() bool {
+	for _, lane := range q.lanes {
+		if len(lane) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *MemoryQueue) bury(t *Task) {
+	at := q.now()
+	t.DeadLetteredAt = &at
+	q.dead = append(q.dead, t)
+}
+
+// promote makes delayed tasks that are due ready and takes back expired
+// leases, dead-lettering tasks that have no attempts left.
+func (q *MemoryQueue) promote(now time.Time) {
+	if len(q.delayed) > 0 {
+		sort.SliceStable(q.delayed, func(i, j int) bool { return q.delayed[i].due.Before(q.delayed[j].due) })
+		n := 0
+		for n < len(q.delayed) && !q.delayed[n].due.After(now) {
+			q.push(q.delayed[n].task)
+			n++
+		}
+		q.delayed = q.delayed[n:]
+	}
+
+	for id, l := range q.inflight {
+		if l.deadline.After(now) {
+			continue
+		}
+		delete(q.inflight, id)
+		if l.task.Attempts >= q.opts.maxAttempts(l.task) {
+			l.task.LastError = "lease expired"
+			q.bury(l.task)
+			continue
+		}
+		q.push(l.task)
+	}
+}
+
+// nextWakeup returns how long until a delayed task is due or a lease
+// expires, or 0 if nothing is pending.
+func (q *MemoryQueue) nextWakeup(now time.Time) time.Duration {
+	var next time.Time
+	for _, d := range q.delayed {
+		if next.IsZero() || d.due.Before(next) {
+			next = d.due
+		}
+	}
+	for _, l := range q.inflight {
+		if next.IsZero() || l.deadline.Before(next) {
+			next = l.deadline
+		}
+	}
+	if next.IsZero() {
+		return 0
+	}
+	if wait := next.Sub(now); wait > 0 {
+		return wait
+	}
+	return time.Millisecond
+}
+
+func (q *MemoryQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
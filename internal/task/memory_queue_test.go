@@ -0,0 +1,39 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryQueue(t *testing.T) {
+	testQueueContract(t, func(t *testing.T, opts QueueOptions, c *fakeClock) TaskQueue {
+		q := NewMemoryQueue(opts)
+		q.now = c.now
+		return q
+	})
+}
+
+func TestMemoryQueue_CloseUnblocksDequeue(t *testing.T) {
+	q := NewMemoryQueue(QueueOptions{})
+	errc := make(chan error, 1)
+	go func() {
+		_, err := q.Dequeue(context.Background())
+		errc <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	_ = q.Close()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, ErrQueueClosed) {
+			t.Errorf("Expected ErrQueueClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Dequeue still blocked after Close")
+	}
+	if err := q.Enqueue(context.Background(), &Task{ID: "t1"}); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Expected ErrQueueClosed, got %v", err)
+	}
+}
@@ -14,15 +14,14 @@ import (
 	"github.com/kubestack-ai/kubestack-ai/internal/task"
 )
 
-// MockNotifier
+// MockNotifier hands each notified result to the test over a channel, since
+// the worker notifies from its own goroutine
 type MockNotifier struct {
-	NotifiedCount int
-	LastResult    *models.DiagnosisResult
+	Notified chan *models.DiagnosisResult
 }
 
 func (m *MockNotifier) Notify(ctx context.Context, result *models.DiagnosisResult) error {
-	m.NotifiedCount++
-	m.LastResult = result
+	m.Notified <- result
 	return nil
 }
 
@@ -62,30 +61,9 @@ func (m *MockTaskStore) SaveError(taskID string, err error) error { return nil }
 func (m *MockTaskStore) GetStatus(taskID string) (*storage.TaskStatus, error) { return nil, nil }
 func (m *MockTaskStore) GetResult(taskID string) (*models.DiagnosisResult, error) { return nil, nil }
 
-// LocalMockQueue
-type LocalMockQueue struct {
-	tasks []*task.Task
-}
-
-func (q *LocalMockQueue) Enqueue(ctx context.Context, t *task.Task) error {
-	q.tasks = append(q.tasks, t)
-	return nil
-}
-
-func (q *LocalMockQueue) Dequeue(ctx context.Context) (*task.Task, error) {
-	if len(q.tasks) == 0 {
-		return nil, nil // causes worker to loop
-	}
-	t := q.tasks[0]
-	q.tasks = q.tasks[1:]
-	return t, nil
-}
-
-func (q *LocalMockQueue) Close() error { return nil }
-
 func TestWorker_Triggers_Notification(t *testing.T) {
-	q := &LocalMockQueue{}
-	notifier := &MockNotifier{}
+	q := task.NewMemoryQueue(task.QueueOptions{})
+	notifier := &MockNotifier{Notified: make(chan *models.DiagnosisResult, 10)}
 	diagManager := &MockDiagnosisManager{}
 	store := &MockTaskStore{}
 	notifCfg := config.NotificationConfig{
@@ -104,15 +82,18 @@ func TestWorker_Triggers_Notification(t *testing.T) {
 	q.Enqueue(context.Background(), testTask)
 
 	worker.Start()
-	defer worker.Stop()
 
-	// Wait for processing
-	time.Sleep(500 * time.Millisecond)
-
-	if notifier.NotifiedCount != 1 {
-		t.Errorf("Expected 1 notification, got %d", notifier.NotifiedCount)
+	select {
+	case result := <-notifier.Notified:
+		if result.Status != enum.StatusCritical {
+			t.Errorf("Expected Critical status, got %s", result.Status)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a notification, got none")
 	}
-	if notifier.LastResult != nil && notifier.LastResult.Status != enum.StatusCritical {
-		t.Errorf("Expected Critical status, got %s", notifier.LastResult.Status)
+
+	worker.Stop()
+	if extra := len(notifier.Notified); extra != 0 {
+		t.Errorf("Expected 1 notification, got %d more", extra)
 	}
 }
@@ -2,23 +2,182 @@ package task
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// Priority selects the lane a task is queued in. Tasks in a higher lane are
+// always dequeued before tasks in a lower one.
+type Priority int
+
+const (
+	// PriorityLow is for background work such as scheduled inspections.
+	PriorityLow Priority = -1
+	// PriorityNormal is the default for user submitted tasks.
+	PriorityNormal Priority = 0
+	// PriorityHigh is for alert-triggered diagnoses.
+	PriorityHigh Priority = 1
+)
+
+// priorities lists the lanes from the highest to the lowest.
+var priorities = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+func (p Priority) String() string {
+	switch {
+	case p > PriorityNormal:
+		return "high"
+	case p < PriorityNormal:
+		return "low"
+	default:
+		return "normal"
+	}
+}
+
+// lane clamps p to one of the known priorities.
+func (p Priority) lane() Priority {
+	switch {
+	case p > PriorityNormal:
+		return PriorityHigh
+	case p < PriorityNormal:
+		return PriorityLow
+	default:
+		return PriorityNormal
+	}
+}
+
+var (
+	// ErrLeaseLost is returned when acknowledging a task whose lease expired
+	// and which was handed to another consumer in the meantime.
+	ErrLeaseLost = errors.New("task lease lost")
+	// ErrTaskNotFound is returned when a dead-lettered task does not exist.
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrQueueClosed is returned by operations on a closed queue.
+	ErrQueueClosed = errors.New("task queue closed")
+)
+
 // Task represents a unit of work to be processed asynchronously.
 type Task struct {
 	ID        string      `json:"id"`
 	Type      string      `json:"type"` // e.g., "diagnosis"
 	Payload   interface{} `json:"payload"`
 	CreatedAt time.Time   `json:"created_at"`
+	Priority  Priority    `json:"priority"`
+	// Tenant is who submitted the task; LLM calls made while running it
+	// count against the tenant's token budget.
+	Tenant string `json:"tenant,omitempty"`
+	// MaxAttempts overrides the queue default when positive.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+
+	// Attempts counts deliveries so far, including the current one. It is
+	// maintained by the queue and identifies the current lease.
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeadLetteredAt *time.Time `json:"dead_lettered_at,omitempty"`
 }
 
 // TaskQueue defines the interface for an asynchronous task queue.
+//
+// Delivery is at-least-once: a dequeued task is leased to the consumer for the
+// visibility timeout and becomes available again if it is neither
+// acknowledged nor renewed in time. Consumers must therefore be idempotent.
 type TaskQueue interface {
 	// Enqueue adds a task to the queue.
 	Enqueue(ctx context.Context, task *Task) error
-	// Dequeue retrieves a task from the queue. It should block if the queue is empty.
+	// Dequeue leases the next task, highest priority first. It blocks until a
+	// task is available or ctx is done.
 	Dequeue(ctx context.Context) (*Task, error)
+	// Ack removes a successfully processed task from the queue.
+	Ack(ctx context.Context, task *Task) error
+	// Nack returns a failed task to the queue, to be redelivered after delay.
+	// Once the task has used up its attempts it is dead-lettered instead.
+	Nack(ctx context.Context, task *Task, delay time.Duration, cause error) error
+	// ExtendLease pushes the visibility deadline of a leased task to d from now.
+	ExtendLease(ctx context.Context, task *Task, d time.Duration) error
+	// DeadLetter moves a task straight to the dead-letter queue, for failures
+	// that retrying cannot fix.
+	DeadLetter(ctx context.Context, task *Task, cause error) error
+	// DeadLetters lists the dead-lettered tasks, oldest first.
+	DeadLetters(ctx context.Context) ([]*Task, error)
+	// Replay moves a dead-lettered task back to its lane with a fresh set of
+	// attempts.
+	Replay(ctx context.Context, id string) error
 	// Close closes the queue connection.
 	Close() error
 }
+
+// QueueOptions tunes the delivery guarantees of a queue.
+type QueueOptions struct {
+	// VisibilityTimeout is how long a dequeued task stays invisible to other
+	// consumers without a lease renewal.
+	VisibilityTimeout time.Duration
+	// MaxAttempts is the default number of deliveries before a task is
+	// dead-lettered.
+	MaxAttempts int
+	// PollInterval is how often a blocked Dequeue checks for delayed or
+	// expired tasks becoming ready.
+	PollInterval time.Duration
+}
+
+const (
+	DefaultVisibilityTimeout = 5 * time.Minute
+	DefaultMaxAttempts       = 5
+	defaultPollInterval      = time.Second
+)
+
+func (o QueueOptions) withDefaults() QueueOptions {
+	if o.VisibilityTimeout <= 0 {
+		o.VisibilityTimeout = DefaultVisibilityTimeout
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = DefaultMaxAttempts
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = defaultPollInterval
+	}
+	return o
+}
+
+func (o QueueOptions) maxAttempts(task *Task) int {
+	if task.MaxAttempts > 0 {
+		return task.MaxAttempts
+	}
+	return o.MaxAttempts
+}
+
+// RetryPolicy computes the delay before a failed task is retried.
+type RetryPolicy struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy retries after 5s, 10s, 20s... capped at 5 minutes.
+var DefaultRetryPolicy = RetryPolicy{InitialBackoff: 5 * time.Second, MaxBackoff: 5 * time.Minute}
+
+// Backoff returns the delay after the given failed attempt, doubling from
+// InitialBackoff and capped at MaxBackoff.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if p.InitialBackoff <= 0 {
+		return 0
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := p.InitialBackoff
+	for i := 1; i < attempt && i < 20; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,230 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable time source shared with the queue under test.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// queueFactory builds a queue with the given options whose clock is c.
+type queueFactory func(t *testing.T, opts QueueOptions, c *fakeClock) TaskQueue
+
+// testQueueContract checks the delivery guarantees every TaskQueue provides.
+func testQueueContract(t *testing.T, newQueue queueFactory) {
+	ctx := context.Background()
+	opts := QueueOptions{VisibilityTimeout: time.Minute, MaxAttempts: 3, PollInterval: 5 * time.Millisecond}
+
+	mustDequeue := func(t *testing.T, q TaskQueue) *Task {
+		t.Helper()
+		dctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		task, err := q.Dequeue(dctx)
+		if err != nil {
+			t.Fatalf("Dequeue failed: %v", err)
+		}
+		return task
+	}
+	expectEmpty := func(t *testing.T, q TaskQueue) {
+		t.Helper()
+		dctx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+		defer cancel()
+		if task, err := q.Dequeue(dctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected no ready task, got %v, %v", task, err)
+		}
+	}
+
+	t.Run("PriorityLanes", func(t *testing.T) {
+		q := newQueue(t, opts, &fakeClock{t: time.Now()})
+		for _, task := range []*Task{
+			{ID: "cron-1", Type: "diagnosis", Priority: PriorityLow},
+			{ID: "api-1", Type: "diagnosis"},
+			{ID: "alert-1", Type: "diagnosis", Priority: PriorityHigh},
+			{ID: "cron-2", Type: "diagnosis", Priority: PriorityLow},
+		} {
+			if err := q.Enqueue(ctx, task); err != nil {
+				t.Fatalf("Enqueue failed: %v", err)
+			}
+		}
+
+		for _, want := range []string{"alert-1", "api-1", "cron-1", "cron-2"} {
+			got := mustDequeue(t, q)
+			if got.ID != want {
+				t.Errorf("Expected %s, got %s", want, got.ID)
+			}
+			if got.Attempts != 1 {
+				t.Errorf("Expected first delivery of %s, got attempt %d", got.ID, got.Attempts)
+			}
+			if err := q.Ack(ctx, got); err != nil {
+				t.Errorf("Ack failed: %v", err)
+			}
+		}
+		expectEmpty(t, q)
+	})
+
+	t.Run("VisibilityTimeout", func(t *testing.T) {
+		clock := &fakeClock{t: time.Now()}
+		q := newQueue(t, opts, clock)
+		if err := q.Enqueue(ctx, &Task{ID: "t1", Type: "diagnosis", Payload: map[string]interface{}{"instance": "redis-0"}}); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+
+		first := mustDequeue(t, q)
+		expectEmpty(t, q)
+
+		// Renewing keeps the task invisible past the original deadline
+		clock.advance(50 * time.Second)
+		if err := q.ExtendLease(ctx, first, time.Minute); err != nil {
+			t.Fatalf("ExtendLease failed: %v", err)
+		}
+		clock.advance(50 * time.Second)
+		expectEmpty(t, q)
+
+		// A crashed consumer stops renewing and the task is redelivered
+		clock.advance(time.Minute)
+		second := mustDequeue(t, q)
+		if second.ID != "t1" || second.Attempts != 2 {
+			t.Fatalf("Expected redelivery of t1 as attempt 2, got %s attempt %d", second.ID, second.Attempts)
+		}
+		if payload, ok := second.Payload.(map[string]interface{}); !ok || payload["instance"] != "redis-0" {
+			t.Errorf("Payload not preserved: %#v", second.Payload)
+		}
+
+		if err := q.Ack(ctx, first); !errors.Is(err, ErrLeaseLost) {
+			t.Errorf("Expected ErrLeaseLost acking a stale delivery, got %v", err)
+		}
+		if err := q.ExtendLease(ctx, first, time.Minute); !errors.Is(err, ErrLeaseLost) {
+			t.Errorf("Expected ErrLeaseLost renewing a stale delivery, got %v", err)
+		}
+		if err := q.Ack(ctx, second); err != nil {
+			t.Errorf("Ack failed: %v", err)
+		}
+		clock.advance(time.Hour)
+		expectEmpty(t, q)
+	})
+
+	t.Run("RetriesThenDeadLetters", func(t *testing.T) {
+		clock := &fakeClock{t: time.Now()}
+		q := newQueue(t, opts, clock)
+		if err := q.Enqueue(ctx, &Task{ID: "t1", Type: "diagnosis", Priority: PriorityHigh}); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+
+		for attempt := 1; attempt <= 3; attempt++ {
+			task := mustDequeue(t, q)
+			if task.Attempts != attempt {
+				t.Fatalf("Expected attempt %d, got %d", attempt, task.Attempts)
+			}
+			if err := q.Nack(ctx, task, 10*time.Second, errors.New("connection refused")); err != nil {
+				t.Fatalf("Nack failed: %v", err)
+			}
+			if attempt < 3 {
+				if task.DeadLetteredAt != nil {
+					t.Fatalf("Task dead-lettered after attempt %d of 3", attempt)
+				}
+				// Not visible before the retry delay elapsed
+				expectEmpty(t, q)
+				clock.advance(10 * time.Second)
+			} else if task.DeadLetteredAt == nil {
+				t.Fatalf("Expected task to be dead-lettered after its last attempt")
+			}
+		}
+		clock.advance(time.Hour)
+		expectEmpty(t, q)
+
+		dead, err := q.DeadLetters(ctx)
+		if err != nil {
+			t.Fatalf("DeadLetters failed: %v", err)
+		}
+		if len(dead) != 1 || dead[0].ID != "t1" {
+			t.Fatalf("Expected t1 in the dead-letter queue, got %v", dead)
+		}
+		if dead[0].LastError != "connection refused" || dead[0].Attempts != 3 || dead[0].DeadLetteredAt == nil {
+			t.Errorf("Unexpected dead letter: %+v", dead[0])
+		}
+
+		if err := q.Replay(ctx, "missing"); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("Expected ErrTaskNotFound, got %v", err)
+		}
+		if err := q.Replay(ctx, "t1"); err != nil {
+			t.Fatalf("Replay failed: %v", err)
+		}
+		replayed := mustDequeue(t, q)
+		if replayed.ID != "t1" || replayed.Attempts != 1 || replayed.Priority != PriorityHigh {
+			t.Errorf("Expected fresh high priority delivery of t1, got %+v", replayed)
+		}
+		if dead, _ := q.DeadLetters(ctx); len(dead) != 0 {
+			t.Errorf("Expected empty dead-letter queue after replay, got %d", len(dead))
+		}
+	})
+
+	t.Run("DeadLetterAndExpiredLease", func(t *testing.T) {
+		clock := &fakeClock{t: time.Now()}
+		q := newQueue(t, opts, clock)
+		_ = q.Enqueue(ctx, &Task{ID: "bad", Type: "unknown"})
+		_ = q.Enqueue(ctx, &Task{ID: "slow", Type: "diagnosis", MaxAttempts: 1})
+
+		bad := mustDequeue(t, q)
+		if err := q.DeadLetter(ctx, bad, errors.New("unknown task type")); err != nil {
+			t.Fatalf("DeadLetter failed: %v", err)
+		}
+		if bad.DeadLetteredAt == nil {
+			t.Errorf("Expected DeadLetteredAt to be set")
+		}
+
+		// The only attempt of slow times out
+		mustDequeue(t, q)
+		clock.advance(2 * time.Minute)
+		expectEmpty(t, q)
+
+		dead, err := q.DeadLetters(ctx)
+		if err != nil {
+			t.Fatalf("DeadLetters failed: %v", err)
+		}
+		if len(dead) != 2 || dead[0].ID != "bad" || dead[1].ID != "slow" {
+			t.Fatalf("Expected bad and slow in the dead-letter queue, got %v", dead)
+		}
+		if dead[1].LastError != "lease expired" {
+			t.Errorf("Expected lease expiry as cause, got %q", dead[1].LastError)
+		}
+	})
+
+	t.Run("DequeueWaitsForEnqueue", func(t *testing.T) {
+		q := newQueue(t, opts, &fakeClock{t: time.Now()})
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			_ = q.Enqueue(ctx, &Task{ID: "late", Type: "diagnosis"})
+		}()
+		if task := mustDequeue(t, q); task.ID != "late" {
+			t.Errorf("Expected late, got %s", task.ID)
+		}
+	})
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+	for attempt, want := range map[int]time.Duration{
+		0:  time.Second,
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		4:  8 * time.Second,
+		5:  10 * time.Second,
+		99: 10 * time.Second,
+	} {
+		if got := p.Backoff(attempt); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+	if got := (RetryPolicy{}).Backoff(3); got != 0 {
+		t.Errorf("Expected no backoff without InitialBackoff, got %v", got)
+	}
+}
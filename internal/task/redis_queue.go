@@ -3,18 +3,34 @@ package task
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
 // RedisQueue implements TaskQueue using Redis.
+//
+// Task bodies live in a hash and only IDs move between the structures below,
+// all prefixed with the queue name:
+//
+//	:ready:{high,normal,low}  lists of tasks waiting for a consumer
+//	:delayed                  zset of nacked tasks by due time
+//	:inflight                 zset of leased tasks by visibility deadline
+//	:dlq                      zset of dead-lettered tasks by time of death
+//
+// State transitions run as Lua scripts so that concurrent workers never see
+// a task in two places at once.
 type RedisQueue struct {
 	client    *redis.Client
 	queueName string
+	opts      QueueOptions
+	now       func() time.Time
 }
 
-// NewRedisQueue creates a new RedisQueue.
+// NewRedisQueue creates a new RedisQueue with the default QueueOptions.
 func NewRedisQueue(addr, password string, db int, queueName string) *RedisQueue {
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     addr,
@@ -22,43 +38,346 @@ func NewRedisQueue(addr, password string, db int, queueName string) *RedisQueue
 		DB:       db,       // use default DB
 	})
 
+	return NewRedisQueueWithOptions(rdb, queueName, QueueOptions{})
+}
+
+// NewRedisQueueWithOptions creates a RedisQueue on an existing client.
+func NewRedisQueueWithOptions(client *redis.Client, queueName string, opts QueueOptions) *RedisQueue {
 	return &RedisQueue{
-		client:    rdb,
+		client:    client,
 		queueName: queueName,
+		opts:      opts.withDefaults(),
+		now:       time.Now,
 	}
 }
 
-// Enqueue adds a task to the queue.
+func (q *RedisQueue) key(suffix string) string {
+	return q.queueName + ":" + suffix
+}
+
+func (q *RedisQueue) laneKeys() []string {
+	keys := make([]string, 0, len(priorities))
+	for _, p := range priorities {
+		keys = append(keys, q.key("ready:"+p.String()))
+	}
+	return keys
+}
+
+// Per-task metadata hashes, keyed by task ID.
+const (
+	fieldTasks    = "tasks"
+	fieldAttempts = "attempts"
+	fieldMax      = "max_attempts"
+	fieldPriority = "priority"
+	fieldErrors   = "errors"
+)
+
+// luaLanes maps a priority name to its ready list. It expects the three lane
+// keys in KEYS[1..3], highest first.
+const luaLanes = `
+local lanes = {high = KEYS[1], normal = KEYS[2], low = KEYS[3]}
+local function lane(id)
+  return lanes[redis.call('HGET', KEYS[7], id)] or KEYS[2]
+end
+`
+
+// luaLease checks that ARGV[1] is leased with the delivery count ARGV[2].
+const luaLease = `
+if not redis.call('ZSCORE', KEYS[5], ARGV[1]) or redis.call('HGET', KEYS[6], ARGV[1]) ~= ARGV[2] then
+  return -1
+end
+`
+
+// Scripts share the key layout:
+//
+//	KEYS[1..3] ready lanes, KEYS[4] delayed, KEYS[5] inflight, KEYS[6] attempts,
+//	KEYS[7] priority, KEYS[8] max attempts, KEYS[9] errors, KEYS[10] dlq,
+//	KEYS[11] task bodies
+var (
+	// ARGV: now, lease deadline
+	dequeueScript = redis.NewScript(luaLanes + `
+local now = tonumber(ARGV[1])
+for _, id in ipairs(redis.call('ZRANGEBYSCORE', KEYS[4], '-inf', now)) do
+  redis.call('ZREM', KEYS[4], id)
+  redis.call('RPUSH', lane(id), id)
+end
+for _, id in ipairs(redis.call('ZRANGEBYSCORE', KEYS[5], '-inf', now)) do
+  redis.call('ZREM', KEYS[5], id)
+  local attempts = tonumber(redis.call('HGET', KEYS[6], id) or '0')
+  local max = tonumber(redis.call('HGET', KEYS[8], id) or '1')
+  if attempts >= max then
+    redis.call('HSET', KEYS[9], id, 'lease expired')
+    redis.call('ZADD', KEYS[10], now, id)
+  else
+    redis.call('LPUSH', lane(id), id)
+  end
+end
+for i = 1, 3 do
+  local id = redis.call('LPOP', KEYS[i])
+  if id then
+    local attempts = redis.call('HINCRBY', KEYS[6], id, 1)
+    redis.call('ZADD', KEYS[5], ARGV[2], id)
+    return {redis.call('HGET', KEYS[11], id), attempts, redis.call('HGET', KEYS[9], id) or ''}
+  end
+end
+return false
+`)
+
+	// ARGV: id, attempts
+	ackScript = redis.NewScript(luaLease + `
+redis.call('ZREM', KEYS[5], ARGV[1])
+for i = 6, 9 do
+  redis.call('HDEL', KEYS[i], ARGV[1])
+end
+redis.call('HDEL', KEYS[11], ARGV[1])
+return 1
+`)
+
+	// ARGV: id, attempts, now, due, cause. Returns 2 if dead-lettered.
+	nackScript = redis.NewScript(luaLanes + luaLease + `
+redis.call('ZREM', KEYS[5], ARGV[1])
+redis.call('HSET', KEYS[9], ARGV[1], ARGV[5])
+if tonumber(ARGV[2]) >= tonumber(redis.call('HGET', KEYS[8], ARGV[1]) or '1') then
+  redis.call('ZADD', KEYS[10], ARGV[3], ARGV[1])
+  return 2
+end
+if tonumber(ARGV[4]) <= tonumber(ARGV[3]) then
+  redis.call('RPUSH', lane(ARGV[1]), ARGV[1])
+else
+  redis.call('ZADD', KEYS[4], ARGV[4], ARGV[1])
+end
+return 1
+`)
+
+	// ARGV: id, attempts, deadline
+	extendScript = redis.NewScript(luaLease + `
+redis.call('ZADD', KEYS[5], ARGV[3], ARGV[1])
+return 1
+`)
+
+	// ARGV: id, attempts, now, cause
+	deadLetterScript = redis.NewScript(luaLease + `
+redis.call('ZREM', KEYS[5], ARGV[1])
+redis.call('HSET', KEYS[9], ARGV[1], ARGV[4])
+redis.call('ZADD', KEYS[10], ARGV[3], ARGV[1])
+return 1
+`)
+
+	// ARGV: id
+	replayScript = redis.NewScript(luaLanes + `
+if redis.call('ZREM', KEYS[10], ARGV[1]) == 0 then
+  return -1
+end
+redis.call('HSET', KEYS[6], ARGV[1], 0)
+redis.call('RPUSH', lane(ARGV[1]), ARGV[1])
+return 1
+`)
+)
+
+func (q *RedisQueue) scriptKeys() []string {
+	return append(q.laneKeys(),
+		q.key("delayed"),
+		q.key("inflight"),
+		q.key(fieldAttempts),
+		q.key(fieldPriority),
+		q.key(fieldMax),
+		q.key(fieldErrors),
+		q.key("dlq"),
+		q.key(fieldTasks),
+	)
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// Enqueue adds a task to the lane of its priority.
 func (q *RedisQueue) Enqueue(ctx context.Context, task *Task) error {
-	data, err := json.Marshal(task)
+	if task.ID == "" {
+		return fmt.Errorf("task ID is required")
+	}
+	t := *task
+	t.Attempts = 0
+	t.LastError = ""
+	t.DeadLetteredAt = nil
+	data, err := json.Marshal(&t)
 	if err != nil {
 		return fmt.Errorf("failed to marshal task: %w", err)
 	}
 
-	return q.client.RPush(ctx, q.queueName, data).Err()
+	lane := t.Priority.lane().String()
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, q.key(fieldTasks), t.ID, data)
+		pipe.HSet(ctx, q.key(fieldAttempts), t.ID, 0)
+		pipe.HSet(ctx, q.key(fieldMax), t.ID, q.opts.maxAttempts(&t))
+		pipe.HSet(ctx, q.key(fieldPriority), t.ID, lane)
+		pipe.HDel(ctx, q.key(fieldErrors), t.ID)
+		pipe.RPush(ctx, q.key("ready:"+lane), t.ID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+	return nil
 }
 
-// Dequeue retrieves a task from the queue. It blocks if the queue is empty.
+// Dequeue leases the next ready task, polling until one is available.
 func (q *RedisQueue) Dequeue(ctx context.Context) (*Task, error) {
-	// BLPop returns a slice where result[0] is the key and result[1] is the value.
-	// 0 timeout means block indefinitely.
-	result, err := q.client.BLPop(ctx, 0, q.queueName).Result()
+	for {
+		task, err := q.tryDequeue(ctx)
+		if err != nil || task != nil {
+			return task, err
+		}
+
+		timer := time.NewTimer(q.opts.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (q *RedisQueue) tryDequeue(ctx context.Context) (*Task, error) {
+	now := q.now()
+	res, err := dequeueScript.Run(ctx, q.client, q.scriptKeys(),
+		millis(now), millis(now.Add(q.opts.VisibilityTimeout))).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to dequeue task: %w", err)
 	}
 
-	if len(result) < 2 {
+	fields, ok := res.([]interface{})
+	if !ok || len(fields) != 3 {
 		return nil, fmt.Errorf("invalid response from redis")
 	}
-
+	data, _ := fields[0].(string)
 	var task Task
-	if err := json.Unmarshal([]byte(result[1]), &task); err != nil {
+	if err := json.Unmarshal([]byte(data), &task); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal task: %w", err)
 	}
-
+	attempts, _ := fields[1].(int64)
+	task.Attempts = int(attempts)
+	task.LastError, _ = fields[2].(string)
 	return &task, nil
 }
 
+func (q *RedisQueue) runLeased(ctx context.Context, script *redis.Script, task *Task, args ...interface{}) (int64, error) {
+	args = append([]interface{}{task.ID, task.Attempts}, args...)
+	n, err := script.Run(ctx, q.client, q.scriptKeys(), args...).Int64()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, ErrLeaseLost
+	}
+	return n, nil
+}
+
+// Ack removes a leased task and its metadata.
+func (q *RedisQueue) Ack(ctx context.Context, task *Task) error {
+	_, err := q.runLeased(ctx, ackScript, task)
+	return err
+}
+
+// Nack requeues a leased task after delay, or dead-letters it once its
+// attempts are used up, in which case task.DeadLetteredAt is set.
+func (q *RedisQueue) Nack(ctx context.Context, task *Task, delay time.Duration, cause error) error {
+	now := q.now()
+	if delay < 0 {
+		delay = 0
+	}
+	n, err := q.runLeased(ctx, nackScript, task, millis(now), millis(now.Add(delay)), errorString(cause))
+	if err != nil {
+		return err
+	}
+	task.LastError = errorString(cause)
+	if n == 2 {
+		task.DeadLetteredAt = &now
+	}
+	return nil
+}
+
+// ExtendLease moves the visibility deadline of a leased task to d from now.
+func (q *RedisQueue) ExtendLease(ctx context.Context, task *Task, d time.Duration) error {
+	_, err := q.runLeased(ctx, extendScript, task, millis(q.now().Add(d)))
+	return err
+}
+
+// DeadLetter moves a leased task to the dead-letter queue.
+func (q *RedisQueue) DeadLetter(ctx context.Context, task *Task, cause error) error {
+	now := q.now()
+	if _, err := q.runLeased(ctx, deadLetterScript, task, millis(now), errorString(cause)); err != nil {
+		return err
+	}
+	task.LastError = errorString(cause)
+	task.DeadLetteredAt = &now
+	return nil
+}
+
+// DeadLetters lists the dead-lettered tasks, oldest first.
+func (q *RedisQueue) DeadLetters(ctx context.Context) ([]*Task, error) {
+	entries, err := q.client.ZRangeWithScores(ctx, q.key("dlq"), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	if len(entries) == 0 {
+		return []*Task{}, nil
+	}
+
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i], _ = e.Member.(string)
+	}
+	bodies, err := q.client.HMGet(ctx, q.key(fieldTasks), ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dead letters: %w", err)
+	}
+	attempts, err := q.client.HMGet(ctx, q.key(fieldAttempts), ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dead letters: %w", err)
+	}
+	causes, err := q.client.HMGet(ctx, q.key(fieldErrors), ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dead letters: %w", err)
+	}
+
+	tasks := make([]*Task, 0, len(entries))
+	for i, e := range entries {
+		data, ok := bodies[i].(string)
+		if !ok {
+			continue
+		}
+		var task Task
+		if err := json.Unmarshal([]byte(data), &task); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal task %s: %w", ids[i], err)
+		}
+		if s, ok := attempts[i].(string); ok {
+			task.Attempts, _ = strconv.Atoi(s)
+		}
+		task.LastError, _ = causes[i].(string)
+		at := time.Unix(0, int64(e.Score)*int64(time.Millisecond))
+		task.DeadLetteredAt = &at
+		tasks = append(tasks, &task)
+	}
+	return tasks, nil
+}
+
+// Replay moves a dead-lettered task back to its lane.
+func (q *RedisQueue) Replay(ctx context.Context, id string) error {
+	n, err := replayScript.Run(ctx, q.client, q.scriptKeys(), id).Int64()
+	if err != nil {
+		return fmt.Errorf("failed to replay task: %w", err)
+	}
+	if n < 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
 // Close closes the Redis client.
 func (q *RedisQueue) Close() error {
 	return q.client.Close()
@@ -0,0 +1,18 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestRedisQueue(t *testing.T) {
+	testQueueContract(t, func(t *testing.T, opts QueueOptions, c *fakeClock) TaskQueue {
+		mr := miniredis.RunT(t)
+		q := NewRedisQueueWithOptions(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "test_tasks", opts)
+		q.now = c.now
+		t.Cleanup(func() { _ = q.Close() })
+		return q
+	})
+}
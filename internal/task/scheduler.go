@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	llminterfaces "github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
 	"github.com/kubestack-ai/kubestack-ai/internal/storage"
 )
 
@@ -24,8 +25,15 @@ func NewScheduler(queue TaskQueue, store storage.TaskStore) *Scheduler {
 	}
 }
 
-// SubmitDiagnosisTask creates a new diagnosis task and submits it to the queue.
-func (s *Scheduler) SubmitDiagnosisTask(req *models.DiagnosisRequest) (string, error) {
+// SubmitDiagnosisTask creates a new diagnosis task and submits it to the
+// queue, on behalf of the LLM tenant tagged on ctx.
+func (s *Scheduler) SubmitDiagnosisTask(ctx context.Context, req *models.DiagnosisRequest) (string, error) {
+	return s.SubmitDiagnosisTaskWithPriority(ctx, req, PriorityNormal)
+}
+
+// SubmitDiagnosisTaskWithPriority submits a diagnosis task to the given lane,
+// e.g. PriorityHigh for diagnoses triggered by an alert.
+func (s *Scheduler) SubmitDiagnosisTaskWithPriority(ctx context.Context, req *models.DiagnosisRequest, priority Priority) (string, error) {
 	taskID := uuid.New().String()
 
 	// Create initial task state in storage
@@ -38,16 +46,18 @@ func (s *Scheduler) SubmitDiagnosisTask(req *models.DiagnosisRequest) (string, e
 		Type:      "diagnosis",
 		Payload:   req,
 		CreatedAt: time.Now(),
+		Priority:  priority,
+		Tenant:    llminterfaces.TenantFromContext(ctx),
 	}
 
 	// Enqueue the task
 	// Use background context for enqueueing to ensure it happens even if request context cancels?
 	// But Enqueue might respect context cancellation.
 	// Let's use a timeout context for enqueue.
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	enqueueCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := s.queue.Enqueue(ctx, task); err != nil {
+	if err := s.queue.Enqueue(enqueueCtx, task); err != nil {
 		// Attempt to update status to failed if enqueue fails
 		_ = s.taskStore.SaveError(taskID, fmt.Errorf("failed to enqueue: %w", err))
 		return "", err
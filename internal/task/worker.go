@@ -3,6 +3,7 @@ package task
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -12,30 +13,62 @@ import (
 	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	llminterfaces "github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
 	"github.com/kubestack-ai/kubestack-ai/internal/notification"
 	"github.com/kubestack-ai/kubestack-ai/internal/storage"
 )
 
 // Worker consumes tasks from the queue and executes them.
 type Worker struct {
-	queue            TaskQueue
-	diagnosisManager interfaces.DiagnosisManager
-	taskStore        storage.TaskStore
-	notifier         notification.Notifier
-	config           config.NotificationConfig
-	stopChan         chan struct{}
+	queue             TaskQueue
+	diagnosisManager  interfaces.DiagnosisManager
+	taskStore         storage.TaskStore
+	notifier          notification.Notifier
+	config            config.NotificationConfig
+	retry             RetryPolicy
+	visibilityTimeout time.Duration
+	ctx               context.Context
+	cancel            context.CancelFunc
+}
+
+// WorkerOption configures a Worker.
+type WorkerOption func(*Worker)
+
+// WithRetryPolicy sets the backoff applied before a failed task is retried.
+func WithRetryPolicy(p RetryPolicy) WorkerOption {
+	return func(w *Worker) {
+		w.retry = p
+	}
+}
+
+// WithVisibilityTimeout sets the lease length the worker renews while a task
+// runs. It should match the visibility timeout of the queue.
+func WithVisibilityTimeout(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		if d > 0 {
+			w.visibilityTimeout = d
+		}
+	}
 }
 
 // NewWorker creates a new Worker.
-func NewWorker(queue TaskQueue, manager interfaces.DiagnosisManager, store storage.TaskStore, notifier notification.Notifier, notifCfg config.NotificationConfig) *Worker {
-	return &Worker{
-		queue:            queue,
-		diagnosisManager: manager,
-		taskStore:        store,
-		notifier:         notifier,
-		config:           notifCfg,
-		stopChan:         make(chan struct{}),
+func NewWorker(queue TaskQueue, manager interfaces.DiagnosisManager, store storage.TaskStore, notifier notification.Notifier, notifCfg config.NotificationConfig, opts ...WorkerOption) *Worker {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Worker{
+		queue:             queue,
+		diagnosisManager:  manager,
+		taskStore:         store,
+		notifier:          notifier,
+		config:            notifCfg,
+		retry:             DefaultRetryPolicy,
+		visibilityTimeout: DefaultVisibilityTimeout,
+		ctx:               ctx,
+		cancel:            cancel,
+	}
+	for _, opt := range opts {
+		opt(w)
 	}
+	return w
 }
 
 // Start starts the worker loop.
@@ -43,64 +76,138 @@ func (w *Worker) Start() {
 	go w.run()
 }
 
-// Stop stops the worker loop.
+// Stop stops the worker loop. A task being processed runs to completion.
 func (w *Worker) Stop() {
-	close(w.stopChan)
+	w.cancel()
 }
 
 func (w *Worker) run() {
-	for {
-		select {
-		case <-w.stopChan:
-			return
-		default:
-			// Dequeue with timeout or use blocking dequeue with context check
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			task, err := w.queue.Dequeue(ctx)
-			cancel()
-
-			if err != nil {
-				// Log error (timeout or connection issue) and retry/continue
-				continue
+	for w.ctx.Err() == nil {
+		task, err := w.queue.Dequeue(w.ctx)
+		if err != nil {
+			if errors.Is(err, ErrQueueClosed) {
+				return
 			}
-
-			if task == nil {
-				// Should not happen if err is nil, but safety check
-				continue
+			if w.ctx.Err() == nil && !errors.Is(err, context.DeadlineExceeded) {
+				// Connection issue, back off before retrying
+				log.Printf("Failed to dequeue task: %v", err)
+				select {
+				case <-w.ctx.Done():
+				case <-time.After(time.Second):
+				}
 			}
+			continue
+		}
 
-			w.processTask(task)
+		if task == nil {
+			// Should not happen if err is nil, but safety check
+			continue
 		}
+
+		w.processTask(task)
 	}
 }
 
+// permanentError marks a failure that retrying the task cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
 func (w *Worker) processTask(task *Task) {
 	// Update status to RUNNING
 	if err := w.taskStore.UpdateStatus(task.ID, storage.TaskStateRunning); err != nil {
 		log.Printf("Failed to update task status to RUNNING: %v", err)
 	}
 
+	// LLM calls made by the run are charged to whoever submitted the task
+	ctx, cancel := context.WithCancel(llminterfaces.WithTenant(context.Background(), task.Tenant))
+	defer cancel()
+	renewed := w.keepLease(ctx, cancel, task)
+
+	result, err := w.execute(ctx, task)
+	cancel()
+	<-renewed
+
+	// Settle the task with a fresh context, the run context is cancelled by now
+	ackCtx, ackCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ackCancel()
+
+	var perm *permanentError
+	switch {
+	case err == nil:
+		_ = w.taskStore.SaveResult(task.ID, result)
+		w.notifyIfNeeded(ackCtx, result)
+		err = w.queue.Ack(ackCtx, task)
+	case errors.As(err, &perm):
+		_ = w.taskStore.SaveError(task.ID, err)
+		err = w.queue.DeadLetter(ackCtx, task, err)
+	default:
+		cause := err
+		err = w.queue.Nack(ackCtx, task, w.retry.Backoff(task.Attempts), cause)
+		if err == nil && task.DeadLetteredAt == nil {
+			log.Printf("Task %s failed on attempt %d, will retry: %v", task.ID, task.Attempts, cause)
+			_ = w.taskStore.UpdateStatus(task.ID, storage.TaskStatePending)
+		} else if err == nil {
+			_ = w.taskStore.SaveError(task.ID, cause)
+		}
+	}
+
+	if errors.Is(err, ErrLeaseLost) {
+		log.Printf("Lease on task %s expired, it was redelivered to another consumer", task.ID)
+	} else if err != nil {
+		log.Printf("Failed to settle task %s: %v", task.ID, err)
+	}
+}
+
+// keepLease renews the lease on task until ctx is done, cancelling the run
+// via lost when another consumer took the task over. The returned channel is
+// closed once renewal has stopped.
+func (w *Worker) keepLease(ctx context.Context, lost context.CancelFunc, task *Task) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(w.visibilityTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := w.queue.ExtendLease(ctx, task, w.visibilityTimeout)
+				if errors.Is(err, ErrLeaseLost) {
+					log.Printf("Lost lease on task %s, abandoning it", task.ID)
+					lost()
+					return
+				}
+				if err != nil && ctx.Err() == nil {
+					log.Printf("Failed to extend lease on task %s: %v", task.ID, err)
+				}
+			}
+		}
+	}()
+	return done
+}
+
+func (w *Worker) execute(ctx context.Context, task *Task) (*models.DiagnosisResult, error) {
 	// Currently only supports "diagnosis" type
 	if task.Type != "diagnosis" {
-		err := fmt.Errorf("unknown task type: %s", task.Type)
-		_ = w.taskStore.SaveError(task.ID, err)
-		return
+		return nil, &permanentError{fmt.Errorf("unknown task type: %s", task.Type)}
 	}
 
 	// Unmarshal payload
 	var req models.DiagnosisRequest
 	payloadBytes, err := json.Marshal(task.Payload)
 	if err != nil {
-		_ = w.taskStore.SaveError(task.ID, fmt.Errorf("failed to marshal payload: %w", err))
-		return
+		return nil, &permanentError{fmt.Errorf("failed to marshal payload: %w", err)}
 	}
 	if err := json.Unmarshal(payloadBytes, &req); err != nil {
-		_ = w.taskStore.SaveError(task.ID, fmt.Errorf("failed to unmarshal payload to DiagnosisRequest: %w", err))
-		return
+		return nil, &permanentError{fmt.Errorf("failed to unmarshal payload to DiagnosisRequest: %w", err)}
 	}
 
 	// Execute diagnosis
-	diagCtx := context.Background()
 	progressChan := make(chan interfaces.DiagnosisProgress, 100)
 
 	go func() {
@@ -109,16 +216,7 @@ func (w *Worker) processTask(task *Task) {
 		}
 	}()
 
-	result, err := w.diagnosisManager.RunDiagnosis(diagCtx, &req, progressChan)
-
-	if err != nil {
-		_ = w.taskStore.SaveError(task.ID, err)
-		// Assuming we don't notify on system error unless configured, or create a failed result
-		// For now, only notify on DiagnosisResult with severity check
-	} else {
-		_ = w.taskStore.SaveResult(task.ID, result)
-		w.notifyIfNeeded(diagCtx, result)
-	}
+	return w.diagnosisManager.RunDiagnosis(ctx, &req, progressChan)
 }
 
 func (w *Worker) notifyIfNeeded(ctx context.Context, result *models.DiagnosisResult) {
@@ -0,0 +1,175 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	llminterfaces "github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/storage"
+)
+
+// flakyManager fails the first failures diagnoses, then succeeds.
+type flakyManager struct {
+	interfaces.DiagnosisManager
+	mu       sync.Mutex
+	failures int
+	calls    int
+	tenants  []string
+}
+
+func (m *flakyManager) RunDiagnosis(ctx context.Context, req *models.DiagnosisRequest, progress chan<- interfaces.DiagnosisProgress) (*models.DiagnosisResult, error) {
+	close(progress)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	m.tenants = append(m.tenants, llminterfaces.TenantFromContext(ctx))
+	if m.calls <= m.failures {
+		return nil, errors.New("collector timeout")
+	}
+	return &models.DiagnosisResult{ID: req.Instance}, nil
+}
+
+func (m *flakyManager) callCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+func runWorker(t *testing.T, q TaskQueue, manager interfaces.DiagnosisManager, store storage.TaskStore, opts ...WorkerOption) {
+	t.Helper()
+	w := NewWorker(q, manager, store, nil, config.NotificationConfig{}, opts...)
+	w.Start()
+	t.Cleanup(w.Stop)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWorker_RetriesUntilSuccess(t *testing.T) {
+	q := NewMemoryQueue(QueueOptions{MaxAttempts: 3})
+	store := storage.NewInMemoryTaskStore()
+	manager := &flakyManager{failures: 2}
+	runWorker(t, q, manager, store, WithRetryPolicy(RetryPolicy{InitialBackoff: time.Millisecond}))
+
+	_ = store.CreateTask("t1")
+	_ = q.Enqueue(context.Background(), &Task{ID: "t1", Type: "diagnosis", Payload: models.DiagnosisRequest{Instance: "redis-0"}})
+
+	waitFor(t, func() bool {
+		status, err := store.GetStatus("t1")
+		return err == nil && status.State == storage.TaskStateCompleted
+	})
+	if calls := manager.callCount(); calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+	if dead, _ := q.DeadLetters(context.Background()); len(dead) != 0 {
+		t.Errorf("Expected no dead letters, got %d", len(dead))
+	}
+}
+
+func TestWorker_RunsTaskAsSubmittingTenant(t *testing.T) {
+	q := NewMemoryQueue(QueueOptions{})
+	store := storage.NewInMemoryTaskStore()
+	manager := &flakyManager{}
+	runWorker(t, q, manager, store)
+
+	ctx := llminterfaces.WithTenant(context.Background(), "team-a")
+	id, err := NewScheduler(q, store).SubmitDiagnosisTask(ctx, &models.DiagnosisRequest{Instance: "redis-0"})
+	if err != nil {
+		t.Fatalf("SubmitDiagnosisTask failed: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		status, err := store.GetStatus(id)
+		return err == nil && status.State == storage.TaskStateCompleted
+	})
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	if len(manager.tenants) != 1 || manager.tenants[0] != "team-a" {
+		t.Errorf("Expected the diagnosis to run as team-a, got %v", manager.tenants)
+	}
+}
+
+func TestWorker_DeadLettersExhaustedTask(t *testing.T) {
+	q := NewMemoryQueue(QueueOptions{MaxAttempts: 2})
+	store := storage.NewInMemoryTaskStore()
+	manager := &flakyManager{failures: 10}
+	runWorker(t, q, manager, store, WithRetryPolicy(RetryPolicy{InitialBackoff: time.Millisecond}))
+
+	_ = store.CreateTask("t1")
+	_ = q.Enqueue(context.Background(), &Task{ID: "t1", Type: "diagnosis", Payload: models.DiagnosisRequest{Instance: "redis-0"}})
+
+	waitFor(t, func() bool {
+		dead, _ := q.DeadLetters(context.Background())
+		return len(dead) == 1
+	})
+	status, err := store.GetStatus("t1")
+	if err != nil || status.State != storage.TaskStateFailed {
+		t.Errorf("Expected task to be FAILED, got %+v, %v", status, err)
+	}
+	if calls := manager.callCount(); calls != 2 {
+		t.Errorf("Expected 2 attempts, got %d", calls)
+	}
+}
+
+func TestWorker_DeadLettersUnknownTaskType(t *testing.T) {
+	q := NewMemoryQueue(QueueOptions{MaxAttempts: 5})
+	manager := &flakyManager{}
+	runWorker(t, q, manager, storage.NewInMemoryTaskStore())
+
+	_ = q.Enqueue(context.Background(), &Task{ID: "t1", Type: "reindex"})
+
+	waitFor(t, func() bool {
+		dead, _ := q.DeadLetters(context.Background())
+		return len(dead) == 1 && dead[0].Attempts == 1
+	})
+	if calls := manager.callCount(); calls != 0 {
+		t.Errorf("Expected no diagnosis for an unknown task type, got %d", calls)
+	}
+}
+
+func TestWorker_RenewsLease(t *testing.T) {
+	q := NewMemoryQueue(QueueOptions{VisibilityTimeout: 30 * time.Millisecond})
+	release := make(chan struct{})
+	manager := &blockingManager{release: release}
+	runWorker(t, q, manager, storage.NewInMemoryTaskStore(), WithVisibilityTimeout(30*time.Millisecond))
+
+	_ = q.Enqueue(context.Background(), &Task{ID: "t1", Type: "diagnosis", Payload: models.DiagnosisRequest{}})
+
+	// Outlive several visibility timeouts; the renewed task must not be redelivered
+	time.Sleep(150 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if task, err := q.Dequeue(ctx); err == nil {
+		t.Errorf("Task %s was redelivered while its lease was being renewed", task.ID)
+	}
+	close(release)
+}
+
+type blockingManager struct {
+	interfaces.DiagnosisManager
+	release chan struct{}
+}
+
+func (m *blockingManager) RunDiagnosis(ctx context.Context, req *models.DiagnosisRequest, progress chan<- interfaces.DiagnosisProgress) (*models.DiagnosisResult, error) {
+	close(progress)
+	select {
+	case <-m.release:
+		return &models.DiagnosisResult{}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+)
+
+// DiagnoseToolName is the name of the tool returned by NewDiagnoseTool
+const DiagnoseToolName = "diagnose"
+
+// NewDiagnoseTool returns a local tool that runs a full diagnosis of a
+// middleware instance through manager and returns the result
+func NewDiagnoseTool(manager interfaces.DiagnosisManager) *Tool {
+	return &Tool{
+		Name:        DiagnoseToolName,
+		Description: "Run a full diagnosis of a middleware instance and return the issues found",
+		Source:      SourceLocal,
+		Schema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "middleware": {"type": "string", "description": "Middleware type, e.g. redis, mysql, kafka"},
+    "instance": {"type": "string", "description": "Instance name"},
+    "namespace": {"type": "string", "description": "Kubernetes namespace"}
+  },
+  "required": ["middleware", "instance"],
+  "additionalProperties": false
+}`),
+		Handler: func(ctx context.Context, args map[string]any) (any, error) {
+			middleware, _ := args["middleware"].(string)
+			mt, err := enum.ParseMiddlewareType(middleware)
+			if err != nil {
+				return nil, err
+			}
+			req := &models.DiagnosisRequest{TargetMiddleware: mt}
+			req.Instance, _ = args["instance"].(string)
+			req.Namespace, _ = args["namespace"].(string)
+
+			progress := make(chan interfaces.DiagnosisProgress)
+			go func() {
+				step := 0
+				for p := range progress {
+					step++
+					ReportProgress(ctx, float64(step), 0, p.Step+": "+p.Message)
+				}
+			}()
+			return manager.RunDiagnosis(ctx, req, progress)
+		},
+	}
+}
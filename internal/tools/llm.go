@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
+)
+
+// maxFunctionNameLen is the longest function name accepted by OpenAI-style APIs
+const maxFunctionNameLen = 64
+
+// LLMToolSet exposes registry tools to an LLM as native function definitions
+// and executes the tool calls the model returns. Function names are restricted
+// to [a-zA-Z0-9_-], so registry names such as "mcp:server:tool" are mapped to
+// valid names and back.
+type LLMToolSet struct {
+	registry   Registry
+	defs       []interfaces.ToolDefinition
+	byFunction map[string]string
+}
+
+// NewLLMToolSet builds function definitions for the given tools, or for every
+// registered tool when no names are given
+func NewLLMToolSet(registry Registry, names ...string) (*LLMToolSet, error) {
+	var selected []*Tool
+	if len(names) == 0 {
+		selected = registry.List()
+	} else {
+		for _, name := range names {
+			tool, err := registry.Get(name)
+			if err != nil {
+				return nil, err
+			}
+			selected = append(selected, tool)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Name < selected[j].Name })
+
+	s := &LLMToolSet{
+		registry:   registry,
+		byFunction: make(map[string]string, len(selected)),
+	}
+	for _, tool := range selected {
+		fn := s.uniqueFunctionName(tool.Name)
+		s.byFunction[fn] = tool.Name
+
+		var params any = map[string]any{"type": "object", "properties": map[string]any{}}
+		if len(tool.Schema) > 0 {
+			params = tool.Schema
+		}
+		s.defs = append(s.defs, interfaces.ToolDefinition{
+			Name:        fn,
+			Description: tool.Description,
+			Parameters:  params,
+		})
+	}
+	return s, nil
+}
+
+// Definitions returns the definitions to pass in LLMRequest.Tools
+func (s *LLMToolSet) Definitions() []interfaces.ToolDefinition {
+	return s.defs
+}
+
+// ToolName returns the registry name behind a function name
+func (s *LLMToolSet) ToolName(function string) (string, bool) {
+	name, ok := s.byFunction[function]
+	return name, ok
+}
+
+// Call executes a tool call and returns the tool message to append to the
+// conversation. The message is always usable: failures are reported to the
+// model in its content as well as returned.
+func (s *LLMToolSet) Call(ctx context.Context, call interfaces.ToolCall) (interfaces.Message, error) {
+	msg := interfaces.Message{Role: interfaces.RoleTool, ToolCallID: call.ID}
+
+	result, err := s.execute(ctx, call)
+	if err != nil {
+		data, _ := json.Marshal(map[string]string{"error": err.Error()})
+		msg.Content = string(data)
+		return msg, err
+	}
+
+	switch v := result.(type) {
+	case nil:
+		msg.Content = "null"
+	case string:
+		msg.Content = v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return msg, fmt.Errorf("failed to encode result of %s: %w", call.Name, err)
+		}
+		msg.Content = string(data)
+	}
+	return msg, nil
+}
+
+func (s *LLMToolSet) execute(ctx context.Context, call interfaces.ToolCall) (any, error) {
+	name, ok := s.byFunction[call.Name]
+	if !ok {
+		return nil, fmt.Errorf("tool %s is not available", call.Name)
+	}
+
+	args := map[string]any{}
+	if strings.TrimSpace(call.Arguments) != "" {
+		if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+			return nil, fmt.Errorf("invalid arguments for %s: %w", call.Name, err)
+		}
+	}
+	return s.registry.Execute(ctx, name, args)
+}
+
+// uniqueFunctionName maps a registry name to a valid, unused function name
+func (s *LLMToolSet) uniqueFunctionName(name string) string {
+	fn := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, name)
+	if len(fn) > maxFunctionNameLen {
+		fn = fn[:maxFunctionNameLen]
+	}
+
+	candidate := fn
+	for i := 2; ; i++ {
+		if _, taken := s.byFunction[candidate]; !taken {
+			return candidate
+		}
+		suffix := fmt.Sprintf("_%d", i)
+		base := fn
+		if len(base)+len(suffix) > maxFunctionNameLen {
+			base = base[:maxFunctionNameLen-len(suffix)]
+		}
+		candidate = base + suffix
+	}
+}
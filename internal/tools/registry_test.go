@@ -3,7 +3,10 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
 )
 
 func TestNewRegistry(t *testing.T) {
@@ -303,3 +306,45 @@ func TestRegistry_ConcurrentAccess(t *testing.T) {
 		<-done
 	}
 }
+
+func TestLLMToolSet_MapsNamesAndExecutesCalls(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&Tool{
+		Name:        "mcp:k8s:get_pods",
+		Description: "List pods",
+		Source:      SourceMCP,
+		Schema:      json.RawMessage(`{"type":"object","properties":{"namespace":{"type":"string"}}}`),
+		Handler: func(ctx context.Context, args map[string]any) (any, error) {
+			return map[string]any{"namespace": args["namespace"], "pods": 3}, nil
+		},
+	})
+	registry.Register(&Tool{Name: "mcp_k8s_get_pods", Handler: func(ctx context.Context, args map[string]any) (any, error) {
+		return "local", nil
+	}})
+
+	set, err := NewLLMToolSet(registry)
+	if err != nil {
+		t.Fatalf("Failed to build tool set: %v", err)
+	}
+
+	defs := set.Definitions()
+	if len(defs) != 2 || defs[0].Name != "mcp_k8s_get_pods" || defs[1].Name != "mcp_k8s_get_pods_2" {
+		t.Fatalf("Unexpected function names: %+v", defs)
+	}
+	if name, _ := set.ToolName("mcp_k8s_get_pods_2"); name != "mcp_k8s_get_pods" {
+		t.Errorf("Expected the colliding name to map back, got %s", name)
+	}
+
+	msg, err := set.Call(context.Background(), interfaces.ToolCall{ID: "call_1", Name: "mcp_k8s_get_pods", Arguments: `{"namespace":"shop"}`})
+	if err != nil {
+		t.Fatalf("Tool call failed: %v", err)
+	}
+	if msg.Role != interfaces.RoleTool || msg.ToolCallID != "call_1" || msg.Content != `{"namespace":"shop","pods":3}` {
+		t.Errorf("Unexpected tool message: %+v", msg)
+	}
+
+	msg, err = set.Call(context.Background(), interfaces.ToolCall{ID: "call_2", Name: "unknown"})
+	if err == nil || !strings.Contains(msg.Content, "not available") {
+		t.Errorf("Expected the failure to be reported to the model, got %+v", msg)
+	}
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidateArguments(t *testing.T) {
+	schema := json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "path": {"type": "string", "pattern": "^/data/"},
+    "mode": {"type": "string", "enum": ["read", "list"]},
+    "depth": {"type": "integer", "minimum": 0, "maximum": 5},
+    "tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2}
+  },
+  "required": ["path"],
+  "additionalProperties": false
+}`)
+
+	tests := []struct {
+		name    string
+		args    map[string]any
+		wantErr bool
+	}{
+		{"valid", map[string]any{"path": "/data/x", "mode": "read", "depth": 2, "tags": []string{"a"}}, false},
+		{"missing required", map[string]any{"mode": "read"}, true},
+		{"wrong type", map[string]any{"path": 3}, true},
+		{"pattern", map[string]any{"path": "/etc/passwd"}, true},
+		{"enum", map[string]any{"path": "/data/x", "mode": "delete"}, true},
+		{"not an integer", map[string]any{"path": "/data/x", "depth": 1.5}, true},
+		{"maximum", map[string]any{"path": "/data/x", "depth": 9}, true},
+		{"item type", map[string]any{"path": "/data/x", "tags": []any{1}}, true},
+		{"max items", map[string]any{"path": "/data/x", "tags": []string{"a", "b", "c"}}, true},
+		{"additional property", map[string]any{"path": "/data/x", "recursive": true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateArguments(schema, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateArguments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateArguments_EmptySchema(t *testing.T) {
+	if err := ValidateArguments(nil, map[string]any{"anything": 1}); err != nil {
+		t.Errorf("Expected empty schema to accept arguments, got %v", err)
+	}
+	if err := ValidateArguments(json.RawMessage(`{"type":"object"}`), nil); err != nil {
+		t.Errorf("Expected no arguments to be an empty object, got %v", err)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/interfaces"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	llminterfaces "github.com/kubestack-ai/kubestack-ai/internal/llm/interfaces"
 	"github.com/kubestack-ai/kubestack-ai/internal/storage"
 	"github.com/kubestack-ai/kubestack-ai/internal/task"
 )
@@ -74,7 +75,7 @@ func (h *ConsoleHandler) HandleDiagnoseRequest(c *gin.Context) {
 	async := c.Query("async") == "true"
 
 	if async && h.scheduler != nil {
-		taskID, err := h.scheduler.SubmitDiagnosisTask(&req)
+		taskID, err := h.scheduler.SubmitDiagnosisTask(c.Request.Context(), &req)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit task: " + err.Error()})
 			return
@@ -97,8 +98,8 @@ func (h *ConsoleHandler) HandleDiagnoseRequest(c *gin.Context) {
 		}
 	}()
 
-	// Use a timeout context
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	// Use a timeout context, keeping the LLM tenant of the request
+	ctx, cancel := context.WithTimeout(llminterfaces.WithTenant(context.Background(), llminterfaces.TenantFromContext(c.Request.Context())), 5*time.Minute)
 	defer cancel()
 
 	result, err := h.manager.RunDiagnosis(ctx, &req, progressChan)
@@ -0,0 +1,111 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/plugin"
+	builtinMongo "github.com/kubestack-ai/kubestack-ai/internal/plugins/builtin/mongodb"
+)
+
+func init() {
+	plugin.RegisterPluginFactory("MongoDB", func() plugin.DiagnosticPlugin {
+		return &MongoDBPlugin{}
+	})
+}
+
+// MongoDBPlugin is the diagnostic plugin implementation for MongoDB.
+type MongoDBPlugin struct {
+	client *builtinMongo.Client
+	log    logger.Logger
+}
+
+func (p *MongoDBPlugin) Name() string {
+	return "mongodb"
+}
+
+func (p *MongoDBPlugin) SupportedTypes() []string {
+	return []string{"mongodb"}
+}
+
+func (p *MongoDBPlugin) Version() string {
+	return "0.1.0"
+}
+
+// Init initializes the plugin with configuration. A "uri" entry sets the
+// server used when a diagnosis request names no instance.
+func (p *MongoDBPlugin) Init(config map[string]interface{}) error {
+	p.log = logger.NewLogger("mongodb")
+
+	uri, _ := config["uri"].(string)
+	if uri == "" {
+		return nil
+	}
+	client, err := builtinMongo.NewClient(uri)
+	if err != nil {
+		return err
+	}
+	p.client = client
+	return nil
+}
+
+func (p *MongoDBPlugin) Diagnose(ctx context.Context, req *models.DiagnosisRequest) (*models.DiagnosisResult, error) {
+	if p.log == nil {
+		p.log = logger.NewLogger("mongodb")
+	}
+
+	client := p.client
+	if req.Instance != "" {
+		uri := req.Instance
+		if !strings.HasPrefix(uri, "mongodb://") && !strings.HasPrefix(uri, "mongodb+srv://") {
+			uri = "mongodb://" + uri
+		}
+		var err error
+		if client, err = builtinMongo.NewClient(uri); err != nil {
+			return nil, err
+		}
+		defer client.Close()
+	}
+	if client == nil {
+		return nil, fmt.Errorf("mongodb diagnosis requires an instance URI in the request")
+	}
+
+	snapshot, err := builtinMongo.NewCollector(client, p.log).CollectSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	issues := builtinMongo.NewAnalyzer(p.log).Analyze(snapshot)
+
+	return &models.DiagnosisResult{
+		ID:        fmt.Sprintf("mongo-diag-%d", time.Now().Unix()),
+		Timestamp: time.Now().UTC(),
+		Summary:   fmt.Sprintf("MongoDB diagnosis complete. Found %d issues.", len(issues)),
+		Issues:    issues,
+		Metrics:   snapshot.Metrics,
+	}, nil
+}
+
+func (p *MongoDBPlugin) Shutdown() error {
+	if p.client != nil {
+		return p.client.Close()
+	}
+	return nil
+}
@@ -118,19 +118,19 @@ func (p *PostgreSQLPlugin) Diagnose(ctx context.Context, req *models.DiagnosisRe
 		analyzer = builtinPG.NewAnalyzer(log)
 	}
 
-	metricsData, err := collector.CollectMetrics(ctx)
+	snapshot, err := collector.CollectSnapshot(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	issues := analyzer.Analyze(metricsData.Data)
+	issues := analyzer.Analyze(snapshot)
 
 	result := &models.DiagnosisResult{
 		ID:        fmt.Sprintf("pg-diag-%d", time.Now().Unix()),
 		Timestamp: time.Now().UTC(),
 		Summary:   fmt.Sprintf("PostgreSQL diagnosis complete. Found %d issues.", len(issues)),
 		Issues:    issues,
-		Metrics:   metricsData.Data,
+		Metrics:   snapshot.Metrics,
 	}
 
 	return result, nil
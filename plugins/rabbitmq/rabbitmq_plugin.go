@@ -0,0 +1,110 @@
+// Copyright © 2024 KubeStack-AI Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/common/logger"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/plugin"
+	builtinRabbit "github.com/kubestack-ai/kubestack-ai/internal/plugins/builtin/rabbitmq"
+)
+
+func init() {
+	plugin.RegisterPluginFactory("RabbitMQ", func() plugin.DiagnosticPlugin {
+		return &RabbitMQPlugin{}
+	})
+}
+
+// RabbitMQPlugin is the diagnostic plugin implementation for RabbitMQ.
+type RabbitMQPlugin struct {
+	client *builtinRabbit.Client
+	log    logger.Logger
+}
+
+func (p *RabbitMQPlugin) Name() string {
+	return "rabbitmq"
+}
+
+func (p *RabbitMQPlugin) SupportedTypes() []string {
+	return []string{"rabbitmq"}
+}
+
+func (p *RabbitMQPlugin) Version() string {
+	return "0.1.0"
+}
+
+// Init initializes the plugin with configuration. The "url", "username"
+// and "password" entries set the management API used when a diagnosis
+// request names no instance.
+func (p *RabbitMQPlugin) Init(config map[string]interface{}) error {
+	p.log = logger.NewLogger("rabbitmq")
+
+	baseURL, _ := config["url"].(string)
+	if baseURL == "" {
+		return nil
+	}
+	username, _ := config["username"].(string)
+	password, _ := config["password"].(string)
+	client, err := builtinRabbit.NewClient(baseURL, username, password)
+	if err != nil {
+		return err
+	}
+	p.client = client
+	return nil
+}
+
+func (p *RabbitMQPlugin) Diagnose(ctx context.Context, req *models.DiagnosisRequest) (*models.DiagnosisResult, error) {
+	if p.log == nil {
+		p.log = logger.NewLogger("rabbitmq")
+	}
+
+	client := p.client
+	if req.Instance != "" {
+		baseURL := req.Instance
+		if !strings.Contains(baseURL, "://") {
+			baseURL = "http://" + baseURL
+		}
+		var err error
+		if client, err = builtinRabbit.NewClient(baseURL, "", ""); err != nil {
+			return nil, err
+		}
+	}
+	if client == nil {
+		return nil, fmt.Errorf("rabbitmq diagnosis requires the management API URL in the request")
+	}
+
+	snapshot, err := builtinRabbit.NewCollector(client, p.log).CollectSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	issues := builtinRabbit.NewAnalyzer(p.log).Analyze(snapshot)
+
+	return &models.DiagnosisResult{
+		ID:        fmt.Sprintf("rabbitmq-diag-%d", time.Now().Unix()),
+		Timestamp: time.Now().UTC(),
+		Summary:   fmt.Sprintf("RabbitMQ diagnosis complete. Found %d issues.", len(issues)),
+		Issues:    issues,
+		Metrics:   snapshot.Metrics,
+	}, nil
+}
+
+func (p *RabbitMQPlugin) Shutdown() error {
+	return nil
+}
@@ -0,0 +1,126 @@
+package alert_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/alert"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/types/enum"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/models"
+	kgraph "github.com/kubestack-ai/kubestack-ai/internal/knowledge/graph"
+	"github.com/kubestack-ai/kubestack-ai/internal/storage/graph"
+	"github.com/kubestack-ai/kubestack-ai/internal/storage/graph/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// topologyGraph builds a graph where services svc-0..svc-(n-1) depend on
+// the Redis "cache" in namespace prod, and "billing" depends on MySQL "db".
+func topologyGraph(t *testing.T, services int) *kgraph.QueryEngine {
+	ctx := context.Background()
+	store := memory.NewMemoryGraphStore()
+	b := kgraph.NewBuilder(store)
+
+	require.NoError(t, store.AddNode(ctx, &graph.Node{
+		ID:        kgraph.GenerateID(graph.NodeTypeMiddleware, "prod", "cache"),
+		Type:      graph.NodeTypeMiddleware,
+		Name:      "cache",
+		Namespace: "prod",
+		Properties: map[string]interface{}{
+			"middleware_type": "redis",
+			"endpoints":       []string{"10.0.0.5:6379"},
+		},
+	}))
+	require.NoError(t, b.AddMiddleware(ctx, "prod", "db", "mysql"))
+	require.NoError(t, b.AddService(ctx, "prod", "billing"))
+	require.NoError(t, b.AddDependency(ctx, kgraph.GenerateID(graph.NodeTypeService, "prod", "billing"),
+		kgraph.GenerateID(graph.NodeTypeMiddleware, "prod", "db")))
+	for i := 0; i < services; i++ {
+		name := fmt.Sprintf("svc-%d", i)
+		require.NoError(t, b.AddService(ctx, "prod", name))
+		require.NoError(t, b.AddDependency(ctx, kgraph.GenerateID(graph.NodeTypeService, "prod", name),
+			kgraph.GenerateID(graph.NodeTypeMiddleware, "prod", "cache")))
+	}
+	return kgraph.NewQueryEngine(store)
+}
+
+func TestCorrelator_TopologyMergesDependentInstances(t *testing.T) {
+	c := alert.NewCorrelator(time.Minute, nil)
+	c.SetTopology(topologyGraph(t, 15), 2)
+
+	now := time.Now()
+	for i := 0; i < 15; i++ {
+		// Pod names resolve to their workloads
+		triggered, _ := c.AddAlert(&models.AlertEvent{
+			Name:     "HighLatency",
+			Instance: fmt.Sprintf("svc-%d-7d9f8b6c5-xk2lp", i),
+			Severity: enum.SeverityWarning,
+			Summary:  "p99 latency above 1s",
+			Labels:   map[string]string{"namespace": "prod"},
+			StartsAt: now.Add(time.Duration(i) * time.Second),
+		})
+		assert.False(t, triggered)
+	}
+	triggered, correlated := c.AddAlert(&models.AlertEvent{
+		Name:     "RedisDown",
+		Instance: "10.0.0.5:9121",
+		Severity: enum.SeverityCritical,
+		Summary:  "redis exporter reports down",
+		StartsAt: now.Add(20 * time.Second),
+	})
+
+	require.True(t, triggered)
+	require.NotNil(t, correlated)
+	assert.Len(t, correlated.Alerts, 16)
+	assert.Equal(t, kgraph.GenerateID(graph.NodeTypeMiddleware, "prod", "cache"), correlated.RootNode)
+	assert.Equal(t, "10.0.0.5:9121", correlated.Instance)
+	assert.Equal(t, enum.Redis, correlated.Middleware)
+	assert.Equal(t, enum.SeverityCritical, correlated.Severity)
+	assert.Len(t, correlated.Symptoms, 15)
+	assert.Len(t, correlated.Instances, 16)
+	assert.InDelta(t, 1.0, correlated.Confidence, 0.001)
+	for _, s := range correlated.Symptoms {
+		assert.Equal(t, "HighLatency", s.Name)
+	}
+}
+
+func TestCorrelator_TopologyRootWithoutAlerts(t *testing.T) {
+	c := alert.NewCorrelator(time.Minute, nil)
+	c.SetTopology(topologyGraph(t, 3), 2)
+
+	c.AddAlert(&models.AlertEvent{Name: "Errors", Instance: "svc-0", Severity: enum.SeverityWarning, Labels: map[string]string{"namespace": "prod"}})
+	c.AddAlert(&models.AlertEvent{Name: "Errors", Instance: "svc-1", Severity: enum.SeverityWarning, Labels: map[string]string{"namespace": "prod"}})
+	triggered, correlated := c.AddAlert(&models.AlertEvent{Name: "Errors", Instance: "svc-2", Severity: enum.SeverityCritical, Labels: map[string]string{"namespace": "prod"}})
+
+	// The shared dependency explains all three alerts
+	require.True(t, triggered)
+	assert.Equal(t, kgraph.GenerateID(graph.NodeTypeMiddleware, "prod", "cache"), correlated.RootNode)
+	assert.Equal(t, "cache", correlated.Instance)
+	assert.Equal(t, "prod", correlated.Namespace)
+	assert.Equal(t, enum.Redis, correlated.Middleware)
+	assert.Len(t, correlated.Symptoms, 3)
+}
+
+func TestCorrelator_TopologyKeepsUnrelatedInstancesApart(t *testing.T) {
+	c := alert.NewCorrelator(time.Minute, nil)
+	c.SetTopology(topologyGraph(t, 2), 2)
+
+	c.AddAlert(&models.AlertEvent{Name: "SlowQueries", Instance: "db", Severity: enum.SeverityWarning, Labels: map[string]string{"namespace": "prod"}})
+	c.AddAlert(&models.AlertEvent{Name: "Unknown", Instance: "somewhere-else", Severity: enum.SeverityWarning})
+	triggered, correlated := c.AddAlert(&models.AlertEvent{Name: "Errors", Instance: "svc-0", Severity: enum.SeverityCritical, Labels: map[string]string{"namespace": "prod"}})
+
+	require.True(t, triggered)
+	assert.Len(t, correlated.Alerts, 1)
+	assert.Equal(t, "svc-0", correlated.Instance)
+	assert.Empty(t, correlated.Symptoms)
+
+	triggered, correlated = c.AddAlert(&models.AlertEvent{Name: "Errors", Instance: "billing", Severity: enum.SeverityCritical, Labels: map[string]string{"namespace": "prod"}})
+	require.True(t, triggered)
+	assert.Len(t, correlated.Alerts, 2)
+	assert.Equal(t, kgraph.GenerateID(graph.NodeTypeMiddleware, "prod", "db"), correlated.RootNode)
+	assert.Equal(t, enum.MySQL, correlated.Middleware)
+	require.Len(t, correlated.Symptoms, 1)
+	assert.Equal(t, "billing", correlated.Symptoms[0].Instance)
+}
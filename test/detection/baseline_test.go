@@ -0,0 +1,199 @@
+package detection_test
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubestack-ai/kubestack-ai/internal/core/detection"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/detection/baseline"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/detection/detectors"
+	"github.com/kubestack-ai/kubestack-ai/internal/core/detection/models"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/collector"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/model"
+	"github.com/kubestack-ai/kubestack-ai/internal/monitor/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dailyTraffic returns 5-minute points with a strong daily cycle, peaking
+// at 06:00 UTC, plus a little noise
+func dailyTraffic(start time.Time, days int, seed int64) []models.DataPoint {
+	rng := rand.New(rand.NewSource(seed))
+	var points []models.DataPoint
+	for t := start; t.Before(start.Add(time.Duration(days) * 24 * time.Hour)); t = t.Add(5 * time.Minute) {
+		points = append(points, models.DataPoint{Time: t, Value: trafficAt(t) + rng.NormFloat64()*20})
+	}
+	return points
+}
+
+func trafficAt(t time.Time) float64 {
+	hour := float64(t.UTC().Hour()) + float64(t.UTC().Minute())/60
+	return 1000 + 600*math.Sin(2*math.Pi*hour/24)
+}
+
+func anomaliesOfType(anomalies []models.Anomaly, typ string) []models.Anomaly {
+	var out []models.Anomaly
+	for _, a := range anomalies {
+		if a.Type == typ {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+func TestBaselineDetector_DailyCycle(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	learner := baseline.NewLearner(nil, nil, baseline.DefaultParams())
+	detector := detectors.NewBaselineDetector(learner)
+	input := func(points []models.DataPoint) *models.DetectionInput {
+		return &models.DetectionInput{
+			TimeSeries: points,
+			Context:    map[string]string{detectors.ContextMetric: "redis_ops_per_sec", detectors.ContextInstance: "redis-0"},
+		}
+	}
+
+	// Learn three days of history
+	_, err := detector.Detect(ctx, input(dailyTraffic(start, 3, 1)))
+	require.NoError(t, err)
+
+	// The next morning's peak is normal
+	day4 := dailyTraffic(start.Add(72*time.Hour), 1, 2)
+	result, err := detector.Detect(ctx, input(day4[:96]))
+	require.NoError(t, err)
+	assert.Empty(t, result.Anomalies)
+	assert.Greater(t, result.Confidence, 0.9)
+
+	// Peak-hour traffic at midnight is not
+	midnight := start.Add(96 * time.Hour)
+	result, err = detector.Detect(ctx, input([]models.DataPoint{{Time: midnight, Value: 1600}}))
+	require.NoError(t, err)
+	require.Len(t, result.Anomalies, 1)
+	a := result.Anomalies[0]
+	assert.Equal(t, models.AnomalyTypeTrafficSpike, a.Type)
+	require.NotNil(t, a.Expected)
+	assert.InDelta(t, 1000, a.Expected.Value, 100)
+	assert.Less(t, a.Expected.Upper, 1600.0)
+	assert.Greater(t, a.Expected.Score, 3.5)
+	assert.Equal(t, "redis-0", a.Metadata["instance"])
+}
+
+func TestBaselineDetector_LevelShift(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	learner := baseline.NewLearner(nil, nil, baseline.DefaultParams())
+
+	history := dailyTraffic(start, 3, 3)
+	_, _, err := learner.Observe(ctx, "mysql_qps", "mysql-0", history)
+	require.NoError(t, err)
+
+	// Traffic settles 300 higher than usual
+	shifted := dailyTraffic(start.Add(72*time.Hour), 1, 4)[:36]
+	for i := range shifted {
+		shifted[i].Value += 300
+	}
+	detector := detectors.NewBaselineDetector(learner)
+	result, err := detector.Detect(ctx, &models.DetectionInput{
+		TimeSeries: shifted,
+		Context:    map[string]string{detectors.ContextMetric: "mysql_qps", detectors.ContextInstance: "mysql-0"},
+	})
+	require.NoError(t, err)
+
+	shifts := anomaliesOfType(result.Anomalies, models.AnomalyTypeLevelShift)
+	require.Len(t, shifts, 1)
+	assert.Equal(t, "up", shifts[0].Metadata["shift"])
+
+	// Once the shift is absorbed, the new level is normal again
+	spikes := anomaliesOfType(result.Anomalies, models.AnomalyTypeTrafficSpike)
+	assert.Less(t, len(spikes), 10)
+	assert.False(t, spikes[len(spikes)-1].StartTime.After(shifts[0].StartTime))
+}
+
+func TestBaselineLearner_TrainsFromStoreAndPersists(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	ts, err := storage.NewSQLiteTimeseriesStore(filepath.Join(dir, "monitor.db"))
+	require.NoError(t, err)
+	defer ts.Close()
+
+	start := time.Now().Add(-72 * time.Hour).Truncate(time.Hour)
+	var points []*model.MetricPoint
+	for _, p := range dailyTraffic(start, 3, 5) {
+		points = append(points,
+			&model.MetricPoint{Name: "kafka_messages_in", Value: p.Value, Timestamp: p.Time, Labels: map[string]string{"instance": "broker-0"}},
+			&model.MetricPoint{Name: "kafka_messages_in", Value: 10, Timestamp: p.Time, Labels: map[string]string{"instance": "broker-1"}},
+		)
+	}
+	require.NoError(t, ts.Write(ctx, points))
+
+	store, err := baseline.NewSQLiteStore(filepath.Join(dir, "baselines", "models.db"))
+	require.NoError(t, err)
+	learner := baseline.NewLearner(ts, store, baseline.DefaultParams())
+
+	m, err := learner.Model(ctx, "kafka_messages_in", "broker-0")
+	require.NoError(t, err)
+	assert.Equal(t, 3*24*12, m.Samples)
+	// The hour-of-day profile has learned the cycle: peak at 06:00, trough at 18:00
+	assert.InDelta(t, 600, m.HourOfDay[6], 150)
+	assert.InDelta(t, -600, m.HourOfDay[18], 150)
+	require.NoError(t, store.Close())
+
+	// A new learner picks the persisted model up without retraining
+	store, err = baseline.NewSQLiteStore(filepath.Join(dir, "baselines", "models.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	saved, err := store.List(ctx, "kafka_messages_in")
+	require.NoError(t, err)
+	require.Len(t, saved, 1)
+	assert.Equal(t, m.LastTime.Unix(), saved[0].LastTime.Unix())
+
+	learner = baseline.NewLearner(ts, store, baseline.DefaultParams())
+	next := time.Now().Add(-time.Second)
+	require.NoError(t, ts.Write(ctx, []*model.MetricPoint{
+		{Name: "kafka_messages_in", Value: trafficAt(next) + 2000, Timestamp: next, Labels: map[string]string{"instance": "broker-0"}},
+	}))
+	observations, updated, err := learner.Observe(ctx, "kafka_messages_in", "broker-0", nil)
+	require.NoError(t, err)
+	require.Len(t, observations, 1)
+	assert.True(t, observations[0].Anomaly)
+	assert.Equal(t, m.Samples+1, updated.Samples)
+}
+
+func TestDetectAnomalies_RecordsScores(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	learner := baseline.NewLearner(nil, nil, baseline.DefaultParams())
+	detector := detection.NewAnomalyDetector(nil, detection.WithBaseline(learner))
+
+	collected := func(points []models.DataPoint) []*model.MetricPoint {
+		var out []*model.MetricPoint
+		for _, p := range points {
+			out = append(out,
+				&model.MetricPoint{Name: "redis_ops_per_sec", Value: p.Value, Timestamp: p.Time, Labels: map[string]string{"instance": "redis-0"}},
+				&model.MetricPoint{Name: "redis_ops_per_sec", Value: p.Value, Timestamp: p.Time, Labels: map[string]string{"instance": "redis-1"}},
+			)
+		}
+		return out
+	}
+
+	// Learn three days of history
+	_, err := collector.DetectAnomalies(ctx, detector, collected(dailyTraffic(start, 3, 1)))
+	require.NoError(t, err)
+
+	// Peak-hour traffic at midnight on one instance only
+	midnight := start.Add(72 * time.Hour)
+	points := collected([]models.DataPoint{{Time: midnight, Value: trafficAt(midnight)}})
+	points[0].Value = 1600
+	scores, err := collector.DetectAnomalies(ctx, detector, points)
+	require.NoError(t, err)
+	require.Len(t, scores, 1)
+	assert.Equal(t, collector.AnomalyScoreMetric, scores[0].Name)
+	assert.Equal(t, "redis-0", scores[0].Labels["instance"])
+	assert.Equal(t, "redis_ops_per_sec", scores[0].Labels["metric"])
+	assert.Equal(t, models.AnomalyTypeTrafficSpike, scores[0].Labels["type"])
+	assert.Greater(t, scores[0].Value, 3.5)
+}
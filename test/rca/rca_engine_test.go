@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/kubestack-ai/kubestack-ai/internal/common/config"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/detection/models"
 	"github.com/kubestack-ai/kubestack-ai/internal/core/rca"
 )
@@ -24,6 +25,40 @@ func TestRulesEngine(t *testing.T) {
 	assert.NotEmpty(t, result.Recommendations)
 }
 
+func TestRulesEngine_ConfigRules(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.RCA.Rules = []config.RuleConfig{
+		{
+			// Legacy condition list
+			Name:       "Critical Memory",
+			Conditions: []config.ConditionConfig{{AnomalyType: models.AnomalyTypeHighMemory, Severity: models.SeverityCritical}},
+			RootCause:  "OOM",
+			Priority:   90,
+		},
+		{
+			ID:        "memory-and-slow",
+			Name:      "Memory and Slow Queries",
+			Condition: `anomaly("HighMemory") && anomaly("SlowQuery")`,
+			RootCause: "Swapping",
+			Priority:  50,
+		},
+	}
+	engine := rca.NewRulesEngine(cfg)
+
+	result, err := engine.Analyze(context.Background(), []models.Anomaly{
+		{Type: models.AnomalyTypeHighMemory, Severity: models.SeverityHigh},
+		{Type: models.AnomalyTypeSlowQuery, Severity: models.SeverityHigh},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Swapping", result.RootCause, "severity in the legacy condition must match")
+
+	result, err = engine.Analyze(context.Background(), []models.Anomaly{
+		{Type: models.AnomalyTypeHighMemory, Severity: models.SeverityCritical},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "OOM", result.RootCause)
+}
+
 func TestKnowledgeGraphQuery(t *testing.T) {
 	kg := rca.NewKnowledgeGraph()
 	anomaly := models.Anomaly{Type: models.AnomalyTypeHighMemory}